@@ -0,0 +1,79 @@
+"""Hook helpers for Python, installed by claudekit.
+
+Import this module from a hook script in the directory above lib/:
+
+    sys.path.insert(0, os.path.join(os.path.dirname(os.path.abspath(__file__)), "lib"))
+    import hook
+
+    event = hook.read_input()
+"""
+
+import json
+import sys
+from datetime import datetime, timezone
+
+
+class Event(dict):
+    """The event Claude Code passes on stdin, with properties for common fields."""
+
+    @property
+    def name(self):
+        return self.get("hook_event_name", "")
+
+    @property
+    def session_id(self):
+        return self.get("session_id", "")
+
+    @property
+    def cwd(self):
+        return self.get("cwd", "")
+
+    @property
+    def tool_name(self):
+        return self.get("tool_name", "")
+
+    @property
+    def tool_input(self):
+        return self.get("tool_input") or {}
+
+
+def read_input(stream=None):
+    """Read the event JSON from stdin; an empty input is an empty event."""
+    raw = (stream or sys.stdin).read()
+    return Event(json.loads(raw) if raw.strip() else {})
+
+
+def emit(output):
+    """Print a JSON output for Claude Code and exit."""
+    print(json.dumps(output))
+    sys.exit(0)
+
+
+def block(reason="Blocked by a hook"):
+    """Print a "block" decision with a reason for Claude and exit.
+
+    It refuses a tool call in PreToolUse, reports a problem after PostToolUse,
+    rejects the prompt in UserPromptSubmit, and keeps Claude working in Stop and
+    SubagentStop.
+    """
+    emit({"decision": "block", "reason": reason})
+
+
+def approve(reason=""):
+    """Print an "approve" decision for PreToolUse, running the tool without asking
+    the user, and exit."""
+    emit({"decision": "approve", "reason": reason})
+
+
+def add_context(event, text):
+    """Add text to Claude's context from UserPromptSubmit or SessionStart and exit."""
+    emit({"hookSpecificOutput": {"hookEventName": event.name, "additionalContext": text}})
+
+
+def log(message):
+    """Write a timestamped message to stderr, which Claude Code shows in verbose mode.
+
+    Stdout is reserved for decisions and, for some events, context.
+    """
+    stamp = datetime.now(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
+    print(f"[{stamp}] {message}", file=sys.stderr)
@@ -0,0 +1,22 @@
+# notify-desktop Hook - Shows a desktop notification when Claude needs you or finishes
+#
+# Uses osascript on macOS and notify-send on Linux. Without either, the message is
+# logged instead.
+
+source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+hook_read_input
+
+title="Claude Code"
+message="$(hook_summary)"
+if command -v osascript >/dev/null 2>&1; then
+  # Pass the text as arguments so quotes in it cannot break the script
+  osascript -e 'on run argv' -e 'display notification (item 1 of argv) with title (item 2 of argv)' -e 'end run' \
+    "$message" "$title" >/dev/null || hook_log "osascript notification failed"
+elif command -v notify-send >/dev/null 2>&1; then
+  notify-send --app-name="$title" "$title" "$message" || hook_log "notify-send notification failed"
+else
+  hook_log "no desktop notifier found (osascript or notify-send): $message"
+fi
+
+# Never block Claude over a notification
+exit 0
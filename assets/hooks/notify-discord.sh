@@ -0,0 +1,21 @@
+# notify-discord Hook - Posts to Discord when Claude needs you or finishes responding
+#
+# Sends a message to the Discord webhook in DISCORD_WEBHOOK_URL. claudekit stores
+# the URL in settings.json's env when you enter one; otherwise export it.
+
+source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+hook_read_input
+
+url="${DISCORD_WEBHOOK_URL:-}"
+if [[ -z "$url" ]]; then
+  hook_log "DISCORD_WEBHOOK_URL is not set; skipping the Discord notification"
+  exit 0
+fi
+
+payload="{\"content\":$(hook_json_string "$(hook_summary)")}"
+if ! curl -fsS --max-time 10 -H "Content-Type: application/json" -d "$payload" "$url" >/dev/null; then
+  hook_log "Discord notification failed"
+fi
+
+# Never block Claude over a notification
+exit 0
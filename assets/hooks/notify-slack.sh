@@ -0,0 +1,21 @@
+# notify-slack Hook - Posts to Slack when Claude needs you or finishes responding
+#
+# Sends a message to the Slack incoming webhook in SLACK_WEBHOOK_URL. claudekit
+# stores the URL in settings.json's env when you enter one; otherwise export it.
+
+source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+hook_read_input
+
+url="${SLACK_WEBHOOK_URL:-}"
+if [[ -z "$url" ]]; then
+  hook_log "SLACK_WEBHOOK_URL is not set; skipping the Slack notification"
+  exit 0
+fi
+
+payload="{\"text\":$(hook_json_string "$(hook_summary)")}"
+if ! curl -fsS --max-time 10 -H "Content-Type: application/json" -d "$payload" "$url" >/dev/null; then
+  hook_log "Slack notification failed"
+fi
+
+# Never block Claude over a notification
+exit 0
@@ -0,0 +1,116 @@
+#!/usr/bin/env python3
+"""
+usage-log Hook - Runs when Claude Code sessions end, recording their usage
+
+Appends one JSON line per session to .claude/usage.jsonl in the project: when the
+session ran, how many prompts it had, which tools Claude called, and the tokens it
+used, all read from the session transcript. `claudekit stats` summarizes the file.
+
+The hook never blocks the session; problems are reported on stderr.
+"""
+
+import json
+import os
+import sys
+from collections import Counter
+from datetime import datetime, timezone
+
+# Transcript usage fields, by the name the log records them under
+TOKEN_FIELDS = {
+    "input": "input_tokens",
+    "output": "output_tokens",
+    "cache_read": "cache_read_input_tokens",
+    "cache_creation": "cache_creation_input_tokens",
+}
+
+
+def parse_time(value):
+    try:
+        return datetime.fromisoformat(value.replace("Z", "+00:00"))
+    except (AttributeError, ValueError):
+        return None
+
+
+def is_prompt(content):
+    """Report whether a user message is a prompt rather than a tool result."""
+    if isinstance(content, str):
+        return True
+    return isinstance(content, list) and any(isinstance(b, dict) and b.get("type") == "text" for b in content)
+
+
+def summarize(lines):
+    """Summarize transcript lines: first and last timestamps, prompts, tools, tokens, models."""
+    times, tools, tokens, models = [], Counter(), Counter(), set()
+    prompts = 0
+    counted = set()  # A message split over several lines repeats its usage
+    for line in lines:
+        try:
+            entry = json.loads(line)
+        except ValueError:
+            continue
+        if not isinstance(entry, dict):
+            continue
+        stamp = parse_time(entry.get("timestamp"))
+        if stamp:
+            times.append(stamp)
+        message = entry.get("message")
+        if not isinstance(message, dict):
+            continue
+        content = message.get("content")
+        if entry.get("type") == "user" and is_prompt(content):
+            prompts += 1
+        if entry.get("type") != "assistant":
+            continue
+        for block in content if isinstance(content, list) else []:
+            if isinstance(block, dict) and block.get("type") == "tool_use":
+                tools[block.get("name") or "unknown"] += 1
+        if message.get("model"):
+            models.add(message["model"])
+        key = message.get("id") or id(message)
+        if key in counted:
+            continue
+        counted.add(key)
+        usage = message.get("usage") or {}
+        for name, field in TOKEN_FIELDS.items():
+            if isinstance(usage.get(field), int):
+                tokens[name] += usage[field]
+    return times, prompts, tools, tokens, models
+
+
+def record(event, now):
+    """Build the usage record for a SessionEnd event."""
+    lines = []
+    transcript = event.get("transcript_path")
+    if transcript and os.path.exists(transcript):
+        with open(transcript, encoding="utf-8", errors="replace") as f:
+            lines = f.readlines()
+    times, prompts, tools, tokens, models = summarize(lines)
+    started = min(times) if times else now
+    return {
+        "session_id": event.get("session_id", ""),
+        "started_at": started.isoformat(),
+        "ended_at": now.isoformat(),
+        "duration_seconds": max(0, round((now - started).total_seconds())),
+        "reason": event.get("reason", ""),
+        "prompts": prompts,
+        "tools": dict(tools),
+        "tokens": {name: tokens[name] for name in TOKEN_FIELDS},
+        "models": sorted(models),
+    }
+
+
+def main():
+    try:
+        event = json.load(sys.stdin)
+        project = os.environ.get("CLAUDE_PROJECT_DIR") or event.get("cwd") or os.getcwd()
+        log = os.path.join(project, ".claude", "usage.jsonl")
+        os.makedirs(os.path.dirname(log), exist_ok=True)
+        with open(log, "a", encoding="utf-8") as f:
+            f.write(json.dumps(record(event, datetime.now(timezone.utc))) + "\n")
+    except Exception as err:  # Never fail the session over logging
+        print(f"usage-log: {err}", file=sys.stderr)
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
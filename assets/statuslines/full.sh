@@ -0,0 +1,24 @@
+#!/usr/bin/env bash
+# Full statusline: model, directory, git branch, output style, and session cost
+# Claude Code pipes session JSON on stdin and shows the first line of output
+
+input=$(cat)
+command -v jq >/dev/null 2>&1 || { echo "statusline: install jq"; exit 0; }
+
+model=$(jq -r '.model.display_name // "Claude"' <<<"$input")
+dir=$(jq -r '.workspace.current_dir // empty' <<<"$input")
+style=$(jq -r '.output_style.name // empty' <<<"$input")
+cost=$(jq -r '.cost.total_cost_usd // 0' <<<"$input")
+
+branch=""
+if git -C "${dir:-.}" rev-parse --git-dir >/dev/null 2>&1; then
+    branch=$(git -C "${dir:-.}" branch --show-current 2>/dev/null)
+    if [ -n "$(git -C "${dir:-.}" status --porcelain 2>/dev/null | head -1)" ]; then
+        branch="$branch*"
+    fi
+fi
+
+line="[$model] ${dir##*/}"
+[ -n "$branch" ] && line="$line on $branch"
+[ -n "$style" ] && [ "$style" != "default" ] && line="$line · $style"
+printf '%s · $%.2f\n' "$line" "$cost"
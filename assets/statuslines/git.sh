@@ -0,0 +1,20 @@
+#!/usr/bin/env bash
+# Git statusline: model, directory, branch, and a marker for uncommitted changes
+# Claude Code pipes session JSON on stdin and shows the first line of output
+
+input=$(cat)
+command -v jq >/dev/null 2>&1 || { echo "statusline: install jq"; exit 0; }
+
+model=$(jq -r '.model.display_name // "Claude"' <<<"$input")
+dir=$(jq -r '.workspace.current_dir // empty' <<<"$input")
+
+branch=""
+if git -C "${dir:-.}" rev-parse --git-dir >/dev/null 2>&1; then
+    branch=$(git -C "${dir:-.}" branch --show-current 2>/dev/null)
+    [ -n "$branch" ] || branch=$(git -C "${dir:-.}" rev-parse --short HEAD 2>/dev/null)
+    if [ -n "$(git -C "${dir:-.}" status --porcelain 2>/dev/null | head -1)" ]; then
+        branch="$branch*"
+    fi
+fi
+
+echo "[$model] ${dir##*/}${branch:+ on $branch}"
@@ -0,0 +1,11 @@
+#!/usr/bin/env bash
+# Minimal statusline: model and current directory
+# Claude Code pipes session JSON on stdin and shows the first line of output
+
+input=$(cat)
+command -v jq >/dev/null 2>&1 || { echo "statusline: install jq"; exit 0; }
+
+model=$(jq -r '.model.display_name // "Claude"' <<<"$input")
+dir=$(jq -r '.workspace.current_dir // empty' <<<"$input")
+
+echo "[$model] ${dir##*/}"
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -203,7 +207,7 @@ func TestGitIntegration(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Initialize git repo
-	os.Chdir(tmpDir)
+	t.Chdir(tmpDir)
 	gitExec := func(args ...string) error {
 		cmd := exec.Command("git", args...)
 		cmd.Dir = tmpDir
@@ -531,13 +535,26 @@ func TestTableFormatting(t *testing.T) {
 	}
 
 	result, _ := formatting.FormatMarkdownFile(&file, formatting.FormatConfig{})
-
-	// Assert: Tables are aligned
-	// Assert: Consistent pipe placement
+	formatted := string(file.FormattedContent)
 
 	if result.Status == "unchanged" {
 		t.Error("Malformed tables should be modified")
 	}
+
+	// Assert: Tables stay markdown
+	if strings.Contains(formatted, "<table>") {
+		t.Errorf("Tables should be written as markdown, got:\n%s", formatted)
+	}
+
+	// Assert: Tables are aligned, with consistent pipe placement
+	for _, want := range []string{
+		"| Name    | Age | City        |\n| ------- | --- | ----------- |\n| Alice   | 30  | New York    |",
+		"| Name  | Age | City    |\n| ----- | --- | ------- |\n| David | 28  | Boston  |",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Formatted tables missing %q, got:\n%s", want, formatted)
+		}
+	}
 }
 
 // T020: Link formatting test
@@ -634,4 +651,594 @@ ___
 	}
 }
 
+// Report artifact tests
+
+// TestFormatReportJSONAndSARIF checks that aggregated results serialize to JSON and SARIF.
+func TestFormatReportJSONAndSARIF(t *testing.T) {
+	report := formatting.NewFormatReport()
+	report.Add(&formatting.FormatResult{
+		File:   formatting.MarkdownFile{RelPath: "docs/guide.md"},
+		Status: formatting.StatusModified,
+		RulesApplied: []formatting.FormattingRule{
+			{Name: "heading-atx-style", Description: "Convert to ATX-style headings", Category: formatting.CategoryHeading, FixCount: 2},
+		},
+		Duration: 3 * time.Millisecond,
+	})
+	report.Add(&formatting.FormatResult{
+		File:   formatting.MarkdownFile{RelPath: "README.md"},
+		Status: formatting.StatusUnchanged,
+	})
+	report.Add(&formatting.FormatResult{
+		File:   formatting.MarkdownFile{RelPath: "broken.md"},
+		Status: formatting.StatusError,
+		Error:  errors.New("file contains invalid UTF-8"),
+	})
+
+	if report.FilesModified != 1 || report.FilesUnchanged != 1 || report.FilesErrored != 1 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+	if report.RuleStats["heading-atx-style"] != 2 || report.TotalFixesApplied != 2 {
+		t.Errorf("rule stats not aggregated: %+v", report.RuleStats)
+	}
+
+	var jsonBuf bytes.Buffer
+	if err := formatting.WriteJSONReport(&jsonBuf, report); err != nil {
+		t.Fatalf("WriteJSONReport() error = %v", err)
+	}
+	var decoded struct {
+		TotalFiles int `json:"total_files"`
+		Files      []struct {
+			Path   string `json:"path"`
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON report is not valid JSON: %v", err)
+	}
+	if decoded.TotalFiles != 3 || len(decoded.Files) != 3 || decoded.Files[2].Error == "" {
+		t.Errorf("unexpected JSON report: %s", jsonBuf.String())
+	}
+
+	var sarifBuf bytes.Buffer
+	if err := formatting.WriteSARIFReport(&sarifBuf, report); err != nil {
+		t.Fatalf("WriteSARIFReport() error = %v", err)
+	}
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(sarifBuf.Bytes(), &sarif); err != nil {
+		t.Fatalf("SARIF report is not valid JSON: %v", err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 2 {
+		t.Fatalf("unexpected SARIF report: %s", sarifBuf.String())
+	}
+	if sarif.Runs[0].Results[1].Level != "error" {
+		t.Errorf("errored file should be reported at error level, got %q", sarif.Runs[0].Results[1].Level)
+	}
+}
+
 // Helper functions for tests
+
+// TestFmtCommand checks the `claudekit fmt` flags and exit codes.
+func TestFmtCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	unformatted := "Title\n===\n\n* a\n* b\n"
+	os.MkdirAll(filepath.Join(tmpDir, "docs"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "vendor"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "docs", "guide.md"), []byte(unformatted), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "vendor", "lib.md"), []byte(unformatted), 0644)
+
+	// --check reports unformatted files without touching them
+	if code := runFmtCommand([]string{tmpDir, "--check"}); code != 1 {
+		t.Errorf("fmt --check exit code = %d, want 1", code)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tmpDir, "docs", "guide.md")); string(content) != unformatted {
+		t.Error("fmt --check modified a file")
+	}
+
+	// Formatting with an exclusion leaves the excluded directory alone
+	if code := runFmtCommand([]string{"--exclude", "vendor", tmpDir}); code != 0 {
+		t.Errorf("fmt exit code = %d, want 0", code)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tmpDir, "docs", "guide.md")); !strings.HasPrefix(string(content), "# Title") {
+		t.Errorf("docs/guide.md was not formatted:\n%s", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tmpDir, "vendor", "lib.md")); string(content) != unformatted {
+		t.Error("excluded file was formatted")
+	}
+	if code := runFmtCommand([]string{"--check", "--exclude", "vendor", tmpDir}); code != 0 {
+		t.Errorf("fmt --check after formatting exit code = %d, want 0", code)
+	}
+
+	// A single file can be named directly
+	single := filepath.Join(tmpDir, "vendor", "lib.md")
+	report, err := formatMarkdown(formatting.FormatConfig{RootDir: single, DryRun: true})
+	if err != nil || report.TotalFiles != 1 || report.FilesModified != 1 {
+		t.Errorf("formatMarkdown(file) = %+v, %v", report, err)
+	}
+	if out := renderFmtReport(report, true); !strings.Contains(out, "Would format "+single) {
+		t.Errorf("report does not name the file:\n%s", out)
+	}
+
+	for _, args := range [][]string{{"--output", "yaml"}, {tmpDir, tmpDir}} {
+		if code := runFmtCommand(args); code != 2 {
+			t.Errorf("fmt %v exit code = %d, want 2", args, code)
+		}
+	}
+}
+
+// TestFmtStaged checks that fmt --staged checks what is staged rather than the working
+// tree, and that --install-git-hook installs a hook running it.
+func TestFmtStaged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	t.Chdir(root)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	os.WriteFile(filepath.Join(root, "guide.md"), []byte("# Guide\n\n- a\n- b\n"), 0644)
+	git("add", "guide.md")
+	git("commit", "-qm", "initial")
+
+	// Staged formatted, working tree not: the commit is fine
+	os.WriteFile(filepath.Join(root, "guide.md"), []byte("# Guide\n\n- a\n- b\n- c\n"), 0644)
+	git("add", "guide.md")
+	os.WriteFile(filepath.Join(root, "guide.md"), []byte("Guide\n=====\n\n- a\n- b\n- c\n"), 0644)
+	if code := runFmtCommand([]string{"--staged"}); code != 0 {
+		t.Errorf("fmt --staged with a formatted index exit code = %d, want 0", code)
+	}
+
+	// Staged unformatted, working tree formatted: the commit is not
+	os.WriteFile(filepath.Join(root, "notes.md"), []byte("Notes\n=====\n"), 0644)
+	git("add", "notes.md")
+	os.WriteFile(filepath.Join(root, "notes.md"), []byte("# Notes\n"), 0644)
+	if code := runFmtCommand([]string{"--staged"}); code != 1 {
+		t.Errorf("fmt --staged with an unformatted index exit code = %d, want 1", code)
+	}
+	if code := runFmtCommand([]string{"--staged", "--exclude", "notes.md"}); code != 0 {
+		t.Errorf("fmt --staged excluding the file exit code = %d, want 0", code)
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "guide.md")); !strings.HasPrefix(string(content), "Guide\n=====") {
+		t.Error("fmt --staged modified a file")
+	}
+
+	files, _, err := stagedMarkdownFiles(root, nil)
+	if err != nil || len(files) != 2 || files[1].RelPath != "notes.md" || string(files[1].Content) != "Notes\n=====\n" {
+		t.Errorf("stagedMarkdownFiles() = %+v, %v", files, err)
+	}
+
+	// The hook runs fmt --staged, and one claudekit did not write is left alone
+	if err := installFmtGitHook(root); err != nil {
+		t.Fatalf("installFmtGitHook() error = %v", err)
+	}
+	hookPath := filepath.Join(root, ".git", "hooks", "pre-commit")
+	hook, err := os.ReadFile(hookPath)
+	if err != nil || !strings.Contains(string(hook), fmtHookMarker) || !strings.Contains(string(hook), `exec "$claudekit" fmt --staged`) {
+		t.Fatalf("pre-commit hook = %q, %v", hook, err)
+	}
+	if err := installFmtGitHook(root); err != nil {
+		t.Errorf("reinstalling the hook: %v", err)
+	}
+	os.WriteFile(hookPath, []byte("#!/bin/sh\nmake lint\n"), 0755)
+	if err := installFmtGitHook(root); err == nil {
+		t.Error("installFmtGitHook() replaced a hook it did not write")
+	}
+}
+
+// TestRuleConfig checks parsing and validation of .claudekit-fmt.yaml.
+func TestRuleConfig(t *testing.T) {
+	rules, err := formatting.ParseRuleConfig([]byte(`
+rules:
+  emphasis-style: false
+  heading-atx-style:
+    style: setext
+  horizontal-rule-style:
+    enabled: true
+    style: "***"
+  list-formatting:
+    marker: "-"
+wrap-width: 100
+`))
+	if err != nil {
+		t.Fatalf("ParseRuleConfig: %v", err)
+	}
+	if rules.Enabled(formatting.RuleEmphasisStyle) || !rules.Enabled(formatting.RuleHorizontalRule) {
+		t.Errorf("Disabled = %v, want only emphasis-style", rules.Disabled)
+	}
+	if rules.HeadingStyle != "setext" || rules.HorizontalRule != "***" || rules.ListMarker != "-" || rules.WrapWidth != 100 {
+		t.Errorf("ParseRuleConfig = %+v", rules)
+	}
+
+	if rules, err := formatting.ParseRuleConfig(nil); err != nil || !rules.Enabled(formatting.RuleEmphasisStyle) {
+		t.Errorf("empty config = %+v, %v; want defaults", rules, err)
+	}
+
+	invalid := map[string]string{
+		"unknown rule":   "rules:\n  no-such-rule: false\n",
+		"fixed rule":     "rules:\n  table-formatting: false\n",
+		"heading style":  "rules:\n  heading-atx-style:\n    style: fancy\n",
+		"rule style":     "rules:\n  horizontal-rule-style:\n    style: \"===\"\n",
+		"list marker":    "rules:\n  list-formatting:\n    marker: \"#\"\n",
+		"misplaced":      "rules:\n  emphasis-style:\n    marker: \"_\"\n",
+		"unknown key":    "wrap: 80\n",
+		"negative width": "wrap-width: -1\n",
+	}
+	for name, data := range invalid {
+		if _, err := formatting.ParseRuleConfig([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// A missing file is not an error; an invalid one names the file
+	dir := t.TempDir()
+	if _, err := formatting.LoadRuleConfig(dir); err != nil {
+		t.Errorf("LoadRuleConfig without a file: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, formatting.ConfigFileName), []byte("wrap: 80\n"), 0644)
+	if _, err := formatting.LoadRuleConfig(dir); err == nil || !strings.Contains(err.Error(), formatting.ConfigFileName) {
+		t.Errorf("LoadRuleConfig error = %v, want one naming %s", err, formatting.ConfigFileName)
+	}
+}
+
+// TestConfiguredFormatting checks that rule settings reach the rendered output.
+func TestConfiguredFormatting(t *testing.T) {
+	format := func(input string, rules formatting.RuleConfig) string {
+		t.Helper()
+		file := formatting.MarkdownFile{Path: "test.md", Content: []byte(input)}
+		if _, err := formatting.FormatMarkdownFile(&file, formatting.FormatConfig{DryRun: true, Rules: rules}); err != nil {
+			t.Fatalf("FormatMarkdownFile: %v", err)
+		}
+		if file.FormattedContent == nil {
+			return input
+		}
+		return string(file.FormattedContent)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		rules   formatting.RuleConfig
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "setext headings",
+			input: "# Title\n\ntext\n",
+			rules: formatting.RuleConfig{HeadingStyle: formatting.HeadingStyleSetext},
+			want:  []string{"Title\n==="},
+		},
+		{
+			name:    "starred rules",
+			input:   "a\n\n---\n\nb\n",
+			rules:   formatting.RuleConfig{HorizontalRule: "***"},
+			want:    []string{"\n***\n"},
+			notWant: []string{"---"},
+		},
+		{
+			name:  "list marker",
+			input: "* a\n* b\n",
+			rules: formatting.RuleConfig{ListMarker: "-"},
+			want:  []string{"- a\n- b"},
+		},
+		{
+			name:  "adjacent lists stay separate",
+			input: "* a\n\n+ b\n",
+			rules: formatting.RuleConfig{ListMarker: "-"},
+			want:  []string{"- a", "+ b"},
+		},
+		{
+			name:  "emphasis kept",
+			input: "_italic_ and __bold__ and *star* and **_mixed_**\n",
+			rules: formatting.RuleConfig{Disabled: map[string]bool{formatting.RuleEmphasisStyle: true}},
+			want:  []string{"_italic_", "__bold__", "*star*", "**_mixed_**"},
+		},
+		{
+			name:    "rule style kept",
+			input:   "a\n\n___\n\nb\n\n***\n",
+			rules:   formatting.RuleConfig{Disabled: map[string]bool{formatting.RuleHorizontalRule: true}},
+			want:    []string{"\n___\n\nb\n\n___"},
+			notWant: []string{"***", "---"},
+		},
+		{
+			name:  "setext style kept",
+			input: "Title\n=====\n\nSub\n---\n",
+			rules: formatting.RuleConfig{Disabled: map[string]bool{formatting.RuleHeadingStyle: true}},
+			want:  []string{"Title\n===", "Sub\n---"},
+		},
+		{
+			name:    "indented code kept",
+			input:   "text\n\n    code\n",
+			rules:   formatting.RuleConfig{Disabled: map[string]bool{formatting.RuleCodeFenceStyle: true}},
+			want:    []string{"    code"},
+			notWant: []string{"```"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := format(tt.input, tt.rules)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output missing %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, got)
+				}
+			}
+			if again := format(got, tt.rules); again != got {
+				t.Errorf("not idempotent:\n%s\nthen:\n%s", got, again)
+			}
+		})
+	}
+
+	// fmt picks up the config file from the directory it formats
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, formatting.ConfigFileName), []byte("rules:\n  list-formatting:\n    marker: \"+\"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("- a\n- b\n"), 0644)
+	if code := runFmtCommand([]string{dir}); code != 0 {
+		t.Fatalf("fmt exit code = %d, want 0", code)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "notes.md")); string(content) != "+ a\n+ b\n" {
+		t.Errorf("notes.md = %q, want + markers", content)
+	}
+	os.WriteFile(filepath.Join(dir, formatting.ConfigFileName), []byte("rules: [\n"), 0644)
+	if code := runFmtCommand([]string{dir}); code != 1 {
+		t.Errorf("fmt with an invalid config exit code = %d, want 1", code)
+	}
+}
+
+// TestFrontmatterFormatting checks that frontmatter survives formatting untouched unless
+// key ordering is turned on.
+func TestFrontmatterFormatting(t *testing.T) {
+	frontmatter := "---\nname: demo\n# comment kept with its key\ntype: command\nasset_paths:\n    - templates/demo.md\ntitle:   spaced   *value*\n---\n"
+	input := frontmatter + "\nTitle\n=====\n\n* item\n"
+
+	file := formatting.MarkdownFile{Path: "demo.md", Content: []byte(input)}
+	if _, err := formatting.FormatMarkdownFile(&file, formatting.FormatConfig{DryRun: true}); err != nil {
+		t.Fatalf("FormatMarkdownFile: %v", err)
+	}
+	if want := frontmatter + "\n# Title\n\n* item\n"; string(file.FormattedContent) != want {
+		t.Errorf("formatted =\n%q\nwant\n%q", file.FormattedContent, want)
+	}
+
+	// Frontmatter-only files, the "..." closer, and unclosed blocks
+	for input, want := range map[string]string{
+		"---\nname: only\n---\n": "---\nname: only\n---\n",
+		"---\nname: only\n...":   "---\nname: only\n...",
+		"---\nnot closed\n":      "",
+	} {
+		fm, body := formatting.SplitFrontmatter([]byte(input))
+		if string(fm) != want || string(fm)+string(body) != input {
+			t.Errorf("SplitFrontmatter(%q) = %q, %q; want frontmatter %q", input, fm, body, want)
+		}
+	}
+
+	// Opting in sorts keys, moving comments with their key, and validates the YAML
+	rules, err := formatting.ParseRuleConfig([]byte("rules:\n  frontmatter-key-order: true\n"))
+	if err != nil || !rules.Enabled(formatting.RuleFrontmatterKeyOrder) {
+		t.Fatalf("ParseRuleConfig(frontmatter-key-order) = %+v, %v", rules, err)
+	}
+	if (formatting.RuleConfig{}).Enabled(formatting.RuleFrontmatterKeyOrder) {
+		t.Error("frontmatter-key-order should be off by default")
+	}
+	sorted, err := formatting.SortFrontmatterKeys([]byte(frontmatter))
+	if err != nil {
+		t.Fatalf("SortFrontmatterKeys: %v", err)
+	}
+	if want := "---\nasset_paths:\n    - templates/demo.md\nname: demo\ntitle:   spaced   *value*\n# comment kept with its key\ntype: command\n---\n"; string(sorted) != want {
+		t.Errorf("SortFrontmatterKeys =\n%s\nwant\n%s", sorted, want)
+	}
+	if again, _ := formatting.SortFrontmatterKeys(sorted); string(again) != string(sorted) {
+		t.Errorf("SortFrontmatterKeys is not idempotent:\n%s", again)
+	}
+	if got, err := formatting.SortFrontmatterKeys([]byte("---\n{}\n---\n")); err != nil || string(got) != "---\n{}\n---\n" {
+		t.Errorf("SortFrontmatterKeys({}) = %q, %v; want it unchanged", got, err)
+	}
+
+	file = formatting.MarkdownFile{Path: "bad.md", Content: []byte("---\nname: [unclosed\n---\n\nbody\n")}
+	result, err := formatting.FormatMarkdownFile(&file, formatting.FormatConfig{DryRun: true, Rules: rules})
+	if err == nil || result.Status != formatting.StatusError {
+		t.Errorf("invalid frontmatter with key ordering = %v, %v; want an error", result.Status, err)
+	}
+}
+
+func TestLineWrapFormatting(t *testing.T) {
+	long := strings.Repeat("word ", 30) // 150 columns of prose
+	frontmatter := "---\ndescription: " + strings.TrimSpace(long) + "\n---\n"
+	code := "```\n" + strings.TrimSpace(long) + "\n```\n"
+	input := frontmatter + "\n" + strings.TrimSpace(long) + "\n\n" + code + "\n" +
+		"- " + strings.TrimSpace(long) + "\n\n> " + strings.TrimSpace(long) + "\n\n" +
+		"See `a code span that must stay whole` and [a link](https://example.com/a/very/long/path \"With a title\") " + long + "\n"
+
+	rules, err := formatting.ParseRuleConfig([]byte("rules:\n  line-wrap: true\nwrap-width: 40\n"))
+	if err != nil || !rules.Enabled(formatting.RuleLineWrap) || rules.WrapWidth != 40 {
+		t.Fatalf("ParseRuleConfig(line-wrap) = %+v, %v", rules, err)
+	}
+	if (formatting.RuleConfig{}).Enabled(formatting.RuleLineWrap) {
+		t.Error("line-wrap should be off by default")
+	}
+
+	file := formatting.MarkdownFile{Path: "wrap.md", Content: []byte(input)}
+	result, err := formatting.FormatMarkdownFile(&file, formatting.FormatConfig{DryRun: true, Rules: rules})
+	if err != nil {
+		t.Fatalf("FormatMarkdownFile: %v", err)
+	}
+	if !slices.ContainsFunc(result.RulesApplied, func(r formatting.FormattingRule) bool { return r.Name == formatting.RuleLineWrap }) {
+		t.Errorf("rules applied = %+v, want %s", result.RulesApplied, formatting.RuleLineWrap)
+	}
+	wrapped := string(file.FormattedContent)
+
+	// Frontmatter and code keep their long lines
+	for _, kept := range []string{frontmatter, code, "`a code span that must stay whole`", "(https://example.com/a/very/long/path \"With a title\")"} {
+		if !strings.Contains(wrapped, kept) {
+			t.Errorf("wrapped output lost %q:\n%s", kept, wrapped)
+		}
+	}
+	body := strings.TrimPrefix(wrapped, frontmatter)
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if inFence || strings.Contains(line, "`a code span") || strings.Contains(line, "](") {
+			continue
+		}
+		if len(line) > 40 {
+			t.Errorf("line is %d columns, want at most 40: %q", len(line), line)
+		}
+	}
+	for _, prefix := range []string{"\n  word word", "\n> word word"} {
+		if !strings.Contains(body, prefix) {
+			t.Errorf("continuation lines should keep their container prefix %q:\n%s", prefix, body)
+		}
+	}
+
+	// Formatting the wrapped file again changes nothing
+	again := formatting.MarkdownFile{Path: "wrap.md", Content: file.FormattedContent}
+	result, err = formatting.FormatMarkdownFile(&again, formatting.FormatConfig{DryRun: true, Rules: rules})
+	if err != nil || result.Status != formatting.StatusUnchanged {
+		t.Errorf("second pass = %v, %v; want unchanged:\n%s", result.Status, err, again.FormattedContent)
+	}
+
+	// Link reference definitions and tables are not paragraphs and keep their long
+	// lines, and a word that would start a list or heading never begins a line
+	refs := "[ref]: https://example.com/" + strings.Repeat("x", 60) + " \"Title\"\n"
+	table := "| column | " + strings.TrimSpace(long) + " |\n| --- | --- |\n| a | b |\n"
+	prose := "Ask for 10 items - # of them and 1. then " + long + "\n"
+	out, n := formatting.WrapProse([]byte(refs+"\n"+table+"\n"+prose), 20)
+	if n != 1 || !strings.HasPrefix(string(out), refs+"\n"+table+"\n") {
+		t.Errorf("WrapProse changed %d paragraphs:\n%s", n, out)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "1. ") {
+			t.Errorf("wrapping started a block: %q", line)
+		}
+	}
+	if again, n := formatting.WrapProse(out, 20); n != 0 || string(again) != string(out) {
+		t.Errorf("WrapProse is not idempotent:\n%s", again)
+	}
+
+	// Hard line breaks survive
+	out, _ = formatting.WrapProse([]byte("short line\\\nnext line\n"), 100)
+	if string(out) != "short line\\\nnext line\n" {
+		t.Errorf("hard break = %q", out)
+	}
+}
+
+// TestLintViolations checks that each rule reports where the source breaks it, and
+// that fmt --lint prints those positions without writing anything.
+func TestLintViolations(t *testing.T) {
+	source := "---\nname: x\ndescription: y\n---\n\n" +
+		"Title\n=====\n\n" +
+		"Some _emph_ text.  \n\n" +
+		"* one\n* two\n\n" +
+		"***\n\n" +
+		"~~~go\nx := 1\n~~~\n\n" +
+		"    indented\n\n" +
+		"A paragraph that is long enough to need wrapping at twenty columns.\n"
+	rules, err := formatting.ParseRuleConfig([]byte("rules:\n  list-formatting:\n    marker: \"-\"\n  horizontal-rule-style:\n    style: \"---\"\n  frontmatter-key-order: true\n  line-wrap: true\nwrap-width: 20\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := formatting.MarkdownFile{Path: "lint.md", Content: []byte(source)}
+	result, err := formatting.FormatMarkdownFile(&file, formatting.FormatConfig{DryRun: true, Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []formatting.Violation{
+		{Rule: formatting.RuleFrontmatterKeyOrder, Line: 3, Column: 1},
+		{Rule: formatting.RuleHeadingStyle, Line: 6, Column: 1},
+		{Rule: formatting.RuleEmphasisStyle, Line: 9, Column: 6},
+		{Rule: formatting.RuleWhitespace, Line: 9, Column: 18},
+		{Rule: formatting.RuleListFormatting, Line: 11, Column: 1},
+		{Rule: formatting.RuleListFormatting, Line: 12, Column: 1},
+		{Rule: formatting.RuleHorizontalRule, Line: 14, Column: 1},
+		{Rule: formatting.RuleCodeFenceStyle, Line: 16, Column: 1},
+		{Rule: formatting.RuleCodeFenceStyle, Line: 20, Column: 1},
+		{Rule: formatting.RuleLineWrap, Line: 22, Column: 1},
+	}
+	if len(result.Violations) != len(want) {
+		t.Fatalf("violations = %+v, want %d", result.Violations, len(want))
+	}
+	for i, v := range result.Violations {
+		if v.Rule != want[i].Rule || v.Line != want[i].Line || v.Column != want[i].Column || v.Message == "" {
+			t.Errorf("violation %d = %+v, want %s at %d:%d", i, v, want[i].Rule, want[i].Line, want[i].Column)
+		}
+	}
+
+	// A formatted file has nothing to report
+	clean := formatting.MarkdownFile{Path: "clean.md", Content: []byte("# Title\n\nSome *emph* text.\n")}
+	if result, _ := formatting.FormatMarkdownFile(&clean, formatting.FormatConfig{DryRun: true}); len(result.Violations) != 0 {
+		t.Errorf("clean file violations = %+v", result.Violations)
+	}
+
+	// fmt --lint prints path:line:col and exits non-zero without touching the file
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "guide.md")
+	os.WriteFile(path, []byte("Title\n===\n\nSome _emph_ text.\n"), 0644)
+	var code int
+	out := testCaptureStdout(t, func() { code = runFmtCommand([]string{"--lint", path}) })
+	if code != 1 {
+		t.Errorf("fmt --lint exit code = %d, want 1", code)
+	}
+	for _, line := range []string{path + ":1:1: heading-atx-style: ", path + ":4:6: emphasis-style: ", "2 violation(s) in 1 file(s)"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("lint output missing %q:\n%s", line, out)
+		}
+	}
+	if content, _ := os.ReadFile(path); string(content) != "Title\n===\n\nSome _emph_ text.\n" {
+		t.Error("fmt --lint modified a file")
+	}
+
+	// SARIF results carry the region of each violation
+	out = testCaptureStdout(t, func() { code = runFmtCommand([]string{"--lint", "--output", "sarif", path}) })
+	var sarif struct {
+		Runs []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(out), &sarif); err != nil || code != 1 {
+		t.Fatalf("lint SARIF = %v (exit %d):\n%s", err, code, out)
+	}
+	results := sarif.Runs[0].Results
+	if len(results) != 2 || results[1].RuleID != formatting.RuleEmphasisStyle ||
+		results[1].Locations[0].PhysicalLocation.Region.StartLine != 4 || results[1].Locations[0].PhysicalLocation.Region.StartColumn != 6 {
+		t.Errorf("unexpected lint SARIF:\n%s", out)
+	}
+
+	// Once formatted, lint passes
+	if code := runFmtCommand([]string{path}); code != 0 {
+		t.Fatalf("fmt exit code = %d", code)
+	}
+	if code := runFmtCommand([]string{"--lint", path}); code != 0 {
+		t.Errorf("fmt --lint after formatting exit code = %d, want 0", code)
+	}
+}
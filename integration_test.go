@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"jeremyclewell.com/claudekit/internal/fsys"
+	"jeremyclewell.com/claudekit/internal/manifest"
+	"jeremyclewell.com/claudekit/internal/testsupport"
+)
+
+// ========== Generation Integration Matrix ==========
+
+// generationComponents is one column of the integration matrix: which component types
+// are selected for a run.
+type generationComponents struct {
+	name          string
+	subagents     []string
+	hooks         []string
+	slashCommands []string
+	mcpServers    []string
+	frameworks    []string
+	outputStyle   string
+	permissions   []string
+	statusline    string
+}
+
+var generationComponentSets = []generationComponents{
+	{name: "none"},
+	{name: "subagents", subagents: []string{"code-reviewer", "test-runner"}},
+	{name: "hooks", hooks: []string{"session-start", "pre-tool-use", "user-prompt-submit"}},
+	{name: "commands", slashCommands: []string{"add-tests", "fix-github-issue"}},
+	{name: "mcp", mcpServers: []string{"github", "sentry"}},
+	{name: "style", outputStyle: "concise"},
+	{
+		name:          "all",
+		subagents:     []string{"code-reviewer", "bug-sleuth"},
+		hooks:         []string{"session-start", "post-tool-use", "stop"},
+		slashCommands: []string{"add-tests"},
+		mcpServers:    []string{"github"},
+		frameworks:    []string{"react"},
+		outputStyle:   "learning",
+		permissions:   []string{"strict", "yolo"},
+		statusline:    "cost",
+	},
+}
+
+// config selects the set's components for a run in the given scope.
+func (set generationComponents) config(projectLocal bool) Config {
+	return Config{
+		IsProjectLocal: projectLocal,
+		ProjectName:    "matrix",
+		Languages:      []string{"Go", "TypeScript"},
+		Frameworks:     set.frameworks,
+		Subagents:      set.subagents,
+		Hooks:          set.hooks,
+		SlashCommands:  set.slashCommands,
+		MCPServers:     set.mcpServers,
+		OutputStyle:    set.outputStyle,
+		Permissions:    set.permissions,
+		Statusline:     set.statusline,
+	}
+}
+
+// previousSelection is the prior run used by the persisted-config column. Every item
+// is absent from all component sets above except "all", so cleanup must remove them.
+var previousSelection = Config{
+	IsProjectLocal: true,
+	ProjectName:    "previous",
+	Subagents:      []string{"docs-writer"},
+	Hooks:          []string{"pre-compact", "user-prompt-submit"},
+	SlashCommands:  []string{"setup-ci"},
+}
+
+// TestGenerationMatrix runs the full generation path for every combination of scope,
+// pre-existing .claude directory, persisted previous choices, and component selection,
+// and verifies the exact file tree and settings content produced.
+func TestGenerationMatrix(t *testing.T) {
+	registry := &ModuleRegistry{}
+	registry.Load(assets)
+
+	original := resolveConflict
+	t.Cleanup(func() { resolveConflict = original })
+	resolveConflict = func(rel string, existing, generated []byte) conflictAction {
+		t.Errorf("unexpected modified-file prompt for %s", rel)
+		return conflictSkip
+	}
+
+	for _, projectLocal := range []bool{true, false} {
+		for _, existing := range []bool{false, true} {
+			for _, persisted := range []bool{false, true} {
+				for _, set := range generationComponentSets {
+					name := strings.Join([]string{
+						map[bool]string{true: "project", false: "global"}[projectLocal],
+						map[bool]string{true: "existing", false: "empty"}[existing],
+						map[bool]string{true: "persisted", false: "fresh"}[persisted],
+						set.name,
+					}, "/")
+					t.Run(name, func(t *testing.T) {
+						testGenerationCase(t, registry, projectLocal, existing, persisted, set)
+					})
+				}
+			}
+		}
+	}
+}
+
+// goldenDir is resolved before any test runs, since some tests change directory.
+var goldenDir, _ = filepath.Abs(filepath.Join("testdata", "golden"))
+
+// goldenDate matches the date CLAUDE.md records its generation on.
+var goldenDate = regexp.MustCompile(`(Initialized by claudekit on )\d{4}-\d{2}-\d{2}`)
+
+// TestGenerationGolden generates each component set of the matrix in memory and
+// compares every file with testdata/golden/<set>, so a template or module change
+// shows up as a diff of the files it changes. Rewrite them with -update.
+func TestGenerationGolden(t *testing.T) {
+	registry := &ModuleRegistry{}
+	registry.Load(assets)
+	for _, set := range generationComponentSets {
+		t.Run(set.name, func(t *testing.T) {
+			got := testsupport.Generate(t, func(files fsys.FS, dir string) error {
+				issues, err := generateInto(files, dir, set.config(true), registry)
+				if err == nil && len(issues) > 0 {
+					err = &hookVerificationError{Issues: issues}
+				}
+				return err
+			})
+			testsupport.CompareGolden(t, filepath.Join(goldenDir, set.name), got, testsupport.GoldenOptions{
+				Update: *updateSnapshots,
+				Ignore: []string{".claude/" + manifest.FileName}, // Records the time and version of the run
+				Normalize: func(rel, content string) string {
+					return goldenDate.ReplaceAllString(content, "${1}YYYY-MM-DD")
+				},
+			})
+			if want := slices.Sorted(slices.Values(expectedGeneratedFiles(set.config(true)))); !slices.Equal(got.Paths(), want) {
+				t.Errorf("generated in memory:\n%swant %v", got, want)
+			}
+		})
+	}
+}
+
+// goldenRecorder collects the failures CompareGolden reports instead of failing the test.
+type goldenRecorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *goldenRecorder) Helper()         {}
+func (r *goldenRecorder) Log(args ...any) {}
+func (r *goldenRecorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// TestCompareGolden checks that a golden comparison names each file that differs,
+// is missing, or is new, and that -update makes the directory match.
+func TestCompareGolden(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden")
+	want := testsupport.Tree{"CLAUDE.md": "# demo\n", ".claude/settings.json": "{}\n", ".claude/agents/old.md": "old\n"}
+	if err := testsupport.WriteDir(golden, want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := testsupport.ReadDir(golden); err != nil || !maps.Equal(got, want) {
+		t.Fatalf("ReadDir() = %v, %v, want %v", got, err, want)
+	}
+
+	got := testsupport.Tree{"CLAUDE.md": "# demo\n", ".claude/settings.json": "{\"env\": {}}\n", ".claude/agents/new.md": "new\n", "stamp.txt": "now"}
+	opts := testsupport.GoldenOptions{Ignore: []string{"*.txt"}}
+	r := &goldenRecorder{TB: t}
+	testsupport.CompareGolden(r, golden, got, opts)
+	if len(r.failures) != 3 ||
+		!strings.HasPrefix(r.failures[0], ".claude/agents/old.md was not generated") ||
+		!strings.Contains(r.failures[1], `+ {"env": {}}`) ||
+		!strings.HasPrefix(r.failures[2], ".claude/agents/new.md was generated but is not in") {
+		t.Errorf("CompareGolden() failures = %q", r.failures)
+	}
+
+	opts.Update = true
+	testsupport.CompareGolden(t, golden, got, opts)
+	if written, err := testsupport.ReadDir(golden); err != nil || len(written) != 3 || written[".claude/agents/new.md"] != "new\n" {
+		t.Errorf("golden files after update = %v, %v", written, err)
+	}
+	testsupport.CompareGolden(t, golden, got, testsupport.GoldenOptions{Ignore: opts.Ignore})
+}
+func testGenerationCase(t *testing.T, registry *ModuleRegistry, projectLocal, existing, persisted bool, set generationComponents) {
+	home := testTempDir(t, "matrix-home-*")
+	project := testTempDir(t, "matrix-project-*")
+	t.Setenv("HOME", home)
+	t.Chdir(project)
+
+	base, err := resolveTargetDir(projectLocal)
+	if err != nil {
+		t.Fatalf("resolveTargetDir() error = %v", err)
+	}
+
+	// Files the user authored before claudekit ever ran; they must survive untouched
+	userFiles := map[string]string{}
+	if existing {
+		userFiles[".claude/agents/my-agent.md"] = "---\nname: my-agent\ndescription: mine\n---\nUser agent.\n"
+		userFiles[".claude/commands/my-command.md"] = "# My command\n"
+		for rel, content := range userFiles {
+			testCreateDirs(t, base, filepath.Dir(rel))
+			testWriteFile(t, filepath.Join(base, rel), content)
+		}
+		testWriteFile(t, filepath.Join(base, ".claude", "settings.json"), `{"env": {"USER_ONLY": "1"}}`)
+	}
+
+	cfg := set.config(projectLocal)
+
+	// Persisted column: a previous run generated other items, then the user deselected them
+	if persisted {
+		prev := previousSelection
+		prev.IsProjectLocal = projectLocal
+		if err := run(prev, registry); err != nil {
+			t.Fatalf("previous run() error = %v", err)
+		}
+		persistedCfg := &PersistenceConfig{
+			IsProjectLocal: prev.IsProjectLocal,
+			ProjectName:    prev.ProjectName,
+			Subagents:      prev.Subagents,
+			Hooks:          prev.Hooks,
+			SlashCommands:  prev.SlashCommands,
+		}
+		if err := cleanupDeselectedItems(fsys.OS{}, cfg, persistedCfg, base); err != nil {
+			t.Fatalf("cleanupDeselectedItems(fsys.OS{}, ) error = %v", err)
+		}
+	}
+
+	if err := run(cfg, registry); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want := expectedGeneratedFiles(cfg)
+	for rel := range userFiles {
+		want = append(want, rel)
+	}
+	slices.Sort(want)
+	if got := listFiles(t, base); !slices.Equal(got, want) {
+		t.Errorf("file tree mismatch\n got: %v\nwant: %v", got, want)
+	}
+
+	for rel, content := range userFiles {
+		if got := testReadFile(t, filepath.Join(base, rel)); got != content {
+			t.Errorf("user file %s was modified", rel)
+		}
+	}
+
+	checkGeneratedSettings(t, base, cfg, registry)
+	checkGeneratedHooks(t, base, cfg)
+	checkGeneratedMCP(t, base, cfg)
+	checkGeneratedManifest(t, base, want, userFiles)
+
+	claudeMD := testReadFile(t, filepath.Join(base, "CLAUDE.md"))
+	if !strings.Contains(claudeMD, "# matrix") {
+		t.Error("CLAUDE.md does not use the project name")
+	}
+	if hasFramework := strings.Contains(claudeMD, "## Framework Guidance"); hasFramework != (len(cfg.Frameworks) > 0) {
+		t.Errorf("CLAUDE.md framework section present = %v, want %v", hasFramework, len(cfg.Frameworks) > 0)
+	}
+}
+
+// expectedGeneratedFiles lists every file run() should leave behind for cfg, relative
+// to the base directory.
+func expectedGeneratedFiles(cfg Config) []string {
+	files := []string{"CLAUDE.md", ".claude/settings.json", ".claude/" + manifest.FileName}
+	for _, a := range cfg.Subagents {
+		files = append(files, ".claude/agents/"+a+".md")
+	}
+	libs := map[string]bool{}
+	for _, h := range cfg.Hooks {
+		name := testHookFileName(h)
+		files = append(files, ".claude/hooks/"+name)
+		if h != "session-start" {
+			libs[".claude/hooks/lib/hook"+path.Ext(name)] = true
+		}
+	}
+	files = slices.AppendSeq(files, maps.Keys(libs))
+	for _, c := range cfg.SlashCommands {
+		files = append(files, ".claude/commands/"+c+".md")
+	}
+	if len(cfg.MCPServers) > 0 {
+		files = append(files, ".mcp.json")
+	}
+	if cfg.Statusline != "" {
+		files = append(files, ".claude/statusline.sh")
+	}
+	if cfg.OutputStyle == "concise" || cfg.OutputStyle == "pair-programmer" {
+		files = append(files, ".claude/output-styles/"+cfg.OutputStyle+".md")
+	}
+	return files
+}
+
+// testHookFileName mirrors the script names run() writes for each hook module.
+func testHookFileName(hook string) string {
+	if hook == "user-prompt-submit" {
+		return hook + ".py"
+	}
+	return hook + ".sh"
+}
+
+// listFiles returns every regular file under base, relative and slash-separated.
+func listFiles(t *testing.T, base string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(base, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", base, err)
+	}
+	slices.Sort(files)
+	return files
+}
+
+func checkGeneratedSettings(t *testing.T, base string, cfg Config, registry *ModuleRegistry) {
+	t.Helper()
+	var st settings
+	if err := json.Unmarshal([]byte(testReadFile(t, filepath.Join(base, ".claude", "settings.json"))), &st); err != nil {
+		t.Fatalf("settings.json is not valid JSON: %v", err)
+	}
+
+	if st.Permissions == nil || !slices.Contains(st.Permissions.Allow, "Read") {
+		t.Error("settings.json missing default permissions")
+	}
+	if st.Env["CLAUDE_CODE_MAX_OUTPUT_TOKENS"] == "" {
+		t.Error("settings.json missing default env")
+	}
+
+	var commands []string
+	for _, matchers := range st.Hooks {
+		for _, matcher := range matchers {
+			for _, h := range matcher.Hooks {
+				commands = append(commands, h.Command)
+			}
+		}
+	}
+	if len(commands) != len(cfg.Hooks) {
+		t.Errorf("settings.json has %d hook commands, want %d: %v", len(commands), len(cfg.Hooks), commands)
+	}
+	for _, hook := range cfg.Hooks {
+		event, _ := registry.Get(TypeHook, hook).Defaults["hook_type"].(string)
+		found := false
+		for _, matcher := range st.Hooks[event] {
+			for _, h := range matcher.Hooks {
+				found = found || strings.HasSuffix(h.Command, "/"+testHookFileName(hook))
+			}
+		}
+		if !found {
+			t.Errorf("settings.json has no %s command for hook %s", event, hook)
+		}
+	}
+	for _, command := range commands {
+		if cfg.IsProjectLocal && !strings.HasPrefix(command, "$CLAUDE_PROJECT_DIR/") {
+			t.Errorf("project hook command %q should be relative to $CLAUDE_PROJECT_DIR", command)
+		}
+		if !cfg.IsProjectLocal && !strings.HasPrefix(command, base+string(filepath.Separator)) {
+			t.Errorf("global hook command %q should be an absolute path under %s", command, base)
+		}
+	}
+
+	wantStyle := ""
+	if module := registry.Get(TypeStyle, cfg.OutputStyle); module != nil {
+		wantStyle, _ = module.Defaults["style_name"].(string)
+	}
+	if st.OutputStyle != wantStyle {
+		t.Errorf("outputStyle = %q, want %q", st.OutputStyle, wantStyle)
+	}
+}
+
+func checkGeneratedHooks(t *testing.T, base string, cfg Config) {
+	t.Helper()
+	for _, hook := range cfg.Hooks {
+		path := filepath.Join(base, ".claude", "hooks", testHookFileName(hook))
+		if !testIsExecutable(t, path) {
+			t.Errorf("hook %s is not executable", hook)
+		}
+		if !strings.HasPrefix(testReadFile(t, path), "#!") {
+			t.Errorf("hook %s has no shebang", hook)
+		}
+	}
+}
+
+func checkGeneratedMCP(t *testing.T, base string, cfg Config) {
+	t.Helper()
+	if len(cfg.MCPServers) == 0 {
+		return
+	}
+	var doc struct {
+		MCPServers map[string]json.RawMessage `json:"mcpServers"`
+	}
+	if err := json.Unmarshal([]byte(testReadFile(t, filepath.Join(base, ".mcp.json"))), &doc); err != nil {
+		t.Fatalf(".mcp.json is not valid JSON: %v", err)
+	}
+	for _, server := range cfg.MCPServers {
+		if _, ok := doc.MCPServers[server]; !ok {
+			t.Errorf(".mcp.json missing server %s", server)
+		}
+	}
+	if len(doc.MCPServers) != len(cfg.MCPServers) {
+		t.Errorf(".mcp.json has %d servers, want %d", len(doc.MCPServers), len(cfg.MCPServers))
+	}
+}
+
+func checkGeneratedManifest(t *testing.T, base string, want []string, userFiles map[string]string) {
+	t.Helper()
+	mf, err := manifest.Load(base)
+	if err != nil {
+		t.Fatalf("manifest.Load() error = %v", err)
+	}
+	for _, rel := range want {
+		_, owned := mf.Lookup(rel)
+		_, isUser := userFiles[rel]
+		switch {
+		case rel == ".claude/"+manifest.FileName:
+		case isUser && owned:
+			t.Errorf("manifest claims user file %s", rel)
+		case !isUser && !owned:
+			t.Errorf("manifest missing generated file %s", rel)
+		}
+	}
+}
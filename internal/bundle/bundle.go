@@ -0,0 +1,247 @@
+// Package bundle reads and writes configuration bundles: gzipped tar archives that
+// hold a lockfile and the files it lists. The lockfile records a SHA-256 checksum of
+// every other file, and Read rejects an archive that does not match it.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LockName is the lockfile's path inside a bundle.
+const LockName = "claudekit.lock.json"
+
+// FormatVersion is the current lockfile format version.
+const FormatVersion = 1
+
+// maxFileSize bounds each file read from a bundle, so a corrupt or hostile archive
+// cannot exhaust memory.
+const maxFileSize = 10 << 20
+
+// ErrChecksum is returned by Read when a file does not match the lockfile.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// Module pins a module by a checksum of the content it generates.
+type Module struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Lock is the bundle's lockfile.
+type Lock struct {
+	Format           int               `json:"format"`
+	Description      string            `json:"description,omitempty"` // Shown where the bundle is offered as a project template
+	GeneratorVersion string            `json:"generator_version"`
+	CreatedAt        time.Time         `json:"created_at"`
+	Choices          json.RawMessage   `json:"choices"`
+	Modules          []Module          `json:"modules"`
+	Files            map[string]string `json:"files"` // Bundle path -> SHA-256 of its content
+}
+
+// Bundle is a lockfile and the files it lists, by slash-separated path.
+type Bundle struct {
+	Lock  Lock
+	Files map[string][]byte
+}
+
+// Checksum returns the hex-encoded SHA-256 of data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Write archives b to w, first recording a checksum of each file in the lockfile.
+// Entries are written in a fixed order and stamped with the lock's creation time,
+// so the same bundle always produces the same archive.
+func Write(w io.Writer, b *Bundle) error {
+	b.Lock.Format = FormatVersion
+	b.Lock.Files = make(map[string]string, len(b.Files))
+	for name, data := range b.Files {
+		if err := checkPath(name); err != nil {
+			return err
+		}
+		b.Lock.Files[name] = Checksum(data)
+	}
+	lock, err := json.MarshalIndent(b.Lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: b.Lock.CreatedAt,
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add(LockName, append(lock, '\n')); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(b.Files)) {
+		if err := add(name, b.Files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read extracts a bundle and verifies it: every file must be listed in the lockfile
+// with a matching checksum, and every listed file must be present.
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzipped bundle: %w", err)
+	}
+	defer gz.Close()
+
+	b := &Bundle{Files: map[string][]byte{}}
+	var lock []byte
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%s: bundles hold only regular files", hdr.Name)
+		}
+		if err := checkPath(hdr.Name); err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxFileSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", hdr.Name, err)
+		}
+		if len(data) > maxFileSize {
+			return nil, fmt.Errorf("%s: larger than %d bytes", hdr.Name, maxFileSize)
+		}
+		switch _, dup := b.Files[hdr.Name]; {
+		case hdr.Name == LockName && lock == nil:
+			lock = data
+		case hdr.Name == LockName || dup:
+			return nil, fmt.Errorf("%s: appears twice", hdr.Name)
+		default:
+			b.Files[hdr.Name] = data
+		}
+	}
+
+	if lock == nil {
+		return nil, fmt.Errorf("no %s in bundle", LockName)
+	}
+	if err := parseLock(b, lock); err != nil {
+		return nil, err
+	}
+	if err := verify(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ReadFS reads an unpacked bundle: the lockfile at the root of fsys and every other
+// regular file below it. A lockfile that lists files is verified as Read verifies
+// an archive; one that lists none, as in bundles kept in source control and edited
+// by hand, is taken as it is.
+func ReadFS(fsys fs.FS) (*Bundle, error) {
+	lock, err := fs.ReadFile(fsys, LockName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", LockName, err)
+	}
+	b := &Bundle{Files: map[string][]byte{}}
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || name == LockName {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%s: bundles hold only regular files", name)
+		}
+		if info, err := d.Info(); err != nil {
+			return err
+		} else if info.Size() > maxFileSize {
+			return fmt.Errorf("%s: larger than %d bytes", name, maxFileSize)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		b.Files[name] = data
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := parseLock(b, lock); err != nil {
+		return nil, err
+	}
+	if b.Lock.Files == nil {
+		return b, nil
+	}
+	if err := verify(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// parseLock decodes the lockfile into b.Lock.
+func parseLock(b *Bundle, lock []byte) error {
+	if err := json.Unmarshal(lock, &b.Lock); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", LockName, err)
+	}
+	if b.Lock.Format > FormatVersion {
+		return fmt.Errorf("bundle format %d is newer than this claudekit supports (%d); upgrade claudekit", b.Lock.Format, FormatVersion)
+	}
+	return nil
+}
+
+// verify checks that every file is listed in the lockfile with a matching checksum,
+// and that every listed file is present.
+func verify(b *Bundle) error {
+	for name, data := range b.Files {
+		want, ok := b.Lock.Files[name]
+		if !ok {
+			return fmt.Errorf("%s: not listed in %s", name, LockName)
+		}
+		if got := Checksum(data); got != want {
+			return fmt.Errorf("%s: %w (lockfile %.12s, content %.12s)", name, ErrChecksum, want, got)
+		}
+	}
+	for name := range b.Lock.Files {
+		if _, ok := b.Files[name]; !ok {
+			return fmt.Errorf("%s: listed in %s but missing", name, LockName)
+		}
+	}
+	return nil
+}
+
+// checkPath rejects names that are not clean relative paths, so extracting a bundle
+// cannot write outside its destination.
+func checkPath(name string) error {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(name, `\`) {
+		return fmt.Errorf("%q: not a relative path", name)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package formatting
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the rule configuration file discovered at FormatConfig.RootDir.
+const ConfigFileName = ".claudekit-fmt.yaml"
+
+// Heading styles accepted by RuleConfig.HeadingStyle.
+const (
+	HeadingStyleATX    = "atx"
+	HeadingStyleSetext = "setext"
+)
+
+// Horizontal rules accepted by RuleConfig.HorizontalRule.
+var horizontalRules = []string{"---", "***", "___"}
+
+// List markers accepted by RuleConfig.ListMarker.
+var listMarkers = []string{"-", "*", "+"}
+
+// configurableRules are the rules a config file may turn on or off. Tables are always
+// re-rendered by goldmark, so table-formatting cannot be disabled.
+var configurableRules = []string{
+	RuleHeadingStyle,
+	RuleListFormatting,
+	RuleCodeFenceStyle,
+	RuleEmphasisStyle,
+	RuleWhitespace,
+	RuleHorizontalRule,
+	RuleFrontmatterKeyOrder,
+	RuleLineWrap,
+}
+
+// optInRules only run when a config file turns them on.
+var optInRules = []string{RuleFrontmatterKeyOrder, RuleLineWrap}
+
+// RuleConfig selects which formatting rules run and how. The zero value is the GFM
+// standard: ATX headings, "---" rules, "*" emphasis, list markers left alone, and no
+// opt-in rules.
+type RuleConfig struct {
+	Disabled       map[string]bool // Rule names that should not run
+	OptedIn        map[string]bool // Opt-in rules that should run
+	HeadingStyle   string          // HeadingStyleATX (default) or HeadingStyleSetext
+	HorizontalRule string          // "---" (default), "***", or "___"
+	ListMarker     string          // "-", "*", or "+"; empty keeps each list's own marker
+	WrapWidth      int             // Column line-wrap wraps prose at; 0 means DefaultWrapWidth
+}
+
+// Enabled reports whether the named rule should run.
+func (c RuleConfig) Enabled(name string) bool {
+	if slices.Contains(optInRules, name) {
+		return c.OptedIn[name]
+	}
+	return !c.Disabled[name]
+}
+
+// fileRule is one entry under "rules:". It is either a bare boolean or a mapping with
+// an enabled flag and the rule's own setting.
+type fileRule struct {
+	Enabled *bool  `yaml:"enabled"`
+	Style   string `yaml:"style"`
+	Marker  string `yaml:"marker"`
+}
+
+func (r *fileRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var enabled bool
+		if err := node.Decode(&enabled); err != nil {
+			return err
+		}
+		r.Enabled = &enabled
+		return nil
+	}
+	type plain fileRule
+	return node.Decode((*plain)(r))
+}
+
+// fileConfig is the on-disk shape of ConfigFileName.
+type fileConfig struct {
+	Rules     map[string]fileRule `yaml:"rules"`
+	WrapWidth int                 `yaml:"wrap-width"`
+}
+
+// LoadRuleConfig reads ConfigFileName from dir. A missing file yields the zero
+// RuleConfig; an invalid one is an error naming the file.
+func LoadRuleConfig(dir string) (RuleConfig, error) {
+	path := filepath.Join(dir, ConfigFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return RuleConfig{}, nil
+		}
+		return RuleConfig{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	rules, err := ParseRuleConfig(data)
+	if err != nil {
+		return RuleConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRuleConfig parses and validates the contents of a ConfigFileName file.
+func ParseRuleConfig(data []byte) (RuleConfig, error) {
+	var file fileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return RuleConfig{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	if file.WrapWidth < 0 {
+		return RuleConfig{}, fmt.Errorf("wrap-width must not be negative, got %d", file.WrapWidth)
+	}
+	cfg := RuleConfig{WrapWidth: file.WrapWidth}
+
+	for name, rule := range file.Rules {
+		if !slices.Contains(configurableRules, name) {
+			return RuleConfig{}, fmt.Errorf("unknown rule %q (configurable rules: %s)", name, strings.Join(configurableRules, ", "))
+		}
+		switch {
+		case slices.Contains(optInRules, name):
+			// Listing an opt-in rule turns it on unless it says otherwise
+			if rule.Enabled == nil || *rule.Enabled {
+				if cfg.OptedIn == nil {
+					cfg.OptedIn = map[string]bool{}
+				}
+				cfg.OptedIn[name] = true
+			}
+		case rule.Enabled != nil && !*rule.Enabled:
+			if cfg.Disabled == nil {
+				cfg.Disabled = map[string]bool{}
+			}
+			cfg.Disabled[name] = true
+		}
+
+		switch name {
+		case RuleHeadingStyle:
+			if rule.Marker != "" {
+				return RuleConfig{}, fmt.Errorf("rule %s has no marker setting", name)
+			}
+			if rule.Style != "" && rule.Style != HeadingStyleATX && rule.Style != HeadingStyleSetext {
+				return RuleConfig{}, fmt.Errorf("rule %s: style must be %s or %s, got %q", name, HeadingStyleATX, HeadingStyleSetext, rule.Style)
+			}
+			cfg.HeadingStyle = rule.Style
+		case RuleHorizontalRule:
+			if rule.Marker != "" {
+				return RuleConfig{}, fmt.Errorf("rule %s has no marker setting", name)
+			}
+			if rule.Style != "" && !slices.Contains(horizontalRules, rule.Style) {
+				return RuleConfig{}, fmt.Errorf("rule %s: style must be one of %s, got %q", name, strings.Join(horizontalRules, " "), rule.Style)
+			}
+			cfg.HorizontalRule = rule.Style
+		case RuleListFormatting:
+			if rule.Style != "" {
+				return RuleConfig{}, fmt.Errorf("rule %s has no style setting", name)
+			}
+			if rule.Marker != "" && !slices.Contains(listMarkers, rule.Marker) {
+				return RuleConfig{}, fmt.Errorf("rule %s: marker must be one of %s, got %q", name, strings.Join(listMarkers, " "), rule.Marker)
+			}
+			cfg.ListMarker = rule.Marker
+		default:
+			if rule.Style != "" || rule.Marker != "" {
+				return RuleConfig{}, fmt.Errorf("rule %s can only be enabled or disabled", name)
+			}
+		}
+	}
+
+	return cfg, nil
+}
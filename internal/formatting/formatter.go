@@ -2,6 +2,7 @@ package formatting
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
 	"os"
 	"time"
@@ -40,8 +41,35 @@ func FormatMarkdownFile(file *MarkdownFile, cfg FormatConfig) (*FormatResult, er
 		return result, result.Error
 	}
 
+	// Frontmatter is YAML, not markdown; keep it out of goldmark's hands
+	frontmatter, body := SplitFrontmatter(file.Content)
+	var frontmatterRules []FormattingRule
+	if frontmatter != nil && cfg.Rules.Enabled(RuleFrontmatterKeyOrder) {
+		sorted, err := SortFrontmatterKeys(frontmatter)
+		if err != nil {
+			result.Status = StatusError
+			result.Error = err
+			file.ParseErrors = append(file.ParseErrors, err)
+			result.Duration = time.Since(startTime)
+			return result, result.Error
+		}
+		if !bytes.Equal(sorted, frontmatter) {
+			rule := FormattingRule{
+				Name:        RuleFrontmatterKeyOrder,
+				Description: "Sort frontmatter keys alphabetically",
+				Category:    CategoryFrontmatter,
+				FixCount:    1,
+			}
+			if v, ok := frontmatterOrderViolation(frontmatter); ok {
+				rule.Violations = []Violation{v}
+			}
+			frontmatterRules = append(frontmatterRules, rule)
+		}
+		frontmatter = sorted
+	}
+
 	// Parse markdown
-	doc, ctx, err := ParseMarkdown(file.Content)
+	doc, ctx, err := ParseMarkdown(body)
 	if err != nil {
 		result.Status = StatusError
 		result.Error = fmt.Errorf("failed to parse markdown: %w", err)
@@ -51,7 +79,22 @@ func FormatMarkdownFile(file *MarkdownFile, cfg FormatConfig) (*FormatResult, er
 	}
 
 	// Apply formatting rules
-	formatted, rulesApplied := ApplyFormattingRules(doc, ctx, file.Content, cfg)
+	formatted, rulesApplied := ApplyFormattingRules(doc, ctx, body, cfg)
+	if frontmatter != nil {
+		joined := bytes.Clone(frontmatter)
+		switch {
+		case len(bytes.TrimSpace(body)) == 0:
+			formatted = nil // Nothing but frontmatter; don't add an empty body line
+		case bytes.HasPrefix(bytes.TrimLeft(body, " \t\r"), []byte("\n")):
+			joined = append(joined, '\n') // Keep one blank line after the closing delimiter
+		}
+		formatted = append(joined, formatted...)
+		rulesApplied = append(frontmatterRules, rulesApplied...)
+	}
+
+	// Violations are reported whether or not the file changes, so lint sees rules
+	// the renderer happens to satisfy already
+	result.Violations = collectViolations(rulesApplied, bytes.Count(frontmatter, []byte("\n")))
 
 	// Check if content changed
 	if bytes.Equal(file.Content, formatted) {
@@ -76,32 +119,65 @@ func FormatMarkdownFile(file *MarkdownFile, cfg FormatConfig) (*FormatResult, er
 	return result, nil
 }
 
-// ApplyFormattingRules applies all formatting rules to the AST.
+// ApplyFormattingRules applies the formatting rules cfg.Rules enables to the AST.
 func ApplyFormattingRules(doc ast.Node, ctx parser.Context, source []byte, cfg FormatConfig) ([]byte, []FormattingRule) {
 	var rulesApplied []FormattingRule
+	rules := resolveStyles(doc, source, cfg.Rules)
 
 	// Transform AST: convert indented code blocks to fenced
-	convertIndentedCodeToFenced(doc, source)
+	var converted []Violation
+	if rules.Enabled(RuleCodeFenceStyle) {
+		converted = convertIndentedCodeToFenced(doc, source)
+	}
 
 	// Walk AST and apply transformations
-	applyHeadingRules(doc, source, &rulesApplied)
-	applyListRules(doc, source, &rulesApplied)
-	applyCodeBlockRules(doc, source, &rulesApplied)
+	if rules.Enabled(RuleHeadingStyle) {
+		applyHeadingRules(doc, source, rules.HeadingStyle, &rulesApplied)
+	}
+	if rules.Enabled(RuleListFormatting) {
+		applyListRules(doc, source, rules.ListMarker, &rulesApplied)
+	}
+	if rules.Enabled(RuleCodeFenceStyle) {
+		applyCodeBlockRules(doc, source, converted, &rulesApplied)
+	}
 	applyTableRules(doc, source, &rulesApplied)
-	applyEmphasisRules(doc, source, &rulesApplied)
-	applyWhitespaceRules(doc, source, &rulesApplied)
-	applyHorizontalRuleRules(doc, source, &rulesApplied)
+	if rules.Enabled(RuleEmphasisStyle) {
+		applyEmphasisRules(doc, source, &rulesApplied)
+	}
+	if rules.Enabled(RuleWhitespace) {
+		applyWhitespaceRules(doc, source, &rulesApplied)
+	}
+	if rules.Enabled(RuleHorizontalRule) {
+		applyHorizontalRuleRules(doc, source, rules.HorizontalRule, &rulesApplied)
+	}
 
 	// Re-render the AST to get formatted output
 	// Goldmark's markdown renderer will normalize most formatting automatically
-	formatted, err := RenderMarkdown(doc, source)
+	formatted, err := RenderMarkdown(doc, source, rules)
 	if err != nil {
 		// If rendering fails, return original
 		return source, []FormattingRule{}
 	}
 
 	// Apply final whitespace cleanup
-	formatted = NormalizeWhitespace(formatted)
+	if rules.Enabled(RuleWhitespace) {
+		formatted = NormalizeWhitespace(formatted)
+	}
+
+	// Wrap prose last, against the lines the file will actually contain
+	if rules.Enabled(RuleLineWrap) {
+		var wrapped int
+		formatted, wrapped = WrapProse(formatted, rules.WrapWidth)
+		if wrapped > 0 {
+			rulesApplied = append(rulesApplied, FormattingRule{
+				Name:        RuleLineWrap,
+				Description: fmt.Sprintf("Wrap paragraphs at %d columns", cmp.Or(rules.WrapWidth, DefaultWrapWidth)),
+				Category:    CategoryLineLength,
+				FixCount:    wrapped,
+				Violations:  wrapViolations(source, rules.WrapWidth),
+			})
+		}
+	}
 
 	return formatted, rulesApplied
 }
@@ -0,0 +1,148 @@
+package formatting
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SplitFrontmatter separates a leading YAML frontmatter block from the markdown body.
+// The block runs from a first line of "---" to the next line of "---" or "...", and
+// is returned with both delimiters and its final line ending intact. Content without
+// a closed block is all body.
+func SplitFrontmatter(content []byte) (frontmatter, body []byte) {
+	first, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found || string(bytes.TrimRight(first, "\r")) != "---" {
+		return nil, content
+	}
+
+	offset := len(first) + 1
+	for len(rest) > 0 {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		end := offset + len(line)
+		if end < len(content) {
+			end++ // Include the line ending
+		}
+		if delim := string(bytes.TrimRight(line, "\r")); delim == "---" || delim == "..." {
+			return content[:end], content[end:]
+		}
+		offset = end
+		rest = next
+	}
+	return nil, content
+}
+
+// frontmatterYAML returns the YAML between the delimiters of a frontmatter block.
+func frontmatterYAML(frontmatter []byte) (open, yamlText, closing []byte) {
+	openEnd := bytes.IndexByte(frontmatter, '\n') + 1
+	trimmed := bytes.TrimRight(frontmatter, "\r\n")
+	closeStart := bytes.LastIndexByte(trimmed, '\n') + 1
+	return frontmatter[:openEnd], frontmatter[openEnd:closeStart], frontmatter[closeStart:]
+}
+
+// SortFrontmatterKeys validates a frontmatter block as a YAML mapping and orders its
+// top-level keys alphabetically, the order claudekit writes module files in. Each key
+// moves with its value and the comments directly above it, byte for byte.
+func SortFrontmatterKeys(frontmatter []byte) ([]byte, error) {
+	open, yamlText, closing := frontmatterYAML(frontmatter)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlText, &doc); err != nil {
+		return nil, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return frontmatter, nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid frontmatter: expected a mapping of keys")
+	}
+	if len(mapping.Content) == 0 {
+		return frontmatter, nil // An empty mapping, {}; no keys to order
+	}
+
+	lines := bytes.SplitAfter(yamlText, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	// Each key owns its lines up to the next key, less the comments and blank lines
+	// that lead into that next key
+	type block struct {
+		key   string
+		lines [][]byte
+	}
+	var blocks []block
+	starts := make([]int, 0, len(mapping.Content)/2)
+	for i := 0; i < len(mapping.Content); i += 2 {
+		start := mapping.Content[i].Line - 1
+		if len(starts) > 0 {
+			prev := mapping.Content[i-2].Line - 1
+			if start <= prev {
+				return frontmatter, nil // Flow style, e.g. {a: 1, b: 2}; nothing to move
+			}
+			for start-1 > prev && isCommentOrBlank(lines[start-1]) {
+				start--
+			}
+		}
+		starts = append(starts, start)
+	}
+	header := lines[:starts[0]]
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		blocks = append(blocks, block{key: mapping.Content[2*i].Value, lines: lines[start:end]})
+	}
+
+	// A last line without a newline must not end up in the middle
+	last := blocks[len(blocks)-1].lines
+	if tail := last[len(last)-1]; !bytes.HasSuffix(tail, []byte("\n")) {
+		last[len(last)-1] = append(bytes.Clone(tail), '\n')
+	}
+
+	slices.SortStableFunc(blocks, func(a, b block) int { return cmp.Compare(a.key, b.key) })
+
+	out := bytes.Clone(open)
+	for _, line := range header {
+		out = append(out, line...)
+	}
+	for _, b := range blocks {
+		for _, line := range b.lines {
+			out = append(out, line...)
+		}
+	}
+	return append(out, closing...), nil
+}
+
+// frontmatterOrderViolation points at the first top-level key of a frontmatter block
+// that sorts before the key above it. Lines count the opening delimiter.
+func frontmatterOrderViolation(frontmatter []byte) (Violation, bool) {
+	_, yamlText, _ := frontmatterYAML(frontmatter)
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlText, &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return Violation{}, false
+	}
+	mapping := doc.Content[0]
+	for i := 2; i < len(mapping.Content); i += 2 {
+		key, prev := mapping.Content[i], mapping.Content[i-2]
+		if key.Value < prev.Value {
+			return Violation{
+				Rule:    RuleFrontmatterKeyOrder,
+				Line:    key.Line + 1,
+				Column:  key.Column,
+				Message: fmt.Sprintf("Key %q should come before %q", key.Value, prev.Value),
+			}, true
+		}
+	}
+	return Violation{}, false
+}
+
+func isCommentOrBlank(line []byte) bool {
+	trimmed := bytes.TrimSpace(line)
+	return len(trimmed) == 0 || trimmed[0] == '#'
+}
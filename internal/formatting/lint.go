@@ -0,0 +1,77 @@
+package formatting
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+)
+
+// RuleSummaries describes what each rule checks, for reports that list rules apart
+// from the violations that cite them.
+var RuleSummaries = map[string]string{
+	RuleHeadingStyle:        "Headings use the configured style",
+	RuleListFormatting:      "Unordered lists use the configured marker",
+	RuleCodeFenceStyle:      "Code blocks are fenced with backticks",
+	RuleEmphasisStyle:       "Emphasis uses asterisks",
+	RuleWhitespace:          "No trailing whitespace, LF line endings, one final newline",
+	RuleHorizontalRule:      "Horizontal rules use the configured style",
+	RuleFrontmatterKeyOrder: "Frontmatter keys are sorted alphabetically",
+	RuleLineWrap:            "Paragraphs are wrapped at the configured width",
+}
+
+// violationAt builds a violation of rule at byte offset in source.
+func violationAt(source []byte, offset int, rule, message string) Violation {
+	lineStart := bytes.LastIndexByte(source[:offset], '\n') + 1
+	return Violation{
+		Rule:    rule,
+		Line:    bytes.Count(source[:offset], []byte("\n")) + 1,
+		Column:  offset - lineStart + 1,
+		Message: message,
+	}
+}
+
+// whitespaceViolations finds what NormalizeWhitespace would change: trailing spaces
+// and tabs, CRLF line endings, and a missing or repeated final newline.
+func whitespaceViolations(source []byte) []Violation {
+	var violations []Violation
+	offset := 0
+	for _, line := range bytes.SplitAfter(source, []byte("\n")) {
+		content := bytes.TrimSuffix(line, []byte("\n"))
+		if trimmed := bytes.TrimRight(content, " \t\r"); len(trimmed) < len(content) {
+			msg := "Trailing whitespace"
+			if string(content[len(trimmed):]) == "\r" {
+				msg = "Line ends with CRLF; use LF"
+			}
+			violations = append(violations, violationAt(source, offset+len(trimmed), RuleWhitespace, msg))
+		}
+		offset += len(line)
+	}
+
+	body := bytes.TrimRight(source, "\n")
+	switch {
+	case len(body) == 0:
+	case len(body) == len(source):
+		violations = append(violations, violationAt(source, len(source), RuleWhitespace, "File does not end with a newline"))
+	case len(source)-len(body) > 1:
+		violations = append(violations, violationAt(source, len(body)+1, RuleWhitespace, "Blank lines at end of file"))
+	}
+	return violations
+}
+
+// collectViolations gathers the violations recorded by rules in file order, moving
+// the lines of body rules down past lineOffset lines of frontmatter.
+func collectViolations(rules []FormattingRule, lineOffset int) []Violation {
+	var violations []Violation
+	for _, rule := range rules {
+		for _, v := range rule.Violations {
+			if rule.Category != CategoryFrontmatter {
+				v.Line += lineOffset
+			}
+			violations = append(violations, v)
+		}
+	}
+	slices.SortStableFunc(violations, func(a, b Violation) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return violations
+}
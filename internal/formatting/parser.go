@@ -2,13 +2,17 @@ package formatting
 
 import (
 	"bytes"
+	"strings"
 
+	"github.com/charmbracelet/x/ansi"
 	markdown "github.com/teekennedy/goldmark-markdown"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 )
 
 // ParseMarkdown parses markdown content using goldmark with GFM extensions.
@@ -29,19 +33,37 @@ func ParseMarkdown(source []byte) (ast.Node, parser.Context, error) {
 	return doc, ctx, nil
 }
 
-// RenderMarkdown renders an AST back to markdown bytes.
-func RenderMarkdown(doc ast.Node, source []byte) ([]byte, error) {
+// RenderMarkdown renders an AST back to markdown bytes in the styles rules selects.
+// Unset styles fall back to the GFM defaults.
+func RenderMarkdown(doc ast.Node, source []byte, rules RuleConfig) ([]byte, error) {
+	var headingStyle markdown.HeadingStyle = markdown.HeadingStyleATX
+	if rules.HeadingStyle == HeadingStyleSetext {
+		headingStyle = markdown.HeadingStyleSetext
+	}
+	var breakStyle markdown.ThematicBreakStyle = markdown.ThematicBreakStyleDashed
+	switch rules.HorizontalRule {
+	case "***":
+		breakStyle = markdown.ThematicBreakStyleStarred
+	case "___":
+		breakStyle = markdown.ThematicBreakStyleUnderlined
+	}
+
 	// Create markdown renderer with our preferred styles
 	renderer := markdown.NewRenderer(
-		markdown.WithHeadingStyle(markdown.HeadingStyleATX),
-		markdown.WithThematicBreakStyle(markdown.ThematicBreakStyleDashed),
+		markdown.WithHeadingStyle(headingStyle),
+		markdown.WithThematicBreakStyle(breakStyle),
 		markdown.WithThematicBreakLength(3),
 	)
+	if !rules.Enabled(RuleEmphasisStyle) {
+		renderer.Register(ast.KindEmphasis, renderOriginalEmphasis)
+	}
 
 	md := goldmark.New(
 		goldmark.WithExtensions(extension.GFM),
 		goldmark.WithRenderer(renderer),
 	)
+	// GFM registers HTML renderers for tables; write them back as markdown instead.
+	renderer.Register(east.KindTable, renderTable)
 
 	var buf bytes.Buffer
 	if err := md.Renderer().Render(&buf, source, doc); err != nil {
@@ -50,3 +72,222 @@ func RenderMarkdown(doc ast.Node, source []byte) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// resolveStyles fills in the heading and horizontal rule styles a render will use.
+// When a style rule is disabled the document's own style is kept instead.
+func resolveStyles(doc ast.Node, source []byte, rules RuleConfig) RuleConfig {
+	if rules.HeadingStyle == "" {
+		rules.HeadingStyle = HeadingStyleATX
+	}
+	if !rules.Enabled(RuleHeadingStyle) {
+		rules.HeadingStyle = detectHeadingStyle(doc, source)
+	}
+	if rules.HorizontalRule == "" {
+		rules.HorizontalRule = "---"
+	}
+	if !rules.Enabled(RuleHorizontalRule) {
+		rules.HorizontalRule = detectHorizontalRule(source, rules.HorizontalRule)
+	}
+	return rules
+}
+
+// detectHeadingStyle reports the style of the first heading that could be written
+// either way: a single-line level 1 or 2 heading. ATX lines start with '#'.
+func detectHeadingStyle(doc ast.Node, source []byte) string {
+	style := HeadingStyleATX
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok || heading.Level > 2 || heading.Lines().Len() != 1 {
+			return ast.WalkContinue, nil
+		}
+		start := heading.Lines().At(0).Start
+		lineStart := bytes.LastIndexByte(source[:start], '\n') + 1
+		if !bytes.HasPrefix(bytes.TrimLeft(source[lineStart:start], " "), []byte("#")) {
+			style = HeadingStyleSetext
+		}
+		return ast.WalkStop, nil
+	})
+	return style
+}
+
+// detectHorizontalRule returns the first thematic break in source, normalized to three
+// characters, or fallback if there is none.
+func detectHorizontalRule(source []byte, fallback string) string {
+	breaks := thematicBreaks(source)
+	if len(breaks) == 0 {
+		return fallback
+	}
+	return strings.Repeat(string(source[breaks[0]]), 3)
+}
+
+// thematicBreaks returns where each thematic break in source starts. Goldmark does not
+// record where thematic breaks came from, so the lines are scanned directly, skipping
+// fenced code and the underlines of setext headings.
+func thematicBreaks(source []byte) []int {
+	var offsets []int
+	inFence := false
+	prevBlank := true
+	offset := 0
+	for _, line := range bytes.SplitAfter(source, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")) {
+			inFence = !inFence
+		}
+		indent := len(line) - len(bytes.TrimLeft(line, " "))
+		if !inFence && indent < 4 {
+			if c, ok := thematicBreakChar(trimmed); ok && (c != '-' || prevBlank) {
+				offsets = append(offsets, offset+indent)
+			}
+		}
+		prevBlank = len(trimmed) == 0
+		offset += len(line)
+	}
+	return offsets
+}
+
+// thematicBreakChar reports whether line is three or more of the same '-', '*', or '_'
+// separated only by spaces, and which character it uses.
+func thematicBreakChar(line []byte) (byte, bool) {
+	if len(line) == 0 || !bytes.ContainsRune([]byte("-*_"), rune(line[0])) {
+		return 0, false
+	}
+	count := 0
+	for _, c := range line {
+		switch c {
+		case line[0]:
+			count++
+		case ' ', '\t':
+		default:
+			return 0, false
+		}
+	}
+	return line[0], count >= 3
+}
+
+// renderTable writes a GFM table as markdown with its columns padded to a common
+// width. Cells are copied from the source, so inline markup and escaped pipes are
+// kept as written.
+func renderTable(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	table := node.(*east.Table)
+	var rows [][]string
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, string(cell.Lines().Value(source)))
+		}
+		rows = append(rows, cells)
+	}
+	widths := make([]int, len(table.Alignments))
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, cells := range rows {
+		for i, cell := range cells {
+			if i < len(widths) {
+				widths[i] = max(widths[i], ansi.StringWidth(cell))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if table.PreviousSibling() != nil {
+		buf.WriteByte('\n')
+	}
+	for r, cells := range rows {
+		writeTableRow(&buf, cells, widths, table.Alignments)
+		if r == 0 {
+			delimiters := make([]string, len(widths))
+			for i, width := range widths {
+				delimiters[i] = tableDelimiter(table.Alignments[i], width)
+			}
+			writeTableRow(&buf, delimiters, widths, nil)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return ast.WalkSkipChildren, err
+}
+
+// writeTableRow writes one table row, padding each cell to its column's width on
+// the side its alignment calls for.
+func writeTableRow(buf *bytes.Buffer, cells []string, widths []int, alignments []east.Alignment) {
+	buf.WriteByte('|')
+	for i, width := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		pad := width - ansi.StringWidth(cell)
+		left := 0
+		if i < len(alignments) {
+			switch alignments[i] {
+			case east.AlignRight:
+				left = pad
+			case east.AlignCenter:
+				left = pad / 2
+			}
+		}
+		buf.WriteString(" " + strings.Repeat(" ", left) + cell + strings.Repeat(" ", pad-left) + " |")
+	}
+	buf.WriteByte('\n')
+}
+
+// tableDelimiter returns the delimiter cell for a column, with colons marking its
+// alignment.
+func tableDelimiter(alignment east.Alignment, width int) string {
+	switch alignment {
+	case east.AlignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case east.AlignRight:
+		return strings.Repeat("-", width-1) + ":"
+	case east.AlignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	}
+	return strings.Repeat("-", width)
+}
+
+// renderOriginalEmphasis writes emphasis with the delimiter the author used instead
+// of normalizing it to '*'.
+func renderOriginalEmphasis(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Emphasis)
+	_, err := w.Write(bytes.Repeat([]byte{emphasisDelimiter(n, source)}, n.Level))
+	return ast.WalkContinue, err
+}
+
+// emphasisDelimiter finds the '*' or '_' that opened n. Emphasis nodes carry no source
+// position, so it is read back from before the first text inside them, stepping over
+// the delimiters of nested emphasis and the openers of code spans and links.
+func emphasisDelimiter(n *ast.Emphasis, source []byte) byte {
+	if offset := emphasisOpener(n, source); offset >= 0 {
+		return source[offset]
+	}
+	return '*'
+}
+
+// emphasisOpener returns the offset of the last delimiter character that opened n, or
+// -1 when it cannot be found.
+func emphasisOpener(n *ast.Emphasis, source []byte) int {
+	skip := 0
+	for child := n.FirstChild(); child != nil; child = child.FirstChild() {
+		if inner, ok := child.(*ast.Emphasis); ok {
+			skip += inner.Level
+		}
+		text, ok := child.(*ast.Text)
+		if !ok {
+			continue
+		}
+		for i := text.Segment.Start - skip - 1; i >= 0; i-- {
+			switch source[i] {
+			case '*', '_':
+				return i
+			case '`', '[', '!', '<':
+			default:
+				return -1
+			}
+		}
+		break
+	}
+	return -1
+}
@@ -0,0 +1,310 @@
+package formatting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// NewFormatReport creates an empty report ready to aggregate results.
+func NewFormatReport() *FormatReport {
+	return &FormatReport{RuleStats: make(map[string]int)}
+}
+
+// Add aggregates a single file result into the report.
+func (r *FormatReport) Add(result *FormatResult) {
+	if result == nil {
+		return
+	}
+	if r.RuleStats == nil {
+		r.RuleStats = make(map[string]int)
+	}
+
+	r.TotalFiles++
+	r.Results = append(r.Results, *result)
+
+	switch result.Status {
+	case StatusModified:
+		r.FilesModified++
+	case StatusUnchanged:
+		r.FilesUnchanged++
+	case StatusExcluded:
+		r.FilesExcluded++
+	case StatusError:
+		r.FilesErrored++
+		if result.Error != nil {
+			r.Errors = append(r.Errors, fmt.Errorf("%s: %w", result.File.RelPath, result.Error))
+		}
+	}
+
+	for _, rule := range result.RulesApplied {
+		r.RuleStats[rule.Name] += rule.FixCount
+		r.TotalFixesApplied += rule.FixCount
+	}
+	r.TotalViolations += len(result.Violations)
+}
+
+// jsonReport is the machine-readable shape of a FormatReport.
+type jsonReport struct {
+	TotalFiles        int              `json:"total_files"`
+	FilesModified     int              `json:"files_modified"`
+	FilesUnchanged    int              `json:"files_unchanged"`
+	FilesExcluded     int              `json:"files_excluded"`
+	FilesErrored      int              `json:"files_errored"`
+	TotalFixesApplied int              `json:"total_fixes_applied"`
+	TotalViolations   int              `json:"total_violations"`
+	RuleStats         map[string]int   `json:"rule_stats"`
+	DurationMs        float64          `json:"duration_ms"`
+	Files             []jsonFileResult `json:"files"`
+}
+
+type jsonFileResult struct {
+	Path         string          `json:"path"`
+	Status       string          `json:"status"`
+	RulesApplied []jsonRule      `json:"rules_applied,omitempty"`
+	Violations   []jsonViolation `json:"violations,omitempty"`
+	DurationMs   float64         `json:"duration_ms"`
+	Error        string          `json:"error,omitempty"`
+}
+
+type jsonRule struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	FixCount int    `json:"fix_count"`
+}
+
+type jsonViolation struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// WriteJSONReport writes the report as JSON for dashboards and CI tooling.
+func WriteJSONReport(w io.Writer, r *FormatReport) error {
+	out := jsonReport{
+		TotalFiles:        r.TotalFiles,
+		FilesModified:     r.FilesModified,
+		FilesUnchanged:    r.FilesUnchanged,
+		FilesExcluded:     r.FilesExcluded,
+		FilesErrored:      r.FilesErrored,
+		TotalFixesApplied: r.TotalFixesApplied,
+		TotalViolations:   r.TotalViolations,
+		RuleStats:         r.RuleStats,
+		DurationMs:        durationMs(r.Duration),
+		Files:             make([]jsonFileResult, 0, len(r.Results)),
+	}
+	if out.RuleStats == nil {
+		out.RuleStats = map[string]int{}
+	}
+
+	for _, res := range r.Results {
+		file := jsonFileResult{
+			Path:       reportPath(res.File),
+			Status:     res.Status,
+			DurationMs: durationMs(res.Duration),
+		}
+		for _, rule := range res.RulesApplied {
+			file.RulesApplied = append(file.RulesApplied, jsonRule{Name: rule.Name, Category: rule.Category, FixCount: rule.FixCount})
+		}
+		for _, v := range res.Violations {
+			file.Violations = append(file.Violations, jsonViolation{Rule: v.Rule, Line: v.Line, Column: v.Column, Message: v.Message})
+		}
+		if res.Error != nil {
+			file.Error = res.Error.Error()
+		}
+		out.Files = append(out.Files, file)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// SARIF 2.1.0 subset used by code-quality tools.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// errorRuleID identifies files the formatter could not process.
+const errorRuleID = "format-error"
+
+// WriteSARIFReport writes the report as SARIF 2.1.0. Modified files are reported as
+// warnings per rule applied; files that failed to format are reported as errors.
+func WriteSARIFReport(w io.Writer, r *FormatReport) error {
+	rules := map[string]string{errorRuleID: "File could not be formatted"}
+	var results []sarifResult
+
+	for _, res := range r.Results {
+		location := []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: reportPath(res.File)},
+		}}}
+
+		switch res.Status {
+		case StatusError:
+			msg := "File could not be formatted"
+			if res.Error != nil {
+				msg = res.Error.Error()
+			}
+			results = append(results, sarifResult{RuleID: errorRuleID, Level: "error", Message: sarifMessage{Text: msg}, Locations: location})
+		case StatusModified:
+			for _, rule := range res.RulesApplied {
+				rules[rule.Name] = rule.Description
+				results = append(results, sarifResult{
+					RuleID:    rule.Name,
+					Level:     "warning",
+					Message:   sarifMessage{Text: fmt.Sprintf("%s (%d fix(es))", rule.Description, rule.FixCount)},
+					Locations: location,
+				})
+			}
+		}
+	}
+
+	return writeSARIF(w, rules, results)
+}
+
+// WriteSARIFLintReport writes the violations in the report as SARIF 2.1.0, one
+// warning per violation with the line and column it starts at. Files that failed to
+// format are reported as errors.
+func WriteSARIFLintReport(w io.Writer, r *FormatReport) error {
+	rules := map[string]string{errorRuleID: "File could not be formatted"}
+	var results []sarifResult
+
+	for _, res := range r.Results {
+		uri := sarifArtifactLocation{URI: reportPath(res.File)}
+		if res.Status == StatusError {
+			msg := "File could not be formatted"
+			if res.Error != nil {
+				msg = res.Error.Error()
+			}
+			results = append(results, sarifResult{RuleID: errorRuleID, Level: "error", Message: sarifMessage{Text: msg},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: uri}}}})
+			continue
+		}
+		for _, v := range res.Violations {
+			rules[v.Rule] = RuleSummaries[v.Rule]
+			results = append(results, sarifResult{
+				RuleID:  v.Rule,
+				Level:   "warning",
+				Message: sarifMessage{Text: v.Message},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: uri,
+					Region:           &sarifRegion{StartLine: v.Line, StartColumn: v.Column},
+				}}},
+			})
+		}
+	}
+	return writeSARIF(w, rules, results)
+}
+
+// writeSARIF wraps results in a single claudekit-fmt run describing rules.
+func writeSARIF(w io.Writer, rules map[string]string, results []sarifResult) error {
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	driver := sarifDriver{Name: "claudekit-fmt"}
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: rules[id]}})
+	}
+	if results == nil {
+		results = []sarifResult{}
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// WriteViolations writes one line per violation in the compiler-style form editors
+// and CI log viewers link to: path:line:column: rule: message.
+func WriteViolations(w io.Writer, r *FormatReport) error {
+	for _, res := range r.Results {
+		for _, v := range res.Violations {
+			if _, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", reportPath(res.File), v.Line, v.Column, v.Rule, v.Message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteSummary writes the human-readable summary that accompanies the machine reports.
+func WriteSummary(w io.Writer, r *FormatReport) error {
+	_, err := fmt.Fprintf(w, "%d file(s): %d modified, %d unchanged, %d excluded, %d errored (%d fixes in %s)\n",
+		r.TotalFiles, r.FilesModified, r.FilesUnchanged, r.FilesExcluded, r.FilesErrored,
+		r.TotalFixesApplied, r.Duration.Round(time.Millisecond))
+	return err
+}
+
+// reportPath prefers the relative path for stable, portable report output.
+func reportPath(f MarkdownFile) string {
+	if f.RelPath != "" {
+		return f.RelPath
+	}
+	return f.Path
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
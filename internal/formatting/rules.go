@@ -1,12 +1,18 @@
 package formatting
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
+
 	"github.com/yuin/goldmark/ast"
 	east "github.com/yuin/goldmark/extension/ast"
 )
 
-// convertIndentedCodeToFenced transforms indented code blocks to fenced code blocks in the AST.
-func convertIndentedCodeToFenced(doc ast.Node, source []byte) {
+// convertIndentedCodeToFenced transforms indented code blocks to fenced code blocks in the AST
+// and returns where each converted block started.
+func convertIndentedCodeToFenced(doc ast.Node, source []byte) []Violation {
+	var violations []Violation
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
@@ -14,6 +20,12 @@ func convertIndentedCodeToFenced(doc ast.Node, source []byte) {
 
 		// Check if this is an indented code block (not fenced)
 		if codeBlock, ok := n.(*ast.CodeBlock); ok {
+			if codeBlock.Lines().Len() > 0 {
+				start := codeBlock.Lines().At(0).Start
+				lineStart := bytes.LastIndexByte(source[:start], '\n') + 1
+				violations = append(violations, violationAt(source, lineStart, RuleCodeFenceStyle, "Use a fenced code block instead of indentation"))
+			}
+
 			// Create a new fenced code block
 			fenced := ast.NewFencedCodeBlock(nil)
 
@@ -32,21 +44,29 @@ func convertIndentedCodeToFenced(doc ast.Node, source []byte) {
 
 		return ast.WalkContinue, nil
 	})
+	return violations
 }
 
-// applyHeadingRules ensures ATX-style headings with proper spacing.
-func applyHeadingRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
+// headingStyleNames spell heading styles the way rule descriptions print them.
+var headingStyleNames = map[string]string{HeadingStyleATX: "ATX", HeadingStyleSetext: "Setext"}
+
+// applyHeadingRules ensures headings use one style with proper spacing.
+func applyHeadingRules(doc ast.Node, source []byte, style string, rules *[]FormattingRule) {
 	fixCount := 0
+	var violations []Violation
 
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
 		}
 
-		if _, ok := n.(*ast.Heading); ok {
+		if heading, ok := n.(*ast.Heading); ok {
 			// Goldmark parser already converts setext to ATX internally
 			// Just count the transformations for reporting
 			fixCount++
+			if v, ok := headingViolation(heading, source, style); ok {
+				violations = append(violations, v)
+			}
 		}
 
 		return ast.WalkContinue, nil
@@ -54,24 +74,56 @@ func applyHeadingRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 
 	if fixCount > 0 {
 		*rules = append(*rules, FormattingRule{
-			Name:        "heading-atx-style",
-			Description: "Convert to ATX-style headings with proper spacing",
+			Name:        RuleHeadingStyle,
+			Description: fmt.Sprintf("Convert to %s-style headings with proper spacing", headingStyleNames[style]),
 			Category:    CategoryHeading,
 			FixCount:    fixCount,
+			Violations:  violations,
 		})
 	}
 }
 
-// applyListRules ensures consistent list indentation and markers.
-func applyListRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
+// headingViolation reports a heading written in the other style. Only single-level 1
+// and 2 headings can be underlined, so longer ATX headings never violate Setext style.
+func headingViolation(heading *ast.Heading, source []byte, style string) (Violation, bool) {
+	if heading.Lines().Len() == 0 {
+		return Violation{}, false
+	}
+	start := heading.Lines().At(0).Start
+	lineStart := bytes.LastIndexByte(source[:start], '\n') + 1
+	indent := len(source[lineStart:start]) - len(bytes.TrimLeft(source[lineStart:start], " "))
+	atx := bytes.HasPrefix(source[lineStart+indent:start], []byte("#"))
+
+	switch {
+	case style == HeadingStyleATX && !atx:
+		return violationAt(source, lineStart+indent, RuleHeadingStyle, "Use an ATX heading (# Title) instead of an underline"), true
+	case style == HeadingStyleSetext && atx && heading.Level <= 2:
+		return violationAt(source, lineStart+indent, RuleHeadingStyle, "Use a Setext heading (underlined) instead of #"), true
+	}
+	return Violation{}, false
+}
+
+// applyListRules ensures consistent list indentation and markers. A non-empty marker
+// replaces the bullet of every unordered list.
+func applyListRules(doc ast.Node, source []byte, marker string, rules *[]FormattingRule) {
 	fixCount := 0
+	var violations []Violation
 
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
 		}
 
-		if _, ok := n.(*ast.List); ok {
+		if list, ok := n.(*ast.List); ok {
+			// Adjacent bullet lists are only separate because their markers differ;
+			// giving them the same marker would merge them
+			prev, adjacent := list.PreviousSibling().(*ast.List)
+			if marker != "" && !list.IsOrdered() && !(adjacent && !prev.IsOrdered()) {
+				if list.Marker != marker[0] {
+					violations = append(violations, listMarkerViolations(list, source, marker)...)
+				}
+				list.Marker = marker[0]
+			}
 			fixCount++
 		}
 
@@ -80,25 +132,50 @@ func applyListRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 
 	if fixCount > 0 {
 		*rules = append(*rules, FormattingRule{
-			Name:        "list-formatting",
+			Name:        RuleListFormatting,
 			Description: "Consistent list indentation and markers",
 			Category:    CategoryList,
 			FixCount:    fixCount,
+			Violations:  violations,
 		})
 	}
 }
 
-// applyCodeBlockRules ensures fenced code blocks with language tags.
-func applyCodeBlockRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
+// listMarkerViolations points at the bullet of each item in list, which is read back
+// from the line of the item's first block.
+func listMarkerViolations(list *ast.List, source []byte, marker string) []Violation {
+	var violations []Violation
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		block := item.FirstChild()
+		if block == nil || block.Lines().Len() == 0 {
+			continue
+		}
+		start := block.Lines().At(0).Start
+		lineStart := bytes.LastIndexByte(source[:start], '\n') + 1
+		if i := bytes.LastIndexByte(source[lineStart:start], list.Marker); i >= 0 {
+			msg := fmt.Sprintf("Use %q for list items instead of %q", marker, string(list.Marker))
+			violations = append(violations, violationAt(source, lineStart+i, RuleListFormatting, msg))
+		}
+	}
+	return violations
+}
+
+// applyCodeBlockRules ensures fenced code blocks with language tags. converted holds the
+// indented blocks convertIndentedCodeToFenced already replaced.
+func applyCodeBlockRules(doc ast.Node, source []byte, converted []Violation, rules *[]FormattingRule) {
 	fixCount := 0
+	violations := converted
 
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
 		}
 
-		if _, ok := n.(*ast.FencedCodeBlock); ok {
+		if fenced, ok := n.(*ast.FencedCodeBlock); ok {
 			fixCount++
+			if offset, ok := fenceOpener(fenced, source); ok && source[offset] == '~' {
+				violations = append(violations, violationAt(source, offset, RuleCodeFenceStyle, "Use ``` instead of ~~~ for code fences"))
+			}
 		} else if _, ok := n.(*ast.CodeBlock); ok {
 			// Indented code blocks exist - goldmark renderer will normalize
 			fixCount++
@@ -109,14 +186,35 @@ func applyCodeBlockRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 
 	if fixCount > 0 {
 		*rules = append(*rules, FormattingRule{
-			Name:        "code-fence-style",
+			Name:        RuleCodeFenceStyle,
 			Description: "Use fenced code blocks with backticks",
 			Category:    CategoryCode,
 			FixCount:    fixCount,
+			Violations:  violations,
 		})
 	}
 }
 
+// fenceOpener finds the first fence character of a fenced code block: on the line of
+// its info string, or the line before its first line of code.
+func fenceOpener(n *ast.FencedCodeBlock, source []byte) (int, bool) {
+	var lineEnd int
+	switch {
+	case n.Info != nil:
+		lineEnd = n.Info.Segment.Start
+	case n.Lines().Len() > 0:
+		lineEnd = bytes.LastIndexByte(source[:n.Lines().At(0).Start], '\n')
+		if lineEnd < 0 {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	lineStart := bytes.LastIndexByte(source[:lineEnd], '\n') + 1
+	i := bytes.IndexAny(source[lineStart:lineEnd], "`~")
+	return lineStart + i, i >= 0
+}
+
 // applyTableRules ensures proper table formatting (GFM extension).
 func applyTableRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 	fixCount := 0
@@ -135,7 +233,7 @@ func applyTableRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 
 	if fixCount > 0 {
 		*rules = append(*rules, FormattingRule{
-			Name:        "table-formatting",
+			Name:        RuleTableFormat,
 			Description: "Proper table alignment and spacing",
 			Category:    CategoryTable,
 			FixCount:    fixCount,
@@ -146,14 +244,20 @@ func applyTableRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 // applyEmphasisRules ensures consistent emphasis markers.
 func applyEmphasisRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 	fixCount := 0
+	var violations []Violation
 
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
 		}
 
-		if _, ok := n.(*ast.Emphasis); ok {
+		if emphasis, ok := n.(*ast.Emphasis); ok {
 			fixCount++
+			if offset := emphasisOpener(emphasis, source); offset >= 0 && source[offset] == '_' {
+				start := offset - emphasis.Level + 1
+				violations = append(violations, violationAt(source, start, RuleEmphasisStyle, fmt.Sprintf("Use %s instead of %s for emphasis",
+					strings.Repeat("*", emphasis.Level), strings.Repeat("_", emphasis.Level))))
+			}
 		}
 
 		return ast.WalkContinue, nil
@@ -161,10 +265,11 @@ func applyEmphasisRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 
 	if fixCount > 0 {
 		*rules = append(*rules, FormattingRule{
-			Name:        "emphasis-style",
+			Name:        RuleEmphasisStyle,
 			Description: "Consistent emphasis markers",
 			Category:    CategoryEmphasis,
 			FixCount:    fixCount,
+			Violations:  violations,
 		})
 	}
 }
@@ -173,16 +278,24 @@ func applyEmphasisRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 func applyWhitespaceRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
 	// Whitespace is handled by normalizeWhitespace() post-render
 	*rules = append(*rules, FormattingRule{
-		Name:        "whitespace-normalization",
+		Name:        RuleWhitespace,
 		Description: "Remove trailing whitespace and normalize line endings",
 		Category:    CategoryWhitespace,
 		FixCount:    1,
+		Violations:  whitespaceViolations(source),
 	})
 }
 
 // applyHorizontalRuleRules ensures consistent horizontal rule style.
-func applyHorizontalRuleRules(doc ast.Node, source []byte, rules *[]FormattingRule) {
+func applyHorizontalRuleRules(doc ast.Node, source []byte, style string, rules *[]FormattingRule) {
 	fixCount := 0
+	var violations []Violation
+	for _, offset := range thematicBreaks(source) {
+		line, _, _ := bytes.Cut(source[offset:], []byte("\n"))
+		if string(bytes.TrimSpace(line)) != style {
+			violations = append(violations, violationAt(source, offset, RuleHorizontalRule, fmt.Sprintf("Use %s for horizontal rules", style)))
+		}
+	}
 
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
@@ -198,10 +311,11 @@ func applyHorizontalRuleRules(doc ast.Node, source []byte, rules *[]FormattingRu
 
 	if fixCount > 0 {
 		*rules = append(*rules, FormattingRule{
-			Name:        "horizontal-rule-style",
-			Description: "Use triple dash for horizontal rules",
+			Name:        RuleHorizontalRule,
+			Description: fmt.Sprintf("Use %s for horizontal rules", style),
 			Category:    CategoryHorizontalRule,
 			FixCount:    fixCount,
+			Violations:  violations,
 		})
 	}
 }
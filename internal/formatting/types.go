@@ -11,6 +11,7 @@ type FormatConfig struct {
 	DryRun          bool
 	Verbose         bool
 	Standard        string // Fixed to "GFM"
+	Rules           RuleConfig
 }
 
 // MarkdownFile represents a single markdown file to process.
@@ -40,8 +41,31 @@ type FormattingRule struct {
 	Description string
 	Category    string
 	FixCount    int
+	Violations  []Violation // Where the source breaks the rule, for rules that can tell
 }
 
+// Violation is one place a file breaks a formatting rule, as reported by lint mode.
+type Violation struct {
+	Rule    string
+	Line    int // 1-based
+	Column  int // 1-based, in bytes
+	Message string
+}
+
+// FormattingRule names, as reported in results and used in ConfigFileName.
+const (
+	RuleHeadingStyle   = "heading-atx-style"
+	RuleListFormatting = "list-formatting"
+	RuleCodeFenceStyle = "code-fence-style"
+	RuleTableFormat    = "table-formatting"
+	RuleEmphasisStyle  = "emphasis-style"
+	RuleWhitespace     = "whitespace-normalization"
+	RuleHorizontalRule = "horizontal-rule-style"
+
+	RuleFrontmatterKeyOrder = "frontmatter-key-order" // Opt-in
+	RuleLineWrap            = "line-wrap"             // Opt-in
+)
+
 // FormattingRule categories.
 const (
 	CategoryHeading        = "heading"
@@ -52,6 +76,8 @@ const (
 	CategoryEmphasis       = "emphasis"
 	CategoryWhitespace     = "whitespace"
 	CategoryHorizontalRule = "horizontal-rule"
+	CategoryFrontmatter    = "frontmatter"
+	CategoryLineLength     = "line-length"
 )
 
 // FormatResult represents the result of formatting a single file.
@@ -59,6 +85,7 @@ type FormatResult struct {
 	File         MarkdownFile
 	Status       string
 	RulesApplied []FormattingRule
+	Violations   []Violation // Every rule's violations, in file order
 	LineChanges  []LineChange
 	Error        error
 	Duration     time.Duration
@@ -89,6 +116,7 @@ type FormatReport struct {
 	FilesExcluded     int
 	FilesErrored      int
 	TotalFixesApplied int
+	TotalViolations   int
 	RuleStats         map[string]int
 	Results           []FormatResult
 	Duration          time.Duration
@@ -0,0 +1,247 @@
+package formatting
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// DefaultWrapWidth is the column RuleLineWrap wraps at when no wrap-width is set.
+const DefaultWrapWidth = 100
+
+// WrapProse hard-wraps the paragraphs of rendered markdown at width columns and
+// reports how many paragraphs changed. Only paragraph text is touched: headings,
+// code blocks, tables, HTML, and link reference definitions are not paragraphs, and
+// code spans, link destinations, and inline HTML are never split. Wrapping the
+// output again yields the same bytes.
+func WrapProse(content []byte, width int) ([]byte, int) {
+	if width <= 0 {
+		width = DefaultWrapWidth
+	}
+	edits := wrapEdits(content, width)
+
+	out := content
+	if len(edits) > 0 {
+		out = bytes.Clone(content)
+		for _, e := range slices.Backward(edits) {
+			out = slices.Replace(out, e.start, e.stop, e.text...)
+		}
+	}
+	return out, len(edits)
+}
+
+// wrapEdit replaces content[start:stop] with a paragraph's wrapped text.
+type wrapEdit struct {
+	start, stop int
+	text        []byte
+}
+
+// wrapEdits returns the paragraphs of content that wrapping at width changes, in order.
+func wrapEdits(content []byte, width int) []wrapEdit {
+	doc, _, err := ParseMarkdown(content)
+	if err != nil {
+		return nil
+	}
+
+	var edits []wrapEdit
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if n.Kind() != ast.KindParagraph && n.Kind() != ast.KindTextBlock {
+			return ast.WalkContinue, nil
+		}
+		lines := n.Lines()
+		if lines.Len() == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		first, last := lines.At(0), lines.At(lines.Len()-1)
+		stop := last.Start + len(bytes.TrimRight(last.Value(content), "\r\n"))
+
+		prefix := content[bytes.LastIndexByte(content[:first.Start], '\n')+1 : first.Start]
+		wrapped := wrapParagraph(n, content, prefix, width)
+		if !bytes.Equal(wrapped, content[first.Start:stop]) {
+			edits = append(edits, wrapEdit{start: first.Start, stop: stop, text: wrapped})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return edits
+}
+
+// wrapViolations reports the paragraphs of source that WrapProse would change.
+func wrapViolations(source []byte, width int) []Violation {
+	if width <= 0 {
+		width = DefaultWrapWidth
+	}
+	var violations []Violation
+	for _, e := range wrapEdits(source, width) {
+		violations = append(violations, violationAt(source, e.start, RuleLineWrap, fmt.Sprintf("Wrap paragraph at %d columns", width)))
+	}
+	return violations
+}
+
+// wrapParagraph refills the lines of one paragraph. prefix is what precedes the
+// paragraph on its first line, such as "> " or "- "; later lines get the same
+// container markers with list markers blanked out. Hard line breaks are kept.
+func wrapParagraph(n ast.Node, source, prefix []byte, width int) []byte {
+	var hardBreaks []int
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := c.(*ast.Text); ok && entering && t.HardLineBreak() {
+			hardBreaks = append(hardBreaks, t.Segment.Stop)
+		}
+		return ast.WalkContinue, nil
+	})
+	continuation := bytes.Map(func(r rune) rune {
+		if r == '>' {
+			return r
+		}
+		return ' '
+	}, prefix)
+
+	var out bytes.Buffer
+	lineWidth := utf8.RuneCount(prefix)
+	var group []string // Lines up to the next hard break, joined with spaces
+	flush := func(ending string) {
+		for i, word := range proseWords(strings.Join(group, " ")) {
+			wordWidth := utf8.RuneCountInString(word)
+			switch {
+			case i == 0 && out.Len() == 0:
+			case i == 0:
+				out.Write(continuation)
+				lineWidth = utf8.RuneCount(continuation)
+			case lineWidth+1+wordWidth > width && !startsBlock(word):
+				out.WriteByte('\n')
+				out.Write(continuation)
+				lineWidth = utf8.RuneCount(continuation)
+			default:
+				out.WriteByte(' ')
+				lineWidth++
+			}
+			out.WriteString(word)
+			lineWidth += wordWidth
+		}
+		out.WriteString(ending)
+		group = group[:0]
+	}
+
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		line := strings.TrimRight(string(seg.Value(source)), "\r\n")
+		group = append(group, strings.TrimSpace(line))
+		if i == lines.Len()-1 {
+			flush("")
+			break
+		}
+		if slices.ContainsFunc(hardBreaks, func(pos int) bool { return pos >= seg.Start && pos <= seg.Stop }) {
+			// Keep the break as written: a trailing backslash is part of the last
+			// word, trailing spaces are not
+			flush(line[len(strings.TrimRight(line, " \t")):] + "\n")
+		}
+	}
+	return out.Bytes()
+}
+
+// proseWords splits paragraph text at whitespace, keeping code spans, link
+// destinations, autolinks, and inline HTML whole.
+func proseWords(text string) []string {
+	var words []string
+	start := -1
+	for i := 0; i < len(text); {
+		c := text[i]
+		if c == ' ' || c == '\t' {
+			if start >= 0 {
+				words = append(words, text[start:i])
+				start = -1
+			}
+			i++
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		switch {
+		case c == '\\':
+			i += 2
+		case c == '`':
+			i = skipCodeSpan(text, i)
+		case c == ']' && strings.HasPrefix(text[i:], "]("):
+			i = skipDestination(text, i+1)
+		case c == '<' && i+1 < len(text) && text[i+1] != ' ':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				i += end + 1
+			} else {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// skipCodeSpan returns the index just past the code span opening at i, or past
+// the backtick run when it is never closed.
+func skipCodeSpan(text string, i int) int {
+	run := i
+	for run < len(text) && text[run] == '`' {
+		run++
+	}
+	ticks := text[i:run]
+	for j := run; j < len(text); {
+		k := strings.Index(text[j:], ticks)
+		if k < 0 {
+			break
+		}
+		end := j + k + len(ticks)
+		if end == len(text) || text[end] != '`' {
+			return end
+		}
+		for end < len(text) && text[end] == '`' {
+			end++ // A longer run does not close this span
+		}
+		j = end
+	}
+	return run
+}
+
+// skipDestination returns the index just past the parenthesised link destination
+// and title opening at i.
+func skipDestination(text string, i int) int {
+	depth := 0
+	for j := i; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(text)
+}
+
+// startsBlock reports whether word would open a new block if it began a line,
+// turning the rest of the paragraph into a list, heading, quote, or the like.
+func startsBlock(word string) bool {
+	if strings.Trim(word, "-*_=+:|") == "" || strings.Trim(word, "#") == "" {
+		return true // List bullets, thematic breaks, setext underlines, table rules, headings
+	}
+	if strings.HasPrefix(word, ">") || strings.HasPrefix(word, "<") ||
+		strings.HasPrefix(word, "```") || strings.HasPrefix(word, "~~~") {
+		return true
+	}
+	digits := strings.TrimLeft(word, "0123456789")
+	return len(digits) < len(word) && (digits == "." || digits == ")")
+}
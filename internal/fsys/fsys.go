@@ -0,0 +1,201 @@
+// Package fsys puts the files claudekit writes behind an interface, so dry runs,
+// diffs, backups, and tests can intercept the writes without touching the disk.
+package fsys
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FS is the file system claudekit generates into and cleans up. Its methods
+// behave like the os functions of the same names.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Chmod(name string, mode fs.FileMode) error
+}
+
+// OS is the real file system.
+type OS struct{}
+
+func (OS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (OS) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OS) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// Mem is an in-memory file system. Like the disk, it needs a file's directory to
+// exist before the file is written; the root always exists.
+type Mem struct {
+	files map[string]*memFile
+}
+
+type memFile struct {
+	data []byte
+	mode fs.FileMode // Includes fs.ModeDir for directories
+}
+
+// NewMem returns an empty in-memory file system.
+func NewMem() *Mem {
+	return &Mem{files: map[string]*memFile{}}
+}
+
+func (m *Mem) lookup(name string) (*memFile, bool) {
+	name = filepath.Clean(name)
+	if filepath.Dir(name) == name {
+		return &memFile{mode: fs.ModeDir | 0o755}, true
+	}
+	f, ok := m.files[name]
+	return f, ok
+}
+
+func (m *Mem) ReadFile(name string) ([]byte, error) {
+	f, ok := m.lookup(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if f.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	return slices.Clone(f.data), nil
+}
+
+func (m *Mem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if dir, ok := m.lookup(filepath.Dir(name)); !ok || !dir.mode.IsDir() {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	// Like os.WriteFile, an existing file keeps its mode
+	if f, ok := m.lookup(name); ok {
+		if f.mode.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+		}
+		f.data = slices.Clone(data)
+		return nil
+	}
+	m.files[filepath.Clean(name)] = &memFile{data: slices.Clone(data), mode: perm.Perm()}
+	return nil
+}
+
+func (m *Mem) MkdirAll(path string, perm fs.FileMode) error {
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if f, ok := m.lookup(dir); ok {
+			if !f.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: fs.ErrExist}
+			}
+			return nil
+		}
+		m.files[dir] = &memFile{mode: fs.ModeDir | perm.Perm()}
+	}
+}
+
+func (m *Mem) Remove(name string) error {
+	name = filepath.Clean(name)
+	f, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if f.mode.IsDir() && len(m.children(name)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *Mem) Stat(name string) (fs.FileInfo, error) {
+	f, ok := m.lookup(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return memInfo{name: filepath.Base(name), file: f}, nil
+}
+
+func (m *Mem) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, ok := m.lookup(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if !f.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	var entries []fs.DirEntry
+	for _, child := range m.children(filepath.Clean(name)) {
+		entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: filepath.Base(child), file: m.files[child]}))
+	}
+	return entries, nil
+}
+
+func (m *Mem) Chmod(name string, mode fs.FileMode) error {
+	f, ok := m.lookup(name)
+	if !ok {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	f.mode = f.mode&fs.ModeType | mode.Perm()
+	return nil
+}
+
+// Files lists the regular files in m, sorted.
+func (m *Mem) Files() []string {
+	var names []string
+	for name, f := range m.files {
+		if !f.mode.IsDir() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// children lists the direct entries of dir, sorted like os.ReadDir.
+func (m *Mem) children(dir string) []string {
+	var names []string
+	for name := range m.files {
+		if filepath.Dir(name) == dir && name != dir {
+			names = append(names, name)
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int { return strings.Compare(filepath.Base(a), filepath.Base(b)) })
+	return names
+}
+
+// memInfo describes a Mem file.
+type memInfo struct {
+	name string
+	file *memFile
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(len(i.file.data)) }
+func (i memInfo) Mode() fs.FileMode  { return i.file.mode }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.file.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
@@ -2,8 +2,6 @@ package generation
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 )
 
 // GenerateSlashCommandAssetFile creates a slash command markdown template.
@@ -35,21 +33,13 @@ Explain how this command uses project context and available tools.
 Provide example scenarios and expected outcomes.
 `, desc.Name, description, desc.Name)
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+	// Write file, unless it already holds this content
+	unchanged, err := writeAssetFile(outputPath, []byte(content), 0644)
+	if err != nil {
 		return GenerationResult{
 			FilePath: outputPath,
 			Status:   StatusFailed,
-			Error:    fmt.Errorf("failed to create directory: %w", err),
-		}
-	}
-
-	// Write file
-	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
-		return GenerationResult{
-			FilePath: outputPath,
-			Status:   StatusFailed,
-			Error:    fmt.Errorf("failed to write file: %w", err),
+			Error:    err,
 		}
 	}
 
@@ -60,6 +50,10 @@ Provide example scenarios and expected outcomes.
 		status = StatusPlaceholderGenerated
 	}
 
+	if unchanged {
+		return GenerationResult{FilePath: outputPath, Status: StatusSkippedUnchanged, IsPlaceholder: isPlaceholder}
+	}
+
 	return GenerationResult{
 		FilePath:      outputPath,
 		Status:        status,
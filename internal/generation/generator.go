@@ -1,6 +1,7 @@
 package generation
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,6 +26,18 @@ func CheckExistingFiles(descriptors []AssetFileDescriptor, baseDir string) Overw
 
 // GenerateAssetFiles orchestrates batch file generation.
 func GenerateAssetFiles(descriptors []AssetFileDescriptor, baseDir string) GenerationReport {
+	return GenerateAssetFilesWithProgress(descriptors, baseDir, nil)
+}
+
+// GenerateAssetFilesWithProgress generates like GenerateAssetFiles, calling progress,
+// when it is not nil, with the result of each file as it is generated.
+func GenerateAssetFilesWithProgress(descriptors []AssetFileDescriptor, baseDir string, progress func(GenerationResult)) GenerationReport {
+	return GenerateAssetFilesWithRetry(descriptors, baseDir, DefaultRetryPolicy, progress)
+}
+
+// GenerateAssetFilesWithRetry generates like GenerateAssetFilesWithProgress, trying
+// each file that fails with a transient error again as policy allows.
+func GenerateAssetFilesWithRetry(descriptors []AssetFileDescriptor, baseDir string, policy RetryPolicy, progress func(GenerationResult)) GenerationReport {
 	report := GenerationReport{
 		TotalFiles: len(descriptors),
 		Results:    make([]GenerationResult, 0, len(descriptors)),
@@ -32,37 +45,57 @@ func GenerateAssetFiles(descriptors []AssetFileDescriptor, baseDir string) Gener
 
 	for _, desc := range descriptors {
 		fullPath := filepath.Join(baseDir, desc.Path)
+		result := policy.Do(func() GenerationResult {
+			return generateAssetFile(desc, fullPath)
+		})
 
-		var result GenerationResult
-		switch desc.Type {
-		case AssetTypeSubagent:
-			result = GenerateSubagentAssetFile(desc, fullPath)
-		case AssetTypeHook:
-			result = GenerateHookAssetFile(desc, fullPath)
-		case AssetTypeSlashCommand:
-			result = GenerateSlashCommandAssetFile(desc, fullPath)
-		default:
-			result = GenerationResult{
-				FilePath: fullPath,
-				Status:   StatusFailed,
-				Error:    fmt.Errorf("unknown asset type: %v", desc.Type),
-			}
+		report.Add(result)
+		if result.Status == StatusFailed {
+			report.FailedDescriptors = append(report.FailedDescriptors, desc)
 		}
+		if progress != nil {
+			progress(result)
+		}
+	}
 
-		report.Results = append(report.Results, result)
+	return report
+}
 
-		switch result.Status {
-		case StatusSuccess:
-			report.Successful++
-		case StatusPlaceholderGenerated:
-			report.PlaceholdersGenerated++
-		case StatusFailed:
-			report.Failed++
-			report.FailedDescriptors = append(report.FailedDescriptors, desc)
+// generateAssetFile writes the file desc describes to fullPath with the generator
+// registered for its type.
+func generateAssetFile(desc AssetFileDescriptor, fullPath string) GenerationResult {
+	info, ok := LookupAssetType(desc.Type)
+	if !ok {
+		return GenerationResult{
+			FilePath: fullPath,
+			Status:   StatusFailed,
+			Error:    fmt.Errorf("unknown asset type: %v", desc.Type),
 		}
 	}
+	return info.Generate(desc, fullPath)
+}
 
-	return report
+// writeAssetFile writes content to path with perm, creating its directory. A file
+// that already holds content, with the execute bits perm asks for, is left alone
+// so its modification time and git status do not change; writeAssetFile then
+// reports it unchanged.
+func writeAssetFile(path string, content []byte, perm os.FileMode) (unchanged bool, err error) {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0o111 == perm&0o111 {
+			return true, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, perm); err != nil {
+		return false, fmt.Errorf("failed to write file: %w", err)
+	}
+	// WriteFile keeps the mode of a file that already exists
+	if err := os.Chmod(path, perm); err != nil {
+		return false, fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	return false, nil
 }
 
 // RetryFailedGeneration retries only failed file generations.
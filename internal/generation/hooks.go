@@ -3,7 +3,6 @@ package generation
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -54,26 +53,17 @@ exit 0
 `, desc.Name, desc.Name)
 	}
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return GenerationResult{
-			FilePath: outputPath,
-			Status:   StatusFailed,
-			Error:    fmt.Errorf("failed to create directory: %w", err),
-		}
-	}
-
 	// Write file with executable permissions (except for .tmpl files)
 	perm := os.FileMode(0644)
 	if !isTemplate {
 		perm = 0755
 	}
-
-	if err := os.WriteFile(outputPath, []byte(content), perm); err != nil {
+	unchanged, err := writeAssetFile(outputPath, []byte(content), perm)
+	if err != nil {
 		return GenerationResult{
 			FilePath: outputPath,
 			Status:   StatusFailed,
-			Error:    fmt.Errorf("failed to write file: %w", err),
+			Error:    err,
 		}
 	}
 
@@ -85,6 +75,10 @@ exit 0
 		status = StatusPlaceholderGenerated
 	}
 
+	if unchanged {
+		return GenerationResult{FilePath: outputPath, Status: StatusSkippedUnchanged, IsPlaceholder: isPlaceholder}
+	}
+
 	return GenerationResult{
 		FilePath:      outputPath,
 		Status:        status,
@@ -0,0 +1,138 @@
+package generation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// AssetTypeInfo says how to generate, check, and stub out files of one AssetType.
+type AssetTypeInfo struct {
+	Name string // How reports and errors name the type, e.g. "subagent"
+
+	// Generate writes the file desc describes to outputPath.
+	Generate func(desc AssetFileDescriptor, outputPath string) GenerationResult
+
+	// Validate checks a file of the type on disk; nil when there is nothing to check.
+	Validate func(path string) error
+
+	// Placeholder writes a file for name that has no module behind it; nil for
+	// Generate with a descriptor that has no Module.
+	Placeholder func(name, outputPath string) GenerationResult
+}
+
+var (
+	assetTypesMu  sync.RWMutex
+	assetTypes    = map[AssetType]AssetTypeInfo{}
+	nextAssetType = AssetTypeSlashCommand + 1
+)
+
+func init() {
+	registerAssetType(AssetTypeSubagent, AssetTypeInfo{
+		Name:        "subagent",
+		Generate:    GenerateSubagentAssetFile,
+		Validate:    ValidateAgentMarkdown,
+		Placeholder: GeneratePlaceholderSubagent,
+	})
+	registerAssetType(AssetTypeHook, AssetTypeInfo{
+		Name:     "hook",
+		Generate: GenerateHookAssetFile,
+		Validate: validateHookScript,
+		Placeholder: func(name, outputPath string) GenerationResult {
+			return GeneratePlaceholderHook(name, outputPath, strings.TrimPrefix(filepath.Ext(outputPath), "."))
+		},
+	})
+	registerAssetType(AssetTypeSlashCommand, AssetTypeInfo{
+		Name:        "slash command",
+		Generate:    GenerateSlashCommandAssetFile,
+		Validate:    ValidateYAMLFrontmatter,
+		Placeholder: GeneratePlaceholderSlashCommand,
+	})
+}
+
+// RegisterAssetType adds a type of asset file GenerateAssetFiles can generate, and
+// returns the AssetType for its descriptors. It panics when info has no Name or
+// Generate function, or when another type already has its Name, so it is best
+// called from an init function.
+func RegisterAssetType(info AssetTypeInfo) AssetType {
+	assetTypesMu.Lock()
+	t := nextAssetType
+	nextAssetType++
+	assetTypesMu.Unlock()
+	registerAssetType(t, info)
+	return t
+}
+
+func registerAssetType(t AssetType, info AssetTypeInfo) {
+	if info.Name == "" || info.Generate == nil {
+		panic("generation: RegisterAssetType needs a Name and a Generate function")
+	}
+	assetTypesMu.Lock()
+	defer assetTypesMu.Unlock()
+	for _, registered := range assetTypes {
+		if registered.Name == info.Name {
+			panic(fmt.Sprintf("generation: asset type %q registered twice", info.Name))
+		}
+	}
+	assetTypes[t] = info
+}
+
+// LookupAssetType returns how files of type t are generated, and whether t is
+// registered at all.
+func LookupAssetType(t AssetType) (AssetTypeInfo, bool) {
+	assetTypesMu.RLock()
+	defer assetTypesMu.RUnlock()
+	info, ok := assetTypes[t]
+	return info, ok
+}
+
+// String returns the name t was registered with.
+func (t AssetType) String() string {
+	if info, ok := LookupAssetType(t); ok {
+		return info.Name
+	}
+	return fmt.Sprintf("AssetType(%d)", int(t))
+}
+
+// GeneratePlaceholder writes a placeholder file of type t for name to outputPath.
+func GeneratePlaceholder(t AssetType, name, outputPath string) GenerationResult {
+	info, ok := LookupAssetType(t)
+	if !ok {
+		return GenerationResult{FilePath: outputPath, Status: StatusFailed, Error: fmt.Errorf("unknown asset type: %v", t)}
+	}
+	if info.Placeholder == nil {
+		return info.Generate(AssetFileDescriptor{Name: name, Type: t}, outputPath)
+	}
+	return info.Placeholder(name, outputPath)
+}
+
+// ValidateAssetFile checks the file desc describes under baseDir as its type's
+// Validate function does.
+func ValidateAssetFile(desc AssetFileDescriptor, baseDir string) error {
+	info, ok := LookupAssetType(desc.Type)
+	if !ok {
+		return fmt.Errorf("unknown asset type: %v", desc.Type)
+	}
+	if info.Validate == nil {
+		return nil
+	}
+	return info.Validate(filepath.Join(baseDir, desc.Path))
+}
+
+// validateHookScript checks that a hook script starts with a shebang. Templates are
+// rendered before they run, so they are not checked.
+func validateHookScript(path string) error {
+	if strings.HasSuffix(path, ".tmpl") {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if !strings.HasPrefix(string(data), "#!") {
+		return fmt.Errorf("missing shebang")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package generation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// String names the status as it appears in machine-readable reports.
+func (s GenerationStatus) String() string {
+	switch s {
+	case StatusSuccess:
+		return "success"
+	case StatusPlaceholderGenerated:
+		return "placeholder"
+	case StatusFailed:
+		return "failed"
+	case StatusSkippedUnchanged:
+		return "unchanged"
+	default:
+		return "unknown"
+	}
+}
+
+// jsonReport is the machine-readable shape of a GenerationReport.
+type jsonReport struct {
+	TotalFiles            int              `json:"total_files"`
+	Successful            int              `json:"successful"`
+	PlaceholdersGenerated int              `json:"placeholders_generated"`
+	Failed                int              `json:"failed"`
+	Unchanged             int              `json:"unchanged"`
+	Files                 []jsonFileResult `json:"files"`
+}
+
+type jsonFileResult struct {
+	Path         string `json:"path"`
+	Status       string `json:"status"`
+	BytesWritten int    `json:"bytes_written"`
+	Attempts     int    `json:"attempts,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Retryable    bool   `json:"retryable,omitempty"`
+}
+
+// WriteJSONReport writes the report as JSON for scripts and CI tooling. File paths
+// are made relative to baseDir when possible.
+func WriteJSONReport(w io.Writer, r *GenerationReport, baseDir string) error {
+	out := jsonReport{
+		TotalFiles:            r.TotalFiles,
+		Successful:            r.Successful,
+		PlaceholdersGenerated: r.PlaceholdersGenerated,
+		Failed:                r.Failed,
+		Unchanged:             r.Unchanged,
+		Files:                 make([]jsonFileResult, 0, len(r.Results)),
+	}
+
+	for _, res := range r.Results {
+		path := res.FilePath
+		if rel, err := filepath.Rel(baseDir, path); err == nil {
+			path = filepath.ToSlash(rel)
+		}
+		file := jsonFileResult{Path: path, Status: res.Status.String(), BytesWritten: res.BytesWritten, Attempts: res.Attempts}
+		if res.Error != nil {
+			file.Error = res.Error.Error()
+			file.Retryable = res.Retryable
+		}
+		out.Files = append(out.Files, file)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// ReadJSONReport reads a report WriteJSONReport wrote, joining relative file paths to
+// baseDir again. The report does not record which descriptors failed, so
+// FailedDescriptors is left empty.
+func ReadJSONReport(r io.Reader, baseDir string) (*GenerationReport, error) {
+	var in jsonReport
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	report := &GenerationReport{
+		TotalFiles:            in.TotalFiles,
+		Successful:            in.Successful,
+		PlaceholdersGenerated: in.PlaceholdersGenerated,
+		Failed:                in.Failed,
+		Unchanged:             in.Unchanged,
+	}
+	for _, file := range in.Files {
+		status, err := parseStatus(file.Status)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Path, err)
+		}
+		path := filepath.FromSlash(file.Path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		result := GenerationResult{
+			FilePath:      path,
+			Status:        status,
+			BytesWritten:  file.BytesWritten,
+			IsPlaceholder: status == StatusPlaceholderGenerated,
+			Attempts:      file.Attempts,
+			Retryable:     file.Retryable,
+		}
+		if file.Error != "" {
+			result.Error = errors.New(file.Error)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// parseStatus reads a status as String names it.
+func parseStatus(name string) (GenerationStatus, error) {
+	for _, status := range []GenerationStatus{StatusSuccess, StatusPlaceholderGenerated, StatusFailed, StatusSkippedUnchanged} {
+		if status.String() == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown status %q", name)
+}
+
+// Remaining returns the descriptors r has no result but a failure for: the files a
+// resumed run still has to generate.
+func (r *GenerationReport) Remaining(descriptors []AssetFileDescriptor, baseDir string) []AssetFileDescriptor {
+	done := map[string]bool{}
+	for _, result := range r.Results {
+		if result.Status != StatusFailed {
+			done[result.FilePath] = true
+		}
+	}
+	var remaining []AssetFileDescriptor
+	for _, desc := range descriptors {
+		if !done[filepath.Join(baseDir, desc.Path)] {
+			remaining = append(remaining, desc)
+		}
+	}
+	return remaining
+}
+
+// MergeResumed puts the files previous generated, and r did not, in front of r's
+// results, so the report of a resumed run covers every file.
+func (r *GenerationReport) MergeResumed(previous *GenerationReport) {
+	generated := make(map[string]bool, len(r.Results))
+	for _, result := range r.Results {
+		generated[result.FilePath] = true
+	}
+	var carried []GenerationResult
+	for _, result := range previous.Results {
+		if result.Status == StatusFailed || generated[result.FilePath] {
+			continue
+		}
+		carried = append(carried, result)
+		r.count(result)
+	}
+	r.TotalFiles += len(carried)
+	r.Results = append(carried, r.Results...)
+}
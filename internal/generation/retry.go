@@ -0,0 +1,75 @@
+package generation
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy says how often a file that fails with a transient error is generated
+// again, and how long to wait in between.
+type RetryPolicy struct {
+	Attempts  int           // Tries per file, the first included; less than 1 means 1
+	BaseDelay time.Duration // Wait before the second try, doubled before each one after
+	MaxDelay  time.Duration // Longest wait between tries; 0 for no limit
+
+	Sleep func(time.Duration) // Waits between tries; nil for time.Sleep
+}
+
+// DefaultRetryPolicy rides out a file briefly held by an editor, a virus scanner, or
+// a sync client: four tries over about a third of a second.
+var DefaultRetryPolicy = RetryPolicy{Attempts: 4, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second}
+
+// transientErrnos are the errors a later try may not run into: a busy or locked
+// file, an interrupted or timed out call, and running out of file descriptors.
+var transientErrnos = []syscall.Errno{
+	syscall.EBUSY,
+	syscall.ETXTBSY,
+	syscall.EAGAIN,
+	syscall.EINTR,
+	syscall.ETIMEDOUT,
+	syscall.EMFILE,
+	syscall.ENFILE,
+}
+
+// IsRetryable reports whether err is transient, so generating the file again may
+// succeed. Errors such as a missing permission or a full disk are permanent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// Do calls generate until it does not fail with a retryable error or the policy's
+// attempts run out. The result records the number of tries and, when it failed,
+// whether the error was retryable.
+func (p RetryPolicy) Do(generate func() GenerationResult) GenerationResult {
+	sleep := p.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	delay := p.BaseDelay
+	for attempt := 1; ; attempt++ {
+		result := generate()
+		result.Attempts = attempt
+		if result.Status != StatusFailed {
+			return result
+		}
+		result.Retryable = IsRetryable(result.Error)
+		if !result.Retryable || attempt >= p.Attempts {
+			return result
+		}
+		sleep(delay)
+		delay *= 2
+		if p.MaxDelay > 0 {
+			delay = min(delay, p.MaxDelay)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package generation
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 )
 
 // GenerateSubagentAssetFile creates a subagent markdown file.
@@ -23,14 +26,30 @@ func GenerateSubagentAssetFile(desc AssetFileDescriptor, outputPath string) Gene
 			shortDesc = strings.TrimSpace(shortDesc)
 		}
 
-		// Extract tools based on category
-		tools = GetToolsForAgent(desc.Name, desc.Module.GetCategory())
-		instructions = GenerateInstructionsForAgent(desc.Name, desc.Module.GetCategory())
+		// Tools come from the module's metadata
+		tools = desc.Module.GetTools()
+		if len(tools) == 0 {
+			tools = placeholderTools
+		}
+		var err error
+		instructions, err = GenerateInstructionsForAgent(AgentTemplateData{
+			Name:        desc.Name,
+			Category:    desc.Module.GetCategory(),
+			Description: fullDesc,
+			Tools:       desc.Module.GetTools(),
+		})
+		if err != nil {
+			return GenerationResult{
+				FilePath: outputPath,
+				Status:   StatusFailed,
+				Error:    fmt.Errorf("failed to render instructions: %w", err),
+			}
+		}
 	} else {
 		// Placeholder content
 		shortDesc = fmt.Sprintf("TODO: Brief description for %s", desc.Name)
 		fullDesc = fmt.Sprintf("TODO: Describe %s agent role and capabilities", desc.Name)
-		tools = []string{"Read", "Write", "Edit", "Grep", "Bash"}
+		tools = placeholderTools
 		instructions = fmt.Sprintf("TODO: Define workflow for %s:\n1. Step 1\n2. Step 2\n3. Step 3", desc.Name)
 	}
 
@@ -55,21 +74,13 @@ tools: %s
 %s
 `, desc.Name, shortDesc, toolsList, fullDesc, instructions, GenerateExamplesMarkdown(desc.Name))
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return GenerationResult{
-			FilePath: outputPath,
-			Status:   StatusFailed,
-			Error:    fmt.Errorf("failed to create directory: %w", err),
-		}
-	}
-
-	// Write file
-	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
+	// Write file, unless it already holds this content
+	unchanged, err := writeAssetFile(outputPath, []byte(content), 0644)
+	if err != nil {
 		return GenerationResult{
 			FilePath: outputPath,
 			Status:   StatusFailed,
-			Error:    fmt.Errorf("failed to write file: %w", err),
+			Error:    err,
 		}
 	}
 
@@ -79,6 +90,10 @@ tools: %s
 		status = StatusPlaceholderGenerated
 	}
 
+	if unchanged {
+		return GenerationResult{FilePath: outputPath, Status: StatusSkippedUnchanged, IsPlaceholder: isPlaceholder}
+	}
+
 	return GenerationResult{
 		FilePath:      outputPath,
 		Status:        status,
@@ -87,286 +102,91 @@ tools: %s
 	}
 }
 
-// GetToolsForAgent returns appropriate tools based on agent type.
-func GetToolsForAgent(name, category string) []string {
-	baseTools := []string{"Read", "Write", "Edit", "Grep"}
-
-	switch {
-	case strings.Contains(name, "test"):
-		return append(baseTools, "Bash", "Task")
-	case strings.Contains(name, "security"):
-		return append(baseTools, "Bash", "WebSearch", "Task")
-	case strings.Contains(name, "perf"):
-		return append(baseTools, "Bash", "Task")
-	case strings.Contains(name, "data"):
-		return append(baseTools, "Bash", "Task")
-	case strings.Contains(name, "release"):
-		return append(baseTools, "Bash", "Task")
-	default:
-		return append(baseTools, "Bash", "Task")
-	}
+// placeholderTools are granted to subagents whose module declares no tools.
+var placeholderTools = []string{"Read", "Write", "Edit", "Grep", "Bash"}
+
+// Templates holds the embedded assets, whose assets/templates/agents directory has
+// a workflow template per subagent category. The binary sets it at startup.
+var Templates fs.FS
+
+// agentTemplateDir holds the workflow templates, named after the category they
+// serve, and default.md.tmpl for subagents of any other category.
+const agentTemplateDir = "assets/templates/agents"
+
+// agentNameCategories pick a workflow template for subagents whose category has
+// none, by a word in their name.
+var agentNameCategories = []struct{ word, category string }{
+	{"code-review", "quality"},
+	{"test", "testing"},
+	{"bug", "debugging"},
+	{"security", "security"},
+	{"perf", "performance"},
+	{"docs", "documentation"},
+	{"release", "devops"},
+	{"data", "data"},
 }
 
-// GenerateInstructionsForAgent creates workflow instructions.
-func GenerateInstructionsForAgent(name, category string) string {
-	switch {
-	case strings.Contains(name, "code-review"):
-		return `### Review Process
-
-1. **Context Gathering**
-   - Run git diff to identify changed files and scope
-   - Use git log --oneline -5 to understand recent development context
-   - Read related files to understand broader impact
-   - Check if changes affect public APIs, data models, or critical paths
-
-2. **Review Categories**
-
-**CRITICAL ISSUES** (Must fix before merge):
-   - Security vulnerabilities (injection, XSS, auth bypass)
-   - Memory leaks, race conditions, deadlocks
-   - Breaking changes to public APIs without versioning
-   - Data corruption risks or unsafe operations
-
-**WARNINGS** (Should fix):
-   - Performance anti-patterns (N+1 queries, inefficient algorithms)
-   - Code smells (large functions, deep nesting, duplicated logic)
-   - Missing error handling or inadequate logging
-   - Inconsistent patterns or style violations
-
-**SUGGESTIONS** (Nice to have):
-   - Refactoring opportunities for better readability
-   - More descriptive naming or documentation
-   - Alternative approaches or libraries
-
-3. **Provide Actionable Feedback**
-   - Reference specific file names and line numbers
-   - Explain *why* something is problematic
-   - Suggest concrete alternatives with code examples
-   - Prioritize feedback by severity`
+// AgentTemplateData is the module metadata a workflow template is executed with.
+type AgentTemplateData struct {
+	Name        string
+	Category    string
+	Description string
+	Tools       []string
+}
 
-	case strings.Contains(name, "test"):
-		return `### Testing Workflow
-
-1. **Discover Test Framework**
-   - Check for pytest, jest, go test, cargo test, etc.
-   - Identify test file patterns (*_test.go, *.test.js, etc.)
-   - Read test configuration files
-
-2. **Run Tests**
-   - Execute full test suite: npm test, go test ./..., pytest
-   - Run specific test files if debugging: go test -run TestName
-   - Check for test coverage: go test -cover, pytest --cov
-
-3. **Analyze Failures**
-   - Read error messages and stack traces carefully
-   - Identify patterns in failures (timing, environment, data)
-   - Check if failures are flaky or deterministic
-   - Use debugging tools if needed (dlv, pdb, Chrome DevTools)
-
-4. **Fix or Improve Tests**
-   - Fix broken test assertions
-   - Add missing test cases for edge conditions
-   - Improve test clarity and maintainability
-   - Ensure tests are isolated and don't depend on order`
+// GenerateInstructionsForAgent creates workflow instructions from the template for
+// the subagent's category.
+func GenerateInstructionsForAgent(data AgentTemplateData) (string, error) {
+	if Templates == nil {
+		return "", fmt.Errorf("no workflow templates are loaded")
+	}
+	name := agentTemplatePath(data)
+	content, err := fs.ReadFile(Templates, name)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(path.Base(name)).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
 
-	case strings.Contains(name, "bug"):
-		return `### RIDDE Debugging Methodology
-
-1. **Reproduce** the issue
-   - Get exact steps to reproduce
-   - Identify minimal reproduction case
-   - Note environment details (OS, version, config)
-   - Try to reproduce locally
-
-2. **Investigate** symptoms
-   - Check logs for errors and warnings
-   - Add strategic logging/print statements
-   - Use debugger to inspect state (dlv, pdb, Chrome DevTools)
-   - Review recent changes with git log and git blame
-
-3. **Deduce** root cause
-   - Form hypotheses about the cause
-   - Test each hypothesis systematically
-   - Trace execution flow through code
-   - Check for common patterns: race conditions, null pointers, type mismatches
-
-4. **Document** findings
-   - Write clear description of root cause
-   - Note why the bug occurred
-   - Document steps taken to identify it
-   - Include relevant code snippets
-
-5. **Execute** solution
-   - Implement minimal fix
-   - Add regression test
-   - Verify fix resolves original issue
-   - Check for similar bugs elsewhere in codebase`
+// agentTemplatePath returns the workflow template for the subagent's category,
+// else for a word in its name, else the default one.
+func agentTemplatePath(data AgentTemplateData) string {
+	category := data.Category
+	if !agentTemplateExists(category) {
+		category = CategoryForAgentName(data.Name)
+		if category == "" {
+			category = "default"
+		}
+	}
+	return agentTemplateDir + "/" + category + ".md.tmpl"
+}
 
-	case strings.Contains(name, "security"):
-		return `### Security Audit Process
-
-1. **OWASP Top 10 Scan**
-   - **Injection**: Check for SQL, NoSQL, command, LDAP injection
-   - **Broken Authentication**: Review session management, password policies
-   - **Sensitive Data Exposure**: Check encryption at rest and in transit
-   - **XML External Entities**: Review XML parsers for XXE vulnerabilities
-   - **Broken Access Control**: Verify authorization checks
-   - **Security Misconfiguration**: Review headers, CORS, error messages
-   - **XSS**: Check for reflected, stored, and DOM-based XSS
-   - **Insecure Deserialization**: Review serialization libraries
-   - **Components with Known Vulnerabilities**: Scan dependencies
-   - **Insufficient Logging**: Verify security event logging
-
-2. **Dependency Analysis**
-   - Run npm audit, go mod verify, or safety check
-   - Check for outdated packages with known CVEs
-   - Review transitive dependencies
-   - Suggest version upgrades or patches
-
-3. **Authentication & Authorization**
-   - Review authentication mechanisms
-   - Check for proper password hashing (bcrypt, argon2)
-   - Verify JWT implementation and secret management
-   - Test authorization boundaries (can user A access user B's data?)
-
-4. **Provide Remediation**
-   - Reference specific OWASP guidelines
-   - Provide code examples for fixes
-   - Suggest security libraries and best practices
-   - Recommend automated security scanning tools`
-
-	case strings.Contains(name, "perf"):
-		return `### Performance Optimization Workflow
-
-1. **Establish Baseline**
-   - Run benchmarks to establish current performance
-   - Use profiling tools: go test -bench, perf, Chrome DevTools
-   - Identify performance goals (latency, throughput, memory)
-
-2. **Profile and Identify Bottlenecks**
-   - CPU profiling: Find hot code paths
-   - Memory profiling: Identify allocations and leaks
-   - I/O profiling: Check disk and network operations
-   - Database profiling: Use EXPLAIN for slow queries
-
-3. **Analyze Bottlenecks**
-   - **Algorithm complexity**: Is O(n²) algorithm causing slowdown?
-   - **Database issues**: N+1 queries, missing indexes, large result sets?
-   - **Memory issues**: Unnecessary allocations, large objects in memory?
-   - **I/O bottlenecks**: Synchronous operations blocking?
-
-4. **Optimize and Measure**
-   - Implement targeted optimizations
-   - Re-run benchmarks to measure improvement
-   - Ensure optimizations don't harm readability
-   - Document performance characteristics
-
-5. **Suggest Architectural Improvements**
-   - Caching strategies (Redis, in-memory)
-   - Database indexing and query optimization
-   - Async/parallel processing opportunities
-   - Load balancing and horizontal scaling`
-
-	case strings.Contains(name, "docs"):
-		return `### Documentation Workflow
-
-1. **Read and Understand Code**
-   - Read the code thoroughly to understand functionality
-   - Identify public APIs, interfaces, and entry points
-   - Note complex algorithms or non-obvious logic
-   - Check existing documentation for gaps
-
-2. **Generate Documentation**
-   - **README**: Project overview, installation, quick start
-   - **API Docs**: Function signatures, parameters, return values
-   - **Guides**: How-to guides for common tasks
-   - **Architecture**: System design, component relationships
-   - **Examples**: Code samples showing real usage
-
-3. **Follow Best Practices**
-   - Use clear, concise language
-   - Include code examples that actually work
-   - Document edge cases and limitations
-   - Add diagrams for complex flows (mermaid, PlantUML)
-   - Keep docs in sync with code
-
-4. **Update Existing Documentation**
-   - Mark deprecated APIs
-   - Update changed behavior
-   - Fix broken examples
-   - Add migration guides for breaking changes`
-
-	case strings.Contains(name, "release"):
-		return `### Release Preparation Workflow
-
-1. **Version Verification**
-   - Check version numbers in: package.json, go.mod, Cargo.toml, etc.
-   - Ensure semantic versioning (MAJOR.MINOR.PATCH)
-   - Update version in all relevant files
-
-2. **Generate Changelog**
-   - Run git log to review commits since last release
-   - Group changes by type: Features, Fixes, Breaking Changes
-   - Use conventional commits format if available
-   - Highlight notable changes and migration steps
-
-3. **Pre-Release Checks**
-   - Run full test suite: npm test, go test ./..., cargo test
-   - Run linters and formatters
-   - Build production artifacts
-   - Check for uncommitted changes
-   - Review security vulnerabilities
-
-4. **Create Release**
-   - Tag release: git tag -a v1.2.3 -m "Release v1.2.3"
-   - Push tags: git push --tags
-   - Create GitHub release with changelog
-   - Publish packages: npm publish, cargo publish
-
-5. **Post-Release**
-   - Verify package published successfully
-   - Update documentation with new version
-   - Announce release if applicable
-   - Monitor for issues in production`
-
-	case strings.Contains(name, "data"):
-		return `### Data Analysis Workflow
-
-1. **Understand Data Schema**
-   - Query schema: SHOW TABLES, DESCRIBE table
-   - Identify primary/foreign keys and relationships
-   - Check data types and constraints
-   - Review indexes and performance characteristics
-
-2. **Exploratory Analysis**
-   - Get row counts: SELECT COUNT(*) FROM table
-   - Check data distribution: MIN, MAX, AVG, percentiles
-   - Identify null values and data quality issues
-   - Sample data to understand patterns
-
-3. **Generate SQL Queries**
-   - Write optimized SELECT queries
-   - Use proper JOINs (INNER, LEFT, RIGHT)
-   - Add WHERE clauses for filtering
-   - Use GROUP BY for aggregations
-   - Apply HAVING for filtered aggregations
-   - Order and limit results appropriately
-
-4. **Data Transformations**
-   - Clean and normalize data
-   - Handle missing values
-   - Convert data types as needed
-   - Create derived columns
-
-5. **Provide Insights**
-   - Summarize key findings
-   - Identify trends and anomalies
-   - Suggest data quality improvements
-   - Recommend indexes for common queries`
+// CategoryForAgentName returns the category a word in a subagent's name suggests,
+// or "" when no word does.
+func CategoryForAgentName(name string) string {
+	for _, c := range agentNameCategories {
+		if strings.Contains(name, c.word) {
+			return c.category
+		}
+	}
+	return ""
+}
 
-	default:
-		return fmt.Sprintf("TODO: Define workflow for %s:\n1. Step 1\n2. Step 2\n3. Step 3", name)
+// agentTemplateExists reports whether category has a workflow template of its own.
+func agentTemplateExists(category string) bool {
+	if category == "" || strings.ContainsAny(category, "/\\.") {
+		return false
 	}
+	_, err := fs.Stat(Templates, agentTemplateDir+"/"+category+".md.tmpl")
+	return err == nil
 }
 
 // GenerateExamplesMarkdown creates usage examples in markdown format.
@@ -412,3 +232,55 @@ func GeneratePlaceholderSubagent(name string, outputPath string) GenerationResul
 	desc := AssetFileDescriptor{Name: name, Type: AssetTypeSubagent}
 	return GenerateSubagentAssetFile(desc, outputPath)
 }
+
+// CustomSubagent is a subagent authored in the TUI rather than loaded from a module.
+// It is stored in the persistence config so it can be offered again on later runs.
+type CustomSubagent struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Tools        []string `json:"tools,omitempty"` // Empty inherits every tool
+	Model        string   `json:"model,omitempty"` // Empty inherits the conversation's model
+	Instructions string   `json:"instructions"`
+}
+
+// SubagentTools lists the Claude Code tools a custom subagent can be granted.
+var SubagentTools = []string{
+	"Read", "Write", "Edit", "MultiEdit", "Grep", "Glob", "LS",
+	"Bash", "WebFetch", "WebSearch", "TodoWrite", "NotebookEdit",
+}
+
+var subagentNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidateSubagentName checks that name is usable as an agent file name and
+// frontmatter name: lowercase letters and digits separated by single hyphens.
+func ValidateSubagentName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !subagentNamePattern.MatchString(name) {
+		return fmt.Errorf("use lowercase letters, digits, and hyphens, e.g. api-designer")
+	}
+	return nil
+}
+
+// RenderCustomSubagent returns the agent markdown for a custom subagent.
+func RenderCustomSubagent(agent CustomSubagent) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "name: %s\n", agent.Name)
+	fmt.Fprintf(&b, "description: %s\n", strconv.Quote(strings.Join(strings.Fields(agent.Description), " ")))
+	if len(agent.Tools) > 0 {
+		fmt.Fprintf(&b, "tools: %s\n", strings.Join(agent.Tools, ", "))
+	}
+	if agent.Model != "" {
+		fmt.Fprintf(&b, "model: %s\n", agent.Model)
+	}
+	b.WriteString("---\n\n")
+	instructions := strings.TrimSpace(agent.Instructions)
+	if instructions == "" {
+		instructions = fmt.Sprintf("TODO: Define workflow for %s", agent.Name)
+	}
+	b.WriteString(instructions)
+	b.WriteString("\n")
+	return b.String()
+}
@@ -1,6 +1,7 @@
 package generation
 
-// AssetType represents the type of asset being generated.
+// AssetType represents the type of asset being generated. Types beyond the ones
+// below are added with RegisterAssetType.
 type AssetType int
 
 const (
@@ -16,12 +17,14 @@ const (
 	StatusSuccess GenerationStatus = iota
 	StatusPlaceholderGenerated
 	StatusFailed
+	StatusSkippedUnchanged // The file already held the generated content and was not written
 )
 
 // ComponentModule interface for accessing module properties.
 type ComponentModule interface {
 	GetDescription() string
 	GetCategory() string
+	GetTools() []string // Tools a subagent module declares; nil when it declares none
 }
 
 // AssetFileDescriptor represents metadata about a file to be generated.
@@ -40,6 +43,8 @@ type GenerationResult struct {
 	Error         error
 	BytesWritten  int
 	IsPlaceholder bool
+	Attempts      int  // Tries it took; see RetryPolicy
+	Retryable     bool // Whether Error is transient, so a later run may succeed
 }
 
 // GenerationReport summarizes batch file generation.
@@ -48,6 +53,7 @@ type GenerationReport struct {
 	Successful            int
 	PlaceholdersGenerated int
 	Failed                int
+	Unchanged             int
 	Results               []GenerationResult
 	FailedDescriptors     []AssetFileDescriptor
 }
@@ -58,6 +64,27 @@ type OverwriteWarning struct {
 	ConfirmedByUser bool
 }
 
+// Add records result in the report's results and counts. TotalFiles is left as it
+// is, and so are FailedDescriptors.
+func (r *GenerationReport) Add(result GenerationResult) {
+	r.Results = append(r.Results, result)
+	r.count(result)
+}
+
+// count adds result to the report's counts.
+func (r *GenerationReport) count(result GenerationResult) {
+	switch result.Status {
+	case StatusSuccess:
+		r.Successful++
+	case StatusPlaceholderGenerated:
+		r.PlaceholdersGenerated++
+	case StatusFailed:
+		r.Failed++
+	case StatusSkippedUnchanged:
+		r.Unchanged++
+	}
+}
+
 // HasFailures returns true if any files failed to generate.
 func (r *GenerationReport) HasFailures() bool {
 	return r.Failed > 0
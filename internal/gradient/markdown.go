@@ -229,3 +229,16 @@ func GenerateGlamourStyle(palette paletteType) *glamour.TermRenderer {
 
 	return renderer
 }
+
+// PlainGlamourRenderer renders markdown with glamour's notty style, which uses no
+// color or other escape codes, for NO_COLOR terminals and screen readers.
+func PlainGlamourRenderer() *glamour.TermRenderer {
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle("notty"),
+		glamour.WithWordWrap(60),
+	)
+	if err != nil {
+		return nil // Fallback to nil, caller will handle
+	}
+	return renderer
+}
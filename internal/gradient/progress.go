@@ -0,0 +1,137 @@
+package gradient
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DefaultProgressWidth is the width of a progress bar, without its count and label.
+const DefaultProgressWidth = 40
+
+// ProgressMsg reports that Done of a ProgressBar's steps are complete, the last
+// of them being Label.
+type ProgressMsg struct {
+	Done  int
+	Label string
+}
+
+// ProgressBar is a Bubble Tea component drawing a bar filled with the theme's
+// gradient, followed by a count of the steps done and the last one finished. The
+// gradient spans the whole bar, so each cell keeps its color as the bar fills.
+type ProgressBar struct {
+	Theme      Theme
+	Capability TerminalCapability
+	Width      int
+	Total      int
+
+	done  int
+	label string
+}
+
+// NewProgressBar returns an empty progress bar for total steps.
+func NewProgressBar(theme Theme, capability TerminalCapability, total int) ProgressBar {
+	return ProgressBar{Theme: theme, Capability: capability, Width: DefaultProgressWidth, Total: total}
+}
+
+// Update records a ProgressMsg.
+func (p ProgressBar) Update(msg tea.Msg) (ProgressBar, tea.Cmd) {
+	if msg, ok := msg.(ProgressMsg); ok {
+		p.done = min(max(msg.Done, 0), p.Total)
+		p.label = msg.Label
+	}
+	return p, nil
+}
+
+// Percent returns the share of the steps that are done, from 0 to 1.
+func (p ProgressBar) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.done) / float64(p.Total)
+}
+
+// View draws the bar.
+func (p ProgressBar) View() string {
+	filled := int(p.Percent() * float64(p.Width))
+	bar := ansi.Truncate(RenderGradient(strings.Repeat("█", p.Width), p.Theme, p.Capability, true), filled, "")
+	bar += lipgloss.NewStyle().Faint(true).Render(strings.Repeat("░", p.Width-filled))
+	view := fmt.Sprintf("%s %d/%d", bar, p.done, p.Total)
+	if p.label != "" {
+		view += " " + p.label
+	}
+	return view
+}
+
+// spinnerFrames are the frames a Spinner cycles through.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how long a Spinner shows each frame.
+const spinnerInterval = 80 * time.Millisecond
+
+// spinnerIDs numbers spinners, so each only advances on its own ticks.
+var spinnerIDs atomic.Int64
+
+// SpinnerTickMsg advances the Spinner that scheduled it.
+type SpinnerTickMsg struct {
+	id int64
+}
+
+// Spinner is a Bubble Tea component drawing a braille spinner whose color sweeps
+// across the theme's gradient and back. Start it with the command Tick returns.
+type Spinner struct {
+	Theme      Theme
+	Capability TerminalCapability
+
+	id    int64
+	frame int
+}
+
+// NewSpinner returns a spinner drawn in theme's colors.
+func NewSpinner(theme Theme, capability TerminalCapability) Spinner {
+	return Spinner{Theme: theme, Capability: capability, id: spinnerIDs.Add(1)}
+}
+
+// Tick schedules the spinner's next frame.
+func (s Spinner) Tick() tea.Cmd {
+	id := s.id
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg {
+		return SpinnerTickMsg{id: id}
+	})
+}
+
+// Update advances the spinner on its own SpinnerTickMsg and schedules the next one.
+func (s Spinner) Update(msg tea.Msg) (Spinner, tea.Cmd) {
+	if msg, ok := msg.(SpinnerTickMsg); ok && msg.id == s.id {
+		s.frame++
+		return s, s.Tick()
+	}
+	return s, nil
+}
+
+// View draws the current frame.
+func (s Spinner) View() string {
+	frame := spinnerFrames[s.frame%len(spinnerFrames)]
+	if s.Capability == NoColor {
+		return frame
+	}
+
+	// One sweep takes a full turn of the frames; the next one comes back
+	n := len(spinnerFrames)
+	position := s.frame % (2 * n)
+	if position >= n {
+		position = 2*n - position
+	}
+	dark := lipgloss.HasDarkBackground()
+	color := QuantizeColor(InterpolateColor(
+		backgroundColor(s.Theme.StartColor, dark),
+		backgroundColor(s.Theme.EndColor, dark),
+		float64(position)/float64(n),
+	), s.Capability)
+	return lipgloss.NewStyle().Foreground(color).Render(frame)
+}
@@ -0,0 +1,94 @@
+package gradient
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// cubeLevels are the channel values of the xterm-256 6×6×6 color cube (16-231).
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// ansiColors are xterm's default RGB values for the eight ANSI colors, by index:
+// black, red, green, yellow, blue, magenta, cyan, white.
+var ansiColors = [8][3]int{
+	{0, 0, 0},
+	{205, 0, 0},
+	{0, 205, 0},
+	{205, 205, 0},
+	{0, 0, 238},
+	{205, 0, 205},
+	{0, 205, 205},
+	{229, 229, 229},
+}
+
+// QuantizeColor maps a hex color to the nearest color the terminal can show: an
+// xterm-256 index for Color256 and an ANSI color index for Color8. Truecolor and
+// colors that are not hex are returned unchanged.
+func QuantizeColor(color lipgloss.Color, capability TerminalCapability) lipgloss.Color {
+	var r, g, b int
+	if n, _ := fmt.Sscanf(string(color), "#%02x%02x%02x", &r, &g, &b); n != 3 {
+		return color
+	}
+	switch capability {
+	case Color256:
+		return lipgloss.Color(strconv.Itoa(nearestXterm256(r, g, b)))
+	case Color8:
+		return lipgloss.Color(strconv.Itoa(nearestANSI(r, g, b)))
+	}
+	return color
+}
+
+// nearestXterm256 returns the index of the color cube entry or grayscale ramp
+// entry (232-255) closest to r, g, b. The first 16 colors are left out because
+// terminal themes redefine them.
+func nearestXterm256(r, g, b int) int {
+	ri, gi, bi := nearestCubeLevel(r), nearestCubeLevel(g), nearestCubeLevel(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDistance := distance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// The ramp runs from 8 to 238 in steps of 10
+	gray := ((r+g+b)/3 - 3) / 10
+	gray = min(max(gray, 0), 23)
+	level := 8 + 10*gray
+	if distance(r, g, b, level, level, level) < cubeDistance {
+		return 232 + gray
+	}
+	return cube
+}
+
+// nearestCubeLevel returns the index of the cube level closest to v.
+func nearestCubeLevel(v int) int {
+	best := 0
+	for i, level := range cubeLevels {
+		if abs(v-level) < abs(v-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// nearestANSI returns the index of the ANSI color closest to r, g, b.
+func nearestANSI(r, g, b int) int {
+	best, bestDistance := 0, -1
+	for i, c := range ansiColors {
+		if d := distance(r, g, b, c[0], c[1], c[2]); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// distance is the squared euclidean distance between two RGB colors.
+func distance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
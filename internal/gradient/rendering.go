@@ -2,8 +2,10 @@ package gradient
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 // ApplyGradient creates a Lipgloss style with gradient.
@@ -14,7 +16,7 @@ func ApplyGradient(theme Theme, capability TerminalCapability) lipgloss.Style {
 	// Note: Full gradient rendering happens in RenderGradient()
 	// This creates a style reference for the theme
 	style := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(theme.StartColor.Dark))
+		Foreground(QuantizeColor(backgroundColor(theme.StartColor, lipgloss.HasDarkBackground()), capability))
 
 	// Adjust intensity (not fully implemented for brevity, would affect alpha/saturation)
 	_ = stops
@@ -22,12 +24,56 @@ func ApplyGradient(theme Theme, capability TerminalCapability) lipgloss.Style {
 	return style
 }
 
-// RenderGradient renders text with gradient colors applied.
+// renderCacheSize bounds the gradient render cache. A theme transition renders
+// each frame with a new theme, so the cache is emptied when it fills up.
+const renderCacheSize = 256
+
+// renderKey identifies a gradient rendering. The color profile is part of it
+// because lipgloss downsamples colors to the profile while rendering.
+type renderKey struct {
+	text       string
+	theme      Theme
+	capability TerminalCapability
+	foreground bool
+	profile    termenv.Profile
+	dark       bool
+}
+
+var (
+	renderCacheMu sync.Mutex
+	renderCache   = make(map[renderKey]string)
+)
+
+// RenderGradient renders text with gradient colors applied. Renderings are
+// memoized, so redrawing an unchanged title or border costs a map lookup.
 func RenderGradient(text string, theme Theme, capability TerminalCapability, foreground bool) string {
-	if text == "" {
-		return ""
+	if text == "" || capability == NoColor {
+		return text
 	}
 
+	dark := lipgloss.HasDarkBackground()
+	key := renderKey{text, theme, capability, foreground, lipgloss.ColorProfile(), dark}
+	renderCacheMu.Lock()
+	rendered, ok := renderCache[key]
+	renderCacheMu.Unlock()
+	if ok {
+		return rendered
+	}
+
+	rendered = renderGradient(text, theme, capability, foreground, dark)
+	renderCacheMu.Lock()
+	if len(renderCache) >= renderCacheSize {
+		clear(renderCache)
+	}
+	renderCache[key] = rendered
+	renderCacheMu.Unlock()
+	return rendered
+}
+
+// renderGradient interpolates the gradient across text in segments, one per stop,
+// between the theme's colors for a dark or light background.
+func renderGradient(text string, theme Theme, capability TerminalCapability, foreground, dark bool) string {
+
 	stops := QuantizeStops(capability, theme.Stops)
 	if stops < 2 {
 		stops = 2
@@ -51,12 +97,12 @@ func RenderGradient(text string, theme Theme, capability TerminalCapability, for
 		segment := string(runes[i:end])
 		progress := float64(i) / float64(len(runes))
 
-		// Interpolate color for this segment
-		color := InterpolateColor(
-			lipgloss.Color(theme.StartColor.Dark),
-			lipgloss.Color(theme.EndColor.Dark),
+		// Interpolate color for this segment, then snap it to the terminal's palette
+		color := QuantizeColor(InterpolateColor(
+			backgroundColor(theme.StartColor, dark),
+			backgroundColor(theme.EndColor, dark),
 			progress,
-		)
+		), capability)
 
 		// Apply color and render
 		var styled string
@@ -71,6 +117,15 @@ func RenderGradient(text string, theme Theme, capability TerminalCapability, for
 	return result.String()
 }
 
+// backgroundColor picks the variant of c for a dark or light terminal background,
+// falling back to the other variant when that one is unset.
+func backgroundColor(c lipgloss.AdaptiveColor, dark bool) lipgloss.Color {
+	if dark && c.Dark != "" || c.Light == "" {
+		return lipgloss.Color(c.Dark)
+	}
+	return lipgloss.Color(c.Light)
+}
+
 // RenderASCIITitle applies gradient to ASCII art line-by-line.
 func RenderASCIITitle(asciiArt string, theme Theme, capability TerminalCapability) string {
 	lines := strings.Split(asciiArt, "\n")
@@ -1,12 +1,18 @@
 package gradient
 
 import (
+	"fmt"
 	"os"
 	"strings"
 )
 
-// detectTerminalCapability detects the terminal's color support level.
+// detectTerminalCapability detects the terminal's color support level. A non-empty
+// NO_COLOR turns color off whatever the terminal supports (https://no-color.org).
 func DetectTerminalCapability() TerminalCapability {
+	if os.Getenv("NO_COLOR") != "" {
+		return NoColor
+	}
+
 	colorterm := os.Getenv("COLORTERM")
 	if colorterm == "truecolor" || colorterm == "24bit" {
 		return Truecolor
@@ -20,6 +26,22 @@ func DetectTerminalCapability() TerminalCapability {
 	return Color8 // Conservative fallback
 }
 
+// ParseCapability parses a capability name: "truecolor" (or "24bit"), "256", "8", or
+// "none".
+func ParseCapability(s string) (TerminalCapability, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "truecolor", "24bit":
+		return Truecolor, nil
+	case "256", "256color":
+		return Color256, nil
+	case "8", "ansi":
+		return Color8, nil
+	case "none", "nocolor":
+		return NoColor, nil
+	}
+	return Color8, fmt.Errorf("unknown terminal capability %q (want truecolor, 256, 8, or none)", s)
+}
+
 // QuantizeStops reduces gradient stops for limited terminals.
 func QuantizeStops(capability TerminalCapability, desiredStops int) int {
 	switch capability {
@@ -1,6 +1,8 @@
 package gradient
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
@@ -52,10 +54,87 @@ func InitGradientPalettes() paletteType {
 	}
 }
 
-// InitStyleMap populates component/state style mappings.
+// DefaultThemeName names the palette InitGradientPalettes returns.
+const DefaultThemeName = "neon"
+
+// themePalettes are the palette presets a theme name selects, in the order ThemeNames
+// lists them.
+var themePalettes = []struct {
+	name    string
+	palette func() paletteType
+}{
+	{DefaultThemeName, InitGradientPalettes},
+	{"synthwave", synthwavePalette},
+	{"solarized", solarizedPalette},
+	{"mono", monoPalette},
+}
+
+// ThemeNames lists the palette presets, the default first.
+func ThemeNames() []string {
+	names := make([]string, len(themePalettes))
+	for i, t := range themePalettes {
+		names[i] = t.name
+	}
+	return names
+}
+
+// PaletteByName returns the palette preset called name; "" selects the default.
+func PaletteByName(name string) (paletteType, error) {
+	if name == "" {
+		name = DefaultThemeName
+	}
+	for _, t := range themePalettes {
+		if t.name == name {
+			return t.palette(), nil
+		}
+	}
+	return paletteType{}, fmt.Errorf("unknown theme %q (want %s)", name, strings.Join(ThemeNames(), ", "))
+}
+
+// synthwavePalette is hot pink fading to violet, after 80s neon signage.
+func synthwavePalette() paletteType {
+	return paletteType{
+		primary:    lipgloss.AdaptiveColor{Light: "#C2185B", Dark: "#FF71CE"},
+		secondary:  lipgloss.AdaptiveColor{Light: "#5E35B1", Dark: "#B967FF"},
+		accent:     lipgloss.AdaptiveColor{Light: "#0097A7", Dark: "#01CDFE"},
+		error:      lipgloss.AdaptiveColor{Light: "#D63031", Dark: "#FF7675"},
+		success:    lipgloss.AdaptiveColor{Light: "#00897B", Dark: "#05FFA1"},
+		background: lipgloss.AdaptiveColor{Light: "#F3E5F5", Dark: "#241734"},
+	}
+}
+
+// solarizedPalette uses Ethan Schoonover's Solarized accent colors, which read the
+// same on light and dark backgrounds.
+func solarizedPalette() paletteType {
+	return paletteType{
+		primary:    lipgloss.AdaptiveColor{Light: "#268BD2", Dark: "#268BD2"},
+		secondary:  lipgloss.AdaptiveColor{Light: "#2AA198", Dark: "#2AA198"},
+		accent:     lipgloss.AdaptiveColor{Light: "#B58900", Dark: "#B58900"},
+		error:      lipgloss.AdaptiveColor{Light: "#DC322F", Dark: "#DC322F"},
+		success:    lipgloss.AdaptiveColor{Light: "#859900", Dark: "#859900"},
+		background: lipgloss.AdaptiveColor{Light: "#FDF6E3", Dark: "#002B36"},
+	}
+}
+
+// monoPalette is grayscale, for users who find the gradients distracting.
+func monoPalette() paletteType {
+	return paletteType{
+		primary:    lipgloss.AdaptiveColor{Light: "#212121", Dark: "#FAFAFA"},
+		secondary:  lipgloss.AdaptiveColor{Light: "#757575", Dark: "#9E9E9E"},
+		accent:     lipgloss.AdaptiveColor{Light: "#424242", Dark: "#E0E0E0"},
+		error:      lipgloss.AdaptiveColor{Light: "#616161", Dark: "#BDBDBD"},
+		success:    lipgloss.AdaptiveColor{Light: "#424242", Dark: "#E0E0E0"},
+		background: lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#121212"},
+	}
+}
+
+// InitStyleMap populates component/state style mappings from the default palette.
 func InitStyleMap() map[ComponentType]map[VisualState]ComponentStyle {
-	palettes := InitGradientPalettes()
+	return StyleMapForPalette(InitGradientPalettes())
+}
 
+// StyleMapForPalette populates component/state style mappings from palettes.
+func StyleMapForPalette(palettes paletteType) map[ComponentType]map[VisualState]ComponentStyle {
 	styleMap := make(map[ComponentType]map[VisualState]ComponentStyle)
 
 	// Define default themes for each component
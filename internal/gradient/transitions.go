@@ -2,14 +2,16 @@ package gradient
 
 import (
 	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
 )
 
 // InterpolateGradient interpolates between two gradient themes.
 func InterpolateGradient(from, to Theme, progress float64) Theme {
 	return Theme{
 		Name:       fmt.Sprintf("interpolated-%f", progress),
-		StartColor: from.StartColor, // AdaptiveColor interpolation complex, keep from
-		EndColor:   from.EndColor,
+		StartColor: interpolateAdaptive(from.StartColor, to.StartColor, progress),
+		EndColor:   interpolateAdaptive(from.EndColor, to.EndColor, progress),
 		Stops:      int(float64(from.Stops) + float64(to.Stops-from.Stops)*progress),
 		Direction:  from.Direction,
 		Intensity:  from.Intensity + (to.Intensity-from.Intensity)*progress,
@@ -23,3 +25,15 @@ func EaseInOutCubic(t float64) float64 {
 	}
 	return 1 - ((-2*t+2)*(-2*t+2)*(-2*t+2))/2
 }
+
+// interpolateAdaptive blends the light and dark variants separately. A theme without
+// colors on either side keeps from's, since there is nothing to blend toward.
+func interpolateAdaptive(from, to lipgloss.AdaptiveColor, progress float64) lipgloss.AdaptiveColor {
+	if from.Light == "" || from.Dark == "" || to.Light == "" || to.Dark == "" {
+		return from
+	}
+	return lipgloss.AdaptiveColor{
+		Light: string(InterpolateColor(lipgloss.Color(from.Light), lipgloss.Color(to.Light), progress)),
+		Dark:  string(InterpolateColor(lipgloss.Color(from.Dark), lipgloss.Color(to.Dark), progress)),
+	}
+}
@@ -10,9 +10,10 @@ import (
 type TerminalCapability int
 
 const (
-	Color8     TerminalCapability = iota // 8 ANSI colors
-	Color256                              // 256-color palette
-	Truecolor                             // 24-bit RGB
+	Color8    TerminalCapability = iota // 8 ANSI colors
+	Color256                            // 256-color palette
+	Truecolor                           // 24-bit RGB
+	NoColor                             // NO_COLOR: plain text without escape codes
 )
 
 // Direction defines gradient orientation.
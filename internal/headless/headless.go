@@ -0,0 +1,220 @@
+// Package headless runs a Bubble Tea model without a terminal, so tests can press
+// keys, resize the window, and read back what the model draws as plain text. Commands
+// the model returns are run as a program would run them, except that ones still
+// waiting after Timeout, such as cursor blinks and other timers, are dropped.
+package headless
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DefaultTimeout is how long a Program waits for a command. It is long enough for
+// a command doing work and short enough to drop a cursor blink or a spinner tick.
+var DefaultTimeout = 50 * time.Millisecond
+
+// maxRounds bounds how many times a Program runs the commands updates return before
+// it decides the model never settles.
+const maxRounds = 1000
+
+// Program drives a model. Create one with New.
+type Program struct {
+	Timeout time.Duration // How long to wait for each command; DefaultTimeout when zero
+
+	t     testing.TB
+	model tea.Model
+	quit  bool
+}
+
+// New starts model as a program in a width x height terminal would: it runs the
+// model's Init commands, then sends the window size.
+func New(t testing.TB, model tea.Model, width, height int) *Program {
+	t.Helper()
+	p := &Program{t: t, model: model}
+	p.run(model.Init())
+	p.Resize(width, height)
+	return p
+}
+
+// Model returns the model as the last update left it.
+func (p *Program) Model() tea.Model {
+	return p.model
+}
+
+// Quit reports whether the model has quit. A program that quit ignores messages.
+func (p *Program) Quit() bool {
+	return p.quit
+}
+
+// Send updates the model with each message in turn, running the commands each
+// update returns until the model settles.
+func (p *Program) Send(msgs ...tea.Msg) {
+	p.t.Helper()
+	for _, msg := range msgs {
+		if p.quit {
+			return
+		}
+		var cmd tea.Cmd
+		p.model, cmd = p.model.Update(msg)
+		p.run(cmd)
+	}
+}
+
+// Resize sends the terminal size.
+func (p *Program) Resize(width, height int) {
+	p.t.Helper()
+	p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Press sends a key press for each name, spelled as tea.KeyMsg.String spells it,
+// such as "enter", "shift+tab", "ctrl+c", "space", or "x". An unknown name fails the
+// test.
+func (p *Program) Press(names ...string) {
+	p.t.Helper()
+	for _, name := range names {
+		key, err := Key(name)
+		if err != nil {
+			p.t.Fatal(err)
+		}
+		p.Send(key)
+	}
+}
+
+// Type sends a key press for each character of text.
+func (p *Program) Type(text string) {
+	p.t.Helper()
+	for _, r := range text {
+		p.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// View returns what the model draws, without escape sequences or the spaces that
+// pad each line.
+func (p *Program) View() string {
+	lines := strings.Split(ansi.Strip(p.model.View()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// keyTypes maps the names of Bubble Tea's special keys to their types.
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{"space": tea.KeySpace}
+	for k := tea.KeyType(-200); k <= 127; k++ {
+		if name := k.String(); name != "" && k != tea.KeyRunes {
+			types[name] = k
+		}
+	}
+	return types
+}()
+
+// Key returns the key press name spells; see Program.Press.
+func Key(name string) (tea.KeyMsg, error) {
+	base, alt := strings.CutPrefix(name, "alt+")
+	if base == "" {
+		base, alt = name, false
+	}
+	if k, ok := keyTypes[base]; ok {
+		return tea.KeyMsg{Type: k, Alt: alt}, nil
+	}
+	if runes := []rune(base); len(runes) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes, Alt: alt}, nil
+	}
+	return tea.KeyMsg{}, fmt.Errorf("headless: unknown key %q", name)
+}
+
+// run runs cmd and the commands the updates it leads to return, one round of
+// commands at a time, until none are left or the model quits.
+func (p *Program) run(cmd tea.Cmd) {
+	p.t.Helper()
+	cmds := []tea.Cmd{cmd}
+	for round := 0; len(cmds) > 0; round++ {
+		if round == maxRounds {
+			p.t.Fatalf("headless: the model was still returning commands after %d rounds", maxRounds)
+		}
+		msgs := p.collect(cmds)
+		cmds = nil
+		for _, msg := range msgs {
+			if p.quit {
+				return
+			}
+			switch msg := msg.(type) {
+			case tea.QuitMsg:
+				p.quit = true
+				continue
+			case tea.BatchMsg:
+				cmds = append(cmds, msg...)
+				continue
+			}
+			// tea.Sequence returns an unexported slice of commands; their order is
+			// kept by collect, which is what a sequence is for here
+			if seq, ok := commands(msg); ok {
+				cmds = append(cmds, seq...)
+				continue
+			}
+			var next tea.Cmd
+			p.model, next = p.model.Update(msg)
+			cmds = append(cmds, next)
+		}
+	}
+}
+
+// collect runs cmds at the same time and returns the messages of those that finish
+// within the timeout, in the order of cmds.
+func (p *Program) collect(cmds []tea.Cmd) []tea.Msg {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	results := make([]chan tea.Msg, len(cmds))
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		results[i] = make(chan tea.Msg, 1)
+		go func(out chan<- tea.Msg) { out <- cmd() }(results[i])
+	}
+
+	deadline := time.After(timeout)
+	expired := false
+	var msgs []tea.Msg
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		var msg tea.Msg
+		select {
+		case msg = <-result:
+		default:
+			// Anything still running after the deadline is a timer
+			if !expired {
+				select {
+				case msg = <-result:
+				case <-deadline:
+					expired = true
+				}
+			}
+		}
+		if msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// commands returns the commands msg holds when it is a slice of them.
+func commands(msg tea.Msg) ([]tea.Cmd, bool) {
+	v := reflect.ValueOf(msg)
+	cmdsType := reflect.TypeOf([]tea.Cmd(nil))
+	if !v.IsValid() || v.Kind() != reflect.Slice || !v.Type().ConvertibleTo(cmdsType) {
+		return nil, false
+	}
+	return v.Convert(cmdsType).Interface().([]tea.Cmd), true
+}
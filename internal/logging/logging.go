@@ -0,0 +1,169 @@
+// Package logging sets up claudekit's log: warnings on the terminal in the
+// "warning: ..." form claudekit has always printed, more or less of them with
+// --verbose and --quiet, and everything in an optional log file.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Options selects what is logged where.
+type Options struct {
+	Verbose bool   // Also show info and debug messages on the terminal
+	Quiet   bool   // Show only errors on the terminal
+	File    string // Append every message, at every level, to this file
+}
+
+// TerminalLevel is the lowest level shown on the terminal.
+func (o Options) TerminalLevel() slog.Level {
+	switch {
+	case o.Quiet:
+		return slog.LevelError
+	case o.Verbose:
+		return slog.LevelDebug
+	}
+	return slog.LevelWarn
+}
+
+// Setup makes the default slog logger write to stderr, and to opts.File when set.
+// The returned function closes the log file.
+func Setup(stderr io.Writer, opts Options) (func() error, error) {
+	handlers := []slog.Handler{NewTerminalHandler(stderr, opts.TerminalLevel())}
+	closeFile := func() error { return nil }
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return closeFile, fmt.Errorf("cannot open log file: %w", err)
+		}
+		handlers = append(handlers, slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		closeFile = f.Close
+	}
+	slog.SetDefault(slog.New(fanout(handlers)))
+	return closeFile, nil
+}
+
+// Stderr writes to os.Stderr as it is at the time of each write, so the terminal
+// log follows stderr when it is redirected after Setup.
+var Stderr io.Writer = stderr{}
+
+type stderr struct{}
+
+func (stderr) Write(p []byte) (int, error) {
+	return os.Stderr.Write(p)
+}
+
+// TerminalHandler writes records as "level: message key=value ...", one per line.
+type TerminalHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+// NewTerminalHandler returns a handler writing records at level or above to w.
+func NewTerminalHandler(w io.Writer, level slog.Leveler) *TerminalHandler {
+	return &TerminalHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *TerminalHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *TerminalHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(levelName(r.Level))
+	b.WriteString(": ")
+	b.WriteString(r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		if !a.Equal(slog.Attr{}) {
+			fmt.Fprintf(&b, " %s=%s", a.Key, quoteValue(a.Value.String()))
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *TerminalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(clone.attrs[:len(clone.attrs):len(clone.attrs)], attrs...)
+	return &clone
+}
+
+// WithGroup is not used by claudekit; grouped attributes are written unqualified.
+func (h *TerminalHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func levelName(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warning"
+	case level >= slog.LevelInfo:
+		return "info"
+	}
+	return "debug"
+}
+
+// quoteValue quotes values that would otherwise run into the next attribute.
+func quoteValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// fanout sends each record to every handler that accepts its level.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithAttrs(attrs)
+	}
+	return next
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithGroup(name)
+	}
+	return next
+}
@@ -0,0 +1,305 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"jeremyclewell.com/claudekit/internal/migrate"
+)
+
+// FileName is the manifest file written inside the .claude directory.
+const FileName = ".claudekit-manifest.json"
+
+// SchemaVersion is the current manifest format version.
+const SchemaVersion = 1
+
+// migrations upgrades manifests from each earlier format. Add one for every change
+// that would make an older manifest read differently, and bump SchemaVersion with it.
+var migrations = migrate.Plan{
+	Name:    "manifest",
+	Current: SchemaVersion,
+	Migrations: []migrate.Migration{
+		{
+			From:        0,
+			Description: "record the format version",
+			// Manifests from before versioning have the same fields
+			Apply: func(doc map[string]any) error { return nil },
+		},
+	},
+}
+
+// ErrNotFound is returned by Load when no manifest exists.
+var ErrNotFound = errors.New("no claudekit manifest found")
+
+// FileKind classifies a generated file.
+type FileKind string
+
+const (
+	KindClaudeMD       FileKind = "claude-md"
+	KindAgent          FileKind = "agent"
+	KindHook           FileKind = "hook"
+	KindHookLib        FileKind = "hook-lib"
+	KindCommand        FileKind = "command"
+	KindSettings       FileKind = "settings"
+	KindMCP            FileKind = "mcp"
+	KindStyle          FileKind = "output-style"
+	KindSetupDoc       FileKind = "setup-doc"
+	KindReadmeSection  FileKind = "readme-section" // Shared with the user; only the setup section is ours
+	KindStatusline     FileKind = "statusline"
+	KindVSCodeTasks    FileKind = "vscode-tasks" // Shared with the user; only EditorTasks are ours
+	KindRunConfig      FileKind = "run-config"
+	KindGitHubWorkflow FileKind = "github-workflow"
+	KindDevcontainer   FileKind = "devcontainer"
+	KindClaudeLocal    FileKind = "claude-local" // Seeded once; the user's from then on
+	KindMemory         FileKind = "memory"       // Seeded once; the user's from then on
+	KindGitignore      FileKind = "gitignore"    // Shared with the user; only the marked block is ours
+)
+
+// Entry records a single file claudekit generated.
+type Entry struct {
+	Path          string   `json:"path"` // Relative to the base directory, slash-separated
+	Kind          FileKind `json:"kind"`
+	Module        string   `json:"module,omitempty"`
+	SHA256        string   `json:"sha256,omitempty"`         // Hash of the content as generated
+	SourceVersion string   `json:"source_version,omitempty"` // Version of the module set that produced it
+	ModuleVersion string   `json:"module_version,omitempty"` // Version of Module, when it declares one
+
+	// Assets lists the assets of Module read from a path of its own, by the path it
+	// declares, when renaming settled an asset conflict between modules.
+	Assets map[string]string `json:"assets,omitempty"`
+}
+
+// SettingsOwnership records which settings.json keys claudekit wrote, so they can be
+// removed without touching user-authored settings.
+type SettingsOwnership struct {
+	Hooks map[string][]string `json:"hooks,omitempty"` // Event -> hook commands
+	Allow []string            `json:"allow,omitempty"`
+	Ask   []string            `json:"ask,omitempty"`
+	Deny  []string            `json:"deny,omitempty"`
+	Env   []string            `json:"env,omitempty"`
+
+	OutputStyle string `json:"output_style,omitempty"` // Value claudekit set for outputStyle
+	StatusLine  string `json:"status_line,omitempty"`  // Command claudekit set for statusLine
+}
+
+// Layout records where generated components are written, as slash-separated paths
+// relative to the base directory. Empty fields mean the Claude Code default.
+type Layout struct {
+	Agents   string `json:"agents,omitempty"`
+	Hooks    string `json:"hooks,omitempty"`
+	Commands string `json:"commands,omitempty"`
+}
+
+// DefaultLayout is where Claude Code looks for agents, hooks, and commands.
+func DefaultLayout() Layout {
+	return Layout{
+		Agents:   ".claude/agents",
+		Hooks:    ".claude/hooks",
+		Commands: ".claude/commands",
+	}
+}
+
+// WithDefaults fills any unset directory from DefaultLayout and cleans the rest.
+func (l Layout) WithDefaults() Layout {
+	def := DefaultLayout()
+	pick := func(dir, fallback string) string {
+		if strings.TrimSpace(dir) == "" {
+			return fallback
+		}
+		return filepath.ToSlash(filepath.Clean(dir))
+	}
+	return Layout{
+		Agents:   pick(l.Agents, def.Agents),
+		Hooks:    pick(l.Hooks, def.Hooks),
+		Commands: pick(l.Commands, def.Commands),
+	}
+}
+
+// Validate rejects directories that are absolute or escape the base directory.
+func (l Layout) Validate() error {
+	for name, dir := range map[string]string{"agents": l.Agents, "hooks": l.Hooks, "commands": l.Commands} {
+		if dir == "" {
+			continue
+		}
+		clean := filepath.Clean(filepath.FromSlash(dir))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("layout %s directory %q must be relative to the project", name, dir)
+		}
+	}
+	return nil
+}
+
+// Dir returns the absolute directory for a layout path.
+func Dir(baseDir, layoutPath string) string {
+	return filepath.Join(baseDir, filepath.FromSlash(layoutPath))
+}
+
+// Manifest tracks everything claudekit generated in a base directory.
+type Manifest struct {
+	SchemaVersion    int               `json:"schema_version"`
+	GeneratorVersion string            `json:"generator_version"`
+	GeneratedAt      time.Time         `json:"generated_at"`
+	Layout           Layout            `json:"layout"`
+	Files            []Entry           `json:"files"`
+	Settings         SettingsOwnership `json:"settings"`
+	MCPServers       []string          `json:"mcp_servers,omitempty"`
+	EditorTasks      []string          `json:"editor_tasks,omitempty"` // Labels of tasks claudekit wrote to .vscode/tasks.json
+	Packages         []string          `json:"packages,omitempty"`     // Workspace packages, relative and slash-separated, with their own manifest
+}
+
+// New creates an empty manifest stamped with the generator version.
+func New(generatorVersion string) *Manifest {
+	return &Manifest{
+		SchemaVersion:    SchemaVersion,
+		GeneratorVersion: generatorVersion,
+		GeneratedAt:      time.Now(),
+		Layout:           DefaultLayout(),
+		Settings:         SettingsOwnership{Hooks: map[string][]string{}},
+	}
+}
+
+// Path returns the manifest location for a base directory.
+func Path(baseDir string) string {
+	return filepath.Join(baseDir, ".claude", FileName)
+}
+
+// RelPath converts absPath to the slash-separated form stored in entries.
+func RelPath(baseDir, absPath string) string {
+	rel, err := filepath.Rel(baseDir, absPath)
+	if err != nil {
+		rel = absPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// HashContent returns the hex-encoded SHA-256 of content.
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// AddFile records a generated file, the version of the module it came from, the
+// renamed assets it was read from, and the hash of its content. absPath must be
+// inside baseDir.
+func (m *Manifest) AddFile(baseDir, absPath string, kind FileKind, module, moduleVersion string, assets map[string]string, content []byte) {
+	m.Put(Entry{
+		Path:          RelPath(baseDir, absPath),
+		Kind:          kind,
+		Module:        module,
+		SHA256:        HashContent(content),
+		SourceVersion: m.GeneratorVersion,
+		ModuleVersion: moduleVersion,
+		Assets:        assets,
+	})
+}
+
+// Put adds or replaces an entry by path.
+func (m *Manifest) Put(entry Entry) {
+	for i, e := range m.Files {
+		if e.Path == entry.Path {
+			m.Files[i] = entry
+			return
+		}
+	}
+	m.Files = append(m.Files, entry)
+}
+
+// Lookup finds the entry for a slash-separated relative path.
+func (m *Manifest) Lookup(rel string) (Entry, bool) {
+	if m == nil {
+		return Entry{}, false
+	}
+	for _, e := range m.Files {
+		if e.Path == rel {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// IsModified reports whether the file on disk no longer matches the hash recorded at
+// generation time. Missing files and entries without a hash are not considered modified.
+func (e Entry) IsModified(baseDir string) (bool, error) {
+	if e.SHA256 == "" {
+		return false, nil
+	}
+	data, err := os.ReadFile(e.AbsPath(baseDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return HashContent(data) != e.SHA256, nil
+}
+
+// AbsPath resolves an entry path against baseDir.
+func (e Entry) AbsPath(baseDir string) string {
+	return filepath.Join(baseDir, filepath.FromSlash(e.Path))
+}
+
+// Load reads the manifest for baseDir. Returns ErrNotFound if none exists.
+func Load(baseDir string) (*Manifest, error) {
+	data, err := os.ReadFile(Path(baseDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes the contents of a manifest file, upgrading one written in an older
+// format. A manifest from a newer claudekit is an error wrapping migrate.ErrTooNew.
+func Parse(data []byte) (*Manifest, error) {
+	data, _, err := migrations.Upgrade(data)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Settings.Hooks == nil {
+		m.Settings.Hooks = map[string][]string{}
+	}
+	// Manifests written before layouts were configurable used the defaults
+	m.Layout = m.Layout.WithDefaults()
+	return &m, nil
+}
+
+// Save writes the manifest into baseDir/.claude.
+func (m *Manifest) Save(baseDir string) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(Path(baseDir)), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return os.WriteFile(Path(baseDir), data, 0644)
+}
+
+// Marshal sorts the entries by path and encodes the manifest as Save writes it.
+func (m *Manifest) Marshal() ([]byte, error) {
+	slices.SortFunc(m.Files, func(a, b Entry) int {
+		if a.Path < b.Path {
+			return -1
+		}
+		if a.Path > b.Path {
+			return 1
+		}
+		return 0
+	})
+
+	return json.MarshalIndent(m, "", "  ")
+}
@@ -0,0 +1,94 @@
+// Package migrate upgrades the JSON files claudekit keeps between runs, such as the
+// saved choices and the generation manifest, from the format an older version wrote.
+// Each file records its format in a schema_version field; a file without one
+// predates versioning and has version 0.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// VersionKey is the field a file records its format version in.
+const VersionKey = "schema_version"
+
+// ErrTooNew reports a file written by a newer claudekit, whose fields this version
+// could misread.
+var ErrTooNew = errors.New("written by a newer version of claudekit; upgrade claudekit to read it")
+
+// Migration upgrades a file from version From to From+1 by editing its decoded
+// top-level object. Numbers are json.Number.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]any) error
+}
+
+// Plan is the format history of one kind of file.
+type Plan struct {
+	Name       string      // How errors name the file, e.g. "saved choices"
+	Current    int         // The version this claudekit writes
+	Migrations []Migration // One for each version before Current, in any order
+}
+
+// Version returns the format version data records, 0 when it records none.
+func Version(data []byte) (int, error) {
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	raw, ok := header[VersionKey]
+	if !ok || string(raw) == "null" {
+		return 0, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+		return 0, fmt.Errorf("%s %s is not a version number", VersionKey, raw)
+	}
+	return version, nil
+}
+
+// Upgrade applies the migrations data needs, oldest first, and returns the upgraded
+// file with the version it was written in. A current file is returned unchanged.
+func (p Plan) Upgrade(data []byte) (upgraded []byte, from int, err error) {
+	from, err = Version(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", p.Name, err)
+	}
+	switch {
+	case from > p.Current:
+		return nil, from, fmt.Errorf("%s version %d: %w", p.Name, from, ErrTooNew)
+	case from == p.Current:
+		return data, from, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, from, fmt.Errorf("%s: %w", p.Name, err)
+	}
+	for version := from; version < p.Current; version++ {
+		m, ok := p.migration(version)
+		if !ok {
+			return nil, from, fmt.Errorf("%s: no migration from version %d", p.Name, version)
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, from, fmt.Errorf("%s: migrating from version %d (%s): %w", p.Name, version, m.Description, err)
+		}
+		doc[VersionKey] = version + 1
+	}
+	upgraded, err = json.Marshal(doc)
+	return upgraded, from, err
+}
+
+func (p Plan) migration(from int) (Migration, bool) {
+	for _, m := range p.Migrations {
+		if m.From == from {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,505 @@
+// Package schema checks settings.json, .mcp.json, and module frontmatter against the
+// shapes Claude Code and claudekit expect. Every problem names the file, the path of
+// the offending value such as hooks.PreToolUse[0].timeout, and a fix where one is
+// known, instead of the first type error a decoder happens to hit.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HookEvents lists the hook events accepted by Claude Code's settings schema.
+var HookEvents = []string{
+	"PreToolUse", "PostToolUse", "Notification", "UserPromptSubmit",
+	"Stop", "SubagentStop", "PreCompact", "SessionStart", "SessionEnd",
+}
+
+// ModuleTypes lists the module types claudekit loads.
+var ModuleTypes = []string{"subagent", "hook", "command", "mcp", "framework", "style", "permissions", "statusline"}
+
+// CommandNamespace matches a command module's namespace: slash-separated directory
+// names such as "git" or "release/notes".
+var CommandNamespace = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*(/[a-z0-9][a-z0-9_-]*)*$`)
+
+// HookLanguages lists the languages a hook module can generate its script in.
+var HookLanguages = []string{"bash", "python", "node", "powershell"}
+
+// SubagentModels lists the values of a subagent's model field; inherit runs the
+// subagent on the main conversation's model.
+var SubagentModels = []string{"sonnet", "opus", "haiku", "inherit"}
+
+// Error is one problem found in a file.
+type Error struct {
+	File    string
+	Path    string // Dotted path of the value, e.g. hooks.PreToolUse[0].timeout; "" for the file
+	Message string
+	Fix     string // Suggested fix; "" when there is no obvious one
+}
+
+func (e Error) Error() string {
+	msg := e.File + ": "
+	if e.Path != "" {
+		msg += e.Path + ": "
+	}
+	msg += e.Message
+	if e.Fix != "" {
+		msg += " (" + e.Fix + ")"
+	}
+	return msg
+}
+
+// Settings validates the contents of a settings.json file.
+func Settings(file string, data []byte) []Error {
+	return validateJSON(file, data, settingsSchema)
+}
+
+// MCP validates the contents of a .mcp.json file.
+func MCP(file string, data []byte) []Error {
+	return validateJSON(file, data, mcpSchema)
+}
+
+// HookDefaults validates a hook module's defaults before they become a settings.json
+// entry. Unlike Frontmatter, it requires the events and the command, or list of
+// commands, every entry needs.
+func HookDefaults(file string, defaults map[string]any) []Error {
+	if defaults == nil {
+		defaults = map[string]any{}
+	}
+	n := hookSettingsSchema
+	if _, ok := defaults["commands"]; ok {
+		n = hookCommandsSettingsSchema
+	}
+	v := &validator{file: file}
+	v.walk(defaults, n, "defaults")
+	return v.errs
+}
+
+// Frontmatter validates a module's YAML frontmatter, without its --- delimiters.
+func Frontmatter(file string, data []byte) []Error {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Error{{File: file, Message: fmt.Sprintf("invalid YAML: %v", err), Fix: "fix the syntax error"}}
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	v := &validator{file: file}
+	v.walk(doc, frontmatterSchema, "")
+	if m, ok := doc.(map[string]any); ok {
+		if defaults, ok := m["defaults"]; ok {
+			switch m["type"] {
+			case "hook":
+				v.walk(defaults, hookDefaultsSchema, "defaults")
+			case "command":
+				v.walk(defaults, commandDefaultsSchema, "defaults")
+			case "subagent":
+				v.walk(defaults, subagentDefaultsSchema, "defaults")
+			}
+		}
+	}
+	return v.errs
+}
+
+func validateJSON(file string, data []byte, schema *node) []Error {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []Error{{File: file, Message: fmt.Sprintf("invalid JSON: %v", err), Fix: "fix the syntax error or regenerate with claudekit"}}
+	}
+	v := &validator{file: file}
+	v.walk(doc, schema, "")
+	return v.errs
+}
+
+// kind is the type a value must have, worded for error messages.
+type kind string
+
+const (
+	kindString  kind = "a string"
+	kindInteger kind = "a whole number"
+	kindBool    kind = "true or false"
+	kindObject  kind = "an object"
+	kindArray   kind = "a list"
+)
+
+// node describes the values allowed at one place in a document.
+type node struct {
+	kind   kind
+	oneOf  []*node // Alternatives, for values that may take more than one shape
+	fields map[string]*node
+	strict bool     // Report every unknown key, not just likely misspellings of known ones
+	keys   []string // The keys a map may have, when it is not open-ended
+	values *node    // Map values or list elements
+	enum   []string
+	min    *float64
+	check  func(v any) (msg, fix string) // Further rules on a value of the right kind
+
+	required []string
+	nonEmpty bool
+	keyNoun  string // Names an unknown key in messages, e.g. "hook event"
+	fix      string // Fix for a value of the wrong kind
+}
+
+type validator struct {
+	file string
+	errs []Error
+}
+
+func (v *validator) report(path, msg, fix string) {
+	v.errs = append(v.errs, Error{File: v.file, Path: path, Message: msg, Fix: fix})
+}
+
+func (v *validator) walk(value any, n *node, path string) {
+	if n == nil {
+		return
+	}
+	if len(n.oneOf) > 0 {
+		kinds := make([]string, len(n.oneOf))
+		for i, alt := range n.oneOf {
+			trial := &validator{file: v.file}
+			trial.walk(value, alt, path)
+			if len(trial.errs) == 0 {
+				return
+			}
+			if sameKind(value, alt.kind) {
+				v.errs = append(v.errs, trial.errs...)
+				return
+			}
+			kinds[i] = string(alt.kind)
+		}
+		v.report(path, fmt.Sprintf("must be %s, not %s", strings.Join(kinds, " or "), describe(value)), n.fix)
+		return
+	}
+	if !sameKind(value, n.kind) {
+		v.report(path, fmt.Sprintf("must be %s, not %s", n.kind, describe(value)), n.fix)
+		return
+	}
+
+	switch n.kind {
+	case kindString:
+		s := value.(string)
+		if n.nonEmpty && strings.TrimSpace(s) == "" {
+			v.report(path, "must not be empty", n.fix)
+		} else if len(n.enum) > 0 && !slices.Contains(n.enum, s) {
+			v.report(path, fmt.Sprintf("%q is not one of %s", s, strings.Join(n.enum, ", ")), suggest(s, n.enum))
+		}
+	case kindInteger:
+		if f, _ := number(value); n.min != nil && f < *n.min {
+			v.report(path, fmt.Sprintf("must be at least %g", *n.min), n.fix)
+		}
+	case kindArray:
+		list := value.([]any)
+		if n.nonEmpty && len(list) == 0 {
+			v.report(path, "must not be empty", n.fix)
+		}
+		for i, item := range list {
+			v.walk(item, n.values, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case kindObject:
+		v.walkObject(value.(map[string]any), n, path)
+	}
+	if n.check != nil {
+		if msg, fix := n.check(value); msg != "" {
+			v.report(path, msg, fix)
+		}
+	}
+}
+
+func (v *validator) walkObject(m map[string]any, n *node, path string) {
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		child := join(path, key)
+		switch {
+		case n.fields[key] != nil:
+			v.walk(m[key], n.fields[key], child)
+		case n.keys != nil && !slices.Contains(n.keys, key):
+			fix := suggest(key, n.keys)
+			if fix == "" {
+				fix = "valid: " + strings.Join(n.keys, ", ")
+			}
+			v.report(child, fmt.Sprintf("unknown %s %q", n.keyNoun, key), fix)
+		case n.values != nil:
+			v.walk(m[key], n.values, child)
+		case n.fields != nil:
+			known := slices.Sorted(maps.Keys(n.fields))
+			if fix := suggest(key, known); fix != "" {
+				v.report(child, fmt.Sprintf("unknown key %q", key), fix)
+			} else if n.strict {
+				v.report(child, fmt.Sprintf("unknown key %q", key), "valid: "+strings.Join(known, ", "))
+			}
+		}
+	}
+	for _, key := range n.required {
+		if _, ok := m[key]; !ok {
+			v.report(join(path, key), "is required", n.fields[key].fix)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sameKind(value any, k kind) bool {
+	switch k {
+	case kindString:
+		_, ok := value.(string)
+		return ok
+	case kindInteger:
+		f, ok := number(value)
+		return ok && f == math.Trunc(f)
+	case kindBool:
+		_, ok := value.(bool)
+		return ok
+	case kindObject:
+		_, ok := value.(map[string]any)
+		return ok
+	case kindArray:
+		_, ok := value.([]any)
+		return ok
+	}
+	return true
+}
+
+// number reads JSON's float64 and YAML's ints alike.
+func number(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func describe(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("the string %q", value)
+	case bool:
+		return fmt.Sprint(value)
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "a list"
+	}
+	if f, ok := number(value); ok {
+		return fmt.Sprintf("%g", f)
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// suggest proposes the candidate closest to a misspelled name, or "" when none is close.
+func suggest(name string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, candidate := range candidates {
+		if strings.EqualFold(name, candidate) {
+			return fmt.Sprintf("did you mean %q?", candidate)
+		}
+		if d := distance(strings.ToLower(name), strings.ToLower(candidate)); d < bestDist && d < len(candidate)/2 {
+			best, bestDist = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("did you mean %q?", best)
+}
+
+// distance is the Levenshtein edit distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func minimum(f float64) *float64 { return &f }
+
+var (
+	stringNode    = &node{kind: kindString}
+	boolNode      = &node{kind: kindBool}
+	stringList    = &node{kind: kindArray, values: stringNode}
+	stringMap     = &node{kind: kindObject, values: &node{kind: kindString, fix: "quote the value"}}
+	permissionSet = &node{kind: kindArray, values: &node{kind: kindString, nonEmpty: true}, fix: `write rules as a list, e.g. ["Bash(git status:*)"]`}
+)
+
+var settingsSchema = &node{kind: kindObject, fields: map[string]*node{
+	"permissions": {kind: kindObject, fields: map[string]*node{
+		"allow":                 permissionSet,
+		"ask":                   permissionSet,
+		"deny":                  permissionSet,
+		"additionalDirectories": stringList,
+		"defaultMode":           {kind: kindString, enum: []string{"default", "acceptEdits", "plan", "bypassPermissions"}},
+	}},
+	"hooks": {kind: kindObject, keys: HookEvents, keyNoun: "hook event", values: &node{
+		kind: kindArray,
+		values: &node{kind: kindObject, required: []string{"hooks"}, fields: map[string]*node{
+			"matcher": {kind: kindString, fix: `use a tool name or pattern, e.g. "Write|Edit"`},
+			"hooks": {kind: kindArray, nonEmpty: true, fix: "add at least one command, or remove the matcher", values: &node{
+				kind: kindObject, strict: true, required: []string{"type", "command"}, fields: map[string]*node{
+					"type":    {kind: kindString, enum: []string{"command"}, fix: `set "type": "command"`},
+					"command": {kind: kindString, nonEmpty: true, fix: "point command at the hook script"},
+					"timeout": {kind: kindInteger, min: minimum(1), fix: "give the timeout in whole seconds, e.g. 30"},
+				},
+			}},
+		}},
+	}},
+	"env":         {kind: kindObject, values: &node{kind: kindString, fix: `quote the value, e.g. "1"`}},
+	"outputStyle": stringNode,
+	"statusLine": {kind: kindObject, required: []string{"type", "command"}, fields: map[string]*node{
+		"type":    {kind: kindString, enum: []string{"command"}, fix: `set "type": "command"`},
+		"command": {kind: kindString, nonEmpty: true, fix: "point command at the statusline script"},
+		"padding": {kind: kindInteger, min: minimum(0)},
+	}},
+	"model":                      stringNode,
+	"apiKeyHelper":               stringNode,
+	"cleanupPeriodDays":          {kind: kindInteger, min: minimum(0)},
+	"includeCoAuthoredBy":        boolNode,
+	"enableAllProjectMcpServers": boolNode,
+	"enabledMcpjsonServers":      stringList,
+	"disabledMcpjsonServers":     stringList,
+}}
+
+var mcpSchema = &node{kind: kindObject, required: []string{"mcpServers"}, fields: map[string]*node{
+	"mcpServers": {kind: kindObject, fix: `add {"mcpServers": {}}`, values: &node{
+		kind: kindObject,
+		fields: map[string]*node{
+			"type":    {kind: kindString, enum: []string{"stdio", "sse", "http"}},
+			"command": {kind: kindString, nonEmpty: true},
+			"args":    {kind: kindArray, values: stringNode, fix: `pass arguments as a list, e.g. ["-y", "server"]`},
+			"env":     stringMap,
+			"url":     {kind: kindString, nonEmpty: true},
+			"headers": stringMap,
+		},
+		check: func(v any) (string, string) {
+			server := v.(map[string]any)
+			if server["command"] == nil && server["url"] == nil {
+				return "needs a command or a url", `add "command" for a local server or "url" for a remote one`
+			}
+			return "", ""
+		},
+	}},
+}}
+
+var frontmatterSchema = &node{kind: kindObject, required: []string{"name", "type"}, fields: map[string]*node{
+	"name":               {kind: kindString, nonEmpty: true, fix: "name the module after its file, e.g. name: code-reviewer"},
+	"type":               {kind: kindString, enum: ModuleTypes, fix: "type: " + strings.Join(ModuleTypes, " | ")},
+	"enabled":            {kind: kindBool, fix: "write enabled: true or enabled: false"},
+	"display_name":       stringNode,
+	"category":           stringNode,
+	"asset_paths":        stringList,
+	"dependencies":       {kind: kindArray, values: stringNode, fix: "list dependencies as type/name, e.g. - hook/session-start"},
+	"defaults":           {kind: kindObject},
+	"requires_claudekit": stringNode,
+	"requires_claude":    stringNode,
+	"version":            {kind: kindString, fix: `quote the version, e.g. version: "1.0.0"`},
+}}
+
+// hookDefaultsSchema checks the defaults claudekit reads from a hook module;
+// module-specific keys such as protected_branches are left alone.
+var hookDefaultsSchema = &node{kind: kindObject, check: singleHookCommandForm, fields: map[string]*node{
+	"command": {kind: kindString, nonEmpty: true, fix: "give the command that runs the hook, e.g. $CLAUDE_PROJECT_DIR/.claude/hooks/format.sh"},
+	"commands": {kind: kindArray, nonEmpty: true, check: uniqueHookCommandNames, fix: "list the commands in the order they run", values: &node{kind: kindObject, strict: true, required: []string{"name", "command"}, fields: map[string]*node{
+		"name":    {kind: kindString, nonEmpty: true, fix: "name the command, e.g. name: format"},
+		"command": {kind: kindString, nonEmpty: true, fix: "give the command to run, e.g. npx prettier --write ."},
+		"timeout": {kind: kindInteger, min: minimum(1), fix: "give the timeout in whole seconds, e.g. 30"},
+	}}},
+	"hook_type": {oneOf: []*node{
+		{kind: kindString, enum: HookEvents},
+		{kind: kindArray, nonEmpty: true, values: &node{kind: kindString, enum: HookEvents}},
+	}, fix: "name one event, or list several"},
+	"matcher":   {kind: kindString},
+	"timeout":   {kind: kindInteger, min: minimum(1), fix: "give the timeout in whole seconds, e.g. 30"},
+	"languages": {kind: kindArray, nonEmpty: true, values: &node{kind: kindString, enum: HookLanguages}},
+}}
+
+// hookSettingsSchema is hookDefaultsSchema for a hook about to be written to
+// settings.json, which cannot do without a command or an event.
+var hookSettingsSchema = &node{kind: kindObject, check: singleHookCommandForm, fields: hookDefaultsSchema.fields, required: []string{"command", "hook_type"}}
+
+// hookCommandsSettingsSchema is hookSettingsSchema for a hook that runs a list of
+// commands.
+var hookCommandsSettingsSchema = &node{kind: kindObject, check: singleHookCommandForm, fields: hookDefaultsSchema.fields, required: []string{"commands", "hook_type"}}
+
+// singleHookCommandForm rejects hook defaults that set both command and commands.
+func singleHookCommandForm(v any) (string, string) {
+	m := v.(map[string]any)
+	_, command := m["command"]
+	_, commands := m["commands"]
+	if command && commands {
+		return "sets both command and commands", "keep command for one script, or move it into the commands list"
+	}
+	return "", ""
+}
+
+// uniqueHookCommandNames rejects a commands list that names two commands alike,
+// since users enable and order them by name.
+func uniqueHookCommandNames(v any) (string, string) {
+	seen := map[string]bool{}
+	for _, item := range v.([]any) {
+		entry, _ := item.(map[string]any)
+		name, _ := entry["name"].(string)
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			return fmt.Sprintf("names %q twice", name), "give each command its own name"
+		}
+		seen[name] = true
+	}
+	return "", ""
+}
+
+// subagentDefaultsSchema checks the model and tools a subagent module declares for
+// its agent file.
+var subagentDefaultsSchema = &node{kind: kindObject, fields: map[string]*node{
+	"description": stringNode,
+	"model":       {kind: kindString, enum: SubagentModels, fix: "model: " + strings.Join(SubagentModels, " | ")},
+	"tools":       {kind: kindArray, values: &node{kind: kindString, nonEmpty: true}, fix: "list tool names, e.g. - Read"},
+}}
+
+// commandDefaultsSchema checks the arguments, allowed tools, and namespace of a
+// command module.
+var commandDefaultsSchema = &node{kind: kindObject, fields: map[string]*node{
+	"namespace": {kind: kindString, check: func(v any) (string, string) {
+		if !CommandNamespace.MatchString(strings.Trim(v.(string), "/")) {
+			return fmt.Sprintf("%q is not a namespace", v), "use lowercase directory names separated by /, e.g. git or release/notes"
+		}
+		return "", ""
+	}},
+	"allowed_tools": {kind: kindArray, values: &node{kind: kindString, nonEmpty: true}, fix: "list permission rules, e.g. - Bash(git diff:*)"},
+	"arguments": {kind: kindArray, values: &node{kind: kindObject, strict: true, required: []string{"name"}, fields: map[string]*node{
+		"name":        {kind: kindString, nonEmpty: true, fix: "name the argument, e.g. name: issue"},
+		"description": stringNode,
+		"required":    boolNode,
+	}}},
+}}
@@ -0,0 +1,187 @@
+// Package testsupport runs claudekit's generation pipeline into an in-memory file
+// system and compares what it wrote with golden directories, so end-to-end tests
+// can cover module combinations without touching the disk.
+package testsupport
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"jeremyclewell.com/claudekit/internal/fsys"
+	"jeremyclewell.com/claudekit/internal/util"
+)
+
+// Root is the directory Generate runs a pipeline in. It exists only in memory.
+var Root = filepath.FromSlash("/project")
+
+// Pipeline generates a configuration into files, rooted at dir.
+type Pipeline func(files fsys.FS, dir string) error
+
+// Tree holds the files of a directory by their slash-separated path relative to it.
+type Tree map[string]string
+
+// Paths returns the paths in t, sorted.
+func (t Tree) Paths() []string {
+	paths := make([]string, 0, len(t))
+	for p := range t {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// String lists the paths of t, one per line, for test failure messages.
+func (t Tree) String() string {
+	var b strings.Builder
+	for _, rel := range t.Paths() {
+		fmt.Fprintln(&b, rel)
+	}
+	return b.String()
+}
+
+// Generate runs pipeline in an empty in-memory file system and returns the files it
+// wrote. A pipeline error fails the test.
+func Generate(t testing.TB, pipeline Pipeline) Tree {
+	t.Helper()
+	files := fsys.NewMem()
+	if err := files.MkdirAll(Root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := pipeline(files, Root); err != nil {
+		t.Fatalf("generating into memory: %v", err)
+	}
+	return MemTree(files, Root)
+}
+
+// MemTree returns the files of files under dir.
+func MemTree(files *fsys.Mem, dir string) Tree {
+	tree := Tree{}
+	for _, name := range files.Files() {
+		rel, err := filepath.Rel(dir, name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		data, _ := files.ReadFile(name)
+		tree[filepath.ToSlash(rel)] = string(data)
+	}
+	return tree
+}
+
+// ReadDir returns the files under dir on disk. A missing dir is an empty tree.
+func ReadDir(dir string) (Tree, error) {
+	tree := Tree{}
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, name)
+		tree[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return tree, nil
+	}
+	return tree, err
+}
+
+// WriteDir replaces the contents of dir with tree.
+func WriteDir(dir string, tree Tree) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for _, rel := range tree.Paths() {
+		name := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, []byte(tree[rel]), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GoldenOptions tunes CompareGolden.
+type GoldenOptions struct {
+	Update bool     // Rewrite the golden directory with the generated files instead of comparing
+	Ignore []string // path.Match patterns of files left out of the comparison, such as ones holding a timestamp
+
+	// Normalize, when set, rewrites each generated file before it is compared or
+	// written, such as to mask the date of the run.
+	Normalize func(rel, content string) string
+}
+
+// CompareGolden checks got against the golden directory, failing the test with a
+// diff of each file that differs and a list of those missing or unexpected. With
+// opts.Update it rewrites the directory instead.
+func CompareGolden(t testing.TB, golden string, got Tree, opts GoldenOptions) {
+	t.Helper()
+	got = withoutIgnored(got, opts.Ignore)
+	if opts.Normalize != nil {
+		for rel, content := range got {
+			got[rel] = opts.Normalize(rel, content)
+		}
+	}
+	if opts.Update {
+		if err := WriteDir(golden, got); err != nil {
+			t.Fatalf("writing golden files: %v", err)
+		}
+		return
+	}
+
+	want, err := ReadDir(golden)
+	if err != nil {
+		t.Fatalf("reading golden files: %v", err)
+	}
+	if len(want) == 0 {
+		t.Fatalf("no golden files in %s (run with -update to create them)", golden)
+	}
+	failed := false
+	fail := func(format string, args ...any) {
+		t.Helper()
+		t.Errorf(format, args...)
+		failed = true
+	}
+	for _, rel := range want.Paths() {
+		content, ok := got[rel]
+		switch {
+		case !ok:
+			fail("%s was not generated", rel)
+		case content != want[rel]:
+			fail("%s differs from %s:\n%s", rel, golden, util.LineDiff("golden/"+rel, "generated/"+rel, []byte(want[rel]), []byte(content)))
+		}
+	}
+	for _, rel := range got.Paths() {
+		if _, ok := want[rel]; !ok {
+			fail("%s was generated but is not in %s", rel, golden)
+		}
+	}
+	if failed {
+		t.Log("run with -update if the change is intended")
+	}
+}
+
+// withoutIgnored returns tree without the files matching patterns.
+func withoutIgnored(tree Tree, patterns []string) Tree {
+	kept := Tree{}
+	for rel, content := range tree {
+		if !slices.ContainsFunc(patterns, func(pattern string) bool {
+			matched, err := path.Match(pattern, rel)
+			return err == nil && matched
+		}) {
+			kept[rel] = content
+		}
+	}
+	return kept
+}
@@ -0,0 +1,190 @@
+// Package usage reads the session log the usage-log hook appends to and summarizes
+// it: totals, the most used tools, and activity per day.
+package usage
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FileName is the log's name inside the .claude directory.
+const FileName = "usage.jsonl"
+
+// Tokens counts the tokens of one or more sessions.
+type Tokens struct {
+	Input         int64 `json:"input"`
+	Output        int64 `json:"output"`
+	CacheRead     int64 `json:"cache_read"`
+	CacheCreation int64 `json:"cache_creation"`
+}
+
+func (t *Tokens) add(o Tokens) {
+	t.Input += o.Input
+	t.Output += o.Output
+	t.CacheRead += o.CacheRead
+	t.CacheCreation += o.CacheCreation
+}
+
+// Record is one session, as the hook logs it.
+type Record struct {
+	SessionID       string         `json:"session_id"`
+	StartedAt       time.Time      `json:"started_at"`
+	EndedAt         time.Time      `json:"ended_at"`
+	DurationSeconds int64          `json:"duration_seconds"`
+	Reason          string         `json:"reason"`
+	Prompts         int            `json:"prompts"`
+	Tools           map[string]int `json:"tools"`
+	Tokens          Tokens         `json:"tokens"`
+	Models          []string       `json:"models"`
+}
+
+// Load reads the records in the log at path, oldest first. Lines that are not
+// records, such as one cut short by a crash, are skipped and counted. A missing log
+// holds no records.
+func Load(path string) (records []Record, skipped int, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil || r.EndedAt.IsZero() {
+			skipped++
+			continue
+		}
+		records = append(records, r)
+	}
+	slices.SortStableFunc(records, func(a, b Record) int { return a.EndedAt.Compare(b.EndedAt) })
+	return records, skipped, scanner.Err()
+}
+
+// ToolCount is how many times a tool was called.
+type ToolCount struct {
+	Name  string `json:"name"`
+	Calls int    `json:"calls"`
+}
+
+// Day is the activity of one calendar day.
+type Day struct {
+	Date     string `json:"date"` // YYYY-MM-DD
+	Sessions int    `json:"sessions"`
+	Tokens   int64  `json:"tokens"` // Input and output
+}
+
+// Summary aggregates the sessions of a period.
+type Summary struct {
+	Since    time.Time   `json:"since"`
+	Until    time.Time   `json:"until"`
+	Sessions int         `json:"sessions"`
+	Seconds  int64       `json:"duration_seconds"`
+	Prompts  int         `json:"prompts"`
+	Tokens   Tokens      `json:"tokens"`
+	Tools    []ToolCount `json:"tools"`  // Most called first
+	Models   []string    `json:"models"` // Sorted
+	Days     []Day       `json:"days"`   // Every day of the period, oldest first
+}
+
+// Summarize aggregates the records that ended in the days calendar days up to and
+// including now's, in now's time zone.
+func Summarize(records []Record, days int, now time.Time) Summary {
+	days = max(days, 1)
+	y, m, d := now.Date()
+	start := time.Date(y, m, d-days+1, 0, 0, 0, 0, now.Location())
+	s := Summary{Since: start, Until: now, Days: make([]Day, days)}
+	for i := range s.Days {
+		s.Days[i].Date = start.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+
+	tools := map[string]int{}
+	models := map[string]bool{}
+	for _, r := range records {
+		ended := r.EndedAt.In(now.Location())
+		if ended.Before(start) || ended.After(now) {
+			continue
+		}
+		s.Sessions++
+		s.Seconds += r.DurationSeconds
+		s.Prompts += r.Prompts
+		s.Tokens.add(r.Tokens)
+		for name, calls := range r.Tools {
+			tools[name] += calls
+		}
+		for _, model := range r.Models {
+			models[model] = true
+		}
+		ey, em, ed := ended.Date()
+		day := &s.Days[dayIndex(start, time.Date(ey, em, ed, 0, 0, 0, 0, now.Location()))]
+		day.Sessions++
+		day.Tokens += r.Tokens.Input + r.Tokens.Output
+	}
+
+	for name, calls := range tools {
+		s.Tools = append(s.Tools, ToolCount{name, calls})
+	}
+	slices.SortFunc(s.Tools, func(a, b ToolCount) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), strings.Compare(a.Name, b.Name))
+	})
+	s.Models = slices.Sorted(maps.Keys(models))
+	return s
+}
+
+// dayIndex counts calendar days from start to day, both at midnight. Days are not
+// all 24 hours long across daylight saving changes, so it rounds.
+func dayIndex(start, day time.Time) int {
+	return int((day.Sub(start) + 12*time.Hour) / (24 * time.Hour))
+}
+
+// sparkBars are the levels of a sparkline, lowest first.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a row of bars scaled to the largest. Zero is the lowest
+// bar and any other value at least the second, so activity is never hidden.
+func Sparkline(values []int64) string {
+	highest := slices.Max(append([]int64{0}, values...))
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if v > 0 {
+			level = max(1, int(v*int64(len(sparkBars)-1)/highest))
+		}
+		b.WriteRune(sparkBars[level])
+	}
+	return b.String()
+}
+
+// Buckets sums consecutive values so that at most width remain, keeping a long
+// period's sparkline on one line. The last bucket may cover fewer values.
+func Buckets(values []int64, width int) []int64 {
+	if width <= 0 || len(values) <= width {
+		return values
+	}
+	size := (len(values) + width - 1) / width
+	var buckets []int64
+	for i := 0; i < len(values); i += size {
+		var sum int64
+		for _, v := range values[i:min(i+size, len(values))] {
+			sum += v
+		}
+		buckets = append(buckets, sum)
+	}
+	return buckets
+}
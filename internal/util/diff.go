@@ -0,0 +1,51 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineDiff renders a unified-style line diff between two texts. Returns "" when equal.
+func LineDiff(oldName, newName string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+
+	oldLines := strings.Split(strings.TrimSuffix(string(a), "\n"), "\n")
+	newLines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+
+	// Longest common subsequence table
+	n, m := len(oldLines), len(newLines)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b2 strings.Builder
+	fmt.Fprintf(&b2, "--- %s\n+++ %s\n", oldName, newName)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			b2.WriteString("  " + oldLines[i] + "\n")
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			b2.WriteString("+ " + newLines[j] + "\n")
+			j++
+		default:
+			b2.WriteString("- " + oldLines[i] + "\n")
+			i++
+		}
+	}
+	return b2.String()
+}
@@ -0,0 +1,189 @@
+// Package version parses dotted version numbers and the simple constraint syntax
+// modules use in requires_claudekit and requires_claude frontmatter.
+package version
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned for malformed versions and constraints.
+var ErrInvalid = errors.New("invalid version")
+
+// Version is a dotted numeric version. Pre-release and build suffixes are ignored.
+type Version struct {
+	parts []int
+}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// Parse parses versions like "1.2.3", "v0.2", or "1.0.72-beta".
+func Parse(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return Version{}, fmt.Errorf("%w: empty", ErrInvalid)
+	}
+
+	var v Version
+	for _, field := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+		}
+		v.parts = append(v.parts, n)
+	}
+	return v, nil
+}
+
+// Extract finds the first version number in free-form output such as
+// "1.0.72 (Claude Code)". It returns false when there is none.
+func Extract(s string) (Version, bool) {
+	match := versionPattern.FindString(s)
+	if match == "" {
+		return Version{}, false
+	}
+	v, err := Parse(match)
+	return v, err == nil
+}
+
+func (v Version) String() string {
+	fields := make([]string, len(v.parts))
+	for i, n := range v.parts {
+		fields[i] = strconv.Itoa(n)
+	}
+	return strings.Join(fields, ".")
+}
+
+// Compare returns -1, 0, or 1 as a is older than, equal to, or newer than b.
+// Missing components count as 0, so 1.2 equals 1.2.0.
+func Compare(a, b Version) int {
+	return compare(a, b, max(len(a.parts), len(b.parts)))
+}
+
+// compare compares the first n components of a and b, treating missing components as 0.
+func compare(a, b Version, n int) int {
+	for i := 0; i < n; i++ {
+		x, y := component(a, i), component(b, i)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func component(v Version, i int) int {
+	if i < len(v.parts) {
+		return v.parts[i]
+	}
+	return 0
+}
+
+// term is a single comparison such as ">=1.x".
+type term struct {
+	op      string
+	version Version
+}
+
+// Constraint is a conjunction of comparisons, e.g. ">=0.2, <1".
+type Constraint struct {
+	raw   string
+	terms []term
+}
+
+var operators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// ParseConstraint parses comma- or space-separated comparisons. Components may be
+// wildcards ("x" or "*"); a partial version only constrains the components it names,
+// so ">=1.x" accepts any 1.y.z or later and "<0.2" rejects 0.2.1.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	fields := strings.FieldsFunc(c.raw, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return Constraint{}, fmt.Errorf("%w constraint: empty", ErrInvalid)
+	}
+
+	for _, field := range fields {
+		op := "="
+		for _, candidate := range operators {
+			if strings.HasPrefix(field, candidate) {
+				op = candidate
+				field = field[len(candidate):]
+				break
+			}
+		}
+		if op == "==" {
+			op = "="
+		}
+
+		// Drop wildcard components: "1.x" constrains only the major version
+		var kept []string
+		for _, part := range strings.Split(field, ".") {
+			if part == "x" || part == "X" || part == "*" {
+				break
+			}
+			kept = append(kept, part)
+		}
+		if len(kept) == 0 {
+			continue // A bare wildcard matches everything
+		}
+
+		v, err := Parse(strings.Join(kept, "."))
+		if err != nil {
+			return Constraint{}, fmt.Errorf("%w constraint %q", ErrInvalid, s)
+		}
+		c.terms = append(c.terms, term{op: op, version: v})
+	}
+	return c, nil
+}
+
+// Check reports whether v satisfies every comparison in the constraint.
+func (c Constraint) Check(v Version) bool {
+	for _, t := range c.terms {
+		cmp := compare(v, t.version, len(t.version.parts))
+		var ok bool
+		switch t.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// Satisfies parses both arguments and reports whether version meets constraint.
+func Satisfies(v, constraint string) (bool, error) {
+	parsed, err := Parse(v)
+	if err != nil {
+		return false, err
+	}
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(parsed), nil
+}
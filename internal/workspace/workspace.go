@@ -0,0 +1,289 @@
+// Package workspace finds the sub-projects of a monorepo from its workspace files:
+// go.work, pnpm-workspace.yaml, and the [workspace] table of Cargo.toml.
+package workspace
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workspace files, in the order Detect reads them.
+const (
+	GoWork = "go.work"
+	PNPM   = "pnpm-workspace.yaml"
+	Cargo  = "Cargo.toml"
+)
+
+// Package is a sub-project listed by a workspace file.
+type Package struct {
+	Dir    string // Slash-separated, relative to the workspace root
+	Source string // Workspace file that lists it
+}
+
+// skipDirs are never searched when expanding member globs.
+var skipDirs = map[string]bool{"node_modules": true, "target": true, "vendor": true}
+
+// Detect returns the packages listed by the workspace files in root, sorted by
+// directory. A directory listed by more than one file is reported once, for the
+// first file that lists it. The root itself is never a package.
+func Detect(root string) ([]Package, error) {
+	var packages []Package
+	seen := map[string]bool{}
+	add := func(source string, dirs []string) {
+		for _, dir := range dirs {
+			dir = path.Clean(dir)
+			if dir == "." || seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			packages = append(packages, Package{Dir: dir, Source: source})
+		}
+	}
+
+	for _, source := range []string{GoWork, PNPM, Cargo} {
+		data, err := os.ReadFile(filepath.Join(root, source))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var dirs []string
+		switch source {
+		case GoWork:
+			dirs = goWorkDirs(data)
+		case PNPM:
+			dirs, err = pnpmDirs(root, data)
+		case Cargo:
+			dirs, err = cargoDirs(root, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", source, err)
+		}
+		add(source, dirs)
+	}
+
+	slices.SortFunc(packages, func(a, b Package) int { return strings.Compare(a.Dir, b.Dir) })
+	return packages, nil
+}
+
+// goWorkDirs reads the use directives of a go.work file, both single-line and
+// parenthesized blocks. Directories outside the root are dropped.
+func goWorkDirs(data []byte) []string {
+	var dirs []string
+	inBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			dirs = append(dirs, line)
+		case line == "use (":
+			inBlock = true
+		default:
+			if dir, ok := strings.CutPrefix(line, "use "); ok {
+				dirs = append(dirs, strings.TrimSpace(dir))
+			}
+		}
+	}
+	return insideRoot(dirs)
+}
+
+// pnpmDirs expands the packages globs of pnpm-workspace.yaml to directories holding
+// a package.json. Globs starting with ! exclude directories.
+func pnpmDirs(root string, data []byte) ([]string, error) {
+	var doc struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var include, exclude []string
+	for _, pattern := range doc.Packages {
+		if p, ok := strings.CutPrefix(pattern, "!"); ok {
+			exclude = append(exclude, p)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+	return expandGlobs(root, include, exclude, "package.json")
+}
+
+// cargoDirs expands the members and exclude arrays of the [workspace] table in
+// Cargo.toml to directories holding a Cargo.toml.
+func cargoDirs(root string, data []byte) ([]string, error) {
+	members, err := tomlArray(data, "workspace", "members")
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := tomlArray(data, "workspace", "exclude")
+	if err != nil {
+		return nil, err
+	}
+	return expandGlobs(root, members, exclude, Cargo)
+}
+
+// tomlArray reads a string array from a table of a TOML document. It understands
+// the subset Cargo workspaces use: quoted strings, arrays spanning lines, and
+// comments; a missing table or key yields nil.
+func tomlArray(data []byte, table, key string) ([]string, error) {
+	var value strings.Builder
+	inTable, collecting := false, false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if collecting {
+			value.WriteString(line)
+			if strings.Contains(line, "]") {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inTable = strings.Trim(line, "[] ") == table
+			continue
+		}
+		name, rest, ok := strings.Cut(line, "=")
+		if !inTable || !ok || strings.TrimSpace(name) != key {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "[") {
+			return nil, fmt.Errorf("%s.%s is not an array", table, key)
+		}
+		value.WriteString(rest)
+		if strings.Contains(rest, "]") {
+			break
+		}
+		collecting = true
+	}
+
+	array := strings.TrimSpace(value.String())
+	if array == "" {
+		return nil, nil
+	}
+	if !strings.HasSuffix(array, "]") {
+		return nil, fmt.Errorf("%s.%s is not closed", table, key)
+	}
+	var items []string
+	for _, item := range strings.Split(strings.Trim(array, "[]"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if len(item) < 2 || (item[0] != '"' && item[0] != '\'') || item[len(item)-1] != item[0] {
+			return nil, fmt.Errorf("%s.%s has a value that is not a string: %s", table, key, item)
+		}
+		items = append(items, item[1:len(item)-1])
+	}
+	return items, nil
+}
+
+// stripTOMLComment drops a # comment that is not inside a quoted string.
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// expandGlobs lists the directories under root that match an include pattern and
+// no exclude pattern and that contain marker. Patterns use path.Match syntax, with
+// ** matching any number of directories.
+func expandGlobs(root string, include, exclude []string, marker string) ([]string, error) {
+	include, exclude = insideRoot(include), insideRoot(exclude)
+	if len(include) == 0 {
+		return nil, nil
+	}
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != root && (strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchAny(include, rel) || matchAny(exclude, rel) {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(p, marker)); err == nil {
+			dirs = append(dirs, rel)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+func matchAny(patterns []string, rel string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool { return matchGlob(pattern, rel) })
+}
+
+// matchGlob matches a slash-separated path against pattern segment by segment,
+// letting a ** segment stand for zero or more directories.
+func matchGlob(pattern, rel string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
+
+// insideRoot cleans relative paths and patterns, dropping those that are absolute
+// or leave the root.
+func insideRoot(paths []string) []string {
+	var kept []string
+	for _, p := range paths {
+		p = path.Clean(strings.TrimPrefix(filepath.ToSlash(strings.Trim(p, `"`)), "./"))
+		if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
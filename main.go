@@ -1,33 +1,76 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"io/fs"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"text/template"
+	"text/template/parse"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
-	"github.com/charmbracelet/lipgloss"
 	huh "github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
 	"gopkg.in/yaml.v3"
 
+	"jeremyclewell.com/claudekit/internal/bundle"
+	"jeremyclewell.com/claudekit/internal/formatting"
+	"jeremyclewell.com/claudekit/internal/fsys"
 	"jeremyclewell.com/claudekit/internal/generation"
 	"jeremyclewell.com/claudekit/internal/gradient"
+	"jeremyclewell.com/claudekit/internal/logging"
+	"jeremyclewell.com/claudekit/internal/manifest"
+	"jeremyclewell.com/claudekit/internal/migrate"
+	"jeremyclewell.com/claudekit/internal/schema"
+	"jeremyclewell.com/claudekit/internal/usage"
+	"jeremyclewell.com/claudekit/internal/util"
+	"jeremyclewell.com/claudekit/internal/version"
+	"jeremyclewell.com/claudekit/internal/workspace"
 )
 //go:embed assets/* assets/modules/**/*
 var assets embed.FS
 
+func init() {
+	generation.Templates = assets
+}
+
 // Version number
 const Version = "0.0.1"
 
@@ -35,32 +78,159 @@ const Version = "0.0.1"
 const (
 	MIN_WIDTH_FOR_PANEL  = 140 // Minimum terminal columns for right panel
 	MIN_HEIGHT_FOR_PANEL = 40  // Minimum terminal rows for right panel
-	RESIZE_DEBOUNCE_MS   = 200 // Debounce delay in milliseconds
+	RESIZE_DEBOUNCE_MS   = 200 // Default debounce delay for sustained resizes, in milliseconds
+	RESIZE_FRAME_MS      = 16  // One frame; a lone resize is applied after this long
 )
 
 type Config struct {
-	IsProjectLocal bool       // true = project-based, false = global/home directory
+	IsProjectLocal bool // true = project-based, false = global/home directory
 	ProjectName    string
 	Languages      []string
+	Frameworks     []string
 	Subagents      []string
 	Hooks          []string
 	SlashCommands  []string
 	MCPServers     []string
+	OutputStyle    string   // Style module name, "" for the Claude Code default
+	Permissions    []string // Permission preset module names, merged into settings.json
+	Statusline     string   // Statusline module name, "" for the Claude Code default
 	ClaudeMDExtras string
-	Confirmed      bool       // for final confirmation step
+	SetupDoc       bool     // Also write docs/CLAUDE-SETUP.md for teammates (project scope only)
+	SetupReadme    bool     // Also keep the same tables in a section of README.md (project scope only)
+	MemoryFiles    []string // Files to seed once: "local" for CLAUDE.local.md, "memory" for .claude/memory/ (project scope only)
+	Gitignore      bool     // Keep local files out of git in a marked block of .gitignore (project scope only)
+	EditorTasks    []string // Editors to generate slash command tasks for: "vscode", "jetbrains" (project scope only)
+	Devcontainer   bool     // Also write .devcontainer/ files that install Claude Code (project scope only)
+	Workflows      []string // GitHub Actions jobs for .github/workflows/claude.yml: "review", "triage" (project scope only)
+	Theme          string   // Palette preset the form is drawn in; see gradient.ThemeNames
+	SplitRatio     float64  // Share of the width the form takes beside the status panel; 0 for the default
+	Packages       []string // Workspace packages that get their own configuration (project scope only)
+	Confirmed      bool     // for final confirmation step
+	ProbeMCP       bool     // --probe-mcp: connect to HTTP and SSE MCP servers after generating
+	NoFmt          bool     // --no-fmt: write generated markdown as rendered, without formatting it
+
+	// IncludeDisabled offers and generates modules whose frontmatter sets enabled:
+	// false, such as experimental ones; set with --include-disabled or on the form.
+	IncludeDisabled bool
+
+	// Package is set while generating a workspace package: its directory, relative
+	// to the workspace root. CLAUDE.md then leaves the shared rules to the root's.
+	Package string
+
+	// callbacks let the generation screen follow the run; unexported, so huh's
+	// bindings do not hash them
+	callbacks generationCallbacks
+
+	// Layout overrides where agents, hooks, and commands are written; set via the
+	// "layout" key in ~/.claudekit.json. Empty fields use the .claude defaults.
+	Layout manifest.Layout
+
+	// CustomSubagents were authored in the TUI; they are offered again on every run.
+	CustomSubagents []generation.CustomSubagent
+
+	// HookLanguages maps a hook name, or "*" for every hook, to the language its
+	// script is generated in (bash, python, node, or powershell); set with --hook-lang.
+	HookLanguages map[string]string
+
+	// Webhook URLs the notify-slack and notify-discord hooks post to, stored in
+	// settings.json's env. Empty leaves the variable to the user's environment.
+	SlackWebhookURL   string
+	DiscordWebhookURL string
+
+	// PermissionRules are the rules kept on the permission editor page, as
+	// "<allow|ask|deny> <rule>" entries; nil uses the rules of the selected presets.
+	// CustomPermissionRules holds rules typed on that page, one entry per line.
+	PermissionRules       []string
+	CustomPermissionRules string
+
+	// Env holds settings.json's env as KEY=VALUE lines; empty uses defaultEnv.
+	Env string
+
+	// MCPUserScope lists the selected MCP servers to register for the user with
+	// claude mcp add-json instead of writing them to the project's .mcp.json.
+	MCPUserScope []string
+
+	// CommandTools overrides the allowed tools of slash commands, one
+	// "command: tool, tool" line each; commands not listed keep their module's tools.
+	CommandTools string
+
+	// AgentModels overrides the model subagents run on, one "agent: model" line
+	// each; agents not listed keep their module's model.
+	AgentModels string
+
+	// HookMatchers overrides the tools hooks fire on, one "hook: matcher" line each;
+	// hooks not listed keep their module's matcher.
+	HookMatchers string
+
+	// HookCommands picks and orders the commands of hooks that run several, one
+	// "hook: command, command" line each; hooks not listed run all of theirs.
+	HookCommands string
+
+	// AssetResolutions settles asset paths more than one selected module declares,
+	// one "path: rename|skip|overwrite" line each; unlisted conflicts keep every module.
+	// AssetRenames holds the paths renaming gave each module, by declared path; it is
+	// set by ResolveAssetConflicts and recorded in the manifest.
+	AssetResolutions string
+	AssetRenames     map[moduleRef]map[string]string
+
+	// AgentTools holds the rows of the agent tools page that differ from the
+	// subagent's module; an empty list lets the agent inherit every tool.
+	AgentTools map[string][]string
 }
 
 // PersistenceConfig stores previous choices for subsequent runs
 type PersistenceConfig struct {
+	SchemaVersion  int       `json:"schema_version"`
 	LastUpdated    time.Time `json:"last_updated"`
 	IsProjectLocal bool      `json:"is_project_local"`
 	ProjectName    string    `json:"project_name"`
 	Languages      []string  `json:"languages"`
+	Frameworks     []string  `json:"frameworks,omitempty"`
 	Subagents      []string  `json:"subagents"`
 	Hooks          []string  `json:"hooks"`
 	SlashCommands  []string  `json:"slash_commands"`
 	MCPServers     []string  `json:"mcp_servers"`
+	OutputStyle    string    `json:"output_style,omitempty"`
+	Permissions    []string  `json:"permissions,omitempty"`
+	Statusline     string    `json:"statusline,omitempty"`
 	ClaudeMDExtras string    `json:"claude_md_extras"`
+	SetupDoc       bool      `json:"setup_doc,omitempty"`
+	SetupReadme    bool      `json:"setup_readme,omitempty"`
+	MemoryFiles    []string  `json:"memory_files,omitempty"`
+	Gitignore      bool      `json:"gitignore,omitempty"`
+	EditorTasks    []string  `json:"editor_tasks,omitempty"`
+	Devcontainer   bool      `json:"devcontainer,omitempty"`
+	Workflows      []string  `json:"github_workflows,omitempty"`
+	Theme          string    `json:"theme,omitempty"`
+	SplitRatio     float64   `json:"split_ratio,omitempty"`
+	Packages       []string  `json:"packages,omitempty"`
+
+	IncludeDisabled bool `json:"include_disabled,omitempty"`
+
+	Layout manifest.Layout `json:"layout,omitzero"`
+
+	CustomSubagents []generation.CustomSubagent `json:"custom_subagents,omitempty"`
+	HookLanguages   map[string]string           `json:"hook_languages,omitempty"`
+
+	SlackWebhookURL   string `json:"slack_webhook_url,omitempty"`
+	DiscordWebhookURL string `json:"discord_webhook_url,omitempty"`
+
+	PermissionRules       []string `json:"permission_rules"` // null until edited; [] keeps no rules
+	CustomPermissionRules string   `json:"custom_permission_rules,omitempty"`
+
+	Env string `json:"env,omitempty"`
+
+	MCPUserScope []string `json:"mcp_user_scope,omitempty"`
+
+	CommandTools string `json:"command_tools,omitempty"`
+
+	AgentModels string              `json:"agent_models,omitempty"`
+	AgentTools  map[string][]string `json:"agent_tools,omitempty"`
+
+	HookMatchers string `json:"hook_matchers,omitempty"`
+	HookCommands string `json:"hook_commands,omitempty"`
+
+	AssetResolutions string `json:"asset_resolutions,omitempty"`
 }
 
 // Hook structs follow Anthropic's hooks schema.
@@ -79,8 +249,17 @@ type settings struct {
 		Ask   []string `json:"ask,omitempty"`
 		Deny  []string `json:"deny,omitempty"`
 	} `json:"permissions,omitempty"`
-	Hooks map[string][]hookMatcher `json:"hooks,omitempty"`
-	Env   map[string]string        `json:"env,omitempty"`
+	Hooks       map[string][]hookMatcher `json:"hooks,omitempty"`
+	Env         map[string]string        `json:"env,omitempty"`
+	OutputStyle string                   `json:"outputStyle,omitempty"`
+	StatusLine  *statusLine              `json:"statusLine,omitempty"`
+}
+
+// statusLine follows Claude Code's statusLine settings schema.
+type statusLine struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Padding int    `json:"padding,omitempty"`
 }
 
 // Module Registry Types (Feature 004)
@@ -89,10 +268,14 @@ type settings struct {
 type ModuleComponentType string
 
 const (
-	TypeSubagent ModuleComponentType = "subagent"
-	TypeHook     ModuleComponentType = "hook"
-	TypeMCP      ModuleComponentType = "mcp"
-	TypeCommand  ModuleComponentType = "command"
+	TypeSubagent    ModuleComponentType = "subagent"
+	TypeHook        ModuleComponentType = "hook"
+	TypeMCP         ModuleComponentType = "mcp"
+	TypeCommand     ModuleComponentType = "command"
+	TypeFramework   ModuleComponentType = "framework"
+	TypeStyle       ModuleComponentType = "style"
+	TypePermissions ModuleComponentType = "permissions"
+	TypeStatusline  ModuleComponentType = "statusline"
 )
 
 // ComponentModule represents a single modular component definition
@@ -109,31 +292,78 @@ type ComponentModule struct {
 	Dependencies []string       `json:"dependencies,omitempty"`
 	Defaults     map[string]any `json:"defaults,omitempty"`
 	Enabled      bool           `json:"enabled,omitempty"`
+
+	// Version constraints, e.g. ">=0.2" or ">=1.x"
+	RequiresClaudekit string `json:"requires_claudekit,omitempty"`
+	RequiresClaude    string `json:"requires_claude,omitempty"`
+
+	// Version of the module's content, recorded in the manifest to detect upgrades
+	Version string `json:"version,omitempty"`
+
+	// Source is the markdown file the module was loaded from: embedded, or in a
+	// user module directory
+	Source string `json:"-"`
+
+	// assets holds a user module's asset files; nil for the embedded assets/
+	assets fs.FS
+
+	// describe reads Description from Source for modules loaded from the cache
+	describe *lazyDescription
 }
 
 // GetDescription implements generation.ComponentModule interface
 func (m *ComponentModule) GetDescription() string {
+	if m.Description == "" && m.describe != nil {
+		return m.describe.get()
+	}
 	return m.Description
 }
 
+// lazyDescription reads a module's description from its markdown body on first use.
+type lazyDescription struct {
+	once sync.Once
+	load func() string
+	text string
+}
+
+func (d *lazyDescription) get() string {
+	d.once.Do(func() { d.text = d.load() })
+	return d.text
+}
+
 // GetCategory implements generation.ComponentModule interface
 func (m *ComponentModule) GetCategory() string {
 	return m.Category
 }
 
+// GetTools implements generation.ComponentModule interface
+func (m *ComponentModule) GetTools() []string {
+	return subagentTools(m)
+}
+
 // ModuleDefinition represents a module definition loaded from Markdown with YAML frontmatter
 // (Feature 008: Module Loading System Migration)
 type ModuleDefinition struct {
 	// Required fields (from frontmatter)
-	Name    string `yaml:"name"`
-	Type    string `yaml:"type"`
-	Enabled bool   `yaml:"enabled"`
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+
+	// Enabled is true unless the frontmatter sets enabled: false
+	Enabled bool `yaml:"enabled"`
 
 	// Optional fields (from frontmatter)
-	DisplayName string                 `yaml:"display_name,omitempty"`
-	Category    string                 `yaml:"category,omitempty"`
-	AssetPaths  []string               `yaml:"asset_paths,omitempty"`
-	Defaults    map[string]interface{} `yaml:"defaults,omitempty"`
+	DisplayName  string                 `yaml:"display_name,omitempty"`
+	Category     string                 `yaml:"category,omitempty"`
+	AssetPaths   []string               `yaml:"asset_paths,omitempty"`
+	Dependencies []string               `yaml:"dependencies,omitempty"` // "type/name", or a bare name of the same type
+	Defaults     map[string]interface{} `yaml:"defaults,omitempty"`
+
+	// Version constraints (optional)
+	RequiresClaudekit string `yaml:"requires_claudekit,omitempty"`
+	RequiresClaude    string `yaml:"requires_claude,omitempty"`
+
+	// Version of the module itself (optional), e.g. "1.2.0"
+	Version string `yaml:"version,omitempty"`
 
 	// Content field (from markdown body)
 	Description string `yaml:"-"` // Not in YAML
@@ -149,13 +379,35 @@ var (
 	ErrInvalidType       = errors.New("invalid module type")
 	ErrMissingDelimiters = errors.New("missing frontmatter delimiters")
 	ErrYAMLParse         = errors.New("YAML parse error")
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+	ErrInvalidVersion    = errors.New("invalid module version")
 )
 
 // ModuleRegistry manages the collection of all component modules
 type ModuleRegistry struct {
-	modules map[ModuleComponentType]map[string]*ComponentModule
-	loaded  bool
-	errors  []error
+	modules       map[ModuleComponentType]map[string]*ComponentModule
+	loaded        bool
+	errors        []error
+	claudeVersion func() string // Reports the installed Claude Code version; nil when unknown
+
+	templates map[string]templateOverride // User templates by name, replacing embedded assets
+}
+
+// moduleDirs are the directories modules are loaded from: assets/modules in
+// production and testdata/modules in tests.
+var moduleDirs = []string{"assets/modules", "testdata/modules"}
+
+// moduleTypeDirs maps the directories under a modules directory to the type of
+// module each holds.
+var moduleTypeDirs = map[string]ModuleComponentType{
+	"subagents":   TypeSubagent,
+	"hooks":       TypeHook,
+	"mcps":        TypeMCP,
+	"commands":    TypeCommand,
+	"frameworks":  TypeFramework,
+	"styles":      TypeStyle,
+	"permissions": TypePermissions,
+	"statuslines": TypeStatusline,
 }
 
 // Load discovers and loads all modules from the embedded filesystem
@@ -164,7 +416,7 @@ func (r *ModuleRegistry) Load(fs embed.FS) []error {
 	r.errors = []error{}
 
 	// Try both paths: assets/modules (production) and testdata/modules (testing)
-	basePaths := []string{"assets/modules", "testdata/modules"}
+	basePaths := moduleDirs
 	var entries []os.DirEntry
 	var err error
 	var basePath string
@@ -190,18 +442,8 @@ func (r *ModuleRegistry) Load(fs embed.FS) []error {
 		}
 
 		typeName := entry.Name()
-		var componentType ModuleComponentType
-
-		switch typeName {
-		case "subagents":
-			componentType = TypeSubagent
-		case "hooks":
-			componentType = TypeHook
-		case "mcps":
-			componentType = TypeMCP
-		case "commands":
-			componentType = TypeCommand
-		default:
+		componentType, ok := moduleTypeDirs[typeName]
+		if !ok {
 			continue // Skip unknown directories
 		}
 
@@ -238,16 +480,12 @@ func (r *ModuleRegistry) Load(fs embed.FS) []error {
 				continue
 			}
 
-			// Convert ModuleDefinition to ComponentModule for compatibility
-			module := ComponentModule{
-				Name:        moduleDef.Name,
-				Type:        ModuleComponentType(moduleDef.Type),
-				Description: moduleDef.Description,
-				DisplayName: moduleDef.DisplayName,
-				Category:    moduleDef.Category,
-				AssetPaths:  moduleDef.AssetPaths,
-				Defaults:    moduleDef.Defaults,
-				Enabled:     moduleDef.Enabled,
+			module := moduleFromDefinition(moduleDef, filePath)
+
+			// Modules built for a newer claudekit may rely on generator features we lack
+			if ok, _ := version.Satisfies(Version, module.RequiresClaudekit); module.RequiresClaudekit != "" && !ok {
+				r.errors = append(r.errors, fmt.Errorf("skipping %s: requires claudekit %s (running %s)", filePath, module.RequiresClaudekit, Version))
+				continue
 			}
 
 			// Validate and apply defaults
@@ -261,10 +499,249 @@ func (r *ModuleRegistry) Load(fs embed.FS) []error {
 		}
 	}
 
+	// Dependencies can name modules of any type, so they are checked once all are loaded
+	r.errors = append(r.errors, r.CheckDependencies()...)
+
 	r.loaded = true
 	return r.errors
 }
 
+// moduleFromDefinition converts a parsed module file to a registry module.
+func moduleFromDefinition(def ModuleDefinition, source string) ComponentModule {
+	return ComponentModule{
+		Name:        def.Name,
+		Type:        ModuleComponentType(def.Type),
+		Description: def.Description,
+		DisplayName: def.DisplayName,
+		Category:    def.Category,
+		AssetPaths:  def.AssetPaths,
+		Defaults:    def.Defaults,
+		Enabled:     def.Enabled,
+
+		Dependencies: def.Dependencies,
+
+		RequiresClaudekit: def.RequiresClaudekit,
+		RequiresClaude:    def.RequiresClaude,
+		Version:           def.Version,
+
+		Source: source,
+	}
+}
+
+// User modules are read from ~/.claudekit/modules, which is laid out like the
+// embedded assets: module files in subagents/, commands/, and the other type
+// directories, and the files they generate from in agents/ and templates/.
+const userModulesDir = ".claudekit/modules"
+
+// userModuleDirs lists the user module directories to load, in order; later wins.
+func userModuleDirs() []string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return []string{filepath.Join(home, userModulesDir)}
+	}
+	return nil
+}
+
+// LoadUserModules adds the modules in dir, a user module directory, replacing
+// loaded modules of the same type and name. A missing directory holds none.
+// Modules that fail to load are reported and skipped.
+func (r *ModuleRegistry) LoadUserModules(dir string) []error {
+	var errs []error
+	files := os.DirFS(dir)
+	for _, typeDir := range slices.Sorted(maps.Keys(moduleTypeDirs)) {
+		componentType := moduleTypeDirs[typeDir]
+		entries, err := fs.ReadDir(files, typeDir)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("cannot read %s: %w", filepath.Join(dir, typeDir), err))
+			}
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+				continue
+			}
+			filePath := filepath.Join(dir, typeDir, entry.Name())
+			data, err := fs.ReadFile(files, typeDir+"/"+entry.Name())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot read %s: %w", filePath, err))
+				continue
+			}
+			moduleDef, err := parseMarkdownModule(filePath, data)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse %s: %w", filePath, err))
+				continue
+			}
+			if ModuleComponentType(moduleDef.Type) != componentType {
+				errs = append(errs, fmt.Errorf("skipping %s: a %s module does not belong in %s/", filePath, moduleDef.Type, typeDir))
+				continue
+			}
+			module := moduleFromDefinition(moduleDef, filePath)
+			module.assets = files
+			if ok, _ := version.Satisfies(Version, module.RequiresClaudekit); module.RequiresClaudekit != "" && !ok {
+				errs = append(errs, fmt.Errorf("skipping %s: requires claudekit %s (running %s)", filePath, module.RequiresClaudekit, Version))
+				continue
+			}
+			if err := validateModule(&module, files); err != nil {
+				errs = append(errs, fmt.Errorf("validation failed for %s: %w", filePath, err))
+			}
+			if r.modules == nil {
+				r.modules = make(map[ModuleComponentType]map[string]*ComponentModule)
+			}
+			if r.modules[componentType] == nil {
+				r.modules[componentType] = make(map[string]*ComponentModule)
+			}
+			r.modules[componentType][module.Name] = &module
+		}
+	}
+	return errs
+}
+
+// readAsset reads one of the module's asset files, by its path in AssetPaths.
+func (m *ComponentModule) readAsset(assetPath string) ([]byte, error) {
+	if m.assets != nil {
+		return fs.ReadFile(m.assets, assetPath)
+	}
+	return assets.ReadFile("assets/" + assetPath)
+}
+
+// Parsed modules are cached in ~/.claudekit/cache.json, keyed by the claudekit
+// version and a hash of the embedded files, so startup can skip parsing them.
+const registryCacheFile = ".claudekit/cache.json"
+
+// registryCache is the contents of the cache file. Descriptions are left out and
+// read from the module's markdown when first asked for.
+type registryCache struct {
+	Version string         `json:"version"`
+	Hash    string         `json:"hash"`
+	Modules []cachedModule `json:"modules"`
+}
+
+// cachedModule is a module as cached, with the file it was loaded from.
+type cachedModule struct {
+	*ComponentModule
+	Source string `json:"source"`
+}
+
+// registryCachePath returns where the module cache lives, or "" without a home directory.
+func registryCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, registryCacheFile)
+}
+
+// LoadCached loads modules like Load, reusing the modules cached at path when this
+// claudekit cached them from the same files. A load without errors refreshes the
+// cache, so modules with problems are parsed, and reported, on every run. An empty
+// path disables the cache.
+func (r *ModuleRegistry) LoadCached(fsys embed.FS, path string) []error {
+	if path == "" {
+		return r.Load(fsys)
+	}
+	hash, err := embeddedHash(fsys)
+	if err != nil {
+		return r.Load(fsys)
+	}
+	if r.loadCache(fsys, path, hash) {
+		slog.Debug("loaded modules from cache", "path", path)
+		return r.errors
+	}
+	if errs := r.Load(fsys); len(errs) > 0 {
+		return errs
+	}
+	if err := r.saveCache(path, hash); err != nil {
+		slog.Debug("cannot write module cache", "path", path, "error", err)
+	}
+	return r.errors
+}
+
+// loadCache fills the registry from the cache at path, reporting false when there
+// is none or it was written by another claudekit or from other files.
+func (r *ModuleRegistry) loadCache(fsys embed.FS, path, hash string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var cache registryCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Version != Version || cache.Hash != hash {
+		return false
+	}
+	modules := make(map[ModuleComponentType]map[string]*ComponentModule)
+	for _, cached := range cache.Modules {
+		module := cached.ComponentModule
+		if module == nil || cached.Source == "" {
+			return false
+		}
+		module.Source = cached.Source
+		module.describe = &lazyDescription{load: func() string {
+			data, err := fsys.ReadFile(module.Source)
+			if err != nil {
+				return ""
+			}
+			_, body, _ := extractFrontmatter(string(data))
+			return body
+		}}
+		if module.Defaults == nil {
+			module.Defaults = make(map[string]any)
+		}
+		if modules[module.Type] == nil {
+			modules[module.Type] = make(map[string]*ComponentModule)
+		}
+		modules[module.Type][module.Name] = module
+	}
+	r.modules = modules
+	r.errors = r.CheckDependencies()
+	r.loaded = true
+	return true
+}
+
+// saveCache writes the loaded modules to the cache at path.
+func (r *ModuleRegistry) saveCache(path, hash string) error {
+	cache := registryCache{Version: Version, Hash: hash, Modules: []cachedModule{}}
+	for _, componentType := range moduleTypes {
+		for _, module := range r.List(componentType) {
+			cached := *module
+			cached.Description = ""
+			cache.Modules = append(cache.Modules, cachedModule{ComponentModule: &cached, Source: module.Source})
+		}
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// embeddedHash hashes the name of every file in fsys, which covers the assets
+// modules point at, and the contents of the module files.
+func embeddedHash(fsys embed.FS) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00", path)
+		if !slices.ContainsFunc(moduleDirs, func(dir string) bool { return strings.HasPrefix(path, dir+"/") }) {
+			return nil
+		}
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%d\x00", len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // Get retrieves a specific module by type and name
 func (r *ModuleRegistry) Get(componentType ModuleComponentType, name string) *ComponentModule {
 	if r == nil || r.modules == nil {
@@ -276,7 +753,7 @@ func (r *ModuleRegistry) Get(componentType ModuleComponentType, name string) *Co
 	return nil
 }
 
-// List returns all modules of a given type, sorted by name
+// List returns all modules of a given type, sorted by name, including disabled ones
 func (r *ModuleRegistry) List(componentType ModuleComponentType) []*ComponentModule {
 	if r == nil || r.modules == nil {
 		return []*ComponentModule{}
@@ -307,16 +784,66 @@ func (r *ModuleRegistry) List(componentType ModuleComponentType) []*ComponentMod
 	return modules
 }
 
-// GetOptions generates TUI form options for a component type
-func (r *ModuleRegistry) GetOptions(componentType ModuleComponentType) []huh.Option[string] {
+// SetClaudeVersion records the installed Claude Code version so GetOptions can flag
+// modules that need a newer release. An empty version disables the check.
+func (r *ModuleRegistry) SetClaudeVersion(v string) {
+	r.claudeVersion = func() string { return v }
+}
+
+// ClaudeVersion returns the installed Claude Code version, or "" when it is unknown.
+func (r *ModuleRegistry) ClaudeVersion() string {
+	if r.claudeVersion == nil {
+		return ""
+	}
+	return r.claudeVersion()
+}
+
+// claudeVersionFor returns the Claude Code version to check m against. Only a module
+// that declares requires_claude needs it, so no other module waits on detecting it.
+func (r *ModuleRegistry) claudeVersionFor(m *ComponentModule) string {
+	if m.RequiresClaude == "" {
+		return ""
+	}
+	return r.ClaudeVersion()
+}
+
+// UnmetRequirements describes each version constraint the given environment does not
+// satisfy. An empty claudeVersion means Claude Code is not installed and is not checked.
+func (m *ComponentModule) UnmetRequirements(claudekitVersion, claudeVersion string) []string {
+	var unmet []string
+	if m.RequiresClaudekit != "" {
+		if ok, _ := version.Satisfies(claudekitVersion, m.RequiresClaudekit); !ok {
+			unmet = append(unmet, fmt.Sprintf("requires claudekit %s (running %s)", m.RequiresClaudekit, claudekitVersion))
+		}
+	}
+	if m.RequiresClaude != "" && claudeVersion != "" {
+		if ok, _ := version.Satisfies(claudeVersion, m.RequiresClaude); !ok {
+			unmet = append(unmet, fmt.Sprintf("requires Claude Code %s (installed %s)", m.RequiresClaude, claudeVersion))
+		}
+	}
+	return unmet
+}
+
+// GetOptions generates TUI form options for a component type. Disabled modules are
+// left out unless includeDisabled is set, and are then marked as disabled.
+func (r *ModuleRegistry) GetOptions(componentType ModuleComponentType, includeDisabled bool) []huh.Option[string] {
 	modules := r.List(componentType)
 	options := make([]huh.Option[string], 0, len(modules))
 
 	for _, module := range modules {
+		if !module.Enabled && !includeDisabled {
+			continue
+		}
 		displayText := module.Name
 		if module.DisplayName != "" {
 			displayText = module.DisplayName
 		}
+		if !module.Enabled {
+			displayText += " (disabled)"
+		}
+		if unmet := module.UnmetRequirements(Version, r.claudeVersionFor(module)); len(unmet) > 0 {
+			displayText += " ⚠ " + strings.Join(unmet, "; ")
+		}
 		options = append(options, huh.NewOption(displayText, module.Name))
 	}
 
@@ -324,1853 +851,14184 @@ func (r *ModuleRegistry) GetOptions(componentType ModuleComponentType) []huh.Opt
 }
 
 // ============================================================================
-// Feature 008: Module Loading from Markdown with YAML Frontmatter
+// Module dependencies: selecting a module selects what it needs
 // ============================================================================
 
-// extractFrontmatter extracts YAML frontmatter and markdown body from content
-// Returns frontmatter YAML, body content, and error if delimiters missing
-func extractFrontmatter(content string) (frontmatter, body string, err error) {
-	// Split on --- delimiters
-	parts := strings.SplitN(content, "---", 3)
+// moduleRef names a module by type and name.
+type moduleRef struct {
+	Type ModuleComponentType
+	Name string
+}
 
-	// Need at least 3 parts: [empty/whitespace, frontmatter, body]
-	if len(parts) < 3 {
-		return "", "", ErrMissingDelimiters
-	}
+func (ref moduleRef) String() string {
+	return string(ref.Type) + "/" + ref.Name
+}
 
-	// First part should be empty or whitespace only (before opening ---)
-	if strings.TrimSpace(parts[0]) != "" {
-		return "", "", fmt.Errorf("%w: opening delimiter not at start", ErrMissingDelimiters)
+// parseDependency reads a dependency entry of a module of type from: "type/name"
+// names a module of any type, and a bare name one of the same type.
+func parseDependency(dep string, from ModuleComponentType) (moduleRef, error) {
+	typeName, name, ok := strings.Cut(dep, "/")
+	if !ok {
+		return moduleRef{Type: from, Name: dep}, nil
 	}
+	ref := moduleRef{Type: ModuleComponentType(typeName), Name: name}
+	if !slices.Contains(moduleTypes, ref.Type) || name == "" || strings.Contains(name, "/") {
+		return ref, fmt.Errorf("invalid dependency %q (want type/name, e.g. hook/pre-tool-use)", dep)
+	}
+	return ref, nil
+}
 
-	frontmatter = strings.TrimSpace(parts[1])
-	body = strings.TrimSpace(parts[2])
-
-	return frontmatter, body, nil
+// moduleTypes lists every module type.
+var moduleTypes = []ModuleComponentType{
+	TypeSubagent, TypeHook, TypeMCP, TypeCommand, TypeFramework, TypeStyle, TypePermissions, TypeStatusline,
 }
 
-// Validate checks if ModuleDefinition has all required fields and valid values
-func (m *ModuleDefinition) Validate() error {
-	// Required field: name
-	if m.Name == "" {
-		return ErrMissingName
+// dependencies resolves a module's dependency entries, skipping ones that do not parse.
+func (m *ComponentModule) dependencies() []moduleRef {
+	var refs []moduleRef
+	for _, dep := range m.Dependencies {
+		if ref, err := parseDependency(dep, m.Type); err == nil {
+			refs = append(refs, ref)
+		}
 	}
+	return refs
+}
 
-	// Required field: type
-	if m.Type == "" {
-		return ErrMissingType
+// CheckDependencies reports dependency entries that do not parse or name a module
+// that is not loaded, and every dependency cycle. Modules with such problems stay
+// loaded; resolution skips what it cannot find.
+func (r *ModuleRegistry) CheckDependencies() []error {
+	var errs []error
+	var all []moduleRef
+	for _, componentType := range slices.Sorted(maps.Keys(r.modules)) {
+		for _, module := range r.List(componentType) {
+			ref := moduleRef{componentType, module.Name}
+			all = append(all, ref)
+			for _, dep := range module.Dependencies {
+				target, err := parseDependency(dep, componentType)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", ref, err))
+				} else if r.Get(target.Type, target.Name) == nil {
+					errs = append(errs, fmt.Errorf("%s: unsatisfiable dependency on %s, which does not exist", ref, target))
+				}
+			}
+		}
 	}
 
-	// Type must be valid enum (FR-008)
-	validTypes := map[string]bool{
-		"subagent": true,
-		"hook":     true,
-		"command":  true,
-		"mcp":      true,
+	// Depth-first search; reaching a module still on the stack closes a cycle
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := map[moduleRef]int{}
+	var stack []moduleRef
+	var visit func(ref moduleRef)
+	visit = func(ref moduleRef) {
+		state[ref] = onStack
+		stack = append(stack, ref)
+		for _, dep := range r.Get(ref.Type, ref.Name).dependencies() {
+			if r.Get(dep.Type, dep.Name) == nil {
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case onStack:
+				cycle := stack[slices.Index(stack, dep):]
+				names := make([]string, 0, len(cycle)+1)
+				for _, member := range cycle {
+					names = append(names, member.String())
+				}
+				errs = append(errs, fmt.Errorf("dependency cycle: %s", strings.Join(append(names, dep.String()), " -> ")))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[ref] = done
 	}
-	if !validTypes[m.Type] {
-		return fmt.Errorf("%w: %s (must be subagent, hook, command, or mcp)", ErrInvalidType, m.Type)
+	for _, ref := range all {
+		if state[ref] == unvisited {
+			visit(ref)
+		}
 	}
-
-	// Note: Enabled is bool, zero value (false) is valid
-	// Note: Optional fields can be empty/nil
-
-	return nil
+	return errs
 }
 
-// parseMarkdownModule parses a single module file with YAML frontmatter
-func parseMarkdownModule(path string, content []byte) (ModuleDefinition, error) {
-	var module ModuleDefinition
+// dependencyAddition is a module selected because a selected module depends on it.
+type dependencyAddition struct {
+	Module     moduleRef
+	RequiredBy moduleRef
+}
 
-	// Extract frontmatter and body
-	frontmatterYAML, body, err := extractFrontmatter(string(content))
-	if err != nil {
-		return module, fmt.Errorf("failed to parse %s: %w", path, err)
+// ResolveDependencies selects in cfg every module its selections depend on, directly
+// or through other dependencies, and returns the additions in the order they were
+// made. An output style or statusline dependency never replaces a different choice,
+// and a disabled module is only selected with cfg.IncludeDisabled; both are
+// described in conflicts instead.
+func (r *ModuleRegistry) ResolveDependencies(cfg *Config) (added []dependencyAddition, conflicts []string) {
+	queue := selectedModules(cfg)
+	seen := map[moduleRef]bool{}
+	for _, ref := range queue {
+		seen[ref] = true
+	}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		module := r.Get(ref.Type, ref.Name)
+		if module == nil {
+			continue // A custom subagent or a module this claudekit lacks
+		}
+		for _, dep := range module.dependencies() {
+			if seen[dep] || r.Get(dep.Type, dep.Name) == nil {
+				continue
+			}
+			seen[dep] = true
+			if !r.Get(dep.Type, dep.Name).Enabled && !cfg.IncludeDisabled {
+				conflicts = append(conflicts, fmt.Sprintf("%s needs %s, which is disabled; use --include-disabled to select it", ref, dep))
+				continue
+			}
+			if !selectModule(cfg, dep) {
+				conflicts = append(conflicts, fmt.Sprintf("%s needs %s, but a different %s is selected", ref, dep, dep.Type))
+				continue
+			}
+			added = append(added, dependencyAddition{Module: dep, RequiredBy: ref})
+			queue = append(queue, dep)
+		}
 	}
+	return added, conflicts
+}
 
-	// Parse YAML frontmatter
-	err = yaml.Unmarshal([]byte(frontmatterYAML), &module)
-	if err != nil {
-		return module, fmt.Errorf("failed to parse %s: %w: %v", path, ErrYAMLParse, err)
+// selectedModules lists cfg's selections as module references, in form page order.
+func selectedModules(cfg *Config) []moduleRef {
+	var refs []moduleRef
+	add := func(componentType ModuleComponentType, names ...string) {
+		for _, name := range names {
+			if name = cleanFormValue(name); name != "" {
+				refs = append(refs, moduleRef{componentType, name})
+			}
+		}
 	}
+	add(TypeFramework, cfg.Frameworks...)
+	add(TypeSubagent, cfg.Subagents...)
+	add(TypeHook, cfg.Hooks...)
+	add(TypeCommand, cfg.SlashCommands...)
+	add(TypeMCP, cfg.MCPServers...)
+	add(TypePermissions, cfg.Permissions...)
+	add(TypeStyle, cfg.OutputStyle)
+	add(TypeStatusline, cfg.Statusline)
+	return refs
+}
 
-	// Set description from markdown body
-	module.Description = body
-
-	// Set file path
-	module.FilePath = path
+// DropDisabled removes disabled modules from cfg's selections and returns them.
+func (r *ModuleRegistry) DropDisabled(cfg *Config) []moduleRef {
+	var dropped []moduleRef
+	deselectModules(cfg, func(ref moduleRef) bool {
+		module := r.Get(ref.Type, ref.Name)
+		if module == nil || module.Enabled {
+			return false // Custom subagents and unknown names are not ours to drop
+		}
+		dropped = append(dropped, moduleRef{ref.Type, module.Name})
+		return true
+	})
+	return dropped
+}
 
-	// Validate required fields
-	err = module.Validate()
-	if err != nil {
-		return module, fmt.Errorf("failed to parse %s: %w", path, err)
+// deselectModules removes the selections of cfg that drop reports true for.
+func deselectModules(cfg *Config, drop func(moduleRef) bool) {
+	filter := func(componentType ModuleComponentType, names []string) []string {
+		return slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+			return drop(moduleRef{componentType, cleanFormValue(name)})
+		})
+	}
+	cfg.Frameworks = filter(TypeFramework, cfg.Frameworks)
+	cfg.Subagents = filter(TypeSubagent, cfg.Subagents)
+	cfg.Hooks = filter(TypeHook, cfg.Hooks)
+	cfg.SlashCommands = filter(TypeCommand, cfg.SlashCommands)
+	cfg.MCPServers = filter(TypeMCP, cfg.MCPServers)
+	cfg.Permissions = filter(TypePermissions, cfg.Permissions)
+	if cfg.OutputStyle != "" && drop(moduleRef{TypeStyle, cleanFormValue(cfg.OutputStyle)}) {
+		cfg.OutputStyle = ""
+	}
+	if cfg.Statusline != "" && drop(moduleRef{TypeStatusline, cleanFormValue(cfg.Statusline)}) {
+		cfg.Statusline = ""
 	}
+}
 
-	return module, nil
+// assetConflict is an asset path that more than one selected module declares, so
+// their generated files would come from the same asset.
+type assetConflict struct {
+	Path    string
+	Modules []moduleRef // In selection order
 }
 
-// loadModulesFromMarkdown loads all module files from embedded filesystem
-func loadModulesFromMarkdown(fsys embed.FS) ([]ModuleDefinition, error) {
-	var modules []ModuleDefinition
+func (c assetConflict) String() string {
+	refs := make([]string, len(c.Modules))
+	for i, ref := range c.Modules {
+		refs[i] = ref.String()
+	}
+	return fmt.Sprintf("%s is declared by %s", c.Path, strings.Join(refs, " and "))
+}
 
-	// Walk the assets/modules directory
-	err := fs.WalkDir(fsys, "assets/modules", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+// How an asset conflict is resolved, set per asset path on the asset conflicts page.
+// A path without a resolution keeps every module.
+const (
+	assetRename    = "rename"    // Keep every module, each reading the asset from its own path
+	assetSkip      = "skip"      // Keep the module selected first and leave out the others
+	assetOverwrite = "overwrite" // Keep the module selected last, in place of the others
+)
 
-		// Skip directories, non-.md files, and README.md files
-		if d.IsDir() || !strings.HasSuffix(path, ".md") || strings.HasSuffix(path, "README.md") {
-			return nil
-		}
+// renamedAssetPath is the path a module reads an asset from once renaming settled
+// its conflict: the asset's directory gains one named after the module, so
+// agents/helper.md becomes agents/<module>/helper.md.
+func renamedAssetPath(ref moduleRef, assetPath string) string {
+	return path.Join(path.Dir(assetPath), ref.Name, path.Base(assetPath))
+}
 
-		// Read file content
-		content, err := fsys.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", path, err)
+// AssetConflicts returns the asset paths more than one of cfg's selected modules
+// declares, in the order the first module declaring each was selected.
+func (r *ModuleRegistry) AssetConflicts(cfg *Config) []assetConflict {
+	var conflicts []assetConflict
+	index := map[string]int{}
+	for _, ref := range selectedModules(cfg) {
+		module := r.Get(ref.Type, ref.Name)
+		if module == nil {
+			continue
+		}
+		for _, assetPath := range module.AssetPaths {
+			assetPath = path.Clean(assetPath)
+			i, seen := index[assetPath]
+			if !seen {
+				index[assetPath] = len(conflicts)
+				conflicts = append(conflicts, assetConflict{Path: assetPath, Modules: []moduleRef{ref}})
+			} else if !slices.Contains(conflicts[i].Modules, ref) {
+				conflicts[i].Modules = append(conflicts[i].Modules, ref)
+			}
 		}
+	}
+	return slices.DeleteFunc(conflicts, func(c assetConflict) bool { return len(c.Modules) < 2 })
+}
 
-		// Parse module
-		module, err := parseMarkdownModule(path, content)
-		if err != nil {
-			return err // Fail-fast on any parse error (FR-013)
+// ResolveAssetConflicts applies the resolutions in cfg.AssetResolutions to the
+// asset conflicts of its selections, recording the paths renaming gives each
+// module in cfg.AssetRenames. It returns the modules it left out, the conflicts it
+// renamed, and those without a resolution, whose modules are all kept.
+func (r *ModuleRegistry) ResolveAssetConflicts(cfg *Config) (dropped []moduleRef, renamed, unresolved []assetConflict) {
+	resolutions := parseAssetResolutionLines(cfg.AssetResolutions)
+	cfg.AssetRenames = nil
+	for _, conflict := range r.AssetConflicts(cfg) {
+		// Modules an earlier resolution left out no longer take part
+		conflict.Modules = slices.DeleteFunc(conflict.Modules, func(ref moduleRef) bool { return slices.Contains(dropped, ref) })
+		if len(conflict.Modules) < 2 {
+			continue
 		}
+		var losers []moduleRef
+		switch resolutions[conflict.Path] {
+		case assetRename:
+			for _, ref := range conflict.Modules {
+				if cfg.AssetRenames == nil {
+					cfg.AssetRenames = map[moduleRef]map[string]string{}
+				}
+				if cfg.AssetRenames[ref] == nil {
+					cfg.AssetRenames[ref] = map[string]string{}
+				}
+				cfg.AssetRenames[ref][conflict.Path] = renamedAssetPath(ref, conflict.Path)
+			}
+			renamed = append(renamed, conflict)
+		case assetSkip:
+			losers = conflict.Modules[1:]
+		case assetOverwrite:
+			losers = conflict.Modules[:len(conflict.Modules)-1]
+		default:
+			unresolved = append(unresolved, conflict)
+		}
+		for _, ref := range losers {
+			if !slices.Contains(dropped, ref) {
+				dropped = append(dropped, ref)
+			}
+		}
+	}
+	deselectModules(cfg, func(ref moduleRef) bool { return slices.Contains(dropped, ref) })
+	for ref := range cfg.AssetRenames {
+		if slices.Contains(dropped, ref) {
+			delete(cfg.AssetRenames, ref)
+		}
+	}
+	return dropped, renamed, unresolved
+}
 
-		modules = append(modules, module)
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
+// readModuleAsset reads one of module's asset files, from the module's own path
+// when renaming settled a conflict over it. Until a file exists there, the shared
+// one is read, so the module generates what it did before.
+func (cfg Config) readModuleAsset(module *ComponentModule, assetPath string) ([]byte, error) {
+	if renamed, ok := cfg.AssetRenames[moduleRef{module.Type, module.Name}][path.Clean(assetPath)]; ok {
+		content, err := module.readAsset(renamed)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return content, err
+		}
 	}
+	return module.readAsset(assetPath)
+}
 
-	return modules, nil
+// selectModule adds ref to cfg's selections. It reports false when ref is an output
+// style or statusline and a different one is already chosen.
+func selectModule(cfg *Config, ref moduleRef) bool {
+	single := func(choice *string) bool {
+		if *choice != "" && *choice != ref.Name {
+			return false
+		}
+		*choice = ref.Name
+		return true
+	}
+	switch ref.Type {
+	case TypeFramework:
+		cfg.Frameworks = append(cfg.Frameworks, ref.Name)
+	case TypeSubagent:
+		cfg.Subagents = append(cfg.Subagents, ref.Name)
+	case TypeHook:
+		cfg.Hooks = append(cfg.Hooks, ref.Name)
+	case TypeCommand:
+		cfg.SlashCommands = append(cfg.SlashCommands, ref.Name)
+	case TypeMCP:
+		cfg.MCPServers = append(cfg.MCPServers, ref.Name)
+	case TypePermissions:
+		// No presets means the default one; keep it alongside the dependency
+		if len(cfg.Permissions) == 0 && ref.Name != defaultPermissionPreset {
+			cfg.Permissions = append(cfg.Permissions, defaultPermissionPreset)
+		}
+		cfg.Permissions = append(cfg.Permissions, ref.Name)
+	case TypeStyle:
+		return single(&cfg.OutputStyle)
+	case TypeStatusline:
+		return single(&cfg.Statusline)
+	}
+	return true
 }
 
 // ============================================================================
-// Feature 005: Asset File Generation Types
+// Module overrides: rename and re-describe embedded modules
 // ============================================================================
 
-// validateModule checks required fields and applies defaults
-func validateModule(module *ComponentModule, fs embed.FS) error {
-	var errs []string
+// Module overrides are read from ~/.claudekit-overrides.yaml and then the current
+// directory's .claudekit-overrides.yaml, or only from the file CLAUDEKIT_OVERRIDES names.
+const (
+	moduleOverridesFileName = ".claudekit-overrides.yaml"
+	envModuleOverrides      = "CLAUDEKIT_OVERRIDES"
+)
 
-	// Check required fields (Feature 008: only name and type are required)
-	if module.Name == "" {
-		errs = append(errs, "name is required")
+// moduleOverride replaces what the form and generated docs show for a module. Empty
+// fields keep the module's own value; assets are never affected.
+type moduleOverride struct {
+	DisplayName string `yaml:"display_name"`
+	Category    string `yaml:"category"`
+	Description string `yaml:"description"`
+}
+
+// moduleOverrides is the contents of an overrides file: module type, then module name.
+type moduleOverrides map[ModuleComponentType]map[string]moduleOverride
+
+// moduleOverridePaths lists the overrides files to apply, in order; later files win.
+func moduleOverridePaths() []string {
+	if path := os.Getenv(envModuleOverrides); path != "" {
+		return []string{path}
 	}
-	if module.Type == "" {
-		errs = append(errs, "type is required")
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, moduleOverridesFileName))
 	}
-	// Description and AssetPaths are optional (e.g., MCPs don't need asset_paths)
+	return append(paths, moduleOverridesFileName)
+}
 
-	// Validate asset paths exist (warning only, not fatal)
-	for _, assetPath := range module.AssetPaths {
-		// Try multiple base paths for assets
-		found := false
-		for _, base := range []string{"assets/", "testdata/", ""} {
-			fullPath := base + assetPath
-			if _, err := fs.ReadFile(fullPath); err == nil {
-				found = true
-				break
-			}
-		}
-		if !found {
-			// Log warning but don't fail - asset might be optional
-			errs = append(errs, fmt.Sprintf("asset not found: %s", assetPath))
+// loadModuleOverrides reads an overrides file. A missing file yields no overrides.
+func loadModuleOverrides(path string) (moduleOverrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
 	}
 
-	// Apply defaults for optional fields
-	if module.DisplayName == "" {
-		module.DisplayName = module.Name
-	}
-	if module.Defaults == nil {
-		module.Defaults = make(map[string]any)
-	}
-
-	if len(errs) > 0 {
-		return fmt.Errorf("validation errors: %s", strings.Join(errs, "; "))
+	var overrides moduleOverrides
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
 	}
-	return nil
+	return overrides, nil
 }
 
-// ASCII Art Title (T011) - Pre-generated with figlet "small" font
-const asciiTitle = `┏━╸╻  ┏━┓╻ ╻╺┳┓┏━╸   ╻┏ ╻╺┳╸
-┃  ┃  ┣━┫┃ ┃ ┃┃┣╸    ┣┻┓┃ ┃ 
-┗━╸┗━╸╹ ╹┗━┛╺┻┛┗━╸   ╹ ╹╹ ╹ `
-
-// Bubble Tea Model for the application
-type model struct {
-	form            *huh.Form
-	config          *Config
-	viewport        viewport.Model
-	glamourRenderer *glamour.TermRenderer
-	ready           bool
-	width           int
-	height          int
-	currentFocus    string
-
-	// Gradient system fields (T028)
-	terminalCap  gradient.TerminalCapability
-	currentTheme gradient.Theme
-	transition   gradient.TransitionState
-	styleMap     map[gradient.ComponentType]map[gradient.VisualState]gradient.ComponentStyle
-
-	// Module registry (Feature 004)
-	registry *ModuleRegistry
-
-	// Adaptive right panel layout (Feature 007)
-	showRightPanel  bool                // Computed: width >= 140 && height >= 40
-	resizeDebouncer *time.Timer         // Active debounce timer (nil if none)
-	pendingResize   *tea.WindowSizeMsg  // Cached resize message during debounce
+// ApplyOverrides replaces display names, categories, and descriptions of loaded
+// modules. Overrides for unknown modules are reported, not fatal, so one file can
+// serve several claudekit versions.
+func (r *ModuleRegistry) ApplyOverrides(overrides moduleOverrides, source string) []error {
+	var errs []error
+	for componentType, byName := range overrides {
+		for name, override := range byName {
+			module := r.Get(componentType, name)
+			if module == nil {
+				errs = append(errs, fmt.Errorf("%s: no %s module named %q", source, componentType, name))
+				continue
+			}
+			if override.DisplayName != "" {
+				module.DisplayName = override.DisplayName
+			}
+			if override.Category != "" {
+				module.Category = override.Category
+			}
+			if description := strings.TrimSpace(override.Description); description != "" {
+				module.Description = description
+			}
+		}
+	}
+	return errs
 }
 
-// Styles for the Uaud
-var (
-	titleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFDF5")).
-			Background(lipgloss.Color("#25A065")).
-			Padding(0, 1)
-
-	statusStyle = lipgloss.NewStyle().
-			Padding(1).
-			MarginLeft(2)
+// ============================================================================
+// Template overrides: user templates in place of embedded assets
+// ============================================================================
 
-	formStyle = lipgloss.NewStyle().
-			Padding(1)
+// Template overrides are read from ~/.claudekit/templates and then the current
+// directory's .claudekit/templates; a template in a later directory wins.
+const templateOverridesDir = ".claudekit/templates"
 
-	descStyle = lipgloss.NewStyle().
-			BorderTop(true).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#25A065")).
-			Padding(1).
-			MarginTop(1)
+// Overridable templates, by path within a templates directory. Hook scripts are
+// overridden by hooks/<hook><ext>.tmpl and agents by agents/<name>.md.tmpl.
+const (
+	claudeMDTemplate      = "CLAUDE.md.tmpl"
+	claudeLocalTemplate   = "CLAUDE.local.md.tmpl"
+	setupDocTemplate      = "CLAUDE-SETUP.md.tmpl"
+	workflowTemplate      = "claude.yml.tmpl"
+	devcontainerTemplate  = "claude-setup.sh.tmpl"
+	postWriteLintTemplate = "hooks/postwrite-lint.sh.tmpl"
+	secretScanTemplate    = "hooks/secret-scan.py.tmpl"
+	testRunnerTemplate    = "hooks/test-runner.sh.tmpl"
+	branchGuardTemplate   = "hooks/branch-guard.sh.tmpl"
 )
 
-// Descriptions for subagents, MCPs, hooks, and commands are now loaded from JSON modules (Feature 004)
-
-// Detailed descriptions for programming languages
-var languageDescriptions = map[string]string{
-	"Go": "## 🐹 Go\nSimple, fast, concurrent. Master goroutines and channels for scalable microservices and cloud-native applications.\n\n### Key Features\n\n* Clean, readable syntax\n* Excellent standard library\n* Built-in concurrency primitives\n* Fast compilation and execution\n\n-------\n\n### Example\n\n```go\npackage main\n\nimport \"fmt\"\n\nfunc main() {\n    fmt.Println(\"Hello, World!\")\n}\n```\n\n---\n\nPerfect for APIs, distributed systems, and microservices.",
-	
-	"TypeScript": "## 🟦 TypeScript\nJavaScript with static types. Build type-safe web applications with excellent IntelliSense and compile-time error catching.\n\n### Key Features\n* Static type checking\n* Excellent IDE support\n* Scales from small to enterprise projects\n* Works with React, Next.js, Node.js, Angular, Vue\n\n---\n\n### Example\n```typescript\nconsole.log(\"Hello, World!\");\n```\n\n---\n\nPerfect for full-stack web development and large-scale applications.",
-
-	"Python": "## 🐍 Python\nReadable, versatile, powerful. Write clean code for data science, machine learning, web development, and automation.\n\n### Key Features\n* Clean, readable syntax\n* Rich ecosystem (Django, FastAPI, NumPy, pandas, PyTorch)\n* Excellent for data science and ML\n* Rapid prototyping and development\n\n---\n\n### Example\n```python\nprint(\"Hello, World!\")\n```\n\n---\n\nPerfect for scientific computing, web apps, and automation scripts.",
-
-	"Java": "## ☕ Java\nEnterprise-grade reliability. Build scalable applications with Spring Boot, microservices architecture, and proven design patterns.\n\n### Key Features\n* Robust JVM performance\n* Extensive standard library\n* Strong typing and OOP\n* Cross-platform compatibility\n\n---\n\n### Example\n```java\npublic class HelloWorld {\n    public static void main(String[] args) {\n        System.out.println(\"Hello, World!\");\n    }\n}\n```\n\n---\n\nExcellent for large-scale enterprise systems and backend services.",
-
-	"Rust": "## 🦀 Rust\nMemory-safe systems programming. Zero-cost abstractions with ownership model and fearless concurrency.\n\n### Key Features\n* Memory safety without garbage collection\n* Prevents data races at compile time\n* Zero-cost abstractions\n* Excellent performance\n\n---\n\n### Example\n```rust\nfn main() {\n    println!(\"Hello, World!\");\n}\n```\n\n---\n\nPerfect for operating systems, game engines, and performance-critical applications.",
-
-	"C++": "## ⚡ C++\nHigh-performance systems programming. Modern C++20 features with RAII patterns and efficient low-level control.\n\n### Key Features\n* Maximum speed and control\n* Templates and metaprogramming\n* Smart pointers and RAII\n* Zero-overhead abstractions\n\n---\n\n### Example\n```cpp\n#include <iostream>\n\nint main() {\n    std::cout << \"Hello, World!\" << std::endl;\n    return 0;\n}\n```\n\n---\n\nPerfect for game engines, embedded systems, and performance-critical applications.",
-
-	"C#": "## 💎 C#\nModern .NET development. Build cross-platform applications with LINQ, async/await, and excellent tooling.\n\n### Key Features\n* Rich type system and LINQ\n* Async/await for concurrency\n* Cross-platform with .NET Core\n* Desktop (WPF), Web (ASP.NET), Cloud (Azure)\n\n---\n\n### Example\n```csharp\nusing System;\n\nclass Program {\n    static void Main() {\n        Console.WriteLine(\"Hello, World!\");\n    }\n}\n```\n\n---\n\nPerfect for enterprise applications and Microsoft ecosystem integration.",
-	
-	"PHP": "## 🐘 PHP\nWeb development made easy. Modern frameworks like Laravel and Symfony for rapid application development.\n\n### Key Features\n* Easy database integration\n* Modern PHP 8+ features\n* Rich framework ecosystem (Laravel, Symfony)\n* Excellent for web applications\n\n---\n\n### Example\n```php\n<?php\necho \"Hello, World!\";\n?>\n```\n\n---\n\nPerfect for CMS, e-commerce, and dynamic web applications.",
-
-	"Ruby": "## 💎 Ruby\nDeveloper happiness first. Elegant Rails development with convention over configuration and expressive syntax.\n\n### Key Features\n* Beautiful, readable syntax\n* Rails framework for rapid development\n* Rich gem ecosystem\n* Powerful metaprogramming\n\n---\n\n### Example\n```ruby\nputs \"Hello, World!\"\n```\n\n---\n\nPerfect for web applications, automation scripts, and developer-friendly APIs.",
-
-	"Swift": "## 🍎 Swift\nApple's modern language. Build native iOS, macOS, and watchOS apps with SwiftUI and protocol-oriented programming.\n\n### Key Features\n* Optionals for null safety\n* SwiftUI for declarative UIs\n* Automatic memory management (ARC)\n* Protocol-oriented programming\n\n---\n\n### Example\n```swift\nprint(\"Hello, World!\")\n```\n\n---\n\nPerfect for iOS and macOS app development.",
-
-	"Kotlin": "## 🎯 Kotlin\nConcise JVM language. Android development with coroutines, null safety, and 100% Java interoperability.\n\n### Key Features\n* Null safety built-in\n* Coroutines for async programming\n* 100% Java interoperability\n* Multiplatform support\n\n---\n\n### Example\n```kotlin\nfun main() {\n    println(\"Hello, World!\")\n}\n```\n\n---\n\nPerfect for Android apps and server-side development.",
-	
-	"Dart": "## 🎯 Dart\nFlutter's foundation. Build beautiful cross-platform apps for iOS, Android, web, and desktop from one codebase.\n\n### Key Features\n* Single codebase for all platforms\n* Hot reload for fast development\n* Rich widget library\n* Native performance\n\n---\n\n### Example\n```dart\nvoid main() {\n  print('Hello, World!');\n}\n```\n\n---\n\nPerfect for cross-platform mobile and web applications.",
-
-	"Shell": "## 🐚 Shell/Bash\nSystem automation master. Write robust scripts for deployment, system administration, and file processing.\n\n### Key Features\n* Powerful text processing with pipes\n* System administration and automation\n* CI/CD pipeline integration\n* Universal Unix/Linux availability\n\n---\n\n### Example\n```bash\n#!/bin/bash\necho \"Hello, World!\"\n```\n\n---\n\nPerfect for automation, DevOps, and system administration.",
-
-	"Lua": "## 🌙 Lua\nLightweight scripting. Embedded applications, game scripting, and configuration management with minimal footprint.\n\n### Key Features\n* Tiny footprint (~280KB)\n* Fast execution\n* Easy C integration\n* Simple, clean syntax\n\n---\n\n### Example\n```lua\nprint(\"Hello, World!\")\n```\n\n---\n\nPerfect for game scripting, embedded systems, and application extensions.",
-
-	"Elixir": "## 💧 Elixir\nFault-tolerant concurrency. Actor model with millions of lightweight processes for distributed, real-time systems.\n\n### Key Features\n* Massive concurrency on Erlang VM\n* Built-in fault tolerance\n* Functional programming patterns\n* Excellent for real-time systems\n\n---\n\n### Example\n```elixir\nIO.puts \"Hello, World!\"\n```\n\n---\n\nPerfect for chat apps, IoT backends, and distributed systems.",
-	
-	"Haskell": "## λ Haskell\nPure functional programming. Type-driven development with mathematically elegant solutions and compile-time guarantees.\n\n### Key Features\n* Pure functional programming\n* Strong static typing\n* Lazy evaluation\n* Advanced type system\n\n---\n\n### Example\n```haskell\nmain = putStrLn \"Hello, World!\"\n```\n\n---\n\nPerfect for compilers, financial systems, and mathematically precise applications.",
-
-	"Elm": "## 🌳 Elm\nDelightful web apps. No runtime exceptions with functional reactive programming and immutable data structures.\n\n### Key Features\n* No runtime exceptions\n* Excellent error messages\n* Time-travel debugging\n* Guaranteed refactoring safety\n\n---\n\n### Example\n```elm\nimport Html exposing (text)\n\nmain =\n  text \"Hello, World!\"\n```\n\n---\n\nPerfect for maintainable frontend web applications.",
-
-	"Julia": "## 🔬 Julia\nScientific computing. High-performance numerical algorithms with Python-like syntax and C-like speed.\n\n### Key Features\n* Python-like syntax, C-like speed\n* Built-in parallel computing\n* Excellent for numerical analysis\n* Multiple dispatch system\n\n---\n\n### Example\n```julia\nprintln(\"Hello, World!\")\n```\n\n---\n\nPerfect for machine learning, scientific research, and computational mathematics.",
-
-	"SQL": "## 🗄️ SQL\nData mastery. Write efficient queries, design normalized schemas, and optimize database performance.\n\n### Key Features\n* Declarative query language\n* Works with PostgreSQL, MySQL, SQLite\n* Essential for data analysis\n* Industry-standard for databases\n\n---\n\n### Example\n```sql\nSELECT 'Hello, World!' AS greeting;\n```\n\n---\n\nPerfect for data analysis, backend development, and business intelligence.",
-
-	"Arduino": "## 🤖 Arduino\nHardware programming. Build IoT devices, sensor networks, and interactive electronic projects with C++ for microcontrollers.\n\n### Key Features\n* Easy hardware interfacing\n* Large sensor and module ecosystem\n* Digital and analog I/O\n* Serial communication protocols\n\n---\n\n### Example\n```cpp\nvoid setup() {\n  Serial.begin(9600);\n}\n\nvoid loop() {\n  Serial.println(\"Hello, World!\");\n  delay(1000);\n}\n```\n\n---\n\nPerfect for home automation, robotics, and IoT projects.",
+// templateFuncs are available to embedded and user templates alongside the
+// text/template builtins. fallback returns its second argument when the first is
+// a blank string.
+var templateFuncs = template.FuncMap{
+	"fallback":   or,
+	"includes":   includes,
+	"join":       strings.Join,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"shellquote": shellQuote,
+	"expr":       githubExpr,
+}
 
-	"Scheme": "## 🧠 Scheme\nMinimalist functional programming. Pure computational thinking and programming language fundamentals with elegant syntax.\n\n### Key Features\n* Minimal, elegant syntax\n* First-class functions\n* Powerful macro system\n* Educational and theoretical\n\n---\n\n### Example\n```scheme\n(display \"Hello, World!\")\n(newline)\n```\n\n---\n\nPerfect for learning computer science and programming language theory.",
+// templateOverride is a parsed user template and the file it was read from.
+type templateOverride struct {
+	tmpl   *template.Template
+	source string
+}
 
-	"Lisp": "## 🧠 Lisp\nSymbolic AI programming. Meta-programming with code-as-data philosophy and powerful macro systems.\n\n### Key Features\n* Homoiconic code-as-data\n* Powerful macro system\n* REPL-driven development\n* Excellent for symbolic computation\n\n---\n\n### Example\n```lisp\n(print \"Hello, World!\")\n```\n\n---\n\nPerfect for AI, symbolic computation, and domain-specific languages.",
+// templateOverrideDirs lists the template directories to load, in order; later wins.
+func templateOverrideDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, templateOverridesDir))
+	}
+	return append(dirs, templateOverridesDir)
 }
 
+// templateData returns a zero value of the data a template is rendered with, or
+// false for a file claudekit never renders.
+func templateData(name string) (any, bool) {
+	switch name {
+	case claudeMDTemplate:
+		return claudeMDData{}, true
+	case claudeLocalTemplate:
+		return claudeLocalData{}, true
+	case setupDocTemplate:
+		return setupDocData{}, true
+	case workflowTemplate:
+		return githubWorkflowData{}, true
+	case devcontainerTemplate:
+		return devcontainerData{}, true
+	case postWriteLintTemplate:
+		return languageFlags{}, true
+	case secretScanTemplate:
+		return secretScanData{}, true
+	case testRunnerTemplate:
+		return testRunnerData{}, true
+	case branchGuardTemplate:
+		return branchGuardData{}, true
+	}
+	if agent, ok := strings.CutPrefix(name, "agents/"); ok && strings.HasSuffix(agent, ".md.tmpl") && !strings.Contains(agent, "/") {
+		return agentTemplateData{}, true
+	}
+	for hookName := range builtinHookDescriptions {
+		for lang := range hookLanguageExt {
+			if name == "hooks/"+hookScriptName(hookName, lang)+".tmpl" {
+				return hookTemplateData{}, true
+			}
+		}
+	}
+	return nil, false
+}
 
-// getPersistenceFilePath returns the path to the persistence file
-func getPersistenceFilePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+// LoadTemplateOverrides parses the templates under dir, replacing the embedded assets
+// and templates from earlier directories. A missing directory holds no templates.
+// Files that fail to parse, refer to fields their data lacks, or are not templates
+// claudekit renders are reported and skipped, leaving the embedded asset in use.
+func (r *ModuleRegistry) LoadTemplateOverrides(dir string) []error {
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".tmpl") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		data, ok := templateData(name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: not a template claudekit renders", path))
+			return nil
+		}
+		tmpl, err := parseTemplateOverride(path, data)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if r.templates == nil {
+			r.templates = make(map[string]templateOverride)
+		}
+		r.templates[name] = templateOverride{tmpl: tmpl, source: path}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("cannot read %s: %w", dir, err))
 	}
-	return filepath.Join(homeDir, ".claudekit.json"), nil
+	return errs
 }
 
-// loadPersistenceConfig loads previous choices from the persistence file
-func loadPersistenceConfig() (*PersistenceConfig, error) {
-	filePath, err := getPersistenceFilePath()
+// parseTemplateOverride reads and parses the user template at path and checks its
+// field references against data, so a typo surfaces at startup rather than on the
+// first run that selects the language or module the field is guarded by.
+// Templates are named by path, which their errors lead with.
+func parseTemplateOverride(path string, data any) (*template.Template, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
 	}
-	
-	// If file doesn't exist, return empty config
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return &PersistenceConfig{}, nil
-	}
-	
-	data, err := os.ReadFile(filePath)
+	tmpl, err := template.New(path).Funcs(templateFuncs).Parse(string(content))
 	if err != nil {
 		return nil, err
 	}
-	
-	var config PersistenceConfig
-	if err := json.Unmarshal(data, &config); err != nil {
+	if err := checkTemplateFields(tmpl, reflect.TypeOf(data)); err != nil {
 		return nil, err
 	}
-	
-	return &config, nil
+	return tmpl, nil
 }
 
-// savePersistenceConfig saves current choices to the persistence file
-func savePersistenceConfig(config Config) error {
-	filePath, err := getPersistenceFilePath()
-	if err != nil {
-		return err
+// renderTemplateOverride executes the user's template for name. It reports false
+// when none is loaded or the template fails to render, which is logged, and the
+// caller falls back to the embedded asset.
+func (r *ModuleRegistry) renderTemplateOverride(name string, data any) (string, bool) {
+	if r == nil {
+		return "", false
 	}
-	
-	persistConfig := PersistenceConfig{
-		LastUpdated:    time.Now(),
-		IsProjectLocal: config.IsProjectLocal,
-		ProjectName:    config.ProjectName,
-		Languages:      config.Languages,
-		Subagents:      config.Subagents,
-		Hooks:          config.Hooks,
-		SlashCommands:  config.SlashCommands,
-		MCPServers:     config.MCPServers,
-		ClaudeMDExtras: config.ClaudeMDExtras,
+	override, ok := r.templates[name]
+	if !ok {
+		return "", false
 	}
-	
-	data, err := json.MarshalIndent(persistConfig, "", "  ")
-	if err != nil {
-		return err
+	var b bytes.Buffer
+	if err := override.tmpl.Execute(&b, data); err != nil {
+		slog.Warn("template override failed; using the built-in template", "template", name, "source", override.source, "err", err)
+		return "", false
 	}
-	
-	return os.WriteFile(filePath, data, 0644)
+	return b.String(), true
 }
 
-// shouldShowRightPanel returns true if terminal dimensions meet thresholds for right panel display.
-// Per FR-002/FR-003: Requires BOTH width >= 140 AND height >= 40 (inclusive thresholds).
-func shouldShowRightPanel(width, height int) bool {
-	return width >= MIN_WIDTH_FOR_PANEL && height >= MIN_HEIGHT_FOR_PANEL
+// renderTemplate executes the user's template for name when one is loaded, or the
+// embedded template at assetPath otherwise.
+func renderTemplate(registry *ModuleRegistry, name, assetPath string, data any) string {
+	if content, ok := registry.renderTemplateOverride(name, data); ok {
+		return content
+	}
+	tmplContent, err := assets.ReadFile(assetPath)
+	if err != nil {
+		panic(err)
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(tmplContent))
+	if err != nil {
+		panic(err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		panic(err)
+	}
+	return b.String()
 }
 
-// debounceCompleteMsg signals that resize debounce period has elapsed
-type debounceCompleteMsg struct{}
-
-// handleWindowSizeMsg processes terminal resize events with debouncing.
-// Cancels any existing timer, caches the new dimensions, and starts 200ms countdown.
-func handleWindowSizeMsg(m model, msg tea.WindowSizeMsg) (model, tea.Cmd) {
-	// Cancel existing debounce timer if present
-	if m.resizeDebouncer != nil {
-		m.resizeDebouncer.Stop()
+// checkTemplateFields reports the first field reference in tmpl that data of type
+// dataType lacks. text/template only notices these when the branch holding them runs.
+func checkTemplateFields(tmpl *template.Template, dataType reflect.Type) error {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		dot := dataType
+		if t.Name() != tmpl.Name() {
+			dot = nil // Dot inside a {{define}} depends on the {{template}} call
+		}
+		c := fieldChecker{tree: t.Tree, vars: map[string]reflect.Type{"$": dataType}}
+		if err := c.walk(t.Tree.Root, dot); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Cache resize message
-	m.pendingResize = &msg
-
-	// Start new debounce timer
-	m.resizeDebouncer = time.NewTimer(RESIZE_DEBOUNCE_MS * time.Millisecond)
-
-	// Return Cmd that waits for timer to expire
-	return m, func() tea.Msg {
-		<-m.resizeDebouncer.C
-		return debounceCompleteMsg{}
-	}
+// fieldChecker walks a template's parse tree tracking the type of dot and of its
+// variables. A nil type is unknown, such as the result of a builtin function, and
+// fields on it are not checked.
+type fieldChecker struct {
+	tree *parse.Tree
+	vars map[string]reflect.Type
 }
 
-// applyPendingResize updates model dimensions and recomputes panel visibility.
-// CRITICAL: MUST NOT modify m.form or m.config (FR-011: preserve all user input).
-func applyPendingResize(m model) (model, tea.Cmd) {
-	if m.pendingResize == nil {
-		return m, nil // No pending resize, nothing to do
+func (c *fieldChecker) walk(node parse.Node, dot reflect.Type) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := c.walk(child, dot); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		_, err := c.pipe(n.Pipe, dot)
+		return err
+	case *parse.IfNode:
+		return c.branch(&n.BranchNode, dot)
+	case *parse.RangeNode:
+		return c.branch(&n.BranchNode, dot)
+	case *parse.WithNode:
+		return c.branch(&n.BranchNode, dot)
+	case *parse.TemplateNode:
+		_, err := c.pipe(n.Pipe, dot)
+		return err
 	}
-
-	// Update dimensions
-	m.width = m.pendingResize.Width
-	m.height = m.pendingResize.Height
-
-	// Recompute panel visibility (FR-002, FR-003)
-	m.showRightPanel = shouldShowRightPanel(m.width, m.height)
-
-	// Clear debounce state
-	m.pendingResize = nil
-	m.resizeDebouncer = nil
-
-	// IMPORTANT: Do NOT modify m.form or m.config - preserves input per FR-011
-
-	return m, nil
+	return nil
 }
 
-func (m model) Init() tea.Cmd {
-	return m.form.Init()
+// branch checks an if, range, or with block; range and with move dot for their body.
+func (c *fieldChecker) branch(n *parse.BranchNode, dot reflect.Type) error {
+	t, err := c.pipe(n.Pipe, dot)
+	if err != nil {
+		return err
+	}
+	inner := dot
+	switch n.NodeType {
+	case parse.NodeWith:
+		inner = t
+	case parse.NodeRange:
+		inner = rangeElem(t)
+		switch decl := n.Pipe.Decl; len(decl) {
+		case 1:
+			c.vars[decl[0].Ident[0]] = inner
+		case 2:
+			c.vars[decl[0].Ident[0]] = rangeKey(t)
+			c.vars[decl[1].Ident[0]] = inner
+		}
+	}
+	if err := c.walk(n.List, inner); err != nil {
+		return err
+	}
+	return c.walk(n.ElseList, dot)
 }
 
-func (m *model) getCurrentDescription() string {
-	// Get current focus from form state
-	if m.form.State == huh.StateCompleted {
-		return "✅ Configuration complete! Ready to generate your Claude Code setup."
-	}
-	
-	// Get the currently focused field
-	focusedField := m.form.GetFocusedField()
-	if focusedField == nil {
-		return m.getDefaultDescription()
+func (c *fieldChecker) pipe(p *parse.PipeNode, dot reflect.Type) (reflect.Type, error) {
+	if p == nil {
+		return nil, nil
 	}
-	
-	// Check field key to identify what type of selection we're in
-	fieldKey := focusedField.GetKey()
-	
-	// Handle language selection
-	if fieldKey == "languages" {
-		if multiSelect, ok := focusedField.(*huh.MultiSelect[string]); ok {
-			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
-				if desc, exists := languageDescriptions[hoveredItem]; exists {
-					return desc
-				}
-			}
+	var t reflect.Type
+	for _, cmd := range p.Cmds {
+		var err error
+		if t, err = c.command(cmd, dot); err != nil {
+			return nil, err
 		}
-		return "💻 Select programming languages used in your project. Claude will provide specialized assistance and optimized configurations for each language. Navigate with arrow keys to see how Claude can help."
 	}
-	
-	// Handle subagent selection (Feature 004: use registry)
-	if fieldKey == "subagents" {
-		if multiSelect, ok := focusedField.(*huh.MultiSelect[string]); ok {
-			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
-				// Extract the subagent name (remove emoji prefix)
-				subagentName := extractSubagentName(hoveredItem)
-				if module := m.registry.Get(TypeSubagent, subagentName); module != nil {
-					return module.Description
-				}
-			}
-		}
-		return "🤖 Select specialized AI assistants for your development workflow. Navigate with arrow keys to see detailed descriptions."
+	for _, v := range p.Decl {
+		c.vars[v.Ident[0]] = t
 	}
-	
-	// Handle hook selection (Feature 004: use registry)
-	if fieldKey == "hooks" {
-		if multiSelect, ok := focusedField.(*huh.MultiSelect[string]); ok {
-			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
-				// Extract the hook name (remove emoji prefix)
-				hookName := extractSubagentName(hoveredItem)
-				if module := m.registry.Get(TypeHook, hookName); module != nil {
-					return module.Description
-				}
-			}
+	return t, nil
+}
+
+// command checks every argument of cmd and returns the type of its first, which is
+// the command's result unless it is a function call.
+func (c *fieldChecker) command(cmd *parse.CommandNode, dot reflect.Type) (reflect.Type, error) {
+	var result reflect.Type
+	for i, arg := range cmd.Args {
+		t, err := c.arg(arg, dot)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result = t
 		}
-		return "🪝 Select automation hooks to enhance your development workflow. These scripts run at specific points to provide safety, quality control, and context. Navigate with arrow keys to see detailed descriptions."
 	}
-	
-	// Handle slash command selection (Feature 004: use registry)
-	if fieldKey == "slash-commands" {
-		if multiSelect, ok := focusedField.(*huh.MultiSelect[string]); ok {
-			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
-				// Extract the command name (remove emoji prefix)
-				commandName := extractSubagentName(hoveredItem)
-				if module := m.registry.Get(TypeCommand, commandName); module != nil {
-					return module.Description
-				}
+	return result, nil
+}
+
+func (c *fieldChecker) arg(node parse.Node, dot reflect.Type) (reflect.Type, error) {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot, nil
+	case *parse.FieldNode:
+		return c.fields(n, dot, n.Ident)
+	case *parse.VariableNode:
+		return c.fields(n, c.vars[n.Ident[0]], n.Ident[1:])
+	case *parse.ChainNode:
+		t, err := c.arg(n.Node, dot)
+		if err != nil {
+			return nil, err
+		}
+		return c.fields(n, t, n.Field)
+	case *parse.PipeNode:
+		return c.pipe(n, dot)
+	case *parse.IdentifierNode:
+		if fn, ok := templateFuncs[n.Ident]; ok {
+			if ft := reflect.TypeOf(fn); ft.NumOut() > 0 {
+				return ft.Out(0), nil
 			}
 		}
-		return "⚡ Select custom slash commands for common development tasks. These powerful shortcuts automate complex workflows and boost productivity. Navigate with arrow keys to see detailed descriptions."
+	case *parse.StringNode:
+		return reflect.TypeOf(""), nil
+	case *parse.BoolNode:
+		return reflect.TypeOf(false), nil
 	}
-	
-	// Handle MCP server selection (Feature 004: use registry)
-	if fieldKey == "mcp-servers" {
-		if multiSelect, ok := focusedField.(*huh.MultiSelect[string]); ok {
-			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
-				// Extract the MCP server name (remove emoji prefix)
-				serverName := extractSubagentName(hoveredItem)
-				if module := m.registry.Get(TypeMCP, serverName); module != nil {
-					return module.Description
-				}
+	return nil, nil
+}
+
+// fields follows a chain of field names from t, reporting the first one t lacks.
+func (c *fieldChecker) fields(node parse.Node, t reflect.Type, names []string) (reflect.Type, error) {
+	for _, name := range names {
+		if t == nil {
+			return nil, nil
+		}
+		next, ok := templateFieldType(t, name)
+		if !ok {
+			location, _ := c.tree.ErrorContext(node)
+			if available := templateFieldNames(t); len(available) > 0 {
+				return nil, fmt.Errorf("%s: unknown field .%s (available: %s)", location, name, strings.Join(available, ", "))
 			}
+			return nil, fmt.Errorf("%s: unknown field .%s", location, name)
 		}
-		return "🔌 Select external tool integrations to enhance Claude's capabilities via Model Context Protocol. Navigate with arrow keys to see detailed descriptions."
+		t = next
 	}
-	
-	return m.getDefaultDescription()
+	return t, nil
 }
 
-func (m *model) getDefaultDescription() string {
-	return `## 📋 Claude Code Project Setup
-
-Welcome to the interactive **Claude Code** project configuration tool! This wizard will help you set up a comprehensive development environment either _globally_, or on a _per project basis_.
-
-### 🔍 NAVIGATION:
-* Use **tab** & **shift-tab** to move between form fields
-* Use **arrow** keys to navigate between options
-* Use **space** to select/deselect items in multi-select lists
-* Use **enter** to proceed/confirm to the next field
-
-### 📚 WHAT YOU'RE CONFIGURING:
-* Project basics (directory, name, languages)
-* AI subagents for specialized development tasks
-* Automation hooks for workflow enhancement
-* External tool integrations via MCP
-
-Choose the options that best fit your development workflow and project needs. Your choices will persist and you may _use this tool again to make changes_.`
+// templateFieldType returns the type text/template yields for .name on a value of
+// type t, with a nil type when it cannot be known, or false when the lookup fails.
+func templateFieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	if m, ok := t.MethodByName(name); ok && m.Type.NumOut() > 0 {
+		return m.Type.Out(0), true
+	}
+	if t.Kind() != reflect.Pointer {
+		if m, ok := reflect.PointerTo(t).MethodByName(name); ok && m.Type.NumOut() > 0 {
+			return m.Type.Out(0), true
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if f, ok := t.FieldByName(name); ok && f.IsExported() {
+			return f.Type, true
+		}
+	case reflect.Map:
+		return t.Elem(), true
+	case reflect.Interface:
+		return nil, true
+	}
+	return nil, false
 }
 
-func extractSubagentName(displayName string) string {
-	// Remove emoji and space prefix (e.g., "🔍 code-reviewer" -> "code-reviewer")
-	parts := strings.SplitN(displayName, " ", 2)
-	if len(parts) > 1 {
-		return parts[1]
+// templateFieldNames lists the exported fields of a struct type, including promoted ones.
+func templateFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
 	}
-	return displayName
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for _, f := range reflect.VisibleFields(t) {
+		if f.IsExported() && !f.Anonymous {
+			names = append(names, f.Name)
+		}
+	}
+	return names
 }
 
-func (m *model) renderMarkdown(content string) string {
-	if m.glamourRenderer == nil {
-		return content // Fallback to plain text
+// rangeElem returns the type of dot inside {{range}} over a value of type t.
+func rangeElem(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
 	}
-	
-	rendered, err := m.glamourRenderer.Render(content)
-	if err != nil {
-		return content // Fallback to plain text on error
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
 	}
-	
-	return rendered
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return t.Elem()
+	case reflect.Int:
+		return t
+	}
+	return nil
 }
 
-// tickMsg is our custom message for gradient animations
-type tickMsg time.Time
-
-// startTransition initiates a gradient theme transition (T033)
-func (m *model) startTransition(to gradient.Theme, duration time.Duration) tea.Cmd {
-	m.transition = gradient.TransitionState{
-		Active:     true,
-		FromTheme:  m.currentTheme,
-		ToTheme:    to,
-		StartTime:  time.Now(),
-		Duration:   duration,
-		EasingFunc: gradient.EaseInOutCubic,
+// rangeKey returns the type of the first variable in {{range $k, $v := ...}}.
+func rangeKey(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
 	}
-
-	// Return initial tick command to start animation
-	return tea.Tick(16*time.Millisecond, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeOf(0)
+	case reflect.Map:
+		return t.Key()
+	}
+	return nil
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		// Feature 007: Debounced resize handling
-		return handleWindowSizeMsg(m, msg)
+// registryLoader loads the module registry in the background so the form can paint
+// immediately; module pages show a loading placeholder until it finishes.
+type registryLoader struct {
+	registry *ModuleRegistry
+	errs     []error
+	done     chan struct{}
+	reported sync.Once // Load errors are reported by the first call to Registry
+}
 
-	case debounceCompleteMsg:
-		// Feature 007: Apply pending resize after debounce period
-		m, cmd := applyPendingResize(m)
+// registryLoadedMsg is sent to the TUI once the background load completes.
+type registryLoadedMsg struct {
+	registry *ModuleRegistry
+}
 
-		// After applying resize, update viewport dimensions if panel is visible
-		if m.showRightPanel {
-			// Calculate layout dimensions with fixed percentages for stability
-			formWidth := int(float64(m.width) * 0.6)        // 60% width for left side
-			statusWidth := m.width - formWidth - 6          // Remaining width for right side
-
-			// Calculate height consistently with View() function
-			const borderPadding = 10
-			const borderHeight = 4
-			innerHeight := m.height - borderHeight
-			if innerHeight < 10 {
-				innerHeight = 10
-			}
-			titleHeight := 5
-			availableHeight := innerHeight - titleHeight
-			if availableHeight < 20 {
-				availableHeight = 20
-			}
-			statusHeight := availableHeight
-
-			if !m.ready {
-				m.viewport = viewport.New(statusWidth, statusHeight)
-				m.ready = true
-			} else {
-				m.viewport.Width = statusWidth
-				m.viewport.Height = statusHeight
-			}
+// loadRegistryAsync starts loading modules from fsys along with the installed Claude
+// Code version, which shells out and is the slowest part of startup. The user module
+// directories, template directories, and overrides files are applied in order once
+// the embedded modules are loaded.
+func loadRegistryAsync(fsys embed.FS, moduleDirs, templateDirs []string, overridePaths ...string) *registryLoader {
+	l := &registryLoader{registry: &ModuleRegistry{}, done: make(chan struct{})}
+	go func() {
+		defer close(l.done)
+		l.errs = l.registry.LoadCached(fsys, registryCachePath())
+		for _, dir := range moduleDirs {
+			l.errs = append(l.errs, l.registry.LoadUserModules(dir)...)
 		}
-
-		return m, cmd
-
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			return m, tea.Quit
+		for _, dir := range templateDirs {
+			l.errs = append(l.errs, l.registry.LoadTemplateOverrides(dir)...)
 		}
-
-	// T032: Handle gradient animation ticks
-	case tickMsg:
-		if m.transition.Active {
-			progress := m.transition.Progress()
-			if progress >= 1.0 {
-				// Transition complete
-				m.transition.Active = false
-				m.currentTheme = m.transition.ToTheme
-			} else {
-				// Continue animating
-				m.currentTheme = gradient.InterpolateGradient(
-					m.transition.FromTheme,
-					m.transition.ToTheme,
-					progress,
-				)
-				// Schedule next tick for smooth animation
-				return m, tea.Tick(16*time.Millisecond, func(t time.Time) tea.Msg {
-					return tickMsg(t)
-				})
+		for _, path := range overridePaths {
+			overrides, err := loadModuleOverrides(path)
+			if err != nil {
+				l.errs = append(l.errs, err)
+				continue
 			}
+			l.errs = append(l.errs, l.registry.ApplyOverrides(overrides, path)...)
 		}
-	}
+		// Asking claude for its version can take seconds, so it waits until a module
+		// that declares requires_claude is checked
+		l.registry.claudeVersion = sync.OnceValue(func() string {
+			v := detectClaudeVersion()
+			slog.Debug("detected Claude Code version", "version", v)
+			return v
+		})
+		slog.Debug("loaded module registry", "errors", len(l.errs))
+	}()
+	return l
+}
 
-	// Update form
-	form, cmd := m.form.Update(msg)
-	if f, ok := form.(*huh.Form); ok {
-		m.form = f
-	}
-	
-	// Handle viewport scrolling for status panel
-	var viewportCmd tea.Cmd
-	m.viewport, viewportCmd = m.viewport.Update(msg)
-	cmd = tea.Batch(cmd, viewportCmd)
+// Wait blocks until loading finishes and returns the registry and any load errors.
+func (l *registryLoader) Wait() (*ModuleRegistry, []error) {
+	<-l.done
+	return l.registry, l.errs
+}
 
-	// Update viewport content with current status/descriptions
-	m.viewport.SetContent(m.renderMarkdown(m.renderStatus()))
+// Registry waits for loading to finish and returns the registry, the first time
+// reporting the modules that failed to load.
+func (l *registryLoader) Registry() *ModuleRegistry {
+	registry, errs := l.Wait()
+	l.reported.Do(func() { reportRegistryErrors(errs) })
+	return registry
+}
 
-	// Check if form is complete
-	if m.form.State == huh.StateCompleted {
-		return m, tea.Quit
+// Options returns a huh OptionsFunc callback for componentType, offering disabled
+// modules while *includeDisabled is set. huh runs it off the UI goroutine and shows
+// its loading indicator until it returns.
+func (l *registryLoader) Options(componentType ModuleComponentType, includeDisabled *bool) func() []huh.Option[string] {
+	return func() []huh.Option[string] {
+		registry, _ := l.Wait()
+		return registry.GetOptions(componentType, *includeDisabled)
 	}
-
-	return m, cmd
 }
 
-func (m model) View() string {
-	if !m.ready {
-		return "Initializing..."
+// Category returns a filterMultiSelect.Grouped callback giving the category of each
+// componentType module. Custom modules have none.
+func (l *registryLoader) Category(componentType ModuleComponentType) func(string) string {
+	return func(name string) string {
+		registry, _ := l.Wait()
+		if module := registry.Get(componentType, name); module != nil {
+			return module.Category
+		}
+		return ""
 	}
+}
 
-	// Account for border + padding
-	// Border adds 2 chars left/right (1 for border char, 1 for automatic border spacing)
-	// Padding adds 2 chars left/right (via Padding(1, 2))
-	// Total per side: 2 + 2 = 4, so 8 total width
-	const borderPadding = 10  // Extra space for border + padding on left/right
-	const borderHeight = 4    // Border (2 lines: top + bottom) + Padding (2 lines: top + bottom via Padding(1, 2))
-
-	innerWidth := m.width - borderPadding
-	innerHeight := m.height - borderHeight
-
-	if innerWidth < 20 {
-		innerWidth = 20
-	}
-	if innerHeight < 10 {
-		innerHeight = 10
+// loadedCmd delivers a registryLoadedMsg when loading finishes.
+func (l *registryLoader) loadedCmd() tea.Cmd {
+	return func() tea.Msg {
+		registry, _ := l.Wait()
+		return registryLoadedMsg{registry: registry}
 	}
+}
 
-	// Calculate dimensions with fixed percentages for stability
-	formWidth := int(float64(innerWidth) * 0.6)
-	statusWidth := innerWidth - formWidth - 6
+// ============================================================================
+// Feature 008: Module Loading from Markdown with YAML Frontmatter
+// ============================================================================
+
+// extractFrontmatter extracts YAML frontmatter and markdown body from content
+// Returns frontmatter YAML, body content, and error if delimiters missing.
+// Delimiters are lines of their own, so values such as PEM headers may contain ---.
+func extractFrontmatter(content string) (frontmatter, body string, err error) {
+	// Anything before the opening --- must be whitespace
+	rest, ok := strings.CutPrefix(strings.TrimLeft(content, " \t\r\n"), "---")
+	if !ok {
+		if strings.Contains(content, "---") {
+			return "", "", fmt.Errorf("%w: opening delimiter not at start", ErrMissingDelimiters)
+		}
+		return "", "", ErrMissingDelimiters
+	}
+
+	// The closing delimiter is the first following line that is just ---
+	lines := strings.SplitAfter(rest, "\n")
+	for i, line := range lines {
+		if i > 0 && strings.TrimRight(line, " \t\r\n") == "---" {
+			frontmatter = strings.TrimSpace(strings.Join(lines[:i], ""))
+			body = strings.TrimSpace(strings.Join(lines[i+1:], ""))
+			return frontmatter, body, nil
+		}
+	}
+	return "", "", ErrMissingDelimiters
+}
+
+// Validate checks if ModuleDefinition has all required fields and valid values
+func (m *ModuleDefinition) Validate() error {
+	// Required field: name
+	if m.Name == "" {
+		return ErrMissingName
+	}
+
+	// Required field: type
+	if m.Type == "" {
+		return ErrMissingType
+	}
+
+	// Type must be valid enum (FR-008)
+	validTypes := map[string]bool{
+		"subagent":    true,
+		"hook":        true,
+		"command":     true,
+		"mcp":         true,
+		"framework":   true,
+		"style":       true,
+		"permissions": true,
+		"statusline":  true,
+	}
+	if !validTypes[m.Type] {
+		return fmt.Errorf("%w: %s (must be subagent, hook, command, mcp, framework, style, permissions, or statusline)", ErrInvalidType, m.Type)
+	}
+
+	// Version constraints must parse so they can be enforced at load time
+	if m.RequiresClaudekit != "" {
+		if _, err := version.ParseConstraint(m.RequiresClaudekit); err != nil {
+			return fmt.Errorf("%w: requires_claudekit %q", ErrInvalidConstraint, m.RequiresClaudekit)
+		}
+	}
+	if m.RequiresClaude != "" {
+		if _, err := version.ParseConstraint(m.RequiresClaude); err != nil {
+			return fmt.Errorf("%w: requires_claude %q", ErrInvalidConstraint, m.RequiresClaude)
+		}
+	}
+	if m.Version != "" {
+		if _, err := version.Parse(m.Version); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidVersion, m.Version)
+		}
+	}
+
+	// Note: Enabled is bool, zero value (false) is valid
+	// Note: Optional fields can be empty/nil
+
+	return nil
+}
+
+// parseMarkdownModule parses a single module file with YAML frontmatter
+func parseMarkdownModule(path string, content []byte) (ModuleDefinition, error) {
+	// Decoding leaves out keys the frontmatter lacks, so a missing enabled stays true
+	module := ModuleDefinition{Enabled: true}
+
+	// Extract frontmatter and body
+	frontmatterYAML, body, err := extractFrontmatter(string(content))
+	if err != nil {
+		return module, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	// Parse YAML frontmatter
+	err = yaml.Unmarshal([]byte(frontmatterYAML), &module)
+	if err != nil {
+		// The schema says which value is wrong where the decoder only names a type
+		if errs := schema.Frontmatter(path, []byte(frontmatterYAML)); len(errs) > 0 {
+			return module, fmt.Errorf("%w: %w", ErrYAMLParse, joinSchemaErrors(errs))
+		}
+		return module, fmt.Errorf("failed to parse %s: %w: %v", path, ErrYAMLParse, err)
+	}
+
+	// Set description from markdown body
+	module.Description = body
+
+	// Set file path
+	module.FilePath = path
+
+	// Validate required fields
+	err = module.Validate()
+	if err != nil {
+		return module, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if errs := schema.Frontmatter(path, []byte(frontmatterYAML)); len(errs) > 0 {
+		return module, joinSchemaErrors(errs)
+	}
+
+	return module, nil
+}
+
+// joinSchemaErrors combines schema errors into one error, one problem per line.
+func joinSchemaErrors(errs []schema.Error) error {
+	joined := make([]error, len(errs))
+	for i, err := range errs {
+		joined[i] = err
+	}
+	return errors.Join(joined...)
+}
+
+// loadModulesFromMarkdown loads all module files from embedded filesystem
+func loadModulesFromMarkdown(fsys embed.FS) ([]ModuleDefinition, error) {
+	var modules []ModuleDefinition
+
+	// Walk the assets/modules directory
+	err := fs.WalkDir(fsys, "assets/modules", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories, non-.md files, and README.md files
+		if d.IsDir() || !strings.HasSuffix(path, ".md") || strings.HasSuffix(path, "README.md") {
+			return nil
+		}
+
+		// Read file content
+		content, err := fsys.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		// Parse module
+		module, err := parseMarkdownModule(path, content)
+		if err != nil {
+			return err // Fail-fast on any parse error (FR-013)
+		}
+
+		modules = append(modules, module)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return modules, nil
+}
+
+// ============================================================================
+// Feature 005: Asset File Generation Types
+// ============================================================================
+
+// validateModule checks required fields and applies defaults
+func validateModule(module *ComponentModule, files fs.FS) error {
+	var errs []string
+
+	// Check required fields (Feature 008: only name and type are required)
+	if module.Name == "" {
+		errs = append(errs, "name is required")
+	}
+	if module.Type == "" {
+		errs = append(errs, "type is required")
+	}
+	// Description and AssetPaths are optional (e.g., MCPs don't need asset_paths)
+
+	// Validate asset paths exist (warning only, not fatal)
+	for _, assetPath := range module.AssetPaths {
+		// Try multiple base paths for assets
+		found := false
+		for _, base := range []string{"assets/", "testdata/", ""} {
+			fullPath := base + assetPath
+			if _, err := fs.ReadFile(files, fullPath); err == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			// Log warning but don't fail - asset might be optional
+			errs = append(errs, fmt.Sprintf("asset not found: %s", assetPath))
+		}
+	}
+
+	// Apply defaults for optional fields
+	if module.DisplayName == "" {
+		module.DisplayName = module.Name
+	}
+	if module.Defaults == nil {
+		module.Defaults = make(map[string]any)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("validation errors: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ASCII Art Title (T011) - Pre-generated with figlet "small" font
+const asciiTitle = `┏━╸╻  ┏━┓╻ ╻╺┳┓┏━╸   ╻┏ ╻╺┳╸
+┃  ┃  ┣━┫┃ ┃ ┃┃┣╸    ┣┻┓┃ ┃ 
+┗━╸┗━╸╹ ╹┗━┛╺┻┛┗━╸   ╹ ╹╹ ╹ `
+
+// Bubble Tea Model for the application
+type model struct {
+	form            *huh.Form
+	config          *Config
+	viewport        viewport.Model
+	glamourRenderer *glamour.TermRenderer
+	ready           bool
+	width           int
+	height          int
+	currentFocus    string
+
+	// Gradient system fields (T028)
+	terminalCap  gradient.TerminalCapability
+	currentTheme gradient.Theme
+	transition   gradient.TransitionState
+	styleMap     map[gradient.ComponentType]map[gradient.VisualState]gradient.ComponentStyle
+	themeName    string // Palette preset styleMap was built from
+	noAnimation  bool   // Reduced motion: theme changes apply at once
+
+	// Module registry (Feature 004). Nil until registryLoader finishes.
+	registry       *ModuleRegistry
+	registryLoader *registryLoader
+
+	// Installed modules with a newer version, keyed by Config.IsProjectLocal; found
+	// once the registry loads
+	upgrades map[bool][]moduleUpgrade
+
+	// Adaptive right panel layout (Feature 007)
+	showRightPanel  bool               // Computed: width >= 140 && height >= 40
+	resizeDebouncer *time.Timer        // Active debounce timer (nil if none)
+	pendingResize   *tea.WindowSizeMsg // Cached resize message during debounce
+	resizeDebounce  time.Duration      // Delay under sustained resizing (--resize-debounce)
+	formShare       float64            // Share of the width the form takes beside the status panel
+
+	// Set by --force-size; replaces every WindowSizeMsg
+	forcedSize *tea.WindowSizeMsg
+
+	// Set while the confirmation page shows the generated files instead of the summary
+	previewing bool
+
+	// What the last copy key on the confirmation page did, until the next key
+	copied string
+
+	// The terminal the program draws on; OSC 52 copies are written to it
+	output io.Writer
+
+	// The right panel as last rendered; shared by copies of the model
+	status *statusCache
+
+	// Writes the configuration once the form completes, reporting to the generation
+	// screen through the callbacks; nil to quit instead
+	generate   func(callbacks generationCallbacks) int
+	generating *generationState // Set once generation starts
+
+	// Escape-key page menu; pages is nil for forms without one
+	pages      []wizardPage
+	visited    map[int]bool // Pages the user has been on
+	pageMenu   bool
+	menuCursor int
+}
+
+// Styles for the Uaud
+var (
+	titleStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFDF5")).
+			Background(lipgloss.Color("#25A065")).
+			Padding(0, 1)
+
+	statusStyle = lipgloss.NewStyle().
+			Padding(1).
+			MarginLeft(2)
+
+	formStyle = lipgloss.NewStyle().
+			Padding(1)
+
+	descStyle = lipgloss.NewStyle().
+			BorderTop(true).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#25A065")).
+			Padding(1).
+			MarginTop(1)
+
+	menuCursorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#25A065")).
+			Bold(true)
+
+	menuHelpStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#888888")).
+			Faint(true)
+)
+
+// Descriptions for subagents, MCPs, hooks, and commands are now loaded from JSON modules (Feature 004)
+
+// Detailed descriptions for programming languages
+var languageDescriptions = map[string]string{
+	"Go": "## 🐹 Go\nSimple, fast, concurrent. Master goroutines and channels for scalable microservices and cloud-native applications.\n\n### Key Features\n\n* Clean, readable syntax\n* Excellent standard library\n* Built-in concurrency primitives\n* Fast compilation and execution\n\n-------\n\n### Example\n\n```go\npackage main\n\nimport \"fmt\"\n\nfunc main() {\n    fmt.Println(\"Hello, World!\")\n}\n```\n\n---\n\nPerfect for APIs, distributed systems, and microservices.",
+	
+	"TypeScript": "## 🟦 TypeScript\nJavaScript with static types. Build type-safe web applications with excellent IntelliSense and compile-time error catching.\n\n### Key Features\n* Static type checking\n* Excellent IDE support\n* Scales from small to enterprise projects\n* Works with React, Next.js, Node.js, Angular, Vue\n\n---\n\n### Example\n```typescript\nconsole.log(\"Hello, World!\");\n```\n\n---\n\nPerfect for full-stack web development and large-scale applications.",
+
+	"Python": "## 🐍 Python\nReadable, versatile, powerful. Write clean code for data science, machine learning, web development, and automation.\n\n### Key Features\n* Clean, readable syntax\n* Rich ecosystem (Django, FastAPI, NumPy, pandas, PyTorch)\n* Excellent for data science and ML\n* Rapid prototyping and development\n\n---\n\n### Example\n```python\nprint(\"Hello, World!\")\n```\n\n---\n\nPerfect for scientific computing, web apps, and automation scripts.",
+
+	"Java": "## ☕ Java\nEnterprise-grade reliability. Build scalable applications with Spring Boot, microservices architecture, and proven design patterns.\n\n### Key Features\n* Robust JVM performance\n* Extensive standard library\n* Strong typing and OOP\n* Cross-platform compatibility\n\n---\n\n### Example\n```java\npublic class HelloWorld {\n    public static void main(String[] args) {\n        System.out.println(\"Hello, World!\");\n    }\n}\n```\n\n---\n\nExcellent for large-scale enterprise systems and backend services.",
+
+	"Rust": "## 🦀 Rust\nMemory-safe systems programming. Zero-cost abstractions with ownership model and fearless concurrency.\n\n### Key Features\n* Memory safety without garbage collection\n* Prevents data races at compile time\n* Zero-cost abstractions\n* Excellent performance\n\n---\n\n### Example\n```rust\nfn main() {\n    println!(\"Hello, World!\");\n}\n```\n\n---\n\nPerfect for operating systems, game engines, and performance-critical applications.",
+
+	"C++": "## ⚡ C++\nHigh-performance systems programming. Modern C++20 features with RAII patterns and efficient low-level control.\n\n### Key Features\n* Maximum speed and control\n* Templates and metaprogramming\n* Smart pointers and RAII\n* Zero-overhead abstractions\n\n---\n\n### Example\n```cpp\n#include <iostream>\n\nint main() {\n    std::cout << \"Hello, World!\" << std::endl;\n    return 0;\n}\n```\n\n---\n\nPerfect for game engines, embedded systems, and performance-critical applications.",
+
+	"C#": "## 💎 C#\nModern .NET development. Build cross-platform applications with LINQ, async/await, and excellent tooling.\n\n### Key Features\n* Rich type system and LINQ\n* Async/await for concurrency\n* Cross-platform with .NET Core\n* Desktop (WPF), Web (ASP.NET), Cloud (Azure)\n\n---\n\n### Example\n```csharp\nusing System;\n\nclass Program {\n    static void Main() {\n        Console.WriteLine(\"Hello, World!\");\n    }\n}\n```\n\n---\n\nPerfect for enterprise applications and Microsoft ecosystem integration.",
+	
+	"PHP": "## 🐘 PHP\nWeb development made easy. Modern frameworks like Laravel and Symfony for rapid application development.\n\n### Key Features\n* Easy database integration\n* Modern PHP 8+ features\n* Rich framework ecosystem (Laravel, Symfony)\n* Excellent for web applications\n\n---\n\n### Example\n```php\n<?php\necho \"Hello, World!\";\n?>\n```\n\n---\n\nPerfect for CMS, e-commerce, and dynamic web applications.",
+
+	"Ruby": "## 💎 Ruby\nDeveloper happiness first. Elegant Rails development with convention over configuration and expressive syntax.\n\n### Key Features\n* Beautiful, readable syntax\n* Rails framework for rapid development\n* Rich gem ecosystem\n* Powerful metaprogramming\n\n---\n\n### Example\n```ruby\nputs \"Hello, World!\"\n```\n\n---\n\nPerfect for web applications, automation scripts, and developer-friendly APIs.",
+
+	"Swift": "## 🍎 Swift\nApple's modern language. Build native iOS, macOS, and watchOS apps with SwiftUI and protocol-oriented programming.\n\n### Key Features\n* Optionals for null safety\n* SwiftUI for declarative UIs\n* Automatic memory management (ARC)\n* Protocol-oriented programming\n\n---\n\n### Example\n```swift\nprint(\"Hello, World!\")\n```\n\n---\n\nPerfect for iOS and macOS app development.",
+
+	"Kotlin": "## 🎯 Kotlin\nConcise JVM language. Android development with coroutines, null safety, and 100% Java interoperability.\n\n### Key Features\n* Null safety built-in\n* Coroutines for async programming\n* 100% Java interoperability\n* Multiplatform support\n\n---\n\n### Example\n```kotlin\nfun main() {\n    println(\"Hello, World!\")\n}\n```\n\n---\n\nPerfect for Android apps and server-side development.",
+	
+	"Dart": "## 🎯 Dart\nFlutter's foundation. Build beautiful cross-platform apps for iOS, Android, web, and desktop from one codebase.\n\n### Key Features\n* Single codebase for all platforms\n* Hot reload for fast development\n* Rich widget library\n* Native performance\n\n---\n\n### Example\n```dart\nvoid main() {\n  print('Hello, World!');\n}\n```\n\n---\n\nPerfect for cross-platform mobile and web applications.",
+
+	"Shell": "## 🐚 Shell/Bash\nSystem automation master. Write robust scripts for deployment, system administration, and file processing.\n\n### Key Features\n* Powerful text processing with pipes\n* System administration and automation\n* CI/CD pipeline integration\n* Universal Unix/Linux availability\n\n---\n\n### Example\n```bash\n#!/bin/bash\necho \"Hello, World!\"\n```\n\n---\n\nPerfect for automation, DevOps, and system administration.",
+
+	"Lua": "## 🌙 Lua\nLightweight scripting. Embedded applications, game scripting, and configuration management with minimal footprint.\n\n### Key Features\n* Tiny footprint (~280KB)\n* Fast execution\n* Easy C integration\n* Simple, clean syntax\n\n---\n\n### Example\n```lua\nprint(\"Hello, World!\")\n```\n\n---\n\nPerfect for game scripting, embedded systems, and application extensions.",
+
+	"Elixir": "## 💧 Elixir\nFault-tolerant concurrency. Actor model with millions of lightweight processes for distributed, real-time systems.\n\n### Key Features\n* Massive concurrency on Erlang VM\n* Built-in fault tolerance\n* Functional programming patterns\n* Excellent for real-time systems\n\n---\n\n### Example\n```elixir\nIO.puts \"Hello, World!\"\n```\n\n---\n\nPerfect for chat apps, IoT backends, and distributed systems.",
+	
+	"Haskell": "## λ Haskell\nPure functional programming. Type-driven development with mathematically elegant solutions and compile-time guarantees.\n\n### Key Features\n* Pure functional programming\n* Strong static typing\n* Lazy evaluation\n* Advanced type system\n\n---\n\n### Example\n```haskell\nmain = putStrLn \"Hello, World!\"\n```\n\n---\n\nPerfect for compilers, financial systems, and mathematically precise applications.",
+
+	"Elm": "## 🌳 Elm\nDelightful web apps. No runtime exceptions with functional reactive programming and immutable data structures.\n\n### Key Features\n* No runtime exceptions\n* Excellent error messages\n* Time-travel debugging\n* Guaranteed refactoring safety\n\n---\n\n### Example\n```elm\nimport Html exposing (text)\n\nmain =\n  text \"Hello, World!\"\n```\n\n---\n\nPerfect for maintainable frontend web applications.",
+
+	"Julia": "## 🔬 Julia\nScientific computing. High-performance numerical algorithms with Python-like syntax and C-like speed.\n\n### Key Features\n* Python-like syntax, C-like speed\n* Built-in parallel computing\n* Excellent for numerical analysis\n* Multiple dispatch system\n\n---\n\n### Example\n```julia\nprintln(\"Hello, World!\")\n```\n\n---\n\nPerfect for machine learning, scientific research, and computational mathematics.",
+
+	"SQL": "## 🗄️ SQL\nData mastery. Write efficient queries, design normalized schemas, and optimize database performance.\n\n### Key Features\n* Declarative query language\n* Works with PostgreSQL, MySQL, SQLite\n* Essential for data analysis\n* Industry-standard for databases\n\n---\n\n### Example\n```sql\nSELECT 'Hello, World!' AS greeting;\n```\n\n---\n\nPerfect for data analysis, backend development, and business intelligence.",
+
+	"Arduino": "## 🤖 Arduino\nHardware programming. Build IoT devices, sensor networks, and interactive electronic projects with C++ for microcontrollers.\n\n### Key Features\n* Easy hardware interfacing\n* Large sensor and module ecosystem\n* Digital and analog I/O\n* Serial communication protocols\n\n---\n\n### Example\n```cpp\nvoid setup() {\n  Serial.begin(9600);\n}\n\nvoid loop() {\n  Serial.println(\"Hello, World!\");\n  delay(1000);\n}\n```\n\n---\n\nPerfect for home automation, robotics, and IoT projects.",
+
+	"Scheme": "## 🧠 Scheme\nMinimalist functional programming. Pure computational thinking and programming language fundamentals with elegant syntax.\n\n### Key Features\n* Minimal, elegant syntax\n* First-class functions\n* Powerful macro system\n* Educational and theoretical\n\n---\n\n### Example\n```scheme\n(display \"Hello, World!\")\n(newline)\n```\n\n---\n\nPerfect for learning computer science and programming language theory.",
+
+	"Lisp": "## 🧠 Lisp\nSymbolic AI programming. Meta-programming with code-as-data philosophy and powerful macro systems.\n\n### Key Features\n* Homoiconic code-as-data\n* Powerful macro system\n* REPL-driven development\n* Excellent for symbolic computation\n\n---\n\n### Example\n```lisp\n(print \"Hello, World!\")\n```\n\n---\n\nPerfect for AI, symbolic computation, and domain-specific languages.",
+}
+
+
+// getPersistenceFilePath returns the path to the persistence file
+func getPersistenceFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".claudekit.json"), nil
+}
+
+// persistenceStore reads and writes the encoded PersistenceConfig. Load returns an
+// error satisfying errors.Is(err, fs.ErrNotExist) when nothing has been saved yet.
+type persistenceStore interface {
+	Load() ([]byte, error)
+	Save(data []byte) error
+	String() string // Where choices are kept, for messages
+
+	// Backup keeps data, saved in format version, before an upgrade replaces it,
+	// and returns where it went.
+	Backup(data []byte, version int) (string, error)
+}
+
+// Environment variables that choose where claudekit remembers choices.
+const (
+	envPersistenceFile = "CLAUDEKIT_CONFIG"   // Path of the persistence file
+	envDotfilesDir     = "CLAUDEKIT_DOTFILES" // Git repository to sync choices through
+)
+
+// dotfilesFileName is the persistence file kept in a dotfiles repository.
+const dotfilesFileName = "claudekit.json"
+
+// newPersistenceStore picks the persistence backend: the file named by
+// CLAUDEKIT_CONFIG, a git-synced dotfiles directory named by CLAUDEKIT_DOTFILES, or
+// ~/.claudekit.json.
+func newPersistenceStore() (persistenceStore, error) {
+	if path := os.Getenv(envPersistenceFile); path != "" {
+		return filePersistenceStore{path: path}, nil
+	}
+	if dir := os.Getenv(envDotfilesDir); dir != "" {
+		return gitPersistenceStore{dir: dir}, nil
+	}
+	path, err := getPersistenceFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return filePersistenceStore{path: path}, nil
+}
+
+// filePersistenceStore keeps choices in a single local file.
+type filePersistenceStore struct {
+	path string
+}
+
+func (s filePersistenceStore) Load() ([]byte, error) {
+	return os.ReadFile(s.path)
+}
+
+func (s filePersistenceStore) Save(data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+func (s filePersistenceStore) String() string {
+	return s.path
+}
+
+// Backup writes data next to the file, as ~/.claudekit.json.v0.bak for version 0.
+func (s filePersistenceStore) Backup(data []byte, version int) (string, error) {
+	path := fmt.Sprintf("%s.v%d.bak", s.path, version)
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// gitPersistenceStore keeps choices in a dotfiles repository. It pulls before loading
+// and commits and pushes after saving, so every machine that clones the repository
+// shares the same choices. Repositories without a remote are used locally.
+type gitPersistenceStore struct {
+	dir string
+}
+
+// gitSyncTimeout bounds each git command so an unreachable remote cannot hang the form.
+const gitSyncTimeout = 10 * time.Second
+
+func (s gitPersistenceStore) Load() ([]byte, error) {
+	if s.hasRemote() {
+		if err := s.git("pull", "--ff-only", "--quiet"); err != nil {
+			// Stale choices beat none; the next save reports the problem again
+			slog.Warn("failed to sync saved choices", "dir", s.dir, "err", err)
+		}
+	}
+	return os.ReadFile(filepath.Join(s.dir, dotfilesFileName))
+}
+
+func (s gitPersistenceStore) Save(data []byte) error {
+	if err := s.git("rev-parse", "--git-dir"); err != nil {
+		return fmt.Errorf("%s is not a git repository: %w", s.dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, dotfilesFileName), data, 0644); err != nil {
+		return err
+	}
+	if err := s.git("add", "--", dotfilesFileName); err != nil {
+		return err
+	}
+	// Nothing staged means the choices did not change
+	if err := s.git("diff", "--cached", "--quiet", "--", dotfilesFileName); err == nil {
+		return nil
+	}
+	if err := s.git("commit", "--quiet", "-m", "Update claudekit choices", "--", dotfilesFileName); err != nil {
+		return err
+	}
+	if s.hasRemote() {
+		if err := s.git("push", "--quiet"); err != nil {
+			return fmt.Errorf("choices committed but not pushed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s gitPersistenceStore) String() string {
+	return filepath.Join(s.dir, dotfilesFileName) + " (git)"
+}
+
+// Backup leaves data to the repository, where the commit before the upgrade keeps it.
+func (s gitPersistenceStore) Backup(data []byte, version int) (string, error) {
+	return "the git history of " + s.dir, nil
+}
+
+// hasRemote reports whether the repository has a remote to pull from and push to.
+func (s gitPersistenceStore) hasRemote() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), gitSyncTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", s.dir, "remote").Output()
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
+// git runs a git command in the repository, folding its output into any error.
+func (s gitPersistenceStore) git(args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), gitSyncTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", append([]string{"-C", s.dir}, args...)...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// loadPersistenceConfig loads previous choices from the persistence store
+func loadPersistenceConfig() (*PersistenceConfig, error) {
+	store, err := newPersistenceStore()
+	if err != nil {
+		return nil, err
+	}
+	
+	// If nothing was saved yet, return empty config
+	data, err := store.Load()
+	if errors.Is(err, fs.ErrNotExist) {
+		return &PersistenceConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	upgraded, from, err := persistenceMigrations.Upgrade(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", store, err)
+	}
+
+	var config PersistenceConfig
+	if err := json.Unmarshal(upgraded, &config); err != nil {
+		return nil, err
+	}
+	if from < persistenceSchemaVersion {
+		// Upgrade in place so older choices are not read the old way again, keeping
+		// the original in case the upgrade got something wrong
+		backup, err := store.Backup(data, from)
+		if err != nil {
+			return nil, fmt.Errorf("backing up %s before upgrading it: %w", store, err)
+		}
+		if err := storePersistenceConfig(config); err != nil {
+			return nil, err
+		}
+		slog.Info("upgraded saved choices", "path", store.String(), "from", from, "to", persistenceSchemaVersion, "backup", backup)
+	}
+
+	return &config, nil
+}
+
+// persistenceSchemaVersion is the PersistenceConfig format this version writes.
+const persistenceSchemaVersion = 1
+
+// persistenceMigrations upgrades saved choices from each earlier format. Add one
+// for every change that would make an older file read differently, and bump
+// persistenceSchemaVersion with it.
+var persistenceMigrations = migrate.Plan{
+	Name:    "saved choices",
+	Current: persistenceSchemaVersion,
+	Migrations: []migrate.Migration{
+		{
+			From:        0,
+			Description: "drop the emoji prefixes saved with module names",
+			Apply: func(doc map[string]any) error {
+				for _, key := range []string{"subagents", "hooks", "slash_commands", "mcp_servers"} {
+					if names, ok := doc[key].([]any); ok {
+						for i, name := range names {
+							if name, ok := name.(string); ok {
+								names[i] = stripEmojiPrefix(name)
+							}
+						}
+					}
+				}
+				for _, key := range []string{"output_style", "statusline"} {
+					if name, ok := doc[key].(string); ok {
+						doc[key] = stripEmojiPrefix(name)
+					}
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// stripEmojiPrefix removes the "🔍 " that form labels put before a module name, but
+// only when what precedes the first space has no letters or digits, so names that
+// merely contain a space are kept.
+func stripEmojiPrefix(name string) string {
+	prefix, rest, ok := strings.Cut(name, " ")
+	if !ok || strings.IndexFunc(prefix, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+		return name
+	}
+	return strings.TrimSpace(rest)
+}
+
+// projectSchemaErrors validates the settings.json and .mcp.json in dir, so choices
+// missing from a malformed file can be explained; missing files are not errors.
+func projectSchemaErrors(dir string) []schema.Error {
+	var errs []schema.Error
+	for _, file := range []struct {
+		rel      string
+		validate func(string, []byte) []schema.Error
+	}{{".claude/settings.json", schema.Settings}, {".mcp.json", schema.MCP}} {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.rel))); err == nil {
+			errs = append(errs, file.validate(file.rel, data)...)
+		}
+	}
+	return errs
+}
+
+// projectChoices reads the choices behind an existing .claude directory in dir back
+// from the files themselves: agents, hook scripts, commands, .mcp.json, settings.json,
+// CLAUDE.md, and the manifest. Found choices replace those in saved, which may be
+// stale or belong to another project. Only names the registry knows are kept, so
+// hand-written files are not mistaken for modules.
+func projectChoices(dir string, saved PersistenceConfig, registry *ModuleRegistry) PersistenceConfig {
+	choices := saved
+	choices.IsProjectLocal = true
+
+	layout := manifest.DefaultLayout()
+	mf, err := manifest.Load(dir)
+	if err == nil {
+		layout = mf.Layout
+		choices.Layout = mf.Layout
+	}
+
+	// names lists the files in a layout directory as name → extension
+	names := func(rel string) map[string]string {
+		found := map[string]string{}
+		entries, _ := os.ReadDir(filepath.Join(dir, filepath.FromSlash(rel)))
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				ext := filepath.Ext(entry.Name())
+				found[strings.TrimSuffix(entry.Name(), ext)] = ext
+			}
+		}
+		return found
+	}
+	sorted := func(found map[string]string, keep func(name, ext string) bool) []string {
+		var list []string
+		for _, name := range slices.Sorted(maps.Keys(found)) {
+			if keep(name, found[name]) {
+				list = append(list, name)
+			}
+		}
+		return list
+	}
+
+	choices.Subagents = sorted(names(layout.Agents), func(name, ext string) bool {
+		custom := slices.ContainsFunc(saved.CustomSubagents, func(c generation.CustomSubagent) bool { return c.Name == name })
+		return ext == ".md" && (custom || registry.Get(TypeSubagent, name) != nil)
+	})
+	// Namespaced commands are in subdirectories named after their namespace
+	choices.SlashCommands = nil
+	commandsDir := filepath.Join(dir, filepath.FromSlash(layout.Commands))
+	_ = filepath.WalkDir(commandsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+		name := strings.TrimSuffix(entry.Name(), ".md")
+		module := registry.Get(TypeCommand, name)
+		if (name == "example" || module != nil) && path == commandPath(commandsDir, name, module) {
+			choices.SlashCommands = append(choices.SlashCommands, name)
+		}
+		return nil
+	})
+	slices.Sort(choices.SlashCommands)
+
+	// A hook script's extension is its language; record it where it is not the default
+	languages := maps.Clone(saved.HookLanguages)
+	choices.Hooks = sorted(names(layout.Hooks), func(name, ext string) bool {
+		module := registry.Get(TypeHook, name)
+		if module == nil {
+			return false
+		}
+		for lang, langExt := range hookLanguageExt {
+			if langExt == ext && slices.Contains(hookLanguages(module), lang) {
+				if def, _ := resolveHookLanguage(name, module, languages); def != lang {
+					if languages == nil {
+						languages = map[string]string{}
+					}
+					languages[name] = string(lang)
+				}
+				return true
+			}
+		}
+		return false
+	})
+	choices.HookLanguages = languages
+
+	// Servers registered for the user are not in .mcp.json; keep those saved
+	choices.MCPServers = slices.DeleteFunc(slices.Clone(saved.MCPServers), func(server string) bool {
+		return !slices.Contains(saved.MCPUserScope, server)
+	})
+	if data, err := os.ReadFile(filepath.Join(dir, ".mcp.json")); err == nil {
+		var doc struct {
+			MCPServers map[string]json.RawMessage `json:"mcpServers"`
+		}
+		if json.Unmarshal(data, &doc) == nil {
+			for _, name := range slices.Sorted(maps.Keys(doc.MCPServers)) {
+				if registry.Get(TypeMCP, name) != nil && !slices.Contains(choices.MCPServers, name) {
+					choices.MCPServers = append(choices.MCPServers, name)
+				}
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, ".claude", "settings.json")); err == nil {
+		var st settings
+		if json.Unmarshal(data, &st) == nil {
+			choices.OutputStyle = ""
+			for _, style := range registry.List(TypeStyle) {
+				name, _ := style.Defaults["style_name"].(string)
+				if st.OutputStyle != "" && (st.OutputStyle == style.Name || st.OutputStyle == name) {
+					choices.OutputStyle = style.Name
+				}
+			}
+			choices.SlackWebhookURL = st.Env[slackWebhookEnv]
+			choices.DiscordWebhookURL = st.Env[discordWebhookEnv]
+
+			// A preset is selected when settings.json still holds all of its rules;
+			// the rules themselves are kept as they are, edits included
+			var entries []string
+			if st.Permissions != nil {
+				entries = permissionEntries(st.Permissions.Allow, st.Permissions.Ask, st.Permissions.Deny)
+			}
+			choices.Permissions = nil
+			for _, preset := range registry.List(TypePermissions) {
+				allow, ask, deny := mergePermissionPresets([]string{preset.Name}, registry)
+				rules := permissionEntries(allow, ask, deny)
+				if len(rules) > 0 && !slices.ContainsFunc(rules, func(rule string) bool { return !slices.Contains(entries, rule) }) {
+					choices.Permissions = append(choices.Permissions, preset.Name)
+				}
+			}
+			choices.PermissionRules = entries
+			if entries == nil {
+				choices.PermissionRules = []string{}
+			}
+		}
+	}
+
+	choices.Statusline = ""
+	if mf != nil {
+		for _, entry := range mf.Files {
+			if entry.Kind == manifest.KindStatusline && registry.Get(TypeStatusline, entry.Module) != nil {
+				choices.Statusline = entry.Module
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "CLAUDE.md")); err == nil {
+		for line := range strings.Lines(string(data)) {
+			if name, ok := strings.CutSuffix(strings.TrimSpace(line), " — Engineering Ground Rules"); ok && strings.HasPrefix(name, "# ") {
+				if name = strings.TrimSpace(name[2:]); name != "Your Project" {
+					choices.ProjectName = name
+				}
+				break
+			}
+		}
+	}
+	return choices
+}
+
+// savePersistenceConfig saves current choices to the persistence store
+func savePersistenceConfig(config Config) error {
+	return storePersistenceConfig(PersistenceConfig{
+		LastUpdated:    time.Now(),
+		IsProjectLocal: config.IsProjectLocal,
+		ProjectName:    config.ProjectName,
+		Languages:      config.Languages,
+		Frameworks:     config.Frameworks,
+		Subagents:      config.Subagents,
+		Hooks:          config.Hooks,
+		SlashCommands:  config.SlashCommands,
+		MCPServers:     config.MCPServers,
+		OutputStyle:    config.OutputStyle,
+		Permissions:    config.Permissions,
+		Statusline:     config.Statusline,
+		ClaudeMDExtras: config.ClaudeMDExtras,
+		SetupDoc:       config.SetupDoc,
+		SetupReadme:    config.SetupReadme,
+		MemoryFiles:    config.MemoryFiles,
+		Gitignore:      config.Gitignore,
+		EditorTasks:    config.EditorTasks,
+		Devcontainer:   config.Devcontainer,
+		Workflows:      config.Workflows,
+		Theme:          config.Theme,
+		SplitRatio:     config.SplitRatio,
+		Packages:       config.Packages,
+		Layout:         config.Layout,
+
+		IncludeDisabled: config.IncludeDisabled,
+
+		CustomSubagents: config.CustomSubagents,
+		HookLanguages:   config.HookLanguages,
+
+		SlackWebhookURL:   config.SlackWebhookURL,
+		DiscordWebhookURL: config.DiscordWebhookURL,
+
+		PermissionRules:       config.PermissionRules,
+		CustomPermissionRules: config.CustomPermissionRules,
+
+		Env: config.Env,
+
+		MCPUserScope: config.MCPUserScope,
+
+		CommandTools: config.CommandTools,
+
+		AgentModels: config.AgentModels,
+		AgentTools:  config.AgentTools,
+
+		HookMatchers: config.HookMatchers,
+		HookCommands: config.HookCommands,
+
+		AssetResolutions: config.AssetResolutions,
+	})
+}
+
+// storePersistenceConfig writes choices to the persistence store as they are, in
+// the current format
+func storePersistenceConfig(choices PersistenceConfig) error {
+	store, err := newPersistenceStore()
+	if err != nil {
+		return err
+	}
+
+	choices.SchemaVersion = persistenceSchemaVersion
+
+	data, err := json.MarshalIndent(choices, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return store.Save(data)
+}
+
+// shouldShowRightPanel returns true if terminal dimensions meet thresholds for right panel display.
+// Per FR-002/FR-003: Requires BOTH width >= 140 AND height >= 40 (inclusive thresholds).
+func shouldShowRightPanel(width, height int) bool {
+	return width >= MIN_WIDTH_FOR_PANEL && height >= MIN_HEIGHT_FOR_PANEL
+}
+
+// ============================================================================
+// Screen Layout
+// ============================================================================
+
+// Screen layout sizing constants. Every region of the TUI is derived from these by
+// computeLayout; View and Update never do their own arithmetic.
+const (
+	// Border adds 2 chars left/right (1 for border char, 1 for automatic border
+	// spacing) and Padding(1, 2) adds 2 more, with slack for wide glyphs
+	layoutBorderWidth = 10
+	// Border (top + bottom) + Padding(1, 2) (top + bottom)
+	layoutBorderHeight = 4
+	// 3 lines ASCII title + 1 line gradient border + 1 line spacing
+	layoutTitleHeight = 5
+	// Share of the inner width given to the form when the right panel is shown,
+	// and how far ctrl+left/ctrl+right move it between its bounds
+	layoutFormShare     = 0.6
+	layoutMinFormShare  = 0.3
+	layoutMaxFormShare  = 0.8
+	layoutFormShareStep = 0.05
+	// Narrowest the form and status panel get when the split is moved
+	layoutMinFormWidth   = 40
+	layoutMinStatusWidth = 30
+	// Borders and padding of the form and status panel sitting side by side
+	layoutPanelGap = 6
+	// formStyle padding when the form has the full width to itself
+	layoutFormPadding = 4
+	// Floors that keep tiny terminals from producing zero or negative sizes
+	layoutMinInnerWidth    = 20
+	layoutMinInnerHeight   = 10
+	layoutMinContentHeight = 20
+	// Narrowest inner width that fits the ASCII art title
+	layoutASCIITitleWidth = 60
+	// Border + Padding(1, 2) above and left of the title and content
+	layoutBorderTop  = 2
+	layoutBorderLeft = 3
+	// Rows the ASCII art title takes; the plain title takes one
+	layoutASCIITitleRows = 3
+)
+
+// ScreenLayout holds the size of every region of the TUI for one terminal size.
+type ScreenLayout struct {
+	Width, Height           int  // Terminal dimensions the layout was computed for
+	InnerWidth, InnerHeight int  // Inside the app border and padding
+	TitleHeight             int  // Rows left for the title; 0 when the content needs them all
+	ContentHeight           int  // Height of the form and the status panel
+	FormWidth               int  // Width of the form column
+	StatusWidth             int  // Width of the status panel; 0 when it is hidden
+	ShowRightPanel          bool // Whether the status panel is shown (FR-002, FR-003)
+	ASCIITitle              bool // Whether the ASCII art title fits
+	ContentTop              int  // Screen row the form and status panel start on
+	ContentLeft             int  // Screen column the form column starts at
+	StatusLeft              int  // Screen column the status panel starts at; 0 when it is hidden
+}
+
+// screenRegion is the part of the layout a mouse event lands in.
+type screenRegion int
+
+const (
+	regionNone screenRegion = iota
+	regionForm
+	regionStatus
+)
+
+// hit returns the region holding screen cell (x, y) and the row within the content
+// area, counted from ContentTop.
+func (l ScreenLayout) hit(x, y int) (screenRegion, int) {
+	row := y - l.ContentTop
+	switch {
+	case row < 0 || row >= l.ContentHeight || x < l.ContentLeft:
+		return regionNone, row
+	case l.ShowRightPanel && x >= l.StatusLeft:
+		return regionStatus, row
+	case x < l.ContentLeft+l.FormWidth:
+		return regionForm, row
+	}
+	return regionNone, row
+}
+
+// computeLayout sizes every region of the TUI from the terminal dimensions with
+// the default split between the form and the status panel.
+func computeLayout(width, height int) ScreenLayout {
+	return computeSplitLayout(width, height, layoutFormShare)
+}
+
+// computeSplitLayout sizes every region of the TUI from the terminal dimensions,
+// giving the form formShare of the width when the status panel is shown. Sizes
+// are floored rather than allowed to go negative, so the result is usable for any
+// input; View truncates whatever then overflows the terminal.
+func computeSplitLayout(width, height int, formShare float64) ScreenLayout {
+	l := ScreenLayout{
+		Width:          width,
+		Height:         height,
+		InnerWidth:     max(width-layoutBorderWidth, layoutMinInnerWidth),
+		InnerHeight:    max(height-layoutBorderHeight, layoutMinInnerHeight),
+		ShowRightPanel: shouldShowRightPanel(width, height),
+	}
+	l.ASCIITitle = l.InnerWidth >= layoutASCIITitleWidth
+
+	// The content keeps its minimum height and the title gives up rows instead
+	l.ContentHeight = max(l.InnerHeight-layoutTitleHeight, layoutMinContentHeight)
+	l.TitleHeight = max(l.InnerHeight-l.ContentHeight, 0)
+
+	if l.ShowRightPanel {
+		// The minimum widths win over the share, the status panel's first
+		l.FormWidth = int(float64(l.InnerWidth) * clampFormShare(formShare))
+		l.FormWidth = min(l.FormWidth, l.InnerWidth-layoutPanelGap-layoutMinStatusWidth)
+		l.FormWidth = max(l.FormWidth, layoutMinFormWidth)
+		l.StatusWidth = max(l.InnerWidth-l.FormWidth-layoutPanelGap, 0)
+	} else {
+		l.FormWidth = l.InnerWidth - layoutFormPadding
+	}
+
+	// The title sits above the gradient border line, then the content
+	titleRows := 1
+	if l.ASCIITitle {
+		titleRows = layoutASCIITitleRows
+	}
+	l.ContentTop = layoutBorderTop + titleRows + 1
+	l.ContentLeft = layoutBorderLeft
+	if l.ShowRightPanel {
+		l.StatusLeft = l.ContentLeft + l.FormWidth
+	}
+	return l
+}
+
+// clampFormShare keeps a form share between its bounds, reading 0 (no preference
+// saved) as the default.
+func clampFormShare(share float64) float64 {
+	if share == 0 {
+		return layoutFormShare
+	}
+	return min(max(share, layoutMinFormShare), layoutMaxFormShare)
+}
+
+// layout sizes the TUI for the model's terminal size and split.
+func (m model) layout() ScreenLayout {
+	return computeSplitLayout(m.width, m.height, m.formShare)
+}
+
+// debounceCompleteMsg signals that resize debounce period has elapsed
+type debounceCompleteMsg struct{}
+
+// handleWindowSizeMsg processes terminal resize events with adaptive debouncing.
+// A lone resize is applied after one frame. If another resize arrives while one is
+// still pending, the terminal is being dragged, so the full debounce delay is used
+// instead and the layout settles once the stream stops.
+func handleWindowSizeMsg(m model, msg tea.WindowSizeMsg) (model, tea.Cmd) {
+	delay := RESIZE_FRAME_MS * time.Millisecond
+	if m.pendingResize != nil {
+		delay = max(m.resizeDebounce, delay)
+	}
+
+	// Cancel existing debounce timer if present
+	if m.resizeDebouncer != nil {
+		m.resizeDebouncer.Stop()
+	}
+
+	// Cache resize message
+	m.pendingResize = &msg
+
+	// Start new debounce timer
+	m.resizeDebouncer = time.NewTimer(delay)
+
+	// Return Cmd that waits for timer to expire
+	return m, func() tea.Msg {
+		<-m.resizeDebouncer.C
+		return debounceCompleteMsg{}
+	}
+}
+
+// applyPendingResize updates model dimensions and recomputes panel visibility.
+// CRITICAL: MUST NOT modify m.form or m.config (FR-011: preserve all user input).
+func applyPendingResize(m model) (model, tea.Cmd) {
+	if m.pendingResize == nil {
+		return m, nil // No pending resize, nothing to do
+	}
+
+	// Update dimensions
+	m.width = m.pendingResize.Width
+	m.height = m.pendingResize.Height
+
+	// Recompute panel visibility (FR-002, FR-003)
+	m.showRightPanel = m.layout().ShowRightPanel
+
+	// Clear debounce state
+	m.pendingResize = nil
+	m.resizeDebouncer = nil
+
+	// IMPORTANT: Do NOT modify m.form or m.config - preserves input per FR-011
+
+	return m, nil
+}
+
+// moveSplit widens the form by one step, or narrows it, and saves the new share
+// with the choices. The layout is recomputed the same way as after a resize.
+func (m model) moveSplit(widen bool) (tea.Model, tea.Cmd) {
+	step := -layoutFormShareStep
+	if widen {
+		step = layoutFormShareStep
+	}
+	current := clampFormShare(m.formShare)
+	share := clampFormShare(math.Round((current+step)*100) / 100)
+	if share == current {
+		return m, nil
+	}
+	m.formShare = share
+	if m.config != nil {
+		m.config.SplitRatio = share
+	}
+	if m.pendingResize != nil {
+		return m, nil // The resize under way lays out with the new share
+	}
+	m.pendingResize = &tea.WindowSizeMsg{Width: m.width, Height: m.height}
+	return m.Update(debounceCompleteMsg{})
+}
+
+func (m model) Init() tea.Cmd {
+	if m.registry == nil && m.registryLoader != nil {
+		return tea.Batch(m.form.Init(), m.registryLoader.loadedCmd())
+	}
+	return m.form.Init()
+}
+
+// moduleLoadingDescription is the right panel placeholder shown on module pages
+// while the registry is still loading.
+const moduleLoadingDescription = "⏳ Loading modules…\n\n" +
+	"░░░░░░░░░░░░░░░░░░░░░░░░\n\n" +
+	"░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░\n" +
+	"░░░░░░░░░░░░░░░░░░░░░░░░░░░░\n" +
+	"░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░░"
+
+// hoveredOption is implemented by the option fields whose cursor picks the status
+// panel description: huh's MultiSelect and Select, and filterMultiSelect.
+type hoveredOption interface {
+	Hovered() (string, bool)
+}
+
+func (m *model) getCurrentDescription() string {
+	// Get current focus from form state
+	if m.form.State == huh.StateCompleted {
+		return "✅ Configuration complete! Ready to generate your Claude Code setup."
+	}
+	
+	// Get the currently focused field
+	focusedField := m.form.GetFocusedField()
+	if focusedField == nil {
+		return m.getDefaultDescription()
+	}
+	
+	// Check field key to identify what type of selection we're in
+	fieldKey := focusedField.GetKey()
+
+	// Module pages have nothing to describe until the registry arrives
+	if m.registry == nil {
+		switch fieldKey {
+		case "frameworks", "subagents", "hooks", "slash-commands", "mcp-servers", "output-style":
+			return moduleLoadingDescription
+		}
+	}
+	
+	// Handle language selection
+	if fieldKey == "languages" {
+		if multiSelect, ok := focusedField.(hoveredOption); ok {
+			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
+				if desc, exists := languageDescriptions[hoveredItem]; exists {
+					return desc
+				}
+			}
+		}
+		return "💻 Select programming languages used in your project. Claude will provide specialized assistance and optimized configurations for each language. Navigate with arrow keys to see how Claude can help."
+	}
+	
+	// Handle framework selection
+	if fieldKey == "frameworks" {
+		if multiSelect, ok := focusedField.(hoveredOption); ok {
+			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
+				if module := m.registry.Get(TypeFramework, hoveredItem); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "🧩 Select the frameworks your project uses. Each one adds test commands, lint tools, and directory conventions to CLAUDE.md. Frameworks found in this directory are preselected."
+	}
+
+	if fieldKey == "include-disabled" {
+		return "🧪 Module authors can switch a module off with enabled: false in its frontmatter, usually because it is experimental or being retired. Such modules are hidden unless you choose to see them here.\n\nShown modules are marked (disabled) and can be selected like any other. The choice is remembered; --include-disabled turns it on for one run."
+	}
+
+	// Workspace packages inherit everything chosen on the later pages
+	if fieldKey == "packages" {
+		return "📦 Select the workspace packages that get their own .claude/ directory.\n\nEach package shares the subagents, hooks, slash commands, MCP servers, permissions, output style, and statusline chosen here. Its languages and frameworks are detected in the package, and its CLAUDE.md leaves the shared rules to the root CLAUDE.md, which Claude Code also reads inside the package."
+	}
+
+	// Handle subagent selection (Feature 004: use registry)
+	if fieldKey == "subagents" {
+		if multiSelect, ok := focusedField.(hoveredOption); ok {
+			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
+				// Extract the subagent name (remove emoji prefix)
+				subagentName := extractSubagentName(hoveredItem)
+				if module := m.registry.Get(TypeSubagent, subagentName); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "🤖 Select specialized AI assistants for your development workflow. Navigate with arrow keys to see detailed descriptions."
+	}
+	
+	// Handle hook selection (Feature 004: use registry)
+	if fieldKey == "hooks" {
+		if multiSelect, ok := focusedField.(hoveredOption); ok {
+			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
+				// Extract the hook name (remove emoji prefix)
+				hookName := extractSubagentName(hoveredItem)
+				if module := m.registry.Get(TypeHook, hookName); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "🪝 Select automation hooks to enhance your development workflow. These scripts run at specific points to provide safety, quality control, and context. Navigate with arrow keys to see detailed descriptions."
+	}
+	
+	if fieldKey == "slack-webhook" || fieldKey == "discord-webhook" {
+		return "🔔 The notify-slack and notify-discord hooks post to a webhook when Claude needs you and when it finishes responding.\n\nA URL entered here is stored in settings.json's env as SLACK_WEBHOOK_URL or DISCORD_WEBHOOK_URL. Project settings are usually committed, so for a shared project leave it empty and export the variable in your shell instead. Create one in Slack under Incoming Webhooks, or in Discord under a channel's Integrations."
+	}
+
+	if fieldKey == "command-tools" {
+		return "🧾 Each command runs with the tools listed in its allowed-tools header, without asking. Claude Code passes what you type after the command as $ARGUMENTS, or $1, $2, ... one argument at a time.\n\nTo change a command's tools, write its name, a colon, and the tools as permission rules: `fix-github-issue: Read, Edit, Bash(gh issue view:*)`. Leave the tools empty to allow none. Commands you do not list keep the tools above."
+	}
+
+	// Handle slash command selection (Feature 004: use registry)
+	if fieldKey == "slash-commands" {
+		if multiSelect, ok := focusedField.(hoveredOption); ok {
+			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
+				// Extract the command name (remove emoji prefix)
+				commandName := extractSubagentName(hoveredItem)
+				if module := m.registry.Get(TypeCommand, commandName); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "⚡ Select custom slash commands for common development tasks. These powerful shortcuts automate complex workflows and boost productivity. Navigate with arrow keys to see detailed descriptions."
+	}
+	
+	// Handle MCP server selection (Feature 004: use registry)
+	if fieldKey == "mcp-user-scope" {
+		return "🔌 Servers in the project's .mcp.json are shared with everyone who clones it, and Claude Code asks each of them before using one.\n\nServers you select here are registered for you alone, in every project, with `claude mcp add-json --scope user`. claudekit prints those commands when it finishes, with tokens left as ${VAR} references. Choose this for personal accounts and tools the rest of the team does not use."
+	}
+
+	if fieldKey == "mcp-servers" {
+		if multiSelect, ok := focusedField.(hoveredOption); ok {
+			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
+				// Extract the MCP server name (remove emoji prefix)
+				serverName := extractSubagentName(hoveredItem)
+				if module := m.registry.Get(TypeMCP, serverName); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "🔌 Select external tool integrations to enhance Claude's capabilities via Model Context Protocol. Navigate with arrow keys to see detailed descriptions."
+	}
+	
+	// Handle the custom subagent wizard
+	if fieldKey == "create-subagent" || strings.HasPrefix(fieldKey, "custom-subagent-") {
+		return `✏️ **Custom subagent**
+
+Author a specialist that is not in the module library. claudekit writes it to the agents directory like any other subagent:
+
+* **Name** becomes the file name and the name Claude uses to delegate
+* **Description** tells Claude when to hand work to this subagent
+* **Tools** limits what it may use; leave empty to allow every tool
+* **Instructions** are its system prompt
+
+The subagent is saved in ~/.claudekit.json and listed with the other subagents on future runs.`
+	}
+
+	// Handle permission preset selection
+	if fieldKey == "permissions" {
+		if multiSelect, ok := focusedField.(hoveredOption); ok {
+			if hoveredItem, hasHovered := multiSelect.Hovered(); hasHovered {
+				if module := m.registry.Get(TypePermissions, hoveredItem); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "🛡️ Select permission presets for settings.json. Multiple presets are merged: a rule denied by any preset is denied, and a rule any preset asks about is never allowed silently."
+	}
+
+	if fieldKey == "permission-rules" || fieldKey == "permission-custom" {
+		return "📜 These are the allow, ask, and deny rules written to settings.json. They start as the rules of the presets you chose, plus any rules you added to settings.json yourself. Deselect a rule to leave it out.\n\nA rule is a tool name, optionally with a specifier: Bash(npm test) matches one command, Bash(git *:*) matches commands starting with git, Read(./secrets/**) matches paths, and WebFetch(domain:github.com) matches a host. Deny beats ask, and ask beats allow."
+	}
+
+	if fieldKey == "env" {
+		return "🌱 Claude Code sets these variables for every session. Common ones:\n\n- CLAUDE_CODE_MAX_OUTPUT_TOKENS: the longest response, in tokens\n- MCP_TOOL_TIMEOUT and MCP_TIMEOUT: how long MCP tool calls and server startup may take, in milliseconds\n- BASH_DEFAULT_TIMEOUT_MS and BASH_MAX_TIMEOUT_MS: how long shell commands may run\n- MAX_THINKING_TOKENS: the extended thinking budget\n- DISABLE_TELEMETRY, DISABLE_AUTOUPDATER: set to 1 to turn them off\n\nNumbers and switches are checked as you type. Project settings are usually committed, so keep credentials out of them."
+	}
+
+	// Handle statusline selection
+	if fieldKey == "statusline" {
+		if sel, ok := focusedField.(*huh.Select[string]); ok {
+			if hoveredItem, hasHovered := sel.Hovered(); hasHovered {
+				if module := m.registry.Get(TypeStatusline, hoveredItem); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "📊 Choose a statusline for Claude Code. The generated `.claude/statusline.sh` receives session details as JSON and prints one line below the prompt."
+	}
+
+	// Handle output style selection
+	if fieldKey == "output-style" {
+		if sel, ok := focusedField.(*huh.Select[string]); ok {
+			if hoveredItem, hasHovered := sel.Hovered(); hasHovered {
+				if module := m.registry.Get(TypeStyle, hoveredItem); module != nil {
+					return module.GetDescription()
+				}
+			}
+		}
+		return "🎨 Choose how Claude Code responds. Built-in styles only set `outputStyle` in settings.json; custom styles also generate a file in `.claude/output-styles/`."
+	}
+
+	return m.getDefaultDescription()
+}
+
+func (m *model) getDefaultDescription() string {
+	return `## 📋 Claude Code Project Setup
+
+Welcome to the interactive **Claude Code** project configuration tool! This wizard will help you set up a comprehensive development environment either _globally_, or on a _per project basis_.
+
+### 🔍 NAVIGATION:
+* Use **tab** & **shift-tab** to move between form fields
+* Use **arrow** keys to navigate between options
+* Use **space** to select/deselect items in multi-select lists
+* Use **enter** to proceed/confirm to the next field
+
+### 📚 WHAT YOU'RE CONFIGURING:
+* Project basics (directory, name, languages)
+* AI subagents for specialized development tasks
+* Automation hooks for workflow enhancement
+* External tool integrations via MCP
+
+Choose the options that best fit your development workflow and project needs. Your choices will persist and you may _use this tool again to make changes_.`
+}
+
+func extractSubagentName(displayName string) string {
+	// Remove emoji and space prefix (e.g., "🔍 code-reviewer" -> "code-reviewer")
+	parts := strings.SplitN(displayName, " ", 2)
+	if len(parts) > 1 {
+		return parts[1]
+	}
+	return displayName
+}
+
+func (m *model) renderMarkdown(content string) string {
+	if m.glamourRenderer == nil {
+		return content // Fallback to plain text
+	}
+	
+	rendered, err := m.glamourRenderer.Render(content)
+	if err != nil {
+		return content // Fallback to plain text on error
+	}
+	
+	return rendered
+}
+
+// tickMsg is our custom message for gradient animations
+type tickMsg time.Time
+
+// startTransition initiates a gradient theme transition (T033)
+func (m *model) startTransition(to gradient.Theme, duration time.Duration) tea.Cmd {
+	// Reduced motion, and colors nobody can see, need no animation
+	if m.noAnimation || m.terminalCap == gradient.NoColor {
+		m.transition.Active = false
+		m.currentTheme = to
+		return nil
+	}
+	m.transition = gradient.TransitionState{
+		Active:     true,
+		FromTheme:  m.currentTheme,
+		ToTheme:    to,
+		StartTime:  time.Now(),
+		Duration:   duration,
+		EasingFunc: gradient.EaseInOutCubic,
+	}
+
+	// Return initial tick command to start animation
+	return tea.Tick(16*time.Millisecond, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// themeTransitionDuration is how long the header takes to fade into a new theme.
+const themeTransitionDuration = 300 * time.Millisecond
+
+// applyTheme switches the form to the named palette preset, fading the header into
+// it from whatever it currently shows.
+func (m *model) applyTheme(name string) tea.Cmd {
+	m.themeName = name
+	m.styleMap, m.glamourRenderer = themeStyles(name, m.terminalCap)
+	return m.startTransition(m.styleMap[gradient.HeaderComponent][gradient.NormalState].Theme, themeTransitionDuration)
+}
+
+// themeStyles returns the component styles and markdown renderer for the named
+// palette preset, or for the default palette if the name is unknown. Without color
+// the markdown is rendered in glamour's plain notty style.
+func themeStyles(name string, capability gradient.TerminalCapability) (map[gradient.ComponentType]map[gradient.VisualState]gradient.ComponentStyle, *glamour.TermRenderer) {
+	palette, err := gradient.PaletteByName(name)
+	if err != nil {
+		palette = gradient.InitGradientPalettes()
+	}
+	styleMap := gradient.StyleMapForPalette(palette)
+	if capability == gradient.NoColor {
+		return styleMap, gradient.PlainGlamourRenderer()
+	}
+
+	// Extend color palette with markdown colors (Feature 006: T013)
+	gradient.ExtendColorPaletteForMarkdown(&palette)
+
+	// renderer is nil-checked by existing code (will fallback to plain text)
+	return styleMap, gradient.GenerateGlamourStyle(palette)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case registryLoadedMsg:
+		m.registry = msg.registry
+		m.upgrades = installedUpgrades(msg.registry)
+		return m, nil
+
+	case generatedFileMsg, generationOutputMsg, conflictMsg, generationDoneMsg:
+		return m.updateGeneration(msg)
+
+	case clipboardCopiedMsg:
+		m.copied = string(msg)
+		m.viewport.SetContent(m.statusContent())
+		return m, nil
+
+	case tea.MouseMsg:
+		if m.generating != nil {
+			return m, nil
+		}
+		return m.handleMouse(msg)
+
+	case tea.WindowSizeMsg:
+		// --force-size pins the layout regardless of the real terminal
+		if m.forcedSize != nil {
+			msg = *m.forcedSize
+		}
+		// Feature 007: Debounced resize handling
+		return handleWindowSizeMsg(m, msg)
+
+	case debounceCompleteMsg:
+		// Feature 007: Apply pending resize after debounce period
+		m, cmd := applyPendingResize(m)
+
+		// Size the status panel viewport from the same layout View() renders
+		l := m.layout()
+		if !m.ready {
+			m.viewport = viewport.New(l.StatusWidth, l.ContentHeight)
+			m.ready = true
+		} else {
+			m.viewport.Width = l.StatusWidth
+			m.viewport.Height = l.ContentHeight
+		}
+
+		return m, cmd
+
+	case tea.KeyMsg:
+		if m.generating != nil {
+			return m.updateGeneration(msg)
+		}
+		if m.pageMenu && msg.String() != "ctrl+c" {
+			return m.updatePageMenu(msg)
+		}
+		m.copied = ""
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "p":
+			if focusedKey(m.form) == generateConfirmKey {
+				return m.togglePreview(), nil
+			}
+		case "s", "m":
+			if focusedKey(m.form) == generateConfirmKey {
+				return m.copyFile(clipboardFiles[msg.String()])
+			}
+		case "ctrl+left", "ctrl+right":
+			// Text inputs move the cursor by word on these keys
+			if _, typing := m.form.GetFocusedField().(*huh.Input); !typing && m.showRightPanel {
+				return m.moveSplit(msg.String() == "ctrl+right")
+			}
+		case "esc":
+			if m.previewing {
+				return m.togglePreview(), nil
+			}
+			if m.pages != nil && !escapeHandledByField(m.form) {
+				return m.openPageMenu(), nil
+			}
+		}
+
+	// T032: Handle gradient animation ticks
+	case tickMsg:
+		if m.transition.Active {
+			progress := m.transition.Progress()
+			if progress >= 1.0 {
+				// Transition complete
+				m.transition.Active = false
+				m.currentTheme = m.transition.ToTheme
+			} else {
+				// Continue animating
+				m.currentTheme = gradient.InterpolateGradient(
+					m.transition.FromTheme,
+					m.transition.ToTheme,
+					progress,
+				)
+				// Schedule next tick for smooth animation
+				return m, tea.Tick(16*time.Millisecond, func(t time.Time) tea.Msg {
+					return tickMsg(t)
+				})
+			}
+		}
+	}
+
+	// The form is done with once generation starts
+	if m.generating != nil {
+		return m, nil
+	}
+
+	// Update form
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+	
+	// Handle viewport scrolling for status panel
+	var viewportCmd tea.Cmd
+	m.viewport, viewportCmd = m.viewport.Update(msg)
+	cmd = tea.Batch(cmd, viewportCmd)
+
+	// Leaving the confirmation page closes the preview
+	if m.previewing && focusedKey(m.form) != generateConfirmKey {
+		m.previewing = false
+	}
+	if page := m.currentPage(); page >= 0 {
+		m.visited[page] = true
+	}
+
+	// The theme page previews each palette as the cursor reaches it
+	if m.config != nil && m.config.Theme != "" && m.config.Theme != m.themeName {
+		cmd = tea.Batch(cmd, m.applyTheme(m.config.Theme))
+	}
+
+	// Update viewport content with current status/descriptions
+	m.viewport.SetContent(m.statusContent())
+
+	// Check if form is complete
+	if m.form.State == huh.StateCompleted {
+		if m.generate != nil {
+			m, generateCmd := m.startGeneration()
+			return m, tea.Batch(cmd, generateCmd)
+		}
+		return m, tea.Quit
+	}
+
+	return m, cmd
+}
+
+// handleMouse scrolls the status panel with the wheel and toggles options of the
+// focused multi-select, or picks a page from the page menu, with a left click. Other
+// mouse events are dropped.
+func (m model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if !m.ready {
+		return m, nil
+	}
+	l := m.layout()
+	region, row := l.hit(msg.X, msg.Y)
+
+	if tea.MouseEvent(msg).IsWheel() {
+		// Without the panel, a preview is scrolled in the form's place
+		if region == regionStatus || (region == regionForm && m.previewing && !l.ShowRightPanel) {
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	if region != regionForm || msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+	row -= formStyle.GetPaddingTop()
+	if m.pageMenu {
+		return m.clickPageMenu(row)
+	}
+	lines := strings.Split(m.formPane(l), "\n")
+	if row < 0 || row >= len(lines) {
+		return m, nil
+	}
+	if field, ok := m.form.GetFocusedField().(*filterMultiSelect); ok && field.click(ansi.Strip(lines[row])) {
+		m.viewport.SetContent(m.statusContent())
+	}
+	return m, nil
+}
+
+// togglePreview switches the right panel between the configuration summary and the
+// generated files, starting either from the top.
+func (m model) togglePreview() model {
+	m.previewing = !m.previewing
+	m.viewport.SetContent(m.statusContent())
+	m.viewport.GotoTop()
+	return m
+}
+
+// clipboardFiles are the generated files the confirmation page copies, by key.
+var clipboardFiles = map[string]string{
+	"s": filepath.Join(".claude", "settings.json"),
+	"m": ".mcp.json",
+}
+
+// clipboardCopiedMsg reports what a copy from the confirmation page did.
+type clipboardCopiedMsg string
+
+// copyFile copies the generated file at path, as the preview shows it, to the
+// clipboard and notes the outcome at the top of the right panel. The clipboard
+// tool runs in a command, so a slow one does not hold up the form.
+func (m model) copyFile(path string) (model, tea.Cmd) {
+	m.copied = fmt.Sprintf("⚠️ %s would not be generated", path)
+	dir, err := resolveTargetDir(m.config.IsProjectLocal)
+	if err == nil {
+		var files []previewFile
+		files, err = previewFiles(*m.config, m.registry, dir)
+		if i := slices.IndexFunc(files, func(f previewFile) bool { return f.Path == path }); i >= 0 {
+			content, output := files[i].Content, m.output
+			m.copied = fmt.Sprintf("📋 Copying %s…", path)
+			m.viewport.SetContent(m.statusContent())
+			return m, func() tea.Msg {
+				return clipboardCopiedMsg(fmt.Sprintf("📋 Copied %s to the %s", path, copyToClipboard(output, content)))
+			}
+		}
+	}
+	if err != nil {
+		m.copied = fmt.Sprintf("⚠️ Could not copy %s: %v", path, err)
+	}
+	m.viewport.SetContent(m.statusContent())
+	return m, nil
+}
+
+// Clipboard backends; tests replace them.
+var (
+	systemClipboard   = clipboard.WriteAll
+	terminalClipboard = func(out io.Writer, text string) { termenv.NewOutput(out).Copy(text) }
+)
+
+// copyToClipboard puts text on the system clipboard. Over SSH, where that clipboard
+// is the remote machine's, or without a clipboard tool, it asks the terminal to with
+// an OSC 52 escape sequence written to out instead. It returns which clipboard it used.
+func copyToClipboard(out io.Writer, text string) string {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		if err := systemClipboard(text); err == nil {
+			return "clipboard"
+		}
+	}
+	terminalClipboard(out, text)
+	return "terminal clipboard (OSC 52)"
+}
+
+// programOutput is the terminal a program draws on. Each write is made whole under
+// a lock, so an escape sequence sent beside the program's frames, such as an OSC
+// 52 copy, never lands inside one. It stays a terminal file, so Bubble Tea still
+// sizes and restores it.
+type programOutput struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (o *programOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+func (o *programOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// withPages enables the page menu for a form laid out as pages.
+func (m model) withPages(pages []wizardPage) model {
+	m.pages = pages
+	m.visited = map[int]bool{}
+	return m
+}
+
+// currentPage returns the index of the page holding the focused field, or -1.
+func (m model) currentPage() int {
+	key := focusedKey(m.form)
+	for i, page := range m.pages {
+		if slices.Contains(page.Keys, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pageHidden reports whether page i is skipped by the form.
+func (m model) pageHidden(i int) bool {
+	return m.pages[i].Hidden != nil && m.pages[i].Hidden()
+}
+
+// escapeHandledByField reports whether the focused field uses esc itself, to clear
+// a filter.
+func escapeHandledByField(form *huh.Form) bool {
+	switch field := form.GetFocusedField().(type) {
+	case *filterMultiSelect:
+		return field.query != ""
+	case *huh.Select[string]:
+		return field.GetFiltering()
+	}
+	return false
+}
+
+// openPageMenu shows the page menu with the cursor on the current page.
+func (m model) openPageMenu() model {
+	m.pageMenu = true
+	m.menuCursor = max(m.currentPage(), 0)
+	return m
+}
+
+// updatePageMenu handles a key while the page menu is open.
+func (m model) updatePageMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.pageMenu = false
+	case "up", "k":
+		m.menuCursor = m.stepMenuCursor(-1)
+	case "down", "j":
+		m.menuCursor = m.stepMenuCursor(1)
+	case "enter":
+		m.pageMenu = false
+		return m.jumpToPage(m.menuCursor)
+	}
+	return m, nil
+}
+
+// stepMenuCursor returns the next page in direction delta that the form shows.
+func (m model) stepMenuCursor(delta int) int {
+	for i := m.menuCursor + delta; i >= 0 && i < len(m.pages); i += delta {
+		if !m.pageHidden(i) {
+			return i
+		}
+	}
+	return m.menuCursor
+}
+
+// pageMenuHeaderRows are the rows renderPageMenu writes above the first page.
+const pageMenuHeaderRows = 2
+
+// clickPageMenu jumps to the page listed on row of the page menu.
+func (m model) clickPageMenu(row int) (tea.Model, tea.Cmd) {
+	row -= pageMenuHeaderRows
+	for i := range m.pages {
+		if m.pageHidden(i) {
+			continue
+		}
+		if row == 0 {
+			m.pageMenu = false
+			return m.jumpToPage(i)
+		}
+		row--
+	}
+	return m, nil
+}
+
+// jumpToPage moves the form group by group to page. Every answer is kept since the
+// fields write through to the config as they change. Validation errors on the way
+// stop the form where they are, as they do for tab and shift+tab.
+func (m model) jumpToPage(page int) (tea.Model, tea.Cmd) {
+	current := m.currentPage()
+	if current < 0 {
+		return m, nil
+	}
+	m.visited[current] = true
+
+	var cmds []tea.Cmd
+	for current != page {
+		if page < current {
+			cmds = append(cmds, m.form.PrevGroup())
+		} else {
+			cmds = append(cmds, m.form.NextGroup())
+		}
+		next := m.currentPage()
+		if next < 0 || next == current {
+			break
+		}
+		current = next
+	}
+	m.visited[current] = true
+	m.viewport.SetContent(m.statusContent())
+	m.viewport.GotoTop()
+	return m, tea.Batch(cmds...)
+}
+
+// renderPageMenu lists the form's pages: ● marks the current page, ✓ pages already
+// visited, and ○ the rest.
+func (m model) renderPageMenu() string {
+	var b strings.Builder
+	b.WriteString(menuCursorStyle.Render("Jump to page") + "\n\n")
+	current := m.currentPage()
+	n := 0
+	for i, page := range m.pages {
+		if m.pageHidden(i) {
+			continue
+		}
+		n++
+		mark := "○"
+		switch {
+		case i == current:
+			mark = "●"
+		case m.visited[i]:
+			mark = "✓"
+		}
+		line := fmt.Sprintf("%s %2d. %s", mark, n, page.Title)
+		if i == m.menuCursor {
+			b.WriteString("▸ " + menuCursorStyle.Render(line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	b.WriteString("\n" + menuHelpStyle.Render("↑/↓ choose • enter jump • esc close"))
+	return b.String()
+}
+
+func (m model) View() string {
+	if !m.ready {
+		return "Initializing..."
+	}
+
+	l := m.layout()
+
+	// Title with gradient (T035)
+	// T015: Width-based conditional rendering for ASCII art title
+	headerTheme := m.currentTheme
+	var title string
+
+	// Version string with subtle styling
+	versionText := "v" + Version
+	versionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#888888")).
+		Faint(true)
+	version := versionStyle.Render(versionText)
+
+	if l.ASCIITitle {
+		// Wide terminal: render ASCII art with gradient foreground + version
+		gradientASCII := gradient.RenderASCIITitle(asciiTitle, headerTheme, m.terminalCap)
+
+		// Split ASCII art into lines and add version to the first line
+		asciiLines := strings.Split(gradientASCII, "\n")
+		if len(asciiLines) > 0 {
+			// Calculate padding to right-align version
+			firstLineWidth := lipgloss.Width(asciiLines[0])
+			padding := l.InnerWidth - firstLineWidth - lipgloss.Width(version)
+			if padding < 0 {
+				padding = 0
+			}
+			asciiLines[0] = asciiLines[0] + strings.Repeat(" ", padding) + version
+		}
+		title = strings.Join(asciiLines, "\n")
+	} else {
+		// Narrow terminal: fallback to regular gradient text with version
+		titleText := "🛠️  ClaudeKit"
+		gradientTitle := gradient.RenderGradient(titleText, headerTheme, m.terminalCap, true)
+
+		// Add version on same line with padding
+		titleWidth := lipgloss.Width(gradientTitle)
+		padding := l.InnerWidth - titleWidth - lipgloss.Width(version)
+		if padding < 0 {
+			padding = 0
+		}
+		title = gradientTitle + strings.Repeat(" ", padding) + version
+	}
+
+	// Create gradient top border with "/" characters
+	borderWidth := l.InnerWidth
+	borderText := strings.Repeat("/", borderWidth)
+	gradientBorder := gradient.RenderGradient(borderText, headerTheme, m.terminalCap, true)
+
+	// Feature 007: Adaptive right panel based on terminal size
+	var content string
+
+	if m.generating != nil {
+		// Generation takes the whole width; the form is done with
+		generation := formStyle.
+			Width(l.InnerWidth - layoutFormPadding).
+			Height(l.ContentHeight).
+			Render(m.renderGeneration(l.ContentHeight - formStyle.GetVerticalPadding()))
+		content = ensureExactHeight(generation, l.ContentHeight)
+	} else if l.ShowRightPanel {
+		// Update viewport height to match available content height
+		m.viewport.Height = l.ContentHeight
+		m.viewport.Width = l.StatusWidth
+
+		// Large terminal: show form + right panel
+		formContent := m.formPane(l)
+		if m.pageMenu {
+			formContent = m.renderPageMenu()
+		}
+		leftContent := formStyle.
+			Width(l.FormWidth).
+			Height(l.ContentHeight).
+			Render(formContent)
+
+		// Right panel content (FR-008: always fresh; unchanged content is not re-rendered)
+		m.viewport.SetContent(m.statusContent())
+
+		// Status panel (right side, fixed height to match form)
+		statusPanel := statusStyle.
+			Width(l.StatusWidth).
+			Height(l.ContentHeight). // Use consistent height
+			Render(m.viewport.View())
+
+		// Main content (left content + status)
+		// Ensure exact height by padding if necessary
+		leftContent = ensureExactHeight(leftContent, l.ContentHeight)
+		statusPanel = ensureExactHeight(statusPanel, l.ContentHeight)
+
+		content = lipgloss.JoinHorizontal(lipgloss.Top, leftContent, statusPanel)
+	} else {
+		// Small terminal: full-width form only (FR-006); the file preview takes the
+		// form's place since there is no panel to show it in
+		formContent := m.formPane(l)
+		if m.pageMenu {
+			formContent = m.renderPageMenu()
+		} else if m.previewing {
+			m.viewport.Width = l.FormWidth
+			m.viewport.Height = l.ContentHeight
+			m.viewport.SetContent(m.statusContent())
+			formContent = m.viewport.View()
+		}
+		leftContent := formStyle.
+			Width(l.FormWidth). // Full width minus padding
+			Height(l.ContentHeight).
+			Render(formContent)
+
+		// Ensure exact height
+		leftContent = ensureExactHeight(leftContent, l.ContentHeight)
+		content = leftContent
+	}
+
+	// Combine title, border, and content
+	app := lipgloss.JoinVertical(lipgloss.Left, title, gradientBorder, content)
+
+	// Add border around entire application with gradient start color and padding
+	borderColor := headerTheme.StartColor
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(1, 2) // 1 line top/bottom, 2 chars left/right padding
+
+	// Render the app with border
+	rendered := borderStyle.Render(app)
+
+	// Enforce exact terminal dimensions to prevent height overflow
+	// Truncate content to fit within terminal bounds
+	lines := strings.Split(rendered, "\n")
+	if len(lines) > m.height {
+		lines = lines[:m.height]
+	}
+
+	// Ensure each line doesn't exceed width
+	for i, line := range lines {
+		if lipgloss.Width(line) > m.width {
+			// Truncate line to fit width (accounting for ANSI codes)
+			lines[i] = truncateLine(line, m.width)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// truncateLine truncates a line to the specified width, preserving ANSI codes
+func truncateLine(line string, width int) string {
+	// Use lipgloss's Truncate which handles ANSI codes properly
+	return lipgloss.NewStyle().Width(width).Render(line)
+}
+
+// formPane renders the form to fit the rows of the content area inside formStyle's
+// padding. A form that is too tall scrolls just far enough to keep the focused
+// field's cursor in view, with a line at either end saying how much is cut off there.
+func (m model) formPane(l ScreenLayout) string {
+	view := m.form.View()
+	rows := l.ContentHeight - formStyle.GetVerticalPadding()
+	lines := strings.Split(view, "\n")
+	if len(lines) <= rows || rows < 3 {
+		return view
+	}
+
+	// Below the first screen, the cursor sits just above the bottom indicator
+	offset := 0
+	if cursor := focusedLine(lines, m.form.GetFocusedField(), rows-2); cursor > rows-2 {
+		offset = min(cursor-(rows-3), len(lines)-rows+1)
+	}
+
+	height := rows
+	if offset > 0 {
+		height--
+	}
+	if offset+height < len(lines) {
+		height--
+	}
+	indicator := lipgloss.NewStyle().Faint(true)
+	var pane []string
+	if offset > 0 {
+		pane = append(pane, indicator.Render(fmt.Sprintf("▲ %d more", offset)))
+	}
+	pane = append(pane, lines[offset:offset+height]...)
+	if below := len(lines) - offset - height; below > 0 {
+		pane = append(pane, indicator.Render(fmt.Sprintf("▼ %d more", below)))
+	}
+	return strings.Join(pane, "\n")
+}
+
+// optionSelector marks the option under the cursor in huh's select fields, after
+// the focused field's left border and padding.
+var (
+	optionSelector     = ansi.Strip(huh.ThemeCharm().Focused.MultiSelectSelector.Render())
+	focusedFieldMargin = " " + lipgloss.ThickBorder().Left
+)
+
+// focusedLine finds field in the form's lines and returns the line that has to be
+// in view: the option under the cursor, or for fields without options their last
+// line, taking at most rows lines from the top of the field. It returns 0 when
+// field is not found.
+func focusedLine(lines []string, field huh.Field, rows int) int {
+	if field == nil {
+		return 0
+	}
+	fieldLines := strings.Split(field.View(), "\n")
+	top := -1
+	for i := 0; i+len(fieldLines) <= len(lines) && top < 0; i++ {
+		if blockContains(lines[i:], fieldLines) {
+			top = i
+		}
+	}
+	if top < 0 {
+		return 0
+	}
+
+	for j, line := range fieldLines {
+		if margin, _, ok := strings.Cut(ansi.Strip(line), optionSelector); ok && strings.Trim(margin, focusedFieldMargin) == "" {
+			return top + j
+		}
+	}
+	return top + min(len(fieldLines), rows) - 1
+}
+
+// blockContains reports whether each of lines contains the matching one of block.
+func blockContains(lines, block []string) bool {
+	for j, line := range block {
+		if !strings.Contains(lines[j], line) {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureExactHeight pads or truncates content to be exactly the specified height
+func ensureExactHeight(content string, targetHeight int) string {
+	lines := strings.Split(content, "\n")
+	currentHeight := len(lines)
+
+	if currentHeight == targetHeight {
+		return content
+	}
+
+	if currentHeight > targetHeight {
+		// Truncate to target height
+		return strings.Join(lines[:targetHeight], "\n")
+	}
+
+	// Pad with empty lines to reach target height
+	padding := make([]string, targetHeight-currentHeight)
+	for i := range padding {
+		padding[i] = ""
+	}
+	return content + "\n" + strings.Join(padding, "\n")
+}
+
+func (m *model) renderStatus() string {
+	// If on the confirmation page, show configuration summary
+	if m.form.State == huh.StateCompleted || isOnConfirmationPage(m.form) {
+		status := m.renderConfigurationSummary()
+		if m.previewing {
+			status = m.renderFilePreview()
+		}
+		if m.copied != "" {
+			status = "> " + m.copied + "\n\n" + status
+		}
+		return status
+	}
+	
+	// Otherwise, show the current description
+	return m.getCurrentDescription()
+}
+
+// statusCache remembers what the right panel last showed, so redrawing it after a
+// keystroke that changed nothing there skips rendering its markdown again.
+type statusCache struct {
+	valid     bool
+	described bool      // Whether key holds what the description was shown for
+	key       statusKey // Only for descriptions; the summary depends on every answer
+	source    string    // Markdown last rendered
+	renderer  *glamour.TermRenderer
+	rendered  string
+}
+
+// statusKey is what a description in the right panel depends on.
+type statusKey struct {
+	field, hovered string
+	loaded         bool
+}
+
+// descriptionKey returns what the right panel's description depends on, or false
+// when the panel shows the configuration summary or file preview instead.
+func (m *model) descriptionKey() (statusKey, bool) {
+	if m.form.State == huh.StateCompleted || isOnConfirmationPage(m.form) {
+		return statusKey{}, false
+	}
+	key := statusKey{field: focusedKey(m.form), loaded: m.registry != nil}
+	if field, ok := m.form.GetFocusedField().(hoveredOption); ok {
+		key.hovered, _ = field.Hovered()
+	}
+	return key, true
+}
+
+// statusContent returns the right panel's content rendered as markdown. A
+// description is reused while the focused field and hovered option stay the same,
+// and other content is only rendered again when its markdown changed.
+func (m *model) statusContent() string {
+	c := m.status
+	if c == nil {
+		return m.renderMarkdown(m.renderStatus())
+	}
+	key, described := m.descriptionKey()
+	if c.valid && c.renderer == m.glamourRenderer && described && c.described && key == c.key {
+		return c.rendered
+	}
+	source := m.renderStatus()
+	if !c.valid || c.renderer != m.glamourRenderer || source != c.source {
+		c.source, c.renderer, c.rendered = source, m.glamourRenderer, m.renderMarkdown(source)
+		c.valid = true
+	}
+	c.key, c.described = key, described
+	return c.rendered
+}
+
+// writeGroupedList writes names as a markdown list under their module categories,
+// or as a flat list when they all share one.
+func (m *model) writeGroupedList(b *strings.Builder, componentType ModuleComponentType, names []string, prefix string) {
+	groups := groupByCategory(names, func(name string) string {
+		if module := m.registry.Get(componentType, cleanFormValue(name)); module != nil {
+			return module.Category
+		}
+		return ""
+	})
+	for _, group := range groups {
+		indent := ""
+		if len(groups) > 1 {
+			fmt.Fprintf(b, "* **%s**\n", categoryLabel(group.Category))
+			indent = "  "
+		}
+		for _, name := range group.Values {
+			fmt.Fprintf(b, "%s* %s%s\n", indent, prefix, cleanFormValue(name))
+		}
+	}
+}
+
+// generateConfirmKey keys the final "Generate Claude Code configuration?" field, where
+// the file preview is available.
+const generateConfirmKey = "generate"
+
+// focusedKey returns the key of the form's focused field, or "" when none has focus.
+func focusedKey(form *huh.Form) string {
+	if field := form.GetFocusedField(); field != nil {
+		return field.GetKey()
+	}
+	return ""
+}
+
+// isOnConfirmationPage checks if we're on the final confirmation page
+func isOnConfirmationPage(form *huh.Form) bool {
+	// Check if the form has a focused field with confirmation-related text
+	focusedField := form.GetFocusedField()
+	if focusedField != nil {
+		// Check if the field title contains "Generate Claude Code configuration"
+		// This is a simple way to detect the confirmation page
+		if confirm, ok := focusedField.(*huh.Confirm); ok {
+			// We can check some property that would indicate it's our confirmation field
+			_ = confirm // Use the confirm variable to avoid unused variable error
+			// For now, let's assume any confirm field on the last page is our confirmation
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) renderConfigurationSummary() string {
+	var status strings.Builder
+	
+	status.WriteString("## 📋 Configuration Summary\n\n")
+	status.WriteString("\n\n-----\n\n")
+	
+	// Show configuration path based on project-local setting
+	if m.config.IsProjectLocal {
+		currentDir, err := os.Getwd()
+		if err != nil {
+			currentDir = "<current directory>"
+		}
+		status.WriteString("### 📁 Configuration Path:\n")
+		status.WriteString(fmt.Sprintf("  %s/.claude/\n\n", currentDir))
+	} else {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			homeDir = "<home directory>"
+		}
+		status.WriteString("### 🏠 Configuration Path:\n")
+		status.WriteString(fmt.Sprintf("  %s/.claude/\n\n", homeDir))
+	}
+	
+	// Language Setup
+	status.WriteString("### 💻 Languages\n")
+	if len(m.config.Languages) > 0 {
+		for _, lang := range m.config.Languages {
+			status.WriteString(fmt.Sprintf("* %s\n", lang))
+		}
+	} else {
+		status.WriteString("* (none selected)\n")
+	}
+	status.WriteString("\n")
+
+	// Frameworks
+	if len(m.config.Frameworks) > 0 {
+		status.WriteString("### 🧩 Frameworks\n")
+		for _, framework := range m.config.Frameworks {
+			status.WriteString(fmt.Sprintf("* %s\n", framework))
+		}
+		status.WriteString("\n")
+	}
+
+	// Workspace packages
+	if len(m.config.Packages) > 0 && m.config.IsProjectLocal {
+		status.WriteString("### 📦 Workspace Packages\n")
+		for _, dir := range m.config.Packages {
+			status.WriteString(fmt.Sprintf("* %s/.claude/\n", dir))
+		}
+		status.WriteString("\n")
+	}
+
+	// Subagents
+	status.WriteString("### 🤖 Subagents\n")
+	if len(m.config.Subagents) > 0 {
+		m.writeGroupedList(&status, TypeSubagent, m.config.Subagents, "")
+		overrides := parseAgentModelLines(m.config.AgentModels)
+		for _, name := range slices.Sorted(maps.Keys(overrides)) {
+			status.WriteString(fmt.Sprintf("* %s runs on %s\n", name, overrides[name]))
+		}
+		for _, name := range slices.Sorted(maps.Keys(m.config.AgentTools)) {
+			tools := strings.Join(m.config.AgentTools[name], ", ")
+			status.WriteString(fmt.Sprintf("* %s may use %s\n", name, cmp.Or(tools, "every tool")))
+		}
+	} else {
+		status.WriteString("* (none selected)\n")
+	}
+	status.WriteString("\n")
+
+	// Hooks
+	status.WriteString("### 🪝 Hooks\n")
+	if len(m.config.Hooks) > 0 {
+		for _, hook := range m.config.Hooks {
+			status.WriteString(fmt.Sprintf("* %s\n", cleanFormValue(hook)))
+		}
+		overrides := parseHookMatcherLines(m.config.HookMatchers)
+		for _, name := range slices.Sorted(maps.Keys(overrides)) {
+			status.WriteString(fmt.Sprintf("* %s fires on %s\n", name, overrides[name]))
+		}
+		orders := parseHookCommandLines(m.config.HookCommands)
+		for _, name := range slices.Sorted(maps.Keys(orders)) {
+			status.WriteString(fmt.Sprintf("* %s runs %s\n", name, strings.Join(orders[name], ", ")))
+		}
+	} else {
+		status.WriteString("* (none selected)\n")
+	}
+	status.WriteString("\n")
+
+	// Slash Commands
+	status.WriteString("### 📟 Slash Commands\n")
+	if len(m.config.SlashCommands) > 0 {
+		m.writeGroupedList(&status, TypeCommand, m.config.SlashCommands, "/")
+	} else {
+		status.WriteString("* (none selected)\n")
+	}
+	status.WriteString("\n")
+
+	// MCP
+	status.WriteString("### 🔌 MCP Integration\n")
+	if len(m.config.MCPServers) > 0 {
+		for _, server := range mcpServerLabels(*m.config) {
+			status.WriteString(fmt.Sprintf("* %s\n", server))
+		}
+	} else {
+		status.WriteString("* (none selected)\n")
+	}
+
+	status.WriteString("\n")
+
+	// Permissions
+	status.WriteString("### 🛡️ Permissions\n")
+	if len(m.config.Permissions) > 0 {
+		for _, preset := range m.config.Permissions {
+			status.WriteString(fmt.Sprintf("* %s\n", preset))
+		}
+	} else {
+		status.WriteString(fmt.Sprintf("* %s (default)\n", defaultPermissionPreset))
+	}
+	if m.config.PermissionRules != nil || strings.TrimSpace(m.config.CustomPermissionRules) != "" {
+		entries := append(slices.Clone(m.config.PermissionRules), parsePermissionLines(m.config.CustomPermissionRules)...)
+		allow, ask, deny := permissionsFromEntries(entries)
+		status.WriteString(fmt.Sprintf("* rules: %d allow, %d ask, %d deny\n", len(allow), len(ask), len(deny)))
+	}
+
+	if m.config.OutputStyle != "" {
+		status.WriteString("\n### 🎨 Output Style\n")
+		status.WriteString(fmt.Sprintf("* %s\n", m.config.OutputStyle))
+	}
+
+	if m.config.Statusline != "" {
+		status.WriteString("\n### 📊 Statusline\n")
+		status.WriteString(fmt.Sprintf("* %s\n", m.config.Statusline))
+	}
+
+	if len(m.config.EditorTasks) > 0 && m.config.IsProjectLocal {
+		status.WriteString("\n### 🧰 Editor Tasks\n")
+		for _, editor := range m.config.EditorTasks {
+			status.WriteString(fmt.Sprintf("* %s\n", editor))
+		}
+	}
+
+	if m.config.Devcontainer && m.config.IsProjectLocal {
+		status.WriteString("\n### 📦 Dev Container\n")
+		status.WriteString("* .devcontainer/claude-setup.sh\n")
+	}
+
+	if labels := memoryFileLabels(*m.config); len(labels) > 0 {
+		status.WriteString("\n### 🧠 Memory Files\n")
+		for _, label := range labels {
+			status.WriteString(fmt.Sprintf("* %s\n", label))
+		}
+	}
+
+	if len(m.config.Workflows) > 0 && m.config.IsProjectLocal {
+		status.WriteString("\n### 🤖 GitHub Actions\n")
+		for _, job := range m.config.Workflows {
+			status.WriteString(fmt.Sprintf("* %s\n", job))
+		}
+	}
+
+	// Installed modules that this run regenerates at a newer version
+	selected := selectedModules(m.config)
+	upgrades := slices.DeleteFunc(slices.Clone(m.upgrades[m.config.IsProjectLocal]), func(u moduleUpgrade) bool {
+		return !slices.Contains(selected, moduleRef{u.Module.Type, u.Module.Name})
+	})
+	if len(upgrades) > 0 {
+		status.WriteString("\n### ⬆️ Module Upgrades\n")
+		for _, u := range upgrades {
+			status.WriteString(fmt.Sprintf("* %s\n", u))
+		}
+		status.WriteString("\nSelected modules are regenerated at the new version. To choose upgrades one by one with a diff, cancel and run `claudekit upgrade`.\n")
+	}
+
+	// Dependencies are only added on generation; show what will come along
+	resolved := *m.config
+	if !resolved.IncludeDisabled {
+		m.registry.DropDisabled(&resolved)
+	}
+	added, conflicts := m.registry.ResolveDependencies(&resolved)
+	if len(added) > 0 || len(conflicts) > 0 {
+		status.WriteString("\n### 🔗 Added for Dependencies\n")
+		for _, addition := range added {
+			status.WriteString(fmt.Sprintf("* %s (required by %s)\n", addition.Module, addition.RequiredBy))
+		}
+		for _, conflict := range conflicts {
+			status.WriteString(fmt.Sprintf("* ⚠ %s\n", conflict))
+		}
+	}
+
+	// Asset paths more than one module declares, and how each is settled
+	if assetConflicts := m.registry.AssetConflicts(&resolved); len(assetConflicts) > 0 {
+		status.WriteString("\n### ⚠️ Asset Conflicts\n")
+		resolutions := parseAssetResolutionLines(m.config.AssetResolutions)
+		for _, conflict := range assetConflicts {
+			status.WriteString(fmt.Sprintf("* %s: %s\n", conflict, cmp.Or(resolutions[conflict.Path], "unresolved")))
+		}
+	}
+	
+	return status.String()
+}
+
+// previewFile is one file the confirmation page preview shows, with its path relative
+// to the configuration directory.
+type previewFile struct {
+	Path    string
+	Content string
+}
+
+// previewFiles returns CLAUDE.md, settings.json, .mcp.json and each hook script
+// exactly as run would write them into dir for cfg.
+func previewFiles(cfg Config, registry *ModuleRegistry, dir string) ([]previewFile, error) {
+	cfg.Subagents = cleanFormValues(cfg.Subagents)
+	cfg.Hooks = cleanFormValues(cfg.Hooks)
+	cfg.MCPServers = cleanFormValues(cfg.MCPServers)
+	if err := cfg.Layout.Validate(); err != nil {
+		return nil, err
+	}
+	hooksDir := manifest.Dir(dir, cfg.Layout.WithDefaults().Hooks)
+
+	claudeMD := renderClaudeMD(cfg, registry)
+	if !cfg.NoFmt {
+		rules, err := formatting.LoadRuleConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+		formatted, _ := formatGeneratedMarkdown("CLAUDE.md", []byte(claudeMD), rules)
+		claudeMD = string(formatted)
+	}
+	if existing, err := os.ReadFile(filepath.Join(dir, "CLAUDE.md")); err == nil {
+		if content, _, err := updateClaudeMD(string(existing), claudeMD); err == nil {
+			claudeMD = content
+		}
+	}
+	files := []previewFile{{Path: "CLAUDE.md", Content: claudeMD}}
+
+	settingsData, err := buildSettings(dir, cfg, registry)
+	if err != nil {
+		return nil, err
+	}
+	settingsJSON, _ := json.MarshalIndent(settingsData, "", "  ")
+	files = append(files, previewFile{Path: filepath.Join(".claude", "settings.json"), Content: string(settingsJSON)})
+	if projectServers, _ := mcpScopes(cfg); len(projectServers) > 0 {
+		files = append(files, previewFile{Path: ".mcp.json", Content: buildMCPJSON(projectServers)})
+	}
+
+	for _, hookName := range cfg.Hooks {
+		lang, err := resolveHookLanguage(hookName, registry.Get(TypeHook, hookName), cfg.HookLanguages)
+		if err != nil {
+			return nil, err
+		}
+		content, ok := hookScriptContent(hookName, lang, cfg.Languages, registry)
+		if !ok {
+			continue
+		}
+		hookPath := filepath.Join(hooksDir, hookScriptName(hookName, lang))
+		rel, err := filepath.Rel(dir, hookPath)
+		if err != nil {
+			rel = hookPath
+		}
+		files = append(files, previewFile{Path: rel, Content: string(executableContent(hookPath, content))})
+	}
+	return files, nil
+}
+
+// previewFenceLanguages maps file extensions to the code block language glamour
+// highlights them with.
+var previewFenceLanguages = map[string]string{
+	".md":   "markdown",
+	".json": "json",
+	".sh":   "bash",
+	".py":   "python",
+	".js":   "javascript",
+	".ps1":  "powershell",
+}
+
+// renderPreview lays files out as markdown, one fenced code block per file. Each fence
+// is longer than any backtick run in its file so embedded code blocks stay intact.
+func renderPreview(files []previewFile) string {
+	var b strings.Builder
+	b.WriteString("## 👀 Generated Files Preview\n\n")
+	b.WriteString("_Press **p** or **esc** to return to the summary, **s** to copy settings.json, or **m** to copy .mcp.json._\n\n")
+	for _, file := range files {
+		fence := "```"
+		for strings.Contains(file.Content, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&b, "### 📄 %s\n\n", file.Path)
+		fmt.Fprintf(&b, "%s%s\n%s\n%s\n\n", fence, previewFenceLanguages[filepath.Ext(file.Path)], strings.TrimRight(file.Content, "\n"), fence)
+	}
+	return b.String()
+}
+
+// renderFilePreview previews the files the current answers would generate.
+func (m *model) renderFilePreview() string {
+	dir, err := resolveTargetDir(m.config.IsProjectLocal)
+	if err != nil {
+		return fmt.Sprintf("## 👀 Generated Files Preview\n\n⚠️ %v\n", err)
+	}
+	files, err := previewFiles(*m.config, m.registry, dir)
+	if err != nil {
+		return fmt.Sprintf("## 👀 Generated Files Preview\n\n⚠️ %v\n", err)
+	}
+	return renderPreview(files)
+}
+
+
+// Helper function to clean emoji prefixes from form selections
+func cleanFormValue(value string) string {
+	// Remove emoji and space prefix (e.g., "🔍 code-reviewer" -> "code-reviewer")
+	parts := strings.SplitN(value, " ", 2)
+	if len(parts) == 2 {
+		return parts[1]
+	}
+	return value
+}
+
+func cleanFormValues(values []string) []string {
+	cleaned := make([]string, len(values))
+	for i, v := range values {
+		cleaned[i] = cleanFormValue(v)
+	}
+	return cleaned
+}
+
+// Gradient System Functions (T021-T027)
+
+// detectTerminalCapability detects terminal color support from env vars (T021)
+
+// interpolateColor performs RGB interpolation between two colors (T022)
+
+// interpolateGradient interpolates between two gradient themes (T023)
+
+// adjustSaturation adjusts the saturation of a hex color (Feature 006: T007)
+
+// increaseBrightness increases the brightness of a hex color (Feature 006: T008)
+
+// extendColorPaletteForMarkdown extends palette with markdown colors (Feature 006: T011)
+
+// generateGlamourStyle creates custom glamour renderer from palette (Feature 006: T012)
+
+// gradient.EaseInOutCubic applies cubic easing for smooth animations (T024)
+
+// quantizeStops reduces gradient stops for limited terminals (T025)
+
+// applyGradient creates a Lipgloss style with gradient (T026)
+
+// renderASCIITitle applies gradient to ASCII art line-by-line (T014)
+
+// renderGradient renders text with gradient colors applied (T027)
+
+// validateGradientTheme validates theme constraints (helper for tests)
+func validateGradientTheme(theme gradient.Theme) error {
+	if theme.Stops < 2 {
+		return fmt.Errorf("stops must be >= 2, got %d", theme.Stops)
+	}
+	if theme.Intensity < 0.0 || theme.Intensity > 1.0 {
+		return fmt.Errorf("intensity must be in [0.0, 1.0], got %f", theme.Intensity)
+	}
+	return nil
+}
+
+// Gradient palette definitions (T030)
+type gradientPalettesType struct {
+	primary    lipgloss.AdaptiveColor
+	secondary  lipgloss.AdaptiveColor
+	accent     lipgloss.AdaptiveColor
+	error      lipgloss.AdaptiveColor
+	success    lipgloss.AdaptiveColor
+	background lipgloss.AdaptiveColor
+
+	// Markdown-specific theme colors (Feature 006: T010)
+	markdownHeading  lipgloss.AdaptiveColor // Heading color (H1-H6)
+	markdownCode     lipgloss.AdaptiveColor // Code block/inline code
+	markdownEmphasis lipgloss.AdaptiveColor // Italic/bold emphasis
+	markdownLink     lipgloss.AdaptiveColor // Hyperlinks
+}
+
+var gradientPalettes = gradient.InitGradientPalettes()
+
+// initGradientPalettes initializes color palettes (T030)
+
+// initStyleMap populates component/state style mappings (T031)
+
+// ============================================================================
+// Feature 005: Asset File Generation Functions
+// ============================================================================
+
+// runGenerateAssetsCommand handles `claudekit module generate-assets [--output json] [--yes] [--resume]`.
+func runGenerateAssetsCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("module generate-assets")
+	output := outputFormatFlag(flags)
+	yes := flags.Bool("yes", false, "overwrite existing asset files without asking")
+	resume := flags.Bool("resume", false, "only generate the files the last run did not, as its report records")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+	if err := generateAllAssets(registry, *output, *yes, *resume); err != nil {
+		if err != errCancelled {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// assetReportFile is where --generate-assets keeps the report of its last run, which
+// --resume picks up from. It is relative to the repository root.
+const assetReportFile = ".claudekit/asset-report.json"
+
+// generateAllAssets generates all asset files from the module registry. Files that
+// fail with a transient error are tried again at once; with resume, only the files
+// the last run's report does not list as generated are. JSON output never prompts:
+// existing files are only overwritten with yes, and failures are not retried by
+// hand but reported, with a non-nil error.
+func generateAllAssets(registry *ModuleRegistry, output string, yes, resume bool) error {
+	// Get current directory (repository root)
+	repoRoot, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	assetsDir := filepath.Join(repoRoot, "assets")
+
+	// Collect all asset paths from all modules
+	var descriptors []generation.AssetFileDescriptor
+	for _, kind := range moduleAssetTypes {
+		for _, module := range registry.List(kind.module) {
+			for _, assetPath := range module.AssetPaths {
+				desc := generation.AssetFileDescriptor{
+					Name:           module.Name,
+					Type:           kind.asset,
+					Path:           assetPath,
+					SourceTemplate: assetPath,
+					Module:         module,
+				}
+				descriptors = append(descriptors, desc)
+			}
+		}
+	}
+
+	// The report is saved after every file, so a run that stops early can be resumed
+	reportPath := filepath.Join(repoRoot, assetReportFile)
+	saved := generation.GenerationReport{TotalFiles: len(descriptors)}
+	var saveErr error
+	if resume {
+		previous, err := loadAssetReport(reportPath, repoRoot)
+		if err != nil {
+			return fmt.Errorf("nothing to resume: %w", err)
+		}
+		descriptors = previous.Remaining(descriptors, assetsDir)
+		saved.TotalFiles = len(descriptors)
+		saved.MergeResumed(previous)
+		if len(descriptors) == 0 && output != outputJSON {
+			fmt.Printf("✅ Nothing to resume: the last run generated all %d asset files.\n", saved.TotalFiles)
+			return nil
+		}
+	}
+	generateFiles := func(progress func(generation.GenerationResult)) generation.GenerationReport {
+		report := generation.GenerateAssetFilesWithProgress(descriptors, assetsDir, func(result generation.GenerationResult) {
+			saved.Add(result)
+			if err := saveAssetReport(reportPath, &saved, repoRoot); err != nil && saveErr == nil {
+				saveErr = err
+				slog.Warn("cannot save the asset report --resume reads", "err", err)
+			}
+			if progress != nil {
+				progress(result)
+			}
+		})
+		return report
+	}
+
+	// Check for existing files
+	warning := generation.CheckExistingFiles(descriptors, assetsDir)
+	if output == outputJSON {
+		if len(warning.ExistingFiles) > 0 && !yes {
+			existing := make([]string, len(warning.ExistingFiles))
+			for i, file := range warning.ExistingFiles {
+				rel, _ := filepath.Rel(repoRoot, file)
+				existing[i] = filepath.ToSlash(rel)
+			}
+			if err := writeJSON(os.Stdout, struct {
+				ExistingFiles []string `json:"existing_files"`
+			}{existing}); err != nil {
+				return err
+			}
+			return &exitError{code: exitCancelled, err: fmt.Errorf("%d asset file(s) already exist; pass --yes to overwrite them", len(existing))}
+		}
+		generateFiles(nil)
+		if err := generation.WriteJSONReport(os.Stdout, &saved, repoRoot); err != nil {
+			return err
+		}
+		return assetFailures(saved.Failed, saved.Successful+saved.PlaceholdersGenerated+saved.Unchanged)
+	}
+	if len(warning.ExistingFiles) > 0 && !yes {
+		fmt.Printf("\n⚠️  WARNING: The following files will be overwritten:\n")
+		for _, file := range warning.ExistingFiles {
+			fmt.Printf("  - %s\n", file)
+		}
+		fmt.Printf("\nAny customizations will be lost.\n\n")
+		fmt.Printf("Continue? (y/n): ")
+
+		var response string
+		fmt.Scanln(&response)
+		if response != "y" && response != "Y" {
+			fmt.Println("\nℹ️  Generation cancelled. No files were modified.")
+			return errCancelled
+		}
+	}
+
+	// Generate all files
+	var report generation.GenerationReport
+	if err := withProgress("Generating asset files", len(descriptors), func(step func(string)) {
+		report = generateFiles(func(result generation.GenerationResult) {
+			relPath, _ := filepath.Rel(repoRoot, result.FilePath)
+			step(relPath)
+		})
+	}); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	// Display results; files that already held their content are only counted
+	for _, result := range report.Results {
+		if result.Status == generation.StatusSkippedUnchanged {
+			continue
+		}
+		status := "✅"
+		if result.Status == generation.StatusFailed {
+			status = "❌"
+		} else if result.Status == generation.StatusPlaceholderGenerated {
+			status = "ℹ️ "
+		}
+		relPath, _ := filepath.Rel(repoRoot, result.FilePath)
+		if result.Error != nil {
+			fmt.Printf("%s %s - %s\n", status, relPath, assetFailure(result))
+		} else {
+			fmt.Printf("%s %s\n", status, relPath)
+		}
+	}
+
+	// Summary
+	fmt.Printf("\n")
+	failed := report.Failed
+	if report.Failed > 0 {
+		fmt.Printf("⚠️  %d files failed to generate. %d files succeeded.\n", report.Failed, report.Successful+report.PlaceholdersGenerated)
+		printUnchangedAssets(report.Unchanged)
+
+		if report.ShouldPromptRetry() {
+			fmt.Printf("\nRetry failed files? (y/n): ")
+			var response string
+			fmt.Scanln(&response)
+			if response == "y" || response == "Y" {
+				fmt.Printf("\nRetrying failed files...\n")
+				retryReport := generation.RetryFailedGeneration(&report, assetsDir)
+
+				for _, result := range retryReport.Results {
+					status := "✅"
+					if result.Status == generation.StatusFailed {
+						status = "❌"
+					}
+					relPath, _ := filepath.Rel(repoRoot, result.FilePath)
+					if result.Error != nil {
+						fmt.Printf("%s %s - %s\n", status, relPath, assetFailure(result))
+					} else {
+						fmt.Printf("%s %s\n", status, relPath)
+					}
+				}
+
+				failed = retryReport.Failed
+				if retryReport.Failed == 0 {
+					fmt.Printf("\n✅ All files generated successfully.\n")
+				} else {
+					fmt.Printf("\n⚠️  %d files still failed.\n", retryReport.Failed)
+				}
+			}
+		}
+	} else {
+		fmt.Printf("✅ Generated %d asset files successfully.\n", report.Successful+report.PlaceholdersGenerated)
+		printUnchangedAssets(report.Unchanged)
+		if report.PlaceholdersGenerated > 0 {
+			fmt.Printf("ℹ️  %d placeholder files were generated. Please review and customize files marked with TODO.\n", report.PlaceholdersGenerated)
+		}
+	}
+
+	return assetFailures(failed, len(report.Results)-failed)
+}
+
+// assetFailures returns the error asset generation ends with when failed files
+// failed and generated files did not: nil when none failed, and a partial failure
+// when others were generated.
+func assetFailures(failed, generated int) error {
+	if failed == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%d asset file(s) failed to generate", failed)
+	if generated > 0 {
+		return partialError(err)
+	}
+	return err
+}
+
+// printUnchangedAssets reports the asset files that were not rewritten because
+// they already held the generated content.
+func printUnchangedAssets(unchanged int) {
+	if unchanged > 0 {
+		fmt.Printf("⏭️  %d files were unchanged and left as they are.\n", unchanged)
+	}
+}
+
+// moduleAssetTypes are the module types whose asset files --generate-assets
+// writes, in order, and the generation.AssetType each file is generated as.
+var moduleAssetTypes = []struct {
+	module ModuleComponentType
+	asset  generation.AssetType
+}{
+	{TypeSubagent, generation.AssetTypeSubagent},
+	{TypeHook, generation.AssetTypeHook},
+	{TypeCommand, generation.AssetTypeSlashCommand},
+}
+
+// assetFailure describes why a file failed to generate and whether trying again
+// later may help.
+func assetFailure(result generation.GenerationResult) string {
+	if result.Retryable {
+		return fmt.Sprintf("%v (still failing after %d tries; run --generate-assets --resume later)", result.Error, result.Attempts)
+	}
+	return fmt.Sprintf("%v (permanent)", result.Error)
+}
+
+// loadAssetReport reads the report a --generate-assets run saved at path.
+func loadAssetReport(path, repoRoot string) (*generation.GenerationReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return generation.ReadJSONReport(f, repoRoot)
+}
+
+// saveAssetReport writes report to path for --resume, with file paths relative to
+// repoRoot.
+func saveAssetReport(path string, report *generation.GenerationReport, repoRoot string) error {
+	var b bytes.Buffer
+	if err := generation.WriteJSONReport(&b, report, repoRoot); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// ============================================================================
+// Progress: a spinner and gradient progress bar for long-running commands
+// ============================================================================
+
+// minProgressSteps is the fewest steps worth drawing a progress bar for.
+const minProgressSteps = 5
+
+// progressDoneMsg tells progressModel the work has finished.
+type progressDoneMsg struct{}
+
+// progressModel draws a spinner beside a title, and a progress bar below, until
+// the work is done; it then clears them, leaving the terminal to the results.
+type progressModel struct {
+	title   string
+	spinner gradient.Spinner
+	bar     gradient.ProgressBar
+	done    bool
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return m.spinner.Tick()
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case progressDoneMsg:
+		m.done = true
+		return m, tea.Quit
+	case gradient.ProgressMsg:
+		m.bar, cmd = m.bar.Update(msg)
+	case gradient.SpinnerTickMsg:
+		m.spinner, cmd = m.spinner.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m progressModel) View() string {
+	if m.done {
+		return ""
+	}
+	return fmt.Sprintf("%s %s\n%s\n", m.spinner.View(), m.title, m.bar.View())
+}
+
+// withProgress runs work, which calls step with the name of each of its total steps
+// as it finishes it, drawing a progress bar on stdout meanwhile. Without a terminal,
+// or for only a few steps, work just runs. work must not write to stdout. Ctrl+C
+// returns errCancelled at once, leaving work to be stopped by claudekit exiting.
+func withProgress(title string, total int, work func(step func(string))) error {
+	if total < minProgressSteps || !isTerminal(os.Stdout) {
+		work(func(string) {})
+		return nil
+	}
+	capability := gradient.DetectTerminalCapability()
+	theme := progressTheme()
+	p := tea.NewProgram(progressModel{
+		title:   title,
+		spinner: gradient.NewSpinner(theme, capability),
+		bar:     gradient.NewProgressBar(theme, capability, total),
+	}, tea.WithInput(nil))
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		done := 0
+		work(func(label string) {
+			done++
+			p.Send(gradient.ProgressMsg{Done: done, Label: label})
+		})
+		p.Send(progressDoneMsg{})
+	}()
+	if _, err := p.Run(); errors.Is(err, tea.ErrInterrupted) {
+		// Ctrl+C stops the command, as it does without the progress bar
+		return errCancelled
+	} else if err != nil {
+		slog.Debug("progress display failed", "err", err)
+	}
+	<-finished
+	return nil
+}
+
+// progressTheme returns the header gradient of the theme CLAUDEKIT_THEME names, or
+// of the default theme. Saved choices are not read, since that may pull a dotfiles
+// repository.
+func progressTheme() gradient.Theme {
+	palette, err := gradient.PaletteByName(resolveTheme("", os.Getenv(envTheme), ""))
+	if err != nil {
+		palette = gradient.InitGradientPalettes()
+	}
+	return gradient.StyleMapForPalette(palette)[gradient.HeaderComponent][gradient.NormalState].Theme
+}
+
+// ============================================================================
+// Generation screen: write the configuration without leaving the TUI
+// ============================================================================
+
+// writeStatus is what became of a file run generated.
+type writeStatus int
+
+const (
+	writeCreated writeStatus = iota
+	writeUpdated
+	writeMerged // Written around the user's own content
+	writeKept   // Left alone to keep the user's edits
+)
+
+// writeStatusNames and writeStatusIcons show a writeStatus on the generation screen.
+var (
+	writeStatusNames = [...]string{writeCreated: "created", writeUpdated: "updated", writeMerged: "merged", writeKept: "kept"}
+	writeStatusIcons = [...]string{writeCreated: "✨", writeUpdated: "✅", writeMerged: "🔀", writeKept: "✋"}
+)
+
+// generationCallbacks let the generation screen follow a run: report is told what
+// became of every file, as it is written, and resolve settles the files the user
+// edited since the last run. A run without them reports nothing and asks on the
+// terminal.
+type generationCallbacks struct {
+	report  func(path string, status writeStatus)
+	resolve conflictResolver
+}
+
+// generatedFileMsg reports a file generation wrote or kept.
+type generatedFileMsg struct {
+	path   string
+	status writeStatus
+}
+
+// generationOutputMsg is a line generation printed.
+type generationOutputMsg struct {
+	line   string
+	stderr bool
+}
+
+// conflictMsg asks the generation screen what to do with a file the user edited
+// since the last run. Generation waits for the answer on reply.
+type conflictMsg struct {
+	rel                 string
+	existing, generated []byte
+	reply               chan<- conflictAction
+}
+
+// generationDoneMsg reports that generation finished with an exit code.
+type generationDoneMsg struct {
+	code int
+}
+
+// generationState is the generation screen's record of a run; shared by copies of
+// the model.
+type generationState struct {
+	events  chan tea.Msg
+	baseDir string // Files are listed relative to it
+	files   []generatedFileMsg
+	output  []generationOutputMsg
+	counts  [len(writeStatusNames)]int
+
+	conflict *conflictMsg // Waiting for an answer
+	showDiff bool
+
+	done bool
+	code int
+}
+
+// next waits for the next message from the generation goroutine.
+func (g *generationState) next() tea.Cmd {
+	return func() tea.Msg {
+		return <-g.events
+	}
+}
+
+// startGeneration runs m.generate in the background with its files, output, and
+// edit conflicts sent to the generation screen.
+func (m model) startGeneration() (model, tea.Cmd) {
+	g := &generationState{events: make(chan tea.Msg)}
+	if m.config != nil {
+		g.baseDir, _ = resolveTargetDir(m.config.IsProjectLocal)
+	}
+	m.generating = g
+
+	generate := m.generate
+	callbacks := generationCallbacks{
+		report: func(path string, status writeStatus) {
+			g.events <- generatedFileMsg{path: path, status: status}
+		},
+		resolve: func(rel string, existing, generated []byte) conflictAction {
+			reply := make(chan conflictAction)
+			g.events <- conflictMsg{rel: rel, existing: existing, generated: generated, reply: reply}
+			return <-reply
+		},
+	}
+	go func() {
+		restore := captureOutput(g.events)
+		code := generate(callbacks)
+		restore()
+		g.events <- generationDoneMsg{code: code}
+	}()
+	return m, g.next()
+}
+
+// captureOutput points os.Stdout and os.Stderr at pipes, sending each line printed
+// to events, so generation's messages and warnings logged through logging.Stderr
+// do not draw over the TUI. The returned function restores them once every line
+// has been sent.
+func captureOutput(events chan<- tea.Msg) (restore func()) {
+	stdout, stderr := os.Stdout, os.Stderr
+	var pipes []*os.File
+	var wg sync.WaitGroup
+	capture := func(target **os.File, isStderr bool) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return // The line lands on the screen; better than losing it
+		}
+		*target = w
+		pipes = append(pipes, w)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.Close()
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				events <- generationOutputMsg{line: scanner.Text(), stderr: isStderr}
+			}
+		}()
+	}
+	capture(&os.Stdout, false)
+	capture(&os.Stderr, true)
+
+	return func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		for _, w := range pipes {
+			w.Close()
+		}
+		wg.Wait()
+	}
+}
+
+// updateGeneration handles a message once the form is complete. Ctrl+C is ignored
+// while generation runs, so it never stops halfway; once it is done any key quits.
+func (m model) updateGeneration(msg tea.Msg) (tea.Model, tea.Cmd) {
+	g := m.generating
+	switch msg := msg.(type) {
+	case generatedFileMsg:
+		g.files = append(g.files, msg)
+		g.counts[msg.status]++
+		return m, g.next()
+	case generationOutputMsg:
+		g.output = append(g.output, msg)
+		return m, g.next()
+	case conflictMsg:
+		g.conflict, g.showDiff = &msg, false
+		return m, g.next()
+	case generationDoneMsg:
+		g.done, g.code = true, msg.code
+		return m, nil
+	case tea.KeyMsg:
+		if g.done {
+			return m, tea.Quit
+		}
+		if g.conflict == nil {
+			return m, nil
+		}
+		switch msg.String() {
+		case "o":
+			g.conflict.reply <- conflictOverwrite
+			g.conflict = nil
+		case "s", "enter":
+			g.conflict.reply <- conflictSkip
+			g.conflict = nil
+		case "d":
+			g.showDiff = !g.showDiff
+		}
+	}
+	return m, nil
+}
+
+// renderGeneration draws the generation screen in height rows: the files written so
+// far, the latest last, then an edit conflict waiting for an answer, or once
+// generation is done what it printed and how to leave.
+func (m model) renderGeneration(height int) string {
+	g := m.generating
+	var header string
+	switch {
+	case !g.done:
+		header = "⏳ Generating your Claude Code configuration…"
+	case g.code != 0:
+		header = "❌ Generation failed"
+	default:
+		header = "✅ Configuration generated"
+	}
+	var counts []string
+	for status, n := range g.counts {
+		if n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, writeStatusNames[status]))
+		}
+	}
+	if len(counts) > 0 {
+		header += "  " + lipgloss.NewStyle().Faint(true).Render(strings.Join(counts, " · "))
+	}
+
+	var footer []string
+	switch {
+	case g.conflict != nil:
+		footer = append(footer, "", fmt.Sprintf("⚠️  %s was modified since claudekit generated it.", g.conflict.rel),
+			"   [o]verwrite, [s]kip, or show [d]iff? [s]")
+		if g.showDiff {
+			diff := util.LineDiff(g.conflict.rel+" (yours)", g.conflict.rel+" (generated)", g.conflict.existing, g.conflict.generated)
+			footer = append(footer, strings.Split(strings.TrimRight(diff, "\n"), "\n")...)
+		}
+	case g.done:
+		for _, out := range g.output {
+			if strings.TrimSpace(out.line) != "" || len(footer) > 0 {
+				footer = append(footer, out.line)
+			}
+		}
+		footer = append(footer, "", lipgloss.NewStyle().Faint(true).Render("Press any key to exit."))
+	}
+
+	// The file list gives way to the footer, keeping its latest entries
+	rows := max(height-2-len(footer), 1)
+	files := g.files
+	var lines []string
+	if len(files) > rows {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("… %d more", len(files)-rows+1)))
+		files = files[len(files)-rows+1:]
+	}
+	for _, f := range files {
+		name := f.path
+		if rel, err := filepath.Rel(g.baseDir, f.path); err == nil && g.baseDir != "" && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		lines = append(lines, fmt.Sprintf("%s %-7s  %s", writeStatusIcons[f.status], writeStatusNames[f.status], filepath.ToSlash(name)))
+	}
+	return strings.Join(append(append([]string{header, ""}, lines...), footer...), "\n")
+}
+
+// replay prints what generation printed once the TUI's screen is gone, so it stays
+// in the terminal, and returns generation's exit code.
+func (g *generationState) replay() int {
+	for _, out := range g.output {
+		if out.stderr {
+			fmt.Fprintln(os.Stderr, out.line)
+		} else {
+			fmt.Println(out.line)
+		}
+	}
+	return g.code
+}
+
+// ============================================================================
+// Fmt: format markdown files with the GFM rules
+// ============================================================================
+
+// stringListFlag collects the values of a flag that may be repeated.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// outputSARIF is accepted by --output on `claudekit fmt` only.
+const outputSARIF = "sarif"
+
+// runFmtCommand handles `claudekit fmt [path] [--dry-run] [--check] [--exclude pattern]`.
+// Flags may come before or after the path, which defaults to the current directory.
+func runFmtCommand(args []string) int {
+	flags := newCommandFlags("fmt")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing files")
+	check := flags.Bool("check", false, "like --dry-run, but exit non-zero when any file needs formatting")
+	lint := flags.Bool("lint", false, "report each rule violation with its line and column, without writing files; exit non-zero if any")
+	staged := flags.Bool("staged", false, "check the staged content of the markdown files staged in git, as --check does")
+	installHook := flags.Bool("install-git-hook", false, "install a git pre-commit hook that runs fmt --staged")
+	var excludes stringListFlag
+	flags.Var(&excludes, "exclude", "skip paths starting with `pattern` (repeatable)")
+	output := flags.String("output", outputText, "report `format`: text, json, or sarif")
+
+	var paths []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return exitUsage
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		paths = append(paths, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(paths) > 1 || (*staged && len(paths) > 0) {
+		fmt.Fprintln(flags.Output(), "usage: claudekit fmt [path] [--dry-run] [--check] [--lint] [--exclude pattern]")
+		fmt.Fprintln(flags.Output(), "       claudekit fmt --staged [--lint] [--exclude pattern]")
+		fmt.Fprintln(flags.Output(), "       claudekit fmt --install-git-hook")
+		return exitUsage
+	}
+	if *output != outputSARIF && !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+	if *installHook {
+		if err := installFmtGitHook("."); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	root := "."
+	if len(paths) == 1 {
+		root = paths[0]
+	}
+
+	// The rule config lives beside the files being formatted
+	configDir := root
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		configDir = filepath.Dir(root)
+	}
+	var rules formatting.RuleConfig
+	var stagedFiles []formatting.MarkdownFile
+	var err error
+	if *staged {
+		*check = !*lint
+		stagedFiles, rules, err = stagedMarkdownFiles(".", excludes)
+	} else {
+		rules, err = formatting.LoadRuleConfig(configDir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	cfg := formatting.FormatConfig{
+		RootDir:         root,
+		ExcludePatterns: excludes,
+		DryRun:          *dryRun || *check || *lint,
+		Standard:        "GFM",
+		Rules:           rules,
+	}
+	var report *formatting.FormatReport
+	if *staged {
+		report, err = formatMarkdownFiles(stagedFiles, cfg, time.Now())
+	} else {
+		report, err = formatMarkdown(cfg)
+	}
+	if err != nil {
+		if err != errCancelled {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		return exitCode(err)
+	}
+
+	switch {
+	case *output == outputJSON:
+		err = formatting.WriteJSONReport(os.Stdout, report)
+	case *output == outputSARIF && *lint:
+		err = formatting.WriteSARIFLintReport(os.Stdout, report)
+	case *output == outputSARIF:
+		err = formatting.WriteSARIFReport(os.Stdout, report)
+	case *lint:
+		if err = formatting.WriteViolations(os.Stdout, report); err == nil {
+			fmt.Print(renderLintSummary(report))
+		}
+	default:
+		fmt.Print(renderFmtReport(report, cfg.DryRun))
+		err = formatting.WriteSummary(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	if *staged && report.FilesModified > 0 && *output == outputText && !*lint {
+		fmt.Println("Run claudekit fmt on these files and stage the result, or commit with --no-verify to skip the check.")
+	}
+	if report.FilesErrored > 0 || (*check && report.FilesModified > 0) || (*lint && report.TotalViolations > 0) {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// formatMarkdown formats every markdown file under cfg.RootDir, or the single file it
+// names, and aggregates the results.
+func formatMarkdown(cfg formatting.FormatConfig) (*formatting.FormatReport, error) {
+	start := time.Now()
+
+	var files []formatting.MarkdownFile
+	if info, err := os.Stat(cfg.RootDir); err == nil && !info.IsDir() {
+		files = []formatting.MarkdownFile{{Path: cfg.RootDir, RelPath: cfg.RootDir, Size: info.Size()}}
+	} else {
+		var err error
+		if files, err = formatting.ScanMarkdownFiles(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return formatMarkdownFiles(files, cfg, start)
+}
+
+// formatMarkdownFiles formats files, which may carry their content already, and
+// aggregates the results. It only fails when interrupted.
+func formatMarkdownFiles(files []formatting.MarkdownFile, cfg formatting.FormatConfig, start time.Time) (*formatting.FormatReport, error) {
+	report := formatting.NewFormatReport()
+	if err := withProgress("Formatting markdown", len(files), func(step func(string)) {
+		for i := range files {
+			// Errors are recorded on the result; one bad file does not stop the rest
+			result, _ := formatting.FormatMarkdownFile(&files[i], cfg)
+			report.Add(result)
+			step(files[i].RelPath)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// stagedMarkdownFiles returns the markdown files staged in the git repository holding
+// dir, loaded with their staged content: a partially staged file is checked as it
+// will be committed, not as it is on disk. The rule config is the staged one at the
+// repository root, or the one on disk when it is not tracked.
+func stagedMarkdownFiles(dir string, excludes []string) ([]formatting.MarkdownFile, formatting.RuleConfig, error) {
+	git := func(args ...string) ([]byte, error) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return out, err
+	}
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, formatting.RuleConfig{}, err
+	}
+	root := strings.TrimSpace(string(top))
+
+	var rules formatting.RuleConfig
+	if data, err := git("cat-file", "blob", ":"+formatting.ConfigFileName); err == nil {
+		if rules, err = formatting.ParseRuleConfig(data); err != nil {
+			return nil, rules, fmt.Errorf("%s (staged): %w", formatting.ConfigFileName, err)
+		}
+	} else if rules, err = formatting.LoadRuleConfig(root); err != nil {
+		return nil, rules, err
+	}
+
+	names, err := git("diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, rules, err
+	}
+	var files []formatting.MarkdownFile
+	for _, name := range strings.Split(strings.TrimSuffix(string(names), "\x00"), "\x00") {
+		if path.Ext(name) != ".md" || slices.ContainsFunc(excludes, func(pattern string) bool {
+			return strings.HasPrefix(name, strings.TrimSuffix(filepath.ToSlash(pattern), "/"))
+		}) {
+			continue
+		}
+		content, err := git("cat-file", "blob", ":"+name)
+		if err != nil {
+			return nil, rules, err
+		}
+		files = append(files, formatting.MarkdownFile{
+			Path:    filepath.Join(root, filepath.FromSlash(name)),
+			RelPath: name,
+			Size:    int64(len(content)),
+			Content: content,
+		})
+	}
+	return files, rules, nil
+}
+
+// fmtHookMarker identifies a pre-commit hook installFmtGitHook wrote.
+const fmtHookMarker = "# Installed by claudekit fmt --install-git-hook"
+
+// installFmtGitHook installs a pre-commit hook running `claudekit fmt --staged` in
+// the git repository holding dir. A repository managed by the pre-commit framework
+// gets the entry to add to .pre-commit-config.yaml instead, and a pre-commit hook
+// claudekit did not write is left alone.
+func installFmtGitHook(dir string) error {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel", "--git-path", "hooks/pre-commit").Output()
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if err != nil || len(lines) != 2 {
+		return fmt.Errorf("%s is not in a git repository", dir)
+	}
+	root, hookPath := lines[0], lines[1]
+	if !filepath.IsAbs(hookPath) {
+		hookPath = filepath.Join(dir, hookPath)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, ".pre-commit-config.yaml")); err == nil {
+		fmt.Print(`This repository uses the pre-commit framework. Add this to .pre-commit-config.yaml:
+
+  - repo: local
+    hooks:
+      - id: claudekit-fmt
+        name: claudekit fmt
+        entry: claudekit fmt --staged
+        language: system
+        files: \.md$
+        pass_filenames: false
+`)
+		return nil
+	}
+
+	if existing, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(existing), fmtHookMarker) {
+		return fmt.Errorf("%s already exists; add `claudekit fmt --staged` to it", hookPath)
+	}
+	executable := "claudekit"
+	if self, err := os.Executable(); err == nil {
+		executable = self
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+%s
+# Checks the formatting of the markdown being committed. Partially staged files are
+# checked as staged. Skip the check once with git commit --no-verify.
+claudekit=%s
+if [ ! -x "$claudekit" ]; then
+  claudekit=claudekit
+fi
+exec "$claudekit" fmt --staged
+`, fmtHookMarker, shellQuote(executable))
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(hookPath, []byte(script), 0o755); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Installed %s\n", hookPath)
+	return nil
+}
+
+// renderFmtReport lists the files fmt changed, or would change, with the rules applied.
+func renderFmtReport(report *formatting.FormatReport, dryRun bool) string {
+	verb := "Formatted"
+	if dryRun {
+		verb = "Would format"
+	}
+
+	var b strings.Builder
+	for _, res := range report.Results {
+		switch res.Status {
+		case formatting.StatusModified:
+			fmt.Fprintf(&b, "✏️  %s %s%s\n", verb, res.File.RelPath, formatRuleCounts(res.RulesApplied))
+		case formatting.StatusError:
+			fmt.Fprintf(&b, "❌ %s: %v\n", res.File.RelPath, res.Error)
+		}
+	}
+	return b.String()
+}
+
+// formatRuleCounts lists the rules the formatter applied to a file with their fix
+// counts, as " (rule ×n, ...)", or "" when there are none.
+func formatRuleCounts(applied []formatting.FormattingRule) string {
+	if len(applied) == 0 {
+		return ""
+	}
+	rules := make([]string, len(applied))
+	for i, rule := range applied {
+		rules[i] = fmt.Sprintf("%s ×%d", rule.Name, rule.FixCount)
+	}
+	return " (" + strings.Join(rules, ", ") + ")"
+}
+
+// loadFormatRules reads the formatter rules of the project in dir from files, as
+// formatting.LoadRuleConfig does from disk.
+func loadFormatRules(files fsys.FS, dir string) (formatting.RuleConfig, error) {
+	path := filepath.Join(dir, formatting.ConfigFileName)
+	data, err := files.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return formatting.RuleConfig{}, nil
+	} else if err != nil {
+		return formatting.RuleConfig{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	rules, err := formatting.ParseRuleConfig(data)
+	if err != nil {
+		return rules, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// formatGeneratedMarkdown formats generated markdown as `claudekit fmt` would with
+// rules. Content the formatter cannot parse is returned as it is, with the error on
+// the result.
+func formatGeneratedMarkdown(rel string, content []byte, rules formatting.RuleConfig) ([]byte, *formatting.FormatResult) {
+	file := formatting.MarkdownFile{Path: rel, RelPath: rel, Size: int64(len(content)), Content: content}
+	result, _ := formatting.FormatMarkdownFile(&file, formatting.FormatConfig{DryRun: true, Standard: "GFM", Rules: rules})
+	if result.Status != formatting.StatusModified {
+		return content, result
+	}
+	return file.FormattedContent, result
+}
+
+// renderLintSummary lists the files lint could not read and counts the violations.
+func renderLintSummary(report *formatting.FormatReport) string {
+	var b strings.Builder
+	files := 0
+	for _, res := range report.Results {
+		if res.Status == formatting.StatusError {
+			fmt.Fprintf(&b, "❌ %s: %v\n", res.File.RelPath, res.Error)
+		}
+		if len(res.Violations) > 0 {
+			files++
+		}
+	}
+	fmt.Fprintf(&b, "%d violation(s) in %d file(s)\n", report.TotalViolations, files)
+	return b.String()
+}
+
+// ============================================================================
+// Command output: prose for people, JSON for scripts
+// ============================================================================
+
+// Values accepted by --output on non-interactive commands.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// outputFormatFlag registers --output on a command's flag set.
+func outputFormatFlag(flags *flag.FlagSet) *string {
+	return flags.String("output", outputText, "report `format`: text or json")
+}
+
+// validOutputFormat reports whether format is a known --output value, explaining
+// the problem on the flag set's output when it is not.
+func validOutputFormat(flags *flag.FlagSet, format string) bool {
+	if format == outputText || format == outputJSON {
+		return true
+	}
+	fmt.Fprintf(flags.Output(), "invalid --output: %q (want text or json)\n", format)
+	return false
+}
+
+// writeJSON writes v as indented JSON followed by a newline.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// nonNil returns s, or an empty slice when s is nil, so JSON reports show [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// ============================================================================
+// Doctor: validate an existing Claude Code configuration
+// ============================================================================
+
+// doctorStatus is the outcome of a single doctor check.
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+// MarshalText names the status in JSON reports.
+func (s doctorStatus) MarshalText() ([]byte, error) {
+	switch s {
+	case doctorOK:
+		return []byte("ok"), nil
+	case doctorWarn:
+		return []byte("warn"), nil
+	case doctorFail:
+		return []byte("fail"), nil
+	}
+	return nil, fmt.Errorf("unknown doctor status %d", int(s))
+}
+
+// doctorCheck is one line of the doctor report.
+type doctorCheck struct {
+	Name   string       `json:"name"`
+	Status doctorStatus `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Fix    string       `json:"fix,omitempty"`
+}
+
+// knownHookEvents lists the hook events accepted by Claude Code's settings schema.
+var knownHookEvents = schema.HookEvents
+
+// mcpEnvVarPattern matches ${VAR} and ${VAR:-default} references in .mcp.json.
+var mcpEnvVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+var (
+	doctorOKStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#00B894", Dark: "#55EFC4"})
+	doctorWarnStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#E17055", Dark: "#FDCB6E"})
+	doctorFailStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#D63031", Dark: "#FF7675"})
+	doctorFixStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+)
+
+// runDoctorCommand implements `claudekit doctor [--global]` and returns the exit code.
+func runDoctorCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("doctor")
+	global := flags.Bool("global", false, "inspect the global configuration in ~/.claude instead of the current project")
+	probe := flags.Bool("probe", false, "also connect to each HTTP and SSE MCP server to check that it is reachable")
+	output := outputFormatFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+
+	baseDir, err := resolveTargetDir(!*global)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	checks := runDoctor(baseDir, registry)
+	if *probe {
+		checks = append(checks, probeMCPServers(filepath.Join(baseDir, ".mcp.json"))...)
+	}
+	failed := slices.ContainsFunc(checks, func(c doctorCheck) bool { return c.Status == doctorFail })
+	if *output == outputJSON {
+		if err := writeJSON(os.Stdout, struct {
+			BaseDir string        `json:"base_dir"`
+			OK      bool          `json:"ok"`
+			Checks  []doctorCheck `json:"checks"`
+		}{baseDir, !failed, checks}); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+	} else {
+		fmt.Print(renderDoctorReport(baseDir, checks))
+	}
+
+	if failed {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// runDoctor inspects baseDir/.claude and baseDir/.mcp.json and returns all check results.
+func runDoctor(baseDir string, registry *ModuleRegistry) []doctorCheck {
+	var checks []doctorCheck
+	claudeDir := filepath.Join(baseDir, ".claude")
+
+	if info, err := os.Stat(claudeDir); err != nil || !info.IsDir() {
+		return []doctorCheck{{
+			Name:   ".claude directory",
+			Status: doctorFail,
+			Detail: fmt.Sprintf("%s not found", claudeDir),
+			Fix:    "run claudekit to generate a configuration",
+		}}
+	}
+
+	checks = append(checks, doctorCheckSettings(baseDir)...)
+	layout := layoutFor(baseDir, manifest.Layout{})
+	checks = append(checks, doctorCheckAgents(manifest.Dir(baseDir, layout.Agents))...)
+	checks = append(checks, doctorCheckMCP(filepath.Join(baseDir, ".mcp.json"))...)
+	checks = append(checks, doctorCheckClaudeCLI())
+	checks = append(checks, doctorCheckRequirements(baseDir, registry)...)
+	checks = append(checks, doctorCheckUpgrades(baseDir, registry)...)
+
+	return checks
+}
+
+// doctorCheckSettings validates settings.json against the hooks schema and verifies hook scripts.
+func doctorCheckSettings(baseDir string) []doctorCheck {
+	path := filepath.Join(baseDir, ".claude", "settings.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []doctorCheck{{
+			Name:   "settings.json",
+			Status: doctorWarn,
+			Detail: "no settings.json found",
+			Fix:    "run claudekit to generate permissions and hooks",
+		}}
+	}
+
+	if !json.Valid(data) {
+		return []doctorCheck{{
+			Name:   "settings.json",
+			Status: doctorFail,
+			Detail: schema.Settings(path, data)[0].Message,
+			Fix:    "fix the syntax error or regenerate with claudekit",
+		}}
+	}
+
+	var checks []doctorCheck
+	if errs := schema.Settings(path, data); len(errs) > 0 {
+		checks = append(checks, schemaCheck("settings.json schema", errs))
+	}
+	// A value of the wrong type leaves nothing reliable to check the hooks against
+	var st settings
+	if err := json.Unmarshal(data, &st); err != nil {
+		return checks
+	}
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{Name: "settings.json schema", Status: doctorOK, Detail: fmt.Sprintf("%d hook event(s) configured", len(st.Hooks))})
+	}
+
+	issues := verifyHookCommands(fsys.OS{}, baseDir, st)
+	if isGlobalBaseDir(baseDir) {
+		issues = append(verifyGlobalHookCommands(st), issues...)
+	}
+	if len(issues) == 0 {
+		checks = append(checks, doctorCheck{Name: "hook scripts", Status: doctorOK, Detail: "all hook scripts exist and are executable"})
+	}
+	for _, issue := range issues {
+		checks = append(checks, doctorCheck{
+			Name:   "hook " + issue.Event,
+			Status: doctorFail,
+			Detail: fmt.Sprintf("%s: %s", issue.Path, issue.Problem),
+			Fix:    issue.Fix,
+		})
+	}
+
+	if st.StatusLine != nil {
+		if path := resolveHookCommandPath(baseDir, st.StatusLine.Command); path != "" {
+			if issue, ok := checkHookScript(fsys.OS{}, path); !ok {
+				checks = append(checks, doctorCheck{Name: "statusline", Status: doctorFail, Detail: fmt.Sprintf("%s: %s", path, issue.Problem), Fix: "re-run claudekit with a statusline selected, or remove statusLine from .claude/settings.json"})
+			} else {
+				checks = append(checks, doctorCheck{Name: "statusline", Status: doctorOK, Detail: "statusline script exists and is executable"})
+			}
+		}
+	}
+
+	return checks
+}
+
+// schemaCheck reports schema errors as one failed check, listing each value's path
+// and problem, then the distinct fixes.
+func schemaCheck(name string, errs []schema.Error) doctorCheck {
+	var details, fixes []string
+	for _, err := range errs {
+		details = append(details, err.Path+": "+err.Message)
+		if err.Fix != "" && !slices.Contains(fixes, err.Fix) {
+			fixes = append(fixes, err.Fix)
+		}
+	}
+	return doctorCheck{Name: name, Status: doctorFail, Detail: strings.Join(details, "; "), Fix: strings.Join(fixes, "; ")}
+}
+
+// doctorCheckAgents validates the frontmatter of every agent markdown file.
+func doctorCheckAgents(agentsDir string) []doctorCheck {
+	entries, err := os.ReadDir(agentsDir)
+	if err != nil {
+		return []doctorCheck{{Name: "agents", Status: doctorOK, Detail: "no agents directory"}}
+	}
+
+	var checks []doctorCheck
+	valid := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		if err := generation.ValidateAgentMarkdown(filepath.Join(agentsDir, entry.Name())); err != nil {
+			checks = append(checks, doctorCheck{
+				Name:   "agent " + strings.TrimSuffix(entry.Name(), ".md"),
+				Status: doctorFail,
+				Detail: err.Error(),
+				Fix:    "add name, description, and tools to the YAML frontmatter",
+			})
+			continue
+		}
+		valid++
+	}
+
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{Name: "agents", Status: doctorOK, Detail: fmt.Sprintf("%d agent(s) with valid frontmatter", valid)})
+	}
+	return checks
+}
+
+// doctorCheckMCP verifies .mcp.json parses and that every referenced environment variable is set.
+func doctorCheckMCP(path string) []doctorCheck {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []doctorCheck{{Name: ".mcp.json", Status: doctorOK, Detail: "no MCP servers configured"}}
+	}
+
+	var root struct {
+		MCPServers map[string]json.RawMessage `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return []doctorCheck{{
+			Name:   ".mcp.json",
+			Status: doctorFail,
+			Detail: fmt.Sprintf("invalid JSON: %v", err),
+			Fix:    "fix the syntax error or regenerate with claudekit",
+		}}
+	}
+
+	var checks []doctorCheck
+	if errs := schema.MCP(path, data); len(errs) > 0 {
+		checks = append(checks, schemaCheck(".mcp.json schema", errs))
+	}
+
+	names := make([]string, 0, len(root.MCPServers))
+	for name := range root.MCPServers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		var problems []string
+		var fix string
+		var server mcpServer
+		if json.Unmarshal(root.MCPServers[name], &server) == nil && server.Command != "" {
+			if _, err := exec.LookPath(server.Command); err != nil {
+				problems = append(problems, fmt.Sprintf("command %s not found on PATH", server.Command))
+				fix = mcpCommandInstallHint(server.Command)
+			}
+		}
+		if _, missing := expandMCPEnv(string(root.MCPServers[name])); len(missing) > 0 {
+			problems = append(problems, "missing environment variables: "+strings.Join(missing, ", "))
+			if fix == "" {
+				fix = fmt.Sprintf("export %s=... in your shell profile", missing[0])
+			}
+		}
+		if len(problems) > 0 {
+			checks = append(checks, doctorCheck{
+				Name:   "mcp " + name,
+				Status: doctorWarn,
+				Detail: strings.Join(problems, "; "),
+				Fix:    fix,
+			})
+		} else {
+			checks = append(checks, doctorCheck{Name: "mcp " + name, Status: doctorOK, Detail: "environment configured"})
+		}
+	}
+	return checks
+}
+
+// expandMCPEnv expands ${VAR} and ${VAR:-default} the way Claude Code does in
+// .mcp.json, and returns the variables that are unset and have no default.
+func expandMCPEnv(s string) (string, []string) {
+	var missing []string
+	expanded := mcpEnvVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		match := mcpEnvVarPattern.FindStringSubmatch(ref)
+		if value, ok := os.LookupEnv(match[1]); ok {
+			return value
+		}
+		if match[2] != "" {
+			return strings.TrimPrefix(match[2], ":-")
+		}
+		if !slices.Contains(missing, match[1]) {
+			missing = append(missing, match[1])
+		}
+		return ref
+	})
+	return expanded, missing
+}
+
+// mcpCommandInstallHint says how to install the launcher of a stdio MCP server.
+func mcpCommandInstallHint(command string) string {
+	switch filepath.Base(command) {
+	case "npx", "node", "npm":
+		return "install Node.js, which provides npx: https://nodejs.org"
+	case "uvx", "uv":
+		return "install uv, which provides uvx: curl -LsSf https://astral.sh/uv/install.sh | sh"
+	case "docker":
+		return "install Docker: https://docs.docker.com/get-docker/"
+	}
+	return fmt.Sprintf("install %s or put it on PATH", command)
+}
+
+// mcpProbeTimeout bounds each connection attempt of probeMCPServers.
+const mcpProbeTimeout = 5 * time.Second
+
+// probeMCPServers connects to each HTTP and SSE server in the .mcp.json at path.
+// Any HTTP response below 500 counts as reachable: servers commonly answer a bare
+// GET with 401 or 405. Servers whose URL needs an unset variable are skipped, as
+// doctorCheckMCP already reports those.
+func probeMCPServers(path string) []doctorCheck {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var root struct {
+		MCPServers map[string]mcpServer `json:"mcpServers"`
+	}
+	if json.Unmarshal(data, &root) != nil {
+		return nil
+	}
+
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(root.MCPServers)) {
+		if root.MCPServers[name].URL != "" {
+			names = append(names, name)
+		}
+	}
+	checks := make([]doctorCheck, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = probeMCPServer(name, root.MCPServers[name].URL)
+		}()
+	}
+	wg.Wait()
+	return slices.DeleteFunc(checks, func(c doctorCheck) bool { return c.Name == "" })
+}
+
+// probeMCPServer sends one GET to endpoint; see probeMCPServers.
+func probeMCPServer(name, endpoint string) doctorCheck {
+	endpoint, missing := expandMCPEnv(endpoint)
+	if len(missing) > 0 {
+		return doctorCheck{}
+	}
+	check := doctorCheck{Name: "mcp " + name + " endpoint"}
+	ctx, cancel := context.WithTimeout(context.Background(), mcpProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		check.Status, check.Detail, check.Fix = doctorFail, fmt.Sprintf("invalid URL %s: %v", endpoint, err), "correct the url in .mcp.json"
+		return check
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		check.Status, check.Detail, check.Fix = doctorWarn, fmt.Sprintf("%s unreachable: %v", endpoint, err), "check the URL, your network, and any proxy settings"
+		return check
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		check.Status, check.Detail, check.Fix = doctorWarn, fmt.Sprintf("%s answered %s", endpoint, resp.Status), "the server may be down; try again later"
+		return check
+	}
+	check.Status, check.Detail = doctorOK, fmt.Sprintf("%s reachable (%s)", endpoint, resp.Status)
+	return check
+}
+
+// reportMCPHealth prints the problems doctor would report for the .mcp.json at
+// path, probing the servers too when probe is set.
+func reportMCPHealth(path string, probe bool) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	checks := doctorCheckMCP(path)
+	if probe {
+		checks = append(checks, probeMCPServers(path)...)
+	}
+	var problems []doctorCheck
+	for _, c := range checks {
+		if c.Status != doctorOK {
+			problems = append(problems, c)
+		}
+	}
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Println("\n🔌 Some MCP servers will not start in Claude Code yet:")
+	for _, c := range problems {
+		fmt.Printf("   %s: %s\n", strings.TrimPrefix(c.Name, "mcp "), c.Detail)
+		if c.Fix != "" {
+			fmt.Printf("      fix: %s\n", c.Fix)
+		}
+	}
+}
+
+// doctorCheckClaudeCLI checks that the claude CLI is on PATH.
+func doctorCheckClaudeCLI() doctorCheck {
+	if path, err := exec.LookPath("claude"); err == nil {
+		return doctorCheck{Name: "claude CLI", Status: doctorOK, Detail: path}
+	}
+	return doctorCheck{
+		Name:   "claude CLI",
+		Status: doctorWarn,
+		Detail: "claude not found on PATH",
+		Fix:    "curl -fsSL https://claude.ai/install.sh | bash",
+	}
+}
+
+// detectClaudeVersion asks the claude CLI for its version. Returns "" if it is missing or unparsable.
+func detectClaudeVersion() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "claude", "--version").Output()
+	if err != nil {
+		return ""
+	}
+	if v, ok := version.Extract(string(out)); ok {
+		return v.String()
+	}
+	return ""
+}
+
+// installedModules lists the registry modules installed in baseDir, taken from the
+// generation manifest when present and otherwise from agent and command file names.
+func installedModules(baseDir string, registry *ModuleRegistry) []*ComponentModule {
+	var installed []*ComponentModule
+	add := func(t ModuleComponentType, name string) {
+		if m := registry.Get(t, name); m != nil && !slices.Contains(installed, m) {
+			installed = append(installed, m)
+		}
+	}
+
+	if mf, err := manifest.Load(baseDir); err == nil {
+		kinds := map[manifest.FileKind]ModuleComponentType{
+			manifest.KindAgent:   TypeSubagent,
+			manifest.KindHook:    TypeHook,
+			manifest.KindCommand: TypeCommand,
+		}
+		for _, e := range mf.Files {
+			if t, ok := kinds[e.Kind]; ok && e.Module != "" {
+				add(t, e.Module)
+			}
+		}
+		for _, name := range mf.MCPServers {
+			add(TypeMCP, name)
+		}
+		return installed
+	}
+
+	layout := manifest.DefaultLayout()
+	for t, dir := range map[ModuleComponentType]string{TypeSubagent: layout.Agents, TypeCommand: layout.Commands} {
+		entries, _ := os.ReadDir(manifest.Dir(baseDir, dir))
+		for _, e := range entries {
+			if name, ok := strings.CutSuffix(e.Name(), ".md"); ok {
+				add(t, name)
+			}
+		}
+	}
+	return installed
+}
+
+// doctorCheckRequirements flags installed modules whose version requirements the
+// current claudekit or Claude Code does not meet.
+func doctorCheckRequirements(baseDir string, registry *ModuleRegistry) []doctorCheck {
+	var checks []doctorCheck
+	for _, m := range installedModules(baseDir, registry) {
+		unmet := m.UnmetRequirements(Version, registry.claudeVersionFor(m))
+		if len(unmet) == 0 {
+			continue
+		}
+		fix := "upgrade claudekit"
+		if m.RequiresClaude != "" {
+			fix = "upgrade Claude Code with `claude update`"
+		}
+		checks = append(checks, doctorCheck{
+			Name:   fmt.Sprintf("%s %s", m.Type, m.Name),
+			Status: doctorWarn,
+			Detail: strings.Join(unmet, "; "),
+			Fix:    fix,
+		})
+	}
+	if len(checks) == 0 {
+		return []doctorCheck{{Name: "module requirements", Status: doctorOK, Detail: "installed components are supported"}}
+	}
+	return checks
+}
+
+// renderDoctorReport formats doctor results as a colored terminal report.
+func renderDoctorReport(baseDir string, checks []doctorCheck) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🩺 claudekit doctor: %s\n\n", baseDir)
+
+	var failed, warned int
+	for _, c := range checks {
+		var icon string
+		switch c.Status {
+		case doctorOK:
+			icon = doctorOKStyle.Render("✔")
+		case doctorWarn:
+			icon = doctorWarnStyle.Render("!")
+			warned++
+		case doctorFail:
+			icon = doctorFailStyle.Render("✘")
+			failed++
+		}
+		fmt.Fprintf(&b, "  %s %s", icon, c.Name)
+		if c.Detail != "" {
+			fmt.Fprintf(&b, " — %s", c.Detail)
+		}
+		b.WriteString("\n")
+		if c.Fix != "" && c.Status != doctorOK {
+			fmt.Fprintf(&b, "      %s\n", doctorFixStyle.Render("fix: "+c.Fix))
+		}
+	}
+
+	b.WriteString("\n")
+	switch {
+	case failed > 0:
+		fmt.Fprintf(&b, "%s\n", doctorFailStyle.Render(fmt.Sprintf("%d problem(s), %d warning(s)", failed, warned)))
+	case warned > 0:
+		fmt.Fprintf(&b, "%s\n", doctorWarnStyle.Render(fmt.Sprintf("%d warning(s)", warned)))
+	default:
+		fmt.Fprintf(&b, "%s\n", doctorOKStyle.Render("Everything looks good"))
+	}
+	return b.String()
+}
+
+// ============================================================================
+// Report: a redacted description of a configuration, for bug reports
+// ============================================================================
+
+// configReport describes a configuration without the values in it, so it can be
+// shared as it is: which modules are installed at which versions, and which keys
+// settings.json and .mcp.json set.
+type configReport struct {
+	Claudekit   string         `json:"claudekit_version"`
+	ClaudeCode  string         `json:"claude_code_version,omitempty"` // "" when it is not installed
+	Platform    string         `json:"platform"`
+	Scope       string         `json:"scope"`                  // project or global
+	GeneratedBy string         `json:"generated_by,omitempty"` // claudekit version of the last run; "" without a manifest
+	GeneratedAt string         `json:"generated_at,omitempty"` // Date of the last run
+	Modules     []reportModule `json:"modules"`
+	Settings    reportKeys     `json:"settings_json"`
+	MCP         reportKeys     `json:"mcp_json"`
+}
+
+// reportModule is an installed module and the number of files claudekit wrote for it.
+type reportModule struct {
+	Kind    manifest.FileKind `json:"kind"`
+	Name    string            `json:"name"`
+	Version string            `json:"version,omitempty"`
+	Files   int               `json:"files"`
+}
+
+// reportKeys lists the keys of a JSON file, or why they could not be read.
+type reportKeys struct {
+	Keys  []string `json:"keys"`
+	Error string   `json:"error,omitempty"` // "not found", or what is wrong with the file
+}
+
+// runReportCommand implements `claudekit report [--global] [--output json]`.
+func runReportCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("report")
+	global := flags.Bool("global", false, "describe the global configuration in ~/.claude instead of the current project")
+	output := outputFormatFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	baseDir, err := resolveTargetDir(!*global)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	report := buildConfigReport(baseDir, !*global, registry.ClaudeVersion())
+	if *output == outputJSON {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	fmt.Print(renderConfigReport(report))
+	return exitOK
+}
+
+// buildConfigReport describes the configuration in baseDir.
+func buildConfigReport(baseDir string, projectLocal bool, claudeVersion string) configReport {
+	report := configReport{
+		Claudekit:  Version,
+		ClaudeCode: claudeVersion,
+		Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+		Scope:      "global",
+		Modules:    []reportModule{},
+		Settings:   readReportKeys(filepath.Join(baseDir, ".claude", "settings.json")),
+		MCP:        readReportKeys(filepath.Join(baseDir, ".mcp.json")),
+	}
+	if projectLocal {
+		report.Scope = "project"
+	}
+	mf, err := manifest.Load(baseDir)
+	if err != nil {
+		return report
+	}
+	report.GeneratedBy = mf.GeneratorVersion
+	report.GeneratedAt = mf.GeneratedAt.Format(time.DateOnly)
+	index := map[string]int{}
+	for _, entry := range mf.Files {
+		if entry.Module == "" {
+			continue
+		}
+		key := string(entry.Kind) + "/" + entry.Module
+		i, ok := index[key]
+		if !ok {
+			i = len(report.Modules)
+			index[key] = i
+			report.Modules = append(report.Modules, reportModule{Kind: entry.Kind, Name: entry.Module, Version: entry.ModuleVersion})
+		}
+		report.Modules[i].Files++
+	}
+	slices.SortFunc(report.Modules, func(a, b reportModule) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+	return report
+}
+
+// readReportKeys reads the key paths of the JSON file at path.
+func readReportKeys(path string) reportKeys {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return reportKeys{Keys: []string{}, Error: "not found"}
+	}
+	if err != nil {
+		return reportKeys{Keys: []string{}, Error: err.Error()}
+	}
+	keys, err := jsonKeyPaths(data)
+	if err != nil {
+		return reportKeys{Keys: []string{}, Error: "invalid JSON: " + err.Error()}
+	}
+	return reportKeys{Keys: keys}
+}
+
+// jsonKeyPaths returns the dotted paths of the keys in a JSON document, without
+// their values. Arrays, which hold rules, hook commands, and arguments, are only
+// counted: permissions.allow[3] is a list of three.
+func jsonKeyPaths(data []byte) ([]string, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if len(v) == 0 && prefix != "" {
+				paths = append(paths, prefix)
+			}
+			for _, key := range slices.Sorted(maps.Keys(v)) {
+				if prefix != "" {
+					walk(prefix+"."+key, v[key])
+				} else {
+					walk(key, v[key])
+				}
+			}
+		case []any:
+			paths = append(paths, fmt.Sprintf("%s[%d]", prefix, len(v)))
+		default:
+			paths = append(paths, prefix)
+		}
+	}
+	walk("", doc)
+	return paths, nil
+}
+
+// renderConfigReport formats a report as markdown, ready to paste into an issue.
+func renderConfigReport(r configReport) string {
+	var b strings.Builder
+	b.WriteString("## claudekit configuration report\n\n")
+	b.WriteString("| | |\n|---|---|\n")
+	claudeCode := r.ClaudeCode
+	if claudeCode == "" {
+		claudeCode = "not installed"
+	}
+	generated := "never (no manifest)"
+	if r.GeneratedBy != "" {
+		generated = fmt.Sprintf("%s by claudekit %s", r.GeneratedAt, r.GeneratedBy)
+	}
+	for _, row := range [][2]string{
+		{"claudekit", r.Claudekit},
+		{"Claude Code", claudeCode},
+		{"Platform", r.Platform},
+		{"Scope", r.Scope},
+		{"Generated", generated},
+	} {
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], row[1])
+	}
+
+	b.WriteString("\n### Modules\n\n")
+	if len(r.Modules) == 0 {
+		b.WriteString("_none recorded_\n")
+	} else {
+		b.WriteString("| Kind | Module | Version | Files |\n|---|---|---|---|\n")
+		for _, m := range r.Modules {
+			version := m.Version
+			if version == "" {
+				version = "-"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %d |\n", m.Kind, m.Name, version, m.Files)
+		}
+	}
+
+	for _, file := range []struct {
+		name string
+		keys reportKeys
+	}{{".claude/settings.json", r.Settings}, {".mcp.json", r.MCP}} {
+		fmt.Fprintf(&b, "\n### %s keys\n\n", file.name)
+		switch {
+		case file.keys.Error != "":
+			fmt.Fprintf(&b, "_%s_\n", file.keys.Error)
+		case len(file.keys.Keys) == 0:
+			b.WriteString("_none_\n")
+		default:
+			for _, key := range file.keys.Keys {
+				fmt.Fprintf(&b, "- `%s`\n", key)
+			}
+		}
+	}
+	b.WriteString("\nValues are left out; arrays such as permission rules are only counted.\n")
+	return b.String()
+}
+
+// ============================================================================
+// Clean: remove everything claudekit generated
+// ============================================================================
+
+// cleanReport lists what cleanGenerated removed, rewrote, or kept because it was
+// edited since it was generated, relative to the base directory.
+type cleanReport struct {
+	Removed []string
+	Updated []string
+	Kept    []string
+}
+
+// settingsOwnership captures the settings.json keys claudekit wrote for the manifest.
+func settingsOwnership(st settings) manifest.SettingsOwnership {
+	owned := manifest.SettingsOwnership{Hooks: map[string][]string{}}
+	for event, matchers := range st.Hooks {
+		for _, matcher := range matchers {
+			for _, h := range matcher.Hooks {
+				owned.Hooks[event] = append(owned.Hooks[event], h.Command)
+			}
+		}
+	}
+	if st.Permissions != nil {
+		owned.Allow = slices.Clone(st.Permissions.Allow)
+		owned.Ask = slices.Clone(st.Permissions.Ask)
+		owned.Deny = slices.Clone(st.Permissions.Deny)
+	}
+	for key := range st.Env {
+		owned.Env = append(owned.Env, key)
+	}
+	slices.Sort(owned.Env)
+	owned.OutputStyle = st.OutputStyle
+	if st.StatusLine != nil {
+		owned.StatusLine = st.StatusLine.Command
+	}
+	return owned
+}
+
+// runCleanCommand implements `claudekit clean [--global|--project] [--dry-run]`.
+func runCleanCommand(args []string) int {
+	flags := newCommandFlags("clean")
+	global := flags.Bool("global", false, "clean the global configuration in ~/.claude")
+	project := flags.Bool("project", false, "clean the current project's configuration (default)")
+	dryRun := flags.Bool("dry-run", false, "list what would be removed without deleting anything")
+	output := outputFormatFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+	if *global && *project {
+		fmt.Fprintln(os.Stderr, "error: --global and --project are mutually exclusive")
+		return exitUsage
+	}
+
+	baseDir, err := resolveTargetDir(!*global)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	report, err := cleanGenerated(fsys.OS{}, baseDir, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	if *output == outputJSON {
+		if err := writeJSON(os.Stdout, struct {
+			BaseDir string   `json:"base_dir"`
+			DryRun  bool     `json:"dry_run"`
+			Removed []string `json:"removed"`
+			Updated []string `json:"updated"`
+			Kept    []string `json:"kept"`
+		}{baseDir, *dryRun, nonNil(report.Removed), nonNil(report.Updated), nonNil(report.Kept)}); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+
+	verb := "Removed"
+	if *dryRun {
+		verb = "Would remove"
+	}
+	for _, path := range report.Removed {
+		fmt.Printf("🗑️  %s %s\n", verb, path)
+	}
+	for _, path := range report.Updated {
+		fmt.Printf("✏️  Stripped claudekit entries from %s\n", path)
+	}
+	for _, path := range report.Kept {
+		fmt.Printf("✋ Kept %s, modified since it was generated\n", path)
+	}
+	if len(report.Removed) == 0 && len(report.Updated) == 0 && len(report.Kept) == 0 {
+		fmt.Println("Nothing to clean.")
+	}
+	return exitOK
+}
+
+// cleanGenerated removes every file and settings entry recorded in the generation manifest
+// for baseDir. Files claudekit did not generate are never touched, and generated files
+// edited since are kept.
+func cleanGenerated(files fsys.FS, baseDir string, dryRun bool) (cleanReport, error) {
+	var report cleanReport
+
+	mf, err := manifest.Load(baseDir)
+	if err != nil {
+		if errors.Is(err, manifest.ErrNotFound) {
+			return report, fmt.Errorf("%w in %s; only files generated with a manifest can be cleaned", err, filepath.Join(baseDir, ".claude"))
+		}
+		return report, err
+	}
+
+	for _, entry := range mf.Files {
+		if entry.Kind == manifest.KindSettings || entry.Kind == manifest.KindMCP || entry.Kind == manifest.KindVSCodeTasks {
+			continue // Shared files; only the owned keys are stripped below
+		}
+		path := entry.AbsPath(baseDir)
+		if entry.Kind == manifest.KindClaudeMD || entry.Kind == manifest.KindReadmeSection || entry.Kind == manifest.KindGitignore {
+			strip := stripClaudeMDSections
+			switch entry.Kind {
+			case manifest.KindReadmeSection:
+				strip = stripReadmeSection
+			case manifest.KindGitignore:
+				strip = stripGitignoreBlock
+			case manifest.KindClaudeMD:
+				// Without markers the file would go whole, so an edited one is kept
+				if edited, err := unmarkedClaudeMDEdited(files, entry, baseDir); err != nil {
+					return report, err
+				} else if edited {
+					report.Kept = append(report.Kept, entry.Path)
+					continue
+				}
+			}
+			if changed, empty, err := strip(files, path, dryRun); err != nil {
+				return report, err
+			} else if empty {
+				report.Removed = append(report.Removed, entry.Path)
+			} else if changed {
+				report.Updated = append(report.Updated, entry.Path)
+			}
+			continue
+		}
+		if _, err := files.Stat(path); err != nil {
+			continue // Already gone
+		}
+		// Edited files are the user's now, as they are when generating over them
+		if modified, err := entry.IsModified(baseDir); err != nil || modified {
+			report.Kept = append(report.Kept, entry.Path)
+			continue
+		}
+		if !dryRun {
+			if err := files.Remove(path); err != nil {
+				return report, fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+			}
+			slog.Debug("removed generated file", "path", entry.Path)
+			if entry.Kind == manifest.KindCommand {
+				removeEmptyNamespaceDir(files, filepath.Dir(path), manifest.Dir(baseDir, mf.Layout.Commands))
+			}
+		}
+		report.Removed = append(report.Removed, entry.Path)
+	}
+
+	settingsPath := filepath.Join(baseDir, ".claude", "settings.json")
+	if changed, empty, err := stripOwnedSettings(files, settingsPath, mf.Settings, dryRun); err != nil {
+		return report, err
+	} else if empty {
+		report.Removed = append(report.Removed, ".claude/settings.json")
+	} else if changed {
+		report.Updated = append(report.Updated, ".claude/settings.json")
+	}
+
+	mcpPath := filepath.Join(baseDir, ".mcp.json")
+	if changed, empty, err := stripOwnedMCPServers(files, mcpPath, mf.MCPServers, dryRun); err != nil {
+		return report, err
+	} else if empty {
+		report.Removed = append(report.Removed, ".mcp.json")
+	} else if changed {
+		report.Updated = append(report.Updated, ".mcp.json")
+	}
+
+	tasksPath := filepath.Join(baseDir, ".vscode", "tasks.json")
+	if changed, empty, err := stripOwnedVSCodeTasks(files, tasksPath, mf.EditorTasks, dryRun); err != nil {
+		return report, err
+	} else if empty {
+		report.Removed = append(report.Removed, ".vscode/tasks.json")
+	} else if changed {
+		report.Updated = append(report.Updated, ".vscode/tasks.json")
+	}
+
+	// Packages generated alongside this configuration are cleaned with it
+	for _, dir := range mf.Packages {
+		pkgAbs, err := packageDir(baseDir, dir)
+		if err != nil {
+			return report, err
+		}
+		pkgReport, err := cleanGenerated(files, pkgAbs, dryRun)
+		if errors.Is(err, manifest.ErrNotFound) {
+			continue // Already cleaned on its own
+		}
+		if err != nil {
+			return report, fmt.Errorf("package %s: %w", dir, err)
+		}
+		for _, rel := range pkgReport.Removed {
+			report.Removed = append(report.Removed, path.Join(dir, rel))
+		}
+		for _, rel := range pkgReport.Updated {
+			report.Updated = append(report.Updated, path.Join(dir, rel))
+		}
+		for _, rel := range pkgReport.Kept {
+			report.Kept = append(report.Kept, path.Join(dir, rel))
+		}
+	}
+
+	if !dryRun {
+		if err := files.Remove(manifest.Path(baseDir)); err != nil && !os.IsNotExist(err) {
+			return report, fmt.Errorf("failed to remove manifest: %w", err)
+		}
+		// Remove directories claudekit created, but only once nothing else lives in them
+		for _, dir := range []string{mf.Layout.Agents, path.Join(mf.Layout.Hooks, "lib"), mf.Layout.Hooks, mf.Layout.Commands, ".claude/output-styles", ".claude", "docs", ".vscode", ".run"} {
+			_ = removeIfEmpty(files, manifest.Dir(baseDir, dir))
+		}
+	}
+
+	return report, nil
+}
+
+// removeIfEmpty removes dir only when it has no entries.
+func removeIfEmpty(files fsys.FS, dir string) error {
+	entries, err := files.ReadDir(dir)
+	if err != nil || len(entries) > 0 {
+		return err
+	}
+	return files.Remove(dir)
+}
+
+// stripOwnedSettings removes claudekit-owned hooks, permissions, and env keys from
+// settings.json. Reports whether the file changed and whether it was deleted for being empty.
+func stripOwnedSettings(files fsys.FS, path string, owned manifest.SettingsOwnership, dryRun bool) (changed, empty bool, err error) {
+	doc, err := readJSONObject(files, path)
+	if err != nil || doc == nil {
+		return false, false, err
+	}
+	before, _ := json.Marshal(doc)
+
+	if hooks, ok := doc["hooks"].(map[string]any); ok {
+		for event, rawMatchers := range hooks {
+			matchers, _ := rawMatchers.([]any)
+			var kept []any
+			for _, rawMatcher := range matchers {
+				matcher, ok := rawMatcher.(map[string]any)
+				if !ok {
+					kept = append(kept, rawMatcher)
+					continue
+				}
+				cmds, _ := matcher["hooks"].([]any)
+				var keptCmds []any
+				for _, rawCmd := range cmds {
+					cmd, _ := rawCmd.(map[string]any)
+					command, _ := cmd["command"].(string)
+					if cmd != nil && slices.Contains(owned.Hooks[event], command) {
+						continue
+					}
+					keptCmds = append(keptCmds, rawCmd)
+				}
+				if len(keptCmds) > 0 {
+					matcher["hooks"] = keptCmds
+					kept = append(kept, matcher)
+				}
+			}
+			if len(kept) > 0 {
+				hooks[event] = kept
+			} else {
+				delete(hooks, event)
+			}
+		}
+		if len(hooks) == 0 {
+			delete(doc, "hooks")
+		}
+	}
+
+	if perms, ok := doc["permissions"].(map[string]any); ok {
+		for key, ownedList := range map[string][]string{"allow": owned.Allow, "ask": owned.Ask, "deny": owned.Deny} {
+			list, _ := perms[key].([]any)
+			var kept []any
+			for _, item := range list {
+				if rule, ok := item.(string); ok && slices.Contains(ownedList, rule) {
+					continue
+				}
+				kept = append(kept, item)
+			}
+			if len(kept) > 0 {
+				perms[key] = kept
+			} else {
+				delete(perms, key)
+			}
+		}
+		if len(perms) == 0 {
+			delete(doc, "permissions")
+		}
+	}
+
+	if env, ok := doc["env"].(map[string]any); ok {
+		for _, key := range owned.Env {
+			delete(env, key)
+		}
+		if len(env) == 0 {
+			delete(doc, "env")
+		}
+	}
+
+	// Only remove the style if the user has not switched it since
+	if style, ok := doc["outputStyle"].(string); ok && owned.OutputStyle != "" && style == owned.OutputStyle {
+		delete(doc, "outputStyle")
+	}
+
+	// Likewise only remove a statusline that still runs the command claudekit set
+	if line, ok := doc["statusLine"].(map[string]any); ok && owned.StatusLine != "" && line["command"] == owned.StatusLine {
+		delete(doc, "statusLine")
+	}
+
+	return writeStrippedJSON(files, path, doc, before, dryRun)
+}
+
+// stripOwnedMCPServers removes claudekit-owned servers from .mcp.json.
+func stripOwnedMCPServers(files fsys.FS, path string, owned []string, dryRun bool) (changed, empty bool, err error) {
+	doc, err := readJSONObject(files, path)
+	if err != nil || doc == nil {
+		return false, false, err
+	}
+	before, _ := json.Marshal(doc)
+
+	if servers, ok := doc["mcpServers"].(map[string]any); ok {
+		for _, name := range owned {
+			delete(servers, name)
+		}
+		if len(servers) == 0 {
+			delete(doc, "mcpServers")
+		}
+	}
+
+	return writeStrippedJSON(files, path, doc, before, dryRun)
+}
+
+// stripOwnedVSCodeTasks removes the tasks claudekit added to .vscode/tasks.json, by label.
+// A file left with no tasks is removed.
+func stripOwnedVSCodeTasks(files fsys.FS, path string, owned []string, dryRun bool) (changed, empty bool, err error) {
+	data, err := files.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(stripJSONComments(data), &doc); err != nil {
+		return false, false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	before, _ := json.Marshal(doc)
+
+	if tasks, ok := doc["tasks"].([]any); ok {
+		kept := slices.DeleteFunc(tasks, func(t any) bool {
+			entry, _ := t.(map[string]any)
+			label, _ := entry["label"].(string)
+			return slices.Contains(owned, label)
+		})
+		doc["tasks"] = kept
+		if len(kept) == 0 {
+			delete(doc, "tasks")
+			if _, ok := doc["version"]; ok && len(doc) == 1 {
+				delete(doc, "version") // Nothing but the schema version is left
+			}
+		}
+	}
+
+	return writeStrippedJSON(files, path, doc, before, dryRun)
+}
+
+// readJSONObject reads a JSON object file, returning nil if the file does not exist.
+func readJSONObject(files fsys.FS, path string) (map[string]any, error) {
+	data, err := files.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// writeStrippedJSON persists doc if it differs from before, deleting the file when doc is empty.
+func writeStrippedJSON(files fsys.FS, path string, doc map[string]any, before []byte, dryRun bool) (changed, empty bool, err error) {
+	after, _ := json.Marshal(doc)
+	if bytes.Equal(before, after) {
+		return false, false, nil
+	}
+	if len(doc) == 0 {
+		if !dryRun {
+			if err := files.Remove(path); err != nil {
+				return false, false, err
+			}
+		}
+		return true, true, nil
+	}
+	if !dryRun {
+		buf, _ := json.MarshalIndent(doc, "", "  ")
+		if err := files.WriteFile(path, buf, 0o644); err != nil {
+			return false, false, err
+		}
+	}
+	return true, false, nil
+}
+
+// envTheme names the color theme when --theme is not given.
+const envTheme = "CLAUDEKIT_THEME"
+
+// envReducedMotion turns animations off like --no-animation when set to anything but
+// a false boolean ("0", "false", ...).
+const envReducedMotion = "CLAUDEKIT_REDUCED_MOTION"
+
+// reducedMotion reports whether envReducedMotion asks for animations to be off.
+func reducedMotion(value string) bool {
+	if value == "" {
+		return false
+	}
+	on, err := strconv.ParseBool(value)
+	return err != nil || on
+}
+
+// resolveTheme picks the form's color theme: --theme, then CLAUDEKIT_THEME, then the
+// theme saved from the last run, then the default. An unknown name in the environment
+// is reported and skipped; one in the saved choices is skipped quietly, since it may
+// come from a newer claudekit.
+func resolveTheme(flagTheme, envValue, saved string) string {
+	if flagTheme != "" {
+		return flagTheme
+	}
+	if envValue != "" {
+		_, err := gradient.PaletteByName(envValue)
+		if err == nil {
+			return envValue
+		}
+		slog.Warn("ignoring "+envTheme, "err", err)
+	}
+	if _, err := gradient.PaletteByName(saved); saved != "" && err == nil {
+		return saved
+	}
+	return gradient.DefaultThemeName
+}
+
+// interactiveOptions holds the flags accepted by the interactive form.
+type interactiveOptions struct {
+	forceCapability *gradient.TerminalCapability // --force-capability
+	forceSize       *tea.WindowSizeMsg           // --force-size
+	theme           string                       // --theme; "" falls back to CLAUDEKIT_THEME, then the saved theme
+	noAnimation     bool                         // --no-animation, or CLAUDEKIT_REDUCED_MOTION
+	noMouse         bool                         // --no-mouse: leave the mouse to the terminal for text selection
+	resizeDebounce  time.Duration                // --resize-debounce
+	hookLanguages   map[string]string            // --hook-lang; nil keeps the persisted choices
+	env             []string                     // --env KEY=VALUE, repeatable; set on top of the saved env
+	headless        bool                         // --headless: skip the form even at a terminal
+	interactive     bool                         // --interactive: open the form even in CI
+	yes             bool                         // --yes: generate without the form when headless
+	includeDisabled bool                         // --include-disabled: offer modules with enabled: false
+	probeMCP        bool                         // --probe-mcp: check that MCP servers are reachable after generating
+	noFmt           bool                         // --no-fmt: leave generated markdown unformatted
+	githubWorkflows []string                     // --github-workflow; nil keeps the persisted choices
+}
+
+// parseInteractiveFlags parses `claudekit [--force-capability truecolor|256|8|none] [--force-size WxH]
+// [--theme NAME] [--no-animation] [--no-mouse] [--resize-debounce DURATION] [--hook-lang LANG|HOOK=LANG,...] [--headless|--interactive] [--yes]
+// [--include-disabled] [--probe-mcp] [--no-fmt] [--github-workflow JOB,...|none] [--env KEY=VALUE ...]`.
+// The force flags exist for reproducible screenshots and for reproducing terminal-specific bugs.
+func parseInteractiveFlags(args []string) (interactiveOptions, error) {
+	return parseSetupFlags("init", args)
+}
+
+// parseSetupFlags parses the flags of command, init or apply. Both take the flags
+// that choose what is generated; only init takes those of the form itself.
+func parseSetupFlags(command string, args []string) (interactiveOptions, error) {
+	opts := interactiveOptions{resizeDebounce: RESIZE_DEBOUNCE_MS * time.Millisecond}
+	flags := newCommandFlags(command)
+	var capability, size string
+	if command == "init" {
+		flags.StringVar(&capability, "force-capability", "", "render as if the terminal supports `truecolor|256|8|none` colors")
+		flags.StringVar(&size, "force-size", "", "lay out the form for a fixed `WxH` terminal size, e.g. 120x40")
+		flags.BoolVar(&opts.noAnimation, "no-animation", false, "show theme changes at once instead of animating them")
+		flags.BoolVar(&opts.noMouse, "no-mouse", false, "do not capture the mouse, so the terminal can select text")
+		flags.StringVar(&opts.theme, "theme", "", "draw the form in the `NAME`d color theme ("+strings.Join(gradient.ThemeNames(), ", ")+")")
+		flags.DurationVar(&opts.resizeDebounce, "resize-debounce", opts.resizeDebounce, "wait this long after a burst of resize events before re-laying out")
+		flags.BoolVar(&opts.headless, "headless", false, "skip the interactive form and use saved choices and defaults")
+		flags.BoolVar(&opts.interactive, "interactive", false, "open the interactive form even when CI or a missing terminal is detected")
+		flags.BoolVar(&opts.yes, "yes", false, "generate without the form when running headless")
+	}
+	hookLang := flags.String("hook-lang", "", "generate hook scripts in `LANG` (bash, python, node, powershell), or per hook with HOOK=LANG, comma-separated")
+	flags.BoolVar(&opts.includeDisabled, "include-disabled", false, "offer and generate modules whose frontmatter sets enabled: false")
+	flags.BoolVar(&opts.probeMCP, "probe-mcp", false, "after generating, connect to each HTTP and SSE MCP server to check that it is reachable")
+	flags.BoolVar(&opts.noFmt, "no-fmt", false, "write generated markdown without running the markdown formatter on it")
+	githubWorkflow := flags.String("github-workflow", "", "generate .github/workflows/claude.yml with the `JOB`s review and/or triage, comma-separated, or none to remove it")
+	flags.Func("env", "set `KEY=VALUE` in settings.json's env; repeat for more variables, or give KEY= to remove one", func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("%q is not KEY=VALUE", s)
+		}
+		if value != "" {
+			if err := validateEnvVar(key, value); err != nil {
+				return err
+			}
+		}
+		opts.env = append(opts.env, s)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.headless && opts.interactive {
+		err := errors.New("--headless and --interactive are mutually exclusive")
+		fmt.Fprintln(flags.Output(), err)
+		return opts, err
+	}
+	if *hookLang != "" {
+		choices, err := parseHookLanguages(*hookLang)
+		if err != nil {
+			fmt.Fprintf(flags.Output(), "invalid --hook-lang: %v\n", err)
+			return opts, err
+		}
+		opts.hookLanguages = choices
+	}
+	if *githubWorkflow != "" {
+		jobs, err := parseGitHubWorkflows(*githubWorkflow)
+		if err != nil {
+			fmt.Fprintf(flags.Output(), "invalid --github-workflow: %v\n", err)
+			return opts, err
+		}
+		opts.githubWorkflows = jobs
+	}
+	if opts.theme != "" {
+		if _, err := gradient.PaletteByName(opts.theme); err != nil {
+			fmt.Fprintf(flags.Output(), "invalid --theme: %v\n", err)
+			return opts, err
+		}
+	}
+	if opts.resizeDebounce < 0 {
+		err := fmt.Errorf("%v is negative", opts.resizeDebounce)
+		fmt.Fprintf(flags.Output(), "invalid --resize-debounce: %v\n", err)
+		return opts, err
+	}
+
+	if capability != "" {
+		c, err := gradient.ParseCapability(capability)
+		if err != nil {
+			fmt.Fprintf(flags.Output(), "invalid --force-capability: %v\n", err)
+			return opts, err
+		}
+		opts.forceCapability = &c
+	}
+	if size != "" {
+		msg, err := parseTerminalSize(size)
+		if err != nil {
+			fmt.Fprintf(flags.Output(), "invalid --force-size: %v\n", err)
+			return opts, err
+		}
+		opts.forceSize = &msg
+	}
+	return opts, nil
+}
+
+// parseTerminalSize parses "WxH" into a window size message.
+func parseTerminalSize(s string) (tea.WindowSizeMsg, error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		return tea.WindowSizeMsg{}, fmt.Errorf("%q is not WxH", s)
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return tea.WindowSizeMsg{}, fmt.Errorf("%q is not WxH with positive integers", s)
+	}
+	return tea.WindowSizeMsg{Width: width, Height: height}, nil
+}
+
+// capabilityProfile maps a gradient capability to the lipgloss color profile, so
+// styled text outside the gradients degrades the same way.
+func capabilityProfile(c gradient.TerminalCapability) termenv.Profile {
+	switch c {
+	case gradient.Truecolor:
+		return termenv.TrueColor
+	case gradient.Color256:
+		return termenv.ANSI256
+	case gradient.NoColor:
+		return termenv.Ascii
+	default:
+		return termenv.ANSI
+	}
+}
+
+// reportRegistryErrors prints module load problems as warnings.
+func reportRegistryErrors(errs []error) {
+	if len(errs) > 0 {
+		slog.Warn("module registry errors", "count", len(errs))
+		for _, regErr := range errs {
+			slog.Warn("module not loaded", "err", regErr)
+		}
+	}
+}
+
+// extractLogFlags removes the logging flags every subcommand accepts from args:
+// --verbose, --quiet, and --log-file FILE (or --log-file=FILE).
+func extractLogFlags(args []string) (logging.Options, []string, error) {
+	var opts logging.Options
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			return opts, append(rest, args[i:]...), nil
+		case arg == "--verbose":
+			opts.Verbose = true
+		case arg == "--quiet":
+			opts.Quiet = true
+		case arg == "--log-file":
+			if i+1 == len(args) {
+				return opts, nil, errors.New("--log-file needs a file name")
+			}
+			i++
+			opts.File = args[i]
+		case strings.HasPrefix(arg, "--log-file="):
+			opts.File = strings.TrimPrefix(arg, "--log-file=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if opts.Verbose && opts.Quiet {
+		return opts, nil, errors.New("--verbose and --quiet are mutually exclusive")
+	}
+	return opts, rest, nil
+}
+
+// Exit codes claudekit ends with, so scripts can tell the kinds of failure apart
+// without reading standard error.
+const (
+	exitOK        = 0 // Done
+	exitFailure   = 1 // A check found problems, or an operation failed
+	exitUsage     = 2 // Invalid flags, arguments, or input; nothing was changed
+	exitPartial   = 3 // Generation stopped partway: some files were written and some were not
+	exitCancelled = 4 // Nothing was written: the user quit, declined, or did not pass --yes
+)
+
+// exitError is an error with the exit code it ends claudekit with. Errors that
+// wrap none end it with exitFailure.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the code claudekit ends with after the error.
+func (e *exitError) ExitCode() int { return e.code }
+
+// usageError marks err as a problem with the command line or input.
+func usageError(err error) error { return &exitError{code: exitUsage, err: err} }
+
+// partialError marks err as having stopped generation after some files were written.
+func partialError(err error) error { return &exitError{code: exitPartial, err: err} }
+
+// errCancelled is returned when the user did not confirm, or pressed Ctrl+C during a
+// progress bar.
+var errCancelled = &exitError{code: exitCancelled, err: errors.New("cancelled")}
+
+// exitCode returns the code claudekit ends with after err: exitOK for nil, the
+// ExitCode of the first error in err's chain that has one, and exitFailure otherwise.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var coded interface{ ExitCode() int }
+	if errors.As(err, &coded) {
+		return coded.ExitCode()
+	}
+	return exitFailure
+}
+
+// globalOptions are the flags every subcommand accepts.
+type globalOptions struct {
+	log     logging.Options
+	config  string // --config FILE: remember choices in FILE, as CLAUDEKIT_CONFIG does
+	noColor bool   // --no-color: draw without color, as NO_COLOR does
+}
+
+// extractGlobalFlags removes the flags every subcommand accepts from args: the
+// logging flags, --config FILE (or --config=FILE), and --no-color.
+func extractGlobalFlags(args []string) (globalOptions, []string, error) {
+	var opts globalOptions
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			rest = append(rest, args[i:]...)
+			i = len(args)
+		case arg == "--no-color":
+			opts.noColor = true
+		case arg == "--config":
+			if i+1 == len(args) {
+				return opts, nil, errors.New("--config needs a file name")
+			}
+			i++
+			opts.config = args[i]
+		case strings.HasPrefix(arg, "--config="):
+			opts.config = strings.TrimPrefix(arg, "--config=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	log, rest, err := extractLogFlags(rest)
+	opts.log = log
+	return opts, rest, err
+}
+
+// ============================================================================
+// Command line: claudekit [COMMAND] [ARGS]
+// ============================================================================
+
+// cliCommand is a claudekit subcommand.
+type cliCommand struct {
+	Name    string // Subcommands of a command are named after both, e.g. "module list"
+	Usage   string // What follows the name on the command line
+	Summary string // One line for the command list
+	Hidden  bool   // Old spellings that keep working but are not listed
+
+	// EmbeddedOnly commands use the modules and templates built into claudekit,
+	// without the user's overrides.
+	EmbeddedOnly bool
+
+	Run func(args []string, loader *registryLoader) int
+}
+
+// cliCommands returns claudekit's commands in the order help lists them.
+func cliCommands() []cliCommand {
+	return []cliCommand{
+		{Name: "init", Usage: "[flags]", Summary: "choose modules in the interactive form and generate the configuration (the default)", Run: runInitCommand},
+		{Name: "apply", Usage: "[flags]", Summary: "generate the configuration from saved choices and defaults, without the form", Run: runApplyCommand},
+		{Name: "fmt", Usage: "[PATH] [--dry-run] [--check] [--exclude PATTERN]", Summary: "format the Markdown of a Claude Code configuration", Run: withoutRegistry(runFmtCommand)},
+		{Name: "doctor", Usage: "[--global]", Summary: "check a Claude Code configuration for problems", Run: withRegistry(runDoctorCommand)},
+		{Name: "report", Usage: "[--global] [--output json]", Summary: "describe the configuration without its values, for bug reports", Run: withRegistry(runReportCommand)},
+		{Name: "module", Usage: "list|browse|import|generate-assets [ARGS]", Summary: "list, browse, and import modules, and generate their asset files", Run: runModuleCommand},
+		{Name: "module list", Usage: "[--type TYPE] [--include-disabled] [--output json]", Summary: "list every module", Run: withRegistry(runModuleListCommand)},
+		{Name: "module import", Usage: "[--force] PATH|URL", Summary: "turn another repository's agents and commands into user modules", Run: withRegistry(runModuleImportCommand)},
+		{Name: "module browse", Usage: "[--include-disabled] [QUERY]", Summary: "browse and search every module", Run: withRegistry(runBrowseCommand)},
+		{Name: "module generate-assets", Usage: "[--output json] [--yes] [--resume]", Summary: "write the asset files of every built-in module into ./assets", EmbeddedOnly: true, Run: withRegistry(runGenerateAssetsCommand)},
+		{Name: "clean", Usage: "[--global|--project] [--dry-run]", Summary: "remove the files claudekit generated", Run: withoutRegistry(runCleanCommand)},
+		{Name: "upgrade", Usage: "[--global] [--dry-run] [--yes] [MODULE...]", Summary: "update installed modules to their latest versions", Run: withRegistry(runUpgradeCommand)},
+		{Name: "permissions", Usage: `test [--global] "Tool(arguments)"`, Summary: "explain which permission rule decides a tool call", Run: withoutRegistry(runPermissionsCommand)},
+		{Name: "stats", Usage: "[--days N] [FILE]", Summary: "summarize Claude Code usage from session transcripts", Run: withoutRegistry(runStatsCommand)},
+		{Name: "new", Usage: "[--list] TEMPLATE [DIR]", Summary: "create a project from a template", Run: withRegistry(runNewCommand)},
+		{Name: "export", Usage: "FILE", Summary: "package saved choices and template overrides into a bundle", Run: withRegistry(runExportCommand)},
+		{Name: "import", Usage: "[--sha256 SUM] [--force] FILE", Summary: "install a bundle made by export", Run: withRegistry(runImportCommand)},
+		{Name: "help", Usage: "[COMMAND]", Summary: "show help for claudekit or a command", Run: runHelpCommand},
+
+		// Spellings from before the command tree
+		{Name: "browse", Usage: "[--include-disabled] [QUERY]", Summary: "browse and search every module", Hidden: true, Run: withRegistry(runBrowseCommand)},
+		{Name: "--generate-assets", Usage: "[--output json] [--yes] [--resume]", Summary: "write the asset files of every built-in module into ./assets", Hidden: true, EmbeddedOnly: true, Run: withRegistry(runGenerateAssetsCommand)},
+	}
+}
+
+// withRegistry adapts a command that needs the module registry.
+func withRegistry(run func([]string, *ModuleRegistry) int) func([]string, *registryLoader) int {
+	return func(args []string, loader *registryLoader) int {
+		return run(args, loader.Registry())
+	}
+}
+
+// withoutRegistry adapts a command that does not use the module registry.
+func withoutRegistry(run func([]string) int) func([]string, *registryLoader) int {
+	return func(args []string, _ *registryLoader) int {
+		return run(args)
+	}
+}
+
+// lookupCommand returns the command named name.
+func lookupCommand(name string) (cliCommand, bool) {
+	for _, cmd := range cliCommands() {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	return cliCommand{}, false
+}
+
+// resolveCommand picks the command args run and returns the arguments left for it.
+// Without a command, or with only flags, args run init, as claudekit did before it
+// had commands. A command that is not known is returned by name with ok false.
+func resolveCommand(args []string) (cmd cliCommand, rest []string, ok bool) {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && args[0] != "--generate-assets") {
+		cmd, _ = lookupCommand("init")
+		return cmd, args, true
+	}
+	cmd, ok = lookupCommand(args[0])
+	if !ok {
+		return cliCommand{Name: args[0]}, nil, false
+	}
+	if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		if sub, found := lookupCommand(args[0] + " " + args[1]); found {
+			return sub, args[2:], true
+		}
+	}
+	return cmd, args[1:], true
+}
+
+// isHelpFlag reports whether arg asks for help.
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// globalFlagsHelp lists the flags extractGlobalFlags takes, for help.
+const globalFlagsHelp = `Global flags:
+  --verbose          also show each file written or removed
+  --quiet            show errors only
+  --log-file FILE    append everything, at every level, to FILE
+  --config FILE      remember choices in FILE instead of ~/.claudekit.json
+  --no-color         draw without colors, as NO_COLOR does
+`
+
+// printUsage prints the list of commands.
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "claudekit sets up Claude Code for a project.\n\nUsage: claudekit [COMMAND] [ARGS]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range cliCommands() {
+		if !cmd.Hidden {
+			fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Summary)
+		}
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%s\nRun 'claudekit help COMMAND' for the usage and flags of a command.\n", globalFlagsHelp)
+}
+
+// printCommandUsage prints how to run cmd, followed by the subcommands it has.
+func printCommandUsage(w io.Writer, cmd cliCommand) {
+	summary := strings.ToUpper(cmd.Summary[:1]) + cmd.Summary[1:]
+	fmt.Fprintf(w, "Usage: claudekit %s %s\n\n%s.\n", cmd.Name, cmd.Usage, summary)
+	var subcommands []cliCommand
+	for _, sub := range cliCommands() {
+		if strings.HasPrefix(sub.Name, cmd.Name+" ") {
+			subcommands = append(subcommands, sub)
+		}
+	}
+	if len(subcommands) > 0 {
+		fmt.Fprintf(w, "\nCommands:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, sub := range subcommands {
+			fmt.Fprintf(tw, "  %s\t%s\n", strings.TrimPrefix(sub.Name, cmd.Name+" "), sub.Summary)
+		}
+		tw.Flush()
+	}
+}
+
+// newCommandFlags returns the flag set of the named command. Its -h prints the
+// command's usage above the flags.
+func newCommandFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		w := flags.Output()
+		cmd, ok := lookupCommand(name)
+		if !ok {
+			cmd, ok = lookupCommand(strings.Fields(name)[0]) // "permissions test"
+		}
+		if ok {
+			printCommandUsage(w, cmd)
+			fmt.Fprintf(w, "\nFlags:\n")
+		}
+		flags.PrintDefaults()
+		fmt.Fprintf(w, "\n%s", globalFlagsHelp)
+	}
+	return flags
+}
+
+// runHelpCommand implements `claudekit help [COMMAND]`.
+func runHelpCommand(args []string, loader *registryLoader) int {
+	if len(args) == 0 || isHelpFlag(args[0]) {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	cmd, rest, ok := resolveCommand(args)
+	if !ok || len(rest) > 0 {
+		fmt.Fprintf(os.Stderr, "claudekit help: unknown command %q\n", strings.Join(args, " "))
+		return exitUsage
+	}
+	if cmd.Name == "help" {
+		printCommandUsage(os.Stdout, cmd)
+		return exitOK
+	}
+	// Commands print their usage as their flags' -h does
+	cmd.Run([]string{"-h"}, loader)
+	return exitOK
+}
+
+// runModuleCommand implements `claudekit module` without a known subcommand.
+func runModuleCommand(args []string, _ *registryLoader) int {
+	cmd, _ := lookupCommand("module")
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		printCommandUsage(os.Stdout, cmd)
+		return exitOK
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "claudekit module: unknown command %q\n\n", args[0])
+	}
+	printCommandUsage(os.Stderr, cmd)
+	return exitUsage
+}
+
+// runModuleListCommand implements `claudekit module list [--type TYPE]
+// [--include-disabled] [--output json]`.
+func runModuleListCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("module list")
+	moduleType := flags.String("type", "", "only list modules of `TYPE`: subagent, hook, command, mcp, framework, style, statusline, or permissions")
+	includeDisabled := flags.Bool("include-disabled", false, "also list modules whose frontmatter sets enabled: false")
+	output := outputFormatFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	var modules []*ComponentModule
+	known := false
+	for _, section := range browseSections {
+		if *moduleType != "" && string(section.Type) != *moduleType {
+			continue
+		}
+		known = true
+		for _, module := range registry.List(section.Type) {
+			if module.Enabled || *includeDisabled {
+				modules = append(modules, module)
+			}
+		}
+	}
+	if !known {
+		fmt.Fprintf(os.Stderr, "invalid --type: %q is not a module type\n", *moduleType)
+		return exitUsage
+	}
+
+	if *output == outputJSON {
+		if err := writeJSON(os.Stdout, modules); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, module := range modules {
+		name := module.Name
+		if !module.Enabled {
+			name += " (disabled)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", module.Type, name, moduleSummary(module))
+	}
+	tw.Flush()
+	return exitOK
+}
+
+// ============================================================================
+// Module import: agents and commands from other repositories
+// ============================================================================
+
+// importedModule is an agent or command found in another repository, converted to
+// a module file and the asset file it generates from.
+type importedModule struct {
+	Type       ModuleComponentType
+	Name       string
+	Origin     string // The file it was converted from, relative to the source
+	Definition []byte // The module file, for the type's directory
+	AssetPath  string // Where the asset goes in the user module directory, as in AssetPaths
+	Asset      []byte
+}
+
+// maxImportSize bounds a markdown file downloaded by module import.
+const maxImportSize = 1 << 20
+
+// runModuleImportCommand implements `claudekit module import [--force] SOURCE`.
+func runModuleImportCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("module import")
+	force := flags.Bool("force", false, "replace modules that already have the same name")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	dirs := userModuleDirs()
+	if len(dirs) == 0 {
+		fmt.Fprintln(os.Stderr, "error: no home directory to keep imported modules in")
+		return exitFailure
+	}
+	dir := dirs[len(dirs)-1]
+
+	source := flags.Arg(0)
+	modules, err := findImports(source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	if len(modules) == 0 {
+		fmt.Fprintf(os.Stderr, "error: no agents or commands found in %s\n", source)
+		return exitFailure
+	}
+
+	var imported, failed int
+	seen := map[string]string{} // type/name → origin, to catch two files converting to one module
+	for _, m := range modules {
+		key := string(m.Type) + "/" + m.Name
+		err := fmt.Errorf("%s also converts to this name", seen[key])
+		if _, dup := seen[key]; !dup {
+			seen[key] = m.Origin
+			err = installImport(dir, m, registry, *force)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s %s (%s): %v\n", m.Type, m.Name, m.Origin, err)
+			failed++
+			continue
+		}
+		fmt.Printf("📥 Imported %s %s (%s)\n", m.Type, m.Name, m.Origin)
+		imported++
+	}
+	if imported > 0 {
+		fmt.Printf("\nInstalled in %s; the form offers them alongside the built-in modules.\n", dir)
+	}
+	switch {
+	case failed == 0:
+		return exitOK
+	case imported > 0:
+		return exitPartial
+	}
+	return exitFailure
+}
+
+// installImport writes m's module file and asset into the user module directory dir.
+// A module that already has its name is only replaced with force.
+func installImport(dir string, m importedModule, registry *ModuleRegistry, force bool) error {
+	if existing := registry.Get(m.Type, m.Name); existing != nil && !force {
+		if existing.assets != nil {
+			return fmt.Errorf("already imported; pass --force to replace it")
+		}
+		return fmt.Errorf("a built-in module has this name; pass --force to use the import in its place")
+	}
+	typeDir := ""
+	for name, t := range moduleTypeDirs {
+		if t == m.Type {
+			typeDir = name
+		}
+	}
+	for path, content := range map[string][]byte{
+		filepath.Join(dir, typeDir, m.Name+".md"):           m.Definition,
+		filepath.Join(dir, filepath.FromSlash(m.AssetPath)): m.Asset,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findImports converts the agents and commands in source: a repository or a Claude
+// Code configuration directory, an agents or commands directory, a single file, or
+// the URL of a git repository or a raw markdown file.
+func findImports(source string) ([]importedModule, error) {
+	if !strings.Contains(source, "://") && !strings.HasPrefix(source, "git@") {
+		return importsFromPath(source)
+	}
+	if u, err := url.Parse(source); err == nil && strings.HasSuffix(u.Path, ".md") {
+		return importFromURL(u)
+	}
+
+	clone, err := os.MkdirTemp("", "claudekit-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(clone)
+	cmd := exec.Command("git", "clone", "--quiet", "--depth", "1", source, clone)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git clone %s: %w", source, err)
+	}
+	return importsFromPath(clone)
+}
+
+// importFromURL downloads and converts a single agent or command file.
+func importFromURL(u *url.URL) ([]importedModule, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize))
+	if err != nil {
+		return nil, err
+	}
+	m, err := convertImportFile(strings.Split(strings.Trim(u.Path, "/"), "/"), data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	}
+	m.Origin = u.String()
+	return []importedModule{m}, nil
+}
+
+// importsFromPath converts the agents and commands at path. A directory is searched
+// in its .claude directory when it has one, and then in agents/ and commands/,
+// unless it is one of those itself.
+func importsFromPath(path string) ([]importedModule, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		m, err := convertImportFile(strings.Split(filepath.ToSlash(abs), "/"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		m.Origin = filepath.Base(path)
+		return []importedModule{m}, nil
+	}
+
+	root := path
+	if info, err := os.Stat(filepath.Join(path, ".claude")); err == nil && info.IsDir() {
+		root = filepath.Join(path, ".claude")
+	}
+	agentsDir, commandsDir := filepath.Join(root, "agents"), filepath.Join(root, "commands")
+	switch filepath.Base(root) {
+	case "agents":
+		agentsDir, commandsDir = root, ""
+	case "commands":
+		agentsDir, commandsDir = "", root
+	}
+
+	var modules []importedModule
+	var errs []error
+	for _, dir := range []string{agentsDir, commandsDir} {
+		if dir == "" {
+			continue
+		}
+		err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if file != dir && dir == agentsDir {
+					return filepath.SkipDir // Claude Code reads agents from the top level only
+				}
+				return nil
+			}
+			if !strings.HasSuffix(d.Name(), ".md") || strings.EqualFold(d.Name(), "README.md") {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, file)
+			segments := strings.Split(filepath.ToSlash(rel), "/")
+			var m importedModule
+			if dir == agentsDir {
+				m, err = convertImportedAgent(d.Name(), data)
+			} else {
+				m, err = convertImportedCommand(segments[:len(segments)-1], d.Name(), data)
+			}
+			origin, _ := filepath.Rel(path, file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", filepath.ToSlash(origin), err))
+				return nil
+			}
+			m.Origin = filepath.ToSlash(origin)
+			modules = append(modules, m)
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if len(errs) > 0 && len(modules) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "⚠️  skipping %v\n", err)
+	}
+	return modules, nil
+}
+
+// convertImportFile converts one file, given the segments of its path: a file in an
+// agents directory is an agent, one under a commands directory a command namespaced
+// by the directories between. Otherwise a name in the frontmatter marks an agent,
+// since command files have none.
+func convertImportFile(segments []string, data []byte) (importedModule, error) {
+	name := segments[len(segments)-1]
+	dirs := segments[:len(segments)-1]
+	if len(dirs) > 0 && dirs[len(dirs)-1] == "agents" {
+		return convertImportedAgent(name, data)
+	}
+	if i := slices.Index(dirs, "commands"); i >= 0 {
+		return convertImportedCommand(dirs[i+1:], name, data)
+	}
+	if frontmatter, _, err := extractFrontmatter(string(data)); err == nil {
+		var meta struct {
+			Name string `yaml:"name"`
+		}
+		if yaml.Unmarshal([]byte(frontmatter), &meta) == nil && meta.Name != "" {
+			return convertImportedAgent(name, data)
+		}
+	}
+	return convertImportedCommand(nil, name, data)
+}
+
+// convertImportedAgent converts a Claude Code agent file. Its frontmatter supplies
+// the name, description, tools, and model, the file name and first paragraph stand
+// in for a missing name and description, and the category is guessed from the name.
+// The file itself becomes the module's asset.
+func convertImportedAgent(fileName string, data []byte) (importedModule, error) {
+	var meta struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Tools       any    `yaml:"tools"`
+		Model       string `yaml:"model"`
+	}
+	frontmatter, body, err := splitImportedFile(data, &meta)
+	if err != nil {
+		return importedModule{}, err
+	}
+	name := importedName(cmp.Or(meta.Name, strings.TrimSuffix(fileName, ".md")))
+	if err := generation.ValidateSubagentName(name); err != nil {
+		return importedModule{}, fmt.Errorf("agent name %q: %w", name, err)
+	}
+	description := cmp.Or(strings.Join(strings.Fields(meta.Description), " "), firstParagraph(body), "Imported subagent")
+
+	defaults := map[string]any{}
+	if meta.Model != "" {
+		defaults["model"] = meta.Model
+	}
+	if tools := importedToolList(meta.Tools); len(tools) > 0 {
+		defaults["tools"] = tools
+	}
+	asset := string(data)
+	if frontmatter {
+		asset = setFrontmatterField(asset, "name", name)
+	} else {
+		asset = "---\nname: " + name + "\ndescription: " + strconv.Quote(description) + "\n---\n\n" + strings.TrimSpace(asset) + "\n"
+	}
+	return newImportedModule(ModuleDefinition{
+		Name:       name,
+		Type:       string(TypeSubagent),
+		Category:   generation.CategoryForAgentName(name),
+		AssetPaths: []string{"agents/" + name + ".md"},
+		Defaults:   defaults,
+	}, "## "+strings.Title(strings.ReplaceAll(name, "-", " "))+"\n**"+description+"**", asset)
+}
+
+// convertImportedCommand converts a Claude Code command file in the namespace the
+// directories name. Its frontmatter supplies the description, allowed tools, and
+// arguments, read from the argument hint; the prompt becomes the module's template.
+func convertImportedCommand(namespace []string, fileName string, data []byte) (importedModule, error) {
+	var meta struct {
+		Description  string `yaml:"description"`
+		ArgumentHint string `yaml:"argument-hint"`
+		AllowedTools any    `yaml:"allowed-tools"`
+	}
+	_, body, err := splitImportedFile(data, &meta)
+	if err != nil {
+		return importedModule{}, err
+	}
+	name := importedName(strings.TrimSuffix(fileName, ".md"))
+	if err := generation.ValidateSubagentName(name); err != nil {
+		return importedModule{}, fmt.Errorf("command name %q: %w", name, err)
+	}
+	if strings.TrimSpace(body) == "" {
+		return importedModule{}, fmt.Errorf("command has no prompt")
+	}
+	description := cmp.Or(strings.Join(strings.Fields(meta.Description), " "), firstParagraph(body), "Imported command")
+
+	defaults := map[string]any{}
+	if tools := importedToolList(meta.AllowedTools); len(tools) > 0 {
+		defaults["allowed_tools"] = tools
+	}
+	if args := importedArguments(meta.ArgumentHint); len(args) > 0 {
+		defaults["arguments"] = args
+	}
+	invocation := "/" + name
+	if len(namespace) > 0 {
+		ns := strings.Join(namespace, "/")
+		if !schema.CommandNamespace.MatchString(ns) {
+			return importedModule{}, fmt.Errorf("namespace %q: use lowercase letters, digits, hyphens, and underscores", ns)
+		}
+		defaults["namespace"] = ns
+		invocation = "/" + strings.Join(namespace, ":") + ":" + name
+	}
+	return newImportedModule(ModuleDefinition{
+		Name:       name,
+		Type:       string(TypeCommand),
+		AssetPaths: []string{"templates/" + name + ".md"},
+		Defaults:   defaults,
+	}, "## "+invocation+"\n**"+strings.TrimSuffix(description, ".")+".**", strings.TrimSpace(body)+"\n")
+}
+
+// newImportedModule completes def as an enabled module at version 1.0.0, and renders
+// it with description as its body. The module file is parsed back, so an import
+// that would not load is refused.
+func newImportedModule(def ModuleDefinition, description, asset string) (importedModule, error) {
+	def.Enabled = true
+	def.DisplayName = def.Name
+	def.Version = "1.0.0"
+	frontmatter, err := yaml.Marshal(def)
+	if err != nil {
+		return importedModule{}, err
+	}
+	definition := "---\n" + string(frontmatter) + "---\n\n" + description + "\n"
+	if _, err := parseMarkdownModule(def.Name+".md", []byte(definition)); err != nil {
+		return importedModule{}, err
+	}
+	return importedModule{
+		Type:       ModuleComponentType(def.Type),
+		Name:       def.Name,
+		Definition: []byte(definition),
+		AssetPath:  def.AssetPaths[0],
+		Asset:      []byte(asset),
+	}, nil
+}
+
+// splitImportedFile decodes the frontmatter of an agent or command file into meta
+// and returns the body after it. A file without frontmatter is all body.
+func splitImportedFile(data []byte, meta any) (hasFrontmatter bool, body string, err error) {
+	frontmatter, body, err := extractFrontmatter(string(data))
+	if errors.Is(err, ErrMissingDelimiters) {
+		return false, string(data), nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	if err := yaml.Unmarshal([]byte(frontmatter), meta); err != nil {
+		return false, "", fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	return true, body, nil
+}
+
+// importedName turns a name from another repository into a module name: lowercase
+// letters and digits, with a hyphen for each run of anything else.
+func importedName(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// importedToolList reads a tools or allowed-tools value, a list or a comma-separated
+// string. Commas inside a rule's parentheses do not separate tools.
+func importedToolList(value any) []any {
+	var tools []any
+	add := func(tool string) {
+		if tool = strings.TrimSpace(tool); tool != "" {
+			tools = append(tools, tool)
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		depth, start := 0, 0
+		for i, r := range v {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth = max(depth-1, 0)
+			case ',':
+				if depth == 0 {
+					add(v[start:i])
+					start = i + 1
+				}
+			}
+		}
+		add(v[start:])
+	case []any:
+		for _, item := range v {
+			if tool, ok := item.(string); ok {
+				add(tool)
+			}
+		}
+	}
+	return tools
+}
+
+// importedArgumentPattern matches one argument of an argument hint: <required> or
+// [optional].
+var importedArgumentPattern = regexp.MustCompile(`<([^<>]+)>|\[([^\[\]]+)\]`)
+
+// importedArguments reads a command's arguments from its argument hint, as in
+// "<issue> [--draft]".
+func importedArguments(hint string) []any {
+	var args []any
+	for _, match := range importedArgumentPattern.FindAllStringSubmatch(hint, -1) {
+		name := strings.TrimSpace(cmp.Or(match[1], match[2]))
+		if name == "" {
+			continue
+		}
+		args = append(args, map[string]any{"name": name, "required": match[1] != ""})
+	}
+	return args
+}
+
+// firstParagraph returns the first paragraph of markdown that is not a heading, on
+// one line, or "" when there is none.
+func firstParagraph(markdown string) string {
+	var lines []string
+	for line := range strings.Lines(markdown) {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" && len(lines) > 0:
+			return strings.Join(lines, " ")
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "<!--"):
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func main() {
+	os.Exit(runMain())
+}
+
+func runMain() int {
+	// Global flags apply to every subcommand, so they are taken out before any parsing
+	global, args, err := extractGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitUsage
+	}
+	os.Args = append(os.Args[:1], args...)
+	closeLog, err := logging.Setup(logging.Stderr, global.log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	defer closeLog()
+	slog.Debug("claudekit starting", "version", Version, "args", strings.Join(os.Args[1:], " "))
+	if global.config != "" {
+		os.Setenv(envPersistenceFile, global.config)
+	}
+	if global.noColor {
+		os.Setenv("NO_COLOR", "1")
+	}
+
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	cmd, args, ok := resolveCommand(args)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "claudekit: unknown command %q\nRun 'claudekit help' for the list of commands.\n", strings.Join(strings.Fields(cmd.Name), " "))
+		return exitUsage
+	}
+
+	// Initialize module registry (Feature 004). Loading runs in the background so the
+	// form paints immediately; subcommands wait for it. Asset generation works from
+	// the modules and templates as embedded, without user modules or overrides.
+	var moduleDirs, templateDirs, overridePaths []string
+	if !cmd.EmbeddedOnly {
+		moduleDirs = userModuleDirs()
+		templateDirs = templateOverrideDirs()
+		overridePaths = moduleOverridePaths()
+	}
+	loader := loadRegistryAsync(assets, moduleDirs, templateDirs, overridePaths...)
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		// Commands print their usage as their flags' -h does; asking for it is no error
+		cmd.Run(args[:1], loader)
+		return exitOK
+	}
+	return cmd.Run(args, loader)
+}
+
+// runInitCommand implements `claudekit [init] [flags]`: the interactive form, which
+// generates the configuration once it is confirmed.
+func runInitCommand(args []string, loader *registryLoader) int {
+	return runSetup("init", args, loader)
+}
+
+// runApplyCommand implements `claudekit apply [flags]`: the configuration the form
+// would start from, generated without opening it.
+func runApplyCommand(args []string, loader *registryLoader) int {
+	return runSetup("apply", args, loader)
+}
+
+// runSetup generates the configuration from saved choices and defaults, through
+// the form unless command is apply or no terminal can show it.
+func runSetup(command string, args []string, loader *registryLoader) int {
+	apply := command == "apply"
+	opts, err := parseSetupFlags(command, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	// Get current directory name for project name default
+	currentDir, err := os.Getwd()
+	dirName := "awesome-app" // default fallback
+	if err == nil {
+		baseName := filepath.Base(currentDir)
+		if baseName != "." && baseName != "/" && baseName != "" {
+			dirName = baseName
+		}
+	}
+
+	// Load previous choices from persistence file
+	persistedConfig, err := loadPersistenceConfig()
+	if err != nil {
+		slog.Warn("failed to load previous choices", "err", err)
+		persistedConfig = &PersistenceConfig{}
+	}
+	// A project configured before is read back from its .claude directory, which
+	// stays accurate when the saved choices belong to another project
+	if info, err := os.Stat(filepath.Join(currentDir, ".claude")); err == nil && info.IsDir() {
+		for _, err := range projectSchemaErrors(currentDir) {
+			slog.Warn(err.Error())
+		}
+		choices := projectChoices(currentDir, *persistedConfig, loader.Registry())
+		persistedConfig = &choices
+	}
+
+	// Initialize config with defaults, then override with persisted values
+	cfg := Config{
+		IsProjectLocal: true,  // Default to project-specific
+		ProjectName:    dirName, // Set directory name as default
+		Languages:      []string{"Go"},
+		Subagents:      []string{"code-reviewer", "test-runner", "bug-sleuth"},
+		Hooks:          []string{"session-start", "pre-tool-use", "post-tool-use"},
+		SlashCommands:  []string{"example", "fix-github-issue"},
+		MCPServers:     []string{"notion", "linear", "sentry", "github"},
+		Permissions:    []string{defaultPermissionPreset},
+		SetupDoc:       true,
+	}
+	
+	// Override with persisted choices if they exist
+	if len(persistedConfig.Languages) > 0 {
+		cfg.Languages = persistedConfig.Languages
+	}
+	if len(persistedConfig.Frameworks) > 0 {
+		cfg.Frameworks = persistedConfig.Frameworks
+	}
+	if len(persistedConfig.Subagents) > 0 {
+		cfg.Subagents = persistedConfig.Subagents
+	}
+	if len(persistedConfig.Hooks) > 0 {
+		cfg.Hooks = persistedConfig.Hooks
+	}
+	if len(persistedConfig.SlashCommands) > 0 {
+		cfg.SlashCommands = persistedConfig.SlashCommands
+	}
+	if len(persistedConfig.MCPServers) > 0 {
+		cfg.MCPServers = persistedConfig.MCPServers
+	}
+	if len(persistedConfig.Permissions) > 0 {
+		cfg.Permissions = persistedConfig.Permissions
+	}
+	if persistedConfig.ClaudeMDExtras != "" {
+		cfg.ClaudeMDExtras = persistedConfig.ClaudeMDExtras
+	}
+	cfg.OutputStyle = persistedConfig.OutputStyle
+	cfg.Statusline = persistedConfig.Statusline
+	cfg.EditorTasks = persistedConfig.EditorTasks
+	cfg.Devcontainer = persistedConfig.Devcontainer
+	cfg.MemoryFiles = persistedConfig.MemoryFiles
+	cfg.Gitignore = persistedConfig.Gitignore
+	cfg.Workflows = persistedConfig.Workflows
+	if opts.githubWorkflows != nil {
+		cfg.Workflows = opts.githubWorkflows
+	}
+	packages := detectWorkspacePackages(currentDir)
+	cfg.Packages = selectedPackages(persistedConfig.Packages, packages)
+	cfg.CustomSubagents = persistedConfig.CustomSubagents
+	cfg.HookLanguages = persistedConfig.HookLanguages
+	if opts.hookLanguages != nil {
+		cfg.HookLanguages = opts.hookLanguages
+	}
+	cfg.Layout = persistedConfig.Layout
+	cfg.Theme = resolveTheme(opts.theme, os.Getenv(envTheme), persistedConfig.Theme)
+	cfg.SplitRatio = persistedConfig.SplitRatio
+	cfg.IncludeDisabled = persistedConfig.IncludeDisabled || opts.includeDisabled
+	cfg.ProbeMCP = opts.probeMCP
+	cfg.NoFmt = opts.noFmt
+	cfg.SlackWebhookURL = persistedConfig.SlackWebhookURL
+	cfg.DiscordWebhookURL = persistedConfig.DiscordWebhookURL
+	cfg.PermissionRules = persistedConfig.PermissionRules
+	cfg.CustomPermissionRules = persistedConfig.CustomPermissionRules
+	cfg.CommandTools = persistedConfig.CommandTools
+	cfg.AgentModels = persistedConfig.AgentModels
+	cfg.AgentTools = persistedConfig.AgentTools
+	cfg.HookMatchers = persistedConfig.HookMatchers
+	cfg.HookCommands = persistedConfig.HookCommands
+	cfg.AssetResolutions = persistedConfig.AssetResolutions
+	cfg.MCPUserScope = persistedConfig.MCPUserScope
+	// Always use persisted boolean and project name if available
+	if persistedConfig.ProjectName != "" {
+		cfg.IsProjectLocal = persistedConfig.IsProjectLocal
+		cfg.SetupDoc = persistedConfig.SetupDoc
+		cfg.SetupReadme = persistedConfig.SetupReadme
+		// Only override project name if it's not the current directory default
+		if persistedConfig.ProjectName != dirName {
+			cfg.ProjectName = persistedConfig.ProjectName
+		}
+	}
+	cfg.Env = initialEnv(cfg.IsProjectLocal, persistedConfig.Env, opts.env)
+
+	if apply {
+		fmt.Fprint(os.Stderr, describeHeadlessPlan(cfg))
+		cfg.Confirmed = true
+		return applyConfiguration(cfg, persistedConfig, loader.Registry())
+	}
+
+	// CI logs and pipes cannot drive the alt-screen form
+	headlessReason := ""
+	if opts.headless {
+		headlessReason = "--headless was given"
+	} else if !opts.interactive {
+		headlessReason = detectHeadless(os.Getenv, isTerminal(os.Stdin), isTerminal(os.Stdout))
+	}
+	if headlessReason != "" {
+		return runHeadless(cfg, persistedConfig, loader.Registry(), headlessReason, opts.yes)
+	}
+
+	// Filled in by the optional custom subagent page
+	var createSubagent bool
+	var newSubagent generation.CustomSubagent
+
+	// Create Bubble Tea model with form (T029: initialize gradient system)
+	termCap := gradient.DetectTerminalCapability()
+	if opts.forceCapability != nil {
+		termCap = *opts.forceCapability
+	}
+	if opts.forceCapability != nil || termCap == gradient.NoColor {
+		lipgloss.SetColorProfile(capabilityProfile(termCap))
+	}
+	// Gradients pick light or dark colors by the background. Ask the terminal now,
+	// before Bubble Tea reads stdin; lipgloss remembers the answer.
+	lipgloss.HasDarkBackground()
+	opts.noAnimation = opts.noAnimation || reducedMotion(os.Getenv(envReducedMotion))
+	m := newModel(newSetupForm(&cfg, loader, currentDir, packages, &createSubagent, &newSubagent), &cfg, loader, termCap, opts).
+		withPages(setupPages(&cfg, loader, packages, &createSubagent))
+
+	// The form writes the configuration itself once it completes, listing the files
+	m.generate = func(callbacks generationCallbacks) int {
+		cfg.callbacks = callbacks
+		// Register the newly authored subagent and select it
+		if createSubagent && newSubagent.Name != "" {
+			cfg.CustomSubagents = addCustomSubagent(cfg.CustomSubagents, newSubagent)
+			if !slices.Contains(cfg.Subagents, newSubagent.Name) {
+				cfg.Subagents = append(cfg.Subagents, newSubagent.Name)
+			}
+		}
+		return applyConfiguration(cfg, persistedConfig, loader.Registry())
+	}
+
+	// Run the Bubble Tea application
+	output := &programOutput{File: os.Stdout}
+	m.output = output
+	programOptions := []tea.ProgramOption{tea.WithAltScreen(), tea.WithOutput(output)}
+	if !opts.noMouse {
+		programOptions = append(programOptions, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(m, programOptions...)
+	finalModel, err := p.Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error running application: %v\n", err)
+		return exitFailure
+	}
+
+	// Check if user cancelled
+	final, ok := finalModel.(model)
+	if !ok || final.form.State != huh.StateCompleted || final.generating == nil {
+		fmt.Fprintf(os.Stderr, "cancelled\n")
+		return exitCancelled
+	}
+
+	// The alternate screen is gone; keep generation's messages in the terminal
+	return final.generating.replay()
+}
+
+// newSetupForm builds the interactive setup form. Answers are written to cfg; the
+// custom subagent page fills in newSubagent when createSubagent is chosen.
+func newSetupForm(cfg *Config, loader *registryLoader, currentDir string, packages []workspace.Package, createSubagent *bool, newSubagent *generation.CustomSubagent) *huh.Form {
+	return huh.NewForm(
+		// Page 1: Project Setup
+		huh.NewGroup(
+			huh.NewNote().Title("📁 Project Setup").Description("Configure your project basics and language support"),
+			huh.NewInput().
+				Key("project-name").
+				Title("Project name").
+				Description("Used in generated documentation and configurations").
+				Value(&cfg.ProjectName),
+			huh.NewConfirm().
+				Key("project-local").
+				Title("Project-specific configuration?").
+				Description("Yes = Configure for this project only\nNo = Global configuration in your home directory").
+				Value(&cfg.IsProjectLocal),
+			newFilterMultiSelect("languages", &cfg.Languages).
+				Title("Primary languages").
+				Description("Select all languages used in your project for optimized defaults").
+				Options(huh.NewOptions(
+					"Go", "TypeScript", "Python", "Java", "Rust", "C++", "C#", 
+					"PHP", "Ruby", "Swift", "Kotlin", "Dart", "Shell", "Lua",
+					"Elixir", "Haskell", "Elm", "Julia", "SQL", "Arduino", 
+					"Scheme", "Lisp")...).
+				Height(8),
+		),
+		
+		// Page 2: Appearance (each theme is previewed as the cursor reaches it)
+		huh.NewGroup(
+			huh.NewNote().Title("🖌️ Appearance").Description("Choose the colors claudekit is drawn in and the modules it offers"),
+			huh.NewSelect[string]().
+				Key("theme").
+				Title("Theme").
+				Description("Previewed as you move the cursor and remembered for future runs; --theme and CLAUDEKIT_THEME override it").
+				Options(huh.NewOptions(gradient.ThemeNames()...)...).
+				Value(&cfg.Theme),
+			huh.NewConfirm().
+				Key("include-disabled").
+				Title("Show experimental and disabled modules?").
+				Description("Offer modules their authors have switched off; they are marked (disabled)").
+				Value(&cfg.IncludeDisabled),
+		),
+
+		// Page 3: Frameworks (detected ones are preselected unless choices were persisted)
+		huh.NewGroup(
+			huh.NewNote().Title("🧩 Frameworks").Description("Add framework-specific guidance to CLAUDE.md"),
+			newFilterMultiSelect("frameworks", &cfg.Frameworks).
+				Title("Frameworks in use").
+				Description("Test commands, lint tools, and directory conventions for each framework").
+				OptionsFunc(frameworkOptions(loader, currentDir, len(cfg.Frameworks) == 0, &cfg.IncludeDisabled), &cfg.IncludeDisabled),
+		),
+
+		// Page 4: Workspace Packages (only in a monorepo, for a project configuration)
+		huh.NewGroup(
+			huh.NewNote().Title("📦 Workspace Packages").Description("Give packages of this workspace their own .claude/ configuration"),
+			huh.NewMultiSelect[string]().
+				Key("packages").
+				Title("Packages to configure").
+				Description("Each inherits the choices below; languages and frameworks are detected per package").
+				Options(packageOptions(packages, cfg.Packages)...).
+				Value(&cfg.Packages),
+		).WithHideFunc(workspacePageHidden(cfg, packages)),
+
+		// Page 5: Subagent Selection
+		huh.NewGroup(
+			huh.NewNote().Title("🤖 Subagent Configuration").Description("Choose specialized AI assistants for your development workflow"),
+			newFilterMultiSelect("subagents", &cfg.Subagents).
+				Title("Select subagents to include").
+				Description("Choose the AI specialists you want available for your project").
+				Grouped(loader.Category(TypeSubagent)).
+				OptionsFunc(subagentOptions(loader, cfg.CustomSubagents, &cfg.IncludeDisabled), &cfg.IncludeDisabled),
+			huh.NewConfirm().
+				Key("create-subagent").
+				Title("Create a custom subagent?").
+				Description("Write your own subagent on the next page; it is saved for future runs").
+				Value(createSubagent),
+		),
+
+		// Page 6: Custom Subagent (only when requested on the previous page)
+		huh.NewGroup(
+			huh.NewNote().Title("✏️ Custom Subagent").Description("Describe a new specialist; it is added to the selected subagents"),
+			huh.NewInput().
+				Key("custom-subagent-name").
+				Title("Name").
+				Description("Lowercase with hyphens, e.g. api-designer").
+				Validate(func(name string) error {
+					return validateCustomSubagentName(name, loader)
+				}).
+				Value(&newSubagent.Name),
+			huh.NewInput().
+				Key("custom-subagent-description").
+				Title("Description").
+				Description("When Claude should delegate to this subagent").
+				Validate(func(desc string) error {
+					if strings.TrimSpace(desc) == "" {
+						return errors.New("description is required")
+					}
+					return nil
+				}).
+				Value(&newSubagent.Description),
+			newFilterMultiSelect("custom-subagent-tools", &newSubagent.Tools).
+				Title("Tools").
+				Description("Leave empty to allow every tool").
+				Options(huh.NewOptions(generation.SubagentTools...)...).
+				Height(8),
+			huh.NewSelect[string]().
+				Key("custom-subagent-model").
+				Title("Model").
+				Description("sonnet suits most work; opus reasons harder, haiku is fastest").
+				Options(append([]huh.Option[string]{huh.NewOption("Inherit the conversation's model", "")}, huh.NewOptions(schema.SubagentModels[:3]...)...)...).
+				Value(&newSubagent.Model),
+			huh.NewText().
+				Key("custom-subagent-instructions").
+				Title("Instructions").
+				Description("The subagent's system prompt: role, workflow, and output format").
+				Value(&newSubagent.Instructions),
+		).WithHideFunc(func() bool { return !*createSubagent }),
+
+		// Page 7: Agent Models (only when a subagent is selected)
+		huh.NewGroup(
+			huh.NewNote().Title("🧠 Agent Models").DescriptionFunc(agentModelUsage(cfg, loader), &cfg.Subagents),
+			huh.NewText().
+				Key("agent-models").
+				Title("Model overrides").
+				Description("Run a subagent on another model with a line like code-reviewer: opus; unlisted agents keep theirs").
+				Placeholder("test-runner: sonnet").
+				Validate(validateAgentModelLines).
+				Value(&cfg.AgentModels),
+		).WithHideFunc(agentModelsHidden(cfg)),
+
+		// Page 8: Agent Tools (only when a subagent is selected)
+		huh.NewGroup(
+			newToolMatrix("agent-tools", cfg, loader),
+		).WithHideFunc(agentModelsHidden(cfg)),
+		
+		// Page 9: Hook Configuration
+		huh.NewGroup(
+			huh.NewNote().Title("🪝 Hook Setup").Description("Configure automation and lifecycle scripts"),
+			newFilterMultiSelect("hooks", &cfg.Hooks).
+				Title("Select hooks to enable").
+				Description("Automation scripts that run at specific points in your workflow").
+				OptionsFunc(loader.Options(TypeHook, &cfg.IncludeDisabled), &cfg.IncludeDisabled),
+		),
+
+		// Page 10: Hook Matchers (only when a selected hook runs on tool calls)
+		huh.NewGroup(
+			huh.NewNote().Title("🎯 Hook Matchers").DescriptionFunc(hookMatcherUsage(cfg, loader), &cfg.Hooks),
+			huh.NewText().
+				Key("hook-matchers").
+				Title("Matcher overrides").
+				Description("Fire a hook on other tools with a line like post-tool-use: Write|Edit, or * for every tool; unlisted hooks keep theirs").
+				Placeholder("post-tool-use: Write|Edit|MultiEdit").
+				Validate(validateHookMatcherLines).
+				Value(&cfg.HookMatchers),
+		).WithHideFunc(hookMatchersHidden(cfg, loader)),
+
+		// Page 11: Hook Commands (only when a selected hook runs several commands)
+		huh.NewGroup(
+			huh.NewNote().Title("🧩 Hook Commands").DescriptionFunc(hookCommandUsage(cfg, loader), &cfg.Hooks),
+			huh.NewText().
+				Key("hook-commands").
+				Title("Commands to run").
+				Description("Reorder a hook's commands, or leave some out, with a line like post-tool-use: test, format; unlisted hooks run all of theirs").
+				Placeholder("post-tool-use: format, lint").
+				Validate(validateHookCommandLines).
+				Value(&cfg.HookCommands),
+		).WithHideFunc(hookCommandsHidden(cfg, loader)),
+
+		// Page 12: Notifications (only when a hook that posts to a webhook is selected)
+		huh.NewGroup(
+			huh.NewNote().Title("🔔 Notifications").Description("Where the notification hooks post; leave a URL empty to read it from your environment"),
+			huh.NewInput().
+				Key("slack-webhook").
+				Title("Slack webhook URL").
+				Description("For notify-slack: https://hooks.slack.com/services/...").
+				Validate(validateWebhookURL).
+				Value(&cfg.SlackWebhookURL),
+			huh.NewInput().
+				Key("discord-webhook").
+				Title("Discord webhook URL").
+				Description("For notify-discord: https://discord.com/api/webhooks/...").
+				Validate(validateWebhookURL).
+				Value(&cfg.DiscordWebhookURL),
+		).WithHideFunc(notificationsPageHidden(cfg, loader)),
+		
+		// Page 13: Slash Commands
+		huh.NewGroup(
+			huh.NewNote().Title("⚡ Custom Commands").Description("Add powerful slash commands for common development tasks"),
+			newFilterMultiSelect("slash-commands", &cfg.SlashCommands).
+				Title("Select custom slash commands").
+				Description("Choose useful commands for common development tasks").
+				Grouped(loader.Category(TypeCommand)).
+				OptionsFunc(loader.Options(TypeCommand, &cfg.IncludeDisabled), &cfg.IncludeDisabled),
+		),
+		
+		// Page 14: Command Parameters (only when a selected command declares arguments or tools)
+		huh.NewGroup(
+			huh.NewNote().Title("🧾 Command Parameters").DescriptionFunc(commandUsage(cfg, loader), &cfg.SlashCommands),
+			huh.NewText().
+				Key("command-tools").
+				Title("Allowed tools").
+				Description("Override a command's tools with a line like fix-github-issue: Read, Bash(gh issue view:*); unlisted commands keep theirs").
+				Placeholder("add-tests: Read, Write, Bash(go test:*)").
+				Validate(validateCommandToolLines).
+				Value(&cfg.CommandTools),
+		).WithHideFunc(commandParametersHidden(cfg, loader)),
+
+		// Page 15: MCP Configuration
+		huh.NewGroup(
+			huh.NewNote().Title("🔌 MCP Integration").Description("Connect to external tools and services via Model Context Protocol"),
+			newFilterMultiSelect("mcp-servers", &cfg.MCPServers).
+				Title("Select MCP servers to include").
+				Description("Choose external tool integrations to enhance Claude's capabilities (optional)").
+				OptionsFunc(loader.Options(TypeMCP, &cfg.IncludeDisabled), &cfg.IncludeDisabled),
+			huh.NewMultiSelect[string]().
+				Key("mcp-user-scope").
+				Title("Register for your user instead").
+				Description("Selected servers are available in every project and stay out of .mcp.json").
+				OptionsFunc(mcpScopeOptions(cfg, loader), &cfg.MCPServers).
+				Value(&cfg.MCPUserScope),
+		),
+		
+		// Page 16: Permissions
+		huh.NewGroup(
+			huh.NewNote().Title("🛡️ Permissions").Description("Choose what Claude Code may do without asking"),
+			newFilterMultiSelect("permissions", &cfg.Permissions).
+				Title("Select permission presets").
+				Description("Presets are merged; when they disagree, deny beats ask and ask beats allow").
+				OptionsFunc(loader.Options(TypePermissions, &cfg.IncludeDisabled), &cfg.IncludeDisabled),
+		),
+
+		// Page 17: Permission Rules (seeded from the presets and the existing settings.json)
+		huh.NewGroup(
+			huh.NewNote().Title("📜 Permission Rules").Description("Review the rules settings.json will get, and add your own"),
+			huh.NewMultiSelect[string]().
+				Key("permission-rules").
+				Title("Rules").
+				Description("Deselect a rule to leave it out").
+				OptionsFunc(newPermissionRuleEditor(cfg, loader).options, []any{&cfg.Permissions, &cfg.IsProjectLocal}).
+				Value(&cfg.PermissionRules),
+			huh.NewText().
+				Key("permission-custom").
+				Title("More rules").
+				Description("One per line: allow, ask, or deny, then the rule, as in ask Bash(git push:*)").
+				Placeholder("allow Bash(npm test:*)\ndeny Read(./.env.production)").
+				Validate(validatePermissionLines).
+				Value(&cfg.CustomPermissionRules),
+		),
+
+		// Page 18: Environment (pre-filled from the existing settings.json)
+		huh.NewGroup(
+			huh.NewNote().Title("🌱 Environment").Description("Variables Claude Code sets for every session, written to settings.json's env"),
+			huh.NewText().
+				Key("env").
+				Title("Environment variables").
+				Description("One KEY=VALUE per line; leave empty for the defaults").
+				Placeholder(defaultEnv).
+				Validate(validateEnvLines).
+				Value(&cfg.Env),
+		),
+
+		// Page 19: Output Style
+		huh.NewGroup(
+			huh.NewNote().Title("🎨 Output Style").Description("Choose how Claude Code formats its responses"),
+			huh.NewSelect[string]().
+				Key("output-style").
+				Title("Output style").
+				Description("Built-in styles ship with Claude Code; custom styles are generated into .claude/output-styles/").
+				OptionsFunc(styleOptions(loader, &cfg.IncludeDisabled), &cfg.IncludeDisabled).
+				Value(&cfg.OutputStyle),
+		),
+
+		// Page 20: Statusline
+		huh.NewGroup(
+			huh.NewNote().Title("📊 Statusline").Description("Choose what Claude Code shows below the prompt"),
+			huh.NewSelect[string]().
+				Key("statusline").
+				Title("Statusline").
+				Description("Generates .claude/statusline.sh and points settings.json at it").
+				OptionsFunc(statuslineOptions(loader, &cfg.IncludeDisabled), &cfg.IncludeDisabled).
+				Value(&cfg.Statusline),
+		),
+
+		// Page 21: Final Configuration
+		huh.NewGroup(
+			huh.NewNote().Title("📝 Final Setup").Description("Add custom instructions and complete your configuration"),
+			huh.NewText().
+				Key("claude-md-extras").
+				Title("Extra CLAUDE.md content (optional)").
+				Description("Project-specific instructions to include in CLAUDE.md").
+				Value(&cfg.ClaudeMDExtras),
+			huh.NewConfirm().
+				Key("setup-doc").
+				Title("Document the setup for teammates?").
+				Description("Writes docs/CLAUDE-SETUP.md listing the installed agents, commands, hooks, and MCP servers (project configurations only)").
+				Value(&cfg.SetupDoc),
+			huh.NewConfirm().
+				Key("setup-readme").
+				Title("Add the same tables to README.md?").
+				Description("Keeps a \"Claude Code Setup\" section at the end of README.md up to date; the rest of the README is left alone (project configurations only)").
+				Value(&cfg.SetupReadme),
+			huh.NewMultiSelect[string]().
+				Key("memory-files").
+				Title("Seed personal and memory files?").
+				Description("CLAUDE.local.md holds your own instructions and is added to .gitignore; .claude/memory/ holds the team's decisions, conventions, and glossary, which CLAUDE.md imports. Both are written once and then left to you (project configurations only)").
+				Options(
+					huh.NewOption("CLAUDE.local.md (personal, gitignored)", memoryLocal),
+					huh.NewOption(".claude/memory/ notes (shared)", memorySeeds),
+				).
+				Value(&cfg.MemoryFiles),
+			huh.NewConfirm().
+				Key("gitignore").
+				Title("Keep local files out of git?").
+				Description("Adds .claude/settings.local.json, the usage log, and hook logs to a marked block of .gitignore, leaving out lines it already has (project configurations only)").
+				Value(&cfg.Gitignore),
+		),
+
+		// Page 22: Integrations (project configurations only)
+		huh.NewGroup(
+			huh.NewNote().Title("🔗 Integrations").Description("Bring the setup to your editor, CI, and dev container"),
+			huh.NewMultiSelect[string]().
+				Key("editor-tasks").
+				Title("Generate editor tasks?").
+				Description("Makes the shell workflows behind the selected slash commands, such as running tests or auditing dependencies, runnable from your editor").
+				Options(
+					huh.NewOption("VS Code tasks (.vscode/tasks.json)", editorVSCode),
+					huh.NewOption("JetBrains run configurations (.run/)", editorJetBrains),
+				).
+				Value(&cfg.EditorTasks),
+			huh.NewMultiSelect[string]().
+				Key("github-workflows").
+				Title("Run Claude in GitHub Actions?").
+				Description("Writes .github/workflows/claude.yml for the claude-code GitHub action, delegating to the selected subagents and allowing the tools your permissions allow").
+				Options(
+					huh.NewOption("Review pull requests", githubWorkflowReview),
+					huh.NewOption("Triage new issues", githubWorkflowTriage),
+				).
+				Value(&cfg.Workflows),
+			huh.NewConfirm().
+				Key("devcontainer").
+				Title("Set up Claude Code in the dev container?").
+				Description("Writes .devcontainer/claude-setup.sh, which installs Claude Code and what the hooks need, and a devcontainer.json that runs it unless you have one").
+				Value(&cfg.Devcontainer),
+		).WithHideFunc(integrationsPageHidden(cfg)),
+		
+		// Page 23: Asset Conflicts (only when selected modules declare the same asset)
+		huh.NewGroup(assetConflictFields(cfg, loader)...).WithHideFunc(assetConflictsHidden(cfg, loader)),
+
+		// Page 24: Confirmation
+		huh.NewGroup(
+			huh.NewNote().Title("✅ Confirmation").Description("Review your configuration and confirm to generate Claude Code setup"),
+			huh.NewConfirm().
+				Key(generateConfirmKey).
+				Title("Generate Claude Code configuration?").
+				Description("This will create/update the Claude Code configuration files with your selections.\nReview the configuration summary in the right panel, or press p to preview the generated files.\nPress s or m to copy the generated settings.json or .mcp.json to the clipboard.").
+				Affirmative("Yes, generate configuration").
+				Negative("No, go back to make changes").
+				Value(&cfg.Confirmed),
+		),
+	)
+}
+
+// wizardPage is one page of the setup form as the page menu lists it.
+type wizardPage struct {
+	Title  string
+	Keys   []string    // Keys of the page's focusable fields
+	Hidden func() bool // Nil for pages the form always shows
+}
+
+// setupPages describes the pages of newSetupForm, in order.
+func setupPages(cfg *Config, loader *registryLoader, packages []workspace.Package, createSubagent *bool) []wizardPage {
+	return []wizardPage{
+		{Title: "📁 Project Setup", Keys: []string{"project-name", "project-local", "languages"}},
+		{Title: "🖌️ Appearance", Keys: []string{"theme", "include-disabled"}},
+		{Title: "🧩 Frameworks", Keys: []string{"frameworks"}},
+		{Title: "📦 Workspace Packages", Keys: []string{"packages"}, Hidden: workspacePageHidden(cfg, packages)},
+		{Title: "🤖 Subagents", Keys: []string{"subagents", "create-subagent"}},
+		{
+			Title:  "✏️ Custom Subagent",
+			Keys:   []string{"custom-subagent-name", "custom-subagent-description", "custom-subagent-tools", "custom-subagent-model", "custom-subagent-instructions"},
+			Hidden: func() bool { return !*createSubagent },
+		},
+		{Title: "🧠 Agent Models", Keys: []string{"agent-models"}, Hidden: agentModelsHidden(cfg)},
+		{Title: "🧰 Agent Tools", Keys: []string{"agent-tools"}, Hidden: agentModelsHidden(cfg)},
+		{Title: "🪝 Hooks", Keys: []string{"hooks"}},
+		{Title: "🎯 Hook Matchers", Keys: []string{"hook-matchers"}, Hidden: hookMatchersHidden(cfg, loader)},
+		{Title: "🧩 Hook Commands", Keys: []string{"hook-commands"}, Hidden: hookCommandsHidden(cfg, loader)},
+		{Title: "🔔 Notifications", Keys: []string{"slack-webhook", "discord-webhook"}, Hidden: notificationsPageHidden(cfg, loader)},
+		{Title: "⚡ Slash Commands", Keys: []string{"slash-commands"}},
+		{Title: "🧾 Command Parameters", Keys: []string{"command-tools"}, Hidden: commandParametersHidden(cfg, loader)},
+		{Title: "🔌 MCP Servers", Keys: []string{"mcp-servers", "mcp-user-scope"}},
+		{Title: "🛡️ Permissions", Keys: []string{"permissions"}},
+		{Title: "📜 Permission Rules", Keys: []string{"permission-rules", "permission-custom"}},
+		{Title: "🌱 Environment", Keys: []string{"env"}},
+		{Title: "🎨 Output Style", Keys: []string{"output-style"}},
+		{Title: "📊 Statusline", Keys: []string{"statusline"}},
+		{Title: "📝 Final Setup", Keys: []string{"claude-md-extras", "setup-doc", "setup-readme", "memory-files", "gitignore"}},
+		{Title: "🔗 Integrations", Keys: []string{"editor-tasks", "github-workflows", "devcontainer"}, Hidden: integrationsPageHidden(cfg)},
+		{Title: "⚠️ Asset Conflicts", Keys: []string{assetResolutionKey}, Hidden: assetConflictsHidden(cfg, loader)},
+		{Title: "✅ Confirmation", Keys: []string{generateConfirmKey}},
+	}
+}
+
+// newModel wraps form in the Bubble Tea model that draws the title, the form, and the
+// status panel, rendering gradients for termCap.
+func newModel(form *huh.Form, cfg *Config, loader *registryLoader, termCap gradient.TerminalCapability, opts interactiveOptions) model {
+	// Create custom glamour renderer from palette (Feature 006: T013)
+	styleMap, renderer := themeStyles(cfg.Theme, termCap)
+	primaryTheme := styleMap[gradient.HeaderComponent][gradient.NormalState].Theme
+
+	return model{
+		form:            form,
+		config:          cfg,
+		glamourRenderer: renderer,
+
+		// Gradient system initialization
+		terminalCap:  termCap,
+		currentTheme: primaryTheme,
+		transition: gradient.TransitionState{
+			Active:     false,
+			EasingFunc: gradient.EaseInOutCubic,
+		},
+		styleMap:    styleMap,
+		themeName:   cfg.Theme,
+		noAnimation: opts.noAnimation,
+
+		// Module registry (Feature 004), filled in by registryLoadedMsg
+		registryLoader: loader,
+
+		// Adaptive right panel layout (Feature 007)
+		// showRightPanel will be computed on first WindowSizeMsg
+		showRightPanel:  true, // Default to showing panel (will be adjusted on first resize)
+		resizeDebouncer: nil,
+		pendingResize:   nil,
+		resizeDebounce:  opts.resizeDebounce,
+		formShare:       clampFormShare(cfg.SplitRatio),
+		forcedSize:      opts.forceSize,
+		status:          &statusCache{},
+	}
+}
+
+// applyConfiguration saves the choices in cfg, removes files for deselected items,
+// and generates the configuration. It returns the process exit code.
+func applyConfiguration(cfg Config, persistedConfig *PersistenceConfig, registry *ModuleRegistry) int {
+	// Clean up emoji prefixes from form selections
+	cfg.Subagents = cleanFormValues(cfg.Subagents)
+	cfg.Hooks = cleanFormValues(cfg.Hooks)
+	cfg.MCPServers = cleanFormValues(cfg.MCPServers)
+
+	resolveModules(&cfg, registry)
+	
+	// Save current choices for future runs
+	if err := savePersistenceConfig(cfg); err != nil {
+		slog.Warn("failed to save choices for future runs", "err", err)
+		// Continue execution - this is not a fatal error
+	}
+	
+	// Clean up deselected items before generating new configuration
+	if targetDir, err := resolveTargetDir(cfg.IsProjectLocal); err == nil {
+		if err := cleanupDeselectedItems(fsys.OS{}, cfg, persistedConfig, targetDir); err != nil {
+			slog.Warn("failed to clean up deselected items", "err", err)
+		}
+	}
+	
+	if err := run(cfg, registry); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitCode(err)
+	}
+	if cfg.IsProjectLocal {
+		fmt.Println("\n✅ claudekit finished. Project-specific Claude Code configuration created!")
+		fmt.Println("   Open Claude Code in this directory and start coding!")
+	} else {
+		homeDir, _ := os.UserHomeDir()
+		configPath := filepath.Join(homeDir, ".claude")
+		fmt.Printf("\n✅ claudekit finished. Global Claude Code configuration created!\n")
+		fmt.Printf("   Configuration saved to: %s\n", configPath)
+		fmt.Println("   This configuration will apply to all your Claude Code sessions.")
+	}
+	return exitOK
+}
+
+// resolveModules drops disabled modules from cfg, unless it includes them, and
+// selects the modules its selections depend on.
+func resolveModules(cfg *Config, registry *ModuleRegistry) {
+	// Disabled modules, perhaps saved from a run that offered them, are not generated
+	if !cfg.IncludeDisabled {
+		for _, ref := range registry.DropDisabled(cfg) {
+			slog.Warn("skipping disabled module; use --include-disabled to generate it", "module", ref)
+		}
+	}
+
+	// Selected modules bring in the modules they depend on
+	added, conflicts := registry.ResolveDependencies(cfg)
+	for _, addition := range added {
+		fmt.Printf("🔗 Selected %s, required by %s\n", addition.Module, addition.RequiredBy)
+	}
+	for _, conflict := range conflicts {
+		slog.Warn(conflict)
+	}
+
+	// Modules that declare the same asset keep the modules their resolution picks
+	dropped, renamed, unresolved := registry.ResolveAssetConflicts(cfg)
+	for _, ref := range dropped {
+		fmt.Printf("⏭️  Left out %s to resolve an asset conflict\n", ref)
+	}
+	for _, conflict := range renamed {
+		for _, ref := range conflict.Modules {
+			fmt.Printf("📁 %s reads %s from %s\n", ref, conflict.Path, renamedAssetPath(ref, conflict.Path))
+		}
+	}
+	for _, conflict := range unresolved {
+		slog.Warn("asset conflict; resolve it on the Asset Conflicts page, or with asset_resolutions in your saved choices", "conflict", conflict.String())
+	}
+}
+
+// ============================================================================
+// Stats: summarize the sessions the usage-log hook recorded
+// ============================================================================
+
+// statsToolRows is how many tools the stats table lists; the rest are summed.
+const statsToolRows = 10
+
+// statsSparklineWidth is the most columns a per-day sparkline takes.
+const statsSparklineWidth = 60
+
+var statsHeaderStyle = lipgloss.NewStyle().Bold(true)
+
+// runStatsCommand implements `claudekit stats [--days N] [FILE]` and returns the exit code.
+func runStatsCommand(args []string) int {
+	flags := newCommandFlags("stats")
+	days := flags.Int("days", 30, "summarize the last `N` days")
+	output := outputFormatFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+	if *days < 1 || flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "usage: claudekit stats [--days N] [--output json] [FILE]")
+		return exitUsage
+	}
+
+	path := flags.Arg(0)
+	if path == "" {
+		baseDir, err := resolveTargetDir(true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+		path = filepath.Join(baseDir, ".claude", usage.FileName)
+	}
+	records, skipped, err := usage.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	if skipped > 0 {
+		slog.Warn("skipped unreadable lines", "count", skipped, "file", path)
+	}
+
+	summary := usage.Summarize(records, *days, time.Now())
+	if *output == outputJSON {
+		if err := writeJSON(os.Stdout, struct {
+			File string `json:"file"`
+			usage.Summary
+		}{path, summary}); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	fmt.Print(renderStats(path, summary, len(records) > 0))
+	return exitOK
+}
+
+// renderStats renders a usage summary as totals, per-day sparklines, and a table of
+// the most called tools. logged reports whether the log holds any sessions at all.
+func renderStats(path string, s usage.Summary, logged bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 claudekit stats: %s\n", path)
+	fmt.Fprintf(&b, "   Last %d days (%s – %s)\n\n", len(s.Days), s.Since.Format(time.DateOnly), s.Until.Format(time.DateOnly))
+	if s.Sessions == 0 {
+		b.WriteString("No sessions recorded in this period.\n")
+		if !logged {
+			fmt.Fprintf(&b, "%s\n", doctorFixStyle.Render("Select the usage-log hook to record sessions, then run claudekit stats again."))
+		}
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "  %-10s %-10d %-8s %s\n", "Sessions", s.Sessions, "Time", formatSeconds(s.Seconds))
+	fmt.Fprintf(&b, "  %-10s %-10d %-8s %s in · %s out · %s cached\n", "Prompts", s.Prompts, "Tokens",
+		formatCount(s.Tokens.Input), formatCount(s.Tokens.Output), formatCount(s.Tokens.CacheRead+s.Tokens.CacheCreation))
+	if len(s.Models) > 0 {
+		fmt.Fprintf(&b, "  %-10s %s\n", "Models", strings.Join(s.Models, ", "))
+	}
+
+	sessions := make([]int64, len(s.Days))
+	tokens := make([]int64, len(s.Days))
+	for i, day := range s.Days {
+		sessions[i], tokens[i] = int64(day.Sessions), day.Tokens
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %-18s %s\n", "Sessions per day", usage.Sparkline(usage.Buckets(sessions, statsSparklineWidth)))
+	fmt.Fprintf(&b, "  %-18s %s\n", "Tokens per day", usage.Sparkline(usage.Buckets(tokens, statsSparklineWidth)))
+
+	if len(s.Tools) > 0 {
+		var total int
+		for _, tool := range s.Tools {
+			total += tool.Calls
+		}
+		rows := s.Tools
+		if len(rows) > statsToolRows {
+			other := usage.ToolCount{Name: fmt.Sprintf("%d others", len(rows)-statsToolRows+1)}
+			for _, tool := range rows[statsToolRows-1:] {
+				other.Calls += tool.Calls
+			}
+			rows = append(slices.Clone(rows[:statsToolRows-1]), other)
+		}
+		tbl := table.New().
+			Border(lipgloss.RoundedBorder()).
+			Headers("Tool", "Calls", "Share", "").
+			StyleFunc(func(row, col int) lipgloss.Style {
+				style := lipgloss.NewStyle().Padding(0, 1)
+				if row == table.HeaderRow {
+					return style.Inherit(statsHeaderStyle)
+				}
+				if col == 1 || col == 2 {
+					return style.Align(lipgloss.Right)
+				}
+				return style
+			})
+		for _, tool := range rows {
+			share := float64(tool.Calls) / float64(total)
+			tbl.Row(tool.Name, strconv.Itoa(tool.Calls), fmt.Sprintf("%.0f%%", share*100), strings.Repeat("█", max(1, int(share*20+0.5))))
+		}
+		fmt.Fprintf(&b, "\n%s\n", tbl.Render())
+	}
+	return b.String()
+}
+
+// formatCount abbreviates large counts: 950, 12.3k, 4.5M.
+func formatCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return strconv.FormatFloat(float64(n)/1e6, 'f', 1, 64) + "M"
+	case n >= 1_000:
+		return strconv.FormatFloat(float64(n)/1e3, 'f', 1, 64) + "k"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// formatSeconds renders a duration in hours and minutes, or seconds when shorter.
+func formatSeconds(seconds int64) string {
+	switch {
+	case seconds >= 3600:
+		return fmt.Sprintf("%dh %dm", seconds/3600, seconds%3600/60)
+	case seconds >= 60:
+		return fmt.Sprintf("%dm", seconds/60)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
+
+// ============================================================================
+// Bundles: share a configuration with a team
+// ============================================================================
+
+// bundleTemplatePrefix is the directory holding template overrides inside a bundle.
+const bundleTemplatePrefix = "templates/"
+
+// bundleChoices drops the parts of saved choices that belong to one person and
+// one run rather than to the team's setup.
+func bundleChoices(choices PersistenceConfig) PersistenceConfig {
+	choices.LastUpdated = time.Time{}
+	choices.Theme = ""
+	choices.SplitRatio = 0
+	choices.SlackWebhookURL = "" // Credentials stay with their owner
+	choices.DiscordWebhookURL = ""
+	choices.Env = withoutSecretEnv(choices.Env)
+	return choices
+}
+
+// lockedModules pins every registry module the choices select. Custom subagents
+// travel in the choices themselves, and names without a module are generated by
+// claudekit's own code, which the bundle's generator version covers.
+func lockedModules(registry *ModuleRegistry, choices PersistenceConfig) ([]bundle.Module, error) {
+	var modules []bundle.Module
+	for _, group := range []struct {
+		componentType ModuleComponentType
+		names         []string
+	}{
+		{TypeFramework, choices.Frameworks},
+		{TypeSubagent, choices.Subagents},
+		{TypeHook, choices.Hooks},
+		{TypeCommand, choices.SlashCommands},
+		{TypeMCP, choices.MCPServers},
+		{TypePermissions, choices.Permissions},
+		{TypeStyle, []string{choices.OutputStyle}},
+		{TypeStatusline, []string{choices.Statusline}},
+	} {
+		for _, name := range group.names {
+			module := registry.Get(group.componentType, name)
+			if module == nil {
+				continue
+			}
+			sum, err := moduleChecksum(module)
+			if err != nil {
+				return nil, err
+			}
+			modules = append(modules, bundle.Module{Type: string(group.componentType), Name: name, SHA256: sum})
+		}
+	}
+	return modules, nil
+}
+
+// moduleChecksum hashes what a module contributes to generated files: its defaults
+// and the content of its assets. Display names and descriptions are left out, so
+// personal module overrides do not make a bundle look changed.
+func moduleChecksum(module *ComponentModule) (string, error) {
+	h := sha256.New()
+	defaults, err := json.Marshal(module.Defaults)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "defaults %d\n%s\n", len(defaults), defaults)
+	for _, assetPath := range module.AssetPaths {
+		content, err := assets.ReadFile("assets/" + assetPath)
+		if err != nil {
+			return "", fmt.Errorf("%s module %s: %w", module.Type, module.Name, err)
+		}
+		fmt.Fprintf(h, "asset %s %d\n%s\n", assetPath, len(content), content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// buildBundle packages choices with the modules they select and the template
+// overrides in effect.
+func buildBundle(choices PersistenceConfig, registry *ModuleRegistry) (*bundle.Bundle, error) {
+	choices = bundleChoices(choices)
+	modules, err := lockedModules(registry, choices)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(choices)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bundle.Bundle{
+		Lock: bundle.Lock{
+			GeneratorVersion: Version,
+			CreatedAt:        time.Now().UTC().Truncate(time.Second),
+			Choices:          data,
+			Modules:          modules,
+		},
+		Files: map[string][]byte{},
+	}
+	for name, override := range registry.templates {
+		content, err := os.ReadFile(override.source)
+		if err != nil {
+			return nil, err
+		}
+		b.Files[bundleTemplatePrefix+name] = content
+	}
+	return b, nil
+}
+
+// verifyBundleModules compares the bundle's module pins with the running registry
+// and describes each module that is missing or generates different content.
+func verifyBundleModules(lock bundle.Lock, registry *ModuleRegistry) []string {
+	var problems []string
+	for _, pinned := range lock.Modules {
+		module := registry.Get(ModuleComponentType(pinned.Type), pinned.Name)
+		if module == nil {
+			problems = append(problems, fmt.Sprintf("%s %s: not available in this claudekit", pinned.Type, pinned.Name))
+			continue
+		}
+		if sum, err := moduleChecksum(module); err != nil || sum != pinned.SHA256 {
+			problems = append(problems, fmt.Sprintf("%s %s: differs from the bundle's version", pinned.Type, pinned.Name))
+		}
+	}
+	return problems
+}
+
+// installBundleTemplates writes the bundle's templates into dir, replacing templates
+// of the same name, and returns the paths written. Files that are not templates
+// claudekit renders are rejected before anything is written.
+func installBundleTemplates(b *bundle.Bundle, dir string) ([]string, error) {
+	names := slices.Sorted(maps.Keys(b.Files))
+	for _, name := range names {
+		tmplName, ok := strings.CutPrefix(name, bundleTemplatePrefix)
+		if !ok {
+			return nil, fmt.Errorf("%s: unexpected file in bundle", name)
+		}
+		if _, ok := templateData(tmplName); !ok {
+			return nil, fmt.Errorf("%s: not a template claudekit renders", name)
+		}
+	}
+
+	var written []string
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, bundleTemplatePrefix)))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, b.Files[name], 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// runExportCommand implements `claudekit export FILE`.
+func runExportCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("export")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	file := flags.Arg(0)
+
+	choices, err := loadPersistenceConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: cannot load saved choices: %v\n", err)
+		return exitFailure
+	}
+	if choices.LastUpdated.IsZero() {
+		fmt.Fprintln(os.Stderr, "error: no saved choices to export; run claudekit first")
+		return exitFailure
+	}
+	b, err := buildBundle(*choices, registry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	sum := bundle.Checksum(buf.Bytes())
+	fmt.Printf("📦 Exported %d modules and %d templates to %s\n", len(b.Lock.Modules), len(b.Files), file)
+	fmt.Printf("   sha256 %s\n", sum)
+	fmt.Printf("   Teammates can check it on import: claudekit import --sha256 %s %s\n", sum, filepath.Base(file))
+	return exitOK
+}
+
+// runImportCommand implements `claudekit import [--sha256 SUM] [--force] FILE`.
+func runImportCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("import")
+	wantSum := flags.String("sha256", "", "refuse the bundle unless its SHA-256 is `SUM`")
+	force := flags.Bool("force", false, "import even when this claudekit's modules differ from the bundle's")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	file := flags.Arg(0)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	if sum := bundle.Checksum(data); *wantSum != "" && !strings.EqualFold(*wantSum, sum) {
+		fmt.Fprintf(os.Stderr, "error: %s: %v (expected %s, got %s)\n", file, bundle.ErrChecksum, *wantSum, sum)
+		return exitFailure
+	}
+	b, err := bundle.Read(bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s: %v\n", file, err)
+		return exitFailure
+	}
+	var choices PersistenceConfig
+	if err := json.Unmarshal(b.Lock.Choices, &choices); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s: cannot parse choices: %v\n", file, err)
+		return exitFailure
+	}
+
+	if problems := verifyBundleModules(b.Lock, registry); len(problems) > 0 {
+		w := os.Stderr
+		if !*force {
+			fmt.Fprintf(w, "error: %s was made with claudekit %s and its modules differ from this one's (%s):\n", file, b.Lock.GeneratorVersion, Version)
+		} else {
+			fmt.Fprintf(w, "warning: importing despite module differences from claudekit %s:\n", b.Lock.GeneratorVersion)
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(w, "  %s\n", problem)
+		}
+		if !*force {
+			fmt.Fprintf(w, "Install claudekit %s, or re-run with --force to import anyway.\n", b.Lock.GeneratorVersion)
+			return exitFailure
+		}
+	}
+
+	written, err := installBundleTemplates(b, templateOverridesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	// The theme and split are personal preferences; keep the importer's own
+	if previous, err := loadPersistenceConfig(); err == nil {
+		choices.Theme = previous.Theme
+		choices.SplitRatio = previous.SplitRatio
+	}
+	choices.LastUpdated = time.Now()
+	if err := storePersistenceConfig(choices); err != nil {
+		fmt.Fprintf(os.Stderr, "error: cannot save choices: %v\n", err)
+		return exitFailure
+	}
+
+	fmt.Printf("📦 Imported %s (claudekit %s, %d modules)\n", file, b.Lock.GeneratorVersion, len(b.Lock.Modules))
+	for _, path := range written {
+		fmt.Printf("   Installed %s\n", path)
+	}
+	fmt.Println("   Saved the bundle's choices. Run claudekit to review them, or claudekit --yes to generate directly.")
+	return exitOK
+}
+
+// ============================================================================
+// Project templates: scaffold a starter project with its configuration
+// ============================================================================
+
+// bundleProjectPrefix is the directory holding a project template's files inside
+// its bundle.
+const bundleProjectPrefix = "project/"
+
+// projectTemplatesDir holds user project templates under the home directory, each
+// an unpacked bundle directory or a .tar.gz bundle named after the template.
+const projectTemplatesDir = ".claudekit/project-templates"
+
+// dotPrefix spells a leading dot in project template file names, since go:embed
+// leaves out dotfiles: dot-gitignore is written as .gitignore.
+const dotPrefix = "dot-"
+
+// projectTemplate is a starter project: a bundle whose choices configure Claude Code
+// and whose project/ files are the skeleton. Files ending in .tmpl are rendered
+// with projectTemplateData and written without the suffix.
+type projectTemplate struct {
+	Name   string
+	Source string // "built-in", or the path of a user template
+	Bundle *bundle.Bundle
+}
+
+// projectTemplateData is what project template files are rendered with.
+type projectTemplateData struct {
+	ProjectName string
+}
+
+// loadProjectTemplates reads the embedded project templates and those in userDir,
+// which replace embedded templates of the same name. Templates that cannot be read
+// are reported and left out.
+func loadProjectTemplates(userDir string) (map[string]projectTemplate, []error) {
+	templates := map[string]projectTemplate{}
+	var errs []error
+	entries, err := assets.ReadDir("assets/project-templates")
+	if err != nil {
+		return nil, []error{err}
+	}
+	for _, entry := range entries {
+		sub, err := fs.Sub(assets, "assets/project-templates/"+entry.Name())
+		if err == nil {
+			err = addProjectTemplate(templates, entry.Name(), "built-in", func() (*bundle.Bundle, error) { return bundle.ReadFS(sub) })
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	entries, err = os.ReadDir(userDir)
+	if err != nil && !os.IsNotExist(err) {
+		return templates, append(errs, err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(userDir, entry.Name())
+		var err error
+		switch name, archive := strings.CutSuffix(entry.Name(), ".tar.gz"); {
+		case entry.IsDir():
+			err = addProjectTemplate(templates, name, path, func() (*bundle.Bundle, error) { return bundle.ReadFS(os.DirFS(path)) })
+		case archive:
+			err = addProjectTemplate(templates, name, path, func() (*bundle.Bundle, error) {
+				f, err := os.Open(path)
+				if err != nil {
+					return nil, err
+				}
+				defer f.Close()
+				return bundle.Read(f)
+			})
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return templates, errs
+}
+
+// addProjectTemplate reads a template and adds it to templates once its files check out.
+func addProjectTemplate(templates map[string]projectTemplate, name, source string, read func() (*bundle.Bundle, error)) error {
+	b, err := read()
+	if err != nil {
+		return fmt.Errorf("project template %s: %w", name, err)
+	}
+	for file := range b.Files {
+		if !strings.HasPrefix(file, bundleProjectPrefix) {
+			return fmt.Errorf("project template %s: %s: not under %s", name, file, bundleProjectPrefix)
+		}
+	}
+	templates[name] = projectTemplate{Name: name, Source: source, Bundle: b}
+	return nil
+}
+
+// projectFiles renders the template's skeleton for projectName, by path relative
+// to the project directory.
+func (t projectTemplate) projectFiles(projectName string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, content := range t.Bundle.Files {
+		rel := strings.TrimPrefix(name, bundleProjectPrefix)
+		parts := strings.Split(rel, "/")
+		for i, part := range parts {
+			if after, ok := strings.CutPrefix(part, dotPrefix); ok {
+				if after == "" || after == "." {
+					return nil, fmt.Errorf("%s: %s would be written as %s", name, part, "."+after)
+				}
+				parts[i] = "." + after
+			}
+		}
+		rel = strings.Join(parts, "/")
+		if before, ok := strings.CutSuffix(rel, ".tmpl"); ok {
+			tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, projectTemplateData{ProjectName: projectName}); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			rel, content = before, buf.Bytes()
+		}
+		files[filepath.FromSlash(rel)] = content
+	}
+	return files, nil
+}
+
+// choicesConfig is the configuration saved choices describe, as a run that takes
+// every choice from them would generate it.
+func choicesConfig(choices PersistenceConfig) Config {
+	return Config{
+		IsProjectLocal:        choices.IsProjectLocal,
+		ProjectName:           choices.ProjectName,
+		Languages:             choices.Languages,
+		Frameworks:            choices.Frameworks,
+		Subagents:             choices.Subagents,
+		Hooks:                 choices.Hooks,
+		SlashCommands:         choices.SlashCommands,
+		MCPServers:            choices.MCPServers,
+		OutputStyle:           choices.OutputStyle,
+		Permissions:           choices.Permissions,
+		Statusline:            choices.Statusline,
+		ClaudeMDExtras:        choices.ClaudeMDExtras,
+		SetupDoc:              choices.SetupDoc,
+		SetupReadme:           choices.SetupReadme,
+		MemoryFiles:           choices.MemoryFiles,
+		Gitignore:             choices.Gitignore,
+		EditorTasks:           choices.EditorTasks,
+		Devcontainer:          choices.Devcontainer,
+		Workflows:             choices.Workflows,
+		Packages:              choices.Packages,
+		Layout:                choices.Layout,
+		IncludeDisabled:       choices.IncludeDisabled,
+		CustomSubagents:       choices.CustomSubagents,
+		HookLanguages:         choices.HookLanguages,
+		SlackWebhookURL:       choices.SlackWebhookURL,
+		DiscordWebhookURL:     choices.DiscordWebhookURL,
+		PermissionRules:       choices.PermissionRules,
+		CustomPermissionRules: choices.CustomPermissionRules,
+		Env:                   choices.Env,
+		MCPUserScope:          choices.MCPUserScope,
+		CommandTools:          choices.CommandTools,
+		AgentModels:           choices.AgentModels,
+		AgentTools:            choices.AgentTools,
+		HookMatchers:          choices.HookMatchers,
+		HookCommands:          choices.HookCommands,
+		AssetResolutions:      choices.AssetResolutions,
+	}
+}
+
+// scaffoldProject writes the template's skeleton into dir and generates its Claude
+// Code configuration there, and returns the skeleton files written. Nothing is
+// written when a skeleton file already exists.
+func scaffoldProject(t projectTemplate, dir string, registry *ModuleRegistry) ([]string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var choices PersistenceConfig
+	if err := json.Unmarshal(t.Bundle.Lock.Choices, &choices); err != nil {
+		return nil, fmt.Errorf("project template %s: cannot parse choices: %w", t.Name, err)
+	}
+	for _, problem := range verifyBundleModules(t.Bundle.Lock, registry) {
+		slog.Warn(problem, "template", t.Name)
+	}
+	files, err := t.projectFiles(filepath.Base(abs))
+	if err != nil {
+		return nil, fmt.Errorf("project template %s: %w", t.Name, err)
+	}
+	names := slices.Sorted(maps.Keys(files))
+	for _, name := range names {
+		if rel, err := filepath.Rel(abs, filepath.Join(abs, name)); err != nil || !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("project template %s: %s is outside the project directory", t.Name, name)
+		}
+		if _, err := os.Lstat(filepath.Join(abs, name)); err == nil {
+			return nil, fmt.Errorf("%s already exists", filepath.Join(dir, name))
+		}
+	}
+
+	var written []string
+	for _, name := range names {
+		path := filepath.Join(abs, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, err
+		}
+		perm := os.FileMode(0644)
+		if strings.HasSuffix(name, ".sh") {
+			perm = 0755
+		}
+		if err := os.WriteFile(path, files[name], perm); err != nil {
+			return written, err
+		}
+		written = append(written, name)
+	}
+
+	cfg := choicesConfig(choices)
+	cfg.IsProjectLocal = true
+	cfg.ProjectName = filepath.Base(abs)
+	cfg.Packages = nil // A new project has no workspace packages yet
+	resolveModules(&cfg, registry)
+	issues, err := generate(abs, cfg, registry)
+	if err != nil {
+		return written, err
+	}
+	if len(issues) > 0 {
+		return written, &hookVerificationError{Issues: issues}
+	}
+	return written, nil
+}
+
+// runNewCommand implements `claudekit new [--list] TEMPLATE [DIR]`.
+func runNewCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("new")
+	list := flags.Bool("list", false, "list the project templates")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *list && flags.NArg() > 0 || !*list && (flags.NArg() < 1 || flags.NArg() > 2) {
+		flags.Usage()
+		return exitUsage
+	}
+
+	userDir := projectTemplatesDir
+	if home, err := os.UserHomeDir(); err == nil {
+		userDir = filepath.Join(home, projectTemplatesDir)
+	}
+	templates, errs := loadProjectTemplates(userDir)
+	for _, err := range errs {
+		slog.Warn(err.Error())
+	}
+
+	if *list {
+		for _, name := range slices.Sorted(maps.Keys(templates)) {
+			t := templates[name]
+			fmt.Printf("%-14s %s\n", name, t.Bundle.Lock.Description)
+			if t.Source != "built-in" {
+				fmt.Printf("%-14s (%s)\n", "", t.Source)
+			}
+		}
+		return exitOK
+	}
+
+	name := flags.Arg(0)
+	t, ok := templates[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: no project template %q; available: %s\n", name, strings.Join(slices.Sorted(maps.Keys(templates)), ", "))
+		return exitFailure
+	}
+	dir := name
+	if flags.NArg() == 2 {
+		dir = flags.Arg(1)
+	}
+
+	written, err := scaffoldProject(t, dir, registry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	fmt.Printf("\n✅ Created %s from the %s template:\n", dir, name)
+	for _, file := range written {
+		fmt.Printf("   %s\n", file)
+	}
+	fmt.Println("   plus its Claude Code configuration in CLAUDE.md and .claude/.")
+	fmt.Printf("   cd %s and start Claude Code.\n", dir)
+	return exitOK
+}
+
+// ============================================================================
+// Filterable multi-select: type to narrow long option lists
+// ============================================================================
+
+// filterMultiSelect is a huh.MultiSelect that narrows its options as you type,
+// ranking fuzzy matches first so "secaud" finds security-auditor, and that can
+// group its options under collapsible category headings. huh only filters by
+// substring behind "/" and cannot be extended, so the wrapper hands the inner field
+// just the options on display and merges what it selects back through
+// filterAccessor; choices hidden by the filter or a collapsed category stay
+// selected.
+type filterMultiSelect struct {
+	*huh.MultiSelect[string]
+	load        func() []huh.Option[string] // Every option, as given to Options or OptionsFunc
+	all         []huh.Option[string]        // load's result, fetched on the first keystroke
+	current     []huh.Option[string]        // What the inner field holds, headings included
+	shown       []string                    // Values on display; nil when every option is
+	value       *[]string
+	description string
+	query       string
+	category    func(value string) string // Groups options under headings; nil for a flat list
+	collapsed   map[string]bool
+	filterKey   key.Binding
+	clearKey    key.Binding
+	collapseKey key.Binding
+	expandKey   key.Binding
+}
+
+// categoryHeadingPrefix marks the values of category heading options, which are
+// never selected. Module names cannot contain it.
+const categoryHeadingPrefix = "\x00category:"
+
+// newFilterMultiSelect returns a filterable multi-select that writes the selected
+// values to value. Options are set with Options or OptionsFunc as for huh's field.
+func newFilterMultiSelect(name string, value *[]string) *filterMultiSelect {
+	f := &filterMultiSelect{
+		value:       value,
+		collapsed:   map[string]bool{},
+		filterKey:   key.NewBinding(key.WithKeys("/"), key.WithHelp("type", "filter")),
+		clearKey:    key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear filter")),
+		collapseKey: key.NewBinding(key.WithKeys("left"), key.WithHelp("←", "collapse")),
+		expandKey:   key.NewBinding(key.WithKeys("right"), key.WithHelp("→", "expand")),
+	}
+	f.MultiSelect = huh.NewMultiSelect[string]().Key(name).Filterable(false).Accessor(filterAccessor{f})
+	return f
+}
+
+func (f *filterMultiSelect) Title(title string) *filterMultiSelect {
+	f.MultiSelect.Title(title)
+	return f
+}
+
+func (f *filterMultiSelect) Description(description string) *filterMultiSelect {
+	f.description = description
+	f.MultiSelect.Description(description)
+	return f
+}
+
+func (f *filterMultiSelect) Height(height int) *filterMultiSelect {
+	f.MultiSelect.Height(height)
+	return f
+}
+
+// Grouped lists the options under a heading per category. Set it before the options.
+func (f *filterMultiSelect) Grouped(category func(value string) string) *filterMultiSelect {
+	f.category = category
+	return f
+}
+
+func (f *filterMultiSelect) Options(options ...huh.Option[string]) *filterMultiSelect {
+	f.load = func() []huh.Option[string] { return options }
+	f.MultiSelect.Options(f.arrange(options)...)
+	return f
+}
+
+func (f *filterMultiSelect) OptionsFunc(load func() []huh.Option[string], bindings any) *filterMultiSelect {
+	f.load = load
+	f.MultiSelect.OptionsFunc(func() []huh.Option[string] { return f.arrange(load()) }, bindings)
+	return f
+}
+
+// Update takes printable keys as the filter query and, in grouped lists, space on a
+// heading and the left and right arrows to collapse and expand categories.
+// Everything else goes to huh. Letters therefore no longer move the cursor or
+// toggle, so space toggles.
+func (f *filterMultiSelect) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && f.load != nil {
+		hovered, _ := f.MultiSelect.Hovered()
+		heading := strings.HasPrefix(hovered, categoryHeadingPrefix)
+		switch {
+		case msg.Type == tea.KeyRunes && !msg.Alt:
+			f.setQuery(f.query + string(msg.Runes))
+			return f, nil
+		case msg.Type == tea.KeyBackspace && f.query != "":
+			runes := []rune(f.query)
+			f.setQuery(string(runes[:len(runes)-1]))
+			return f, nil
+		case key.Matches(msg, f.clearKey) && f.query != "":
+			f.setQuery("")
+			return f, nil
+		case f.shown != nil && len(f.shown) == 0 && msg.Type == tea.KeySpace:
+			return f, nil // Nothing to toggle, only the "No matches" line
+		case heading && msg.Type == tea.KeySpace:
+			category := strings.TrimPrefix(hovered, categoryHeadingPrefix)
+			f.setCollapsed(category, !f.collapsed[category])
+			return f, nil
+		case f.grouping() && key.Matches(msg, f.collapseKey, f.expandKey) && hovered != "":
+			category := strings.TrimPrefix(hovered, categoryHeadingPrefix)
+			if !heading {
+				category = f.category(hovered)
+			}
+			f.setCollapsed(category, key.Matches(msg, f.collapseKey))
+			return f, nil
+		}
+	}
+	_, cmd := f.MultiSelect.Update(msg)
+	if msg, ok := msg.(tea.KeyMsg); ok && f.grouping() && key.Matches(msg, huh.NewDefaultKeyMap().MultiSelect.SelectAll) {
+		// Select all ticks the headings too; redraw them from the selection
+		hovered, _ := f.MultiSelect.Hovered()
+		f.refresh(hovered)
+	}
+	return f, cmd
+}
+
+// Blur clears the filter so the page shows every choice when revisited.
+func (f *filterMultiSelect) Blur() tea.Cmd {
+	if f.query != "" {
+		f.setQuery("")
+	}
+	return f.MultiSelect.Blur()
+}
+
+func (f *filterMultiSelect) KeyBinds() []key.Binding {
+	f.clearKey.SetEnabled(f.query != "")
+	f.collapseKey.SetEnabled(f.grouping())
+	f.expandKey.SetEnabled(f.grouping())
+	return append(f.MultiSelect.KeyBinds(), f.filterKey, f.clearKey, f.collapseKey, f.expandKey)
+}
+
+// WithKeyMap hands huh a keymap without the letter shortcuts the filter takes over.
+func (f *filterMultiSelect) WithKeyMap(k *huh.KeyMap) huh.Field {
+	keymap := *k
+	keymap.MultiSelect.Toggle = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle"))
+	f.MultiSelect.WithKeyMap(&keymap)
+	return f
+}
+
+// grouping reports whether the options are shown under category headings, which
+// they are unless the list is ungrouped or filtered.
+func (f *filterMultiSelect) grouping() bool {
+	return f.category != nil && f.query == ""
+}
+
+// setQuery shows the options matching query, best match first, with the cursor on
+// the first of them.
+func (f *filterMultiSelect) setQuery(query string) {
+	f.query = query
+	f.refresh("")
+}
+
+// setCollapsed collapses or expands category, leaving the cursor on its heading.
+func (f *filterMultiSelect) setCollapsed(category string, collapsed bool) {
+	f.collapsed[category] = collapsed
+	f.refresh(categoryHeadingPrefix + category)
+}
+
+// refresh hands the inner field the options to display for the current query and
+// collapsed categories, moving the cursor to the option with value cursor, or to
+// the top.
+func (f *filterMultiSelect) refresh(cursor string) {
+	if f.all == nil {
+		f.all = slices.Clone(f.load())
+	}
+
+	matches := f.all
+	f.MultiSelect.Description(f.description)
+	if f.query != "" {
+		matches = rankOptions(f.all, f.query)
+		f.MultiSelect.Description(fmt.Sprintf("🔍 %s (%d of %d)", f.query, len(matches), len(f.all)))
+	}
+
+	options := make([]huh.Option[string], len(matches))
+	for i, option := range matches {
+		options[i] = option.Selected(slices.Contains(*f.value, option.Value))
+	}
+	if f.grouping() {
+		options = f.arrange(options)
+	}
+
+	f.shown = nil
+	hidden := slices.ContainsFunc(options, func(o huh.Option[string]) bool {
+		category, heading := strings.CutPrefix(o.Value, categoryHeadingPrefix)
+		return heading && f.collapsed[category]
+	})
+	if f.query != "" || hidden {
+		f.shown = []string{}
+		for _, option := range options {
+			if !strings.HasPrefix(option.Value, categoryHeadingPrefix) {
+				f.shown = append(f.shown, option.Value)
+			}
+		}
+	}
+
+	if len(options) == 0 {
+		// huh keeps its old options when given none
+		options = []huh.Option[string]{huh.NewOption("No matches", "")}
+	}
+	f.current = options
+	f.MultiSelect.Options(options...)
+	f.MultiSelect.Update(tea.KeyMsg{Type: tea.KeyHome})
+	for range max(slices.IndexFunc(options, func(o huh.Option[string]) bool { return o.Value == cursor }), 0) {
+		f.MultiSelect.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+}
+
+// click toggles the option shown on line, a line of the field's view with the
+// styling stripped, as moving the cursor there and pressing space would; a heading
+// is collapsed or expanded. It reports whether line shows an option.
+func (f *filterMultiSelect) click(line string) bool {
+	if f.load == nil {
+		return false
+	}
+	if f.current == nil {
+		hovered, _ := f.MultiSelect.Hovered()
+		f.refresh(hovered)
+	}
+
+	// Lines end with the option's label; prefer the longest, so "C#" beats "C"
+	line = strings.TrimRight(line, " ")
+	clicked := -1
+	for i, option := range f.current {
+		if option.Value != "" && strings.HasSuffix(line, " "+option.Key) && (clicked < 0 || len(option.Key) > len(f.current[clicked].Key)) {
+			clicked = i
+		}
+	}
+	if clicked < 0 {
+		return false
+	}
+
+	f.MultiSelect.Update(tea.KeyMsg{Type: tea.KeyHome})
+	for range clicked {
+		f.MultiSelect.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	f.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	return true
+}
+
+// arrange puts a heading above each category of options, leaving out the options
+// of collapsed categories. Ungrouped lists are returned as they are.
+func (f *filterMultiSelect) arrange(options []huh.Option[string]) []huh.Option[string] {
+	if f.category == nil {
+		return options
+	}
+	byValue := make(map[string]huh.Option[string], len(options))
+	values := make([]string, len(options))
+	for i, option := range options {
+		byValue[option.Value] = option
+		values[i] = option.Value
+	}
+
+	var arranged []huh.Option[string]
+	for _, group := range groupByCategory(values, f.category) {
+		label := "▾ " + categoryLabel(group.Category)
+		if f.collapsed[group.Category] {
+			label = "▸ " + categoryLabel(group.Category)
+			if n := len(slices.DeleteFunc(slices.Clone(group.Values), func(v string) bool { return !slices.Contains(*f.value, v) })); n > 0 {
+				label += fmt.Sprintf(" (%d selected)", n)
+			}
+		}
+		arranged = append(arranged, huh.NewOption(label, categoryHeadingPrefix+group.Category))
+		if !f.collapsed[group.Category] {
+			for _, v := range group.Values {
+				arranged = append(arranged, byValue[v])
+			}
+		}
+	}
+	return arranged
+}
+
+// filterAccessor is the inner field's view of the selection. Writes only replace
+// the values of the options on display, keeping those the filter hides.
+type filterAccessor struct{ f *filterMultiSelect }
+
+func (a filterAccessor) Get() []string { return *a.f.value }
+
+func (a filterAccessor) Set(visible []string) {
+	f := a.f
+	visible = slices.DeleteFunc(slices.Clone(visible), func(v string) bool { return strings.HasPrefix(v, categoryHeadingPrefix) })
+	if f.shown == nil {
+		*f.value = visible
+		return
+	}
+	selected := slices.DeleteFunc(slices.Clone(*f.value), func(v string) bool { return slices.Contains(f.shown, v) })
+	if len(f.shown) > 0 {
+		selected = append(selected, visible...)
+	}
+
+	// Keep the options' order rather than the order things were picked in
+	index := func(v string) int {
+		if i := slices.IndexFunc(f.all, func(o huh.Option[string]) bool { return o.Value == v }); i >= 0 {
+			return i
+		}
+		return len(f.all)
+	}
+	slices.SortStableFunc(selected, func(a, b string) int { return index(a) - index(b) })
+	*f.value = selected
+}
+
+// categoryGroup is a category and its values, in their original order.
+type categoryGroup struct {
+	Category string
+	Values   []string
+}
+
+// groupByCategory groups values by category, ordering the groups by name with
+// uncategorized values last.
+func groupByCategory(values []string, category func(string) string) []categoryGroup {
+	var groups []categoryGroup
+	for _, v := range values {
+		c := category(v)
+		i := slices.IndexFunc(groups, func(g categoryGroup) bool { return g.Category == c })
+		if i < 0 {
+			groups = append(groups, categoryGroup{Category: c})
+			i = len(groups) - 1
+		}
+		groups[i].Values = append(groups[i].Values, v)
+	}
+	slices.SortStableFunc(groups, func(a, b categoryGroup) int {
+		if (a.Category == "") != (b.Category == "") {
+			return cmp.Compare(b.Category, a.Category) // "" sorts last
+		}
+		return cmp.Compare(a.Category, b.Category)
+	})
+	return groups
+}
+
+// categoryLabel is how a module category is shown: "quality" becomes "Quality" and
+// no category becomes "Other".
+func categoryLabel(category string) string {
+	if category == "" {
+		return "Other"
+	}
+	r, size := utf8.DecodeRuneInString(category)
+	return string(unicode.ToUpper(r)) + category[size:]
+}
+
+// rankOptions returns the options whose label or value fuzzy-matches query, best
+// first, keeping the original order between equal matches.
+func rankOptions(options []huh.Option[string], query string) []huh.Option[string] {
+	type ranked struct {
+		option huh.Option[string]
+		score  int
+	}
+	var matches []ranked
+	for _, option := range options {
+		keyScore, keyOK := fuzzyScore(query, option.Key)
+		valueScore, valueOK := fuzzyScore(query, option.Value)
+		if keyOK || valueOK {
+			matches = append(matches, ranked{option, max(keyScore, valueScore)})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b ranked) int { return b.score - a.score })
+
+	result := make([]huh.Option[string], len(matches))
+	for i, m := range matches {
+		result[i] = m.option
+	}
+	return result
+}
+
+// fuzzyScore reports whether the characters of query appear in order in text,
+// ignoring case, and scores the match. Runs of consecutive characters and
+// characters that start a word score higher, so "sa" prefers security-auditor to
+// user-stats.
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	score, matched, prev := 0, 0, -2
+	for i := 0; i < len(t) && matched < len(q); i++ {
+		if t[i] != q[matched] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 3
+		}
+		prev = i
+		matched++
+	}
+	return score, matched == len(q)
+}
+
+// ============================================================================
+// Tool matrix: toggle the tools each selected subagent may use
+// ============================================================================
+
+// toolMatrix is the agent tools page: a row per selected subagent, a column per
+// tool, and a cell per pair that space toggles. Rows start from the tools each
+// module declares; a row changed from those is kept in cfg.AgentTools. huh's Field
+// interface has an unexported method, so the matrix embeds a Note for it and draws
+// and edits the grid itself.
+type toolMatrix struct {
+	*huh.Note
+	key      string
+	cfg      *Config
+	loader   *registryLoader
+	theme    *huh.Theme
+	keymap   toolMatrixKeyMap
+	focused  bool
+	row, col int
+}
+
+type toolMatrixKeyMap struct {
+	Up, Down, Left, Right key.Binding
+	Toggle, ToggleRow     key.Binding
+	Reset                 key.Binding
+	Prev, Next, Submit    key.Binding
+}
+
+func newToolMatrix(key string, cfg *Config, loader *registryLoader) *toolMatrix {
+	m := &toolMatrix{Note: huh.NewNote(), key: key, cfg: cfg, loader: loader}
+	m.WithKeyMap(huh.NewDefaultKeyMap())
+	return m
+}
+
+// agents lists the selected subagents, one per row.
+func (m *toolMatrix) agents() []string {
+	return cleanFormValues(m.cfg.Subagents)
+}
+
+// tools lists the columns: the tools a subagent can be granted, then any other tool
+// a row grants, such as an MCP tool a module declares.
+func (m *toolMatrix) tools() []string {
+	registry, _ := m.loader.Wait()
+	columns := slices.Clone(generation.SubagentTools)
+	for _, agent := range m.agents() {
+		for _, tool := range agentTools(*m.cfg, agent, registry) {
+			if !slices.Contains(columns, tool) {
+				columns = append(columns, tool)
+			}
+		}
+	}
+	return columns
+}
+
+// toggle flips the tool in column col of the agent in row row. A row that inherits
+// every tool starts from all of them.
+func (m *toolMatrix) toggle(row, col int) {
+	registry, _ := m.loader.Wait()
+	agent, columns := m.agents()[row], m.tools()
+	granted := agentTools(*m.cfg, agent, registry)
+	if len(granted) == 0 {
+		granted = columns
+	}
+	tool := columns[col]
+	if slices.Contains(granted, tool) {
+		granted = slices.DeleteFunc(slices.Clone(granted), func(t string) bool { return t == tool })
+	} else {
+		granted = append(slices.Clone(granted), tool)
+	}
+	m.set(agent, columns, granted)
+}
+
+// toggleRow grants the agent every tool unless it already has them all, in which
+// case it takes them all away, leaving the agent to inherit every tool.
+func (m *toolMatrix) toggleRow(row int) {
+	registry, _ := m.loader.Wait()
+	agent, columns := m.agents()[row], m.tools()
+	granted := agentTools(*m.cfg, agent, registry)
+	if len(granted) == len(columns) {
+		m.set(agent, columns, nil)
+		return
+	}
+	m.set(agent, columns, columns)
+}
+
+// set records the agent's tools in column order, forgetting them when they are
+// the module's own so the agent keeps following its module.
+func (m *toolMatrix) set(agent string, columns, granted []string) {
+	registry, _ := m.loader.Wait()
+	ordered := []string{}
+	for _, tool := range columns {
+		if slices.Contains(granted, tool) {
+			ordered = append(ordered, tool)
+		}
+	}
+	defaults := defaultAgentTools(*m.cfg, agent, registry)
+	if slices.Equal(slices.Sorted(slices.Values(ordered)), slices.Sorted(slices.Values(defaults))) {
+		delete(m.cfg.AgentTools, agent)
+		return
+	}
+	if m.cfg.AgentTools == nil {
+		m.cfg.AgentTools = map[string][]string{}
+	}
+	m.cfg.AgentTools[agent] = ordered
+}
+
+func (m *toolMatrix) Init() tea.Cmd { return nil }
+
+func (m *toolMatrix) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !m.focused {
+		return m, nil
+	}
+	rows, cols := len(m.agents()), len(m.tools())
+	switch {
+	case key.Matches(keyMsg, m.keymap.Prev):
+		return m, huh.PrevField
+	case key.Matches(keyMsg, m.keymap.Next, m.keymap.Submit):
+		return m, huh.NextField
+	case rows == 0:
+	case key.Matches(keyMsg, m.keymap.Up):
+		m.row = max(m.row-1, 0)
+	case key.Matches(keyMsg, m.keymap.Down):
+		m.row = min(m.row+1, rows-1)
+	case key.Matches(keyMsg, m.keymap.Left):
+		m.col = max(m.col-1, 0)
+	case key.Matches(keyMsg, m.keymap.Right):
+		m.col = min(m.col+1, cols-1)
+	case key.Matches(keyMsg, m.keymap.Toggle):
+		m.toggle(min(m.row, rows-1), min(m.col, cols-1))
+	case key.Matches(keyMsg, m.keymap.ToggleRow):
+		m.toggleRow(min(m.row, rows-1))
+	case key.Matches(keyMsg, m.keymap.Reset):
+		delete(m.cfg.AgentTools, m.agents()[min(m.row, rows-1)])
+	}
+	return m, nil
+}
+
+// View draws the grid with two-letter column headings and names the tool and agent
+// under the cursor below it.
+func (m *toolMatrix) View() string {
+	styles := m.styles()
+	registry, _ := m.loader.Wait()
+	agents, columns := m.agents(), m.tools()
+	row, col := min(m.row, max(len(agents)-1, 0)), min(m.col, len(columns)-1)
+
+	var b strings.Builder
+	b.WriteString(styles.Title.Render("Agent tools") + "\n")
+	b.WriteString(styles.Description.Render("Space toggles a tool, a toggles the row, r restores the module's tools") + "\n")
+	if len(agents) == 0 {
+		b.WriteString(styles.Description.Render("No subagents selected"))
+		return styles.Base.Render(b.String())
+	}
+
+	nameWidth := 0
+	for _, agent := range agents {
+		nameWidth = max(nameWidth, lipgloss.Width(agent))
+	}
+	fmt.Fprintf(&b, "%-*s ", nameWidth, "")
+	for i, tool := range columns {
+		heading := toolAbbreviation(tool)
+		if i == col && m.focused {
+			heading = styles.SelectSelector.Render(heading)
+		}
+		b.WriteString(" " + heading)
+	}
+	b.WriteString("\n")
+
+	cursor := lipgloss.NewStyle().Reverse(true)
+	for r, agent := range agents {
+		granted := agentTools(*m.cfg, agent, registry)
+		name := fmt.Sprintf("%-*s ", nameWidth, agent)
+		if r == row && m.focused {
+			name = styles.SelectSelector.Render(name)
+		}
+		b.WriteString(name)
+		for c, tool := range columns {
+			cell := styles.UnselectedOption.Render("○")
+			switch {
+			case len(granted) == 0:
+				cell = styles.UnselectedOption.Render("·")
+			case slices.Contains(granted, tool):
+				cell = styles.SelectedOption.Render("●")
+			}
+			if r == row && c == col && m.focused {
+				cell = cursor.Render(cell)
+			}
+			b.WriteString("  " + cell)
+		}
+		if len(granted) == 0 {
+			b.WriteString(styles.Description.Render("  every tool"))
+		}
+		if _, changed := m.cfg.AgentTools[agent]; changed {
+			b.WriteString(styles.Description.Render("  (changed)"))
+		}
+		b.WriteString("\n")
+	}
+
+	agent, tool := agents[row], columns[col]
+	verdict := "may not use"
+	if granted := agentTools(*m.cfg, agent, registry); len(granted) == 0 || slices.Contains(granted, tool) {
+		verdict = "may use"
+	}
+	b.WriteString(styles.Description.Render(fmt.Sprintf("%s %s %s", agent, verdict, tool)))
+	return styles.Base.Render(b.String())
+}
+
+func (m *toolMatrix) styles() huh.FieldStyles {
+	theme := m.theme
+	if theme == nil {
+		theme = huh.ThemeCharm()
+	}
+	if m.focused {
+		return theme.Focused
+	}
+	return theme.Blurred
+}
+
+func (m *toolMatrix) Focus() tea.Cmd {
+	m.focused = true
+	return nil
+}
+
+func (m *toolMatrix) Blur() tea.Cmd {
+	m.focused = false
+	return nil
+}
+
+func (m *toolMatrix) Error() error { return nil }
+func (m *toolMatrix) Skip() bool   { return false }
+func (m *toolMatrix) Zoom() bool   { return false }
+func (m *toolMatrix) GetKey() string {
+	return m.key
+}
+func (m *toolMatrix) GetValue() any { return m.cfg.AgentTools }
+
+func (m *toolMatrix) KeyBinds() []key.Binding {
+	k := m.keymap
+	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.Toggle, k.ToggleRow, k.Reset, k.Prev, k.Next, k.Submit}
+}
+
+func (m *toolMatrix) WithTheme(theme *huh.Theme) huh.Field {
+	if m.theme == nil {
+		m.theme = theme
+	}
+	return m
+}
+
+func (m *toolMatrix) WithKeyMap(k *huh.KeyMap) huh.Field {
+	m.keymap = toolMatrixKeyMap{
+		Up:        k.MultiSelect.Up,
+		Down:      k.MultiSelect.Down,
+		Left:      key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/→", "tool")),
+		Right:     key.NewBinding(key.WithKeys("right", "l")),
+		Toggle:    key.NewBinding(key.WithKeys(" ", "x"), key.WithHelp("space", "toggle")),
+		ToggleRow: key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "toggle row")),
+		Reset:     key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "module's tools")),
+		Prev:      k.MultiSelect.Prev,
+		Next:      k.MultiSelect.Next,
+		Submit:    k.MultiSelect.Submit,
+	}
+	return m
+}
+
+func (m *toolMatrix) WithAccessible(accessible bool) huh.Field {
+	m.Note.WithAccessible(accessible)
+	return m
+}
+
+func (m *toolMatrix) WithWidth(width int) huh.Field {
+	m.Note.WithWidth(width)
+	return m
+}
+
+func (m *toolMatrix) WithHeight(height int) huh.Field {
+	m.Note.WithHeight(height)
+	return m
+}
+
+func (m *toolMatrix) WithPosition(p huh.FieldPosition) huh.Field {
+	m.keymap.Prev.SetEnabled(!p.IsFirst())
+	m.keymap.Next.SetEnabled(!p.IsLast())
+	m.keymap.Submit.SetEnabled(p.IsLast())
+	return m
+}
+
+// toolAbbreviation names a tool in two letters for the matrix's column headings:
+// its first two capitals, as in WF for WebFetch, or its first two letters.
+func toolAbbreviation(tool string) string {
+	var capitals []rune
+	for _, r := range tool {
+		if unicode.IsUpper(r) {
+			capitals = append(capitals, r)
+		}
+	}
+	if len(capitals) >= 2 {
+		return string(capitals[:2])
+	}
+	runes := []rune(tool + "  ")
+	return string(runes[:2])
+}
+
+// ============================================================================
+// Module browser: claudekit browse
+// ============================================================================
+
+// browseSections lists the module types in the order the browser shows them.
+var browseSections = []struct {
+	Type  ModuleComponentType
+	Title string
+}{
+	{TypeSubagent, "Subagents"},
+	{TypeHook, "Hooks"},
+	{TypeCommand, "Slash Commands"},
+	{TypeMCP, "MCP Servers"},
+	{TypeFramework, "Frameworks"},
+	{TypeStyle, "Output Styles"},
+	{TypeStatusline, "Statuslines"},
+	{TypePermissions, "Permission Presets"},
+}
+
+var (
+	browseHeadingStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.AdaptiveColor{Light: "#6C5CE7", Dark: "#A29BFE"})
+	browseSelectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.AdaptiveColor{Light: "#00B894", Dark: "#55EFC4"})
+	browseDimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+	browsePanelStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#888888")).Padding(0, 1)
+)
+
+// runBrowseCommand implements `claudekit module browse [--include-disabled] [QUERY]`: a
+// read-only list of every module, for discovering what is available.
+func runBrowseCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("module browse")
+	includeDisabled := flags.Bool("include-disabled", false, "also list modules whose frontmatter sets enabled: false")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "usage: claudekit module browse [--include-disabled] [QUERY]")
+		return exitUsage
+	}
+
+	capability := gradient.DetectTerminalCapability()
+	var savedTheme string
+	if persisted, err := loadPersistenceConfig(); err == nil {
+		savedTheme = persisted.Theme
+	}
+	theme := resolveTheme("", os.Getenv(envTheme), savedTheme)
+	m := newBrowseModel(registry, *includeDisabled, capability, theme)
+	m.setQuery(flags.Arg(0))
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// browseModel is the Bubble Tea model of claudekit browse. Typing filters the list;
+// the right panel renders the module under the cursor.
+type browseModel struct {
+	modules  []*ComponentModule // Every listed module, in section order
+	matches  []*ComponentModule // Those matching query, best first within each section
+	query    string
+	cursor   int
+	width    int
+	height   int
+	renderer *glamour.TermRenderer
+	panel    viewport.Model
+	status   string // Outcome of the last copy or open
+}
+
+// browseOpenedMsg reports that the viewer of a module's source exited.
+type browseOpenedMsg struct {
+	module string
+	err    error
+}
+
+func newBrowseModel(registry *ModuleRegistry, includeDisabled bool, capability gradient.TerminalCapability, theme string) browseModel {
+	m := browseModel{width: 100, height: 30}
+	for _, section := range browseSections {
+		for _, module := range registry.List(section.Type) {
+			if module.Enabled || includeDisabled {
+				m.modules = append(m.modules, module)
+			}
+		}
+	}
+	_, m.renderer = themeStyles(theme, capability)
+	m.panel = viewport.New(0, 0)
+	m.setQuery("")
+	return m
+}
+
+// browseSection returns the index of t in browseSections.
+func browseSection(t ModuleComponentType) int {
+	for i, section := range browseSections {
+		if section.Type == t {
+			return i
+		}
+	}
+	return len(browseSections)
+}
+
+// setQuery filters the list to the modules whose name, display name, or category
+// fuzzy-match query, and moves the cursor to the best match.
+func (m *browseModel) setQuery(query string) {
+	m.query = query
+	type ranked struct {
+		module  *ComponentModule
+		section int
+		score   int
+	}
+	var matches []ranked
+	for _, module := range m.modules {
+		section := browseSection(module.Type)
+		best, ok := 0, query == ""
+		for _, text := range []string{module.Name, cleanFormValue(module.DisplayName), module.Category} {
+			if score, matched := fuzzyScore(query, text); matched && text != "" {
+				best, ok = max(best, score), true
+			}
+		}
+		if ok {
+			matches = append(matches, ranked{module, section, best})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b ranked) int {
+		return cmp.Or(cmp.Compare(a.section, b.section), cmp.Compare(b.score, a.score))
+	})
+
+	m.matches = m.matches[:0]
+	for _, match := range matches {
+		m.matches = append(m.matches, match.module)
+	}
+	m.cursor = 0
+	if query != "" {
+		for i, match := range matches {
+			if match.score > matches[m.cursor].score {
+				m.cursor = i
+			}
+		}
+	}
+	m.refreshPanel()
+}
+
+// selected returns the module under the cursor, or nil when nothing matches.
+func (m browseModel) selected() *ComponentModule {
+	if m.cursor < len(m.matches) {
+		return m.matches[m.cursor]
+	}
+	return nil
+}
+
+// browseLayout splits the width between the list and the panel.
+func (m browseModel) browseLayout() (listWidth, panelWidth, bodyHeight int) {
+	listWidth = max(24, m.width*2/5)
+	panelWidth = max(20, m.width-listWidth-1)
+	bodyHeight = max(3, m.height-3) // Search line above, help line below
+	return listWidth, panelWidth, bodyHeight
+}
+
+// refreshPanel renders the selected module into the right panel.
+func (m *browseModel) refreshPanel() {
+	_, panelWidth, bodyHeight := m.browseLayout()
+	frame := browsePanelStyle.GetHorizontalFrameSize()
+	m.panel.Width = panelWidth - frame
+	m.panel.Height = bodyHeight - browsePanelStyle.GetVerticalFrameSize()
+	content := "No module matches " + strconv.Quote(m.query) + "."
+	if module := m.selected(); module != nil {
+		content = browseModuleMarkdown(module)
+		if m.renderer != nil {
+			if rendered, err := m.renderer.Render(content); err == nil {
+				content = rendered
+			}
+		}
+	}
+	m.panel.SetContent(lipgloss.NewStyle().Width(m.panel.Width).Render(content))
+	m.panel.GotoTop()
+}
+
+// browseModuleMarkdown describes a module for the right panel.
+func browseModuleMarkdown(module *ComponentModule) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", cmp.Or(module.DisplayName, module.Name))
+	facts := []string{fmt.Sprintf("`%s/%s`", module.Type, module.Name)}
+	if module.Category != "" {
+		facts = append(facts, module.Category)
+	}
+	if module.Version != "" {
+		facts = append(facts, "v"+module.Version)
+	}
+	if !module.Enabled {
+		facts = append(facts, "disabled")
+	}
+	fmt.Fprintf(&b, "%s\n\n", strings.Join(facts, " · "))
+	b.WriteString(strings.TrimSpace(module.GetDescription()))
+	b.WriteString("\n")
+	if len(module.Dependencies) > 0 {
+		fmt.Fprintf(&b, "\n**Depends on:** %s\n", strings.Join(module.Dependencies, ", "))
+	}
+	if module.RequiresClaude != "" {
+		fmt.Fprintf(&b, "\n**Requires Claude Code** %s\n", module.RequiresClaude)
+	}
+	return b.String()
+}
+
+func (m browseModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.refreshPanel()
+	case browseOpenedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Could not open %s: %v", msg.module, msg.err)
+		}
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m browseModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		if m.query == "" {
+			return m, tea.Quit
+		}
+		m.setQuery("")
+	case tea.KeyUp, tea.KeyCtrlP:
+		if m.cursor > 0 {
+			m.cursor--
+			m.refreshPanel()
+		}
+	case tea.KeyDown, tea.KeyCtrlN:
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+			m.refreshPanel()
+		}
+	case tea.KeyHome:
+		m.cursor = 0
+		m.refreshPanel()
+	case tea.KeyEnd:
+		m.cursor = max(0, len(m.matches)-1)
+		m.refreshPanel()
+	case tea.KeyPgUp:
+		m.panel.HalfViewUp()
+	case tea.KeyPgDown:
+		m.panel.HalfViewDown()
+	case tea.KeyBackspace:
+		if m.query != "" {
+			runes := []rune(m.query)
+			m.setQuery(string(runes[:len(runes)-1]))
+		}
+	case tea.KeyEnter:
+		if module := m.selected(); module != nil {
+			termenv.Copy(module.Name)
+			m.status = "Copied " + module.Name + " to the clipboard"
+		}
+	case tea.KeyCtrlO:
+		if module := m.selected(); module != nil {
+			cmd, err := openModuleSource(module)
+			if err != nil {
+				m.status = fmt.Sprintf("Could not open %s: %v", module.Name, err)
+				return m, nil
+			}
+			return m, cmd
+		}
+	case tea.KeyRunes:
+		m.setQuery(m.query + string(msg.Runes))
+	}
+	return m, nil
+}
+
+// openModuleSource shows a module's markdown file in $VISUAL, $EDITOR, or $PAGER,
+// falling back to less. Embedded modules are copied to a temporary file first;
+// editing the copy changes nothing. User modules are opened where they are.
+func openModuleSource(module *ComponentModule) (tea.Cmd, error) {
+	path := module.Source
+	if module.assets == nil {
+		data, err := assets.ReadFile(module.Source)
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(os.TempDir(), fmt.Sprintf("claudekit-%s-%s.md", module.Type, module.Name))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	viewer := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), os.Getenv("PAGER"), "less"))
+	cmd := exec.Command(viewer[0], append(viewer[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return browseOpenedMsg{module: module.Name, err: err}
+	}), nil
+}
+
+func (m browseModel) View() string {
+	listWidth, _, bodyHeight := m.browseLayout()
+
+	search := "🔎 " + m.query
+	if m.query == "" {
+		search += browseDimStyle.Render("type to search")
+	}
+	search += browseDimStyle.Render(fmt.Sprintf("  %d of %d modules", len(m.matches), len(m.modules)))
+
+	// The list scrolls to keep the cursor in view
+	var lines []string
+	cursorLine := 0
+	var section ModuleComponentType
+	for i, module := range m.matches {
+		if module.Type != section {
+			section = module.Type
+			lines = append(lines, browseHeadingStyle.Render(browseSections[browseSection(section)].Title))
+		}
+		label := cleanFormValue(cmp.Or(module.DisplayName, module.Name))
+		if module.Category != "" {
+			label += browseDimStyle.Render(" · " + module.Category)
+		}
+		if i == m.cursor {
+			cursorLine = len(lines)
+			label = browseSelectedStyle.Render("› ") + label
+		} else {
+			label = "  " + label
+		}
+		lines = append(lines, label)
+	}
+	offset := max(0, min(cursorLine-bodyHeight/2, len(lines)-bodyHeight))
+	lines = lines[offset:min(len(lines), offset+bodyHeight)]
+	list := lipgloss.NewStyle().Width(listWidth).Height(bodyHeight).MaxWidth(listWidth).Render(strings.Join(lines, "\n"))
+
+	panel := browsePanelStyle.Render(m.panel.View())
+	help := m.status
+	if help == "" {
+		help = "↑/↓ move · enter copy name · ctrl+o open source · pgup/pgdn scroll · esc clear/quit"
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		search,
+		lipgloss.JoinHorizontal(lipgloss.Top, list, " ", panel),
+		browseDimStyle.Render(help),
+	)
+}
+
+// ============================================================================
+// Headless mode: CI, containers, and pipes
+// ============================================================================
+
+// ciEnvVars are set by common CI providers.
+var ciEnvVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "JENKINS_URL", "TF_BUILD", "TEAMCITY_VERSION"}
+
+// detectHeadless returns why the interactive form should not run, or "" when a person
+// is at a terminal. The environment is passed in so tests can fake it.
+func detectHeadless(getenv func(string) string, stdinTTY, stdoutTTY bool) string {
+	var reason string
+	for _, name := range ciEnvVars {
+		if v := getenv(name); v != "" && v != "false" && v != "0" {
+			reason = fmt.Sprintf("CI environment detected (%s is set)", name)
+			break
+		}
+	}
+	if reason == "" {
+		switch {
+		case !stdinTTY:
+			reason = "standard input is not a terminal"
+		case !stdoutTTY:
+			reason = "standard output is not a terminal"
+		default:
+			return ""
+		}
+	}
+	if inContainer(getenv) {
+		reason += " inside a container"
+	}
+	return reason
+}
+
+// inContainer reports whether claudekit runs in a Docker, Podman, or Kubernetes container.
+func inContainer(getenv func(string) string) bool {
+	if getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal reports whether f is a character device such as a TTY.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runHeadless generates cfg without the form. Writing requires confirmed (--yes) so
+// a stray invocation in CI cannot overwrite a configuration; without it the plan is
+// printed and nothing is written.
+func runHeadless(cfg Config, persistedConfig *PersistenceConfig, registry *ModuleRegistry, reason string, confirmed bool) int {
+	fmt.Fprintf(os.Stderr, "claudekit: %s; skipping the interactive form.\n", reason)
+	fmt.Fprint(os.Stderr, describeHeadlessPlan(cfg))
+	if !confirmed {
+		fmt.Fprintln(os.Stderr, "Nothing was written. Re-run with --yes to generate this configuration, or with --interactive to open the form anyway.")
+		return exitCancelled
+	}
+	cfg.Confirmed = true
+	return applyConfiguration(cfg, persistedConfig, registry)
+}
+
+// describeHeadlessPlan lists what a headless run generates from saved choices and defaults.
+func describeHeadlessPlan(cfg Config) string {
+	var b strings.Builder
+	scope := "project (current directory)"
+	if !cfg.IsProjectLocal {
+		scope = "global (~/.claude)"
+	}
+	fmt.Fprintf(&b, "Configuration from saved choices and defaults:\n")
+	fmt.Fprintf(&b, "  scope:       %s\n", scope)
+	for _, row := range []struct {
+		label string
+		items []string
+	}{
+		{"languages", cfg.Languages},
+		{"subagents", cleanFormValues(cfg.Subagents)},
+		{"hooks", cleanFormValues(cfg.Hooks)},
+		{"commands", cfg.SlashCommands},
+		{"mcp servers", mcpServerLabels(cfg)},
+		{"permissions", cfg.Permissions},
+		{"editor tasks", cfg.EditorTasks},
+		{"workflows", cfg.Workflows},
+		{"container", devcontainerLabel(cfg)},
+		{"memory", memoryFileLabels(cfg)},
+		{"gitignore", gitignoreEntries(cfg)},
+		{"packages", cfg.Packages},
+	} {
+		items := strings.Join(row.items, ", ")
+		if items == "" {
+			items = "(none)"
+		}
+		fmt.Fprintf(&b, "  %-12s %s\n", row.label+":", items)
+	}
+	return b.String()
+}
+
+// cleanupDeselectedItems removes files for items that were previously selected but now deselected
+func cleanupDeselectedItems(files fsys.FS, cfg Config, persistedConfig *PersistenceConfig, targetDir string) error {
+	// Deselected files live wherever the previous run put them
+	layout := layoutFor(targetDir, persistedConfig.Layout)
+	
+	// Clean up deselected subagents
+	for _, oldAgent := range persistedConfig.Subagents {
+		if !slices.Contains(cfg.Subagents, oldAgent) {
+			agentFile := filepath.Join(manifest.Dir(targetDir, layout.Agents), oldAgent+".md")
+			removeDeselected(files, agentFile, "agent")
+		}
+	}
+	
+	// Clean up deselected hooks
+	for _, oldHook := range persistedConfig.Hooks {
+		if !slices.Contains(cfg.Hooks, oldHook) {
+			// Hook scripts are shell, Python, Node, or PowerShell depending on the module and --hook-lang
+			for _, ext := range []string{".sh", ".py", ".js", ".ps1"} {
+				hookFile := filepath.Join(manifest.Dir(targetDir, layout.Hooks), oldHook+ext)
+				removeDeselected(files, hookFile, "hook")
+			}
+		}
+	}
+	
+	// Clean up deselected slash commands
+	commandsDir := manifest.Dir(targetDir, layout.Commands)
+	mf, _ := manifest.Load(targetDir)
+	for _, oldCmd := range persistedConfig.SlashCommands {
+		if !slices.Contains(cfg.SlashCommands, oldCmd) {
+			// Remove both .md and .py files (legacy .py support)
+			cmdFiles := []string{filepath.Join(commandsDir, oldCmd+".md"), filepath.Join(commandsDir, oldCmd+".py")}
+			// A namespaced command is wherever the manifest recorded it
+			if mf != nil {
+				for _, entry := range mf.Files {
+					if entry.Kind == manifest.KindCommand && entry.Module == cleanFormValue(oldCmd) {
+						cmdFiles = append(cmdFiles, entry.AbsPath(targetDir))
+					}
+				}
+			}
+			for _, cmdFile := range cmdFiles {
+				if removeDeselected(files, cmdFile, "command") {
+					removeEmptyNamespaceDir(files, filepath.Dir(cmdFile), commandsDir)
+				}
+			}
+		}
+	}
+	
+	// Clean up a custom output style that is no longer selected
+	if old := persistedConfig.OutputStyle; old != "" && old != cfg.OutputStyle {
+		styleFile := filepath.Join(targetDir, ".claude", "output-styles", old+".md")
+		removeDeselected(files, styleFile, "output style")
+	}
+
+	// Clean up the statusline script once no statusline is selected
+	if persistedConfig.Statusline != "" && cfg.Statusline == "" {
+		scriptFile := filepath.Join(targetDir, ".claude", "statusline.sh")
+		removeDeselected(files, scriptFile, "statusline")
+	}
+
+	// Clean up the teammate setup doc once it is no longer wanted
+	if persistedConfig.SetupDoc && !cfg.SetupDoc {
+		docFile := filepath.Join(targetDir, "docs", "CLAUDE-SETUP.md")
+		removeDeselected(files, docFile, "setup doc")
+	}
+
+	// Clean up the GitHub workflow once no job is selected
+	if len(persistedConfig.Workflows) > 0 && len(cfg.Workflows) == 0 {
+		workflowFile := filepath.Join(targetDir, ".github", "workflows", "claude.yml")
+		removeDeselected(files, workflowFile, "GitHub workflow")
+	}
+
+	// Clean up the dev container files once they are no longer wanted; a
+	// devcontainer.json is only removed when claudekit wrote it
+	if persistedConfig.Devcontainer && !cfg.Devcontainer {
+		dir := filepath.Join(targetDir, ".devcontainer")
+		removeDeselected(files, filepath.Join(dir, "claude-setup.sh"), "dev container setup")
+		if _, ok := mf.Lookup(".devcontainer/devcontainer.json"); ok {
+			removeDeselected(files, filepath.Join(dir, "devcontainer.json"), "devcontainer.json")
+		}
+		_ = removeIfEmpty(files, dir)
+	}
+
+	// Clean up seeded memory files once they are no longer wanted, unless the user
+	// has written in them since
+	if mf != nil {
+		for _, entry := range mf.Files {
+			deselected := entry.Kind == manifest.KindClaudeLocal && !wantsMemoryFile(cfg, memoryLocal) ||
+				entry.Kind == manifest.KindMemory && !wantsMemoryFile(cfg, memorySeeds)
+			if modified, err := entry.IsModified(targetDir); deselected && err == nil && !modified {
+				removeDeselected(files, entry.AbsPath(targetDir), "memory file")
+			}
+		}
+		_ = removeIfEmpty(files, filepath.Join(targetDir, filepath.FromSlash(memoryDir)))
+	}
+
+	// Take the setup section back out of README.md, leaving the user's text
+	if persistedConfig.SetupReadme && !cfg.SetupReadme {
+		readme := filepath.Join(targetDir, "README.md")
+		if changed, _, err := stripReadmeSection(files, readme, false); err != nil {
+			slog.Warn("failed to remove the setup section from README.md", "err", err)
+		} else if changed {
+			slog.Info("removed deselected setup section", "path", readme)
+		}
+	}
+
+	return nil
+}
+
+// removeDeselected removes path, which a choice no longer selected left behind, and
+// reports whether it did. A file that is already gone is not an error.
+func removeDeselected(files fsys.FS, path, what string) bool {
+	if _, err := files.Stat(path); err != nil {
+		return false
+	}
+	if err := files.Remove(path); err != nil {
+		slog.Warn("failed to remove deselected "+what, "path", path, "err", err)
+		return false
+	}
+	slog.Info("removed deselected "+what, "path", path)
+	return true
+}
+
+// layoutFor returns the layout recorded by the last run in baseDir, or fallback
+// (with defaults applied) when there is no manifest.
+func layoutFor(baseDir string, fallback manifest.Layout) manifest.Layout {
+	if mf, err := manifest.Load(baseDir); err == nil {
+		return mf.Layout
+	}
+	return fallback.WithDefaults()
+}
+
+// layoutHookCommand points a module's default hook command at the configured hooks
+// directory. Commands outside .claude/hooks are returned unchanged.
+func layoutHookCommand(command string, layout manifest.Layout) string {
+	const defaultDir = "/.claude/hooks/"
+	hooksDir := "/" + layout.WithDefaults().Hooks + "/"
+	if hooksDir == defaultDir {
+		return command
+	}
+	return strings.Replace(command, defaultDir, hooksDir, 1)
+}
+
+// scopeHookCommand makes a hook command work in the configuration's scope. Project
+// settings keep $CLAUDE_PROJECT_DIR so they can be shared; in global settings that
+// variable points at whichever project Claude Code is opened in, so it is replaced
+// with baseDir, where the scripts were actually written.
+func scopeHookCommand(command, baseDir string, projectLocal bool) string {
+	if projectLocal || !strings.Contains(command, "CLAUDE_PROJECT_DIR") {
+		return command
+	}
+	dir := filepath.ToSlash(baseDir)
+	fields := splitHookCommand(command)
+	for i, field := range fields {
+		field = strings.ReplaceAll(field, "${CLAUDE_PROJECT_DIR}", dir)
+		fields[i] = quoteHookArg(strings.ReplaceAll(field, "$CLAUDE_PROJECT_DIR", dir))
+	}
+	return strings.Join(fields, " ")
+}
+
+// quoteHookArg wraps an argument containing spaces or shell metacharacters in double
+// quotes, the one quoting style bash, cmd, and PowerShell all understand. Paths never
+// contain double quotes on Windows, so no escaping is needed.
+func quoteHookArg(arg string) string {
+	if strings.ContainsAny(arg, " \t&()^;'|<>") {
+		return `"` + arg + `"`
+	}
+	return arg
+}
+
+// powershellHookCommand runs a .ps1 hook script through PowerShell with the execution
+// policy bypassed, since scripts are not executable on their own. Windows ships
+// powershell; elsewhere PowerShell 7 is installed as pwsh.
+func powershellHookCommand(command string) string {
+	fields := splitHookCommand(command)
+	if len(fields) == 0 {
+		return command
+	}
+	shell := "pwsh"
+	if targetOS == "windows" {
+		shell = "powershell"
+	}
+	args := []string{shell, "-NoProfile", "-ExecutionPolicy", "Bypass", "-File", `"` + fields[0] + `"`}
+	for _, arg := range fields[1:] {
+		args = append(args, quoteHookArg(arg))
+	}
+	return strings.Join(args, " ")
+}
+
+// splitHookCommand splits a hook command into fields, keeping double-quoted strings
+// together and dropping the quotes.
+func splitHookCommand(command string) []string {
+	var fields []string
+	var field strings.Builder
+	inQuote, inField := false, false
+	for _, r := range command {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			inField = true
+		case unicode.IsSpace(r) && !inQuote:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// hookCommandScript returns the script a hook command runs: its first field, or the
+// argument to -File when the command starts PowerShell.
+func hookCommandScript(command string) string {
+	fields := splitHookCommand(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	shell := strings.TrimSuffix(strings.ToLower(path.Base(strings.ReplaceAll(fields[0], `\`, "/"))), ".exe")
+	if shell == "powershell" || shell == "pwsh" {
+		for i, field := range fields[:len(fields)-1] {
+			if strings.EqualFold(field, "-File") {
+				return fields[i+1]
+			}
+		}
+		return ""
+	}
+	return fields[0]
+}
+
+// isGlobalBaseDir reports whether baseDir is the global configuration directory.
+func isGlobalBaseDir(baseDir string) bool {
+	global, err := resolveTargetDir(false)
+	return err == nil && filepath.Clean(global) == filepath.Clean(baseDir)
+}
+
+// resolveTargetDir returns the base directory claudekit generates into: the current
+// directory for project-local configurations, or ~/.claude for global ones.
+func resolveTargetDir(isProjectLocal bool) (string, error) {
+	if isProjectLocal {
+		// Project-specific: use current directory
+		targetDir, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		return filepath.Abs(targetDir)
+	}
+
+	// Global: use home directory with .claude subdirectory
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Abs(filepath.Join(homeDir, ".claude"))
+}
+
+func run(cfg Config, registry *ModuleRegistry) error {
+	abs, err := resolveTargetDir(cfg.IsProjectLocal)
+	if err != nil {
+		return err
+	}
+	issues, err := generate(abs, cfg, registry)
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return &hookVerificationError{Issues: issues}
+	}
+	if projectServers, _ := mcpScopes(cfg); len(projectServers) > 0 {
+		reportMCPHealth(filepath.Join(abs, ".mcp.json"), cfg.ProbeMCP)
+	}
+
+	// Servers for every project live in Claude Code's own user config
+	if _, userServers := mcpScopes(cfg); len(userServers) > 0 {
+		fmt.Println("\n🔌 Register these MCP servers for your user, in every project:")
+		for _, command := range mcpAddCommands(userServers) {
+			fmt.Println("   " + command)
+		}
+	}
+
+	// Gentle reminder if claude CLI is missing
+	if _, err := exec.LookPath("claude"); err != nil {
+		fmt.Println("\nℹ️  Claude Code CLI not found on PATH. Install with:")
+		fmt.Println("   curl -fsSL https://claude.ai/install.sh | bash   # macOS/Linux/WSL")
+	}
+
+	return nil
+}
+
+// Generator writes one kind of artifact of a configuration. New artifact types
+// are added to generators rather than to generate itself.
+type Generator interface {
+	Generate(r *generationRun) error
+}
+
+// generationRun is what the generators of one generate call share.
+type generationRun struct {
+	abs      string // The configuration's base directory
+	cfg      Config
+	registry *ModuleRegistry
+	layout   manifest.Layout // cfg.Layout with defaults filled in
+	w        *generationWriter
+	settings settings // Set by SettingsGenerator, for the hook checks after generating
+}
+
+// generators run in order: settings.json points at the hook scripts, output style,
+// and statusline written before it.
+var generators = []Generator{
+	ClaudeMDGenerator{},
+	MemoryFilesGenerator{},
+	GitignoreGenerator{},
+	SetupDocGenerator{},
+	AgentsGenerator{},
+	HooksGenerator{},
+	OutputStyleGenerator{},
+	StatuslineGenerator{},
+	SettingsGenerator{},
+	CommandsGenerator{},
+	EditorTasksGenerator{},
+	GitHubWorkflowGenerator{},
+	DevcontainerGenerator{},
+	MCPGenerator{},
+}
+
+// ClaudeMDGenerator writes CLAUDE.md. It runs first, so it also creates the target
+// directory, which for a global configuration may not exist yet.
+type ClaudeMDGenerator struct{}
+
+func (ClaudeMDGenerator) Generate(r *generationRun) error {
+	r.w.mkdir(r.abs)
+	return r.w.writeClaudeMD(filepath.Join(r.abs, "CLAUDE.md"), renderClaudeMD(r.cfg, r.registry))
+}
+
+// MemoryFilesGenerator seeds CLAUDE.local.md and the notes in .claude/memory/ in
+// project configurations. Each is written only when missing and is the user's from
+// then on. GitignoreGenerator keeps CLAUDE.local.md out of git.
+type MemoryFilesGenerator struct{}
+
+func (MemoryFilesGenerator) Generate(r *generationRun) error {
+	if wantsMemoryFile(r.cfg, memoryLocal) {
+		if err := r.w.seed(filepath.Join(r.abs, "CLAUDE.local.md"), []byte(renderClaudeLocal(r.cfg, r.registry)), manifest.KindClaudeLocal); err != nil {
+			return err
+		}
+	}
+	if wantsMemoryFile(r.cfg, memorySeeds) {
+		dir := filepath.Join(r.abs, filepath.FromSlash(memoryDir))
+		r.w.mkdir(dir)
+		for _, name := range memorySeedFiles() {
+			content, err := assets.ReadFile("assets/templates/memory/" + name)
+			if err != nil {
+				return err
+			}
+			if err := r.w.seed(filepath.Join(dir, name), content, manifest.KindMemory); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GitignoreGenerator keeps claudekit's block of .gitignore in step with the local
+// files the configuration leaves in a project, and takes the block out once there
+// are none.
+type GitignoreGenerator struct{}
+
+func (GitignoreGenerator) Generate(r *generationRun) error {
+	if !r.cfg.IsProjectLocal || r.cfg.Package != "" {
+		return nil
+	}
+	return r.w.writeGitignore(filepath.Join(r.abs, ".gitignore"), gitignoreEntries(r.cfg))
+}
+
+// SetupDocGenerator documents the setup for human teammates in docs/CLAUDE-SETUP.md,
+// a section of README.md, or both. A global configuration has no repository to hold it.
+type SetupDocGenerator struct{}
+
+func (SetupDocGenerator) Generate(r *generationRun) error {
+	if !r.cfg.IsProjectLocal {
+		return nil
+	}
+	if r.cfg.SetupDoc {
+		docsDir := filepath.Join(r.abs, "docs")
+		r.w.mkdir(docsDir)
+		if _, err := r.w.write(filepath.Join(docsDir, "CLAUDE-SETUP.md"), []byte(renderSetupDoc(r.cfg, r.registry)), 0o644, manifest.KindSetupDoc, ""); err != nil {
+			return err
+		}
+	}
+	if r.cfg.SetupReadme {
+		return r.w.writeReadmeSection(filepath.Join(r.abs, "README.md"), renderSetupReadmeSection(r.cfg, r.registry))
+	}
+	return nil
+}
+
+// AgentsGenerator writes the selected subagents, built-in and custom.
+type AgentsGenerator struct{}
+
+func (AgentsGenerator) Generate(r *generationRun) error {
+	agentsDir := manifest.Dir(r.abs, r.layout.Agents)
+	r.w.mkdir(agentsDir)
+	for _, a := range r.cfg.Subagents {
+		content := renderAgent(a, r.cfg, r.registry)
+		if i := slices.IndexFunc(r.cfg.CustomSubagents, func(c generation.CustomSubagent) bool { return c.Name == a }); i >= 0 {
+			agent := r.cfg.CustomSubagents[i]
+			if model, ok := parseAgentModelLines(r.cfg.AgentModels)[a]; ok {
+				agent.Model = model
+			}
+			if tools, ok := r.cfg.AgentTools[a]; ok {
+				agent.Tools = tools
+			}
+			content = generation.RenderCustomSubagent(agent)
+		}
+		if _, err := r.w.write(filepath.Join(agentsDir, a+".md"), []byte(content), 0o644, manifest.KindAgent, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HooksGenerator writes the selected hook scripts and the helper libraries they use.
+type HooksGenerator struct{}
+
+func (HooksGenerator) Generate(r *generationRun) error {
+	cfg, registry, w := r.cfg, r.registry, r.w
+	hooksDir := manifest.Dir(r.abs, r.layout.Hooks)
+	w.mkdir(hooksDir)
+
+	hookLangs := map[hookLanguage]bool{}
+	for _, hookDisplay := range cfg.Hooks {
+		hookName := cleanFormValue(hookDisplay)
+		lang, err := resolveHookLanguage(hookName, registry.Get(TypeHook, hookName), cfg.HookLanguages)
+		if err != nil {
+			return err
+		}
+		content, ok := hookScriptContent(hookName, lang, cfg.Languages, registry)
+		if !ok {
+			continue
+		}
+
+		hookPath := filepath.Join(hooksDir, hookScriptName(hookName, lang))
+		if _, err := w.write(hookPath, executableContent(hookPath, content), 0o755, manifest.KindHook, hookName); err != nil {
+			return err
+		}
+		if !standaloneHooks[hookName] {
+			hookLangs[lang] = true
+		}
+		// Drop the script a previous run wrote in another language
+		for other := range hookLanguageExt {
+			if other != lang {
+				w.removeStale(filepath.Join(hooksDir, hookScriptName(hookName, other)))
+			}
+		}
+	}
+	return writeHookLibraries(w, hooksDir, hookLangs)
+}
+
+// OutputStyleGenerator writes the custom output style; built-in styles only need
+// the settings key.
+type OutputStyleGenerator struct{}
+
+func (OutputStyleGenerator) Generate(r *generationRun) error {
+	style := r.registry.Get(TypeStyle, r.cfg.OutputStyle)
+	if style == nil {
+		return nil
+	}
+	content, ok := renderOutputStyle(style, r.cfg)
+	if !ok {
+		return nil
+	}
+	stylesDir := filepath.Join(r.abs, ".claude", "output-styles")
+	r.w.mkdir(stylesDir)
+	_, err := r.w.write(filepath.Join(stylesDir, style.Name+".md"), []byte(content), 0o644, manifest.KindStyle, style.Name)
+	return err
+}
+
+// StatuslineGenerator writes the statusline script that settings.json points at.
+type StatuslineGenerator struct{}
+
+func (StatuslineGenerator) Generate(r *generationRun) error {
+	module := r.registry.Get(TypeStatusline, r.cfg.Statusline)
+	if module == nil {
+		return nil
+	}
+	content, ok := renderStatusline(module, r.cfg)
+	if !ok {
+		return nil
+	}
+	r.w.mkdir(filepath.Join(r.abs, ".claude"))
+	_, err := r.w.write(filepath.Join(r.abs, ".claude", "statusline.sh"), []byte(content), 0o755, manifest.KindStatusline, module.Name)
+	return err
+}
+
+// SettingsGenerator writes settings.json with hooks, permissions, and env, and
+// records the keys claudekit owns.
+type SettingsGenerator struct{}
+
+func (SettingsGenerator) Generate(r *generationRun) error {
+	w := r.w
+	var err error
+	if r.settings, err = buildSettings(r.abs, r.cfg, r.registry); err != nil {
+		return err
+	}
+	buf, _ := json.MarshalIndent(r.settings, "", "  ")
+	if errs := schema.Settings(".claude/settings.json", buf); len(errs) > 0 {
+		return joinSchemaErrors(errs)
+	}
+	w.mkdir(filepath.Join(r.abs, ".claude"))
+	wrote, err := w.write(filepath.Join(r.abs, ".claude", "settings.json"), buf, 0o644, manifest.KindSettings, "")
+	if err != nil {
+		return err
+	}
+	if wrote {
+		w.current.Settings = settingsOwnership(r.settings)
+	} else if w.previous != nil {
+		w.current.Settings = w.previous.Settings
+	}
+	return nil
+}
+
+// CommandsGenerator writes the selected slash commands into their namespaces.
+type CommandsGenerator struct{}
+
+func (CommandsGenerator) Generate(r *generationRun) error {
+	w := r.w
+	commandsDir := manifest.Dir(r.abs, r.layout.Commands)
+	for _, cmdDisplay := range r.cfg.SlashCommands {
+		cmdName := cleanFormValue(cmdDisplay)
+		var content string
+		if cmdName == "example" {
+			content = sampleSlashCommand()
+		} else {
+			content = generateSlashCommand(cmdName, r.cfg, r.registry)
+		}
+
+		cmdPath := commandPath(commandsDir, cmdName, r.registry.Get(TypeCommand, cmdName))
+		w.mkdir(filepath.Dir(cmdPath))
+		if _, err := w.write(cmdPath, []byte(content), 0o644, manifest.KindCommand, cmdName); err != nil {
+			return err
+		}
+		// Drop the file a previous run wrote under another namespace
+		if w.previous != nil {
+			for _, entry := range w.previous.Files {
+				if entry.Kind == manifest.KindCommand && entry.Module == cmdName && entry.AbsPath(r.abs) != cmdPath {
+					w.removeStale(entry.AbsPath(r.abs))
+					removeEmptyNamespaceDir(w.fs, filepath.Dir(entry.AbsPath(r.abs)), commandsDir)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// EditorTasksGenerator mirrors the shell workflows of the selected slash commands
+// as editor tasks, in project configurations only.
+type EditorTasksGenerator struct{}
+
+func (EditorTasksGenerator) Generate(r *generationRun) error {
+	if !r.cfg.IsProjectLocal {
+		return nil
+	}
+	return writeEditorTasks(r.w, r.cfg, r.registry)
+}
+
+// GitHubWorkflowGenerator writes .github/workflows/claude.yml for the selected jobs,
+// in project configurations only.
+type GitHubWorkflowGenerator struct{}
+
+func (GitHubWorkflowGenerator) Generate(r *generationRun) error {
+	if !r.cfg.IsProjectLocal || len(r.cfg.Workflows) == 0 {
+		return nil
+	}
+	content := renderGitHubWorkflow(r.cfg, r.registry)
+	var doc any
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return fmt.Errorf(".github/workflows/claude.yml is not valid YAML: %w", err)
+	}
+	dir := filepath.Join(r.abs, ".github", "workflows")
+	r.w.mkdir(dir)
+	_, err := r.w.write(filepath.Join(dir, "claude.yml"), []byte(content), 0o644, manifest.KindGitHubWorkflow, "")
+	return err
+}
+
+// DevcontainerGenerator writes .devcontainer/claude-setup.sh, and a devcontainer.json
+// that runs it when the project has none of its own, in project configurations only.
+type DevcontainerGenerator struct{}
+
+func (DevcontainerGenerator) Generate(r *generationRun) error {
+	if !r.cfg.IsProjectLocal || !r.cfg.Devcontainer {
+		return nil
+	}
+	w := r.w
+	dir := filepath.Join(r.abs, ".devcontainer")
+	w.mkdir(dir)
+	if _, err := w.write(filepath.Join(dir, "claude-setup.sh"), []byte(renderDevcontainerSetup(r.cfg, r.registry)), 0o755, manifest.KindDevcontainer, ""); err != nil {
+		return err
+	}
+
+	// A devcontainer.json claudekit did not write is the user's; point them at the script
+	configPath := filepath.Join(dir, "devcontainer.json")
+	rel := manifest.RelPath(w.baseDir, configPath)
+	if existing, err := w.fs.ReadFile(configPath); err == nil {
+		if _, ours := w.previous.Lookup(rel); !ours {
+			if !bytes.Contains(existing, []byte(devcontainerSetupCommand)) {
+				slog.Warn("run the Claude Code setup from your "+rel, "postCreateCommand", devcontainerSetupCommand)
+			}
+			return nil
+		}
+	}
+	_, err := w.write(configPath, []byte(buildDevcontainerJSON(r.cfg)), 0o644, manifest.KindDevcontainer, "")
+	return err
+}
+
+// MCPGenerator writes the project's .mcp.json. Servers registered for the user are
+// left to claude mcp add-json.
+type MCPGenerator struct{}
+
+func (MCPGenerator) Generate(r *generationRun) error {
+	w := r.w
+	projectServers, _ := mcpScopes(r.cfg)
+	if len(projectServers) == 0 {
+		return nil
+	}
+	mcp := buildMCPJSON(projectServers)
+	if errs := schema.MCP(".mcp.json", []byte(mcp)); len(errs) > 0 {
+		return joinSchemaErrors(errs)
+	}
+	wrote, err := w.write(filepath.Join(r.abs, ".mcp.json"), []byte(mcp), 0o644, manifest.KindMCP, "")
+	if err != nil {
+		return err
+	}
+	if wrote {
+		w.current.MCPServers = append(w.current.MCPServers, projectServers...)
+	} else if w.previous != nil {
+		w.current.MCPServers = append(w.current.MCPServers, w.previous.MCPServers...)
+	}
+	return nil
+}
+
+// generate writes cfg's configuration into abs and then into each selected workspace
+// package. It returns the hook commands in the written settings that will not r.
+func generate(abs string, cfg Config, registry *ModuleRegistry) ([]hookIssue, error) {
+	return generateInto(fsys.OS{}, abs, cfg, registry)
+}
+
+// generateInto is generate writing through files, so tests can run the whole
+// pipeline in memory.
+func generateInto(files fsys.FS, abs string, cfg Config, registry *ModuleRegistry) ([]hookIssue, error) {
+	if err := cfg.Layout.Validate(); err != nil {
+		return nil, usageError(err)
+	}
+	layout := cfg.Layout.WithDefaults()
+
+	// Track every generated file so `claudekit clean` can remove exactly what we wrote,
+	// and so files the user edited since the last run are not silently overwritten
+	w, err := newGenerationWriter(files, abs, cfg.callbacks, registry)
+	if err != nil {
+		return nil, err
+	}
+	w.assetRenames = cfg.AssetRenames
+	mf := w.current
+	mf.Layout = layout
+	if !cfg.NoFmt {
+		rules, err := loadFormatRules(files, abs)
+		if err != nil {
+			return nil, err
+		}
+		w.format = &rules
+	}
+
+	r := &generationRun{abs: abs, cfg: cfg, registry: registry, layout: layout, w: w}
+	for _, g := range generators {
+		if err := g.Generate(r); err != nil {
+			if len(mf.Files) > 0 {
+				return nil, partialError(err)
+			}
+			return nil, err
+		}
+	}
+	st := r.settings
+
+	// Workspace packages inherit this configuration; each keeps its own manifest
+	var packageIssues []hookIssue
+	if cfg.IsProjectLocal {
+		for _, dir := range cfg.Packages {
+			pkgAbs, err := packageDir(abs, dir)
+			if err != nil {
+				return nil, err
+			}
+			issues, err := generateInto(files, pkgAbs, packageConfig(cfg, dir, pkgAbs, registry), registry)
+			if err != nil {
+				return nil, fmt.Errorf("package %s: %w", dir, err)
+			}
+			packageIssues = append(packageIssues, issues...)
+			mf.Packages = append(mf.Packages, dir)
+		}
+	}
+
+	if err := w.saveManifest(); err != nil {
+		return nil, fmt.Errorf("failed to write generation manifest: %w", err)
+	}
+
+	if len(w.skipped) > 0 {
+		fmt.Printf("\nℹ️  Kept %d modified file(s) unchanged:\n", len(w.skipped))
+		for _, rel := range w.skipped {
+			fmt.Printf("   %s\n", path.Join(cfg.Package, rel))
+		}
+	}
+	if len(w.formatted) > 0 {
+		fmt.Printf("\n✏️  Formatted %d generated markdown file(s); pass --no-fmt to write them as rendered:\n", len(w.formatted))
+		for _, result := range w.formatted {
+			fmt.Printf("   %s%s\n", path.Join(cfg.Package, result.File.RelPath), formatRuleCounts(result.RulesApplied))
+		}
+	}
+
+	// Verify every hook command referenced from settings.json will actually r.
+	// Claude Code silently skips hooks it cannot execute, so surface problems now.
+	issues := verifyHookCommands(files, abs, st)
+	if !cfg.IsProjectLocal {
+		issues = append(verifyGlobalHookCommands(st), issues...)
+	}
+	return append(issues, packageIssues...), nil
+}
+
+func writeExecutable(path string, content string) error {
+	return os.WriteFile(path, executableContent(path, content), 0o755)
+}
+
+// builtinHookDescriptions describes the hooks claudekit generates a script for.
+var builtinHookDescriptions = map[string]string{
+	"pre-tool-use":       "Runs before Claude executes any tool",
+	"post-tool-use":      "Runs after successful tool execution",
+	"notification":       "Runs when Claude needs permission or when prompts idle",
+	"user-prompt-submit": "Runs when users submit prompts, before Claude processes them",
+	"stop":               "Runs when Claude finishes responding",
+	"subagent-stop":      "Runs when Claude Code subagents finish responding",
+	"session-end":        "Runs when Claude Code sessions terminate",
+	"pre-compact":        "Runs before context compaction operations",
+	"session-start":      "Runs when Claude Code sessions start, loading project context",
+	"secret-scan":        "Runs before Claude writes or edits a file, blocking likely secrets",
+	"usage-log":          "Runs when Claude Code sessions end, recording their usage",
+	"test-runner":        "Runs the tests affected by each file Claude writes or edits",
+	"branch-guard":       "Blocks edits on protected branches and in conflicted working trees",
+	"notify-slack":       "Posts to Slack when Claude needs you or finishes responding",
+	"notify-discord":     "Posts to Discord when Claude needs you or finishes responding",
+	"notify-desktop":     "Shows a desktop notification when Claude needs you or finishes",
+}
+
+// hookScriptAssets are the hooks whose script is an embedded file under assets/hooks
+// rather than a starter generated by generateHookScript.
+var hookScriptAssets = map[string]string{
+	"usage-log":      "usage-log.py",
+	"notify-slack":   "notify-slack.sh",
+	"notify-discord": "notify-discord.sh",
+	"notify-desktop": "notify-desktop.sh",
+}
+
+// standaloneHooks have scripts that do not use the hook helper libraries.
+var standaloneHooks = map[string]bool{"session-start": true, "secret-scan": true, "usage-log": true}
+
+// hookLibraries are the helper libraries bash and Python hooks import, by language;
+// they are installed in the lib directory beside the hook scripts.
+var hookLibraries = []struct {
+	Lang hookLanguage
+	File string
+}{
+	{hookLangBash, "hook.sh"},
+	{hookLangPython, "hook.py"},
+}
+
+// writeHookLibraries installs the helper library of each language in langs and
+// removes the libraries no hook uses anymore.
+func writeHookLibraries(w *generationWriter, hooksDir string, langs map[hookLanguage]bool) error {
+	for _, lib := range hookLibraries {
+		libPath := filepath.Join(hooksDir, "lib", lib.File)
+		if !langs[lib.Lang] {
+			w.removeStale(libPath)
+			continue
+		}
+		content, err := assets.ReadFile("assets/hooks/lib/" + lib.File)
+		if err != nil {
+			return err
+		}
+		w.mkdir(filepath.Dir(libPath))
+		if _, err := w.write(libPath, content, 0o644, manifest.KindHookLib, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hookTemplateData is what a hooks/<hook><ext>.tmpl override renders.
+type hookTemplateData struct {
+	Name        string
+	Description string
+	Language    string // bash, python, node, or powershell
+}
+
+// hookScriptContent returns the script run writes for a built-in hook, preferring the
+// user's template override, or false for a hook claudekit does not generate a script for.
+// langs are the project's languages, for hooks generated per language.
+func hookScriptContent(hookName string, lang hookLanguage, langs []string, registry *ModuleRegistry) (string, bool) {
+	description, ok := builtinHookDescriptions[hookName]
+	if !ok {
+		return "", false
+	}
+	switch hookName {
+	case "secret-scan":
+		return secretScanScript(registry.Get(TypeHook, hookName), description, registry), true
+	case "test-runner":
+		return testRunnerScript(registry.Get(TypeHook, hookName), description, langs, registry), true
+	case "branch-guard":
+		return branchGuardScript(registry.Get(TypeHook, hookName), description, registry), true
+	}
+	data := hookTemplateData{Name: hookName, Description: description, Language: string(lang)}
+	if content, ok := registry.renderTemplateOverride("hooks/"+hookScriptName(hookName, lang)+".tmpl", data); ok {
+		return content, true
+	}
+	if hookName == "session-start" {
+		return sessionStartScript(), true // Use existing script
+	}
+	if asset, ok := hookScriptAssets[hookName]; ok {
+		content, err := assets.ReadFile("assets/hooks/" + asset)
+		if err != nil {
+			panic(err)
+		}
+		return string(content), true
+	}
+	return generateHookScript(hookName, description, lang), true
+}
+
+// secretScanData is what the secret-scan hook's template renders. Rules and Allowlist
+// are JSON arrays, which are also Python literals.
+type secretScanData struct {
+	Name        string
+	Description string
+	Rules       string // [{"name", "pattern", "example"}, ...]
+	Allowlist   string // ["pattern", ...]
+}
+
+// secretScanRule is one rule of the secret-scan hook's defaults.
+type secretScanRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Example string `json:"example"`
+}
+
+// testRunnerData is what the test-runner hook's template renders.
+type testRunnerData struct {
+	languageFlags
+	Name        string
+	Description string
+	Timeout     int // The hook's timeout in settings.json, in seconds
+	TestTimeout int // How long the tests may run, leaving time to report a failure
+}
+
+// testRunnerScript renders the test-runner hook for the selected languages, giving
+// the tests most of the module's timeout.
+func testRunnerScript(module *ComponentModule, description string, langs []string, registry *ModuleRegistry) string {
+	timeout := 300
+	if t := hookTimeout(module); t > 0 {
+		timeout = t
+	}
+	data := testRunnerData{
+		languageFlags: newLanguageFlags(langs),
+		Name:          "test-runner",
+		Description:   description,
+		Timeout:       timeout,
+		TestTimeout:   max(timeout-15, timeout*3/4),
+	}
+	return renderTemplate(registry, testRunnerTemplate, "assets/hooks/test-runner.sh.tmpl", data)
+}
+
+// branchGuardData is what the branch-guard hook's template renders.
+type branchGuardData struct {
+	Name        string
+	Description string
+	Protected   []string // Branch name globs, as in release/*
+}
+
+// branchGuardScript renders the branch-guard hook with the protected branches from
+// the module's defaults.
+func branchGuardScript(module *ComponentModule, description string, registry *ModuleRegistry) string {
+	data := branchGuardData{Name: "branch-guard", Description: description}
+	if module != nil {
+		list, _ := module.Defaults["protected_branches"].([]any)
+		for _, branch := range list {
+			if branch, ok := branch.(string); ok && branch != "" {
+				data.Protected = append(data.Protected, branch)
+			}
+		}
+	}
+	return renderTemplate(registry, branchGuardTemplate, "assets/hooks/branch-guard.sh.tmpl", data)
+}
+
+// secretScanScript renders the secret-scan hook with the rules and allowlist from
+// the module's defaults.
+func secretScanScript(module *ComponentModule, description string, registry *ModuleRegistry) string {
+	var rules []secretScanRule
+	var allowlist []string
+	if module != nil {
+		list, _ := module.Defaults["rules"].([]any)
+		for _, item := range list {
+			fields, _ := item.(map[string]any)
+			var rule secretScanRule
+			rule.Name, _ = fields["name"].(string)
+			rule.Pattern, _ = fields["pattern"].(string)
+			rule.Example, _ = fields["example"].(string)
+			if rule.Pattern != "" {
+				rules = append(rules, rule)
+			}
+		}
+		patterns, _ := module.Defaults["allowlist"].([]any)
+		for _, pattern := range patterns {
+			if pattern, ok := pattern.(string); ok {
+				allowlist = append(allowlist, pattern)
+			}
+		}
+	}
+	data := secretScanData{Name: "secret-scan", Description: description, Rules: pythonJSON(rules), Allowlist: pythonJSON(allowlist)}
+	return renderTemplate(registry, secretScanTemplate, "assets/hooks/secret-scan.py.tmpl", data)
+}
+
+// pythonJSON encodes v as indented JSON to embed in a Python script; an empty list
+// is [] rather than null.
+func pythonJSON[T any](v []T) string {
+	if v == nil {
+		v = []T{}
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	_ = enc.Encode(v)
+	return strings.TrimSpace(b.String())
+}
+
+// executableContent prepends the bash prelude to hook scripts; Python and Node scripts carry
+// their own shebang, and PowerShell scripts are run through powershell -File.
+func executableContent(path string, content string) []byte {
+	if strings.HasSuffix(path, ".py") || strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".ps1") {
+		return []byte(content)
+	}
+	return []byte("#!/usr/bin/env bash\nset -euo pipefail\n" + content + "\n")
+}
+
+// ============================================================================
+// Workspaces: per-package configuration in monorepos
+// ============================================================================
+
+// detectWorkspacePackages lists the packages of the workspace rooted at dir. A
+// workspace file that cannot be read is reported and treated as absent.
+func detectWorkspacePackages(dir string) []workspace.Package {
+	packages, err := workspace.Detect(dir)
+	if err != nil {
+		slog.Warn("ignoring workspace files", "err", err)
+	}
+	return packages
+}
+
+// selectedPackages keeps the saved package choices that are packages of this
+// workspace; choices are saved per user, not per project.
+func selectedPackages(saved []string, packages []workspace.Package) []string {
+	var selected []string
+	for _, dir := range saved {
+		if slices.ContainsFunc(packages, func(p workspace.Package) bool { return p.Dir == dir }) {
+			selected = append(selected, dir)
+		}
+	}
+	return selected
+}
+
+// packageOptions lists the workspace packages with the file that declares them.
+func packageOptions(packages []workspace.Package, selected []string) []huh.Option[string] {
+	options := make([]huh.Option[string], 0, len(packages))
+	for _, p := range packages {
+		options = append(options, huh.NewOption(p.Dir+" ("+p.Source+")", p.Dir).Selected(slices.Contains(selected, p.Dir)))
+	}
+	return options
+}
+
+// workspacePageHidden hides the packages page outside a workspace and for a global
+// configuration, which has no packages.
+func workspacePageHidden(cfg *Config, packages []workspace.Package) func() bool {
+	return func() bool { return len(packages) == 0 || !cfg.IsProjectLocal }
+}
+
+// integrationsPageHidden hides the integrations page from global configurations,
+// which have no repository for editor tasks, workflows, or a dev container.
+func integrationsPageHidden(cfg *Config) func() bool {
+	return func() bool { return !cfg.IsProjectLocal }
+}
+
+// packageDir resolves a package directory against the workspace root, refusing
+// directories a hand-edited choices file could point outside it.
+func packageDir(root, dir string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(dir))
+	if dir == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("workspace package %q must be a directory inside the project", dir)
+	}
+	return filepath.Join(root, clean), nil
+}
+
+// packageConfig derives a workspace package's configuration from the root's. The
+// package shares the root's agents, hooks, commands, MCP servers, permissions,
+// output style, statusline, and layout, since Claude Code reads settings only from
+// the directory it starts in. Languages and frameworks are detected in the package,
+// keeping the root's languages when none are recognized. CLAUDE.md notes, the setup
+// doc, and editor tasks stay at the root.
+func packageConfig(root Config, dir, abs string, registry *ModuleRegistry) Config {
+	cfg := root
+	cfg.ProjectName = path.Join(cmp.Or(root.ProjectName, "workspace"), dir)
+	cfg.Package = dir
+	cfg.Packages = nil
+	cfg.ClaudeMDExtras = ""
+	cfg.SetupDoc = false
+	cfg.SetupReadme = false
+	cfg.EditorTasks = nil
+	cfg.Devcontainer = false
+	cfg.MemoryFiles = nil
+	cfg.Gitignore = false
+	cfg.Workflows = nil
+	if languages := detectLanguages(abs); len(languages) > 0 {
+		cfg.Languages = languages
+	}
+	cfg.Frameworks = detectFrameworks(abs, registry)
+	return cfg
+}
+
+// languageMarkers are files whose presence identifies a language, by glob.
+var languageMarkers = []struct {
+	pattern  string
+	language string
+}{
+	{"go.mod", "Go"},
+	{"package.json", "TypeScript"},
+	{"tsconfig.json", "TypeScript"},
+	{"pyproject.toml", "Python"},
+	{"requirements.txt", "Python"},
+	{"setup.py", "Python"},
+	{"Cargo.toml", "Rust"},
+	{"CMakeLists.txt", "C++"},
+	{"pom.xml", "Java"},
+	{"build.gradle", "Java"},
+	{"build.gradle.kts", "Kotlin"},
+	{"*.csproj", "C#"},
+	{"composer.json", "PHP"},
+	{"Gemfile", "Ruby"},
+	{"Package.swift", "Swift"},
+	{"pubspec.yaml", "Dart"},
+	{"mix.exs", "Elixir"},
+	{"stack.yaml", "Haskell"},
+	{"elm.json", "Elm"},
+	{"Project.toml", "Julia"},
+}
+
+// detectLanguages returns the languages whose marker files exist in dir.
+func detectLanguages(dir string) []string {
+	var languages []string
+	for _, marker := range languageMarkers {
+		if matches, _ := filepath.Glob(filepath.Join(dir, marker.pattern)); len(matches) > 0 && !slices.Contains(languages, marker.language) {
+			languages = append(languages, marker.language)
+		}
+	}
+	return languages
+}
+
+// ============================================================================
+// Module upgrades: regenerate installed modules that have a newer version
+// ============================================================================
+
+// kindModuleTypes maps the manifest kinds generated from a single module to its type.
+var kindModuleTypes = map[manifest.FileKind]ModuleComponentType{
+	manifest.KindAgent:      TypeSubagent,
+	manifest.KindHook:       TypeHook,
+	manifest.KindCommand:    TypeCommand,
+	manifest.KindStyle:      TypeStyle,
+	manifest.KindStatusline: TypeStatusline,
+}
+
+// entryModule returns the module a manifest entry was generated from, or nil.
+func entryModule(registry *ModuleRegistry, kind manifest.FileKind, name string) *ComponentModule {
+	componentType, ok := kindModuleTypes[kind]
+	if !ok || name == "" {
+		return nil
+	}
+	return registry.Get(componentType, name)
+}
+
+// moduleUpgrade is an installed file whose module now has a newer version.
+type moduleUpgrade struct {
+	Entry  manifest.Entry
+	Module *ComponentModule
+}
+
+func (u moduleUpgrade) String() string {
+	return fmt.Sprintf("%s %s %s → %s", u.Module.Type, u.Module.Name, u.Entry.ModuleVersion, u.Module.Version)
+}
+
+// findUpgrades lists the files in mf whose module has a newer version than the one
+// recorded. Files from before versions were recorded are left out; the next run
+// regenerates them and records their version.
+func findUpgrades(mf *manifest.Manifest, registry *ModuleRegistry) []moduleUpgrade {
+	var upgrades []moduleUpgrade
+	for _, entry := range mf.Files {
+		module := entryModule(registry, entry.Kind, entry.Module)
+		if module == nil || module.Version == "" || entry.ModuleVersion == "" {
+			continue
+		}
+		installed, err := version.Parse(entry.ModuleVersion)
+		if err != nil {
+			continue
+		}
+		if available, err := version.Parse(module.Version); err == nil && version.Compare(available, installed) > 0 {
+			upgrades = append(upgrades, moduleUpgrade{Entry: entry, Module: module})
+		}
+	}
+	return upgrades
+}
+
+// installedUpgrades finds upgrades for the project and global configurations, keyed
+// by Config.IsProjectLocal.
+func installedUpgrades(registry *ModuleRegistry) map[bool][]moduleUpgrade {
+	upgrades := map[bool][]moduleUpgrade{}
+	for _, projectLocal := range []bool{true, false} {
+		dir, err := resolveTargetDir(projectLocal)
+		if err != nil {
+			continue
+		}
+		if mf, err := manifest.Load(dir); err == nil {
+			upgrades[projectLocal] = findUpgrades(mf, registry)
+		}
+	}
+	return upgrades
+}
+
+// upgradeContent regenerates the file of an upgrade as a full run would write it.
+func upgradeContent(baseDir string, u moduleUpgrade, registry *ModuleRegistry) ([]byte, error) {
+	path := u.Entry.AbsPath(baseDir)
+	// Assets the file was generated from under their own path are read from there again
+	var cfg Config
+	if len(u.Entry.Assets) > 0 {
+		cfg.AssetRenames = map[moduleRef]map[string]string{{u.Module.Type, u.Module.Name}: u.Entry.Assets}
+	}
+	var content string
+	var ok bool
+	switch u.Module.Type {
+	case TypeSubagent:
+		content, ok = renderAgent(u.Module.Name, cfg, registry), true
+	case TypeHook:
+		for lang := range hookLanguageExt {
+			if filepath.Base(path) == hookScriptName(u.Module.Name, lang) {
+				if content, ok = hookScriptContent(u.Module.Name, lang, detectLanguages(baseDir), registry); ok {
+					content = string(executableContent(path, content))
+				}
+			}
+		}
+	case TypeCommand:
+		content, ok = generateSlashCommand(u.Module.Name, cfg, registry), true
+	case TypeStyle:
+		content, ok = renderOutputStyle(u.Module, cfg)
+	case TypeStatusline:
+		content, ok = renderStatusline(u.Module, cfg)
+	}
+	if !ok {
+		return nil, fmt.Errorf("cannot regenerate %s", u.Entry.Path)
+	}
+	if formattedKinds[u.Entry.Kind] && filepath.Ext(path) == ".md" {
+		rules, err := formatting.LoadRuleConfig(baseDir)
+		if err != nil {
+			return nil, err
+		}
+		formatted, _ := formatGeneratedMarkdown(u.Entry.Path, []byte(content), rules)
+		return formatted, nil
+	}
+	return []byte(content), nil
+}
+
+// upgradeAction is the answer to an upgrade prompt.
+type upgradeAction int
+
+const (
+	upgradeSkip upgradeAction = iota
+	upgradeApply
+)
+
+// upgradeChooser decides whether to apply an upgrade; existing is the file on disk.
+type upgradeChooser func(u moduleUpgrade, edited bool, existing, upgraded []byte) upgradeAction
+
+// promptUpgrade asks on the terminal whether to apply each upgrade, showing a diff
+// on request.
+func promptUpgrade(in *bufio.Reader, out io.Writer) upgradeChooser {
+	return func(u moduleUpgrade, edited bool, existing, upgraded []byte) upgradeAction {
+		fmt.Fprintf(out, "\n⬆️  %s (%s)\n", u, u.Entry.Path)
+		if edited {
+			fmt.Fprintln(out, "   You edited this file since claudekit generated it; upgrading replaces your changes.")
+		}
+		for {
+			fmt.Fprint(out, "   [u]pgrade, [s]kip, or show [d]iff? [s] ")
+			answer, err := in.ReadString('\n')
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "u", "upgrade":
+				return upgradeApply
+			case "", "s", "skip":
+				return upgradeSkip
+			case "d", "diff":
+				fmt.Fprint(out, util.LineDiff(u.Entry.Path+" (installed)", u.Entry.Path+" ("+u.Module.Version+")", existing, upgraded))
+			}
+			if err != nil {
+				return upgradeSkip // Input closed; never upgrade without an answer
+			}
+		}
+	}
+}
+
+// applyUpgrades regenerates the files of the chosen upgrades and records their new
+// versions in the manifest. It returns the upgrades applied.
+func applyUpgrades(baseDir string, mf *manifest.Manifest, upgrades []moduleUpgrade, registry *ModuleRegistry, choose upgradeChooser) ([]moduleUpgrade, error) {
+	var applied []moduleUpgrade
+	for _, u := range upgrades {
+		upgraded, err := upgradeContent(baseDir, u, registry)
+		if err != nil {
+			return applied, err
+		}
+		path := u.Entry.AbsPath(baseDir)
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return applied, err
+		}
+		edited, err := u.Entry.IsModified(baseDir)
+		if err != nil {
+			return applied, err
+		}
+		if choose(u, edited, existing, upgraded) != upgradeApply {
+			continue
+		}
+
+		perm := os.FileMode(0o644)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		} else if u.Entry.Kind == manifest.KindHook || u.Entry.Kind == manifest.KindStatusline {
+			perm = 0o755
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return applied, err
+		}
+		if err := os.WriteFile(path, upgraded, perm); err != nil {
+			return applied, err
+		}
+		mf.AddFile(baseDir, path, u.Entry.Kind, u.Entry.Module, u.Module.Version, u.Entry.Assets, upgraded)
+		applied = append(applied, u)
+	}
+	return applied, nil
+}
+
+// runUpgradeCommand implements `claudekit upgrade [--global] [--dry-run] [--yes] [MODULE...]`.
+func runUpgradeCommand(args []string, registry *ModuleRegistry) int {
+	flags := newCommandFlags("upgrade")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: claudekit upgrade [flags] [MODULE...]")
+		fmt.Fprintln(flags.Output(), "MODULE is a module name, or type/name such as hook/stop; the default is every outdated module.")
+		flags.PrintDefaults()
+	}
+	global := flags.Bool("global", false, "upgrade the global configuration in ~/.claude")
+	dryRun := flags.Bool("dry-run", false, "list outdated modules without changing anything")
+	yes := flags.Bool("yes", false, "upgrade without asking, including files you edited")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	baseDir, err := resolveTargetDir(!*global)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	mf, err := manifest.Load(baseDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+
+	upgrades := findUpgrades(mf, registry)
+	if names := flags.Args(); len(names) > 0 {
+		upgrades = slices.DeleteFunc(upgrades, func(u moduleUpgrade) bool {
+			ref := moduleRef{u.Module.Type, u.Module.Name}
+			return !slices.Contains(names, ref.Name) && !slices.Contains(names, ref.String())
+		})
+	}
+	if len(upgrades) == 0 {
+		fmt.Println("All installed modules are up to date.")
+		return exitOK
+	}
+	if *dryRun {
+		for _, u := range upgrades {
+			fmt.Printf("⬆️  %s (%s)\n", u, u.Entry.Path)
+		}
+		return exitOK
+	}
+
+	choose := promptUpgrade(bufio.NewReader(os.Stdin), os.Stdout)
+	if *yes {
+		choose = func(moduleUpgrade, bool, []byte, []byte) upgradeAction { return upgradeApply }
+	}
+	applied, err := applyUpgrades(baseDir, mf, upgrades, registry, choose)
+	if len(applied) > 0 {
+		if saveErr := mf.Save(baseDir); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	for _, u := range applied {
+		fmt.Printf("✅ Upgraded %s\n", u)
+	}
+	if err != nil && len(applied) > 0 {
+		err = partialError(err)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitCode(err)
+	}
+	if skipped := len(upgrades) - len(applied); skipped > 0 {
+		fmt.Printf("%d upgrade(s) skipped; run claudekit upgrade again to review them.\n", skipped)
+	}
+	return exitOK
+}
+
+// doctorCheckUpgrades warns about installed modules with a newer version available.
+func doctorCheckUpgrades(baseDir string, registry *ModuleRegistry) []doctorCheck {
+	mf, err := manifest.Load(baseDir)
+	if err != nil {
+		return nil // Only generated configurations record module versions
+	}
+	var checks []doctorCheck
+	for _, u := range findUpgrades(mf, registry) {
+		checks = append(checks, doctorCheck{
+			Name:   fmt.Sprintf("%s %s", u.Module.Type, u.Module.Name),
+			Status: doctorWarn,
+			Detail: fmt.Sprintf("version %s is installed; %s is available", u.Entry.ModuleVersion, u.Module.Version),
+			Fix:    "run `claudekit upgrade` to review and apply it",
+		})
+	}
+	if len(checks) == 0 {
+		return []doctorCheck{{Name: "module versions", Status: doctorOK, Detail: "installed modules are up to date"}}
+	}
+	return checks
+}
+
+// ============================================================================
+// Modified File Detection
+// ============================================================================
+
+// conflictAction is what to do with a generated file the user has edited since the last run.
+type conflictAction int
+
+const (
+	conflictOverwrite conflictAction = iota
+	conflictSkip
+)
+
+// conflictResolver decides how to handle a modified file. rel is relative to the base directory.
+type conflictResolver func(rel string, existing, generated []byte) conflictAction
+
+// resolveConflict is the resolver run uses unless its generation hooks set one; it
+// asks on the terminal.
+var resolveConflict conflictResolver = promptConflict(bufio.NewReader(os.Stdin), os.Stdout)
+
+// generationWriter writes generated files and records their hashes in the manifest.
+// Files whose on-disk hash no longer matches the previous manifest are handed to resolve
+// instead of being overwritten.
+type generationWriter struct {
+	fs       fsys.FS
+	baseDir  string
+	previous *manifest.Manifest // nil when no earlier run left a manifest
+	current  *manifest.Manifest
+	resolve  conflictResolver
+	registry *ModuleRegistry // Supplies the module versions recorded with each file
+	skipped  []string
+
+	reportWrite  func(path string, status writeStatus) // generationCallbacks.report; nil reports nothing
+	assetRenames map[moduleRef]map[string]string // Config.AssetRenames, recorded with each module's files
+
+	format    *formatting.RuleConfig     // Rules generated markdown is formatted with; nil leaves it as rendered
+	formatted []*formatting.FormatResult // Generated files the formatter changed
+}
+
+// formattedKinds are the generated markdown files the formatter runs on, so fmt
+// --check passes on a freshly generated tree. CLAUDE.md is formatted before its
+// sections are merged into the user's file.
+var formattedKinds = map[manifest.FileKind]bool{
+	manifest.KindAgent:       true,
+	manifest.KindCommand:     true,
+	manifest.KindSetupDoc:    true,
+	manifest.KindClaudeLocal: true,
+	manifest.KindMemory:      true,
+}
+
+// formatMarkdown formats the generated content of path when the writer formats
+// markdown, and records what the formatter changed.
+func (w *generationWriter) formatMarkdown(path string, content []byte) []byte {
+	if w.format == nil {
+		return content
+	}
+	rel := manifest.RelPath(w.baseDir, path)
+	formatted, result := formatGeneratedMarkdown(rel, content, *w.format)
+	switch result.Status {
+	case formatting.StatusModified:
+		w.formatted = append(w.formatted, result)
+	case formatting.StatusError:
+		slog.Warn("not formatting "+rel, "err", result.Error)
+	}
+	return formatted
+}
+
+func newGenerationWriter(files fsys.FS, baseDir string, callbacks generationCallbacks, registry *ModuleRegistry) (*generationWriter, error) {
+	if callbacks.resolve == nil {
+		callbacks.resolve = resolveConflict
+	}
+	var previous *manifest.Manifest
+	data, err := files.ReadFile(manifest.Path(baseDir))
+	switch {
+	case err == nil:
+		if previous, err = manifest.Parse(data); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return &generationWriter{
+		fs:       files,
+		baseDir:  baseDir,
+		previous: previous,
+		current:  manifest.New(Version),
+		resolve:     callbacks.resolve,
+		registry:    registry,
+		reportWrite: callbacks.report,
+	}, nil
+}
+
+// write writes content to path unless the user modified it and chose to keep their version.
+// It reports whether the file was written.
+func (w *generationWriter) write(path string, content []byte, perm os.FileMode, kind manifest.FileKind, module string) (bool, error) {
+	if formattedKinds[kind] && filepath.Ext(path) == ".md" {
+		content = w.formatMarkdown(path, content)
+	}
+	rel := manifest.RelPath(w.baseDir, path)
+	if prev, ok := w.previous.Lookup(rel); ok {
+		modified, err := w.modified(prev)
+		if err != nil {
+			return false, err
+		}
+		if modified {
+			existing, err := w.fs.ReadFile(path)
+			if err != nil {
+				return false, err
+			}
+			if !bytes.Equal(existing, content) && w.resolve(rel, existing, content) == conflictSkip {
+				// Keep the old hash so the edit is still detected next run
+				w.current.Put(prev)
+				w.skipped = append(w.skipped, rel)
+				slog.Info("kept modified file", "path", rel)
+				w.report(path, writeKept)
+				return false, nil
+			}
+		}
+	}
+
+	status := writeUpdated
+	if _, err := w.fs.Stat(path); err != nil {
+		status = writeCreated
+	}
+	if err := w.fs.WriteFile(path, content, perm); err != nil {
+		return false, err
+	}
+	// WriteFile keeps the mode of an existing file; Windows has no execute bit to set
+	if perm&0o111 != 0 && targetOS != "windows" {
+		if err := w.fs.Chmod(path, perm); err != nil {
+			return false, err
+		}
+	}
+	var moduleVersion string
+	var assets map[string]string
+	if m := entryModule(w.registry, kind, module); m != nil {
+		moduleVersion = m.Version
+		assets = w.assetRenames[moduleRef{m.Type, m.Name}]
+	}
+	w.current.AddFile(w.baseDir, path, kind, module, moduleVersion, assets, content)
+	slog.Debug("wrote file", "path", rel, "kind", kind, "module", module)
+	w.report(path, status)
+	return true, nil
+}
+
+// modified reports whether the file of a previous entry no longer has the content it
+// was generated with, as manifest.Entry.IsModified does on disk.
+func (w *generationWriter) modified(prev manifest.Entry) (bool, error) {
+	if prev.SHA256 == "" {
+		return false, nil
+	}
+	data, err := w.fs.ReadFile(prev.AbsPath(w.baseDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return manifest.HashContent(data) != prev.SHA256, nil
+}
+
+// saveManifest writes the manifest of this run beside the files it records.
+func (w *generationWriter) saveManifest() error {
+	data, err := w.current.Marshal()
+	if err != nil {
+		return err
+	}
+	w.mkdir(filepath.Dir(manifest.Path(w.baseDir)))
+	return w.fs.WriteFile(manifest.Path(w.baseDir), data, 0o644)
+}
+
+// writeMerged writes a file that already carries the user's own content alongside
+// ours, so it is recorded without a hash and never raises an edit conflict.
+func (w *generationWriter) writeMerged(path string, content []byte, kind manifest.FileKind) error {
+	if err := w.fs.WriteFile(path, content, 0o644); err != nil {
+		return err
+	}
+	w.current.Put(manifest.Entry{Path: manifest.RelPath(w.baseDir, path), Kind: kind, SourceVersion: w.current.GeneratorVersion})
+	slog.Debug("merged file", "path", manifest.RelPath(w.baseDir, path), "kind", kind)
+	w.report(path, writeMerged)
+	return nil
+}
+
+// report tells reportWrite, when it is set, what became of path.
+func (w *generationWriter) report(path string, status writeStatus) {
+	if w.reportWrite != nil {
+		w.reportWrite(path, status)
+	}
+}
+
+// writeClaudeMD writes CLAUDE.md, replacing only claudekit's sections of an existing
+// file. A file with damaged markers is left alone with a warning, as is tasks.json.
+func (w *generationWriter) writeClaudeMD(path, generated string) error {
+	existing, err := w.fs.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	generated = string(w.formatMarkdown(path, []byte(generated)))
+	content, merged, err := updateClaudeMD(string(existing), generated)
+	if err != nil {
+		rel := manifest.RelPath(w.baseDir, path)
+		slog.Warn("not updating "+rel, "err", err)
+		if prev, ok := w.previous.Lookup(rel); ok {
+			w.current.Put(prev)
+		}
+		return nil
+	}
+	if merged {
+		return w.writeMerged(path, []byte(content), manifest.KindClaudeMD)
+	}
+	_, err = w.write(path, []byte(content), 0o644, manifest.KindClaudeMD, "")
+	return err
+}
+
+// writeReadmeSection adds claudekit's section to README.md, or replaces it, creating
+// the README when there is none. README.md is always the user's, so it is recorded
+// like a merged CLAUDE.md; one with damaged markers is left alone with a warning.
+func (w *generationWriter) writeReadmeSection(path, section string) error {
+	existing, err := w.fs.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := section
+	if strings.TrimSpace(string(existing)) != "" {
+		parts, err := parseSections(string(existing))
+		if err != nil {
+			rel := manifest.RelPath(w.baseDir, path)
+			slog.Warn("not updating "+rel, "err", err)
+			if prev, ok := w.previous.Lookup(rel); ok {
+				w.current.Put(prev)
+			}
+			return nil
+		}
+		if !hasSections(parts) && !strings.HasSuffix(string(existing), "\n") {
+			parts[len(parts)-1].Text += "\n"
+		}
+		fresh, _ := parseSections(section)
+		content = mergeSections(parts, fresh)
+	}
+	return w.writeMerged(path, []byte(content), manifest.KindReadmeSection)
+}
+
+// seed writes content to path when there is no file there yet. A file that exists
+// is the user's, whatever claudekit first wrote to it, so it is left as it is; its
+// entry is kept so clean can still tell whether it was edited.
+func (w *generationWriter) seed(path string, content []byte, kind manifest.FileKind) error {
+	if _, err := w.fs.Stat(path); err == nil {
+		if prev, ok := w.previous.Lookup(manifest.RelPath(w.baseDir, path)); ok {
+			w.current.Put(prev)
+		}
+		return nil
+	}
+	_, err := w.write(path, content, 0o644, kind, "")
+	return err
+}
+
+// writeGitignore replaces claudekit's block of the .gitignore at path with entries,
+// creating the file when there is none and removing the block when no entry is
+// left. .gitignore is the user's, so it is recorded like README.md; one with
+// damaged markers is left alone with a warning.
+func (w *generationWriter) writeGitignore(path string, entries []string) error {
+	existing, err := w.fs.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	rel := manifest.RelPath(w.baseDir, path)
+	content, err := updateGitignore(string(existing), entries)
+	if err != nil {
+		slog.Warn("not updating "+rel, "err", err)
+		if prev, ok := w.previous.Lookup(rel); ok {
+			w.current.Put(prev)
+		}
+		return nil
+	}
+	ours := strings.Contains(content, gitignoreBegin)
+	switch {
+	case content == string(existing):
+		if ours {
+			w.current.Put(manifest.Entry{Path: rel, Kind: manifest.KindGitignore, SourceVersion: w.current.GeneratorVersion})
+		}
+		return nil
+	case ours:
+		return w.writeMerged(path, []byte(content), manifest.KindGitignore)
+	case content == "":
+		return w.fs.Remove(path)
+	}
+	if err := w.fs.WriteFile(path, []byte(content), 0o644); err != nil {
+		return err
+	}
+	w.report(path, writeUpdated)
+	return nil
+}
+
+// mkdir creates dir and its parents; a failure surfaces when a file is written
+// into it.
+func (w *generationWriter) mkdir(dir string) {
+	_ = w.fs.MkdirAll(dir, 0o755)
+}
+
+// removeStale deletes a file the previous run generated and the current run replaced,
+// unless the user has edited it since.
+func (w *generationWriter) removeStale(path string) {
+	prev, ok := w.previous.Lookup(manifest.RelPath(w.baseDir, path))
+	if !ok {
+		return
+	}
+	if modified, err := w.modified(prev); err == nil && !modified {
+		if err := w.fs.Remove(path); err == nil {
+			slog.Debug("removed stale file", "path", prev.Path)
+		}
+	}
+}
+
+// promptConflict asks whether to overwrite, skip, or diff a modified file.
+// Anything other than an explicit overwrite keeps the user's version.
+func promptConflict(in *bufio.Reader, out io.Writer) conflictResolver {
+	return func(rel string, existing, generated []byte) conflictAction {
+		fmt.Fprintf(out, "\n⚠️  %s was modified since claudekit generated it.\n", rel)
+		for {
+			fmt.Fprint(out, "   [o]verwrite, [s]kip, or show [d]iff? [s] ")
+			answer, err := in.ReadString('\n')
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "o", "overwrite":
+				return conflictOverwrite
+			case "", "s", "skip":
+				return conflictSkip
+			case "d", "diff":
+				fmt.Fprint(out, util.LineDiff(rel+" (yours)", rel+" (generated)", existing, generated))
+			}
+			if err != nil {
+				return conflictSkip // Input closed; never overwrite without an answer
+			}
+		}
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSettings assembles settings.json for cfg. It fails when a selected hook
+// module's defaults cannot make a valid hook entry.
+func buildSettings(projectDir string, cfg Config, registry *ModuleRegistry) (settings, error) {
+	s := settings{
+		Env:   parseEnvLines(or(cfg.Env, defaultEnv)),
+		Hooks: map[string][]hookMatcher{},
+	}
+
+	if allow, ask, deny := configPermissions(cfg, registry); len(allow)+len(ask)+len(deny) > 0 {
+		s.Permissions = &struct {
+			Allow []string `json:"allow,omitempty"`
+			Ask   []string `json:"ask,omitempty"`
+			Deny  []string `json:"deny,omitempty"`
+		}{Allow: allow, Ask: ask, Deny: deny}
+	}
+
+	// Activate the selected output style by the name Claude Code knows it by
+	if style := registry.Get(TypeStyle, cfg.OutputStyle); style != nil {
+		s.OutputStyle = style.Name
+		if name, ok := style.Defaults["style_name"].(string); ok && name != "" {
+			s.OutputStyle = name
+		}
+	}
+
+	// Point the statusline at the generated script
+	if module := registry.Get(TypeStatusline, cfg.Statusline); module != nil {
+		s.StatusLine = &statusLine{Type: "command", Command: statuslineCommand(projectDir, cfg.IsProjectLocal)}
+		switch padding := module.Defaults["padding"].(type) {
+		case int:
+			s.StatusLine.Padding = padding
+		case float64:
+			s.StatusLine.Padding = int(padding)
+		}
+	}
+
+	// Add all selected hooks using registry (Feature 004)
+	var errs []error
+	for _, hookDisplay := range cfg.Hooks {
+		hookName := cleanFormValue(hookDisplay)
+
+		// Get hook module from registry
+		hookModule := registry.Get(TypeHook, hookName)
+		if hookModule == nil {
+			continue // Skip unknown hooks
+		}
+
+		// Extract defaults from module
+		if err := validateHookDefaults(hookModule); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		events := hookEvents(hookModule)
+		matcher := hookToolMatcher(cfg, hookName, hookModule) // Tool names; empty matches every tool
+		commands, err := enabledHookCommands(cfg, hookName, hookModule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// The commands run one after another, in the order they are listed
+		lang, langErr := resolveHookLanguage(hookName, hookModule, cfg.HookLanguages)
+		var cmds []hookCmd
+		for _, c := range commands {
+			command := c.Command
+			if c.Script && langErr == nil {
+				command = hookCommandWithScript(command, hookScriptName(hookName, lang))
+			}
+			command = scopeHookCommand(layoutHookCommand(command, cfg.Layout), projectDir, cfg.IsProjectLocal)
+			if c.Script && lang == hookLangPowerShell {
+				command = powershellHookCommand(command)
+			}
+			cmds = append(cmds, hookCmd{Type: "command", Command: command, Timeout: c.Timeout})
+		}
+
+		for _, event := range events {
+			s.Hooks[event] = append(s.Hooks[event], hookMatcher{Matcher: matcher, Hooks: cmds})
+		}
+
+		// Hand the hook its webhook URL; without one it reads the user's environment
+		if env, ok := hookModule.Defaults["webhook_env"].(string); ok {
+			if url := cfg.webhookURL(env); url != "" {
+				s.Env[env] = url
+			}
+		}
+	}
+
+	return s, errors.Join(errs...)
+}
+
+// defaultEnv is settings.json's env until the Environment page or --env changes it.
+const defaultEnv = "CLAUDE_CODE_MAX_OUTPUT_TOKENS=8192\nMCP_TOOL_TIMEOUT=180000"
+
+// envVarKind is the type of value a known Claude Code environment variable takes.
+type envVarKind int
+
+const (
+	envInt  envVarKind = iota + 1 // A positive whole number
+	envBool                       // 1, 0, true, or false
+)
+
+// knownEnvVars are Claude Code settings whose values are checked on the Environment page.
+var knownEnvVars = map[string]envVarKind{
+	"CLAUDE_CODE_MAX_OUTPUT_TOKENS":            envInt,
+	"MAX_THINKING_TOKENS":                      envInt,
+	"MAX_MCP_OUTPUT_TOKENS":                    envInt,
+	"MCP_TIMEOUT":                              envInt,
+	"MCP_TOOL_TIMEOUT":                         envInt,
+	"BASH_DEFAULT_TIMEOUT_MS":                  envInt,
+	"BASH_MAX_TIMEOUT_MS":                      envInt,
+	"BASH_MAX_OUTPUT_LENGTH":                   envInt,
+	"DISABLE_TELEMETRY":                        envBool,
+	"DISABLE_ERROR_REPORTING":                  envBool,
+	"DISABLE_AUTOUPDATER":                      envBool,
+	"CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC": envBool,
+	"CLAUDE_CODE_USE_BEDROCK":                  envBool,
+	"CLAUDE_CODE_USE_VERTEX":                   envBool,
+}
+
+// envKeyPattern matches an environment variable name.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateEnvVar checks value against the type of a known variable; others take any value.
+func validateEnvVar(key, value string) error {
+	switch knownEnvVars[key] {
+	case envInt:
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return fmt.Errorf("%s must be a positive whole number, not %q", key, value)
+		}
+	case envBool:
+		if !slices.Contains([]string{"0", "1", "true", "false"}, strings.ToLower(value)) {
+			return fmt.Errorf("%s must be 1, 0, true, or false, not %q", key, value)
+		}
+	}
+	return nil
+}
+
+// validateEnvLines checks the Environment page: one KEY=VALUE per line, each key
+// once. Blank lines and lines starting with # are skipped.
+func validateEnvLines(text string) error {
+	seen := map[string]bool{}
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		switch {
+		case !ok || !envKeyPattern.MatchString(key):
+			return fmt.Errorf("line %d: %q is not KEY=VALUE", i+1, line)
+		case seen[key]:
+			return fmt.Errorf("line %d: %s is set twice", i+1, key)
+		}
+		if err := validateEnvVar(key, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// parseEnvLines returns the variables on the Environment page, skipping lines that
+// are not KEY=VALUE; a later line for the same key wins.
+func parseEnvLines(text string) map[string]string {
+	env := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && envKeyPattern.MatchString(strings.TrimSpace(key)) {
+			env[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return env
+}
+
+// formatEnvLines writes env as KEY=VALUE lines, sorted by key.
+func formatEnvLines(env map[string]string) string {
+	lines := make([]string, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		lines = append(lines, key+"="+env[key])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setEnvLines applies --env KEY=VALUE settings to the Environment page's text; an
+// empty value removes the variable.
+func setEnvLines(text string, overrides []string) string {
+	if len(overrides) == 0 {
+		return text
+	}
+	env := parseEnvLines(or(text, defaultEnv))
+	for _, setting := range overrides {
+		key, value, _ := strings.Cut(setting, "=")
+		if value == "" {
+			delete(env, key)
+		} else {
+			env[key] = value
+		}
+	}
+	return formatEnvLines(env)
+}
+
+// initialEnv fills the Environment page from the env in the target's settings.json,
+// so hand edits there are kept, then from the saved choices, then defaultEnv, and
+// applies --env on top. Webhook URLs have a page of their own and are left out.
+func initialEnv(projectLocal bool, saved string, overrides []string) string {
+	env := or(saved, defaultEnv)
+	if dir, err := resolveTargetDir(projectLocal); err == nil {
+		if data, err := os.ReadFile(filepath.Join(dir, ".claude", "settings.json")); err == nil {
+			var st settings
+			if json.Unmarshal(data, &st) == nil && len(st.Env) > 0 {
+				delete(st.Env, slackWebhookEnv)
+				delete(st.Env, discordWebhookEnv)
+				env = formatEnvLines(st.Env)
+			}
+		}
+	}
+	return setEnvLines(env, overrides)
+}
+
+// secretEnvKey matches variable names that usually hold credentials.
+var secretEnvKey = regexp.MustCompile(`(?i)(secret|password|passwd|api_?key|_token$|^token$|webhook|credential)`)
+
+// withoutSecretEnv drops credential-looking variables from the Environment page's
+// text, for bundles shared with others.
+func withoutSecretEnv(text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		key, _, _ := strings.Cut(strings.TrimSpace(line), "=")
+		if !secretEnvKey.MatchString(key) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// Environment variables the notification hooks read their webhook URLs from.
+const (
+	slackWebhookEnv   = "SLACK_WEBHOOK_URL"
+	discordWebhookEnv = "DISCORD_WEBHOOK_URL"
+)
+
+// webhookURL returns the URL entered for a hook's webhook_env variable.
+func (c Config) webhookURL(env string) string {
+	switch env {
+	case slackWebhookEnv:
+		return c.SlackWebhookURL
+	case discordWebhookEnv:
+		return c.DiscordWebhookURL
+	}
+	return ""
+}
+
+// validateWebhookURL accepts an empty value, which leaves the URL to the
+// environment, or an https URL.
+func validateWebhookURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("enter an https:// webhook URL, or leave it empty")
+	}
+	return nil
+}
+
+// notificationsPageHidden hides the webhook page unless a hook that posts to a
+// webhook is selected.
+func notificationsPageHidden(cfg *Config, loader *registryLoader) func() bool {
+	return func() bool {
+		registry, _ := loader.Wait()
+		return !slices.ContainsFunc(cfg.Hooks, func(display string) bool {
+			module := registry.Get(TypeHook, cleanFormValue(display))
+			if module == nil {
+				return false
+			}
+			_, ok := module.Defaults["webhook_env"].(string)
+			return ok
+		})
+	}
+}
+
+// defaultPermissionPreset is the permissions module used when none is selected.
+const defaultPermissionPreset = "standard"
+
+// mergePermissionPresets combines the allow/ask/deny lists of the named permissions
+// modules, in selection order and without duplicates. When presets disagree about a
+// rule the most restrictive wins: deny beats ask, and ask beats allow.
+func mergePermissionPresets(names []string, registry *ModuleRegistry) (allow, ask, deny []string) {
+	for _, name := range names {
+		module := registry.Get(TypePermissions, cleanFormValue(name))
+		if module == nil {
+			continue // Skip unknown presets
+		}
+		deny = appendPermissionRules(deny, module.Defaults["deny"])
+		ask = appendPermissionRules(ask, module.Defaults["ask"])
+		allow = appendPermissionRules(allow, module.Defaults["allow"])
+	}
+	return resolvePermissionConflicts(allow, ask, deny)
+}
+
+// configPermissions returns cfg's permission rules: those kept on the rule editor page,
+// or else the selected presets merged, falling back to the standard preset, followed
+// by the rules typed on that page.
+func configPermissions(cfg Config, registry *ModuleRegistry) (allow, ask, deny []string) {
+	presets := cfg.Permissions
+	if len(presets) == 0 {
+		presets = []string{defaultPermissionPreset}
+	}
+	entries := cfg.PermissionRules
+	if entries == nil {
+		entries = permissionEntries(mergePermissionPresets(presets, registry))
+	}
+	entries = append(slices.Clone(entries), parsePermissionLines(cfg.CustomPermissionRules)...)
+	return permissionsFromEntries(entries)
+}
+
+// resolvePermissionConflicts drops a rule from the lists less restrictive than the
+// most restrictive one naming it: deny beats ask, and ask beats allow.
+func resolvePermissionConflicts(allow, ask, deny []string) ([]string, []string, []string) {
+	ask = slices.DeleteFunc(ask, func(rule string) bool { return slices.Contains(deny, rule) })
+	allow = slices.DeleteFunc(allow, func(rule string) bool {
+		return slices.Contains(deny, rule) || slices.Contains(ask, rule)
+	})
+	return allow, ask, deny
+}
+
+// Permission editor entries pair a verdict with a rule, as in "ask Bash(git push:*)".
+const (
+	permissionAllow = "allow"
+	permissionAsk   = "ask"
+	permissionDeny  = "deny"
+)
+
+// permissionEntries lists allow, ask, and deny rules as editor entries.
+func permissionEntries(allow, ask, deny []string) []string {
+	var entries []string
+	for _, list := range []struct {
+		verdict string
+		rules   []string
+	}{{permissionAllow, allow}, {permissionAsk, ask}, {permissionDeny, deny}} {
+		for _, rule := range list.rules {
+			entries = append(entries, list.verdict+" "+rule)
+		}
+	}
+	return entries
+}
+
+// parsePermissionEntry splits an editor entry into its verdict and a valid rule.
+func parsePermissionEntry(entry string) (verdict, rule string, err error) {
+	verdict, rule, _ = strings.Cut(strings.TrimSpace(entry), " ")
+	switch verdict {
+	case permissionAllow, permissionAsk, permissionDeny:
+	default:
+		return "", "", fmt.Errorf("%q: start with allow, ask, or deny, as in \"ask Bash(git push:*)\"", entry)
+	}
+	rule = strings.TrimSpace(rule)
+	if err := validatePermissionRule(rule); err != nil {
+		return "", "", err
+	}
+	return verdict, rule, nil
+}
+
+// permissionsFromEntries sorts editor entries into allow, ask, and deny lists,
+// skipping invalid entries and duplicates.
+func permissionsFromEntries(entries []string) (allow, ask, deny []string) {
+	lists := map[string]*[]string{permissionAllow: &allow, permissionAsk: &ask, permissionDeny: &deny}
+	for _, entry := range entries {
+		verdict, rule, err := parsePermissionEntry(entry)
+		if err != nil {
+			continue
+		}
+		if list := lists[verdict]; !slices.Contains(*list, rule) {
+			*list = append(*list, rule)
+		}
+	}
+	return resolvePermissionConflicts(allow, ask, deny)
+}
+
+// permissionToolName matches a tool name: a built-in tool such as Bash, or an MCP
+// tool such as mcp__github__create_issue.
+var permissionToolName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// validatePermissionRule checks that rule is a tool name, optionally followed by a
+// specifier in parentheses. A Bash prefix rule puts :* at the end, as in
+// "Bash(git *:*)", and a WebFetch domain rule names a host, as in
+// "WebFetch(domain:github.com)".
+func validatePermissionRule(rule string) error {
+	tool, spec, err := parsePermissionRule(rule)
+	if err != nil {
+		return err
+	}
+	if !permissionToolName.MatchString(tool) {
+		return fmt.Errorf("invalid permission rule %q: %q is not a tool name", rule, tool)
+	}
+	if !strings.Contains(rule, "(") {
+		return nil
+	}
+	if strings.TrimSpace(spec) == "" {
+		return fmt.Errorf("invalid permission rule %q: empty parentheses; name the tool alone to match every call", rule)
+	}
+	if depth := strings.Count(spec, "(") - strings.Count(spec, ")"); depth != 0 {
+		return fmt.Errorf("invalid permission rule %q: unbalanced parentheses", rule)
+	}
+	switch tool {
+	case "Bash":
+		if i := strings.Index(spec, ":*"); i >= 0 && i != len(spec)-2 {
+			return fmt.Errorf("invalid permission rule %q: :* must end a Bash prefix rule", rule)
+		}
+	case "WebFetch":
+		if host, ok := strings.CutPrefix(spec, "domain:"); ok && (host == "" || strings.ContainsAny(host, "/ ")) {
+			return fmt.Errorf("invalid permission rule %q: expected domain:HOST", rule)
+		}
+	}
+	return nil
+}
+
+// parsePermissionLines returns the entries typed on the permission editor page, one
+// per line; blank lines and lines starting with # are skipped.
+func parsePermissionLines(text string) []string {
+	var entries []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// validatePermissionLines reports the first invalid entry typed on the permission
+// editor page, with its line number.
+func validatePermissionLines(text string) error {
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, err := parsePermissionEntry(line); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// existingPermissionEntries returns the rules in baseDir's settings.json that
+// claudekit did not write, so the editor can offer to keep them.
+func existingPermissionEntries(baseDir string) []string {
+	data, err := os.ReadFile(filepath.Join(baseDir, ".claude", "settings.json"))
+	if err != nil {
+		return nil
+	}
+	var st settings
+	if json.Unmarshal(data, &st) != nil || st.Permissions == nil {
+		return nil
+	}
+	var owned manifest.SettingsOwnership
+	if mf, err := manifest.Load(baseDir); err == nil {
+		owned = mf.Settings
+	}
+	unowned := func(rules, ownedRules []string) []string {
+		return slices.DeleteFunc(slices.Clone(rules), func(rule string) bool { return slices.Contains(ownedRules, rule) })
+	}
+	return permissionEntries(unowned(st.Permissions.Allow, owned.Allow), unowned(st.Permissions.Ask, owned.Ask), unowned(st.Permissions.Deny, owned.Deny))
+}
+
+// permissionRuleEditor offers the rules of the selected presets, and the rules
+// found in settings.json, on the permission editor page.
+type permissionRuleEditor struct {
+	cfg    *Config
+	loader *registryLoader
+
+	mu      sync.Mutex      // Options load in the background
+	loaded  bool            // Whether options were listed before
+	offered map[string]bool // Entries offered before, which keep the user's choice
+}
+
+func newPermissionRuleEditor(cfg *Config, loader *registryLoader) *permissionRuleEditor {
+	return &permissionRuleEditor{cfg: cfg, loader: loader, offered: map[string]bool{}}
+}
+
+// options lists the candidate rules. At first a preset's rule is selected unless
+// saved choices leave it out; after that a newly offered rule starts selected, so
+// choosing another preset adds its rules. Rules from settings.json start selected.
+func (e *permissionRuleEditor) options() []huh.Option[string] {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	registry, _ := e.loader.Wait()
+	presets := e.cfg.Permissions
+	if len(presets) == 0 {
+		presets = []string{defaultPermissionPreset}
+	}
+	candidates := permissionEntries(mergePermissionPresets(presets, registry))
+	fromSettings := map[string]bool{}
+	if dir, err := resolveTargetDir(e.cfg.IsProjectLocal); err == nil {
+		for _, entry := range existingPermissionEntries(dir) {
+			if !slices.Contains(candidates, entry) {
+				candidates = append(candidates, entry)
+				fromSettings[entry] = true
+			}
+		}
+	}
+
+	first := !e.loaded
+	e.loaded = true
+	options := make([]huh.Option[string], 0, len(candidates))
+	for _, entry := range candidates {
+		verdict, rule, _ := strings.Cut(entry, " ")
+		label := fmt.Sprintf("%-5s  %s", verdict, rule)
+		if fromSettings[entry] {
+			label += "  (settings.json)"
+		}
+		option := huh.NewOption(label, entry)
+		if !e.offered[entry] {
+			option = option.Selected(!first || fromSettings[entry] || e.cfg.PermissionRules == nil || slices.Contains(e.cfg.PermissionRules, entry))
+		}
+		options = append(options, option)
+	}
+	clear(e.offered) // A rule offered again after its preset was dropped starts selected
+	for _, entry := range candidates {
+		e.offered[entry] = true
+	}
+	return options
+}
+
+// appendPermissionRules appends the string rules in a frontmatter list to rules,
+// skipping any already present.
+func appendPermissionRules(rules []string, list any) []string {
+	items, _ := list.([]any)
+	for _, item := range items {
+		if rule, ok := item.(string); ok && rule != "" && !slices.Contains(rules, rule) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// permissionVerdict is the outcome of evaluating a tool invocation against permission rules.
+type permissionVerdict string
+
+const (
+	verdictAllow permissionVerdict = "allow"
+	verdictAsk   permissionVerdict = "ask"
+	verdictDeny  permissionVerdict = "deny"
+)
+
+// readOnlyTools run without prompting in Claude Code when no rule mentions them.
+var readOnlyTools = []string{"Read", "LS", "Grep", "Glob", "NotebookRead"}
+
+// permissionMatch is a single rule that matched a tool invocation.
+type permissionMatch struct {
+	List   permissionVerdict `json:"list"` // Which list the rule came from
+	Rule   string            `json:"rule"`
+	Reason string            `json:"reason"` // Why the rule matched
+}
+
+// permissionDecision explains how a tool invocation would be handled.
+type permissionDecision struct {
+	Verdict permissionVerdict
+	Matched *permissionMatch  // Nil when no rule matched and the default applied
+	Others  []permissionMatch // Lower-precedence rules that also matched
+	Reason  string
+}
+
+// runPermissionsCommand handles `claudekit permissions <subcommand>`.
+func runPermissionsCommand(args []string) int {
+	if len(args) == 0 || args[0] != "test" {
+		fmt.Fprintln(os.Stderr, "usage: claudekit permissions test [--global] \"Tool(arguments)\"")
+		return exitUsage
+	}
+
+	flags := newCommandFlags("permissions test")
+	global := flags.Bool("global", false, "evaluate against the global configuration in ~/.claude")
+	output := outputFormatFlag(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if !validOutputFormat(flags, *output) {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: claudekit permissions test [--global] \"Tool(arguments)\"")
+		return exitUsage
+	}
+	tool, arg, err := parsePermissionRule(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitUsage
+	}
+
+	baseDir, err := resolveTargetDir(!*global)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	path := filepath.Join(baseDir, ".claude", "settings.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitFailure
+	}
+	var st settings
+	if err := json.Unmarshal(data, &st); err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid %s: %v\n", path, err)
+		return exitFailure
+	}
+
+	var allow, ask, deny []string
+	if st.Permissions != nil {
+		allow, ask, deny = st.Permissions.Allow, st.Permissions.Ask, st.Permissions.Deny
+	}
+	decision := evaluatePermission(tool, arg, allow, ask, deny)
+	if *output == outputJSON {
+		if err := writeJSON(os.Stdout, struct {
+			Query    string            `json:"query"`
+			Settings string            `json:"settings"`
+			Verdict  permissionVerdict `json:"verdict"`
+			Matched  *permissionMatch  `json:"matched"`
+			Others   []permissionMatch `json:"also_matched"`
+			Reason   string            `json:"reason,omitempty"`
+		}{flags.Arg(0), path, decision.Verdict, decision.Matched, nonNil(decision.Others), decision.Reason}); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	fmt.Print(renderPermissionDecision(flags.Arg(0), path, decision))
+	return exitOK
+}
+
+// evaluatePermission decides how Claude Code would handle tool invoked with arg. Deny
+// rules are checked first, then ask, then allow; the first list with a matching rule wins.
+func evaluatePermission(tool, arg string, allow, ask, deny []string) permissionDecision {
+	var matches []permissionMatch
+	for _, list := range []struct {
+		verdict permissionVerdict
+		rules   []string
+	}{{verdictDeny, deny}, {verdictAsk, ask}, {verdictAllow, allow}} {
+		for _, rule := range list.rules {
+			if ok, reason := permissionRuleMatches(rule, tool, arg); ok {
+				matches = append(matches, permissionMatch{List: list.verdict, Rule: rule, Reason: reason})
+			}
+		}
+	}
 
-	// Reserve space for title (3 lines ASCII + 1 line gradient border + 1 line spacing)
-	titleHeight := 5
-	availableHeight := innerHeight - titleHeight
-	if availableHeight < 20 {
-		availableHeight = 20
-		titleHeight = innerHeight - 20  // Reduce title space if needed
+	if len(matches) == 0 {
+		if slices.Contains(readOnlyTools, tool) {
+			return permissionDecision{Verdict: verdictAllow, Reason: fmt.Sprintf("no rule matched, and %s is read-only, so Claude Code runs it without asking", tool)}
+		}
+		return permissionDecision{Verdict: verdictAsk, Reason: fmt.Sprintf("no rule matched, so Claude Code asks before using %s", tool)}
 	}
 
-	formHeight := availableHeight
-	statusHeight := availableHeight // Status panel should match form height
+	first := matches[0]
+	decision := permissionDecision{Verdict: first.List, Matched: &first, Others: matches[1:]}
+	if slices.ContainsFunc(decision.Others, func(m permissionMatch) bool { return m.List != first.List }) {
+		decision.Reason = "deny rules take precedence over ask rules, and ask rules over allow rules"
+	}
+	return decision
+}
 
-	// Title with gradient (T035)
-	// T015: Width-based conditional rendering for ASCII art title
-	headerTheme := m.styleMap[gradient.HeaderComponent][gradient.NormalState].Theme
-	var title string
+// parsePermissionRule splits a rule or query such as "Bash(git push:*)" into its tool
+// name and the specifier in parentheses, which is empty for a bare tool name.
+func parsePermissionRule(rule string) (tool, spec string, err error) {
+	rule = strings.TrimSpace(rule)
+	open := strings.IndexByte(rule, '(')
+	if open < 0 {
+		if rule == "" || strings.ContainsAny(rule, ") ") {
+			return "", "", fmt.Errorf("invalid permission rule %q: expected Tool or Tool(arguments)", rule)
+		}
+		return rule, "", nil
+	}
+	if open == 0 || !strings.HasSuffix(rule, ")") {
+		return "", "", fmt.Errorf("invalid permission rule %q: expected Tool or Tool(arguments)", rule)
+	}
+	return rule[:open], rule[open+1 : len(rule)-1], nil
+}
 
-	// Version string with subtle styling
-	versionText := "v" + Version
-	versionStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#888888")).
-		Faint(true)
-	version := versionStyle.Render(versionText)
+// permissionRuleMatches reports whether rule covers tool invoked with arg, and why. Bare
+// tool names match every invocation. Bash rules match the command exactly, or by prefix
+// when they end in ":*"; file tools match gitignore-style path globs; WebFetch rules of the
+// form "domain:host" match URLs on that host.
+func permissionRuleMatches(rule, tool, arg string) (bool, string) {
+	ruleTool, spec, err := parsePermissionRule(rule)
+	if err != nil || ruleTool != tool {
+		return false, ""
+	}
+	if spec == "" {
+		return true, fmt.Sprintf("the rule names %s without arguments, so it covers every %s call", tool, tool)
+	}
+	if arg == "" {
+		return false, "" // The rule is narrower than an invocation without arguments
+	}
 
-	if innerWidth >= 60 {
-		// Wide terminal: render ASCII art with gradient foreground + version
-		gradientASCII := gradient.RenderASCIITitle(asciiTitle, headerTheme, m.terminalCap)
+	switch tool {
+	case "Bash":
+		if prefix, ok := strings.CutSuffix(spec, ":*"); ok {
+			if globMatch(prefix, arg, false) || globMatchPrefix(prefix, arg) {
+				return true, fmt.Sprintf("the command starts with %q", prefix)
+			}
+			return false, ""
+		}
+		if globMatch(spec, arg, false) {
+			return true, "the command matches the rule exactly"
+		}
+	case "Read", "Edit", "Write", "MultiEdit", "NotebookEdit", "NotebookRead", "Glob", "Grep", "LS":
+		pattern := strings.TrimPrefix(spec, "./")
+		target := strings.TrimPrefix(arg, "./")
+		if globMatch(pattern, target, true) {
+			return true, fmt.Sprintf("the path matches the glob %q", spec)
+		}
+	case "WebFetch":
+		if domain, ok := strings.CutPrefix(spec, "domain:"); ok {
+			host := strings.TrimPrefix(arg, "domain:")
+			if i := strings.Index(host, "://"); i >= 0 {
+				host = host[i+3:]
+			}
+			if i := strings.IndexAny(host, "/:?#"); i >= 0 {
+				host = host[:i]
+			}
+			if globMatch(domain, host, false) {
+				return true, fmt.Sprintf("the URL's host is %s", host)
+			}
+			return false, ""
+		}
+		if globMatch(spec, arg, false) {
+			return true, "the URL matches the rule exactly"
+		}
+	default:
+		if globMatch(spec, arg, false) {
+			return true, "the arguments match the rule exactly"
+		}
+	}
+	return false, ""
+}
 
-		// Split ASCII art into lines and add version to the first line
-		asciiLines := strings.Split(gradientASCII, "\n")
-		if len(asciiLines) > 0 {
-			// Calculate padding to right-align version
-			firstLineWidth := lipgloss.Width(asciiLines[0])
-			padding := innerWidth - firstLineWidth - lipgloss.Width(version)
-			if padding < 0 {
-				padding = 0
+// globMatch matches s against a pattern where "*" is a wildcard. With paths set, "*" and
+// "?" stop at slashes and "**" spans directories, as in .gitignore.
+func globMatch(pattern, s string, paths bool) bool {
+	re, err := regexp.Compile("^" + globToRegexp(pattern, paths) + "$")
+	return err == nil && re.MatchString(s)
+}
+
+// globMatchPrefix reports whether s begins with a whole-word match of pattern, so that
+// "git push" covers "git push origin main" but not "git pushd".
+func globMatchPrefix(pattern, s string) bool {
+	re, err := regexp.Compile("^" + globToRegexp(pattern, false) + `(\s|$)`)
+	return err == nil && re.MatchString(s)
+}
+
+// globToRegexp translates a glob pattern into an unanchored regular expression.
+func globToRegexp(pattern string, paths bool) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*' && paths && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				b.WriteString("(?:.*/)?") // "**/" is whole directories, or none
+				i++
+			} else {
+				b.WriteString(".*")
 			}
-			asciiLines[0] = asciiLines[0] + strings.Repeat(" ", padding) + version
+		case c == '*' && paths:
+			b.WriteString("[^/]*")
+		case c == '*':
+			b.WriteString(".*")
+		case c == '?' && paths:
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
 		}
-		title = strings.Join(asciiLines, "\n")
-	} else {
-		// Narrow terminal: fallback to regular gradient text with version
-		titleText := "🛠️  ClaudeKit"
-		gradientTitle := gradient.RenderGradient(titleText, headerTheme, m.terminalCap, true)
+	}
+	return b.String()
+}
 
-		// Add version on same line with padding
-		titleWidth := lipgloss.Width(gradientTitle)
-		padding := innerWidth - titleWidth - lipgloss.Width(version)
-		if padding < 0 {
-			padding = 0
+// renderPermissionDecision formats the explanation printed by `claudekit permissions test`.
+func renderPermissionDecision(query, settingsPath string, d permissionDecision) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s → %s\n", query, d.Verdict)
+	if d.Matched == nil {
+		fmt.Fprintf(&b, "  %s (checked %s)\n", d.Reason, settingsPath)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  matched %s rule %q in %s\n", d.Matched.List, d.Matched.Rule, settingsPath)
+	fmt.Fprintf(&b, "  why: %s\n", d.Matched.Reason)
+	for _, m := range d.Others {
+		fmt.Fprintf(&b, "  also matched %s rule %q, which does not apply\n", m.List, m.Rule)
+	}
+	if d.Reason != "" {
+		fmt.Fprintf(&b, "  %s\n", d.Reason)
+	}
+	return b.String()
+}
+
+// hookIssue describes a hook command from settings.json that Claude Code will not be able to run.
+type hookIssue struct {
+	Event   string // Hook event name (e.g. "PreToolUse")
+	Command string // Command as written in settings.json
+	Path    string // Resolved script path on disk
+	Problem string // What is wrong
+	Fix     string // Suggested fix
+}
+
+// hookVerificationError is returned by run() when generated hooks would be skipped at runtime.
+type hookVerificationError struct {
+	Issues []hookIssue
+}
+
+// ExitCode reports a partial failure: the configuration was written, but some of
+// its hooks will not run.
+func (e *hookVerificationError) ExitCode() int { return exitPartial }
+
+func (e *hookVerificationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d hook command(s) in settings.json will not run:\n", len(e.Issues))
+	for _, issue := range e.Issues {
+		fmt.Fprintf(&b, "  - [%s] %s: %s\n", issue.Event, issue.Path, issue.Problem)
+		fmt.Fprintf(&b, "    fix: %s\n", issue.Fix)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// resolveHookCommandPath extracts the script path from a hook command and expands
+// $CLAUDE_PROJECT_DIR against projectDir. Returns "" for commands that are not paths
+// (e.g. inline shell like "echo hi"), which cannot be checked on disk.
+func resolveHookCommandPath(projectDir, command string) string {
+	script := strings.Trim(hookCommandScript(command), "'")
+	if script == "" {
+		return ""
+	}
+	script = strings.ReplaceAll(script, "${CLAUDE_PROJECT_DIR}", projectDir)
+	script = strings.ReplaceAll(script, "$CLAUDE_PROJECT_DIR", projectDir)
+	if strings.Contains(script, "$") || !strings.ContainsAny(script, `/\`) {
+		return "" // Unresolvable variable or bare command looked up on PATH
+	}
+	return filepath.Clean(script)
+}
+
+// verifyHookCommands stats every hook command referenced in st and checks that the
+// script exists, is executable, and starts with a shebang line.
+func verifyHookCommands(files fsys.FS, projectDir string, st settings) []hookIssue {
+	var issues []hookIssue
+
+	events := make([]string, 0, len(st.Hooks))
+	for event := range st.Hooks {
+		events = append(events, event)
+	}
+	slices.Sort(events)
+
+	for _, event := range events {
+		for _, matcher := range st.Hooks[event] {
+			for _, h := range matcher.Hooks {
+				if h.Type != "command" {
+					continue
+				}
+				path := resolveHookCommandPath(projectDir, h.Command)
+				if path == "" {
+					continue
+				}
+				if issue, ok := checkHookScript(files, path); !ok {
+					issue.Event = event
+					issue.Command = h.Command
+					issues = append(issues, issue)
+				}
+			}
 		}
-		title = gradientTitle + strings.Repeat(" ", padding) + version
 	}
 
-	// Create gradient top border with "/" characters
-	borderWidth := innerWidth
-	borderText := strings.Repeat("/", borderWidth)
-	gradientBorder := gradient.RenderGradient(borderText, headerTheme, m.terminalCap, true)
+	return issues
+}
 
-	// Feature 007: Adaptive right panel based on terminal size
-	var content string
+// verifyGlobalHookCommands flags hook commands in global settings that reference
+// $CLAUDE_PROJECT_DIR. That variable names the project Claude Code is opened in, so
+// such a command looks for the script in every project instead of the global
+// configuration and never runs.
+func verifyGlobalHookCommands(st settings) []hookIssue {
+	var issues []hookIssue
+
+	events := make([]string, 0, len(st.Hooks))
+	for event := range st.Hooks {
+		events = append(events, event)
+	}
+	slices.Sort(events)
+
+	for _, event := range events {
+		for _, matcher := range st.Hooks[event] {
+			for _, h := range matcher.Hooks {
+				if strings.Contains(h.Command, "CLAUDE_PROJECT_DIR") {
+					issues = append(issues, hookIssue{
+						Event:   event,
+						Command: h.Command,
+						Path:    h.Command,
+						Problem: "global hook command references $CLAUDE_PROJECT_DIR, which points at the current project rather than the global configuration",
+						Fix:     "use an absolute path to the script, or re-run claudekit to regenerate global settings",
+					})
+				}
+			}
+		}
+	}
 
-	if m.showRightPanel {
-		// Update viewport height to match available content height
-		m.viewport.Height = statusHeight
-		m.viewport.Width = statusWidth
+	return issues
+}
 
-		// Large terminal: show form + right panel
-		formContent := m.form.View()
-		leftContent := formStyle.
-			Width(formWidth).
-			Height(formHeight).
-			Render(formContent)
+// checkHookScript validates a single hook script in files.
+func checkHookScript(files fsys.FS, path string) (hookIssue, bool) {
+	info, err := files.Stat(path)
+	if err != nil {
+		return hookIssue{
+			Path:    path,
+			Problem: "script does not exist",
+			Fix:     "re-run claudekit with the hook selected, or remove the hook from .claude/settings.json",
+		}, false
+	}
+	if info.IsDir() {
+		return hookIssue{
+			Path:    path,
+			Problem: "path is a directory, not a script",
+			Fix:     "point the hook command at a script file",
+		}, false
+	}
+	// PowerShell scripts run through powershell -File, and Windows has no execute bit
+	if strings.HasSuffix(path, ".ps1") {
+		return hookIssue{}, true
+	}
+	if info.Mode()&0o111 == 0 && targetOS != "windows" {
+		return hookIssue{
+			Path:    path,
+			Problem: "script is not executable",
+			Fix:     fmt.Sprintf("chmod +x %s", path),
+		}, false
+	}
+
+	content, err := files.ReadFile(path)
+	if err != nil {
+		return hookIssue{
+			Path:    path,
+			Problem: fmt.Sprintf("cannot read script: %v", err),
+			Fix:     "check the file permissions",
+		}, false
+	}
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return hookIssue{
+			Path:    path,
+			Problem: "script has no shebang line",
+			Fix:     "add an interpreter line such as #!/usr/bin/env bash as the first line",
+		}, false
+	}
 
-		// Regenerate right panel content (FR-008: always fresh)
-		m.viewport.SetContent(m.renderMarkdown(m.renderStatus()))
+	return hookIssue{}, true
+}
 
-		// Status panel (right side, fixed height to match form)
-		statusPanel := statusStyle.
-			Width(statusWidth).
-			Height(statusHeight). // Use consistent height
-			Render(m.viewport.View())
+// Editors that slash command workflows can be generated for.
+const (
+	editorVSCode    = "vscode"
+	editorJetBrains = "jetbrains"
+)
 
-		// Main content (left content + status)
-		// Ensure exact height by padding if necessary
-		leftContent = ensureExactHeight(leftContent, formHeight)
-		statusPanel = ensureExactHeight(statusPanel, statusHeight)
+// editorTask is a repeatable shell workflow behind a slash command, declared in the
+// command module's defaults, that can also be run from an editor.
+type editorTask struct {
+	Module  string // Command module the task comes from
+	Label   string // "add-tests: Run tests", with the language appended when several apply
+	Group   string // VS Code task group: "build", "test", or "" for none
+	Command string
+}
 
-		content = lipgloss.JoinHorizontal(lipgloss.Top, leftContent, statusPanel)
-	} else {
-		// Small terminal: full-width form only (FR-006)
-		formContent := m.form.View()
-		leftContent := formStyle.
-			Width(innerWidth - 4). // Full width minus padding
-			Height(formHeight).
-			Render(formContent)
+// commandTasks collects the tasks of the selected slash commands for the selected
+// languages. A module declares each task's label, optional group, and one shell
+// command per language:
+//
+//	tasks:
+//	  - label: Run tests
+//	    group: test
+//	    run:
+//	      Go: go test ./...
+func commandTasks(cfg Config, registry *ModuleRegistry) []editorTask {
+	var tasks []editorTask
+	for _, display := range cfg.SlashCommands {
+		name := cleanFormValue(display)
+		module := registry.Get(TypeCommand, name)
+		if module == nil {
+			continue
+		}
+		defs, _ := module.Defaults["tasks"].([]any)
+		for _, d := range defs {
+			def, _ := d.(map[string]any)
+			label, _ := def["label"].(string)
+			group, _ := def["group"].(string)
+			run, _ := def["run"].(map[string]any)
+			if label == "" {
+				continue
+			}
 
-		// Ensure exact height
-		leftContent = ensureExactHeight(leftContent, formHeight)
-		content = leftContent
+			var languages []string
+			for _, lang := range cfg.Languages {
+				if command, _ := run[lang].(string); command != "" {
+					languages = append(languages, lang)
+				}
+			}
+			for _, lang := range languages {
+				task := editorTask{Module: name, Label: name + ": " + label, Group: group, Command: run[lang].(string)}
+				if len(languages) > 1 {
+					task.Label += " (" + lang + ")"
+				}
+				tasks = append(tasks, task)
+			}
+		}
 	}
+	return tasks
+}
 
-	// Combine title, border, and content
-	app := lipgloss.JoinVertical(lipgloss.Left, title, gradientBorder, content)
+// writeEditorTasks adds the slash command tasks to .vscode/tasks.json and writes a
+// JetBrains run configuration per task, as cfg.EditorTasks asks. tasks.json is shared
+// with the user: only the tasks labelled in the manifest are replaced or removed.
+func writeEditorTasks(w *generationWriter, cfg Config, registry *ModuleRegistry) error {
+	tasks := commandTasks(cfg, registry)
+	var owned []string
+	if w.previous != nil {
+		owned = w.previous.EditorTasks
+	}
 
-	// Add border around entire application with gradient start color and padding
-	borderColor := headerTheme.StartColor
-	borderStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(borderColor).
-		Padding(1, 2) // 1 line top/bottom, 2 chars left/right padding
+	tasksPath := filepath.Join(w.baseDir, ".vscode", "tasks.json")
+	if slices.Contains(cfg.EditorTasks, editorVSCode) && len(tasks) > 0 {
+		existing, err := w.fs.ReadFile(tasksPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		content, err := mergeVSCodeTasks(existing, owned, tasks)
+		if err != nil {
+			// Leave a tasks.json we cannot read alone, and keep owning what we wrote before
+			slog.Warn("not updating .vscode/tasks.json", "err", err)
+			w.current.EditorTasks = owned
+			if prev, ok := w.previous.Lookup(manifest.RelPath(w.baseDir, tasksPath)); ok {
+				w.current.Put(prev)
+			}
+		} else {
+			w.mkdir(filepath.Dir(tasksPath))
+			if err := w.writeMerged(tasksPath, content, manifest.KindVSCodeTasks); err != nil {
+				return err
+			}
+			for _, task := range tasks {
+				w.current.EditorTasks = append(w.current.EditorTasks, task.Label)
+			}
+		}
+	} else if len(owned) > 0 {
+		if _, _, err := stripOwnedVSCodeTasks(w.fs, tasksPath, owned, false); err != nil {
+			return err
+		}
+	}
 
-	// Render the app with border
-	rendered := borderStyle.Render(app)
+	if slices.Contains(cfg.EditorTasks, editorJetBrains) {
+		runDir := filepath.Join(w.baseDir, ".run")
+		for _, task := range tasks {
+			w.mkdir(runDir)
+			path := filepath.Join(runDir, runConfigFileName(task.Label))
+			if _, err := w.write(path, []byte(renderRunConfiguration(task)), 0o644, manifest.KindRunConfig, task.Module); err != nil {
+				return err
+			}
+		}
+	}
+	// Drop run configurations for commands, languages, or editors no longer selected
+	if w.previous != nil {
+		for _, entry := range w.previous.Files {
+			if _, ok := w.current.Lookup(entry.Path); entry.Kind == manifest.KindRunConfig && !ok {
+				w.removeStale(entry.AbsPath(w.baseDir))
+			}
+		}
+	}
+	return nil
+}
 
-	// Enforce exact terminal dimensions to prevent height overflow
-	// Truncate content to fit within terminal bounds
-	lines := strings.Split(rendered, "\n")
-	if len(lines) > m.height {
-		lines = lines[:m.height]
+// mergeVSCodeTasks returns tasks.json content holding the user's tasks from existing
+// followed by tasks. Tasks whose labels are in owned, or match a new task, are replaced.
+func mergeVSCodeTasks(existing []byte, owned []string, tasks []editorTask) ([]byte, error) {
+	doc := map[string]any{}
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if err := json.Unmarshal(stripJSONComments(existing), &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse tasks.json: %w", err)
+		}
+	}
+	if _, ok := doc["version"]; !ok {
+		doc["version"] = "2.0.0"
 	}
 
-	// Ensure each line doesn't exceed width
-	for i, line := range lines {
-		if lipgloss.Width(line) > m.width {
-			// Truncate line to fit width (accounting for ANSI codes)
-			lines[i] = truncateLine(line, m.width)
+	replaced := map[string]bool{}
+	for _, label := range owned {
+		replaced[label] = true
+	}
+	for _, task := range tasks {
+		replaced[task.Label] = true
+	}
+
+	var merged []any
+	existingTasks, _ := doc["tasks"].([]any)
+	for _, t := range existingTasks {
+		entry, _ := t.(map[string]any)
+		if label, _ := entry["label"].(string); replaced[label] {
+			continue
+		}
+		merged = append(merged, t)
+	}
+	for _, task := range tasks {
+		entry := map[string]any{
+			"label":          task.Label,
+			"type":           "shell",
+			"command":        task.Command,
+			"problemMatcher": []any{},
+		}
+		if task.Group != "" {
+			entry["group"] = task.Group
 		}
+		merged = append(merged, entry)
 	}
+	doc["tasks"] = merged
 
-	return strings.Join(lines, "\n")
+	buf, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
 }
 
-// truncateLine truncates a line to the specified width, preserving ANSI codes
-func truncateLine(line string, width int) string {
-	// Use lipgloss's Truncate which handles ANSI codes properly
-	return lipgloss.NewStyle().Width(width).Render(line)
+// stripJSONComments turns the JSON-with-comments VS Code accepts into plain JSON by
+// blanking // and /* */ comments and dropping trailing commas, outside of strings.
+func stripJSONComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			// A comma followed only by whitespace before a closer is a trailing comma
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
 }
 
-// ensureExactHeight pads or truncates content to be exactly the specified height
-func ensureExactHeight(content string, targetHeight int) string {
-	lines := strings.Split(content, "\n")
-	currentHeight := len(lines)
+// runConfigFileName names the .run/ file for a task label, e.g. "add-tests-run-tests-go.run.xml".
+func runConfigFileName(label string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-") + ".run.xml"
+}
 
-	if currentHeight == targetHeight {
-		return content
+// renderRunConfiguration renders a JetBrains shell script run configuration that runs
+// the task's command from the project root.
+func renderRunConfiguration(task editorTask) string {
+	escape := func(s string) string {
+		var b strings.Builder
+		_ = xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	return fmt.Sprintf(`<component name="ProjectRunConfigurationManager">
+  <configuration default="false" name="%s" type="ShConfigurationType">
+    <option name="SCRIPT_TEXT" value="%s" />
+    <option name="INDEPENDENT_SCRIPT_PATH" value="true" />
+    <option name="SCRIPT_PATH" value="" />
+    <option name="SCRIPT_OPTIONS" value="" />
+    <option name="INDEPENDENT_SCRIPT_WORKING_DIRECTORY" value="true" />
+    <option name="SCRIPT_WORKING_DIRECTORY" value="$PROJECT_DIR$" />
+    <option name="INDEPENDENT_INTERPRETER_PATH" value="true" />
+    <option name="INTERPRETER_PATH" value="" />
+    <option name="INTERPRETER_OPTIONS" value="" />
+    <option name="EXECUTE_IN_TERMINAL" value="true" />
+    <option name="EXECUTE_SCRIPT_FILE" value="false" />
+    <envs />
+    <method v="2" />
+  </configuration>
+</component>
+`, escape(task.Label), escape(task.Command))
+}
+
+// Jobs .github/workflows/claude.yml can run with the claude-code GitHub action.
+const (
+	githubWorkflowReview = "review"
+	githubWorkflowTriage = "triage"
+)
+
+// Tools each job needs besides what the selected permissions allow.
+var (
+	githubReviewTools = []string{"mcp__github_inline_comment__create_inline_comment", "Bash(gh pr comment:*)", "Bash(gh pr diff:*)", "Bash(gh pr view:*)"}
+	githubTriageTools = []string{"Bash(gh issue view:*)", "Bash(gh issue edit:*)", "Bash(gh issue comment:*)", "Bash(gh label list:*)", "Bash(gh search issues:*)"}
+)
+
+// githubWorkflowData is what claude.yml.tmpl renders.
+type githubWorkflowData struct {
+	ProjectName string
+	Review      bool
+	Triage      bool
+	Agents      []setupDocEntry // The selected subagents, for the review prompt
+	ReviewTools []string        // --allowedTools of the review job
+	TriageTools []string        // --allowedTools of the triage job
+	DeniedTools []string        // --disallowedTools of both jobs
+}
+
+// renderGitHubWorkflow renders .github/workflows/claude.yml. The jobs allow what the
+// selected permissions allow and deny what they deny; ask rules are left out, since
+// nobody is there to answer in CI.
+func renderGitHubWorkflow(cfg Config, registry *ModuleRegistry) string {
+	allow, _, deny := configPermissions(cfg, registry)
+	data := githubWorkflowData{
+		ProjectName: cfg.ProjectName,
+		Review:      slices.Contains(cfg.Workflows, githubWorkflowReview),
+		Triage:      slices.Contains(cfg.Workflows, githubWorkflowTriage),
+		ReviewTools: workflowTools(allow, deny, githubReviewTools),
+		TriageTools: workflowTools(allow, deny, githubTriageTools),
+		DeniedTools: deny,
+	}
+	for _, agent := range setupDocEntries(TypeSubagent, cfg.Subagents, registry) {
+		agent.Summary = strings.ReplaceAll(agent.Summary, "\\|", "|") // Escaped for markdown tables
+		data.Agents = append(data.Agents, agent)
+	}
+	return renderTemplate(registry, workflowTemplate, "assets/templates/claude.yml.tmpl", data)
+}
+
+// workflowTools adds a job's own tools to the allow rules, unless a rule denies them.
+func workflowTools(allow, deny, needed []string) []string {
+	tools := slices.Clone(allow)
+	for _, tool := range needed {
+		if !slices.Contains(tools, tool) && !slices.Contains(deny, tool) {
+			tools = append(tools, tool)
+		}
 	}
+	return tools
+}
 
-	if currentHeight > targetHeight {
-		// Truncate to target height
-		return strings.Join(lines[:targetHeight], "\n")
+// parseGitHubWorkflows parses --github-workflow: jobs separated by commas, or "none".
+func parseGitHubWorkflows(s string) ([]string, error) {
+	jobs := []string{}
+	if strings.TrimSpace(s) == "none" {
+		return jobs, nil
+	}
+	for _, job := range strings.Split(s, ",") {
+		job = strings.TrimSpace(job)
+		if job != githubWorkflowReview && job != githubWorkflowTriage {
+			return nil, fmt.Errorf("unknown job %q; use %s, %s, or none", job, githubWorkflowReview, githubWorkflowTriage)
+		}
+		if !slices.Contains(jobs, job) {
+			jobs = append(jobs, job)
+		}
 	}
+	return jobs, nil
+}
 
-	// Pad with empty lines to reach target height
-	padding := make([]string, targetHeight-currentHeight)
-	for i := range padding {
-		padding[i] = ""
+// devcontainerSetupCommand runs the generated setup script from the workspace root.
+const devcontainerSetupCommand = "bash .devcontainer/claude-setup.sh"
+
+// devcontainerPackage is a command the hooks run on and the apt package providing it.
+type devcontainerPackage struct {
+	Command string
+	Package string
+}
+
+// hookLanguagePackages lists what hooks in each language need in the container.
+// lib/hook.sh reads the hook input with jq. PowerShell has no apt package.
+var hookLanguagePackages = map[hookLanguage]devcontainerPackage{
+	hookLangBash:   {Command: "jq", Package: "jq"},
+	hookLangPython: {Command: "python3", Package: "python3"},
+	hookLangNode:   {Command: "node", Package: "nodejs"},
+}
+
+// devcontainerData is what claude-setup.sh.tmpl renders.
+type devcontainerData struct {
+	ProjectName string
+	HooksDir    string
+	Packages    []devcontainerPackage // Installed with apt-get when missing
+	MCPCommands []string              // Register the MCP servers scoped to the user
+}
+
+// renderDevcontainerSetup renders .devcontainer/claude-setup.sh.
+func renderDevcontainerSetup(cfg Config, registry *ModuleRegistry) string {
+	_, userServers := mcpScopes(cfg)
+	data := devcontainerData{
+		ProjectName: cfg.ProjectName,
+		HooksDir:    cfg.Layout.WithDefaults().Hooks,
+		MCPCommands: mcpAddCommands(userServers),
+	}
+	for _, display := range cfg.Hooks {
+		name := cleanFormValue(display)
+		module := registry.Get(TypeHook, name)
+		if module == nil {
+			continue
+		}
+		lang, err := resolveHookLanguage(name, module, cfg.HookLanguages)
+		if pkg, ok := hookLanguagePackages[lang]; err == nil && ok && !slices.Contains(data.Packages, pkg) {
+			data.Packages = append(data.Packages, pkg)
+		}
+	}
+	return renderTemplate(registry, devcontainerTemplate, "assets/templates/claude-setup.sh.tmpl", data)
+}
+
+// buildDevcontainerJSON returns the devcontainer.json written for projects without
+// one: an Ubuntu image with Node.js that runs claude-setup.sh, keeps the Claude Code
+// login in a volume across rebuilds, and passes ANTHROPIC_API_KEY through.
+func buildDevcontainerJSON(cfg Config) string {
+	doc := struct {
+		Name              string            `json:"name"`
+		Image             string            `json:"image"`
+		Features          map[string]any    `json:"features"`
+		Mounts            []string          `json:"mounts"`
+		RemoteEnv         map[string]string `json:"remoteEnv"`
+		PostCreateCommand string            `json:"postCreateCommand"`
+		Customizations    map[string]any    `json:"customizations"`
+	}{
+		Name:              cmp.Or(cfg.ProjectName, "Claude Code"),
+		Image:             "mcr.microsoft.com/devcontainers/base:ubuntu",
+		Features:          map[string]any{"ghcr.io/devcontainers/features/node:1": map[string]any{}},
+		Mounts:            []string{"source=claude-code-config-${devcontainerId},target=/home/vscode/.claude,type=volume"},
+		RemoteEnv:         map[string]string{"ANTHROPIC_API_KEY": "${localEnv:ANTHROPIC_API_KEY}"},
+		PostCreateCommand: devcontainerSetupCommand,
+		Customizations:    map[string]any{"vscode": map[string]any{"extensions": []string{"anthropic.claude-code"}}},
+	}
+	out, _ := json.MarshalIndent(doc, "", "  ")
+	return string(out)
+}
+
+// devcontainerLabel summarizes the dev container choice for the headless plan.
+func devcontainerLabel(cfg Config) []string {
+	if cfg.Devcontainer {
+		return []string{".devcontainer/claude-setup.sh"}
+	}
+	return nil
+}
+
+// githubExpr writes a GitHub Actions expression, whose braces templates cannot hold
+// literally: {{expr "secrets.ANTHROPIC_API_KEY"}} gives ${{ secrets.ANTHROPIC_API_KEY }}.
+func githubExpr(expression string) string {
+	return "${{ " + expression + " }}"
+}
+
+// frameworkCommand is one command listed in a framework's CLAUDE.md block.
+type frameworkCommand struct {
+	Run         string
+	Description string
+}
+
+// frameworkGuidance is the CLAUDE.md block for a selected framework, read from the
+// framework module's defaults (title, language, commands, conventions).
+type frameworkGuidance struct {
+	Title       string
+	Language    string
+	Commands    []frameworkCommand
+	Conventions []string
+}
+
+// frameworkGuidanceFor extracts the guidance block from a framework module.
+func frameworkGuidanceFor(module *ComponentModule) frameworkGuidance {
+	g := frameworkGuidance{Title: module.Name}
+	if title, ok := module.Defaults["title"].(string); ok && title != "" {
+		g.Title = title
+	}
+	g.Language, _ = module.Defaults["language"].(string)
+
+	commands, _ := module.Defaults["commands"].([]any)
+	for _, c := range commands {
+		entry, _ := c.(map[string]any)
+		run, _ := entry["run"].(string)
+		desc, _ := entry["description"].(string)
+		if run != "" {
+			g.Commands = append(g.Commands, frameworkCommand{Run: run, Description: desc})
+		}
 	}
-	return content + "\n" + strings.Join(padding, "\n")
+	conventions, _ := module.Defaults["conventions"].([]any)
+	for _, c := range conventions {
+		if text, ok := c.(string); ok && text != "" {
+			g.Conventions = append(g.Conventions, text)
+		}
+	}
+	return g
 }
 
-func (m *model) renderStatus() string {
-	// If on the confirmation page, show configuration summary
-	if m.form.State == huh.StateCompleted || isOnConfirmationPage(m.form) {
-		return m.renderConfigurationSummary()
+// detectFrameworks returns the framework modules whose detect rules match files in
+// dir. A rule matches when its file glob exists and, if set, the file contains the
+// given text; any matching rule detects the framework.
+func detectFrameworks(dir string, registry *ModuleRegistry) []string {
+	var detected []string
+	for _, module := range registry.List(TypeFramework) {
+		if !module.Enabled {
+			continue // Never preselect what the form hides
+		}
+		rules, _ := module.Defaults["detect"].([]any)
+		for _, r := range rules {
+			rule, _ := r.(map[string]any)
+			pattern, _ := rule["file"].(string)
+			contains, _ := rule["contains"].(string)
+			if pattern != "" && frameworkRuleMatches(dir, pattern, contains) {
+				detected = append(detected, module.Name)
+				break
+			}
+		}
 	}
-	
-	// Otherwise, show the current description
-	return m.getCurrentDescription()
+	return detected
 }
 
-// isOnConfirmationPage checks if we're on the final confirmation page
-func isOnConfirmationPage(form *huh.Form) bool {
-	// Check if the form has a focused field with confirmation-related text
-	focusedField := form.GetFocusedField()
-	if focusedField != nil {
-		// Check if the field title contains "Generate Claude Code configuration"
-		// This is a simple way to detect the confirmation page
-		if confirm, ok := focusedField.(*huh.Confirm); ok {
-			// We can check some property that would indicate it's our confirmation field
-			_ = confirm // Use the confirm variable to avoid unused variable error
-			// For now, let's assume any confirm field on the last page is our confirmation
+func frameworkRuleMatches(dir, pattern, contains string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+	for _, match := range matches {
+		if contains == "" {
+			return true
+		}
+		if data, err := os.ReadFile(match); err == nil && strings.Contains(string(data), contains) {
 			return true
 		}
 	}
 	return false
 }
 
-func (m *model) renderConfigurationSummary() string {
-	var status strings.Builder
-	
-	status.WriteString("## 📋 Configuration Summary\n\n")
-	status.WriteString("\n\n-----\n\n")
-	
-	// Show configuration path based on project-local setting
-	if m.config.IsProjectLocal {
-		currentDir, err := os.Getwd()
-		if err != nil {
-			currentDir = "<current directory>"
-		}
-		status.WriteString("### 📁 Configuration Path:\n")
-		status.WriteString(fmt.Sprintf("  %s/.claude/\n\n", currentDir))
-	} else {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			homeDir = "<home directory>"
+// frameworkOptions builds the framework page options once the registry loads,
+// preselecting frameworks detected in dir when preselect is set.
+func frameworkOptions(loader *registryLoader, dir string, preselect bool, includeDisabled *bool) func() []huh.Option[string] {
+	return func() []huh.Option[string] {
+		registry, _ := loader.Wait()
+		options := registry.GetOptions(TypeFramework, *includeDisabled)
+		if !preselect {
+			return options
 		}
-		status.WriteString("### 🏠 Configuration Path:\n")
-		status.WriteString(fmt.Sprintf("  %s/.claude/\n\n", homeDir))
-	}
-	
-	// Language Setup
-	status.WriteString("### 💻 Languages\n")
-	if len(m.config.Languages) > 0 {
-		for _, lang := range m.config.Languages {
-			status.WriteString(fmt.Sprintf("* %s\n", lang))
+		detected := detectFrameworks(dir, registry)
+		for i := range options {
+			if slices.Contains(detected, options[i].Value) {
+				options[i] = options[i].Selected(true)
+			}
 		}
-	} else {
-		status.WriteString("* (none selected)\n")
+		return options
 	}
-	status.WriteString("\n")
+}
 
-	// Subagents
-	status.WriteString("### 🤖 Subagents\n")
-	if len(m.config.Subagents) > 0 {
-		for _, agent := range m.config.Subagents {
-			status.WriteString(fmt.Sprintf("* %s\n", cleanFormValue(agent)))
-		}
-	} else {
-		status.WriteString("* (none selected)\n")
-	}
-	status.WriteString("\n")
+// languageFlags reports which languages were selected, for templates that add
+// per-language guidance or lint commands.
+type languageFlags struct {
+	HasGo         bool
+	HasTypeScript bool
+	HasPython     bool
+	HasRust       bool
+	HasCpp        bool
+	HasJava       bool
+	HasCsharp     bool
+	HasPhp        bool
+	HasRuby       bool
+	HasSwift      bool
+	HasDart       bool
+	HasShell      bool
+	HasLua        bool
+	HasElixir     bool
+	HasHaskell    bool
+	HasElm        bool
+	HasJulia      bool
+	HasSql        bool
+}
 
-	// Hooks
-	status.WriteString("### 🪝 Hooks\n")
-	if len(m.config.Hooks) > 0 {
-		for _, hook := range m.config.Hooks {
-			status.WriteString(fmt.Sprintf("* %s\n", cleanFormValue(hook)))
-		}
-	} else {
-		status.WriteString("* (none selected)\n")
+func newLanguageFlags(langs []string) languageFlags {
+	return languageFlags{
+		HasGo:         includes(langs, "Go"),
+		HasTypeScript: includes(langs, "TypeScript"),
+		HasPython:     includes(langs, "Python"),
+		HasRust:       includes(langs, "Rust"),
+		HasCpp:        includes(langs, "C++"),
+		HasJava:       includes(langs, "Java") || includes(langs, "Kotlin"),
+		HasCsharp:     includes(langs, "C#"),
+		HasPhp:        includes(langs, "PHP"),
+		HasRuby:       includes(langs, "Ruby"),
+		HasSwift:      includes(langs, "Swift"),
+		HasDart:       includes(langs, "Dart"),
+		HasShell:      includes(langs, "Shell"),
+		HasLua:        includes(langs, "Lua"),
+		HasElixir:     includes(langs, "Elixir"),
+		HasHaskell:    includes(langs, "Haskell"),
+		HasElm:        includes(langs, "Elm"),
+		HasJulia:      includes(langs, "Julia"),
+		HasSql:        includes(langs, "SQL"),
 	}
-	status.WriteString("\n")
+}
 
-	// Slash Commands
-	status.WriteString("### 📟 Slash Commands\n")
-	if len(m.config.SlashCommands) > 0 {
-		for _, cmd := range m.config.SlashCommands {
-			cleanCmd := cleanFormValue(cmd)
-			status.WriteString(fmt.Sprintf("* /%s\n", cleanCmd))
+// claudeMDData is what CLAUDE.md.tmpl renders: the answers, language flags, and the
+// guidance of the selected frameworks.
+type claudeMDData struct {
+	Config
+	languageFlags
+	Frameworks []frameworkGuidance
+	Memory     []string // Memory files to import, relative to the project
+	Date       string
+}
+
+func renderClaudeMD(cfg Config, registry *ModuleRegistry) string {
+	data := claudeMDData{
+		Config:        cfg,
+		languageFlags: newLanguageFlags(cfg.Languages),
+		Date:          time.Now().Format("2006-01-02"),
+	}
+	for _, name := range cfg.Frameworks {
+		if module := registry.Get(TypeFramework, name); module != nil {
+			data.Frameworks = append(data.Frameworks, frameworkGuidanceFor(module))
 		}
-	} else {
-		status.WriteString("* (none selected)\n")
 	}
-	status.WriteString("\n")
-
-	// MCP
-	status.WriteString("### 🔌 MCP Integration\n")
-	if len(m.config.MCPServers) > 0 {
-		for _, server := range m.config.MCPServers {
-			status.WriteString(fmt.Sprintf("* %s\n", cleanFormValue(server)))
+	if wantsMemoryFile(cfg, memorySeeds) {
+		for _, name := range memorySeedFiles() {
+			data.Memory = append(data.Memory, memoryDir+"/"+name)
 		}
-	} else {
-		status.WriteString("* (none selected)\n")
 	}
-	
-	return status.String()
+	return renderTemplate(registry, claudeMDTemplate, "assets/templates/CLAUDE.md.tmpl", data)
 }
 
+// ============================================================================
+// Personal and memory files: seeded once, then the user's
+// ============================================================================
 
-// Helper function to clean emoji prefixes from form selections
-func cleanFormValue(value string) string {
-	// Remove emoji and space prefix (e.g., "🔍 code-reviewer" -> "code-reviewer")
-	parts := strings.SplitN(value, " ", 2)
-	if len(parts) == 2 {
-		return parts[1]
+// Files the memory files choice seeds in a project.
+const (
+	memoryLocal = "local"  // CLAUDE.local.md: personal instructions, kept out of git
+	memorySeeds = "memory" // .claude/memory/: shared notes that CLAUDE.md imports
+)
+
+// memoryDir holds the memory notes, relative to the project.
+const memoryDir = ".claude/memory"
+
+// memorySeedFiles lists the names of the notes seeded into memoryDir.
+func memorySeedFiles() []string {
+	entries, err := assets.ReadDir("assets/templates/memory")
+	if err != nil {
+		panic(err)
 	}
-	return value
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
 }
 
-func cleanFormValues(values []string) []string {
-	cleaned := make([]string, len(values))
-	for i, v := range values {
-		cleaned[i] = cleanFormValue(v)
+// wantsMemoryFile reports whether cfg seeds choice. Only a project's root
+// configuration does; packages share the root's notes.
+func wantsMemoryFile(cfg Config, choice string) bool {
+	return cfg.IsProjectLocal && cfg.Package == "" && slices.Contains(cfg.MemoryFiles, choice)
+}
+
+// memoryFileLabels summarizes the memory file choices for the headless plan.
+func memoryFileLabels(cfg Config) []string {
+	var labels []string
+	if wantsMemoryFile(cfg, memoryLocal) {
+		labels = append(labels, "CLAUDE.local.md")
 	}
-	return cleaned
+	if wantsMemoryFile(cfg, memorySeeds) {
+		labels = append(labels, memoryDir+"/")
+	}
+	return labels
 }
 
-// Gradient System Functions (T021-T027)
+// claudeLocalData is what CLAUDE.local.md.tmpl renders.
+type claudeLocalData struct {
+	ProjectName string
+}
 
-// detectTerminalCapability detects terminal color support from env vars (T021)
+func renderClaudeLocal(cfg Config, registry *ModuleRegistry) string {
+	return renderTemplate(registry, claudeLocalTemplate, "assets/templates/CLAUDE.local.md.tmpl", claudeLocalData{ProjectName: cfg.ProjectName})
+}
 
-// interpolateColor performs RGB interpolation between two colors (T022)
+// ============================================================================
+// .gitignore: claudekit's block of local files
+// ============================================================================
 
-// interpolateGradient interpolates between two gradient themes (T023)
+// Markers around the lines of .gitignore claudekit owns.
+const (
+	gitignoreBegin = "# claudekit:begin"
+	gitignoreEnd   = "# claudekit:end"
+)
 
-// adjustSaturation adjusts the saturation of a hex color (Feature 006: T007)
+// gitignoreLocalFiles are files Claude Code and the generated hooks leave in a
+// project that belong to one person's checkout: personal settings, the usage-log
+// hook's log, and the logs other hooks keep.
+var gitignoreLocalFiles = []string{
+	".claude/settings.local.json",
+	".claude/" + usage.FileName,
+	".claude/logs/",
+}
 
-// increaseBrightness increases the brightness of a hex color (Feature 006: T008)
+// gitignoreEntries lists the lines cfg wants in claudekit's block of .gitignore:
+// the local files when the user opted in, and CLAUDE.local.md whenever it is seeded.
+func gitignoreEntries(cfg Config) []string {
+	var entries []string
+	if cfg.IsProjectLocal && cfg.Package == "" && cfg.Gitignore {
+		entries = append(entries, gitignoreLocalFiles...)
+	}
+	if wantsMemoryFile(cfg, memoryLocal) {
+		entries = append(entries, "CLAUDE.local.md")
+	}
+	return entries
+}
 
-// extendColorPaletteForMarkdown extends palette with markdown colors (Feature 006: T011)
+// updateGitignore returns existing with claudekit's block holding entries, less
+// those a line outside the block already covers. The block is added at the end when
+// there is none, and removed when it would be empty.
+func updateGitignore(existing string, entries []string) (string, error) {
+	var before, after []string
+	var inBlock, seenBlock bool
+	for line := range strings.Lines(existing) {
+		switch trimmed := strings.TrimSpace(line); {
+		case trimmed == gitignoreBegin:
+			if inBlock || seenBlock {
+				return "", fmt.Errorf("%q appears twice", gitignoreBegin)
+			}
+			inBlock, seenBlock = true, true
+		case trimmed == gitignoreEnd:
+			if !inBlock {
+				return "", fmt.Errorf("%q without %q before it", gitignoreEnd, gitignoreBegin)
+			}
+			inBlock = false
+		case inBlock:
+			// The block is written afresh
+		case seenBlock:
+			after = append(after, strings.TrimSuffix(line, "\n"))
+		default:
+			before = append(before, strings.TrimSuffix(line, "\n"))
+		}
+	}
+	if inBlock {
+		return "", fmt.Errorf("%q without %q after it", gitignoreBegin, gitignoreEnd)
+	}
 
-// generateGlamourStyle creates custom glamour renderer from palette (Feature 006: T012)
+	// A pattern the user already has, with or without a leading slash, is theirs
+	covered := map[string]bool{}
+	for _, line := range slices.Concat(before, after) {
+		covered[strings.TrimPrefix(strings.TrimSpace(line), "/")] = true
+	}
+	var block []string
+	for _, entry := range entries {
+		if !covered[strings.TrimPrefix(entry, "/")] && !slices.Contains(block, entry) {
+			block = append(block, entry)
+		}
+	}
 
-// gradient.EaseInOutCubic applies cubic easing for smooth animations (T024)
+	lines := before
+	if len(block) > 0 {
+		if !seenBlock && len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, gitignoreBegin)
+		lines = append(lines, block...)
+		lines = append(lines, gitignoreEnd)
+	} else if !seenBlock {
+		return existing, nil
+	}
+	lines = append(lines, after...)
+	if len(block) == 0 {
+		// Drop the blank line left where the block was at the end
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
 
-// quantizeStops reduces gradient stops for limited terminals (T025)
+// stripGitignoreBlock removes claudekit's block from .gitignore, deleting the file
+// when nothing else remains.
+func stripGitignoreBlock(files fsys.FS, path string, dryRun bool) (changed, empty bool, err error) {
+	data, err := files.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	content, err := updateGitignore(string(data), nil)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if content == string(data) {
+		return false, false, nil
+	}
+	if !dryRun {
+		if content == "" {
+			err = files.Remove(path)
+		} else {
+			err = files.WriteFile(path, []byte(content), 0o644)
+		}
+	}
+	return true, content == "", err
+}
 
-// applyGradient creates a Lipgloss style with gradient (T026)
+// ============================================================================
+// CLAUDE.md managed sections
+// ============================================================================
 
-// renderASCIITitle applies gradient to ASCII art line-by-line (T014)
+// CLAUDE.md is shared with the user: claudekit owns only the blocks between
+// <!-- claudekit:begin NAME --> and <!-- claudekit:end NAME --> lines, and rewrites
+// just those on later runs.
+const (
+	sectionMarkerPrefix = "<!-- claudekit:"
+	sectionMarkerSuffix = " -->"
+)
 
-// renderGradient renders text with gradient colors applied (T027)
+// sectionPart is a marked claudekit section, markers included, or the user's text
+// between sections when Name is "".
+type sectionPart struct {
+	Name string
+	Text string
+}
 
-// validateGradientTheme validates theme constraints (helper for tests)
-func validateGradientTheme(theme gradient.Theme) error {
-	if theme.Stops < 2 {
-		return fmt.Errorf("stops must be >= 2, got %d", theme.Stops)
+// legacyClaudeMDHeadings maps the headings of CLAUDE.md files written before sections
+// existed to the sections that replace them.
+var legacyClaudeMDHeadings = map[string]string{
+	"## Build & Test Commands":   "languages",
+	"## Framework Guidance":      "frameworks",
+	"## Code Style":              "code-style",
+	"## Workflow":                "workflow",
+	"## Important Files to Know": "files",
+	"## Claude Usage":            "usage",
+	"## Project‑Specific Notes":  "notes",
+}
+
+// sectionMarker reports the section name when line is a begin or end marker of kind.
+func sectionMarker(line, kind string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), sectionMarkerPrefix+kind+" ")
+	if !ok {
+		return "", false
 	}
-	if theme.Intensity < 0.0 || theme.Intensity > 1.0 {
-		return fmt.Errorf("intensity must be in [0.0, 1.0], got %f", theme.Intensity)
+	name, ok := strings.CutSuffix(rest, sectionMarkerSuffix)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", false
 	}
-	return nil
+	return name, true
 }
 
-// Gradient palette definitions (T030)
-type gradientPalettesType struct {
-	primary    lipgloss.AdaptiveColor
-	secondary  lipgloss.AdaptiveColor
-	accent     lipgloss.AdaptiveColor
-	error      lipgloss.AdaptiveColor
-	success    lipgloss.AdaptiveColor
-	background lipgloss.AdaptiveColor
+// parseSections splits content into claudekit sections and the text around them.
+// Markers that do not pair up are an error, since nothing can then be merged safely.
+func parseSections(content string) ([]sectionPart, error) {
+	var parts []sectionPart
+	var text strings.Builder
+	var open string
+	seen := map[string]bool{}
+	flush := func(name string) {
+		if text.Len() > 0 {
+			parts = append(parts, sectionPart{Name: name, Text: text.String()})
+			text.Reset()
+		}
+	}
 
-	// Markdown-specific theme colors (Feature 006: T010)
-	markdownHeading  lipgloss.AdaptiveColor // Heading color (H1-H6)
-	markdownCode     lipgloss.AdaptiveColor // Code block/inline code
-	markdownEmphasis lipgloss.AdaptiveColor // Italic/bold emphasis
-	markdownLink     lipgloss.AdaptiveColor // Hyperlinks
+	for i, line := range strings.SplitAfter(content, "\n") {
+		if name, ok := sectionMarker(line, "begin"); ok {
+			switch {
+			case open != "":
+				return nil, fmt.Errorf("line %d: section %q begins inside section %q", i+1, name, open)
+			case seen[name]:
+				return nil, fmt.Errorf("line %d: section %q appears twice", i+1, name)
+			}
+			flush("")
+			open, seen[name] = name, true
+		} else if name, ok := sectionMarker(line, "end"); ok {
+			if name != open {
+				return nil, fmt.Errorf("line %d: end of section %q without its begin marker", i+1, name)
+			}
+			text.WriteString(line)
+			flush(open)
+			open = ""
+			continue
+		}
+		text.WriteString(line)
+	}
+	if open != "" {
+		return nil, fmt.Errorf("section %q has no end marker", open)
+	}
+	flush("")
+	return parts, nil
 }
 
-var gradientPalettes = gradient.InitGradientPalettes()
-
-// initGradientPalettes initializes color palettes (T030)
-
-// initStyleMap populates component/state style mappings (T031)
-
-// ============================================================================
-// Feature 005: Asset File Generation Functions
-// ============================================================================
+func hasSections(parts []sectionPart) bool {
+	return slices.ContainsFunc(parts, func(p sectionPart) bool { return p.Name != "" })
+}
 
-// generateAllAssets generates all asset files from the module registry
-func generateAllAssets(registry *ModuleRegistry) error {
-	// Get current directory (repository root)
-	repoRoot, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+// mergeSections replaces the sections in parts with their generated versions and
+// keeps everything else. Sections claudekit no longer writes are dropped; new ones
+// are placed after the generated section that precedes them.
+func mergeSections(parts, generated []sectionPart) string {
+	fresh := map[string]string{}
+	var order []string
+	for _, p := range generated {
+		if p.Name != "" {
+			fresh[p.Name] = p.Text
+			order = append(order, p.Name)
+		}
 	}
 
-	assetsDir := filepath.Join(repoRoot, "assets")
-
-	// Collect all asset paths from all modules
-	var descriptors []generation.AssetFileDescriptor
+	var merged []sectionPart
+	for i := 0; i < len(parts); i++ {
+		p := parts[i]
+		if p.Name == "" {
+			merged = append(merged, p)
+			continue
+		}
+		text, ok := fresh[p.Name]
+		if !ok {
+			// Take the blank line that separated the dropped section along with it
+			if i+1 < len(parts) && parts[i+1].Name == "" && strings.TrimSpace(parts[i+1].Text) == "" {
+				i++
+			}
+			continue
+		}
+		merged = append(merged, sectionPart{Name: p.Name, Text: text})
+	}
 
-	// Process subagents
-	subagents := registry.List(TypeSubagent)
-	for _, module := range subagents {
-		for _, assetPath := range module.AssetPaths {
-			desc := generation.AssetFileDescriptor{
-				Name:           module.Name,
-				Type:           generation.AssetTypeSubagent,
-				Path:           assetPath,
-				SourceTemplate: assetPath,
-				Module:         module,
+	index := func(name string) int {
+		return slices.IndexFunc(merged, func(p sectionPart) bool { return p.Name == name })
+	}
+	for i, name := range order {
+		if index(name) >= 0 {
+			continue
+		}
+		section := sectionPart{Name: name, Text: fresh[name]}
+		blank := sectionPart{Text: "\n"}
+		placed := false
+		for j := i - 1; j >= 0 && !placed; j-- {
+			if at := index(order[j]); at >= 0 {
+				merged = slices.Insert(merged, at+1, blank, section)
+				placed = true
+			}
+		}
+		for j := i + 1; j < len(order) && !placed; j++ {
+			if at := index(order[j]); at >= 0 {
+				merged = slices.Insert(merged, at, section, blank)
+				placed = true
 			}
-			descriptors = append(descriptors, desc)
+		}
+		if !placed {
+			merged = append(merged, blank, section)
 		}
 	}
 
-	// Process hooks
-	hooks := registry.List(TypeHook)
-	for _, module := range hooks {
-		for _, assetPath := range module.AssetPaths {
-			desc := generation.AssetFileDescriptor{
-				Name:           module.Name,
-				Type:           generation.AssetTypeHook,
-				Path:           assetPath,
-				SourceTemplate: assetPath,
-				Module:         module,
-			}
-			descriptors = append(descriptors, desc)
-		}
+	var b strings.Builder
+	for _, p := range merged {
+		b.WriteString(p.Text)
 	}
+	return b.String()
+}
 
-	// Process slash commands
-	commands := registry.List(TypeCommand)
-	for _, module := range commands {
-		for _, assetPath := range module.AssetPaths {
-			desc := generation.AssetFileDescriptor{
-				Name:           module.Name,
-				Type:           generation.AssetTypeSlashCommand,
-				Path:           assetPath,
-				SourceTemplate: assetPath,
-				Module:         module,
+// migrateClaudeMD splits a CLAUDE.md without markers, written by claudekit before
+// sections existed, along the headings claudekit wrote. Those blocks become sections
+// to replace; anything under the user's own headings is kept.
+func migrateClaudeMD(content string) []sectionPart {
+	var parts []sectionPart
+	current := sectionPart{}
+	closePart := func() {
+		if current.Name != "" {
+			// Blank lines after a block separate it from the next one and are not its own
+			body := strings.TrimRight(current.Text, "\n")
+			if trailing := current.Text[len(body):]; len(trailing) > 1 {
+				parts = append(parts, sectionPart{Name: current.Name, Text: body + "\n"}, sectionPart{Text: trailing[1:]})
+				current = sectionPart{}
+				return
 			}
-			descriptors = append(descriptors, desc)
 		}
+		if current.Text != "" {
+			parts = append(parts, current)
+		}
+		current = sectionPart{}
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "# ") && strings.HasSuffix(trimmed, "— Engineering Ground Rules"):
+			closePart()
+			current.Name = "title"
+		case strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "## "):
+			closePart()
+			current.Name = legacyClaudeMDHeadings[trimmed]
+		case strings.HasPrefix(line, "> Initialized by claudekit"):
+			closePart()
+			parts = append(parts, sectionPart{Name: "footer", Text: line})
+			continue
+		}
+		current.Text += line
 	}
+	closePart()
+	return parts
+}
 
-	// Check for existing files
-	warning := generation.CheckExistingFiles(descriptors, assetsDir)
-	if len(warning.ExistingFiles) > 0 {
-		fmt.Printf("\n⚠️  WARNING: The following files will be overwritten:\n")
-		for _, file := range warning.ExistingFiles {
-			fmt.Printf("  - %s\n", file)
-		}
-		fmt.Printf("\nAny customizations will be lost.\n\n")
-		fmt.Printf("Continue? (y/n): ")
+// updateClaudeMD returns the CLAUDE.md to write over existing. merged reports that
+// the result carries the user's content alongside claudekit's sections. Without
+// markers in the generated file, from a template override that has none, the file
+// is claudekit's alone; an existing file without markers is migrated, and one with
+// damaged markers is an error.
+func updateClaudeMD(existing, generated string) (content string, merged bool, err error) {
+	fresh, err := parseSections(generated)
+	if err != nil || !hasSections(fresh) {
+		return generated, false, nil
+	}
+	if strings.TrimSpace(existing) == "" {
+		return generated, true, nil
+	}
+	parts, err := parseSections(existing)
+	if err != nil {
+		return "", false, err
+	}
+	if !hasSections(parts) {
+		return mergeSections(migrateClaudeMD(existing), fresh), false, nil
+	}
+	return mergeSections(parts, fresh), true, nil
+}
 
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("\nℹ️  Generation cancelled. No files were modified.")
-			return nil
+// stripClaudeMDSections removes claudekit's sections from CLAUDE.md, deleting the
+// file when nothing of the user's remains. A file without markers predates sections
+// and is removed whole. Reports whether the file changed and whether it was deleted.
+func stripClaudeMDSections(files fsys.FS, path string, dryRun bool) (changed, empty bool, err error) {
+	return stripSections(files, path, true, dryRun)
+}
+
+// unmarkedClaudeMDEdited reports whether the CLAUDE.md of entry has no markers and
+// is no longer what was generated: either it was written with markers that have
+// since been taken out, or its content differs from the recorded hash.
+func unmarkedClaudeMDEdited(files fsys.FS, entry manifest.Entry, baseDir string) (bool, error) {
+	data, err := files.ReadFile(entry.AbsPath(baseDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
 		}
+		return false, err
 	}
+	parts, err := parseSections(string(data))
+	if err != nil || hasSections(parts) {
+		return false, nil // Damaged markers are reported by stripClaudeMDSections
+	}
+	if entry.SHA256 == "" {
+		return true, nil // Merged files are written with markers
+	}
+	return manifest.HashContent(data) != entry.SHA256, nil
+}
 
-	// Generate all files
-	fmt.Printf("\nGenerating asset files...\n")
-	report := generation.GenerateAssetFiles(descriptors, assetsDir)
+// stripReadmeSection removes claudekit's section from README.md like
+// stripClaudeMDSections, except that a README without markers is never claudekit's
+// and is left as it is.
+func stripReadmeSection(files fsys.FS, path string, dryRun bool) (changed, empty bool, err error) {
+	return stripSections(files, path, false, dryRun)
+}
 
-	// Display results
-	for _, result := range report.Results {
-		status := "✅"
-		if result.Status == generation.StatusFailed {
-			status = "❌"
-		} else if result.Status == generation.StatusPlaceholderGenerated {
-			status = "ℹ️ "
+// stripSections removes claudekit's sections from path, deleting the file when
+// nothing else remains, and the whole file when it has no markers and unmarkedIsOurs.
+func stripSections(files fsys.FS, path string, unmarkedIsOurs, dryRun bool) (changed, empty bool, err error) {
+	data, err := files.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, false, nil
 		}
-		relPath, _ := filepath.Rel(repoRoot, result.FilePath)
-		if result.Error != nil {
-			fmt.Printf("%s %s - %v\n", status, relPath, result.Error)
-		} else {
-			fmt.Printf("%s %s\n", status, relPath)
+		return false, false, err
+	}
+	parts, err := parseSections(string(data))
+	if err != nil {
+		return false, false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if !hasSections(parts) && !unmarkedIsOurs {
+		return false, false, nil
+	}
+
+	var b strings.Builder
+	for _, p := range parts {
+		if p.Name == "" {
+			b.WriteString(p.Text)
+		}
+	}
+	remaining := strings.Trim(regexp.MustCompile(`\n{3,}`).ReplaceAllString(b.String(), "\n\n"), "\n") + "\n"
+	if !hasSections(parts) || strings.TrimSpace(remaining) == "" {
+		if !dryRun {
+			if err := files.Remove(path); err != nil {
+				return false, false, err
+			}
 		}
+		return true, true, nil
 	}
+	if !dryRun {
+		if err := files.WriteFile(path, []byte(remaining), 0o644); err != nil {
+			return false, false, err
+		}
+	}
+	return true, false, nil
+}
 
-	// Summary
-	fmt.Printf("\n")
-	if report.Failed > 0 {
-		fmt.Printf("⚠️  %d files failed to generate. %d files succeeded.\n", report.Failed, report.Successful+report.PlaceholdersGenerated)
+// setupDocEntry is one row of docs/CLAUDE-SETUP.md.
+type setupDocEntry struct {
+	Name    string
+	Summary string
+	Event   string // Hooks only: the lifecycle event that triggers the script
+	Script  string // Hooks only: script path relative to the project
+}
 
-		if report.ShouldPromptRetry() {
-			fmt.Printf("\nRetry failed files? (y/n): ")
-			var response string
-			fmt.Scanln(&response)
-			if response == "y" || response == "Y" {
-				fmt.Printf("\nRetrying failed files...\n")
-				retryReport := generation.RetryFailedGeneration(&report, assetsDir)
+// setupDocData is what CLAUDE-SETUP.md.tmpl renders.
+type setupDocData struct {
+	ProjectName string
+	AgentsDir   string
+	HooksDir    string
+	CommandsDir string
+	Agents      []setupDocEntry
+	Commands    []setupDocEntry
+	Hooks       []setupDocEntry
+	MCPServers  []setupDocEntry
+	Permissions []setupDocEntry
+	OutputStyle *setupDocEntry
+	Date        string
+	Section     bool // Rendering the README.md section rather than a page of its own
+}
 
-				for _, result := range retryReport.Results {
-					status := "✅"
-					if result.Status == generation.StatusFailed {
-						status = "❌"
-					}
-					relPath, _ := filepath.Rel(repoRoot, result.FilePath)
-					if result.Error != nil {
-						fmt.Printf("%s %s - %v\n", status, relPath, result.Error)
-					} else {
-						fmt.Printf("%s %s\n", status, relPath)
-					}
-				}
+// renderSetupDoc renders docs/CLAUDE-SETUP.md, which documents the installed agents,
+// commands, hooks, and MCP servers for human teammates.
+func renderSetupDoc(cfg Config, registry *ModuleRegistry) string {
+	return renderTemplate(registry, setupDocTemplate, "assets/templates/CLAUDE-SETUP.md.tmpl", newSetupDocData(cfg, registry))
+}
 
-				if retryReport.Failed == 0 {
-					fmt.Printf("\n✅ All files generated successfully.\n")
-				} else {
-					fmt.Printf("\n⚠️  %d files still failed.\n", retryReport.Failed)
-				}
-			}
-		}
-	} else {
-		fmt.Printf("✅ Generated %d asset files successfully.\n", report.Successful+report.PlaceholdersGenerated)
-		if report.PlaceholdersGenerated > 0 {
-			fmt.Printf("ℹ️  %d placeholder files were generated. Please review and customize files marked with TODO.\n", report.PlaceholdersGenerated)
+// setupReadmeSection is the name of claudekit's section in README.md.
+const setupReadmeSection = "claude-setup"
+
+// renderSetupReadmeSection renders the setup doc as a marked section for the end of
+// README.md, its headings one level down so they sit under the README's title.
+func renderSetupReadmeSection(cfg Config, registry *ModuleRegistry) string {
+	data := newSetupDocData(cfg, registry)
+	data.Section = true
+	var b strings.Builder
+	b.WriteString(sectionMarkerPrefix + "begin " + setupReadmeSection + sectionMarkerSuffix + "\n")
+	for line := range strings.Lines(renderTemplate(registry, setupDocTemplate, "assets/templates/CLAUDE-SETUP.md.tmpl", data)) {
+		if strings.HasPrefix(line, "#") {
+			b.WriteString("#")
 		}
+		b.WriteString(line)
+	}
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
 	}
+	b.WriteString(sectionMarkerPrefix + "end " + setupReadmeSection + sectionMarkerSuffix + "\n")
+	return b.String()
+}
 
-	return nil
+// newSetupDocData collects what the setup doc lists from cfg's selections.
+func newSetupDocData(cfg Config, registry *ModuleRegistry) setupDocData {
+	layout := cfg.Layout.WithDefaults()
+	data := setupDocData{
+		ProjectName: cfg.ProjectName,
+		AgentsDir:   layout.Agents,
+		HooksDir:    layout.Hooks,
+		CommandsDir: layout.Commands,
+		Agents:      setupDocEntries(TypeSubagent, cfg.Subagents, registry),
+		Commands:    setupDocEntries(TypeCommand, cfg.SlashCommands, registry),
+		MCPServers:  setupDocEntries(TypeMCP, cfg.MCPServers, registry),
+		Permissions: setupDocEntries(TypePermissions, cfg.Permissions, registry),
+		Date:        time.Now().Format("2006-01-02"),
+	}
+	for _, entry := range setupDocEntries(TypeHook, cfg.Hooks, registry) {
+		module := registry.Get(TypeHook, entry.Name)
+		entry.Event = strings.Join(hookEvents(module), ", ")
+		if lang, err := resolveHookLanguage(entry.Name, module, cfg.HookLanguages); err == nil {
+			entry.Script = path.Join(layout.Hooks, hookScriptName(entry.Name, lang))
+		}
+		data.Hooks = append(data.Hooks, entry)
+	}
+	if styles := setupDocEntries(TypeStyle, []string{cfg.OutputStyle}, registry); len(styles) > 0 {
+		data.OutputStyle = &styles[0]
+	}
+	return data
 }
 
-func main() {
-	// Initialize module registry (Feature 004)
-	registry := &ModuleRegistry{}
-	registryErrs := registry.Load(assets)
-	if len(registryErrs) > 0 {
-		fmt.Fprintf(os.Stderr, "warning: module registry errors: %d issues\n", len(registryErrs))
-		for _, regErr := range registryErrs {
-			fmt.Fprintf(os.Stderr, "  - %v\n", regErr)
+// setupDocEntries looks up the selected modules of one type, skipping unknown names.
+func setupDocEntries(componentType ModuleComponentType, selected []string, registry *ModuleRegistry) []setupDocEntry {
+	var entries []setupDocEntry
+	for _, display := range selected {
+		name := cleanFormValue(display)
+		if module := registry.Get(componentType, name); module != nil {
+			entries = append(entries, setupDocEntry{Name: name, Summary: moduleSummary(module)})
 		}
 	}
+	return entries
+}
 
-	// Feature 005: Check for --generate-assets flag
-	if len(os.Args) > 1 && os.Args[1] == "--generate-assets" {
-		if err := generateAllAssets(registry); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+// moduleSummary returns a one-line summary of a module: the bold lead sentence of its
+// description, or its first line of prose when there is none.
+func moduleSummary(module *ComponentModule) string {
+	for _, line := range strings.Split(module.GetDescription(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-		return
+		if rest, ok := strings.CutPrefix(line, "**"); ok {
+			if lead, _, found := strings.Cut(rest, "**"); found {
+				line = lead
+			}
+		}
+		line = strings.TrimSuffix(line, ".")
+		return strings.ReplaceAll(line, "|", "\\|") // Keep markdown table cells intact
 	}
+	return module.Name
+}
 
-	// Get current directory name for project name default
-	currentDir, err := os.Getwd()
-	dirName := "awesome-app" // default fallback
-	if err == nil {
-		baseName := filepath.Base(currentDir)
-		if baseName != "." && baseName != "/" && baseName != "" {
-			dirName = baseName
+// subagentOptions lists subagent modules after the registry loads, followed by the
+// user's custom subagents from earlier runs.
+func subagentOptions(loader *registryLoader, custom []generation.CustomSubagent, includeDisabled *bool) func() []huh.Option[string] {
+	return func() []huh.Option[string] {
+		registry, _ := loader.Wait()
+		options := registry.GetOptions(TypeSubagent, *includeDisabled)
+		for _, agent := range custom {
+			if registry.Get(TypeSubagent, agent.Name) == nil {
+				options = append(options, huh.NewOption("✏️ "+agent.Name+" (custom)", agent.Name))
+			}
 		}
+		return options
 	}
+}
 
-	// Load previous choices from persistence file
-	persistedConfig, err := loadPersistenceConfig()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to load previous choices: %v\n", err)
-		persistedConfig = &PersistenceConfig{}
+// validateCustomSubagentName rejects malformed names and names already taken by a
+// subagent module.
+func validateCustomSubagentName(name string, loader *registryLoader) error {
+	if err := generation.ValidateSubagentName(name); err != nil {
+		return err
 	}
+	if registry, _ := loader.Wait(); registry.Get(TypeSubagent, name) != nil {
+		return fmt.Errorf("%s is a built-in subagent; choose another name", name)
+	}
+	return nil
+}
 
-	// Initialize config with defaults, then override with persisted values
-	cfg := Config{
-		IsProjectLocal: true,  // Default to project-specific
-		ProjectName:    dirName, // Set directory name as default
-		Languages:      []string{"Go"},
-		Subagents:      []string{"code-reviewer", "test-runner", "bug-sleuth"},
-		Hooks:          []string{"session-start", "pre-tool-use", "post-tool-use"},
-		SlashCommands:  []string{"example", "fix-github-issue"},
-		MCPServers:     []string{"notion", "linear", "sentry", "github"},
+// addCustomSubagent adds agent to the list, replacing an earlier one with the same name.
+func addCustomSubagent(list []generation.CustomSubagent, agent generation.CustomSubagent) []generation.CustomSubagent {
+	agent.Description = strings.TrimSpace(agent.Description)
+	list = slices.DeleteFunc(slices.Clone(list), func(c generation.CustomSubagent) bool { return c.Name == agent.Name })
+	return append(list, agent)
+}
+
+// agentTemplateData is what an agents/<name>.md.tmpl override renders.
+type agentTemplateData struct {
+	Name        string
+	Description string // One-line summary of the subagent module, "" for custom agents
+}
+
+// renderAgent returns the agent file for a subagent module, with the model it runs
+// on and the tools it may use under cfg set in the frontmatter.
+func renderAgent(name string, cfg Config, registry *ModuleRegistry) string {
+	data := agentTemplateData{Name: name}
+	module := registry.Get(TypeSubagent, name)
+	if module != nil {
+		data.Description = moduleSummary(module)
 	}
-	
-	// Override with persisted choices if they exist
-	if len(persistedConfig.Languages) > 0 {
-		cfg.Languages = persistedConfig.Languages
+	content, ok := registry.renderTemplateOverride("agents/"+name+".md.tmpl", data)
+	if !ok {
+		asset, err := assets.ReadFile("assets/agents/" + name + ".md")
+		if module != nil && module.assets != nil && len(module.AssetPaths) > 0 {
+			asset, err = cfg.readModuleAsset(module, module.AssetPaths[0])
+		}
+		if err != nil {
+			return `---
+name: ` + name + `
+description: Custom subagent
+---
+Provide a focused role and steps.`
+		}
+		content = string(asset)
 	}
-	if len(persistedConfig.Subagents) > 0 {
-		cfg.Subagents = persistedConfig.Subagents
+	if _, ok := cfg.AgentTools[name]; ok || module != nil && len(subagentTools(module)) > 0 {
+		content = setFrontmatterField(content, "tools", strings.Join(agentTools(cfg, name, registry), ", "))
 	}
-	if len(persistedConfig.Hooks) > 0 {
-		cfg.Hooks = persistedConfig.Hooks
+	if model := agentModel(cfg, name, registry); model != "" {
+		content = setFrontmatterField(content, "model", model)
 	}
-	if len(persistedConfig.SlashCommands) > 0 {
-		cfg.SlashCommands = persistedConfig.SlashCommands
+	return content
+}
+
+// subagentModel returns the model a subagent module declares, or "" when it leaves
+// the choice to its agent file.
+func subagentModel(module *ComponentModule) string {
+	model, _ := module.Defaults["model"].(string)
+	return model
+}
+
+// subagentTools returns the tools a subagent module restricts its agent to, or nil
+// when it leaves them to its agent file.
+func subagentTools(module *ComponentModule) []string {
+	var tools []string
+	list, _ := module.Defaults["tools"].([]any)
+	for _, item := range list {
+		if tool, ok := item.(string); ok && tool != "" {
+			tools = append(tools, tool)
+		}
 	}
-	if len(persistedConfig.MCPServers) > 0 {
-		cfg.MCPServers = persistedConfig.MCPServers
+	return tools
+}
+
+// agentTools returns the tools a selected subagent may use: its row on the agent
+// tools page when that was changed, else its default tools. Empty means every tool.
+func agentTools(cfg Config, name string, registry *ModuleRegistry) []string {
+	if tools, ok := cfg.AgentTools[name]; ok {
+		return tools
 	}
-	if persistedConfig.ClaudeMDExtras != "" {
-		cfg.ClaudeMDExtras = persistedConfig.ClaudeMDExtras
+	return defaultAgentTools(cfg, name, registry)
+}
+
+// defaultAgentTools returns the tools a subagent's module declares, or for a custom
+// subagent the tools it was created with.
+func defaultAgentTools(cfg Config, name string, registry *ModuleRegistry) []string {
+	if module := registry.Get(TypeSubagent, name); module != nil {
+		return subagentTools(module)
 	}
-	// Always use persisted boolean and project name if available
-	if persistedConfig.ProjectName != "" {
-		cfg.IsProjectLocal = persistedConfig.IsProjectLocal
-		// Only override project name if it's not the current directory default
-		if persistedConfig.ProjectName != dirName {
-			cfg.ProjectName = persistedConfig.ProjectName
-		}
+	if i := slices.IndexFunc(cfg.CustomSubagents, func(c generation.CustomSubagent) bool { return c.Name == name }); i >= 0 {
+		return cfg.CustomSubagents[i].Tools
 	}
+	return nil
+}
 
-	form := huh.NewForm(
-		// Page 1: Project Setup
-		huh.NewGroup(
-			huh.NewNote().Title("📁 Project Setup").Description("Configure your project basics and language support"),
-			huh.NewInput().
-				Title("Project name").
-				Description("Used in generated documentation and configurations").
-				Value(&cfg.ProjectName),
-			huh.NewConfirm().
-				Title("Project-specific configuration?").
-				Description("Yes = Configure for this project only\nNo = Global configuration in your home directory").
-				Value(&cfg.IsProjectLocal),
-			huh.NewMultiSelect[string]().
-				Key("languages").
-				Title("Primary languages").
-				Description("Select all languages used in your project for optimized defaults").
-				Options(huh.NewOptions(
-					"Go", "TypeScript", "Python", "Java", "Rust", "C++", "C#", 
-					"PHP", "Ruby", "Swift", "Kotlin", "Dart", "Shell", "Lua",
-					"Elixir", "Haskell", "Elm", "Julia", "SQL", "Arduino", 
-					"Scheme", "Lisp")...).
-				Height(8).
-				Value(&cfg.Languages),
-		),
-		
-		// Page 2: Subagent Selection
-		huh.NewGroup(
-			huh.NewNote().Title("🤖 Subagent Configuration").Description("Choose specialized AI assistants for your development workflow"),
-			huh.NewMultiSelect[string]().
-				Key("subagents").
-				Title("Select subagents to include").
-				Description("Choose the AI specialists you want available for your project").
-				Options(registry.GetOptions(TypeSubagent)...).
-				Value(&cfg.Subagents),
-		),
-		
-		// Page 3: Hook Configuration
-		huh.NewGroup(
-			huh.NewNote().Title("🪝 Hook Setup").Description("Configure automation and lifecycle scripts"),
-			huh.NewMultiSelect[string]().
-				Key("hooks").
-				Title("Select hooks to enable").
-				Description("Automation scripts that run at specific points in your workflow").
-				Options(registry.GetOptions(TypeHook)...).
-				Value(&cfg.Hooks),
-		),
-		
-		// Page 4: Slash Commands
-		huh.NewGroup(
-			huh.NewNote().Title("⚡ Custom Commands").Description("Add powerful slash commands for common development tasks"),
-			huh.NewMultiSelect[string]().
-				Key("slash-commands").
-				Title("Select custom slash commands").
-				Description("Choose useful commands for common development tasks").
-				Options(registry.GetOptions(TypeCommand)...).
-				Value(&cfg.SlashCommands),
-		),
-		
-		// Page 5: MCP Configuration
-		huh.NewGroup(
-			huh.NewNote().Title("🔌 MCP Integration").Description("Connect to external tools and services via Model Context Protocol"),
-			huh.NewMultiSelect[string]().
-				Key("mcp-servers").
-				Title("Select MCP servers to include").
-				Description("Choose external tool integrations to enhance Claude's capabilities (optional)").
-				Options(registry.GetOptions(TypeMCP)...).
-				Value(&cfg.MCPServers),
-		),
-		
-		// Page 6: Final Configuration  
-		huh.NewGroup(
-			huh.NewNote().Title("📝 Final Setup").Description("Add custom instructions and complete your configuration"),
-			huh.NewText().
-				Title("Extra CLAUDE.md content (optional)").
-				Description("Project-specific instructions to include in CLAUDE.md").
-				Value(&cfg.ClaudeMDExtras),
-		),
-		
-		// Page 7: Confirmation
-		huh.NewGroup(
-			huh.NewNote().Title("✅ Confirmation").Description("Review your configuration and confirm to generate Claude Code setup"),
-			huh.NewConfirm().
-				Title("Generate Claude Code configuration?").
-				Description("This will create/update the Claude Code configuration files with your selections.\nReview the configuration summary in the right panel.").
-				Affirmative("Yes, generate configuration").
-				Negative("No, go back to make changes").
-				Value(&cfg.Confirmed),
-		),
-	)
+// agentModel returns the model a subagent runs on: the one set on the agent models
+// page when it is listed there, else its module's.
+func agentModel(cfg Config, name string, registry *ModuleRegistry) string {
+	if model, ok := parseAgentModelLines(cfg.AgentModels)[name]; ok {
+		return model
+	}
+	if module := registry.Get(TypeSubagent, name); module != nil {
+		return subagentModel(module)
+	}
+	return ""
+}
 
-	// Create Bubble Tea model with form (T029: initialize gradient system)
-	termCap := gradient.DetectTerminalCapability()
-	styleMap := gradient.InitStyleMap()
-	primaryTheme := styleMap[gradient.HeaderComponent][gradient.NormalState].Theme
+// parseAgentModelLines reads the agent models page: "agent: model" per line, with
+// blank lines and # comments skipped.
+func parseAgentModelLines(text string) map[string]string {
+	models := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, model, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) != "" {
+			models[strings.TrimSpace(name)] = strings.TrimSpace(model)
+		}
+	}
+	return models
+}
 
-	// Extend color palette with markdown colors (Feature 006: T013)
-	palette := gradientPalettes
-	gradient.ExtendColorPaletteForMarkdown(&palette)
+// validateAgentModelLines checks the agent models page: each line names a subagent
+// once, then one of the models Claude Code accepts.
+func validateAgentModelLines(text string) error {
+	seen := map[string]bool{}
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, model, ok := strings.Cut(line, ":")
+		name, model = strings.TrimSpace(name), strings.TrimSpace(model)
+		switch {
+		case !ok || name == "" || strings.Contains(name, " "):
+			return fmt.Errorf("line %d: %q is not agent: model", i+1, line)
+		case seen[name]:
+			return fmt.Errorf("line %d: %s is listed twice", i+1, name)
+		case !slices.Contains(schema.SubagentModels, model):
+			return fmt.Errorf("line %d: model %q is not one of %s", i+1, model, strings.Join(schema.SubagentModels, ", "))
+		}
+		seen[name] = true
+	}
+	return nil
+}
 
-	// Create custom glamour renderer from palette (Feature 006: T013)
-	renderer := gradient.GenerateGlamourStyle(palette)
-	// renderer is nil-checked by existing code (will fallback to plain text)
+// agentModelsHidden hides the agent models and tools pages until a subagent is selected.
+func agentModelsHidden(cfg *Config) func() bool {
+	return func() bool { return len(cfg.Subagents) == 0 }
+}
 
-	m := model{
-		form:            form,
-		config:          &cfg,
-		glamourRenderer: renderer,
+// agentModelUsage lists the selected subagents with the model each module picks,
+// for the agent models page.
+func agentModelUsage(cfg *Config, loader *registryLoader) func() string {
+	return func() string {
+		registry, _ := loader.Wait()
+		var b strings.Builder
+		for _, display := range cfg.Subagents {
+			name := cleanFormValue(display)
+			model := "inherit (the conversation's model)"
+			if module := registry.Get(TypeSubagent, name); module != nil && subagentModel(module) != "" {
+				model = subagentModel(module)
+			} else if i := slices.IndexFunc(cfg.CustomSubagents, func(c generation.CustomSubagent) bool { return c.Name == name }); i >= 0 && cfg.CustomSubagents[i].Model != "" {
+				model = cfg.CustomSubagents[i].Model
+			}
+			fmt.Fprintf(&b, "%s: %s\n", name, model)
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+}
 
-		// Gradient system initialization
-		terminalCap:  termCap,
-		currentTheme: primaryTheme,
-		transition: gradient.TransitionState{
-			Active:     false,
-			EasingFunc: gradient.EaseInOutCubic,
-		},
-		styleMap: styleMap,
+// setFrontmatterField sets key to value in a markdown file's YAML frontmatter,
+// replacing the key's line or adding one before the closing ---; an empty value
+// removes the line. Content without frontmatter is returned as it is.
+func setFrontmatterField(content, key, value string) string {
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return content
+	}
+	lines := strings.SplitAfter(rest, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.TrimRight(line, " \t\r\n") == "---":
+			if value != "" {
+				lines = slices.Insert(lines, i, key+": "+value+"\n")
+			}
+		case strings.HasPrefix(line, key+":") && value == "":
+			lines = slices.Delete(lines, i, i+1)
+		case strings.HasPrefix(line, key+":"):
+			lines[i] = key + ": " + value + "\n"
+		default:
+			continue
+		}
+		return "---\n" + strings.Join(lines, "")
+	}
+	return content
+}
 
-		// Module registry (Feature 004)
-		registry: registry,
+// renderOutputStyle returns the output style file for a custom style module, read as
+// cfg renames its asset. Built-in styles have no asset and report false.
+func renderOutputStyle(module *ComponentModule, cfg Config) (string, bool) {
+	if builtin, _ := module.Defaults["builtin"].(bool); builtin || len(module.AssetPaths) == 0 {
+		return "", false
+	}
+	content, err := cfg.readModuleAsset(module, module.AssetPaths[0])
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
 
-		// Adaptive right panel layout (Feature 007)
-		// showRightPanel will be computed on first WindowSizeMsg
-		showRightPanel:  true, // Default to showing panel (will be adjusted on first resize)
-		resizeDebouncer: nil,
-		pendingResize:   nil,
+// styleOptions lists output styles after the registry loads, with the Claude Code
+// default first so no style is forced on the user.
+func styleOptions(loader *registryLoader, includeDisabled *bool) func() []huh.Option[string] {
+	return func() []huh.Option[string] {
+		registry, _ := loader.Wait()
+		options := []huh.Option[string]{huh.NewOption("Default (no output style)", "")}
+		return append(options, registry.GetOptions(TypeStyle, *includeDisabled)...)
 	}
+}
 
-	// Run the Bubble Tea application
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	finalModel, err := p.Run()
+// renderStatusline returns the script for a statusline module, read as cfg renames
+// its asset.
+func renderStatusline(module *ComponentModule, cfg Config) (string, bool) {
+	if len(module.AssetPaths) == 0 {
+		return "", false
+	}
+	content, err := cfg.readModuleAsset(module, module.AssetPaths[0])
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error running application: %v\n", err)
-		os.Exit(1)
+		return "", false
 	}
+	return string(content), true
+}
 
-	// Check if user cancelled
-	if finalModel, ok := finalModel.(model); ok {
-		if finalModel.form.State != huh.StateCompleted {
-			fmt.Fprintf(os.Stderr, "cancelled\n")
-			os.Exit(1)
-		}
+// statuslineCommand is the statusLine command for the generated script. Project
+// settings are shared, so they reference the script through $CLAUDE_PROJECT_DIR;
+// global settings use the script's absolute path.
+func statuslineCommand(baseDir string, projectLocal bool) string {
+	if projectLocal {
+		return "$CLAUDE_PROJECT_DIR/.claude/statusline.sh"
 	}
+	return quoteHookArg(filepath.ToSlash(filepath.Join(baseDir, ".claude", "statusline.sh")))
+}
 
-	// Clean up emoji prefixes from form selections
-	cfg.Subagents = cleanFormValues(cfg.Subagents)
-	cfg.Hooks = cleanFormValues(cfg.Hooks)
-	cfg.MCPServers = cleanFormValues(cfg.MCPServers)
-	
-	// Save current choices for future runs
-	if err := savePersistenceConfig(cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to save choices for future runs: %v\n", err)
-		// Continue execution - this is not a fatal error
+// statuslineOptions lists statusline presets after the registry loads, with the
+// Claude Code default first.
+func statuslineOptions(loader *registryLoader, includeDisabled *bool) func() []huh.Option[string] {
+	return func() []huh.Option[string] {
+		registry, _ := loader.Wait()
+		options := []huh.Option[string]{huh.NewOption("Default (no statusline)", "")}
+		return append(options, registry.GetOptions(TypeStatusline, *includeDisabled)...)
 	}
-	
-	// Clean up deselected items before generating new configuration
-	var targetDir string
-	if cfg.IsProjectLocal {
-		targetDir, _ = os.Getwd()
-	} else {
-		homeDir, _ := os.UserHomeDir()
-		targetDir = filepath.Join(homeDir, ".claude")
+}
+
+func postWriteLintScript(langs []string, registry *ModuleRegistry) string {
+	return renderTemplate(registry, postWriteLintTemplate, "assets/hooks/postwrite-lint.sh.tmpl", newLanguageFlags(langs))
+}
+
+// generateHookScript returns a starter hook script in lang. Bash scripts omit the
+// shebang because executableContent adds it along with strict mode.
+func generateHookScript(hookName, description string, lang hookLanguage) string {
+	switch lang {
+	case hookLangPython:
+		// Generate Python script; lib/hook.py parses the event and prints decisions
+		return fmt.Sprintf(`#!/usr/bin/env python3
+"""
+%s Hook - %s
+
+Claude Code passes the event as JSON on stdin, and lib/hook.py parses it. Every
+event has session_id, transcript_path, cwd, and hook_event_name; tool events add
+tool_name and tool_input, and PostToolUse adds tool_response.
+"""
+
+import os
+import sys
+
+sys.path.insert(0, os.path.join(os.path.dirname(os.path.abspath(__file__)), "lib"))
+import hook  # noqa: E402
+
+
+def main():
+    event = hook.read_input()
+    hook.log(f"%s hook triggered (session {event.session_id})")
+%s
+    # Return 0 to continue; hook.block() prints a decision and exits
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
+`, hookName, description, hookName, hookDecisionExample(hookName, lang))
+	case hookLangNode:
+		// Generate Node.js script; Claude Code passes the event as JSON on stdin
+		return fmt.Sprintf(`#!/usr/bin/env node
+/**
+ * %s Hook - %s
+ *
+ * This hook is called by Claude Code during specific events.
+ * You can customize this script to add logging, validation, or other actions.
+ *
+ * The event arrives as JSON on stdin. CLAUDE_PROJECT_DIR holds the project directory.
+ */
+
+let input = "";
+process.stdin.on("data", (chunk) => (input += chunk));
+process.stdin.on("end", () => {
+  const event = input.trim() ? JSON.parse(input) : {};
+  console.log("[" + new Date().toISOString() + "] %s hook triggered");
+
+  // Add your custom logic here
+  // Example: inspect event.tool_name, log to a file, send notifications, etc.
+  void event;
+
+  // Exit 0 for success; exit 2 blocks the action and shows stderr to Claude
+  process.exit(0);
+});
+`, hookName, description, hookName)
+	case hookLangPowerShell:
+		// Generate PowerShell script; Claude Code passes the event as JSON on stdin
+		return fmt.Sprintf(`<#
+%s Hook - %s
+
+This hook is called by Claude Code during specific events.
+You can customize this script to add logging, validation, or other actions.
+
+The event arrives as JSON on stdin. CLAUDE_PROJECT_DIR holds the project directory.
+#>
+
+$ErrorActionPreference = "Stop"
+
+$raw = [Console]::In.ReadToEnd()
+$hookEvent = if ($raw.Trim()) { $raw | ConvertFrom-Json } else { $null }
+Write-Output "[$(Get-Date -Format o)] %s hook triggered"
+
+# Add your custom logic here
+# Example: inspect $hookEvent.tool_name, log to a file, send notifications, etc.
+
+# Exit 0 for success; exit 2 blocks the action and shows stderr to Claude
+exit 0
+`, hookName, description, hookName)
+	default:
+		// Generate bash script; lib/hook.sh parses the event and prints decisions
+		return fmt.Sprintf(`# %s Hook - %s
+#
+# Claude Code passes the event as JSON on stdin, and lib/hook.sh parses it. Every
+# event has session_id, transcript_path, cwd, and hook_event_name; tool events add
+# tool_name and tool_input, and PostToolUse adds tool_response.
+
+source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+hook_read_input
+
+hook_log "%s hook triggered (session $HOOK_SESSION_ID)"
+%s
+# Exit 0 to continue; hook_block prints a decision and exits
+exit 0`, hookName, description, hookName, hookDecisionExample(hookName, lang))
+	}
+}
+
+// hookDecisionExamples show, per hook and language, how a generated script reads the
+// event and controls what Claude does next.
+var hookDecisionExamples = map[string]map[hookLanguage]string{
+	"pre-tool-use": {
+		hookLangBash: `
+# Decision control: refuse a tool call and tell Claude why
+if [[ "$HOOK_TOOL_NAME" == "Bash" && "$(hook_get tool_input.command)" == *"rm -rf /"* ]]; then
+  hook_block "Refusing to delete from the filesystem root"
+fi
+`,
+		hookLangPython: `
+    # Decision control: refuse a tool call and tell Claude why
+    if event.tool_name == "Bash" and "rm -rf /" in event.tool_input.get("command", ""):
+        hook.block("Refusing to delete from the filesystem root")
+`,
+	},
+	"post-tool-use": {
+		hookLangBash: `
+# Decision control: report a problem with the tool's result to Claude
+# if [[ "$HOOK_TOOL_NAME" == "Write" ]] && ! ./scripts/check "$(hook_get tool_input.file_path)"; then
+#   hook_block "The written file failed ./scripts/check"
+# fi
+`,
+		hookLangPython: `
+    # Decision control: report a problem with the tool's result to Claude
+    # if event.tool_name == "Write" and not check(event.tool_input.get("file_path", "")):
+    #     hook.block("The written file failed validation")
+`,
+	},
+	"user-prompt-submit": {
+		hookLangBash: `
+# Decision control: reject a prompt, or add context for Claude
+# [[ "$(hook_get prompt)" == *"password"* ]] && hook_block "Prompts must not contain credentials"
+# hook_context "Current branch: $(git branch --show-current)"
+`,
+		hookLangPython: `
+    # Decision control: reject a prompt, or add context for Claude
+    # if "password" in event.get("prompt", ""):
+    #     hook.block("Prompts must not contain credentials")
+    # hook.add_context(event, "Remember to run the tests")
+`,
+	},
+	"stop": {
+		hookLangBash: `
+# Decision control: keep Claude working; stop_hook_active is true when a Stop hook
+# already kept it going, so check it to avoid a loop
+# if [[ "$(hook_get stop_hook_active)" != "true" ]] && ! make test >/dev/null 2>&1; then
+#   hook_block "Tests are failing; fix them before finishing"
+# fi
+`,
+		hookLangPython: `
+    # Decision control: keep Claude working; stop_hook_active is true when a Stop hook
+    # already kept it going, so check it to avoid a loop
+    # if not event.get("stop_hook_active") and not tests_pass():
+    #     hook.block("Tests are failing; fix them before finishing")
+`,
+	},
+}
+
+// hookDecisionExample returns the decision control example for a hook, or a blank
+// line for hooks whose output Claude Code does not act on.
+func hookDecisionExample(hookName string, lang hookLanguage) string {
+	if hookName == "subagent-stop" {
+		hookName = "stop" // Same decision control as Stop
 	}
-	if targetDir != "" {
-		if err := cleanupDeselectedItems(cfg, persistedConfig, targetDir); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to clean up deselected items: %v\n", err)
+	if example, ok := hookDecisionExamples[hookName][lang]; ok {
+		return example
+	}
+	return "\n"
+}
+
+// targetOS is the operating system generated hooks must run on. It is a variable so
+// tests can exercise Windows generation on any platform.
+var targetOS = runtime.GOOS
+
+// hookLanguage is the language a hook script is generated in.
+type hookLanguage string
+
+const (
+	hookLangBash       hookLanguage = "bash"
+	hookLangPython     hookLanguage = "python"
+	hookLangNode       hookLanguage = "node"
+	hookLangPowerShell hookLanguage = "powershell"
+)
+
+// hookLanguageExt maps each hook language to its script extension.
+var hookLanguageExt = map[hookLanguage]string{
+	hookLangBash:       ".sh",
+	hookLangPython:     ".py",
+	hookLangNode:       ".js",
+	hookLangPowerShell: ".ps1",
+}
+
+// hookDefaultLanguage is the HookLanguages key that applies to every hook without
+// its own entry.
+const hookDefaultLanguage = "*"
+
+// hookEvents returns the events a hook module runs on. Its hook_type default is
+// one event name or a list of them.
+func hookEvents(module *ComponentModule) []string {
+	switch v := module.Defaults["hook_type"].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
 		}
+	case []any:
+		var events []string
+		for _, event := range v {
+			if event, ok := event.(string); ok && event != "" {
+				events = append(events, event)
+			}
+		}
+		return events
 	}
-	
-	if err := run(cfg, registry); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+	return nil
+}
+
+// validateHookDefaults checks that a hook module's defaults make a settings.json
+// entry: a command, one or more events, and a matcher and timeout of the right
+// type. Frontmatter is checked as modules load, but modules read from the module
+// cache or built in code are not.
+func validateHookDefaults(module *ComponentModule) error {
+	file := module.Source
+	if file == "" {
+		file = "hook " + module.Name
 	}
-	if cfg.IsProjectLocal {
-		fmt.Println("\n✅ claudekit finished. Project-specific Claude Code configuration created!")
-		fmt.Println("   Open Claude Code in this directory and start coding!")
-	} else {
-		homeDir, _ := os.UserHomeDir()
-		configPath := filepath.Join(homeDir, ".claude")
-		fmt.Printf("\n✅ claudekit finished. Global Claude Code configuration created!\n")
-		fmt.Printf("   Configuration saved to: %s\n", configPath)
-		fmt.Println("   This configuration will apply to all your Claude Code sessions.")
+	if errs := schema.HookDefaults(file, module.Defaults); len(errs) > 0 {
+		return joinSchemaErrors(errs)
 	}
+	return nil
 }
 
-// cleanupDeselectedItems removes files for items that were previously selected but now deselected
-func cleanupDeselectedItems(cfg Config, persistedConfig *PersistenceConfig, targetDir string) error {
-	claudeDir := filepath.Join(targetDir, ".claude")
-	
-	// Clean up deselected subagents
-	for _, oldAgent := range persistedConfig.Subagents {
-		if !slices.Contains(cfg.Subagents, oldAgent) {
-			agentFile := filepath.Join(claudeDir, "agents", oldAgent+".md")
-			if _, err := os.Stat(agentFile); err == nil {
-				if err := os.Remove(agentFile); err != nil {
-					fmt.Fprintf(os.Stderr, "warning: failed to remove deselected agent %s: %v\n", oldAgent, err)
-				}
-			}
+// toolHookEvents are the hook events whose matcher picks the tools a hook fires on.
+var toolHookEvents = []string{"PreToolUse", "PostToolUse"}
+
+// hookToolMatcher returns the tools a selected hook fires on: the matcher set on the hook
+// matchers page when the hook is listed there, else its module's. Empty matches
+// every tool.
+func hookToolMatcher(cfg Config, name string, module *ComponentModule) string {
+	if matcher, ok := parseHookMatcherLines(cfg.HookMatchers)[name]; ok {
+		return matcher
+	}
+	matcher, _ := module.Defaults["matcher"].(string)
+	return matcher
+}
+
+// toolHooks returns the selected hook modules that run on tool calls, whose
+// matchers the hook matchers page overrides.
+func toolHooks(selected []string, registry *ModuleRegistry) []*ComponentModule {
+	var modules []*ComponentModule
+	for _, display := range selected {
+		module := registry.Get(TypeHook, cleanFormValue(display))
+		if module != nil && slices.ContainsFunc(hookEvents(module), func(event string) bool { return slices.Contains(toolHookEvents, event) }) {
+			modules = append(modules, module)
 		}
 	}
-	
-	// Clean up deselected hooks
-	for _, oldHook := range persistedConfig.Hooks {
-		if !slices.Contains(cfg.Hooks, oldHook) {
-			hookFile := filepath.Join(claudeDir, "hooks", oldHook+".sh")
-			if _, err := os.Stat(hookFile); err == nil {
-				if err := os.Remove(hookFile); err != nil {
-					fmt.Fprintf(os.Stderr, "warning: failed to remove deselected hook %s: %v\n", oldHook, err)
-				}
-			}
+	return modules
+}
+
+// parseHookMatcherLines reads the hook matchers page: "hook: matcher" per line, with
+// blank lines and # comments skipped.
+func parseHookMatcherLines(text string) map[string]string {
+	matchers := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, matcher, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) != "" {
+			matchers[strings.TrimSpace(name)] = strings.TrimSpace(matcher)
 		}
 	}
-	
-	// Clean up deselected slash commands
-	for _, oldCmd := range persistedConfig.SlashCommands {
-		if !slices.Contains(cfg.SlashCommands, oldCmd) {
-			// Remove both .md and .py files (legacy .py support)
-			for _, ext := range []string{".md", ".py"} {
-				cmdFile := filepath.Join(claudeDir, "commands", oldCmd+ext)
-				if _, err := os.Stat(cmdFile); err == nil {
-					if err := os.Remove(cmdFile); err != nil {
-						fmt.Fprintf(os.Stderr, "warning: failed to remove deselected command %s: %v\n", oldCmd, err)
-					}
-				}
+	return matchers
+}
+
+// validateHookMatcherLines checks the hook matchers page: each line names a hook
+// once, then a tool name or pattern Claude Code can match, or * for every tool.
+func validateHookMatcherLines(text string) error {
+	seen := map[string]bool{}
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, matcher, ok := strings.Cut(line, ":")
+		name, matcher = strings.TrimSpace(name), strings.TrimSpace(matcher)
+		switch {
+		case !ok || name == "" || strings.Contains(name, " "):
+			return fmt.Errorf("line %d: %q is not hook: matcher", i+1, line)
+		case seen[name]:
+			return fmt.Errorf("line %d: %s is listed twice", i+1, name)
+		case matcher == "":
+			return fmt.Errorf("line %d: give %s a tool name such as Write|Edit, or * for every tool", i+1, name)
+		case matcher != "*":
+			if _, err := regexp.Compile(matcher); err != nil {
+				return fmt.Errorf("line %d: %q is not a tool pattern: %v", i+1, matcher, err)
 			}
 		}
+		seen[name] = true
 	}
-	
 	return nil
 }
 
-func run(cfg Config, registry *ModuleRegistry) error {
-	var targetDir string
-	var err error
-	
-	if cfg.IsProjectLocal {
-		// Project-specific: use current directory
-		targetDir, err = os.Getwd()
-		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
-		}
-	} else {
-		// Global: use home directory with .claude subdirectory
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
+// hookMatchersHidden hides the hook matchers page until a hook that runs on tool
+// calls is selected.
+func hookMatchersHidden(cfg *Config, loader *registryLoader) func() bool {
+	return func() bool {
+		registry, _ := loader.Wait()
+		return len(toolHooks(cfg.Hooks, registry)) == 0
+	}
+}
+
+// hookMatcherUsage lists the selected hooks that run on tool calls with the tools
+// each module fires on, for the hook matchers page.
+func hookMatcherUsage(cfg *Config, loader *registryLoader) func() string {
+	return func() string {
+		registry, _ := loader.Wait()
+		var b strings.Builder
+		for _, module := range toolHooks(cfg.Hooks, registry) {
+			matcher, _ := module.Defaults["matcher"].(string)
+			fmt.Fprintf(&b, "%s: %s\n", module.Name, cmp.Or(matcher, "every tool"))
 		}
-		targetDir = filepath.Join(homeDir, ".claude")
+		return strings.TrimSuffix(b.String(), "\n")
 	}
-	
-	abs, err := filepath.Abs(targetDir)
-	if err != nil {
-		return err
+}
+
+// hookTimeout returns a hook module's timeout default in seconds, or 0 for none.
+// Modules parsed from YAML hold ints; those decoded from JSON hold float64s.
+func hookTimeout(module *ComponentModule) int {
+	if module == nil {
+		return 0
 	}
-	// Create directories
-	mustMkdir(filepath.Join(abs, ".claude"))
-	mustMkdir(filepath.Join(abs, ".claude", "agents"))
-	mustMkdir(filepath.Join(abs, ".claude", "hooks"))
-	if len(cfg.SlashCommands) > 0 {
-		mustMkdir(filepath.Join(abs, ".claude", "commands"))
+	return timeoutSeconds(module.Defaults["timeout"])
+}
+
+// timeoutSeconds reads a timeout default, an int from YAML or a float64 from JSON,
+// or returns 0 for none.
+func timeoutSeconds(value any) int {
+	switch t := value.(type) {
+	case int:
+		return t
+	case float64:
+		return int(t)
 	}
+	return 0
+}
 
-	// Write CLAUDE.md
-	if err := os.WriteFile(filepath.Join(abs, "CLAUDE.md"),
-		[]byte(renderClaudeMD(cfg)), 0o644); err != nil {
-		return err
+// hookCommand is one of the commands a hook module runs on its events.
+type hookCommand struct {
+	Name    string
+	Command string
+	Timeout int  // Seconds; 0 leaves the timeout to Claude Code
+	Script  bool // Runs the module's own script, so it follows the hook's language
+}
+
+// hookCommands returns the commands a hook module runs, in order: the entries of
+// its commands default, or its single command, named after the module. An entry
+// without a timeout of its own takes the module's.
+func hookCommands(module *ComponentModule) []hookCommand {
+	timeout := hookTimeout(module)
+	if command, ok := module.Defaults["command"].(string); ok {
+		return []hookCommand{{Name: module.Name, Command: command, Timeout: timeout, Script: true}}
+	}
+	list, _ := module.Defaults["commands"].([]any)
+	var commands []hookCommand
+	for _, item := range list {
+		entry, _ := item.(map[string]any)
+		c := hookCommand{Timeout: cmp.Or(timeoutSeconds(entry["timeout"]), timeout)}
+		c.Name, _ = entry["name"].(string)
+		c.Command, _ = entry["command"].(string)
+		if fields := strings.Fields(c.Command); len(fields) > 0 {
+			c.Script = strings.TrimSuffix(path.Base(fields[0]), path.Ext(fields[0])) == module.Name
+		}
+		commands = append(commands, c)
 	}
+	return commands
+}
 
-	// Write subagents
-	for _, a := range cfg.Subagents {
-		path := filepath.Join(abs, ".claude", "agents", a+".md")
-		if err := os.WriteFile(path, []byte(renderAgent(a)), 0o644); err != nil {
-			return err
+// enabledHookCommands returns the commands a selected hook runs: those listed for it
+// on the hook commands page, in that order, else all of its module's.
+func enabledHookCommands(cfg Config, name string, module *ComponentModule) ([]hookCommand, error) {
+	commands := hookCommands(module)
+	order, ok := parseHookCommandLines(cfg.HookCommands)[name]
+	if !ok {
+		return commands, nil
+	}
+	var enabled []hookCommand
+	for _, commandName := range order {
+		i := slices.IndexFunc(commands, func(c hookCommand) bool { return c.Name == commandName })
+		if i < 0 {
+			names := make([]string, len(commands))
+			for j, c := range commands {
+				names[j] = c.Name
+			}
+			return nil, fmt.Errorf("hook %s has no command %q (it has %s)", name, commandName, strings.Join(names, ", "))
 		}
+		enabled = append(enabled, commands[i])
 	}
+	return enabled, nil
+}
 
-	// Write selected hook scripts
-	for _, hookDisplay := range cfg.Hooks {
-		hookName := cleanFormValue(hookDisplay)
-		var content string
-		var filename string
-		
-		switch hookName {
-		case "pre-tool-use":
-			content = generateHookScript(hookName, "Runs before Claude executes any tool")
-			filename = "pre-tool-use.sh"
-		case "post-tool-use":
-			content = generateHookScript(hookName, "Runs after successful tool execution")
-			filename = "post-tool-use.sh"
-		case "notification":
-			content = generateHookScript(hookName, "Runs when Claude needs permission or when prompts idle")
-			filename = "notification.sh"
-		case "user-prompt-submit":
-			content = generateHookScript(hookName, "Runs when users submit prompts, before Claude processes them")
-			filename = "user-prompt-submit.py"
-		case "stop":
-			content = generateHookScript(hookName, "Runs when Claude finishes responding")
-			filename = "stop.sh"
-		case "subagent-stop":
-			content = generateHookScript(hookName, "Runs when Claude Code subagents finish responding")
-			filename = "subagent-stop.sh"
-		case "session-end":
-			content = generateHookScript(hookName, "Runs when Claude Code sessions terminate")
-			filename = "session-end.sh"
-		case "pre-compact":
-			content = generateHookScript(hookName, "Runs before context compaction operations")
-			filename = "pre-compact.sh"
-		case "session-start":
-			content = sessionStartScript() // Use existing script
-			filename = "session-start.sh"
-		default:
+// parseHookCommandLines reads the hook commands page: "hook: command, command" per
+// line, with blank lines and # comments skipped.
+func parseHookCommandLines(text string) map[string][]string {
+	orders := map[string][]string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
-		
-		if err := writeExecutable(filepath.Join(abs, ".claude", "hooks", filename), content); err != nil {
-			return err
+		name, list, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
 		}
+		var order []string
+		for _, command := range strings.Split(list, ",") {
+			if command = strings.TrimSpace(command); command != "" {
+				order = append(order, command)
+			}
+		}
+		orders[strings.TrimSpace(name)] = order
 	}
+	return orders
+}
 
-	// Write settings.json with hooks + permissions
-	st := buildSettings(abs, cfg, registry)
-	buf, _ := json.MarshalIndent(st, "", "  ")
-	if err := os.WriteFile(filepath.Join(abs, ".claude", "settings.json"), buf, 0o644); err != nil {
-		return err
+// validateHookCommandLines checks the hook commands page: each line names a hook
+// once, then at least one of its commands, each once.
+func validateHookCommandLines(text string) error {
+	seen := map[string]bool{}
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, _, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		switch {
+		case !ok || name == "" || strings.Contains(name, " "):
+			return fmt.Errorf("line %d: %q is not hook: command, command", i+1, line)
+		case seen[name]:
+			return fmt.Errorf("line %d: %s is listed twice", i+1, name)
+		}
+		seen[name] = true
+		order := parseHookCommandLines(line)[name]
+		if len(order) == 0 {
+			return fmt.Errorf("line %d: list at least one command, or deselect %s", i+1, name)
+		}
+		for j, command := range order {
+			if slices.Contains(order[:j], command) {
+				return fmt.Errorf("line %d: %s runs %s twice", i+1, name, command)
+			}
+		}
 	}
+	return nil
+}
 
-	// Create selected slash commands
-	for _, cmdDisplay := range cfg.SlashCommands {
-		cmdName := cleanFormValue(cmdDisplay)
-		var content string
-		if cmdName == "example" {
-			content = sampleSlashCommand()
-		} else {
-			content = generateSlashCommand(cmdName, registry)
+// multiCommandHooks returns the selected hook modules that run more than one
+// command, whose commands the hook commands page enables and orders.
+func multiCommandHooks(selected []string, registry *ModuleRegistry) []*ComponentModule {
+	var modules []*ComponentModule
+	for _, display := range selected {
+		module := registry.Get(TypeHook, cleanFormValue(display))
+		if module != nil && len(hookCommands(module)) > 1 {
+			modules = append(modules, module)
 		}
-		
-		if err := os.WriteFile(
-			filepath.Join(abs, ".claude", "commands", cmdName+".md"),
-			[]byte(content), 0o644); err != nil {
-			return err
+	}
+	return modules
+}
+
+// hookCommandsHidden hides the hook commands page until a hook that runs more than
+// one command is selected.
+func hookCommandsHidden(cfg *Config, loader *registryLoader) func() bool {
+	return func() bool {
+		registry, _ := loader.Wait()
+		return len(multiCommandHooks(cfg.Hooks, registry)) == 0
+	}
+}
+
+// hookCommandUsage lists the selected hooks that run more than one command with
+// their commands in order, for the hook commands page.
+func hookCommandUsage(cfg *Config, loader *registryLoader) func() string {
+	return func() string {
+		registry, _ := loader.Wait()
+		var b strings.Builder
+		for _, module := range multiCommandHooks(cfg.Hooks, registry) {
+			commands := hookCommands(module)
+			names := make([]string, len(commands))
+			for i, c := range commands {
+				names[i] = c.Name
+			}
+			fmt.Fprintf(&b, "%s: %s\n", module.Name, strings.Join(names, ", "))
+			for _, c := range commands {
+				fmt.Fprintf(&b, "  %s: %s\n", c.Name, c.Command)
+			}
 		}
+		return strings.TrimSuffix(b.String(), "\n")
 	}
+}
 
-	// MCP project config
-	if len(cfg.MCPServers) > 0 {
-		mcp := buildMCPJSON(cfg.MCPServers)
-		if err := os.WriteFile(filepath.Join(abs, ".mcp.json"), []byte(mcp), 0o644); err != nil {
-			return err
+// parseAssetResolutionLines reads saved asset resolutions: "path: resolution" per
+// line, with blank lines and # comments skipped.
+func parseAssetResolutionLines(text string) map[string]string {
+	resolutions := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, ":")
+		if i < 0 || strings.TrimSpace(line[:i]) == "" {
+			continue
 		}
+		resolutions[path.Clean(strings.TrimSpace(line[:i]))] = strings.TrimSpace(line[i+1:])
 	}
+	return resolutions
+}
 
-	// Gentle reminder if claude CLI is missing
-	if _, err := exec.LookPath("claude"); err != nil {
-		fmt.Println("\nℹ️  Claude Code CLI not found on PATH. Install with:")
-		fmt.Println("   curl -fsSL https://claude.ai/install.sh | bash   # macOS/Linux/WSL")
+// selectedAssetConflicts returns the asset conflicts of the modules generating cfg
+// would select: its selections without disabled modules, with their dependencies.
+func selectedAssetConflicts(cfg Config, registry *ModuleRegistry) []assetConflict {
+	if !cfg.IncludeDisabled {
+		registry.DropDisabled(&cfg)
+	}
+	registry.ResolveDependencies(&cfg)
+	return registry.AssetConflicts(&cfg)
+}
+
+// assetConflictsHidden hides the asset conflicts page until selected modules
+// declare the same asset path.
+func assetConflictsHidden(cfg *Config, loader *registryLoader) func() bool {
+	return func() bool {
+		registry, _ := loader.Wait()
+		return len(selectedAssetConflicts(*cfg, registry)) == 0
+	}
+}
+
+// assetResolutionKey is the form key of the asset conflict choice.
+const assetResolutionKey = "asset-resolution"
+
+// assetConflictFields returns the fields of the asset conflicts page: a banner and
+// one choice that pages through the conflicting paths.
+func assetConflictFields(cfg *Config, loader *registryLoader) []huh.Field {
+	return []huh.Field{
+		huh.NewNote().Title("⚠️ Asset Conflicts").Description("These selected modules declare the same asset. Pick what each path does; unresolved paths keep every module. Enter moves to the next path and shift+tab back"),
+		newAssetConflictSelect(cfg, loader),
 	}
+}
 
-	return nil
+// assetConflictSelect chooses the resolution of each asset conflict of the modules
+// generating cfg would select, one at a time: moving on from a conflict shows the
+// next instead of leaving the field, and moving back the one before, so the page
+// holds as many conflicts as there are. The choice is kept in cfg.AssetResolutions
+// through assetResolutionAccessor.
+type assetConflictSelect struct {
+	*huh.Select[string]
+	index  int // The conflict shown
+	cfg    *Config
+	loader *registryLoader
 }
 
-func mustMkdir(p string) {
-	_ = os.MkdirAll(p, 0o755)
+func newAssetConflictSelect(cfg *Config, loader *registryLoader) *assetConflictSelect {
+	s := &assetConflictSelect{cfg: cfg, loader: loader}
+	// The index is bound too, so paging redraws the choice
+	bindings := []any{cfg, &s.index}
+	s.Select = huh.NewSelect[string]().
+		Key(assetResolutionKey).
+		TitleFunc(func() string {
+			conflict, _ := s.conflict()
+			if n := len(s.conflicts()); n > 1 {
+				return fmt.Sprintf("%s (%d of %d)", conflict.Path, s.index+1, n)
+			}
+			return conflict.Path
+		}, bindings).
+		DescriptionFunc(func() string {
+			conflict, _ := s.conflict()
+			return strings.TrimPrefix(conflict.String(), conflict.Path+" is ")
+		}, bindings).
+		OptionsFunc(s.options, bindings).
+		Height(6).
+		Accessor(assetResolutionAccessor{s})
+	return s
 }
-func writeExecutable(path string, content string) error {
-	if strings.HasSuffix(path, ".py") {
-		return os.WriteFile(path, []byte(content), 0o755)
-	}
-	return os.WriteFile(path, []byte("#!/usr/bin/env bash\nset -euo pipefail\n"+content+"\n"), 0o755)
+
+// conflicts returns the asset conflicts the field pages through.
+func (s *assetConflictSelect) conflicts() []assetConflict {
+	registry, _ := s.loader.Wait()
+	return selectedAssetConflicts(*s.cfg, registry)
 }
 
-func contains(ss []string, s string) bool {
-	for _, x := range ss {
-		if x == s {
-			return true
-		}
+// conflict returns the asset conflict the field shows, and false when there are
+// not that many.
+func (s *assetConflictSelect) conflict() (assetConflict, bool) {
+	conflicts := s.conflicts()
+	if s.index >= len(conflicts) {
+		return assetConflict{}, false
 	}
-	return false
+	return conflicts[s.index], true
 }
 
-func buildSettings(projectDir string, cfg Config, registry *ModuleRegistry) settings {
-	s := settings{
-		Permissions: &struct {
-			Allow []string `json:"allow,omitempty"`
-			Ask   []string `json:"ask,omitempty"`
-			Deny  []string `json:"deny,omitempty"`
-		}{
-			Allow: []string{"Read", "LS", "Grep", "Glob"},
-			Ask:   []string{"Bash(git *:*)", "WebFetch"},
-			Deny:  []string{"Read(./.env)", "Read(./.env.*)", "Read(./secrets/**)"},
-		},
-		Env: map[string]string{
-			"CLAUDE_CODE_MAX_OUTPUT_TOKENS": "8192",
-			"MCP_TOOL_TIMEOUT":              "180000",
-		},
-		Hooks: map[string][]hookMatcher{},
+// options offers keeping every module, as they are or each under its own path, the
+// one selected first, or the one selected last.
+func (s *assetConflictSelect) options() []huh.Option[string] {
+	conflict, ok := s.conflict()
+	if !ok {
+		return nil
 	}
+	first, last := conflict.Modules[0], conflict.Modules[len(conflict.Modules)-1]
+	return []huh.Option[string]{
+		huh.NewOption("Keep every module (unresolved)", ""),
+		huh.NewOption(fmt.Sprintf("Keep every module, each reading %s", renamedAssetPath(moduleRef{Name: "<module>"}, conflict.Path)), assetRename),
+		huh.NewOption(fmt.Sprintf("Keep %s, selected first", first), assetSkip),
+		huh.NewOption(fmt.Sprintf("Keep %s, selected last", last), assetOverwrite),
+	}
+}
 
-	// Add all selected hooks using registry (Feature 004)
-	for _, hookDisplay := range cfg.Hooks {
-		hookName := cleanFormValue(hookDisplay)
-
-		// Get hook module from registry
-		hookModule := registry.Get(TypeHook, hookName)
-		if hookModule == nil {
-			continue // Skip unknown hooks
+// Update pages to the next conflict on the keys that would leave the field
+// forward, and to the previous one on those leaving it back, until the last or
+// the first conflict.
+func (s *assetConflictSelect) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	page := 0
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		keys := huh.NewDefaultKeyMap().Select
+		switch {
+		case key.Matches(msg, keys.Next) && s.index+1 < len(s.conflicts()):
+			page = 1
+		case key.Matches(msg, keys.Prev) && s.index > 0:
+			page = -1
 		}
+	}
+	_, cmd := s.Select.Update(msg)
+	if page != 0 {
+		// The choice is saved; show the other conflict instead of leaving the field
+		s.index += page
+		s.Select.Options(s.options()...)
+		s.Select.Accessor(assetResolutionAccessor{s})
+		return s, nil
+	}
+	return s, cmd
+}
 
-		// Extract defaults from module
-		hookType, _ := hookModule.Defaults["hook_type"].(string)
-		command, _ := hookModule.Defaults["command"].(string)
-		timeout, _ := hookModule.Defaults["timeout"].(float64) // JSON numbers are float64
+// Focus shows a conflict that still exists and moves the cursor to its saved
+// resolution, since the conflicts change with the selections.
+func (s *assetConflictSelect) Focus() tea.Cmd {
+	s.index = max(0, min(s.index, len(s.conflicts())-1))
+	s.Select.Options(s.options()...)
+	s.Select.Accessor(assetResolutionAccessor{s})
+	return s.Select.Focus()
+}
 
-		if hookType == "" || command == "" {
-			continue // Skip malformed hook modules
-		}
+// assetResolutionAccessor reads and writes the resolution of its field's conflict
+// in the "path: resolution" lines of cfg.AssetResolutions.
+type assetResolutionAccessor struct{ s *assetConflictSelect }
 
-		s.Hooks[hookType] = append(s.Hooks[hookType],
-			hookMatcher{
-				Hooks: []hookCmd{{
-					Type:    "command",
-					Command: command,
-					Timeout: int(timeout),
-				}},
-			},
-		)
+func (a assetResolutionAccessor) Get() string {
+	conflict, ok := a.s.conflict()
+	if !ok {
+		return ""
 	}
-
-	return s
+	return parseAssetResolutionLines(a.s.cfg.AssetResolutions)[conflict.Path]
 }
 
-func renderClaudeMD(cfg Config) string {
-	tmplContent, err := assets.ReadFile("assets/templates/CLAUDE.md.tmpl")
-	if err != nil {
-		panic(err)
-	}
-	
-	tmpl, err := template.New("claude").Funcs(template.FuncMap{
-		"or": or,
-	}).Parse(string(tmplContent))
-	if err != nil {
-		panic(err)
+func (a assetResolutionAccessor) Set(resolution string) {
+	// huh sets the value whenever the cursor lands on it; keep the lines in order then
+	if conflict, ok := a.s.conflict(); ok && a.Get() != resolution {
+		a.s.cfg.AssetResolutions = setAssetResolution(a.s.cfg.AssetResolutions, conflict.Path, resolution)
 	}
-	
-	data := struct {
-		Config
-		HasGo         bool
-		HasTypeScript bool
-		HasPython     bool
-		HasRust       bool
-		HasCpp        bool
-		HasJava       bool
-		HasCsharp     bool
-		HasPhp        bool
-		HasRuby       bool
-		HasSwift      bool
-		HasDart       bool
-		HasShell      bool
-		HasLua        bool
-		HasElixir     bool
-		HasHaskell    bool
-		HasElm        bool
-		HasJulia      bool
-		HasSql        bool
-		Date          string
-	}{
-		Config:        cfg,
-		HasGo:         includes(cfg.Languages, "Go"),
-		HasTypeScript: includes(cfg.Languages, "TypeScript"),
-		HasPython:     includes(cfg.Languages, "Python"),
-		HasRust:       includes(cfg.Languages, "Rust"),
-		HasCpp:        includes(cfg.Languages, "C++"),
-		HasJava:       includes(cfg.Languages, "Java") || includes(cfg.Languages, "Kotlin"),
-		HasCsharp:     includes(cfg.Languages, "C#"),
-		HasPhp:        includes(cfg.Languages, "PHP"),
-		HasRuby:       includes(cfg.Languages, "Ruby"),
-		HasSwift:      includes(cfg.Languages, "Swift"),
-		HasDart:       includes(cfg.Languages, "Dart"),
-		HasShell:      includes(cfg.Languages, "Shell"),
-		HasLua:        includes(cfg.Languages, "Lua"),
-		HasElixir:     includes(cfg.Languages, "Elixir"),
-		HasHaskell:    includes(cfg.Languages, "Haskell"),
-		HasElm:        includes(cfg.Languages, "Elm"),
-		HasJulia:      includes(cfg.Languages, "Julia"),
-		HasSql:        includes(cfg.Languages, "SQL"),
-		Date:          time.Now().Format("2006-01-02"),
+}
+
+// setAssetResolution returns the resolution lines of text with assetPath settled by
+// resolution, or left unresolved when it is "".
+func setAssetResolution(text, assetPath, resolution string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if i := strings.LastIndex(trimmed, ":"); i >= 0 && !strings.HasPrefix(trimmed, "#") && path.Clean(strings.TrimSpace(trimmed[:i])) == assetPath {
+			continue
+		}
+		lines = append(lines, trimmed)
 	}
-	
-	var b bytes.Buffer
-	if err := tmpl.Execute(&b, data); err != nil {
-		panic(err)
+	if resolution != "" {
+		lines = append(lines, assetPath+": "+resolution)
 	}
-	return b.String()
+	return strings.Join(lines, "\n")
 }
 
-func renderAgent(name string) string {
-	content, err := assets.ReadFile("assets/agents/" + name + ".md")
-	if err != nil {
-		return `---
-name: ` + name + `
-description: Custom subagent
----
-Provide a focused role and steps.`
+// hookLanguages returns the languages a hook module supports, preferred first.
+// Modules that declare none support bash only.
+func hookLanguages(module *ComponentModule) []hookLanguage {
+	var langs []hookLanguage
+	if module != nil {
+		list, _ := module.Defaults["languages"].([]any)
+		for _, item := range list {
+			if lang, ok := item.(string); ok {
+				if _, known := hookLanguageExt[hookLanguage(lang)]; known {
+					langs = append(langs, hookLanguage(lang))
+				}
+			}
+		}
 	}
-	return string(content)
+	if len(langs) == 0 {
+		langs = []hookLanguage{hookLangBash}
+	}
+	return langs
 }
 
-func postWriteLintScript(langs []string) string {
-	tmplContent, err := assets.ReadFile("assets/hooks/postwrite-lint.sh.tmpl")
-	if err != nil {
-		panic(err)
-	}
-	
-	tmpl, err := template.New("postwrite-lint").Parse(string(tmplContent))
-	if err != nil {
-		panic(err)
+// resolveHookLanguage picks the script language for a hook. A per-hook choice must be
+// supported by the module; the "*" default is used only where it is supported, so one
+// flag can switch every hook that has a template in that language. Without a choice,
+// Windows gets PowerShell where the module supports it.
+func resolveHookLanguage(name string, module *ComponentModule, choices map[string]string) (hookLanguage, error) {
+	supported := hookLanguages(module)
+	if choice, ok := choices[name]; ok {
+		if !slices.Contains(supported, hookLanguage(choice)) {
+			return "", fmt.Errorf("hook %s cannot be generated in %s (supports %s)", name, choice, joinHookLanguages(supported))
+		}
+		return hookLanguage(choice), nil
 	}
-	
-	data := struct {
-		HasGo         bool
-		HasTypeScript bool
-		HasPython     bool
-		HasRust       bool
-		HasCpp        bool
-		HasJava       bool
-		HasCsharp     bool
-		HasPhp        bool
-		HasRuby       bool
-		HasSwift      bool
-		HasDart       bool
-		HasShell      bool
-		HasLua        bool
-		HasElixir     bool
-		HasHaskell    bool
-		HasElm        bool
-		HasJulia      bool
-		HasSql        bool
-	}{
-		HasGo:         includes(langs, "Go"),
-		HasTypeScript: includes(langs, "TypeScript"),
-		HasPython:     includes(langs, "Python"),
-		HasRust:       includes(langs, "Rust"),
-		HasCpp:        includes(langs, "C++"),
-		HasJava:       includes(langs, "Java") || includes(langs, "Kotlin"),
-		HasCsharp:     includes(langs, "C#"),
-		HasPhp:        includes(langs, "PHP"),
-		HasRuby:       includes(langs, "Ruby"),
-		HasSwift:      includes(langs, "Swift"),
-		HasDart:       includes(langs, "Dart"),
-		HasShell:      includes(langs, "Shell"),
-		HasLua:        includes(langs, "Lua"),
-		HasElixir:     includes(langs, "Elixir"),
-		HasHaskell:    includes(langs, "Haskell"),
-		HasElm:        includes(langs, "Elm"),
-		HasJulia:      includes(langs, "Julia"),
-		HasSql:        includes(langs, "SQL"),
+	if choice, ok := choices[hookDefaultLanguage]; ok && slices.Contains(supported, hookLanguage(choice)) {
+		return hookLanguage(choice), nil
 	}
-	
-	var b bytes.Buffer
-	if err := tmpl.Execute(&b, data); err != nil {
-		panic(err)
+	if targetOS == "windows" && slices.Contains(supported, hookLangPowerShell) {
+		return hookLangPowerShell, nil
 	}
-	return b.String()
+	return supported[0], nil
 }
 
-func generateHookScript(hookName, description string) string {
-	if strings.HasSuffix(hookName, ".py") || strings.Contains(hookName, "prompt") {
-		// Generate Python script for Python-based hooks
-		return fmt.Sprintf(`#!/usr/bin/env python3
-"""
-%s Hook - %s
-
-This hook is called by Claude Code during specific events.
-You can customize this script to add logging, validation, or other actions.
-
-Environment variables available:
-- CLAUDE_PROJECT_DIR: Current project directory
-- CLAUDE_SESSION_ID: Current session identifier
-- CLAUDE_USER_MESSAGE: User's message (for prompt hooks)
-- CLAUDE_TOOL_NAME: Tool name (for tool hooks)
-- CLAUDE_TOOL_ARGS: Tool arguments (for tool hooks)
-"""
-
-import os
-import sys
-from datetime import datetime
-
-def main():
-    print(f"[{datetime.now().isoformat()}] %s hook triggered")
-    
-    # Add your custom logic here
-    # Example: Log to file, send notifications, validate inputs, etc.
-    
-    # Return 0 for success, non-zero for failure
-    return 0
-
-if __name__ == "__main__":
-    sys.exit(main())
-`, hookName, description, hookName)
-	} else {
-		// Generate bash script for shell-based hooks
-		return fmt.Sprintf(`#!/usr/bin/env bash
-# %s Hook - %s
-#
-# This hook is called by Claude Code during specific events.
-# You can customize this script to add logging, validation, or other actions.
-#
-# Environment variables available:
-# - CLAUDE_PROJECT_DIR: Current project directory
-# - CLAUDE_SESSION_ID: Current session identifier  
-# - CLAUDE_USER_MESSAGE: User's message (for prompt hooks)
-# - CLAUDE_TOOL_NAME: Tool name (for tool hooks)
-# - CLAUDE_TOOL_ARGS: Tool arguments (for tool hooks)
+func joinHookLanguages(langs []hookLanguage) string {
+	names := make([]string, len(langs))
+	for i, lang := range langs {
+		names[i] = string(lang)
+	}
+	return strings.Join(names, ", ")
+}
 
-echo "[$(date -Iseconds)] %s hook triggered"
+// hookScriptName is the file name of a hook's script in lang.
+func hookScriptName(name string, lang hookLanguage) string {
+	return name + hookLanguageExt[lang]
+}
 
-# Add your custom logic here
-# Examples:
-# - Log events: echo "Event logged" >> "$CLAUDE_PROJECT_DIR/.claude/hooks.log"
-# - Send notifications: curl -X POST ... 
-# - Validate inputs: [[ "$CLAUDE_TOOL_NAME" == "Write" ]] && echo "Validating write operation"
+// hookCommandWithScript replaces the script file name in a module's hook command,
+// keeping its directory and any arguments.
+func hookCommandWithScript(command, script string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return command
+	}
+	fields[0] = path.Join(path.Dir(fields[0]), script)
+	return strings.Join(fields, " ")
+}
 
-# Return 0 for success, non-zero for failure
-exit 0
-`, hookName, description, hookName)
+// parseHookLanguages parses --hook-lang: a default language ("node") and/or
+// per-hook choices ("stop=python"), comma-separated.
+func parseHookLanguages(s string) (map[string]string, error) {
+	choices := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, lang, found := strings.Cut(part, "=")
+		if !found {
+			name, lang = hookDefaultLanguage, part
+		}
+		if _, ok := hookLanguageExt[hookLanguage(lang)]; !ok || name == "" {
+			return nil, fmt.Errorf("%q: want LANG or HOOK=LANG with LANG one of bash, python, node, powershell", part)
+		}
+		choices[name] = lang
 	}
+	return choices, nil
 }
 
 func preWriteGuardScript() string {
@@ -2221,22 +15079,249 @@ func sampleSlashCommand() string {
 	return string(content)
 }
 
-func generateSlashCommand(cmdName string, registry *ModuleRegistry) string {
+// commandArgument is a named argument a command module declares in its defaults.
+type commandArgument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// commandArguments returns the arguments a command module declares, in order.
+func commandArguments(module *ComponentModule) []commandArgument {
+	var args []commandArgument
+	list, _ := module.Defaults["arguments"].([]any)
+	for _, item := range list {
+		m, _ := item.(map[string]any)
+		name, _ := m["name"].(string)
+		if name == "" {
+			continue
+		}
+		description, _ := m["description"].(string)
+		required, _ := m["required"].(bool)
+		args = append(args, commandArgument{Name: name, Description: description, Required: required})
+	}
+	return args
+}
+
+// commandArgumentHint is the argument-hint Claude Code shows after a command's
+// name, as in "<issue> [--draft]".
+func commandArgumentHint(args []commandArgument) string {
+	hints := make([]string, len(args))
+	for i, arg := range args {
+		hints[i] = "[" + arg.Name + "]"
+		if arg.Required {
+			hints[i] = "<" + arg.Name + ">"
+		}
+	}
+	return strings.Join(hints, " ")
+}
+
+// commandAllowedTools returns the tools a command module lets Claude use without
+// asking, as permission rules.
+func commandAllowedTools(module *ComponentModule) []string {
+	var tools []string
+	list, _ := module.Defaults["allowed_tools"].([]any)
+	for _, item := range list {
+		if tool, ok := item.(string); ok && tool != "" {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// splitToolList splits a comma-separated tool list, leaving commas inside a rule's
+// parentheses alone.
+func splitToolList(text string) []string {
+	var tools []string
+	depth, start := 0, 0
+	for i, r := range text + "," {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth > 0 {
+				continue
+			}
+			if tool := strings.TrimSpace(text[start:min(i, len(text))]); tool != "" {
+				tools = append(tools, tool)
+			}
+			start = i + 1
+		}
+	}
+	return tools
+}
+
+// parseCommandToolLines returns the tool lists typed on the command parameters
+// page by command name, skipping lines that are not "command: tools".
+func parseCommandToolLines(text string) map[string][]string {
+	tools := map[string][]string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, list, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) != "" {
+			tools[strings.TrimSpace(name)] = splitToolList(list)
+		}
+	}
+	return tools
+}
+
+// validateCommandToolLines checks the command parameters page: each line names a
+// command once, then its tools as permission rules.
+func validateCommandToolLines(text string) error {
+	seen := map[string]bool{}
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, list, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		switch {
+		case !ok || name == "" || strings.ContainsAny(name, " ("):
+			return fmt.Errorf("line %d: %q is not command: tools", i+1, line)
+		case seen[name]:
+			return fmt.Errorf("line %d: %s is listed twice", i+1, name)
+		}
+		for _, tool := range splitToolList(list) {
+			if err := validatePermissionRule(tool); err != nil {
+				return fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// commandTools returns the allowed tools for a command: those typed on the command
+// parameters page when it is listed there, else the module's.
+func commandTools(cfg Config, module *ComponentModule) []string {
+	if tools, ok := parseCommandToolLines(cfg.CommandTools)[module.Name]; ok {
+		return tools
+	}
+	return commandAllowedTools(module)
+}
+
+// commandParametersHidden hides the command parameters page unless a selected
+// command declares arguments or tools.
+func commandParametersHidden(cfg *Config, loader *registryLoader) func() bool {
+	return func() bool {
+		registry, _ := loader.Wait()
+		return len(parameterizedCommands(cfg.SlashCommands, registry)) == 0
+	}
+}
+
+// parameterizedCommands returns the selected command modules that declare
+// arguments or allowed tools.
+func parameterizedCommands(selected []string, registry *ModuleRegistry) []*ComponentModule {
+	var modules []*ComponentModule
+	for _, display := range selected {
+		module := registry.Get(TypeCommand, cleanFormValue(display))
+		if module != nil && (len(commandArguments(module)) > 0 || len(commandAllowedTools(module)) > 0) {
+			modules = append(modules, module)
+		}
+	}
+	return modules
+}
+
+// commandUsage describes the selected commands' arguments and default tools for
+// the command parameters page.
+func commandUsage(cfg *Config, loader *registryLoader) func() string {
+	return func() string {
+		registry, _ := loader.Wait()
+		var b strings.Builder
+		for _, module := range parameterizedCommands(cfg.SlashCommands, registry) {
+			b.WriteString(commandInvocation(module.Name, module))
+			if hint := commandArgumentHint(commandArguments(module)); hint != "" {
+				fmt.Fprintf(&b, " %s", hint)
+			}
+			if tools := commandAllowedTools(module); len(tools) > 0 {
+				fmt.Fprintf(&b, "\n  tools: %s", strings.Join(tools, ", "))
+			}
+			b.WriteString("\n")
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+}
+
+// commandTemplateBody returns the prompt in a command module's template asset, read
+// as cfg renames it, or "" when it has none.
+func commandTemplateBody(module *ComponentModule, cfg Config) string {
+	for _, assetPath := range module.AssetPaths {
+		if !strings.HasPrefix(assetPath, "templates/") {
+			continue
+		}
+		if content, err := cfg.readModuleAsset(module, assetPath); err == nil {
+			return strings.TrimSpace(string(content))
+		}
+	}
+	return ""
+}
+
+// commandArgumentRef matches the placeholders Claude Code fills with a command's
+// arguments: $ARGUMENTS for all of them, $1, $2, ... for each in turn.
+var commandArgumentRef = regexp.MustCompile(`\$(ARGUMENTS|[1-9])\b`)
+
+// commandNamespace returns the directory a command module is generated into under
+// the commands directory, or "" for none. Claude Code names a command in git/ as
+// /git:commit.
+func commandNamespace(module *ComponentModule) string {
+	if module == nil {
+		return ""
+	}
+	namespace, _ := module.Defaults["namespace"].(string)
+	namespace = strings.Trim(namespace, "/")
+	if !schema.CommandNamespace.MatchString(namespace) {
+		return ""
+	}
+	return namespace
+}
+
+// commandPath is where a command is generated: in its namespace's directory under
+// commandsDir, if it has one.
+func commandPath(commandsDir, name string, module *ComponentModule) string {
+	return filepath.Join(commandsDir, filepath.FromSlash(commandNamespace(module)), name+".md")
+}
+
+// commandInvocation is how a command is invoked in Claude Code, as in /git:commit.
+func commandInvocation(name string, module *ComponentModule) string {
+	if namespace := commandNamespace(module); namespace != "" {
+		return "/" + strings.ReplaceAll(namespace, "/", ":") + ":" + name
+	}
+	return "/" + name
+}
+
+// removeEmptyNamespaceDir removes dir and its parents up to commandsDir while they
+// are empty, so removing the last command in a namespace removes its directory.
+func removeEmptyNamespaceDir(files fsys.FS, dir, commandsDir string) {
+	for {
+		rel, err := filepath.Rel(commandsDir, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		if files.Remove(dir) != nil {
+			return // Not empty, or already gone
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func generateSlashCommand(cmdName string, cfg Config, registry *ModuleRegistry) string {
 	// Generate custom slash command content based on the command name (Feature 004: use registry)
 	module := registry.Get(TypeCommand, cmdName)
 	if module == nil {
 		return fmt.Sprintf(`---
-name: %s
 description: Custom command
 ---
 
 # %s Command
 
 Add your custom command implementation here.
-`, cmdName, strings.Title(strings.ReplaceAll(cmdName, "-", " ")))
+`, strings.Title(strings.ReplaceAll(cmdName, "-", " ")))
 	}
 
-	desc := module.Description
+	desc := module.GetDescription()
 
 	// Extract command name from description (between ** markers)
 	titleStart := strings.Index(desc, "**")
@@ -2248,21 +15333,32 @@ Add your custom command implementation here.
 		title = "/" + cmdName
 	}
 
-	// Extract description after the title
+	// Extract description after the title; Claude Code lists the command by it
 	descStart := strings.Index(desc, " - ")
 	var description string
 	if descStart != -1 {
 		description = strings.TrimSpace(desc[descStart+3:])
+	} else if titleStart != -1 && titleEnd != -1 {
+		description = strings.TrimSuffix(title, ".")
 	} else {
 		description = "Custom development command"
 	}
 
-	return fmt.Sprintf(`---
-name: %s
-description: %s
----
+	args := commandArguments(module)
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "description: %s\n", strconv.Quote(strings.Join(strings.Fields(description), " ")))
+	if hint := commandArgumentHint(args); hint != "" {
+		fmt.Fprintf(&b, "argument-hint: %s\n", strconv.Quote(hint))
+	}
+	if tools := commandTools(cfg, module); len(tools) > 0 {
+		fmt.Fprintf(&b, "allowed-tools: %s\n", strings.Join(tools, ", "))
+	}
+	b.WriteString("---\n\n")
 
-# %s
+	body := commandTemplateBody(module, cfg)
+	if body == "" {
+		body = fmt.Sprintf(`# %s
 
 %s
 
@@ -2273,51 +15369,134 @@ Use this command to automate complex development tasks. The command will:
 1. Analyze the current project context
 2. Execute the requested operation
 3. Provide detailed feedback and results
-4. Ensure code quality and best practices
+4. Ensure code quality and best practices`, title, description)
+	}
+	b.WriteString(body)
+	b.WriteString("\n")
+
+	// Hand the arguments over unless the template already places them
+	if !commandArgumentRef.MatchString(body) {
+		b.WriteString("\n## Arguments\n\n")
+		if len(args) > 0 {
+			fmt.Fprintf(&b, "Invoked as `%s %s`.\n\n", commandInvocation(cmdName, module), commandArgumentHint(args))
+			for i, arg := range args {
+				need := "optional"
+				if arg.Required {
+					need = "required"
+				}
+				fmt.Fprintf(&b, "- `%s` (%s, `$%d`)", arg.Name, need, i+1)
+				if arg.Description != "" {
+					fmt.Fprintf(&b, ": %s", arg.Description)
+				}
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("The user's input: $ARGUMENTS\n")
+	}
+	return b.String()
+}
 
-Add specific implementation details and parameters as needed.
-`, cmdName, description, title, description)
+// mcpServer is one server in .mcp.json, or in a claude mcp add-json command.
+type mcpServer struct {
+	Type    string            `json:"type,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
-func buildMCPJSON(selected []string) string {
-	// Project-scoped .mcp.json using type/http or stdio servers; env expansion supported by Claude Code.
-	// See docs for exact schema and variable expansion semantics.
-	type server struct {
-		Type    string            `json:"type,omitempty"`
-		URL     string            `json:"url,omitempty"`
-		Command string            `json:"command,omitempty"`
-		Args    []string          `json:"args,omitempty"`
-		Env     map[string]string `json:"env,omitempty"`
-		Headers map[string]string `json:"headers,omitempty"`
-	}
-	m := map[string]server{}
+// mcpServerConfigs returns the configuration of each selected server claudekit knows.
+func mcpServerConfigs(selected []string) map[string]mcpServer {
+	m := map[string]mcpServer{}
 	for _, name := range selected {
 		switch name {
 		case "notion":
-			m["notion"] = server{Type: "http", URL: "https://mcp.notion.com/mcp",
+			m["notion"] = mcpServer{Type: "http", URL: "https://mcp.notion.com/mcp",
 				Headers: map[string]string{"Authorization": "Bearer ${NOTION_TOKEN}"}} // env expansion supported
 		case "linear":
-			m["linear"] = server{Type: "sse", URL: "https://mcp.linear.app/sse",
+			m["linear"] = mcpServer{Type: "sse", URL: "https://mcp.linear.app/sse",
 				Headers: map[string]string{"Authorization": "Bearer ${LINEAR_TOKEN}"}}
 		case "sentry":
-			m["sentry"] = server{Type: "http", URL: "https://mcp.sentry.dev/mcp"}
+			m["sentry"] = mcpServer{Type: "http", URL: "https://mcp.sentry.dev/mcp"}
 		case "github":
 			// Example stdio: npx server (official server names may vary; adjust to your org's choice)
-			m["github"] = server{Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-github"},
+			m["github"] = mcpServer{Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-github"},
 				Env: map[string]string{"GITHUB_TOKEN": "${GITHUB_TOKEN}"}}
 		case "airtable":
 			// Cli-installed server (JS community)
-			m["airtable"] = server{Command: "npx", Args: []string{"-y", "airtable-mcp-server"},
+			m["airtable"] = mcpServer{Command: "npx", Args: []string{"-y", "airtable-mcp-server"},
 				Env: map[string]string{"AIRTABLE_API_KEY": "${AIRTABLE_API_KEY}"}}
 		}
 	}
+	return m
+}
+
+func buildMCPJSON(selected []string) string {
+	// Project-scoped .mcp.json using type/http or stdio servers; env expansion supported by Claude Code.
+	// See docs for exact schema and variable expansion semantics.
 	root := struct {
-		MCPServers map[string]server `json:"mcpServers"`
-	}{MCPServers: m}
+		MCPServers map[string]mcpServer `json:"mcpServers"`
+	}{MCPServers: mcpServerConfigs(selected)}
 	out, _ := json.MarshalIndent(root, "", "  ")
 	return string(out)
 }
 
+// mcpScopes splits the selected MCP servers into those written to the project's
+// .mcp.json and those registered for the user.
+func mcpScopes(cfg Config) (project, user []string) {
+	for _, server := range cleanFormValues(cfg.MCPServers) {
+		if slices.Contains(cleanFormValues(cfg.MCPUserScope), server) {
+			user = append(user, server)
+		} else {
+			project = append(project, server)
+		}
+	}
+	return project, user
+}
+
+// mcpAddCommands returns the claude CLI commands that register servers for the
+// user, in every project. ${VAR} references stay unexpanded, as in .mcp.json.
+func mcpAddCommands(servers []string) []string {
+	configs := mcpServerConfigs(servers)
+	var commands []string
+	for _, name := range servers {
+		if config, ok := configs[name]; ok {
+			data, _ := json.Marshal(config)
+			commands = append(commands, fmt.Sprintf("claude mcp add-json --scope user %s %s", name, shellQuote(string(data))))
+		}
+	}
+	return commands
+}
+
+// mcpServerLabels lists the selected MCP servers for summaries, marking those
+// registered for the user.
+func mcpServerLabels(cfg Config) []string {
+	project, user := mcpScopes(cfg)
+	labels := slices.Clone(project)
+	for _, server := range user {
+		labels = append(labels, server+" (user)")
+	}
+	return labels
+}
+
+// mcpScopeOptions offers the selected MCP servers for the user-scope choice.
+func mcpScopeOptions(cfg *Config, loader *registryLoader) func() []huh.Option[string] {
+	return func() []huh.Option[string] {
+		registry, _ := loader.Wait()
+		var options []huh.Option[string]
+		for _, server := range cleanFormValues(cfg.MCPServers) {
+			label := server
+			if module := registry.Get(TypeMCP, server); module != nil {
+				label = module.DisplayName
+			}
+			options = append(options, huh.NewOption(label, server))
+		}
+		return options
+	}
+}
+
 func includes(ss []string, s string) bool {
 	for _, x := range ss {
 		if strings.EqualFold(x, s) {
@@ -2326,6 +15505,12 @@ func includes(ss []string, s string) bool {
 	}
 	return false
 }
+
+// shellQuote quotes s as one word for bash and POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func or(a, b string) string {
 	if strings.TrimSpace(a) == "" {
 		return b
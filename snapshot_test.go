@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"jeremyclewell.com/claudekit/internal/generation"
+	"jeremyclewell.com/claudekit/internal/gradient"
+	"jeremyclewell.com/claudekit/internal/headless"
+)
+
+// updateSnapshots rewrites the golden files instead of comparing against them:
+//
+//	go test -run 'TestANSISnapshots|TestScreenSnapshots|TestGenerationGolden' -update
+var updateSnapshots = flag.Bool("update", false, "rewrite the testdata/snapshots, testdata/screens, and testdata/golden golden files")
+
+// snapshotDir is resolved before any test runs, since some tests change directory.
+var snapshotDir, _ = filepath.Abs(filepath.Join("testdata", "snapshots"))
+
+// screenDir holds the text frames of TestScreenSnapshots.
+var screenDir, _ = filepath.Abs(filepath.Join("testdata", "screens"))
+
+// TestANSISnapshots renders View() at fixed sizes and color capabilities and compares
+// the ANSI output with golden files, catching layout and gradient regressions that the
+// VHS screenshots only show on manual review.
+func TestANSISnapshots(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		capability    gradient.TerminalCapability
+		width, height int
+	}{
+		{"wide-truecolor", gradient.Truecolor, 160, 50},
+		{"panel-threshold-256color", gradient.Color256, 140, 40},
+		{"no-panel-truecolor", gradient.Truecolor, 120, 40},
+		{"classic-8color", gradient.Color8, 80, 24},
+		{"narrow-title-truecolor", gradient.Truecolor, 60, 30},
+		{"no-color", gradient.NoColor, 120, 40},
+	}
+
+	registry := &ModuleRegistry{}
+	if errs := registry.Load(assets); len(errs) > 0 {
+		t.Fatalf("loading modules: %v", errs)
+	}
+	loader := &registryLoader{registry: registry, done: make(chan struct{})}
+	close(loader.done)
+
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			restore := lipgloss.ColorProfile()
+			lipgloss.SetColorProfile(capabilityProfile(sc.capability))
+			t.Cleanup(func() { lipgloss.SetColorProfile(restore) })
+
+			view := renderSnapshotView(t, loader, sc.capability, sc.width, sc.height)
+			golden := filepath.Join(snapshotDir, fmt.Sprintf("%s-%dx%d.ansi", sc.name, sc.width, sc.height))
+			compareSnapshot(t, golden, view)
+		})
+	}
+}
+
+// TestScreenSnapshots drives the setup wizard through scripted key presses without
+// a terminal and compares the text of each frame with golden files, at several
+// terminal sizes. Unlike TestANSISnapshots it follows the form from page to page,
+// and unlike the VHS tests it needs no external binary.
+func TestScreenSnapshots(t *testing.T) {
+	sizes := []struct{ width, height int }{{160, 50}, {120, 40}, {80, 24}}
+	scripts := []struct {
+		name  string
+		steps []screenStep
+	}{
+		{"project-setup", []screenStep{
+			{label: "start"},
+			{label: "type a project name", text: "headless-app"},
+			{label: "pick Go", keys: []string{"enter", "enter", "space"}},
+			{label: "next page", keys: []string{"enter"}},
+		}},
+		{"page-menu", []screenStep{
+			{label: "open the page menu", keys: []string{"esc"}},
+			{label: "move down", keys: []string{"down", "down"}},
+		}},
+	}
+
+	registry := &ModuleRegistry{}
+	if errs := registry.Load(assets); len(errs) > 0 {
+		t.Fatalf("loading modules: %v", errs)
+	}
+	loader := &registryLoader{registry: registry, done: make(chan struct{})}
+	close(loader.done)
+
+	restore := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(capabilityProfile(gradient.NoColor))
+	t.Cleanup(func() { lipgloss.SetColorProfile(restore) })
+
+	for _, script := range scripts {
+		for _, size := range sizes {
+			t.Run(fmt.Sprintf("%s-%dx%d", script.name, size.width, size.height), func(t *testing.T) {
+				cfg := Config{IsProjectLocal: true}
+				var createSubagent bool
+				var custom generation.CustomSubagent
+				form := newSetupForm(&cfg, loader, t.TempDir(), nil, &createSubagent, &custom)
+				m := newModel(form, &cfg, loader, gradient.NoColor, interactiveOptions{noAnimation: true}).
+					withPages(setupPages(&cfg, loader, nil, &createSubagent))
+				m.registry = registry
+
+				p := headless.New(t, m, size.width, size.height)
+				var frames strings.Builder
+				for _, step := range script.steps {
+					p.Press(step.keys...)
+					p.Type(step.text)
+					fmt.Fprintf(&frames, "── %s ──\n%s\n", step.label, p.View())
+				}
+				golden := filepath.Join(screenDir, fmt.Sprintf("%s-%dx%d.txt", script.name, size.width, size.height))
+				compareSnapshot(t, golden, frames.String())
+			})
+		}
+	}
+}
+
+// screenStep presses keys, then types text, then records a frame under label.
+type screenStep struct {
+	label string
+	keys  []string
+	text  string
+}
+
+// renderSnapshotView builds the setup form with fixed answers and renders one frame
+// at width x height, resizing through Update as a real terminal would.
+func renderSnapshotView(t *testing.T, loader *registryLoader, capability gradient.TerminalCapability, width, height int) string {
+	t.Helper()
+	cfg := Config{
+		IsProjectLocal: true,
+		ProjectName:    "snapshot-app",
+		Languages:      []string{"Go"},
+		Subagents:      []string{"code-reviewer", "test-runner"},
+		Hooks:          []string{"session-start"},
+		SlashCommands:  []string{"example"},
+		Permissions:    []string{defaultPermissionPreset},
+	}
+	var createSubagent bool
+	var custom generation.CustomSubagent
+	form := newSetupForm(&cfg, loader, t.TempDir(), nil, &createSubagent, &custom)
+	form.Init()
+
+	m := newModel(form, &cfg, loader, capability, interactiveOptions{})
+	m.registry = loader.registry
+	m.pendingResize = &tea.WindowSizeMsg{Width: width, Height: height}
+	updated, _ := m.Update(debounceCompleteMsg{})
+
+	// The form lays out its groups on the first message it sees, which a running
+	// program gets from the form's own start-up commands
+	updated, _ = updated.Update(snapshotFrameMsg{})
+	return updated.(model).View()
+}
+
+// snapshotFrameMsg is a message no component handles, used to drive one update.
+type snapshotFrameMsg struct{}
+
+// compareSnapshot checks got against the golden file, or rewrites it with -update.
+func compareSnapshot(t *testing.T, golden, got string) {
+	t.Helper()
+	if *updateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading snapshot (run with -update to create it): %v", err)
+	}
+	if got == string(want) {
+		return
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+	for i := range max(len(gotLines), len(wantLines)) {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Fatalf("%s differs at line %d (run with -update if the change is intended):\ngot:  %q\nwant: %q", golden, i+1, g, w)
+		}
+	}
+}
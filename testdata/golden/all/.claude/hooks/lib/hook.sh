@@ -0,0 +1,94 @@
+# Hook helpers for bash, installed by claudekit. Source this file from a hook script
+# in the directory above lib/:
+#
+#   source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+#   hook_read_input
+#
+# Reading the event needs jq, or python3 when jq is not installed.
+
+# hook_read_input reads the event JSON from stdin into HOOK_INPUT and sets
+# HOOK_EVENT, HOOK_SESSION_ID, HOOK_TOOL_NAME, and HOOK_CWD from it.
+hook_read_input() {
+  HOOK_INPUT="$(cat)"
+  HOOK_EVENT="$(hook_get hook_event_name)"
+  HOOK_SESSION_ID="$(hook_get session_id)"
+  HOOK_TOOL_NAME="$(hook_get tool_name)"
+  HOOK_CWD="$(hook_get cwd)"
+}
+
+# hook_get prints a field of the event: strings as text, anything else as JSON, and
+# nothing when the field is missing. Name nested fields with dots:
+#
+#   command="$(hook_get tool_input.command)"
+hook_get() {
+  if command -v jq >/dev/null 2>&1; then
+    printf '%s' "${HOOK_INPUT:-}" | jq -r --arg field "$1" \
+      'getpath($field | split(".")) | if . == null then empty elif type == "string" then . else tojson end' 2>/dev/null || true
+  elif command -v python3 >/dev/null 2>&1; then
+    printf '%s' "${HOOK_INPUT:-}" | python3 -c '
+import json, sys
+try:
+    value = json.load(sys.stdin)
+    for key in sys.argv[1].split("."):
+        value = value[key]
+except Exception:
+    sys.exit(0)
+if value is not None:
+    print(value if isinstance(value, str) else json.dumps(value))
+' "$1" || true
+  else
+    echo "hook.sh: jq or python3 is needed to read the hook event" >&2
+    return 1
+  fi
+}
+
+# hook_json_string prints its argument as a JSON string.
+hook_json_string() {
+  local s="$1"
+  s="${s//\\/\\\\}"
+  s="${s//\"/\\\"}"
+  s="${s//$'\n'/\\n}"
+  s="${s//$'\r'/\\r}"
+  s="${s//$'\t'/\\t}"
+  printf '"%s"' "$s"
+}
+
+# hook_block prints a "block" decision with a reason for Claude, then exits. It
+# refuses a tool call in PreToolUse, reports a problem after PostToolUse, rejects
+# the prompt in UserPromptSubmit, and keeps Claude working in Stop and SubagentStop.
+hook_block() {
+  printf '{"decision":"block","reason":%s}\n' "$(hook_json_string "${1:-Blocked by a hook}")"
+  exit 0
+}
+
+# hook_approve prints an "approve" decision for PreToolUse, running the tool without
+# asking the user, then exits.
+hook_approve() {
+  printf '{"decision":"approve","reason":%s}\n' "$(hook_json_string "${1:-}")"
+  exit 0
+}
+
+# hook_context adds text to Claude's context from UserPromptSubmit or SessionStart,
+# then exits.
+hook_context() {
+  printf '{"hookSpecificOutput":{"hookEventName":%s,"additionalContext":%s}}\n' \
+    "$(hook_json_string "${HOOK_EVENT:-}")" "$(hook_json_string "$1")"
+  exit 0
+}
+
+# hook_summary prints a one-line description of a Notification or Stop event for
+# people: the project's name and what Claude needs or did.
+hook_summary() {
+  local message
+  case "${HOOK_EVENT:-}" in
+    Stop | SubagentStop) message="Claude finished responding" ;;
+    *) message="$(hook_get message)" ;;
+  esac
+  printf '%s: %s' "$(basename "${CLAUDE_PROJECT_DIR:-${HOOK_CWD:-$PWD}}")" "${message:-Claude needs your attention}"
+}
+
+# hook_log writes a timestamped message to stderr, which Claude Code shows in
+# verbose mode. Stdout is reserved for decisions and, for some events, context.
+hook_log() {
+  printf '[%s] %s\n' "$(date -u +%Y-%m-%dT%H:%M:%SZ)" "$*" >&2
+}
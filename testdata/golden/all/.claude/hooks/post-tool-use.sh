@@ -0,0 +1,20 @@
+#!/usr/bin/env bash
+set -euo pipefail
+# post-tool-use Hook - Runs after successful tool execution
+#
+# Claude Code passes the event as JSON on stdin, and lib/hook.sh parses it. Every
+# event has session_id, transcript_path, cwd, and hook_event_name; tool events add
+# tool_name and tool_input, and PostToolUse adds tool_response.
+
+source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+hook_read_input
+
+hook_log "post-tool-use hook triggered (session $HOOK_SESSION_ID)"
+
+# Decision control: report a problem with the tool's result to Claude
+# if [[ "$HOOK_TOOL_NAME" == "Write" ]] && ! ./scripts/check "$(hook_get tool_input.file_path)"; then
+#   hook_block "The written file failed ./scripts/check"
+# fi
+
+# Exit 0 to continue; hook_block prints a decision and exits
+exit 0
@@ -0,0 +1,21 @@
+#!/usr/bin/env bash
+set -euo pipefail
+# stop Hook - Runs when Claude finishes responding
+#
+# Claude Code passes the event as JSON on stdin, and lib/hook.sh parses it. Every
+# event has session_id, transcript_path, cwd, and hook_event_name; tool events add
+# tool_name and tool_input, and PostToolUse adds tool_response.
+
+source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+hook_read_input
+
+hook_log "stop hook triggered (session $HOOK_SESSION_ID)"
+
+# Decision control: keep Claude working; stop_hook_active is true when a Stop hook
+# already kept it going, so check it to avoid a loop
+# if [[ "$(hook_get stop_hook_active)" != "true" ]] && ! make test >/dev/null 2>&1; then
+#   hook_block "Tests are failing; fix them before finishing"
+# fi
+
+# Exit 0 to continue; hook_block prints a decision and exits
+exit 0
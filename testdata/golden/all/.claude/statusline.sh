@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+# Cost statusline: model, session cost, duration, and lines changed
+# Claude Code pipes session JSON on stdin and shows the first line of output
+
+input=$(cat)
+command -v jq >/dev/null 2>&1 || { echo "statusline: install jq"; exit 0; }
+
+model=$(jq -r '.model.display_name // "Claude"' <<<"$input")
+cost=$(jq -r '.cost.total_cost_usd // 0' <<<"$input")
+ms=$(jq -r '.cost.total_duration_ms // 0 | floor' <<<"$input")
+added=$(jq -r '.cost.total_lines_added // 0' <<<"$input")
+removed=$(jq -r '.cost.total_lines_removed // 0' <<<"$input")
+
+printf '[%s] $%.2f · %dm%02ds · +%s/-%s\n' "$model" "$cost" $((ms / 60000)) $((ms / 1000 % 60)) "$added" "$removed"
@@ -0,0 +1,20 @@
+#!/usr/bin/env bash
+set -euo pipefail
+# pre-tool-use Hook - Runs before Claude executes any tool
+#
+# Claude Code passes the event as JSON on stdin, and lib/hook.sh parses it. Every
+# event has session_id, transcript_path, cwd, and hook_event_name; tool events add
+# tool_name and tool_input, and PostToolUse adds tool_response.
+
+source "$(dirname "${BASH_SOURCE[0]}")/lib/hook.sh"
+hook_read_input
+
+hook_log "pre-tool-use hook triggered (session $HOOK_SESSION_ID)"
+
+# Decision control: refuse a tool call and tell Claude why
+if [[ "$HOOK_TOOL_NAME" == "Bash" && "$(hook_get tool_input.command)" == *"rm -rf /"* ]]; then
+  hook_block "Refusing to delete from the filesystem root"
+fi
+
+# Exit 0 to continue; hook_block prints a decision and exits
+exit 0
@@ -0,0 +1,31 @@
+#!/usr/bin/env python3
+"""
+user-prompt-submit Hook - Runs when users submit prompts, before Claude processes them
+
+Claude Code passes the event as JSON on stdin, and lib/hook.py parses it. Every
+event has session_id, transcript_path, cwd, and hook_event_name; tool events add
+tool_name and tool_input, and PostToolUse adds tool_response.
+"""
+
+import os
+import sys
+
+sys.path.insert(0, os.path.join(os.path.dirname(os.path.abspath(__file__)), "lib"))
+import hook  # noqa: E402
+
+
+def main():
+    event = hook.read_input()
+    hook.log(f"user-prompt-submit hook triggered (session {event.session_id})")
+
+    # Decision control: reject a prompt, or add context for Claude
+    # if "password" in event.get("prompt", ""):
+    #     hook.block("Prompts must not contain credentials")
+    # hook.add_context(event, "Remember to run the tests")
+
+    # Return 0 to continue; hook.block() prints a decision and exits
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())